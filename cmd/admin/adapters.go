@@ -22,6 +22,11 @@ func (s *stubStorage) Upload(_ context.Context, key string, _ io.Reader, _ int64
 	return nil, fmt.Errorf("storage not configured")
 }
 
+func (s *stubStorage) UploadStream(_ context.Context, key string, _ io.Reader, _ string) (*storage.UploadResult, error) {
+	logger.Warn("stubStorage.UploadStream called — wire a real Storage provider", zap.String("key", key))
+	return nil, fmt.Errorf("storage not configured")
+}
+
 func (s *stubStorage) Download(_ context.Context, key string) (io.ReadCloser, error) {
 	logger.Warn("stubStorage.Download called — wire a real Storage provider", zap.String("key", key))
 	return nil, fmt.Errorf("storage not configured")
@@ -27,6 +27,7 @@ import (
 	"github.com/richxcame/ride-hailing/internal/promos"
 	"github.com/richxcame/ride-hailing/internal/ridetypes"
 	"github.com/richxcame/ride-hailing/internal/support"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
@@ -218,6 +219,7 @@ func main() {
 	// Initialize documents (stub storage + stub driver service — admin only reviews documents)
 	documentsRepo := documents.NewRepository(db)
 	documentsSvc := documents.NewService(documentsRepo, &stubStorage{}, documents.ServiceConfig{})
+	documentsSvc.SetAuditLogger(audit.NewDBLogger(db))
 	documentsHandler := documents.NewHandler(documentsSvc, &stubDriverService{})
 
 	// Set up Gin router
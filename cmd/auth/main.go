@@ -19,6 +19,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
+	redisclient "github.com/richxcame/ride-hailing/pkg/redis"
 	"github.com/richxcame/ride-hailing/pkg/swagger"
 	"github.com/richxcame/ride-hailing/pkg/tracing"
 	"go.uber.org/zap"
@@ -110,6 +111,16 @@ func main() {
 	keyManager.StartAutoRotation(rootCtx)
 
 	service := auth.NewService(repo, keyManager, cfg.JWT.Expiration)
+
+	// Refresh tokens need Redis to track revocations; without it only access tokens are issued
+	redisClient, redisErr := redisclient.NewRedisClient(&cfg.Redis)
+	if redisErr != nil {
+		logger.Warn("Failed to initialize Redis - refresh tokens disabled", zap.Error(redisErr))
+	} else {
+		defer redisClient.Close()
+		logger.Info("Connected to Redis")
+		service.SetRefreshTokenStore(auth.NewRedisRefreshTokenStore(redisClient), time.Duration(cfg.JWT.RefreshExpiration)*time.Hour)
+	}
 	handler := auth.NewHandler(service)
 
 	// Setup Gin router
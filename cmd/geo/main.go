@@ -18,6 +18,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/realtime"
 	redisClient "github.com/richxcame/ride-hailing/pkg/redis"
 	"github.com/richxcame/ride-hailing/pkg/resilience"
 	"github.com/richxcame/ride-hailing/pkg/swagger"
@@ -133,7 +134,8 @@ func main() {
 	if realtimeServiceURL == "" {
 		realtimeServiceURL = "http://localhost:8086"
 	}
-	etaTracker := geo.NewETATracker(redis, realtimeServiceURL)
+	realtimeClient := realtime.NewHTTPClient(realtimeServiceURL, os.Getenv("INTERNAL_API_KEY"))
+	etaTracker := geo.NewETATracker(redis, realtimeClient)
 	service.SetETATracker(etaTracker)
 	logger.Info("Real-time ETA tracking enabled")
 
@@ -7,15 +7,115 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/currency"
+	"github.com/richxcame/ride-hailing/internal/documents"
+	"github.com/richxcame/ride-hailing/internal/loyalty"
 	"github.com/richxcame/ride-hailing/internal/onboarding"
 	"github.com/richxcame/ride-hailing/internal/pool"
 	"github.com/richxcame/ride-hailing/internal/ridetypes"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/httpclient"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/pkg/storage"
 	"go.uber.org/zap"
 )
 
+// ---- Notifications service client ----
+
+// notificationSender delivers push notifications through the notifications service
+type notificationSender struct {
+	client *httpclient.Client
+}
+
+func newNotificationSender(baseURL string) *notificationSender {
+	return &notificationSender{client: httpclient.NewClient(baseURL)}
+}
+
+func (n *notificationSender) send(ctx context.Context, userID uuid.UUID, notificationType, title, body string, data map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"user_id": userID.String(),
+		"type":    notificationType,
+		"channel": "push",
+		"title":   title,
+		"body":    body,
+		"data":    data,
+	}
+	_, err := n.client.Post(ctx, "/api/v1/notifications/send", payload, nil)
+	return err
+}
+
+// NotifyRewardAvailable implements loyalty.WaitlistNotifier
+func (n *notificationSender) NotifyRewardAvailable(ctx context.Context, riderID uuid.UUID, reward *loyalty.RewardCatalogItem) error {
+	return n.send(ctx, riderID, "reward_available", "Reward back in stock",
+		fmt.Sprintf("%s is available again. Redeem it before it runs out.", reward.Name),
+		map[string]interface{}{"reward_id": reward.ID.String()},
+	)
+}
+
+// NotifyDocumentExpiring implements documents.Notifier
+func (n *notificationSender) NotifyDocumentExpiring(ctx context.Context, reminder *documents.ExpiryReminder) error {
+	data := map[string]interface{}{
+		"driver_id":         reminder.DriverID.String(),
+		"document_id":       reminder.DocumentID.String(),
+		"document_type":     reminder.DocumentType,
+		"days_until_expiry": reminder.DaysUntilExpiry,
+		"severity":          string(reminder.Severity),
+	}
+	if reminder.ExpiryDate != nil {
+		data["expiry_date"] = reminder.ExpiryDate.Format("2006-01-02")
+	}
+	return n.send(ctx, reminder.UserID, "document_expiring", reminder.Title, reminder.Message, data)
+}
+
+// ---- Loyalty FareConverter ----
+
+// currencyFareConverter converts fares for loyalty earning rules with the currency service
+type currencyFareConverter struct {
+	service *currency.Service
+}
+
+// ConvertToBase implements loyalty.FareConverter
+func (c *currencyFareConverter) ConvertToBase(ctx context.Context, amount float64, code string) (float64, error) {
+	result, err := c.service.Convert(ctx, amount, code, c.service.GetBaseCurrency())
+	if err != nil {
+		return 0, err
+	}
+	return result.Converted.Amount, nil
+}
+
+// ---- Driver verification events ----
+
+// eventPublisher is the part of the event bus the mobile service publishes through
+type eventPublisher interface {
+	Publish(ctx context.Context, subject string, event *eventbus.Event) error
+}
+
+// publishVerificationChange returns a documents.StatusChangeHook that tells
+// matching and dispatch, through the event bus, when a driver's verification
+// status changes
+func publishVerificationChange(bus eventPublisher) documents.StatusChangeHook {
+	return func(ctx context.Context, change *documents.VerificationStatusChange) {
+		evt, err := eventbus.NewEvent(eventbus.SubjectDriverVerificationChanged, "mobile-service", eventbus.DriverVerificationChangedData{
+			DriverID:  change.DriverID,
+			OldStatus: string(change.OldStatus),
+			NewStatus: string(change.NewStatus),
+			CanDrive:  change.CanDrive,
+			ChangedAt: change.ChangedAt,
+		})
+		if err == nil {
+			err = bus.Publish(ctx, eventbus.SubjectDriverVerificationChanged, evt)
+		}
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to publish driver verification change",
+				zap.String("driver_id", change.DriverID.String()),
+				zap.String("new_status", string(change.NewStatus)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
 // ---- Pool MapsService stub ----
 
 type stubMapsService struct{}
@@ -41,6 +141,11 @@ func (s *stubStorage) Upload(_ context.Context, key string, _ io.Reader, _ int64
 	return nil, fmt.Errorf("storage not configured")
 }
 
+func (s *stubStorage) UploadStream(_ context.Context, key string, _ io.Reader, _ string) (*storage.UploadResult, error) {
+	logger.Warn("stubStorage.UploadStream called — wire a real Storage provider", zap.String("key", key))
+	return nil, fmt.Errorf("storage not configured")
+}
+
 func (s *stubStorage) Download(_ context.Context, key string) (io.ReadCloser, error) {
 	logger.Warn("stubStorage.Download called — wire a real Storage provider", zap.String("key", key))
 	return nil, fmt.Errorf("storage not configured")
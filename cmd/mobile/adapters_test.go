@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/documents"
+	"github.com/richxcame/ride-hailing/internal/loyalty"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startNotificationsServer records the notifications sent to a fake notifications service
+func startNotificationsServer(t *testing.T) (*notificationSender, <-chan map[string]interface{}) {
+	t.Helper()
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/notifications/send", r.URL.Path)
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"success":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return newNotificationSender(server.URL), received
+}
+
+func TestNotificationSender_NotifyRewardAvailable(t *testing.T) {
+	sender, received := startNotificationsServer(t)
+	riderID := uuid.New()
+	reward := &loyalty.RewardCatalogItem{ID: uuid.New(), Name: "Free ride"}
+
+	require.NoError(t, sender.NotifyRewardAvailable(context.Background(), riderID, reward))
+
+	payload := <-received
+	assert.Equal(t, riderID.String(), payload["user_id"])
+	assert.Equal(t, "reward_available", payload["type"])
+	assert.Equal(t, "push", payload["channel"])
+	assert.Contains(t, payload["body"], "Free ride")
+	assert.Equal(t, reward.ID.String(), payload["data"].(map[string]interface{})["reward_id"])
+}
+
+func TestNotificationSender_NotifyDocumentExpiring(t *testing.T) {
+	sender, received := startNotificationsServer(t)
+	expiry := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	reminder := &documents.ExpiryReminder{
+		DriverID:        uuid.New(),
+		UserID:          uuid.New(),
+		DocumentID:      uuid.New(),
+		DocumentType:    "drivers_license",
+		ExpiryDate:      &expiry,
+		DaysUntilExpiry: 5,
+		Severity:        documents.ReminderSeverityCritical,
+		Title:           "Driver's license expires soon",
+		Message:         "Your driver's license expires in 5 days.",
+	}
+
+	var notifier documents.Notifier = sender
+	require.NoError(t, notifier.NotifyDocumentExpiring(context.Background(), reminder))
+
+	payload := <-received
+	assert.Equal(t, reminder.UserID.String(), payload["user_id"])
+	assert.Equal(t, "document_expiring", payload["type"])
+	assert.Equal(t, reminder.Title, payload["title"])
+	assert.Equal(t, reminder.Message, payload["body"])
+	data := payload["data"].(map[string]interface{})
+	assert.Equal(t, reminder.DocumentID.String(), data["document_id"])
+	assert.Equal(t, "critical", data["severity"])
+	assert.Equal(t, "2026-11-01", data["expiry_date"])
+}
+
+// recordingPublisher captures the events published to the event bus
+type recordingPublisher struct {
+	subject string
+	event   *eventbus.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, subject string, event *eventbus.Event) error {
+	p.subject, p.event = subject, event
+	return nil
+}
+
+func TestPublishVerificationChange(t *testing.T) {
+	bus := &recordingPublisher{}
+	change := &documents.VerificationStatusChange{
+		DriverID:  uuid.New(),
+		OldStatus: documents.VerificationPendingReview,
+		NewStatus: documents.VerificationApproved,
+		CanDrive:  true,
+		ChangedAt: time.Now(),
+	}
+
+	publishVerificationChange(bus)(context.Background(), change)
+
+	assert.Equal(t, eventbus.SubjectDriverVerificationChanged, bus.subject)
+	require.NotNil(t, bus.event)
+	var data eventbus.DriverVerificationChangedData
+	require.NoError(t, json.Unmarshal(bus.event.Data, &data))
+	assert.Equal(t, change.DriverID, data.DriverID)
+	assert.Equal(t, string(documents.VerificationPendingReview), data.OldStatus)
+	assert.Equal(t, string(documents.VerificationApproved), data.NewStatus)
+	assert.True(t, data.CanDrive)
+}
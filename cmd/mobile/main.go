@@ -50,14 +50,19 @@ import (
 	"github.com/richxcame/ride-hailing/internal/twofa"
 	"github.com/richxcame/ride-hailing/internal/vehicle"
 	"github.com/richxcame/ride-hailing/internal/waittime"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/outbox"
 	"github.com/richxcame/ride-hailing/pkg/ratelimit"
+	"github.com/richxcame/ride-hailing/pkg/realtime"
 	redisclient "github.com/richxcame/ride-hailing/pkg/redis"
+	"github.com/richxcame/ride-hailing/pkg/storage"
 	"github.com/richxcame/ride-hailing/pkg/swagger"
 	"github.com/richxcame/ride-hailing/pkg/tracing"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
@@ -201,6 +206,8 @@ func main() {
 
 	// Initialize WebSocket hub
 	wsHub := ws.NewHub()
+	wsHub.SetLimits(ws.LimitsFromConfig(cfg.WebSocket))
+	wsHub.SetKeepalive(ws.KeepaliveFromConfig(cfg.WebSocket))
 	go wsHub.Run()
 
 	// Initialize repositories
@@ -224,6 +231,14 @@ func main() {
 	corporateRepo := corporate.NewRepository(db)
 	twofaRepo := twofa.NewRepository(db)
 	loyaltyRepo := loyalty.NewRepository(db)
+	if realtimeURL := getEnv("REALTIME_SERVICE_URL", ""); realtimeURL != "" {
+		// Record tier upgrades in the outbox and relay them to the rider's connections
+		loyaltyRepo.SetOutbox(outbox.NewPublisher("loyalty"))
+		outboxRelay := outbox.NewRelay(outbox.NewPostgresStore(db),
+			outbox.NewRealtimeDeliverer(realtime.NewHTTPClient(realtimeURL, getEnv("INTERNAL_API_KEY", ""))),
+			outbox.DefaultRelayConfig())
+		go outboxRelay.Start(rootCtx)
+	}
 	poolRepo := pool.NewRepository(db)
 	deliveryRepo := delivery.NewRepository(db)
 	recordingRepo := recording.NewRepository(db)
@@ -261,7 +276,23 @@ func main() {
 	chatService := chat.NewService(chatRepo, wsHub)
 	corporateService := corporate.NewService(corporateRepo)
 	twofaService := twofa.NewService(twofaRepo, &stubSMSSender{}, nil, getEnv("APP_NAME", "RideHailing")) // Redis is nil-safe (OTP stored in DB)
-	loyaltyService := loyalty.NewService(loyaltyRepo)
+	auditLogger := audit.NewDBLogger(db)
+	loyaltyService := loyalty.NewServiceWithConfig(loyaltyRepo, loyalty.ServiceConfig{
+		RoundingMode:            loyalty.RoundingMode(cfg.Loyalty.RoundingMode),
+		BirthdayApplyMultiplier: getEnv("LOYALTY_BIRTHDAY_APPLY_MULTIPLIER", "false") == "true",
+	})
+	if redisErr == nil {
+		// Share the points lock between instances; without Redis it is per process
+		loyaltyService.SetPointsLocker(loyalty.NewRedisPointsLocker(redisClient.Client, 0, 0))
+	}
+	notifications := newNotificationSender(getEnv("NOTIFICATIONS_SERVICE_URL", "http://localhost:8085"))
+	loyaltyService.SetAuditLogger(auditLogger)
+	loyaltyService.SetWaitlistNotifier(notifications)
+	loyaltyService.StartBirthdayScheduler(rootCtx, time.Hour)
+	if path := getEnv("LOYALTY_EARNING_RULES_FILE", ""); path != "" {
+		reloadEvery := time.Duration(getEnvAsInt("LOYALTY_EARNING_RULES_RELOAD_SECONDS", 60)) * time.Second
+		loyaltyService.WatchEarningRules(rootCtx, path, reloadEvery)
+	}
 	poolService := pool.NewService(poolRepo, &stubMapsService{}, pool.DefaultServiceConfig())
 	deliveryService := delivery.NewService(deliveryRepo)
 	recordingService := recording.NewService(recordingRepo, &stubStorage{}, recording.Config{})
@@ -272,7 +303,26 @@ func main() {
 	gamificationService := gamification.NewService(gamificationRepo)
 	paymentsplitService := paymentsplit.NewService(paymentsplitRepo, &stubPaymentService{}, &stubSplitNotificationService{})
 	geographyService := geography.NewService(geographyRepo)
-	currencyService := currency.NewService(currencyRepo, getEnv("BASE_CURRENCY", "USD"))
+	currencyService := currency.NewService(currencyRepo, getEnv("BASE_CURRENCY", "USD"), currency.NewRateProvider(cfg.Currency))
+	currencyService.SetAuditLogger(auditLogger)
+	currencyService.SetMaxRateChange(cfg.Currency.MaxRateChangePercent)
+	currencyService.StartCleanupLoop(rootCtx,
+		time.Duration(cfg.Currency.CleanupIntervalMinutes)*time.Minute,
+		time.Duration(cfg.Currency.RateRetentionHours)*time.Hour,
+	)
+	if cfg.Currency.SeedFile != "" {
+		seed, err := currency.LoadSeedSet(cfg.Currency.SeedFile)
+		if err != nil {
+			logger.Fatal("Failed to load currency seed file", zap.Error(err))
+		}
+		currencyService.SetSeedSet(seed)
+	}
+	if cfg.Currency.SeedOnBoot {
+		if _, err := currencyService.SeedDefaults(rootCtx); err != nil {
+			logger.Warn("Failed to seed currencies", zap.Error(err))
+		}
+	}
+	loyaltyService.SetFareConverter(&currencyFareConverter{service: currencyService})
 	pricingService := pricing.NewService(pricingRepo, geographyService, currencyService)
 	ridesService.SetPricingService(pricingService)
 	rideTypesService := ridetypes.NewService(rideTypesRepo, geographyService)
@@ -280,11 +330,66 @@ func main() {
 	safetyService := safety.NewService(safetyRepo, safety.Config{
 		EmergencyNumber: getEnv("EMERGENCY_NUMBER", "112"),
 	})
-	documentsService := documents.NewService(documentsRepo, &stubStorage{}, documents.ServiceConfig{
+	// Document files live in the object store when one is configured
+	var documentsStorage storage.Storage = &stubStorage{}
+	if cfg.Storage.Enabled() {
+		s3Store, err := storage.NewS3Storage(rootCtx, storage.S3Config{
+			Bucket:    cfg.Storage.Bucket,
+			Region:    cfg.Storage.Region,
+			Endpoint:  cfg.Storage.Endpoint,
+			AccessKey: cfg.Storage.AccessKey,
+			SecretKey: cfg.Storage.SecretKey,
+			BaseURL:   cfg.Storage.BaseURL,
+		})
+		if err != nil {
+			logger.Fatal("Failed to initialize document storage", zap.Error(err))
+		}
+		documentsStorage = storage.NewRetryStorage(s3Store, storage.RetryOptions{})
+	}
+	documentsOCREnabled := getEnv("DOCUMENT_OCR_ENABLED", "false") == "true"
+	documentsService := documents.NewService(documentsRepo, documentsStorage, documents.ServiceConfig{
 		MaxFileSizeMB:    10,
 		AllowedMimeTypes: []string{"image/jpeg", "image/png", "application/pdf"},
-		OCREnabled:       false,
+		OCREnabled:       documentsOCREnabled,
+		OCRProvider:      getEnv("DOCUMENT_OCR_PROVIDER", string(documents.OCRProviderNoop)),
+
+		SupersededVersionsToKeep: getEnvAsInt("DOCUMENT_VERSIONS_TO_KEEP", 0),
+		MaxPDFPages:              getEnvAsInt("DOCUMENT_MAX_PDF_PAGES", 20),
+
+		ExpiryReminderDays:     getEnvAsInt("DOCUMENT_EXPIRY_REMINDER_DAYS", 30),
+		ExpiryReminderCooldown: time.Duration(getEnvAsInt("DOCUMENT_EXPIRY_REMINDER_COOLDOWN_HOURS", 24)) * time.Hour,
 	})
+	documentsService.SetAuditLogger(auditLogger)
+	documentsService.SetNotifier(notifications)
+	if cfg.NATS.Enabled {
+		bus, err := eventbus.New(eventbus.Config{
+			URL:        cfg.NATS.URL,
+			Name:       "mobile-service",
+			StreamName: cfg.NATS.StreamName,
+		})
+		if err != nil {
+			logger.Warn("Failed to connect to NATS - driver verification events disabled", zap.Error(err))
+		} else {
+			documentsService.SetStatusChangeHook(publishVerificationChange(bus))
+			defer bus.Close()
+			logger.Info("Publishing driver verification changes to NATS", zap.String("url", cfg.NATS.URL))
+		}
+	}
+	documentsService.StartExpirySweeper(rootCtx, time.Duration(getEnvAsInt("DOCUMENT_EXPIRY_SWEEP_MINUTES", 60))*time.Minute)
+	if documentsOCREnabled {
+		if cfg.Storage.Enabled() {
+			ocrWorker := documents.NewOCRWorker(documentsService, documents.OCRWorkerConfig{
+				PollInterval:  time.Duration(getEnvAsInt("DOCUMENT_OCR_POLL_SECONDS", 30)) * time.Second,
+				Concurrency:   getEnvAsInt("DOCUMENT_OCR_CONCURRENCY", 4),
+				TesseractPath: getEnv("DOCUMENT_OCR_TESSERACT_PATH", ""),
+				TesseractLang: getEnv("DOCUMENT_OCR_TESSERACT_LANG", ""),
+			})
+			go ocrWorker.Start(rootCtx)
+		} else {
+			// Without storage every job would fail its download and be exhausted
+			logger.Warn("STORAGE_S3_BUCKET not set, the document OCR worker is not started")
+		}
+	}
 
 	// Initialize handlers
 	ridesHandler := rides.NewHandler(ridesService)
@@ -323,6 +428,7 @@ func main() {
 	rideTypesHandler := ridetypes.NewHandler(rideTypesService)
 	safetyHandler := safety.NewHandler(safetyService)
 	documentsHandler := documents.NewHandler(documentsService, &stubDriverService{})
+	documentsHandler.SetOCRCallbackSecret(getEnv("OCR_CALLBACK_SECRET", ""))
 
 	// Set up Gin router
 	router := gin.New()
@@ -442,6 +548,7 @@ func main() {
 	rideTypesHandler.RegisterRoutes(apiGroup)
 	safetyHandler.RegisterRoutes(apiGroup)
 	documentsHandler.RegisterRoutesOnGroup(apiGroup)
+	documentsHandler.RegisterOCRCallbackRoutes(router)
 
 	// Create HTTP server with timeouts
 	srv := &http.Server{
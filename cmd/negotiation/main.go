@@ -117,6 +117,8 @@ func main() {
 
 	// Initialize WebSocket hub
 	wsHub := websocket.NewHub()
+	wsHub.SetLimits(websocket.LimitsFromConfig(cfg.WebSocket))
+	wsHub.SetKeepalive(websocket.KeepaliveFromConfig(cfg.WebSocket))
 	go wsHub.Run()
 	log.Info("WebSocket hub started")
 
@@ -125,7 +127,12 @@ func main() {
 	geoService := geography.NewService(geoRepo)
 
 	currencyRepo := currency.NewRepository(db)
-	currencyService := currency.NewService(currencyRepo, "USD")
+	currencyService := currency.NewService(currencyRepo, "USD", currency.NewRateProvider(cfg.Currency))
+	currencyService.SetMaxRateChange(cfg.Currency.MaxRateChangePercent)
+	currencyService.StartCleanupLoop(rootCtx,
+		time.Duration(cfg.Currency.CleanupIntervalMinutes)*time.Minute,
+		time.Duration(cfg.Currency.RateRetentionHours)*time.Hour,
+	)
 
 	pricingRepo := pricing.NewRepository(db)
 	pricingService := pricing.NewService(pricingRepo, geoService, currencyService)
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -23,10 +25,12 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/health"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
 	"github.com/richxcame/ride-hailing/pkg/redis"
+	"github.com/richxcame/ride-hailing/pkg/storage"
 	"github.com/richxcame/ride-hailing/pkg/swagger"
 	"github.com/richxcame/ride-hailing/pkg/tracing"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
@@ -82,7 +86,45 @@ func (a *geoServiceAdapter) CalculateDistance(latitude1, longitude1, latitude2,
 	return a.Service.CalculateDistance(latitude1, longitude1, latitude2, longitude2)
 }
 
+// corsOrigins holds the allowed CORS origins, which can be swapped at runtime.
+// A "*" entry allows every origin, but only when credentials are disabled.
+type corsOrigins struct {
+	allowed     atomic.Pointer[map[string]bool]
+	credentials bool
+}
+
+// Set replaces the allowed origins with a comma-separated list, falling back to
+// localhost for development, and returns the parsed origins
+func (o *corsOrigins) Set(raw string) []string {
+	if raw == "" {
+		raw = "http://localhost:3000"
+	}
+	origins := strings.Split(raw, ",")
+	allowed := make(map[string]bool, len(origins))
+	for i := range origins {
+		origins[i] = strings.TrimSpace(origins[i])
+		allowed[origins[i]] = true
+	}
+	o.allowed.Store(&allowed)
+	return origins
+}
+
+// Allow reports whether origin is currently allowed
+func (o *corsOrigins) Allow(origin string) bool {
+	allowed := o.allowed.Load()
+	if allowed == nil {
+		return false
+	}
+	return (*allowed)[origin] || (!o.credentials && (*allowed)["*"])
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run starts the service and blocks until it has shut down, returning the
+// process exit code. It is separate from main so deferred cleanup runs first.
+func run() int {
 	// Set default port for realtime service if not set
 	if os.Getenv("PORT") == "" {
 		os.Setenv("PORT", "8086")
@@ -176,6 +218,8 @@ func main() {
 
 	// Create WebSocket hub
 	hub := ws.NewHub()
+	hub.SetLimits(ws.LimitsFromConfig(cfg.WebSocket))
+	hub.SetKeepalive(ws.KeepaliveFromConfig(cfg.WebSocket))
 	go hub.Run()
 	logger.Info("WebSocket hub started")
 
@@ -225,6 +269,61 @@ func main() {
 	// Create service and handler
 	log := logger.Get()
 	service := realtime.NewService(hub, db, redisClient, geoService, log)
+	if persistedTypes := os.Getenv("REALTIME_PERSISTED_MESSAGE_TYPES"); persistedTypes != "" {
+		service.SetPersistencePolicy(realtime.NewPersistencePolicy(strings.Split(persistedTypes, ",")...))
+	}
+
+	// Chat image attachments, stored in the object store when one is configured
+	var attachmentStore storage.Storage
+	if cfg.Storage.Enabled() {
+		s3Store, err := storage.NewS3Storage(rootCtx, storage.S3Config{
+			Bucket:    cfg.Storage.Bucket,
+			Region:    cfg.Storage.Region,
+			Endpoint:  cfg.Storage.Endpoint,
+			AccessKey: cfg.Storage.AccessKey,
+			SecretKey: cfg.Storage.SecretKey,
+			BaseURL:   cfg.Storage.BaseURL,
+		})
+		if err != nil {
+			logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
+		}
+		attachmentStore = storage.NewRetryStorage(s3Store, storage.RetryOptions{})
+
+		attachmentPolicy := realtime.DefaultAttachmentPolicy()
+		if v, err := strconv.ParseInt(os.Getenv("REALTIME_CHAT_ATTACHMENT_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+			attachmentPolicy.MaxBytes = v
+		}
+		if v, err := strconv.Atoi(os.Getenv("REALTIME_CHAT_ATTACHMENT_URL_TTL_MINUTES")); err == nil && v > 0 {
+			attachmentPolicy.UploadURLTTL = time.Duration(v) * time.Minute
+			attachmentPolicy.DownloadURLTTL = time.Duration(v) * time.Minute
+		}
+		service.SetAttachmentStorage(attachmentStore, attachmentPolicy)
+		logger.Info("Chat attachments enabled", zap.String("bucket", cfg.Storage.Bucket), zap.Int64("max_bytes", attachmentPolicy.MaxBytes))
+	} else {
+		logger.Warn("STORAGE_S3_BUCKET not set, chat attachments are disabled")
+	}
+
+	offlineBufferSize := realtime.DefaultOfflineBufferSize
+	if v, err := strconv.Atoi(os.Getenv("REALTIME_OFFLINE_BUFFER_SIZE")); err == nil {
+		offlineBufferSize = v
+	}
+	offlineBufferTTL := realtime.DefaultOfflineBufferTTL
+	if v, err := strconv.Atoi(os.Getenv("REALTIME_OFFLINE_BUFFER_TTL_MINUTES")); err == nil && v > 0 {
+		offlineBufferTTL = time.Duration(v) * time.Minute
+	}
+	service.SetOfflineBuffer(offlineBufferSize, offlineBufferTTL)
+	locationMinDistance := realtime.DefaultLocationMinDistance
+	if v, err := strconv.ParseFloat(os.Getenv("REALTIME_LOCATION_MIN_DISTANCE_METERS"), 64); err == nil && v >= 0 {
+		locationMinDistance = v
+	}
+	locationMaxInterval := realtime.DefaultLocationMaxInterval
+	if v, err := strconv.Atoi(os.Getenv("REALTIME_LOCATION_MAX_INTERVAL_SECONDS")); err == nil && v >= 0 {
+		locationMaxInterval = time.Duration(v) * time.Second
+	}
+	service.SetLocationThrottle(locationMinDistance, locationMaxInterval)
+	if v, err := strconv.Atoi(os.Getenv("REALTIME_ETA_MIN_CHANGE_SECONDS")); err == nil && v >= 0 {
+		service.SetETAMinChange(time.Duration(v) * time.Second)
+	}
 	handler := realtime.NewHandler(service, log)
 
 	// Set up Gin router with proper middleware stack
@@ -256,83 +355,52 @@ func main() {
 	// Add Sentry error handler (should be near the end of middleware chain)
 	router.Use(middleware.ErrorHandler())
 
-	// CORS configuration
+	// CORS configuration. Origins are checked through AllowOriginFunc so that
+	// config reloads can change them without a restart.
 	corsConfig := cors.DefaultConfig()
-	// Parse CORS origins from config (comma-separated for production)
-	if cfg.Server.CORSOrigins != "" {
-		origins := strings.Split(cfg.Server.CORSOrigins, ",")
-		// Trim whitespace from each origin
-		for i := range origins {
-			origins[i] = strings.TrimSpace(origins[i])
-		}
-		corsConfig.AllowOrigins = origins
-		logger.Info("CORS configured with origins", zap.Strings("origins", origins))
-	} else {
-		// Development fallback
-		corsConfig.AllowOrigins = []string{"http://localhost:3000"}
-		logger.Info("CORS configured for development (localhost:3000)")
-	}
+	allowedOrigins := &corsOrigins{credentials: cfg.Server.CORSAllowCredentials}
+	logger.Info("CORS configured with origins", zap.Strings("origins", allowedOrigins.Set(cfg.Server.CORSOrigins)))
+	corsConfig.AllowOriginFunc = allowedOrigins.Allow
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	corsConfig.AllowCredentials = true
+	corsConfig.AllowCredentials = cfg.Server.CORSAllowCredentials
 	router.Use(cors.New(corsConfig))
 
+	// Apply CORS origin changes from the env file without a restart
+	go config.Watch(rootCtx, "realtime", func(newCfg *config.Config) {
+		defer newCfg.Close()
+		logger.Info("CORS origins updated", zap.Strings("origins", allowedOrigins.Set(newCfg.Server.CORSOrigins)))
+	})
+
 	// Health check endpoints
+	dependencies := []health.Dependency{
+		health.DatabaseDependency(db),
+		health.RedisDependency(redisClient.Client),
+	}
+	if attachmentStore != nil {
+		// Chat works without attachments, so a storage outage only degrades the service
+		dependencies = append(dependencies, health.StorageDependency(attachmentStore).Optional())
+	}
+	handler.SetHealthChecker(health.NewHealthChecker(health.CheckerConfig{Timeout: 2 * time.Second}, dependencies...))
 	router.GET("/healthz", handler.HealthCheck)
 	router.GET("/health/live", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "alive", "service": "realtime-service", "version": "1.0.0"})
 	})
 
 	// Readiness probe with dependency checks
-	healthChecks := make(map[string]func() error)
-	healthChecks["database"] = func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		return db.PingContext(ctx)
-	}
-	healthChecks["redis"] = func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		return redisClient.Client.Ping(ctx).Err()
-	}
-
-	router.GET("/health/ready", func(c *gin.Context) {
-		allHealthy := true
-		for name, check := range healthChecks {
-			if err := check(); err != nil {
-				c.JSON(503, gin.H{"status": "not ready", "service": "realtime-service", "failed_check": name, "error": err.Error()})
-				allHealthy = false
-				return
-			}
-		}
-		if allHealthy {
-			c.JSON(200, gin.H{"status": "ready", "service": "realtime-service", "version": "1.0.0"})
-		}
-	})
+	router.GET("/readyz", handler.ReadyCheck)
+	router.GET("/health/ready", handler.ReadyCheck)
 
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	swagger.RegisterRoutes(router)
 
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		// WebSocket connection (requires authentication)
-		api.GET("/ws", middleware.AuthMiddlewareWithProvider(jwtProvider), handler.HandleWebSocket)
-
-		// Chat history
-		api.GET("/rides/:ride_id/chat", middleware.AuthMiddlewareWithProvider(jwtProvider), handler.GetChatHistory)
-
-		// Stats (admin only)
-		api.GET("/stats", middleware.AuthMiddlewareWithProvider(jwtProvider), middleware.RequireAdmin(), handler.GetStats)
-
-		// Internal endpoints (for other services to broadcast)
-		internal := api.Group("/internal")
-		internal.Use(middleware.InternalAPIKey())
-		{
-			internal.POST("/broadcast/ride", handler.BroadcastRideUpdate)
-			internal.POST("/broadcast/user", handler.BroadcastToUser)
-		}
+	// API routes. Clients are throttled per user across all realtime
+	// instances; health and metrics endpoints are left unthrottled.
+	var apiMiddleware []gin.HandlerFunc
+	if cfg.RateLimit.Enabled {
+		apiMiddleware = append(apiMiddleware, middleware.RateLimitMiddleware(redisClient.Client, middleware.UserOrIPKey, cfg.RateLimit.DefaultLimit, cfg.RateLimit.Window()))
 	}
+	handler.RegisterRoutes(router, jwtProvider, apiMiddleware...)
 
 	// Create HTTP server with timeouts
 	srv := &http.Server{
@@ -355,15 +423,37 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down server...")
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	logger.Info("Shutting down server...", zap.Duration("timeout", shutdownTimeout))
 
-	// Graceful shutdown with 5 second timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Stop accepting connections and let in-flight requests finish, then send
+	// WebSocket clients their queued messages and a close frame. Upgraded
+	// connections are not tracked by the HTTP server, so the hub drains them.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
+	exitCode := 0
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+		logger.Error("HTTP requests did not drain in time", zap.Error(err))
+		exitCode = 1
+	}
+	if err := hub.Shutdown(shutdownCtx); err != nil {
+		logger.Error("WebSocket clients did not drain in time", zap.Error(err))
+		exitCode = 1
+	}
+
+	// Stop background work before closing the pools it uses
+	cancelKeys()
+	if eventBus != nil {
+		eventBus.Close()
+	}
+	if err := redisClient.Close(); err != nil {
+		logger.Warn("Failed to close Redis client", zap.Error(err))
+	}
+	if err := db.Close(); err != nil {
+		logger.Warn("Failed to close database", zap.Error(err))
 	}
 
 	logger.Info("Server stopped")
+	return exitCode
 }
@@ -0,0 +1,4 @@
+-- Rollback: Remove points transaction metadata
+
+ALTER TABLE loyalty_points_transactions
+    DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,3 @@
+-- Audit details for points transactions (e.g. multiplier and rounding mode used)
+ALTER TABLE loyalty_points_transactions
+    ADD COLUMN IF NOT EXISTS metadata JSONB;
@@ -0,0 +1,4 @@
+-- Rollback: Remove per-type allowed MIME types
+
+ALTER TABLE document_types
+    DROP COLUMN IF EXISTS allowed_mime_types;
@@ -0,0 +1,3 @@
+-- Optional per-type upload allow-list; NULL falls back to the service-wide list
+ALTER TABLE document_types
+    ADD COLUMN IF NOT EXISTS allowed_mime_types TEXT[];
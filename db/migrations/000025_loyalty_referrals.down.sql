@@ -0,0 +1,3 @@
+-- Rollback: Remove loyalty referral rewards
+
+DROP TABLE IF EXISTS loyalty_referrals;
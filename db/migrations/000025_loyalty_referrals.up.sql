@@ -0,0 +1,18 @@
+-- Loyalty referral rewards. A referee can only ever be referred once, which
+-- makes issuance idempotent and stops a second referrer from earning the same
+-- referee another welcome bonus. Each side's reward is stamped once paid.
+CREATE TABLE IF NOT EXISTS loyalty_referrals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    referrer_id UUID NOT NULL,
+    referee_id UUID NOT NULL,
+    first_ride_id UUID NOT NULL,
+    referrer_points INTEGER NOT NULL,
+    referee_points INTEGER NOT NULL,
+    referrer_rewarded_at TIMESTAMPTZ,
+    referee_rewarded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (referee_id),
+    CHECK (referrer_id <> referee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_referrals_referrer ON loyalty_referrals(referrer_id);
@@ -0,0 +1,7 @@
+-- Rollback: Remove birthday bonus support
+
+DROP INDEX IF EXISTS idx_loyalty_points_birthday_once;
+DROP INDEX IF EXISTS idx_rider_loyalty_birthday;
+
+ALTER TABLE rider_loyalty
+    DROP COLUMN IF EXISTS birth_date;
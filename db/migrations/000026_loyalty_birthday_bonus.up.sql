@@ -0,0 +1,12 @@
+-- Birthday bonuses: riders' birth dates, and a guard so each rider gets at most
+-- one birthday bonus per year (the transaction's source_id identifies the year)
+ALTER TABLE rider_loyalty
+    ADD COLUMN IF NOT EXISTS birth_date DATE;
+
+CREATE INDEX IF NOT EXISTS idx_rider_loyalty_birthday
+    ON rider_loyalty ((EXTRACT(MONTH FROM birth_date)), (EXTRACT(DAY FROM birth_date)))
+    WHERE birth_date IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_points_birthday_once
+    ON loyalty_points_transactions (rider_id, source_id)
+    WHERE source = 'birthday';
@@ -0,0 +1,6 @@
+-- Rollback: Remove reward inventory and waitlist
+
+DROP TABLE IF EXISTS loyalty_reward_waitlist;
+
+ALTER TABLE loyalty_rewards_catalog
+    DROP COLUMN IF EXISTS total_inventory;
@@ -0,0 +1,18 @@
+-- Reward inventory: remaining stock for limited rewards (NULL means unlimited),
+-- decremented atomically on redemption, plus a waitlist for riders who want an
+-- out-of-stock reward
+ALTER TABLE loyalty_rewards_catalog
+    ADD COLUMN IF NOT EXISTS total_inventory INTEGER CHECK (total_inventory >= 0);
+
+CREATE TABLE IF NOT EXISTS loyalty_reward_waitlist (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reward_id UUID NOT NULL REFERENCES loyalty_rewards_catalog(id) ON DELETE CASCADE,
+    rider_id UUID NOT NULL,
+    notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (reward_id, rider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_reward_waitlist_pending
+    ON loyalty_reward_waitlist(reward_id, created_at)
+    WHERE notified_at IS NULL;
@@ -0,0 +1,3 @@
+-- Rollback: Remove loyalty idempotency keys
+
+DROP TABLE IF EXISTS loyalty_idempotency_keys;
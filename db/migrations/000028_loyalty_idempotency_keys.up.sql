@@ -0,0 +1,15 @@
+-- Idempotency keys for loyalty earn and redeem requests. The primary key lets
+-- only one request claim a key; response stays NULL while it is in progress and
+-- holds the original result once it completes, until the key expires.
+CREATE TABLE IF NOT EXISTS loyalty_idempotency_keys (
+    rider_id UUID NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response JSONB,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (rider_id, operation, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_idempotency_keys_expires ON loyalty_idempotency_keys(expires_at);
@@ -0,0 +1,86 @@
+-- Rollback: Restore the trigger that maintained driver_verification_status
+
+CREATE OR REPLACE FUNCTION update_driver_verification_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_driver_id UUID;
+    v_required_count INT;
+    v_submitted_count INT;
+    v_approved_count INT;
+    v_new_status VARCHAR(50);
+    v_next_expiry DATE;
+BEGIN
+    -- Get driver_id from the modified document
+    v_driver_id := COALESCE(NEW.driver_id, OLD.driver_id);
+
+    -- Count required documents
+    SELECT COUNT(*) INTO v_required_count
+    FROM document_types
+    WHERE is_required = true AND is_active = true;
+
+    -- Count submitted documents (latest version, not superseded)
+    SELECT COUNT(DISTINCT document_type_id) INTO v_submitted_count
+    FROM driver_documents
+    WHERE driver_id = v_driver_id
+      AND status NOT IN ('superseded', 'expired');
+
+    -- Count approved documents
+    SELECT COUNT(DISTINCT document_type_id) INTO v_approved_count
+    FROM driver_documents
+    WHERE driver_id = v_driver_id
+      AND status = 'approved';
+
+    -- Get next expiry date
+    SELECT MIN(expiry_date) INTO v_next_expiry
+    FROM driver_documents
+    WHERE driver_id = v_driver_id
+      AND status = 'approved'
+      AND expiry_date IS NOT NULL;
+
+    -- Determine verification status
+    IF v_approved_count >= v_required_count THEN
+        v_new_status := 'approved';
+    ELSIF v_submitted_count >= v_required_count THEN
+        v_new_status := 'pending_review';
+    ELSE
+        v_new_status := 'incomplete';
+    END IF;
+
+    -- Upsert verification status
+    INSERT INTO driver_verification_status (
+        driver_id, verification_status, required_documents_count,
+        submitted_documents_count, approved_documents_count, next_document_expiry
+    )
+    VALUES (
+        v_driver_id, v_new_status, v_required_count,
+        v_submitted_count, v_approved_count, v_next_expiry
+    )
+    ON CONFLICT (driver_id) DO UPDATE SET
+        verification_status = EXCLUDED.verification_status,
+        required_documents_count = EXCLUDED.required_documents_count,
+        submitted_documents_count = EXCLUDED.submitted_documents_count,
+        approved_documents_count = EXCLUDED.approved_documents_count,
+        next_document_expiry = EXCLUDED.next_document_expiry,
+        documents_submitted_at = CASE
+            WHEN EXCLUDED.submitted_documents_count >= EXCLUDED.required_documents_count
+                 AND driver_verification_status.documents_submitted_at IS NULL
+            THEN NOW()
+            ELSE driver_verification_status.documents_submitted_at
+        END,
+        documents_approved_at = CASE
+            WHEN EXCLUDED.approved_documents_count >= EXCLUDED.required_documents_count
+                 AND driver_verification_status.documents_approved_at IS NULL
+            THEN NOW()
+            ELSE driver_verification_status.documents_approved_at
+        END,
+        updated_at = NOW();
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Trigger to update verification status on document changes
+CREATE TRIGGER trigger_update_driver_verification
+    AFTER INSERT OR UPDATE OF status ON driver_documents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_driver_verification_status();
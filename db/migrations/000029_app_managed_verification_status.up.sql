@@ -0,0 +1,5 @@
+-- The documents service now computes driver_verification_status itself and writes
+-- it back after reviews, uploads and expiries. The trigger computed the status
+-- differently and overwrote suspensions, so it is dropped.
+DROP TRIGGER IF EXISTS trigger_update_driver_verification ON driver_documents;
+DROP FUNCTION IF EXISTS update_driver_verification_status();
@@ -0,0 +1,5 @@
+-- Rollback: Remove pinned exchange rates
+
+DROP INDEX IF EXISTS idx_exchange_rates_pinned;
+
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS pinned;
@@ -0,0 +1,8 @@
+-- Pinned exchange rates: manual overrides that never expire, are never replaced
+-- by provider refreshes and are never removed by the expired-rate cleanup
+ALTER TABLE exchange_rates
+    ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_pinned
+    ON exchange_rates(from_currency, to_currency, fetched_at DESC)
+    WHERE pinned;
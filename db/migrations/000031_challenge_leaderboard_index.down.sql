@@ -0,0 +1,3 @@
+-- Rollback: Remove challenge leaderboard index
+
+DROP INDEX IF EXISTS idx_rider_challenge_progress_leaderboard;
@@ -0,0 +1,3 @@
+-- Challenge leaderboards rank a challenge's progress rows by value, then by completion time
+CREATE INDEX IF NOT EXISTS idx_rider_challenge_progress_leaderboard
+    ON rider_challenge_progress(challenge_id, current_value DESC, completed_at ASC NULLS LAST);
@@ -0,0 +1,3 @@
+-- Rollback: Remove challenge activity date
+
+ALTER TABLE rider_challenge_progress DROP COLUMN IF EXISTS last_activity_date;
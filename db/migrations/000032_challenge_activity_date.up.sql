@@ -0,0 +1,3 @@
+-- Day of a rider's latest activity on a challenge, so streak challenges can
+-- tell a consecutive day from a missed one
+ALTER TABLE rider_challenge_progress ADD COLUMN IF NOT EXISTS last_activity_date DATE;
@@ -0,0 +1,3 @@
+-- Rollback: Remove original document file keys
+
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS original_file_key;
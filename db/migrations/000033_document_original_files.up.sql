@@ -0,0 +1,3 @@
+-- Storage key of an uploaded image as received, kept when the stored image was
+-- downscaled or re-encoded so OCR can read the high-resolution original
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS original_file_key TEXT;
@@ -0,0 +1,7 @@
+-- Rollback: Remove document soft delete columns
+
+DROP INDEX IF EXISTS idx_driver_documents_pending_file_cleanup;
+
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS files_deleted_at;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- Soft-deleted documents keep their record for history. files_deleted_at stays
+-- NULL until the document's files are removed from storage, so a failed storage
+-- delete can be retried later.
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS deleted_by UUID;
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS files_deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_driver_documents_pending_file_cleanup
+    ON driver_documents(deleted_at)
+    WHERE status = 'deleted' AND files_deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Rollback: Remove document review claim columns
+
+DROP INDEX IF EXISTS idx_driver_documents_review_claims;
+
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS claim_expires_at;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,10 @@
+-- A reviewer claims a document when starting its review. While the claim is
+-- live no other reviewer can start or complete the review; abandoned claims are
+-- released back to pending by a sweep.
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS claimed_by UUID;
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS claim_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_driver_documents_review_claims
+    ON driver_documents(claimed_at)
+    WHERE status = 'under_review';
@@ -0,0 +1,3 @@
+-- Rollback: Remove per-type OCR auto-approval threshold
+
+ALTER TABLE document_types DROP COLUMN IF EXISTS ocr_approve_threshold;
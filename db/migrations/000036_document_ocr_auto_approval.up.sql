@@ -0,0 +1,5 @@
+-- OCR confidence a document type's uploads must exceed to be approved without
+-- a reviewer. NULL uses the service default; types that require manual review
+-- are never auto-approved.
+ALTER TABLE document_types ADD COLUMN IF NOT EXISTS ocr_approve_threshold NUMERIC(4,3)
+    CHECK (ocr_approve_threshold IS NULL OR (ocr_approve_threshold >= 0 AND ocr_approve_threshold <= 1));
@@ -0,0 +1,3 @@
+-- Rollback: Remove point multiplier promotions
+
+DROP TABLE IF EXISTS loyalty_point_promos;
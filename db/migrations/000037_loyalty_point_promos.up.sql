@@ -0,0 +1,20 @@
+-- Limited-time point multiplier promotions ("2x points this weekend"). A promo
+-- multiplies earned points after the tier multiplier while NOW() is in
+-- [starts_at, ends_at). An empty sources list applies to every point source; a
+-- tier_restriction limits it to riders in that tier.
+CREATE TABLE IF NOT EXISTS loyalty_point_promos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    multiplier DECIMAL(4,2) NOT NULL CHECK (multiplier > 1),
+    sources TEXT[] NOT NULL DEFAULT '{}',
+    tier_restriction UUID REFERENCES loyalty_tiers(id),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_point_promos_window
+    ON loyalty_point_promos(starts_at, ends_at)
+    WHERE is_active = TRUE;
@@ -0,0 +1,4 @@
+-- Rollback: Remove document thumbnails
+
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS thumbnail_key;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1,4 @@
+-- Small JPEG previews of document images, so the review queue doesn't have to
+-- load full-resolution files
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS thumbnail_key TEXT;
@@ -0,0 +1,3 @@
+-- Rollback: Remove the transactional outbox
+
+DROP TABLE IF EXISTS outbox_events;
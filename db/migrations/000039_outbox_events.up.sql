@@ -0,0 +1,22 @@
+-- Transactional outbox. Services insert events here in the same transaction as
+-- the state change they describe; a relay delivers pending events and retries
+-- failures, so an event is delivered at least once for every commit. Consumers
+-- de-duplicate on id.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    user_id UUID,  -- Recipient, for events delivered to a user's connections
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,  -- Set while a relay is delivering the event
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+    ON outbox_events(next_attempt_at)
+    WHERE status = 'pending';
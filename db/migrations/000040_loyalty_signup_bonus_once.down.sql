@@ -0,0 +1,4 @@
+-- Rollback: Remove the one-signup-bonus guard. Duplicate bonuses removed by the
+-- up migration are not restored.
+
+DROP INDEX IF EXISTS idx_loyalty_points_signup_once;
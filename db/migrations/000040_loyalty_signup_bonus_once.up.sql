@@ -0,0 +1,33 @@
+-- Signup bonuses: a guard so each rider gets at most one, however many times
+-- account creation is retried
+
+-- Riders already paid more than once keep their earliest bonus; the others are
+-- removed and their points taken back off the balances
+WITH duplicates AS (
+    DELETE FROM loyalty_points_transactions
+    WHERE id IN (
+        SELECT id FROM (
+            SELECT id, ROW_NUMBER() OVER (PARTITION BY rider_id ORDER BY created_at, id) AS n
+            FROM loyalty_points_transactions
+            WHERE source = 'signup'
+        ) ranked
+        WHERE n > 1
+    )
+    RETURNING rider_id, points
+), excess AS (
+    SELECT rider_id, SUM(points) AS points
+    FROM duplicates
+    GROUP BY rider_id
+)
+UPDATE rider_loyalty rl
+SET available_points = GREATEST(rl.available_points - excess.points, 0),
+    total_points = GREATEST(rl.total_points - excess.points, 0),
+    lifetime_points = GREATEST(rl.lifetime_points - excess.points, 0),
+    tier_points = GREATEST(rl.tier_points - excess.points, 0),
+    updated_at = NOW()
+FROM excess
+WHERE rl.rider_id = excess.rider_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_points_signup_once
+    ON loyalty_points_transactions (rider_id)
+    WHERE source = 'signup';
@@ -0,0 +1,3 @@
+-- Rollback: Remove outbox request IDs
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,3 @@
+-- Outbox request IDs: the ID of the request that published an event, so its
+-- delivery can be traced back to the user action that caused it
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS request_id VARCHAR(100);
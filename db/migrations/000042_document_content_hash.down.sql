@@ -0,0 +1,5 @@
+-- Rollback: Remove document content hashes
+
+DROP INDEX IF EXISTS idx_driver_documents_content_hash;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS duplicate_flagged;
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,8 @@
+-- Document content hashes: the SHA-256 of each uploaded file, to turn away a
+-- rejected file uploaded again and flag files shared between drivers
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS duplicate_flagged BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_driver_documents_content_hash
+    ON driver_documents (content_hash)
+    WHERE content_hash IS NOT NULL;
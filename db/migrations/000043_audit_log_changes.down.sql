@@ -0,0 +1,7 @@
+-- Rollback: Restore UUID audit log targets and drop change summaries
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS after_state;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS before_state;
+DELETE FROM audit_logs
+WHERE target_id !~ '^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$';
+ALTER TABLE audit_logs ALTER COLUMN target_id TYPE UUID USING target_id::uuid;
@@ -0,0 +1,5 @@
+-- Audit log for every admin and reviewer action: targets that aren't UUIDs,
+-- such as currency codes, and a summary of each change
+ALTER TABLE audit_logs ALTER COLUMN target_id TYPE TEXT USING target_id::text;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS before_state JSONB;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS after_state JSONB;
@@ -0,0 +1,3 @@
+-- Rollback: Remove tier benefit usage tracking
+
+DROP TABLE IF EXISTS loyalty_benefit_usages;
@@ -0,0 +1,13 @@
+-- Tier benefit usage: each free cancellation or upgrade a rider used, and on
+-- which ride, so a benefit is counted once per ride
+CREATE TABLE IF NOT EXISTS loyalty_benefit_usages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rider_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ride_id UUID NOT NULL,
+    benefit VARCHAR(30) NOT NULL,  -- free_cancellation, free_upgrade
+    used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (rider_id, ride_id, benefit)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_benefit_usages_rider
+    ON loyalty_benefit_usages (rider_id, used_at DESC);
@@ -0,0 +1,3 @@
+-- Rollback: Remove document page counts
+
+ALTER TABLE driver_documents DROP COLUMN IF EXISTS page_count;
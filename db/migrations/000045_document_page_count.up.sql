@@ -0,0 +1,3 @@
+-- Document page counts: how many pages a PDF upload has, so reviewers know what
+-- they're opening
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS page_count INTEGER;
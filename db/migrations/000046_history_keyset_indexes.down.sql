@@ -0,0 +1,4 @@
+-- Rollback: Remove keyset pagination indexes
+
+DROP INDEX IF EXISTS idx_driver_documents_pending_keyset;
+DROP INDEX IF EXISTS idx_loyalty_points_transactions_rider_keyset;
@@ -0,0 +1,7 @@
+-- Keyset pagination: index the (timestamp, id) orders history and review queue
+-- pages are fetched in by cursor, so each page is an index range scan
+CREATE INDEX IF NOT EXISTS idx_loyalty_points_transactions_rider_keyset
+    ON loyalty_points_transactions(rider_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_driver_documents_pending_keyset
+    ON driver_documents(submitted_at, id) WHERE status IN ('pending', 'under_review');
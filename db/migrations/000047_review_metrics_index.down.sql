@@ -0,0 +1,3 @@
+-- Rollback: Remove review metrics index
+
+DROP INDEX IF EXISTS idx_doc_verification_history_decisions;
@@ -0,0 +1,6 @@
+-- Review metrics: index reviewer decisions by time so the review time
+-- percentiles and throughput over a window are an index range scan rather than
+-- a pass over the whole history table
+CREATE INDEX IF NOT EXISTS idx_doc_verification_history_decisions
+    ON document_verification_history(created_at)
+    WHERE action IN ('approve', 'reject', 'request_resubmit') AND NOT is_system_action;
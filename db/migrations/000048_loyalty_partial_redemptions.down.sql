@@ -0,0 +1,3 @@
+-- Rollback: Remove partial redemption cash amounts
+
+ALTER TABLE loyalty_redemptions DROP COLUMN IF EXISTS cash_amount;
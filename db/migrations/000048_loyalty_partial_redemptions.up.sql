@@ -0,0 +1,3 @@
+-- Partial redemptions: the part of a reward's value paid in cash rather than
+-- points, for the payment service to charge
+ALTER TABLE loyalty_redemptions ADD COLUMN IF NOT EXISTS cash_amount NUMERIC(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Rollback: Remove face match columns
+
+ALTER TABLE driver_documents
+    DROP COLUMN IF EXISTS face_matched_at,
+    DROP COLUMN IF EXISTS face_match_score;
@@ -0,0 +1,4 @@
+-- Identity face match: how closely a driver's selfie matched the photo on their ID
+ALTER TABLE driver_documents
+    ADD COLUMN IF NOT EXISTS face_match_score NUMERIC(5,4),
+    ADD COLUMN IF NOT EXISTS face_matched_at TIMESTAMPTZ;
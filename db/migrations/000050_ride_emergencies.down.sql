@@ -0,0 +1,3 @@
+-- Rollback: Remove the SOS event record
+
+DROP TABLE IF EXISTS ride_emergencies;
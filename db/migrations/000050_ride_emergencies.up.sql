@@ -0,0 +1,25 @@
+-- SOS events raised by riders and drivers on active rides, and who resolved
+-- them. Redis holds the live list for the dispatch console; this is the record.
+CREATE TABLE IF NOT EXISTS ride_emergencies (
+    id UUID PRIMARY KEY,
+    ride_id UUID NOT NULL REFERENCES rides(id),
+    sender_id UUID NOT NULL,
+    sender_role VARCHAR(20) NOT NULL,  -- rider, driver
+    driver_id UUID,
+    message TEXT,
+    latitude DOUBLE PRECISION,  -- Driver's last known location
+    longitude DOUBLE PRECISION,
+    located_at TIMESTAMPTZ,
+    reported_latitude DOUBLE PRECISION,  -- Where the sender's device said it was
+    reported_longitude DOUBLE PRECISION,
+    raised_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    resolved_by UUID
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_emergencies_ride
+    ON ride_emergencies (ride_id, raised_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_ride_emergencies_unresolved
+    ON ride_emergencies (raised_at)
+    WHERE resolved_at IS NULL;
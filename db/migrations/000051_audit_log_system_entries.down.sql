@@ -0,0 +1,4 @@
+-- Rollback: Require an admin on every audit entry
+
+DELETE FROM audit_logs WHERE admin_id IS NULL;
+ALTER TABLE audit_logs ALTER COLUMN admin_id SET NOT NULL;
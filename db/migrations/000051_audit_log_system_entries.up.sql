@@ -0,0 +1,3 @@
+-- System audit entries: automated changes, such as provider exchange rates
+-- jumping past the guardrail, are audited without an admin
+ALTER TABLE audit_logs ALTER COLUMN admin_id DROP NOT NULL;
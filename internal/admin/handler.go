@@ -472,10 +472,8 @@ func (h *Handler) GetAuditLogs(c *gin.Context) {
 	if targetType := c.Query("target_type"); targetType != "" {
 		filter.TargetType = targetType
 	}
-	if targetIDStr := c.Query("target_id"); targetIDStr != "" {
-		if id, err := uuid.Parse(targetIDStr); err == nil {
-			filter.TargetID = &id
-		}
+	if targetID := c.Query("target_id"); targetID != "" {
+		filter.TargetID = targetID
 	}
 
 	logs, total, err := h.service.GetAuditLogs(c.Request.Context(), params.Limit, params.Offset, filter)
@@ -22,27 +22,32 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// AuditLog represents an admin action audit record.
+// AuditLog represents an admin action audit record. Other services write to
+// the same table through pkg/audit.
 // Required table:
 //
 //	CREATE TABLE IF NOT EXISTS audit_logs (
 //	    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-//	    admin_id UUID NOT NULL REFERENCES users(id),
+//	    admin_id UUID REFERENCES users(id),  -- NULL for system entries
 //	    action TEXT NOT NULL,
 //	    target_type TEXT NOT NULL,
-//	    target_id UUID NOT NULL,
+//	    target_id TEXT NOT NULL,
 //	    metadata JSONB DEFAULT '{}',
+//	    before_state JSONB,
+//	    after_state JSONB,
 //	    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 //	);
 //	CREATE INDEX idx_audit_logs_admin ON audit_logs(admin_id);
 //	CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id);
 type AuditLog struct {
 	ID         uuid.UUID              `json:"id"`
-	AdminID    uuid.UUID              `json:"admin_id"`
+	AdminID    *uuid.UUID             `json:"admin_id"` // Nil for system entries
 	Action     string                 `json:"action"`
 	TargetType string                 `json:"target_type"`
-	TargetID   uuid.UUID              `json:"target_id"`
+	TargetID   string                 `json:"target_id"`
 	Metadata   map[string]interface{} `json:"metadata"`
+	Before     map[string]interface{} `json:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty"`
 	CreatedAt  time.Time              `json:"created_at"`
 }
 
@@ -51,7 +56,7 @@ type AuditLogFilter struct {
 	AdminID    *uuid.UUID
 	Action     string
 	TargetType string
-	TargetID   *uuid.UUID
+	TargetID   string
 }
 
 // GetAuditLogs retrieves audit logs with pagination and filters
@@ -76,9 +81,9 @@ func (r *Repository) GetAuditLogs(ctx context.Context, limit, offset int, filter
 			args = append(args, filter.TargetType)
 			argIndex++
 		}
-		if filter.TargetID != nil {
+		if filter.TargetID != "" {
 			whereClause += fmt.Sprintf(" AND al.target_id = $%d", argIndex)
-			args = append(args, *filter.TargetID)
+			args = append(args, filter.TargetID)
 			argIndex++
 		}
 	}
@@ -91,7 +96,8 @@ func (r *Repository) GetAuditLogs(ctx context.Context, limit, offset int, filter
 	}
 
 	query := fmt.Sprintf(`
-		SELECT al.id, al.admin_id, al.action, al.target_type, al.target_id, al.metadata, al.created_at,
+		SELECT al.id, al.admin_id, al.action, al.target_type, al.target_id, al.metadata,
+		       al.before_state, al.after_state, al.created_at,
 		       COALESCE(u.first_name || ' ' || u.last_name, '') as admin_name
 		FROM audit_logs al
 		LEFT JOIN users u ON al.admin_id = u.id
@@ -114,7 +120,7 @@ func (r *Repository) GetAuditLogs(ctx context.Context, limit, offset int, filter
 		var adminName string
 		err := rows.Scan(
 			&log.ID, &log.AdminID, &log.Action, &log.TargetType,
-			&log.TargetID, &log.Metadata, &log.CreatedAt, &adminName,
+			&log.TargetID, &log.Metadata, &log.Before, &log.After, &log.CreatedAt, &adminName,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan audit log: %w", err)
@@ -139,7 +145,7 @@ func (r *Repository) InsertAuditLog(ctx context.Context, adminID uuid.UUID, acti
 		INSERT INTO audit_logs (id, admin_id, action, target_type, target_id, metadata)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err := r.db.Exec(ctx, query, uuid.New(), adminID, action, targetType, targetID, metadata)
+	_, err := r.db.Exec(ctx, query, uuid.New(), adminID, action, targetType, targetID.String(), metadata)
 	if err != nil {
 		// Audit log failure should not block the operation
 		logger.Warn("failed to insert audit log", zap.Error(err))
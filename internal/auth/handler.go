@@ -62,6 +62,47 @@ func (h *Handler) Login(c *gin.Context) {
 	common.SuccessResponse(c, response)
 }
 
+// RefreshToken exchanges a refresh token for a new access token
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.service.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "token refresh failed")
+		return
+	}
+
+	common.SuccessResponse(c, response)
+}
+
+// Logout revokes a refresh token
+func (h *Handler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "logout failed")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"message": "logged out"})
+}
+
 // GetProfile handles getting user profile
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
@@ -116,6 +157,8 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.RefreshToken)
+		auth.POST("/logout", h.Logout)
 
 		// Protected routes
 		protected := auth.Group("")
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/redis"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRefreshTokenTTL is used when SetRefreshTokenStore is given a non-positive TTL
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	// revokedRefreshTokenPrefix namespaces revoked refresh token IDs in Redis
+	revokedRefreshTokenPrefix = "auth:refresh:revoked:"
+)
+
+// RefreshTokenStore records revoked refresh tokens by their token ID
+type RefreshTokenStore interface {
+	// Revoke marks a token as revoked for ttl, after which it has expired anyway
+	Revoke(ctx context.Context, tokenID string, ttl time.Duration) error
+	// IsRevoked reports whether a token has been revoked
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// redisRefreshTokenStore keeps refresh token revocations in Redis
+type redisRefreshTokenStore struct {
+	client redis.ClientInterface
+}
+
+// NewRedisRefreshTokenStore creates a RefreshTokenStore backed by Redis
+func NewRedisRefreshTokenStore(client redis.ClientInterface) RefreshTokenStore {
+	return &redisRefreshTokenStore{client: client}
+}
+
+func (s *redisRefreshTokenStore) Revoke(ctx context.Context, tokenID string, ttl time.Duration) error {
+	return s.client.SetWithExpiration(ctx, revokedRefreshTokenPrefix+tokenID, "1", ttl)
+}
+
+func (s *redisRefreshTokenStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return s.client.Exists(ctx, revokedRefreshTokenPrefix+tokenID)
+}
+
+// SetRefreshTokenStore enables refresh tokens. Login only issues refresh tokens
+// once a store is set, since without one logout could not revoke them.
+func (s *Service) SetRefreshTokenStore(store RefreshTokenStore, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultRefreshTokenTTL
+	}
+	s.refreshStore = store
+	s.refreshTTL = ttl
+}
+
+// generateRefreshToken generates a long-lived refresh token for a user
+func (s *Service) generateRefreshToken(ctx context.Context, user *models.User) (string, error) {
+	now := time.Now()
+	claims := &middleware.Claims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: middleware.TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return s.signToken(ctx, claims)
+}
+
+// parseRefreshToken verifies a refresh token and returns its claims
+func (s *Service) parseRefreshToken(refreshToken string) (*middleware.Claims, error) {
+	if s.keyManager == nil {
+		return nil, fmt.Errorf("jwt key manager is not configured")
+	}
+
+	claims, err := middleware.ParseToken(s.keyManager, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != middleware.TokenTypeRefresh || claims.ID == "" {
+		return nil, middleware.ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// RefreshToken issues a new access token for a valid, unrevoked refresh token
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*models.RefreshTokenResponse, error) {
+	if s.refreshStore == nil {
+		return nil, common.NewServiceUnavailableError("token refresh is not enabled")
+	}
+
+	claims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, middleware.TokenError(err)
+	}
+
+	revoked, err := s.refreshStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		logger.Error("Failed to check refresh token revocation", zap.String("user_id", claims.UserID.String()), zap.Error(err))
+		return nil, common.NewServiceUnavailableError("unable to verify refresh token")
+	}
+	if revoked {
+		return nil, middleware.TokenError(middleware.ErrTokenInvalid)
+	}
+
+	// Pick up role changes and deactivations made since the refresh token was issued
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, middleware.TokenError(middleware.ErrTokenInvalid)
+	}
+	if !user.IsActive {
+		return nil, common.NewUnauthorizedError("account is inactive")
+	}
+
+	expiresAt := time.Now().Add(s.accessTokenTTL())
+	token, err := s.generateToken(ctx, user)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to generate token")
+	}
+
+	return &models.RefreshTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Logout revokes a refresh token. Revoking a token that has already expired is a no-op.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	if s.refreshStore == nil {
+		return nil
+	}
+
+	claims, err := s.parseRefreshToken(refreshToken)
+	if errors.Is(err, middleware.ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return middleware.TokenError(err)
+	}
+
+	ttl := s.refreshTTL
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.refreshStore.Revoke(ctx, claims.ID, ttl); err != nil {
+		logger.Error("Failed to revoke refresh token", zap.String("user_id", claims.UserID.String()), zap.Error(err))
+		return common.NewInternalServerError("failed to log out")
+	}
+
+	return nil
+}
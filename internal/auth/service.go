@@ -21,6 +21,9 @@ type Service struct {
 	repo       RepositoryInterface
 	keyManager *jwtkeys.Manager
 	jwtExpiry  int
+
+	refreshStore RefreshTokenStore
+	refreshTTL   time.Duration
 }
 
 // NewService creates a new auth service
@@ -173,12 +176,22 @@ func (s *Service) Login(ctx context.Context, req *models.LoginRequest) (*models.
 		return nil, common.NewInternalServerError("failed to generate token")
 	}
 
+	// Issue a refresh token when revocation is available to back logout
+	var refreshToken string
+	if s.refreshStore != nil {
+		refreshToken, err = s.generateRefreshToken(ctx, user)
+		if err != nil {
+			return nil, common.NewInternalServerError("failed to generate token")
+		}
+	}
+
 	// Clear password hash from response
 	user.PasswordHash = ""
 
 	return &models.LoginResponse{
-		User:  user,
-		Token: token,
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
@@ -226,8 +239,29 @@ func (s *Service) UpdateProfile(ctx context.Context, userID uuid.UUID, updates *
 	return user, nil
 }
 
-// generateToken generates a JWT token for a user
+// generateToken generates a JWT access token for a user
 func (s *Service) generateToken(ctx context.Context, user *models.User) (string, error) {
+	claims := &middleware.Claims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: middleware.TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return s.signToken(ctx, claims)
+}
+
+// accessTokenTTL returns how long access tokens stay valid
+func (s *Service) accessTokenTTL() time.Duration {
+	return time.Hour * time.Duration(s.jwtExpiry)
+}
+
+// signToken signs claims with the current signing key
+func (s *Service) signToken(ctx context.Context, claims *middleware.Claims) (string, error) {
 	if s.keyManager == nil {
 		return "", fmt.Errorf("jwt key manager is not configured")
 	}
@@ -246,16 +280,6 @@ func (s *Service) generateToken(ctx context.Context, user *models.User) (string,
 		return "", fmt.Errorf("invalid signing key: %w", err)
 	}
 
-	claims := &middleware.Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(s.jwtExpiry))),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	token.Header["kid"] = key.ID
 	tokenString, err := token.SignedString(secretBytes)
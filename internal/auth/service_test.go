@@ -458,6 +458,200 @@ func TestService_GenerateToken_ContainsCorrectClaims(t *testing.T) {
 	assert.True(t, claims.ExpiresAt.After(claims.IssuedAt.Time))
 }
 
+// memoryRefreshStore is an in-memory RefreshTokenStore for tests
+type memoryRefreshStore struct {
+	revoked map[string]time.Duration
+	err     error
+}
+
+func newMemoryRefreshStore() *memoryRefreshStore {
+	return &memoryRefreshStore{revoked: map[string]time.Duration{}}
+}
+
+func (m *memoryRefreshStore) Revoke(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.revoked[tokenID] = ttl
+	return nil
+}
+
+func (m *memoryRefreshStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	_, ok := m.revoked[tokenID]
+	return ok, nil
+}
+
+func newRefreshTestService(t *testing.T, repo RepositoryInterface) (*Service, *memoryRefreshStore) {
+	t.Helper()
+	service := newTestService(t, repo)
+	store := newMemoryRefreshStore()
+	service.SetRefreshTokenStore(store, time.Hour)
+	return service, store
+}
+
+func TestService_Login_IssuesRefreshTokenWhenEnabled(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, _ := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+	mockRepo.On("GetUserByEmail", mock.Anything, testUser.Email).Return(testUser, nil)
+
+	resp, err := service.Login(context.Background(), &models.LoginRequest{Email: testUser.Email, Password: "password123"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	assert.NotEmpty(t, resp.RefreshToken)
+}
+
+func TestService_Login_NoRefreshTokenWhenDisabled(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service := newTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+	mockRepo.On("GetUserByEmail", mock.Anything, testUser.Email).Return(testUser, nil)
+
+	resp, err := service.Login(context.Background(), &models.LoginRequest{Email: testUser.Email, Password: "password123"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, resp.RefreshToken)
+}
+
+func TestService_RefreshToken_Success(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, _ := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+	mockRepo.On("GetUserByID", mock.Anything, testUser.ID).Return(testUser, nil)
+
+	refreshToken, err := service.generateRefreshToken(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	resp, err := service.RefreshToken(context.Background(), refreshToken)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	assert.True(t, resp.ExpiresAt.After(time.Now()))
+
+	// The new token is an access token accepted by the auth middleware
+	claims, err := middleware.ParseAccessToken(service.keyManager, resp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, testUser.ID, claims.UserID)
+	assert.Equal(t, middleware.TokenTypeAccess, claims.TokenType)
+}
+
+func TestService_RefreshToken_RejectsAccessToken(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, _ := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+
+	accessToken, err := service.generateToken(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	_, err = service.RefreshToken(context.Background(), accessToken)
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 401, appErr.Code)
+	assert.Equal(t, common.ErrCodeInvalidToken, appErr.ErrorCode)
+}
+
+func TestService_RefreshToken_Expired(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, _ := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+
+	service.refreshTTL = -time.Minute
+	refreshToken, err := service.generateRefreshToken(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	_, err = service.RefreshToken(context.Background(), refreshToken)
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, common.ErrCodeExpiredToken, appErr.ErrorCode)
+}
+
+func TestService_RefreshToken_InactiveUser(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, _ := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+	inactive := *testUser
+	inactive.IsActive = false
+	mockRepo.On("GetUserByID", mock.Anything, testUser.ID).Return(&inactive, nil)
+
+	refreshToken, err := service.generateRefreshToken(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	_, err = service.RefreshToken(context.Background(), refreshToken)
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 401, appErr.Code)
+}
+
+func TestService_Logout_RevokesRefreshToken(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, store := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+
+	refreshToken, err := service.generateRefreshToken(context.Background(), testUser)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Logout(context.Background(), refreshToken))
+	assert.Len(t, store.revoked, 1)
+	for _, ttl := range store.revoked {
+		assert.True(t, ttl > 0 && ttl <= time.Hour)
+	}
+
+	_, err = service.RefreshToken(context.Background(), refreshToken)
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 401, appErr.Code)
+	mockRepo.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
+
+func TestService_RefreshToken_StoreUnavailable(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service, store := newRefreshTestService(t, mockRepo)
+	testUser := helpers.CreateTestUser()
+
+	refreshToken, err := service.generateRefreshToken(context.Background(), testUser)
+	assert.NoError(t, err)
+	store.err = errors.New("redis down")
+
+	_, err = service.RefreshToken(context.Background(), refreshToken)
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 503, appErr.Code)
+}
+
+func TestService_RefreshToken_Disabled(t *testing.T) {
+	mockRepo := new(mocks.MockAuthRepository)
+	service := newTestService(t, mockRepo)
+
+	_, err := service.RefreshToken(context.Background(), "anything")
+
+	var appErr *common.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 503, appErr.Code)
+}
+
+func TestRedisRefreshTokenStore(t *testing.T) {
+	client := new(mocks.MockRedisClient)
+	store := NewRedisRefreshTokenStore(client)
+	client.On("SetWithExpiration", mock.Anything, "auth:refresh:revoked:abc", "1", time.Hour).Return(nil)
+	client.On("Exists", mock.Anything, "auth:refresh:revoked:abc").Return(true, nil)
+
+	assert.NoError(t, store.Revoke(context.Background(), "abc", time.Hour))
+	revoked, err := store.IsRevoked(context.Background(), "abc")
+
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	client.AssertExpectations(t)
+}
+
 func TestPasswordHashing(t *testing.T) {
 	password := "SecurePassword123!"
 
@@ -0,0 +1,81 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultCleanupInterval is used when StartCleanupLoop is given a non-positive interval
+	defaultCleanupInterval = 1 * time.Hour
+	// defaultRateRetention is used when StartCleanupLoop is given a non-positive retention
+	defaultRateRetention = 7 * 24 * time.Hour
+)
+
+// StartCleanupLoop periodically deletes exchange rates that expired more than
+// olderThan ago, until ctx is cancelled. A sweep that is still running when the
+// next tick fires causes that tick to be skipped.
+func (s *Service) StartCleanupLoop(ctx context.Context, interval, olderThan time.Duration) {
+	if interval <= 0 {
+		interval = defaultCleanupInterval
+	}
+	if olderThan <= 0 {
+		olderThan = defaultRateRetention
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.InfoContext(ctx, "Exchange rate cleanup loop started",
+			zap.Duration("interval", interval),
+			zap.Duration("retention", olderThan),
+		)
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.InfoContext(ctx, "Exchange rate cleanup loop stopped")
+				return
+			case <-ticker.C:
+				go s.CleanupExpiredRates(ctx, olderThan)
+			}
+		}
+	}()
+}
+
+// CleanupExpiredRates deletes stored and cached rates that expired more than
+// olderThan ago. Pinned rates are never deleted. It returns false without doing anything if another sweep is
+// already in progress.
+func (s *Service) CleanupExpiredRates(ctx context.Context, olderThan time.Duration) bool {
+	if !s.sweeping.CompareAndSwap(false, true) {
+		logger.WarnContext(ctx, "Skipping exchange rate cleanup, previous sweep still running")
+		return false
+	}
+	defer s.sweeping.Store(false)
+
+	deleted, err := s.repo.CleanupExpiredRates(ctx, olderThan)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to clean up expired exchange rates", zap.Error(err))
+	} else {
+		logger.InfoContext(ctx, "Cleaned up expired exchange rates", zap.Int64("deleted", deleted))
+	}
+
+	s.purgeExpiredCache(time.Now().Add(-olderThan))
+	return true
+}
+
+// purgeExpiredCache removes cached rates whose validity ended before cutoff. Pinned
+// rates never expire.
+func (s *Service) purgeExpiredCache(cutoff time.Time) {
+	s.cache.mu.Lock()
+	for key, rate := range s.cache.rates {
+		if !rate.IsValidAt(cutoff) {
+			delete(s.cache.rates, key)
+		}
+	}
+	s.cache.mu.Unlock()
+}
@@ -0,0 +1,159 @@
+package currency
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// supportedPairsTTL is how long the supported conversions are cached. Setting
+// rates through the service rebuilds them at once; this bounds rates expiring
+// or being stored elsewhere.
+const supportedPairsTTL = 1 * time.Minute
+
+// supportedPairsCache holds the last computed supported conversions
+type supportedPairsCache struct {
+	mu        sync.Mutex
+	pairs     *SupportedPairs
+	expiresAt time.Time
+}
+
+// SupportedTarget is a currency that can be converted to from a source currency
+type SupportedTarget struct {
+	Currency   string         `json:"currency"`
+	Derivation RateDerivation `json:"derivation"`    // direct, inverse or triangulated
+	Hops       int            `json:"hops"`          // Number of rates chained, 1 unless triangulated
+	Via        []string       `json:"via,omitempty"` // Intermediate currencies of a triangulated conversion
+}
+
+// SourceConversions lists the conversions possible from one currency
+type SourceConversions struct {
+	Currency    string             `json:"currency"`
+	Targets     []*SupportedTarget `json:"targets"`
+	Unreachable []string           `json:"unreachable,omitempty"` // Active currencies it can't be converted to
+}
+
+// SupportedPairs is every conversion between active currencies that the current
+// rate set allows, without asking the rate provider
+type SupportedPairs struct {
+	BaseCurrency string               `json:"base_currency"`
+	Sources      []*SourceConversions `json:"sources"`
+	NoPathToBase []string             `json:"no_path_to_base,omitempty"` // Active currencies that can't be converted to the base currency
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
+
+// GetSupportedPairs returns which conversions between active currencies are
+// possible with the stored rates, and how each would be derived. Triangulated
+// conversions use the same bounded search as GetExchangeRate, so a pair that is
+// missing here can only be converted if the rate provider has a rate for it.
+func (s *Service) GetSupportedPairs(ctx context.Context) (*SupportedPairs, error) {
+	s.supportedPairs.mu.Lock()
+	defer s.supportedPairs.mu.Unlock()
+
+	if s.supportedPairs.pairs != nil && time.Now().Before(s.supportedPairs.expiresAt) {
+		return s.supportedPairs.pairs, nil
+	}
+
+	currencies, err := s.repo.GetActiveCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.loadRateGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		codes = append(codes, c.Code)
+	}
+	sort.Strings(codes)
+
+	pairs := &SupportedPairs{
+		BaseCurrency: s.baseCurrency,
+		Sources:      make([]*SourceConversions, 0, len(codes)),
+		GeneratedAt:  time.Now(),
+	}
+	for _, from := range codes {
+		paths := shortestRatePaths(graph, from)
+		source := &SourceConversions{Currency: from, Targets: make([]*SupportedTarget, 0)}
+
+		for _, to := range codes {
+			if to == from {
+				continue
+			}
+			path, ok := paths[to]
+			if !ok {
+				source.Unreachable = append(source.Unreachable, to)
+				if to == s.baseCurrency {
+					pairs.NoPathToBase = append(pairs.NoPathToBase, from)
+				}
+				continue
+			}
+			source.Targets = append(source.Targets, supportedTarget(to, path))
+		}
+
+		pairs.Sources = append(pairs.Sources, source)
+	}
+
+	s.supportedPairs.pairs = pairs
+	s.supportedPairs.expiresAt = time.Now().Add(supportedPairsTTL)
+	return pairs, nil
+}
+
+// shortestRatePaths returns the shortest path of at most maxTriangulationHops
+// rates from a currency to every currency reachable from it, searching the
+// graph the same way findRatePath does
+func shortestRatePaths(graph map[string][]rateEdge, from string) map[string][]*ExchangeRate {
+	paths := make(map[string][]*ExchangeRate)
+	visited := map[string]bool{from: true}
+	frontier := []string{from}
+
+	for hops := 0; hops < maxTriangulationHops && len(frontier) > 0; hops++ {
+		var next []string
+		for _, current := range frontier {
+			for _, edge := range graph[current] {
+				if visited[edge.to] {
+					// A pair stored both ways is converted with the direct rate
+					if hops == 0 && edge.rate.Derivation() == DerivationDirect {
+						paths[edge.to] = []*ExchangeRate{edge.rate}
+					}
+					continue
+				}
+				visited[edge.to] = true
+
+				path := make([]*ExchangeRate, len(paths[current]), len(paths[current])+1)
+				copy(path, paths[current])
+				paths[edge.to] = append(path, edge.rate)
+				next = append(next, edge.to)
+			}
+		}
+		frontier = next
+	}
+
+	return paths
+}
+
+// supportedTarget describes converting along path to a currency
+func supportedTarget(to string, path []*ExchangeRate) *SupportedTarget {
+	target := &SupportedTarget{
+		Currency:   to,
+		Derivation: path[0].Derivation(),
+		Hops:       len(path),
+	}
+	if len(path) > 1 {
+		target.Derivation = DerivationTriangulated
+		for _, leg := range path[:len(path)-1] {
+			target.Via = append(target.Via, leg.ToCurrency)
+		}
+	}
+	return target
+}
+
+// invalidateSupportedPairs makes the next GetSupportedPairs rebuild them
+func (s *Service) invalidateSupportedPairs() {
+	s.supportedPairs.mu.Lock()
+	s.supportedPairs.pairs = nil
+	s.supportedPairs.mu.Unlock()
+}
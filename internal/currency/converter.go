@@ -24,12 +24,36 @@ func (c *Converter) Convert(amount float64, rate *ExchangeRate, roundingMode Rou
 	return c.Round(converted, roundingMode, decimalPlaces)
 }
 
+// ConvertMoney converts money into another currency in minor units, rounding
+// once to the target currency's decimal places
+func (c *Converter) ConvertMoney(money Money, rate *ExchangeRate, to string, roundingMode RoundingMode, decimalPlaces int) Money {
+	if decimalPlaces < 0 {
+		decimalPlaces = 0
+	}
+	minor := mulMinor(money.AmountMinor, rate.Rate, decimalPlaces-money.DecimalPlaces, roundingMode)
+	return NewMoneyFromMinor(minor, to, decimalPlaces)
+}
+
 // ConvertInverse converts an amount using the inverse rate
 func (c *Converter) ConvertInverse(amount float64, rate *ExchangeRate, roundingMode RoundingMode, decimalPlaces int) float64 {
 	converted := amount * rate.InverseRate
 	return c.Round(converted, roundingMode, decimalPlaces)
 }
 
+// ApplySpread returns the rate quoted to a customer converting at the given mid rate.
+// The spread is split symmetrically around the mid rate (bid = mid * (1 - spread/2),
+// ask = mid * (1 + spread/2)) and the customer receives the bid side.
+func (c *Converter) ApplySpread(midRate float64, spreadBps int) float64 {
+	bid, _ := c.BidAsk(midRate, spreadBps)
+	return bid
+}
+
+// BidAsk returns the buy and sell rates for a spread in basis points around a mid rate
+func (c *Converter) BidAsk(midRate float64, spreadBps int) (float64, float64) {
+	halfSpread := float64(spreadBps) / 10000 / 2
+	return midRate * (1 - halfSpread), midRate * (1 + halfSpread)
+}
+
 // Round rounds an amount according to the specified mode and decimal places
 func (c *Converter) Round(amount float64, mode RoundingMode, decimalPlaces int) float64 {
 	if decimalPlaces < 0 {
@@ -47,6 +71,8 @@ func (c *Converter) Round(amount float64, mode RoundingMode, decimalPlaces int)
 		return math.Floor(amount*multiplier) / multiplier
 	case RoundingModeBankers:
 		return c.bankersRound(amount, decimalPlaces)
+	case RoundingModeDown:
+		return math.Trunc(amount*multiplier) / multiplier
 	default: // RoundingModeStandard
 		return math.Round(amount*multiplier) / multiplier
 	}
@@ -0,0 +1,110 @@
+package currency
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LocaleFormat describes how a locale writes money amounts
+type LocaleFormat struct {
+	DecimalSeparator string
+	GroupSeparator   string
+	SymbolAfter      bool // Symbol follows the amount, as in "1.234,50 €"
+	SymbolSpace      bool // Non-breaking space between the symbol and the amount
+}
+
+// localeFormats holds the supported locales, keyed by lowercase BCP 47 tag.
+// A bare language entry covers every region of that language not listed.
+var localeFormats = map[string]LocaleFormat{
+	"en":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"ja":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"zh":    {DecimalSeparator: ".", GroupSeparator: ","},
+	"tr":    {DecimalSeparator: ",", GroupSeparator: "."},
+	"de":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"es":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"it":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"pt":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true},
+	"pt-br": {DecimalSeparator: ",", GroupSeparator: ".", SymbolSpace: true},
+	"nl":    {DecimalSeparator: ",", GroupSeparator: ".", SymbolSpace: true},
+	"fr":    {DecimalSeparator: ",", GroupSeparator: "\u202f", SymbolAfter: true, SymbolSpace: true},
+	"ru":    {DecimalSeparator: ",", GroupSeparator: "\u00a0", SymbolAfter: true, SymbolSpace: true},
+}
+
+// lookupLocaleFormat finds the format for a locale such as "de-DE" or "pt_BR",
+// falling back from the full tag to its language
+func lookupLocaleFormat(locale string) (LocaleFormat, bool) {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if tag == "" {
+		return LocaleFormat{}, false
+	}
+	if format, ok := localeFormats[tag]; ok {
+		return format, true
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	format, ok := localeFormats[language]
+	return format, ok
+}
+
+// FormatAmountLocale formats an amount with the currency's decimal places and
+// the locale's separators and symbol position. Unknown locales get FormatAmount.
+func (c *Converter) FormatAmountLocale(amount float64, currency *Currency, locale string) string {
+	format, ok := lookupLocaleFormat(locale)
+	if !ok {
+		return c.FormatAmount(amount, currency)
+	}
+
+	decimalPlaces := currency.DecimalPlaces
+	if decimalPlaces < 0 {
+		decimalPlaces = 0
+	}
+	rounded := c.Round(amount, RoundingModeStandard, decimalPlaces)
+
+	digits := strconv.FormatFloat(rounded, 'f', decimalPlaces, 64)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if negative && strings.Trim(digits, "0.") == "" {
+		negative = false // Don't show -0.00
+	}
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	number := groupDigits(intPart, format.GroupSeparator)
+	if fracPart != "" {
+		number += format.DecimalSeparator + fracPart
+	}
+
+	space := "" // Non-breaking, so the symbol never wraps away from the amount
+	if format.SymbolSpace && currency.Symbol != "" {
+		space = "\u00a0"
+	}
+
+	var formatted string
+	if format.SymbolAfter {
+		formatted = number + space + currency.Symbol
+	} else {
+		formatted = currency.Symbol + space + number
+	}
+	if negative {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// groupDigits inserts sep between every three digits from the right
+func groupDigits(digits, sep string) string {
+	if len(digits) <= 3 || sep == "" {
+		return digits
+	}
+
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
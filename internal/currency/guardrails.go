@@ -0,0 +1,168 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var rateGuardrailExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "currency_rate_guardrail_exceeded_total",
+	Help: "Total number of exchange rates applied despite moving past the rate change guardrail, by source",
+}, []string{"source"})
+
+// ForceRateChange applies a manually set rate even when it moves further from
+// the stored rate than the guardrail allows, e.g. after a devaluation
+func ForceRateChange() RateOption {
+	return func(r *ExchangeRate) {
+		r.forced = true
+	}
+}
+
+// SetMaxRateChange sets how far, as a percentage of the stored rate, a manually
+// set rate may move before it is refused with ErrRateChangeTooLarge. Zero turns
+// the guardrail off.
+func (s *Service) SetMaxRateChange(percent float64) {
+	s.maxRateChangePercent = percent
+}
+
+// rateChangePercent returns how far rate moved from previous, as a percentage
+// of previous
+func rateChangePercent(previous, rate float64) float64 {
+	return math.Abs(rate-previous) / previous * 100
+}
+
+// exceedsRateGuardrail reports whether a rate moved further from the previous
+// rate than the guardrail allows, and by what percentage
+func (s *Service) exceedsRateGuardrail(previous *ExchangeRate, rate float64) (float64, bool) {
+	if s.maxRateChangePercent <= 0 || previous == nil || previous.Rate <= 0 {
+		return 0, false
+	}
+	change := rateChangePercent(previous.Rate, rate)
+	return change, change > s.maxRateChangePercent
+}
+
+// checkRateChange refuses a manually set rate that moved past the guardrail
+// from the previous rate, unless it was forced. A forced change is logged.
+func (s *Service) checkRateChange(ctx context.Context, previous, rate *ExchangeRate) error {
+	change, exceeded := s.exceedsRateGuardrail(previous, rate.Rate)
+	if !exceeded {
+		return nil
+	}
+	if !rate.forced {
+		return fmt.Errorf("%w: %s/%s from %g to %g is a %.1f%% change, over the %.1f%% limit",
+			ErrRateChangeTooLarge, rate.FromCurrency, rate.ToCurrency, previous.Rate, rate.Rate,
+			change, s.maxRateChangePercent)
+	}
+
+	rateGuardrailExceeded.WithLabelValues(rate.Source).Inc()
+	logger.WarnContext(ctx, "Exchange rate forced past guardrail",
+		zap.String("from", rate.FromCurrency),
+		zap.String("to", rate.ToCurrency),
+		zap.Float64("previous_rate", previous.Rate),
+		zap.Float64("rate", rate.Rate),
+		zap.Float64("change_percent", change),
+	)
+	return nil
+}
+
+// previousRate returns the stored rate a new rate for the pair is compared
+// against, or nil if there is none or the guardrail is off
+func (s *Service) previousRate(ctx context.Context, from, to string) (*ExchangeRate, error) {
+	if s.maxRateChangePercent <= 0 {
+		return nil, nil
+	}
+	previous, err := s.repo.GetLatestExchangeRate(ctx, from, to)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("check previous rate %s/%s: %w", from, to, err)
+	}
+	return previous, nil
+}
+
+// previousRatesFrom returns the stored rates from a currency, by target
+// currency, or nil if the guardrail is off
+func (s *Service) previousRatesFrom(ctx context.Context, from string) (map[string]*ExchangeRate, error) {
+	if s.maxRateChangePercent <= 0 {
+		return nil, nil
+	}
+	rates, err := s.repo.GetAllExchangeRatesFromBase(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("check previous rates from %s: %w", from, err)
+	}
+	byTarget := make(map[string]*ExchangeRate, len(rates))
+	for _, r := range rates {
+		byTarget[r.ToCurrency] = r
+	}
+	return byTarget, nil
+}
+
+// rateJump is a provider rate that moved past the guardrail
+type rateJump struct {
+	rate     *ExchangeRate
+	previous float64
+	change   float64 // Percentage of the previous rate
+}
+
+// noteProviderRateJumps logs provider rates that moved past the guardrail and
+// returns them. The provider is trusted, so they are still applied; once they
+// are, auditProviderRateJumps records each one.
+func (s *Service) noteProviderRateJumps(ctx context.Context, from string, rates []*ExchangeRate) []rateJump {
+	previous, err := s.previousRatesFrom(ctx, from)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to check provider rates against guardrail", zap.Error(err))
+		return nil
+	}
+
+	var jumps []rateJump
+	for _, rate := range rates {
+		prev := previous[rate.ToCurrency]
+		change, exceeded := s.exceedsRateGuardrail(prev, rate.Rate)
+		if !exceeded {
+			continue
+		}
+		rateGuardrailExceeded.WithLabelValues(rate.Source).Inc()
+		logger.WarnContext(ctx, "Provider rate moved past guardrail; applying it",
+			zap.String("from", rate.FromCurrency),
+			zap.String("to", rate.ToCurrency),
+			zap.String("source", rate.Source),
+			zap.Float64("previous_rate", prev.Rate),
+			zap.Float64("rate", rate.Rate),
+			zap.Float64("change_percent", change),
+		)
+		jumps = append(jumps, rateJump{rate: rate, previous: prev.Rate, change: change})
+	}
+	return jumps
+}
+
+// auditProviderRateJumps records an audit entry for each applied provider rate
+// that moved past the guardrail. Nobody made the change, so it is a system
+// entry unless an admin triggered the fetch.
+func (s *Service) auditProviderRateJumps(ctx context.Context, jumps []rateJump) {
+	for _, jump := range jumps {
+		s.audit.Record(ctx, audit.Entry{
+			System:     true,
+			Action:     "currency.rate_jump",
+			TargetType: "exchange_rate",
+			TargetID:   jump.rate.ID.String(),
+			Before:     map[string]interface{}{"rate": jump.previous},
+			After: map[string]interface{}{
+				"from":           jump.rate.FromCurrency,
+				"to":             jump.rate.ToCurrency,
+				"rate":           jump.rate.Rate,
+				"source":         jump.rate.Source,
+				"change_percent": jump.change,
+			},
+		})
+	}
+}
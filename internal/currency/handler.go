@@ -4,7 +4,10 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
 // Handler handles HTTP requests for currency
@@ -69,6 +72,26 @@ func (h *Handler) GetExchangeRate(c *gin.Context) {
 	common.SuccessResponse(c, ToExchangeRateResponse(rate))
 }
 
+// GetRateMetadata returns the exchange rate between two currencies with its
+// freshness and how it was derived
+func (h *Handler) GetRateMetadata(c *gin.Context) {
+	from := c.Query("from")
+	to := c.Query("to")
+
+	if len(from) != 3 || len(to) != 3 {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid currency codes")
+		return
+	}
+
+	meta, err := h.service.GetRateMetadata(c.Request.Context(), from, to)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusNotFound, "exchange rate not found")
+		return
+	}
+
+	common.SuccessResponse(c, meta)
+}
+
 // GetAllRates returns all exchange rates from base currency
 func (h *Handler) GetAllRates(c *gin.Context) {
 	rates, err := h.service.GetAllRatesFromBase(c.Request.Context())
@@ -88,6 +111,70 @@ func (h *Handler) GetAllRates(c *gin.Context) {
 	})
 }
 
+// GetSupportedConversions returns which conversions the current rates allow
+func (h *Handler) GetSupportedConversions(c *gin.Context) {
+	pairs, err := h.service.GetSupportedPairs(c.Request.Context())
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get supported conversions")
+		return
+	}
+
+	common.SuccessResponse(c, pairs)
+}
+
+// SeedDefaults creates the seed currencies and rates that don't exist yet
+func (h *Handler) SeedDefaults(c *gin.Context) {
+	result, err := h.service.SeedDefaults(c.Request.Context())
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to seed currencies")
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
+// ReconcileInverses fixes stored rates that disagree with the rate for the
+// opposite direction
+func (h *Handler) ReconcileInverses(c *gin.Context) {
+	result, err := h.service.ReconcileInverses(c.Request.Context())
+	if err != nil && result == nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to reconcile inverse rates")
+		return
+	}
+
+	// Mismatches that couldn't be fixed are reported as not fixed
+	common.SuccessResponse(c, result)
+}
+
+// ExportRates returns every rate in effect as a snapshot ImportRates can load
+func (h *Handler) ExportRates(c *gin.Context) {
+	rates, err := h.service.ExportRates(c.Request.Context())
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to export exchange rates")
+		return
+	}
+
+	common.SuccessResponse(c, rates)
+}
+
+// ImportRates loads a rate table snapshot. Pairs that already have a rate are
+// skipped unless ?overwrite=true is passed.
+func (h *Handler) ImportRates(c *gin.Context) {
+	var rates []RateExport
+	if err := c.ShouldBindJSON(&rates); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.ImportRates(c.Request.Context(), rates, c.Query("overwrite") == "true")
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to import exchange rates")
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
 // Convert converts an amount between currencies
 func (h *Handler) Convert(c *gin.Context) {
 	var req ConvertRequest
@@ -125,6 +212,18 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 		curr.GET("/currencies/:code", h.GetCurrency)
 		curr.GET("/rates", h.GetAllRates)
 		curr.GET("/rate", h.GetExchangeRate)
+		curr.GET("/rate/metadata", h.GetRateMetadata)
+		curr.GET("/conversions", h.GetSupportedConversions)
 		curr.POST("/convert", h.Convert)
 	}
+
+	admin := rg.Group("/admin/currency")
+	admin.Use(middleware.RequireRole(models.RoleAdmin))
+	admin.Use(audit.Actor())
+	{
+		admin.POST("/seed", h.SeedDefaults)
+		admin.POST("/rates/reconcile-inverses", h.ReconcileInverses)
+		admin.GET("/rates/export", h.ExportRates)
+		admin.POST("/rates/import", h.ImportRates)
+	}
 }
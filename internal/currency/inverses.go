@@ -0,0 +1,167 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// inverseRateTolerance is how far the product of a pair's rates in both
+// directions may stray from 1 before the rates are treated as inconsistent
+const inverseRateTolerance = 0.0001
+
+// SyncInverseRate also stores the rate's inverse for the opposite direction, so
+// a rate set earlier the other way can't contradict it
+func SyncInverseRate() RateOption {
+	return func(r *ExchangeRate) {
+		r.syncInverse = true
+	}
+}
+
+// InverseMismatch is a pair whose stored rates in the two directions disagree
+type InverseMismatch struct {
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	Rate         float64 `json:"rate"`         // Stored FromCurrency -> ToCurrency rate
+	ReverseRate  float64 `json:"reverse_rate"` // Stored ToCurrency -> FromCurrency rate
+	Deviation    float64 `json:"deviation"`    // How far Rate × ReverseRate is from 1
+	Kept         string  `json:"kept"`         // Direction whose rate was kept, e.g. "USD/EUR"
+	Fixed        bool    `json:"fixed"`        // The other direction was replaced with the kept rate's inverse
+}
+
+// InverseReconciliation is the outcome of ReconcileInverses
+type InverseReconciliation struct {
+	PairsChecked int               `json:"pairs_checked"`
+	Mismatches   []InverseMismatch `json:"mismatches"`
+}
+
+// inverseDeviation returns how far the product of a pair's rates in the two
+// directions is from 1
+func inverseDeviation(rate, reverseRate float64) float64 {
+	return math.Abs(rate*reverseRate - 1)
+}
+
+// inverseOf returns a rate for the opposite direction that agrees with rate
+func inverseOf(rate *ExchangeRate, now time.Time) *ExchangeRate {
+	return &ExchangeRate{
+		FromCurrency: rate.ToCurrency,
+		ToCurrency:   rate.FromCurrency,
+		Rate:         1 / rate.Rate,
+		InverseRate:  rate.Rate,
+		Source:       rate.Source,
+		FetchedAt:    now,
+		ValidUntil:   rate.ValidUntil,
+		Pinned:       rate.Pinned,
+	}
+}
+
+// authoritativeRate picks which of a pair's two rates to keep: a pinned rate
+// over an unpinned one, as lookups do, and otherwise the more recent
+func authoritativeRate(a, b *ExchangeRate) (kept, replaced *ExchangeRate) {
+	if a.Pinned != b.Pinned {
+		if a.Pinned {
+			return a, b
+		}
+		return b, a
+	}
+	if b.FetchedAt.After(a.FetchedAt) {
+		return b, a
+	}
+	return a, b
+}
+
+// ReconcileInverses finds pairs whose stored rates in the two directions
+// disagree by more than inverseRateTolerance and replaces the stale direction
+// with the inverse of the one kept. Every mismatch is reported, whether or not
+// it could be fixed; fixes that failed are returned as an error as well.
+func (s *Service) ReconcileInverses(ctx context.Context) (*InverseReconciliation, error) {
+	rates, err := s.repo.GetLatestExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load exchange rates: %w", err)
+	}
+
+	byPair := make(map[string]*ExchangeRate, len(rates))
+	for _, rate := range rates {
+		byPair[rate.FromCurrency+"/"+rate.ToCurrency] = rate
+	}
+
+	result := &InverseReconciliation{Mismatches: make([]InverseMismatch, 0)}
+	var fixErrs []error
+	now := time.Now()
+
+	for _, rate := range rates {
+		// Visit each pair once, from the alphabetically first currency
+		if rate.FromCurrency >= rate.ToCurrency {
+			continue
+		}
+		reverse, ok := byPair[rate.ToCurrency+"/"+rate.FromCurrency]
+		if !ok {
+			continue
+		}
+		result.PairsChecked++
+
+		deviation := inverseDeviation(rate.Rate, reverse.Rate)
+		if deviation <= inverseRateTolerance {
+			continue
+		}
+
+		kept, replaced := authoritativeRate(rate, reverse)
+		mismatch := InverseMismatch{
+			FromCurrency: rate.FromCurrency,
+			ToCurrency:   rate.ToCurrency,
+			Rate:         rate.Rate,
+			ReverseRate:  reverse.Rate,
+			Deviation:    deviation,
+			Kept:         kept.FromCurrency + "/" + kept.ToCurrency,
+		}
+		logger.WarnContext(ctx, "Inconsistent inverse exchange rates",
+			zap.String("from", rate.FromCurrency),
+			zap.String("to", rate.ToCurrency),
+			zap.Float64("rate", rate.Rate),
+			zap.Float64("reverse_rate", reverse.Rate),
+			zap.Float64("deviation", deviation),
+			zap.String("kept", mismatch.Kept),
+		)
+
+		fixed := inverseOf(kept, now)
+		if err := s.repo.CreateExchangeRate(ctx, fixed); err != nil {
+			logger.WarnContext(ctx, "Failed to fix inverse exchange rate",
+				zap.String("from", fixed.FromCurrency), zap.String("to", fixed.ToCurrency), zap.Error(err))
+			fixErrs = append(fixErrs, fmt.Errorf("replace %s/%s: %w", replaced.FromCurrency, replaced.ToCurrency, err))
+		} else {
+			mismatch.Fixed = true
+			s.invalidateCache(fixed.FromCurrency, fixed.ToCurrency)
+			s.publishRateChanges(fixed)
+			s.recordAdminChange(ctx, audit.Entry{
+				Action:     "currency.inverse_reconciled",
+				TargetType: "exchange_rate",
+				TargetID:   fixed.ID.String(),
+				Before:     map[string]interface{}{"rate": replaced.Rate},
+				After: map[string]interface{}{
+					"from":      fixed.FromCurrency,
+					"to":        fixed.ToCurrency,
+					"rate":      fixed.Rate,
+					"kept_rate": kept.Rate,
+				},
+			})
+		}
+		result.Mismatches = append(result.Mismatches, mismatch)
+	}
+
+	sort.Slice(result.Mismatches, func(i, j int) bool {
+		a, b := result.Mismatches[i], result.Mismatches[j]
+		if a.FromCurrency != b.FromCurrency {
+			return a.FromCurrency < b.FromCurrency
+		}
+		return a.ToCurrency < b.ToCurrency
+	})
+
+	return result, errors.Join(fixErrs...)
+}
@@ -0,0 +1,160 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RateDerivation is how the service arrived at an exchange rate
+type RateDerivation string
+
+const (
+	DerivationIdentity     RateDerivation = "identity"     // Same currency, always 1
+	DerivationDirect       RateDerivation = "direct"       // Stored or fetched for the pair as asked
+	DerivationInverse      RateDerivation = "inverse"      // Stored for the reverse pair and inverted
+	DerivationTriangulated RateDerivation = "triangulated" // Chained through other currencies
+)
+
+// Derivation reports how the service arrived at the rate
+func (r *ExchangeRate) Derivation() RateDerivation {
+	if r.derivation == "" {
+		return DerivationDirect
+	}
+	return r.derivation
+}
+
+// Shared by every Service in the process; it tracks whichever cache changed last
+var rateCacheOldestAge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "currency_rate_cache_oldest_age_seconds",
+	Help: "Age of the oldest unpinned exchange rate in the in-memory cache, by when its data was fetched",
+})
+
+// RateLeg is one stored rate a triangulated rate was derived from
+type RateLeg struct {
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         float64   `json:"rate"`
+	Source       string    `json:"source"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	ValidUntil   time.Time `json:"valid_until"`
+	Pinned       bool      `json:"pinned,omitempty"`
+}
+
+// RateMetadata describes how fresh an exchange rate is and where it came from
+type RateMetadata struct {
+	Rate             *ExchangeRate  `json:"rate"`
+	Derivation       RateDerivation `json:"derivation"`
+	FromCache        bool           `json:"from_cache"`
+	FetchedAt        time.Time      `json:"fetched_at"`  // When the oldest unpinned underlying rate was fetched
+	AgeSeconds       float64        `json:"age_seconds"` // Time since FetchedAt
+	ExpiresInSeconds float64        `json:"expires_in_seconds"`
+	Legs             []RateLeg      `json:"legs,omitempty"`
+	ExpiringLeg      *RateLeg       `json:"expiring_leg,omitempty"` // The leg a triangulated rate expires with
+}
+
+// GetRateMetadata returns the rate GetExchangeRate would use between two
+// currencies along with how fresh it is: how long ago its data was fetched,
+// whether it was served from the cache, how it was derived and how long until
+// it expires. A triangulated rate is as old as its oldest unpinned leg and
+// expires with its earliest expiring unpinned leg, which is reported as
+// ExpiringLeg. Pinned rates never expire and report zero ExpiresInSeconds.
+func (s *Service) GetRateMetadata(ctx context.Context, from, to string) (*RateMetadata, error) {
+	cached := s.cachedRate(from, to)
+
+	rate, err := s.GetExchangeRate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	meta := &RateMetadata{
+		Rate:       rate,
+		Derivation: rate.Derivation(),
+		FromCache:  cached != nil && cached == rate,
+		FetchedAt:  rate.dataFetchedAt(),
+	}
+	meta.AgeSeconds = now.Sub(meta.FetchedAt).Seconds()
+	if !rate.Pinned {
+		meta.ExpiresInSeconds = rate.ValidUntil.Sub(now).Seconds()
+	}
+
+	for _, leg := range rate.legs {
+		meta.Legs = append(meta.Legs, RateLeg{
+			FromCurrency: leg.FromCurrency,
+			ToCurrency:   leg.ToCurrency,
+			Rate:         leg.Rate,
+			Source:       leg.Source,
+			FetchedAt:    leg.FetchedAt,
+			ValidUntil:   leg.ValidUntil,
+			Pinned:       leg.Pinned,
+		})
+	}
+	if i := expiringLeg(rate.legs); i >= 0 {
+		meta.ExpiringLeg = &meta.Legs[i]
+	}
+
+	return meta, nil
+}
+
+// cachedRate returns the cache entry for a pair, valid or not
+func (s *Service) cachedRate(from, to string) *ExchangeRate {
+	s.cache.mu.RLock()
+	defer s.cache.mu.RUnlock()
+	return s.cache.rates[fmt.Sprintf("%s-%s", from, to)]
+}
+
+// dataFetchedAt is when the data behind the rate was fetched: for a
+// triangulated rate, when its oldest unpinned leg was. Pinned legs don't age,
+// so a rate triangulated only through pins is as old as the triangulation.
+func (r *ExchangeRate) dataFetchedAt() time.Time {
+	var oldest time.Time
+	for _, leg := range r.legs {
+		if !leg.Pinned && (oldest.IsZero() || leg.FetchedAt.Before(oldest)) {
+			oldest = leg.FetchedAt
+		}
+	}
+	if oldest.IsZero() {
+		return r.FetchedAt
+	}
+	return oldest
+}
+
+// expiringLeg returns the index of the unpinned leg that expires first, the one
+// triangulatedRate takes its ValidUntil from, or -1 if every leg is pinned
+func expiringLeg(legs []*ExchangeRate) int {
+	index := -1
+	for i, leg := range legs {
+		if leg.Pinned {
+			continue
+		}
+		if index < 0 || leg.ValidUntil.Before(legs[index].ValidUntil) {
+			index = i
+		}
+	}
+	return index
+}
+
+// observeCacheAge updates the oldest cached rate age gauge. Pinned rates are
+// left out as they never go stale. Expired entries count until they are
+// replaced, so a pair that keeps failing to refresh shows up as a growing age.
+func (s *Service) observeCacheAge() {
+	now := time.Now()
+	oldest := 0.0
+
+	s.cache.mu.RLock()
+	for _, rate := range s.cache.rates {
+		if rate.Pinned {
+			continue
+		}
+		if age := now.Sub(rate.dataFetchedAt()).Seconds(); age > oldest {
+			oldest = age
+		}
+	}
+	s.cache.mu.RUnlock()
+
+	rateCacheOldestAge.Set(oldest)
+}
@@ -1,6 +1,8 @@
 package currency
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,21 +29,49 @@ type ExchangeRate struct {
 	FetchedAt    time.Time `json:"fetched_at" db:"fetched_at"`
 	ValidUntil   time.Time `json:"valid_until" db:"valid_until"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	Pinned       bool      `json:"pinned" db:"pinned"`   // Manual override that never expires
+	SpreadBps    int       `json:"spread_bps,omitempty"` // Spread applied on top of the mid rate (not stored)
+
+	derivation  RateDerivation  // How the service arrived at the rate (not stored)
+	legs        []*ExchangeRate // Rates a triangulated rate was derived from (not stored)
+	forced      bool            // Set past the rate change guardrail on purpose (not stored)
+	syncInverse bool            // Also store the inverse for the opposite direction (not stored)
+	inactiveOK  bool            // Set for an inactive currency on purpose (not stored)
+}
+
+// IsValidAt reports whether the rate can be used at t. Pinned rates are always valid.
+func (r *ExchangeRate) IsValidAt(t time.Time) bool {
+	return r.Pinned || r.ValidUntil.After(t)
 }
 
-// Money represents an amount with currency
+// Money represents an amount with currency. AmountMinor holds the amount in the
+// currency's smallest unit and is what arithmetic works on; Amount is derived
+// from it for display and backward compatibility.
 type Money struct {
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	Amount        float64 `json:"amount"` // Derived from AmountMinor; don't do arithmetic on it
+	AmountMinor   int64   `json:"amount_minor"`
+	DecimalPlaces int     `json:"decimal_places"`
+	Currency      string  `json:"currency"`
 }
 
 // ConversionResult represents the result of a currency conversion
 type ConversionResult struct {
-	Original       Money     `json:"original"`
-	Converted      Money     `json:"converted"`
-	ExchangeRate   float64   `json:"exchange_rate"`
-	ExchangeRateID uuid.UUID `json:"exchange_rate_id,omitempty"`
-	ConvertedAt    time.Time `json:"converted_at"`
+	Original       Money        `json:"original"`
+	Converted      Money        `json:"converted"`
+	ExchangeRate   float64      `json:"exchange_rate"`
+	ExchangeRateID uuid.UUID    `json:"exchange_rate_id,omitempty"`
+	AppliedRate    float64      `json:"applied_rate,omitempty"` // Rate after spread, when a spread was requested
+	SpreadBps      int          `json:"spread_bps,omitempty"`
+	RoundingMode   RoundingMode `json:"rounding_mode"`
+	ConvertedAt    time.Time    `json:"converted_at"`
+}
+
+// BatchConversionResult is the result of converting several amounts into one
+// currency. Total is the sum of the rounded Items.
+type BatchConversionResult struct {
+	Items       []*ConversionResult `json:"items"`
+	Total       Money               `json:"total"`
+	ConvertedAt time.Time           `json:"converted_at"`
 }
 
 // CurrencyResponse is the API response for currency
@@ -58,6 +88,7 @@ type ExchangeRateResponse struct {
 	ToCurrency   string    `json:"to_currency"`
 	Rate         float64   `json:"rate"`
 	ValidUntil   time.Time `json:"valid_until"`
+	Pinned       bool      `json:"pinned,omitempty"`
 }
 
 // ConvertRequest is the API request for conversion
@@ -82,13 +113,37 @@ type ConvertResponse struct {
 type RoundingMode int
 
 const (
-	RoundingModeNone       RoundingMode = iota // No rounding
-	RoundingModeStandard                       // Standard rounding
-	RoundingModeCeiling                        // Always round up
-	RoundingModeFloor                          // Always round down
-	RoundingModeBankers                        // Banker's rounding (round to even)
+	RoundingModeNone     RoundingMode = iota // No rounding
+	RoundingModeStandard                     // Standard rounding (half up, away from zero)
+	RoundingModeCeiling                      // Always round up
+	RoundingModeFloor                        // Always round down
+	RoundingModeBankers                      // Banker's rounding (round half to even)
+	RoundingModeDown                         // Truncate towards zero
 )
 
+// roundingModeNames are the names rounding modes are reported by in API responses
+var roundingModeNames = map[RoundingMode]string{
+	RoundingModeNone:     "none",
+	RoundingModeStandard: "half_up",
+	RoundingModeCeiling:  "ceiling",
+	RoundingModeFloor:    "floor",
+	RoundingModeBankers:  "half_even",
+	RoundingModeDown:     "down",
+}
+
+// String returns the rounding mode's name, e.g. "half_even"
+func (m RoundingMode) String() string {
+	if name, ok := roundingModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// MarshalText encodes the rounding mode by name so it reads clearly in JSON
+func (m RoundingMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
 // Common currency codes
 const (
 	CurrencyUSD = "USD"
@@ -103,6 +158,20 @@ const (
 	CurrencyINR = "INR"
 )
 
+// ErrNoRateAtTime is returned by GetRateAt when no stored rate covered the requested time
+var ErrNoRateAtTime = errors.New("no exchange rate was in effect")
+
+// ErrInactiveCurrency is returned when converting from or to, or setting a rate
+// for, a currency that has been deactivated
+var ErrInactiveCurrency = errors.New("currency is not active")
+
+// ErrRateChangeTooLarge is returned when a manually set rate moves further from
+// the stored rate than the configured guardrail allows and wasn't forced
+var ErrRateChangeTooLarge = errors.New("exchange rate change exceeds guardrail")
+
+// MaxSpreadBps is the widest spread accepted by ConvertWithSpread (100%)
+const MaxSpreadBps = 10000
+
 // ExchangeRateSource defines the source of exchange rates
 type ExchangeRateSource string
 
@@ -111,4 +180,6 @@ const (
 	SourceOpenExchange ExchangeRateSource = "openexchange"
 	SourceFixer        ExchangeRateSource = "fixer"
 	SourceCurrencyAPI  ExchangeRateSource = "currencyapi"
+	SourceECB          ExchangeRateSource = "ecb"
+	SourceSeed         ExchangeRateSource = "seed" // Bootstrap rate from SeedDefaults
 )
@@ -0,0 +1,137 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// NewMoney creates Money from a decimal amount, rounding it to the currency's
+// minor units
+func NewMoney(amount float64, currency string, decimalPlaces int) Money {
+	if decimalPlaces < 0 {
+		decimalPlaces = 0
+	}
+	rat, ok := decimalRat(amount)
+	if !ok {
+		rat = new(big.Rat)
+	}
+	return NewMoneyFromMinor(roundRat(rat.Mul(rat, pow10Rat(decimalPlaces)), RoundingModeStandard), currency, decimalPlaces)
+}
+
+// NewMoneyFromMinor creates Money from an amount in minor units (e.g. cents)
+func NewMoneyFromMinor(amountMinor int64, currency string, decimalPlaces int) Money {
+	if decimalPlaces < 0 {
+		decimalPlaces = 0
+	}
+	return Money{
+		Amount:        float64(amountMinor) / math.Pow(10, float64(decimalPlaces)),
+		AmountMinor:   amountMinor,
+		DecimalPlaces: decimalPlaces,
+		Currency:      currency,
+	}
+}
+
+// Add returns m + other. Both amounts must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return NewMoneyFromMinor(m.AmountMinor+other.AmountMinor, m.Currency, m.DecimalPlaces), nil
+}
+
+// Sub returns m - other. Both amounts must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return NewMoneyFromMinor(m.AmountMinor-other.AmountMinor, m.Currency, m.DecimalPlaces), nil
+}
+
+// MulRate multiplies m by rate, rounding the result to the nearest minor unit
+func (m Money) MulRate(rate float64) Money {
+	return NewMoneyFromMinor(mulMinor(m.AmountMinor, rate, 0, RoundingModeStandard), m.Currency, m.DecimalPlaces)
+}
+
+func (m Money) checkSameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("currency mismatch: %s and %s", m.Currency, other.Currency)
+	}
+	if m.DecimalPlaces != other.DecimalPlaces {
+		return fmt.Errorf("%s amounts have different precision: %d and %d decimal places",
+			m.Currency, m.DecimalPlaces, other.DecimalPlaces)
+	}
+	return nil
+}
+
+// mulMinor computes amountMinor * rate * 10^shift exactly and rounds once, so
+// converting between currencies with different decimal places loses nothing
+// beyond the final rounding
+func mulMinor(amountMinor int64, rate float64, shift int, mode RoundingMode) int64 {
+	product := new(big.Rat).SetInt64(amountMinor)
+	rateRat, ok := decimalRat(rate)
+	if !ok {
+		return 0
+	}
+	product.Mul(product, rateRat)
+	if shift >= 0 {
+		product.Mul(product, pow10Rat(shift))
+	} else {
+		product.Quo(product, pow10Rat(-shift))
+	}
+	return roundRat(product, mode)
+}
+
+// roundRat rounds r to an integer using mode. RoundingModeNone rounds like
+// RoundingModeStandard, since minor units can't hold a fraction.
+func roundRat(r *big.Rat, mode RoundingMode) int64 {
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo.Int64()
+	}
+
+	negative := r.Sign() < 0
+	// Compare twice the remainder with the denominator to find which half it's in
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.Cmp(den)
+
+	awayFromZero := false
+	switch mode {
+	case RoundingModeCeiling:
+		awayFromZero = !negative
+	case RoundingModeFloor:
+		awayFromZero = negative
+	case RoundingModeBankers:
+		awayFromZero = half > 0 || (half == 0 && quo.Bit(0) == 1)
+	case RoundingModeDown:
+		awayFromZero = false
+	default:
+		awayFromZero = half >= 0
+	}
+
+	result := quo.Int64()
+	if awayFromZero {
+		if negative {
+			result--
+		} else {
+			result++
+		}
+	}
+	return result
+}
+
+// decimalRat returns the shortest decimal form of f rather than its binary value,
+// so that 1.005 rounds to 1.01 as written. It fails for NaN and infinities.
+func decimalRat(f float64) (*big.Rat, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func pow10Rat(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
@@ -0,0 +1,94 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMoney_RoundsToMinorUnits(t *testing.T) {
+	assert.Equal(t, int64(101), NewMoney(1.005, CurrencyUSD, 2).AmountMinor)
+	assert.Equal(t, int64(-101), NewMoney(-1.005, CurrencyUSD, 2).AmountMinor)
+	assert.Equal(t, int64(1235), NewMoney(1234.5, "JPY", 0).AmountMinor)
+
+	m := NewMoney(12.34, CurrencyEUR, 2)
+	assert.Equal(t, int64(1234), m.AmountMinor)
+	assert.Equal(t, 12.34, m.Amount)
+	assert.Equal(t, 2, m.DecimalPlaces)
+}
+
+func TestMoney_AddSub(t *testing.T) {
+	a := NewMoney(10.10, CurrencyUSD, 2)
+	b := NewMoney(0.20, CurrencyUSD, 2)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1030), sum.AmountMinor)
+	assert.Equal(t, 10.30, sum.Amount)
+
+	diff, err := a.Sub(b)
+	require.NoError(t, err)
+	assert.Equal(t, 9.90, diff.Amount)
+}
+
+func TestMoney_AddRejectsMismatchedCurrencies(t *testing.T) {
+	_, err := NewMoney(1, CurrencyUSD, 2).Add(NewMoney(1, CurrencyEUR, 2))
+	assert.Error(t, err)
+
+	_, err = NewMoney(1, CurrencyUSD, 2).Sub(NewMoney(1, CurrencyUSD, 3))
+	assert.Error(t, err)
+}
+
+func TestMoney_MulRate(t *testing.T) {
+	assert.Equal(t, int64(125), NewMoneyFromMinor(1000, CurrencyUSD, 2).MulRate(0.125).AmountMinor)
+	assert.Equal(t, int64(1), NewMoneyFromMinor(5, CurrencyUSD, 2).MulRate(0.1).AmountMinor, "0.5 cents rounds up")
+	assert.Equal(t, int64(-1), NewMoneyFromMinor(-5, CurrencyUSD, 2).MulRate(0.1).AmountMinor, "and away from zero")
+}
+
+func TestConverter_ConvertMoney_ChangesPrecision(t *testing.T) {
+	c := NewConverter(CurrencyUSD)
+
+	// 12.34 USD * 150.5 = 1857.17 -> 1857 JPY
+	jpy := c.ConvertMoney(NewMoney(12.34, CurrencyUSD, 2), &ExchangeRate{Rate: 150.5}, "JPY", RoundingModeStandard, 0)
+	assert.Equal(t, int64(1857), jpy.AmountMinor)
+	assert.Equal(t, "JPY", jpy.Currency)
+
+	// 1857 JPY / 150.5 = 12.3388... -> 12.339 KWD-style three decimal places
+	kwd := c.ConvertMoney(jpy, &ExchangeRate{Rate: 1 / 150.5}, "KWD", RoundingModeStandard, 3)
+	assert.Equal(t, int64(12339), kwd.AmountMinor)
+}
+
+func TestConverter_ConvertMoney_RoundingModes(t *testing.T) {
+	c := NewConverter(CurrencyUSD)
+	m := NewMoneyFromMinor(25, CurrencyUSD, 2) // 0.25 * 0.1 = 2.5 cents
+	rate := &ExchangeRate{Rate: 0.1}
+
+	assert.Equal(t, int64(3), c.ConvertMoney(m, rate, CurrencyEUR, RoundingModeStandard, 2).AmountMinor)
+	assert.Equal(t, int64(2), c.ConvertMoney(m, rate, CurrencyEUR, RoundingModeBankers, 2).AmountMinor)
+	assert.Equal(t, int64(2), c.ConvertMoney(m, rate, CurrencyEUR, RoundingModeFloor, 2).AmountMinor)
+	assert.Equal(t, int64(3), c.ConvertMoney(m, rate, CurrencyEUR, RoundingModeCeiling, 2).AmountMinor)
+}
+
+func TestMoney_NoDriftOverRepeatedOperations(t *testing.T) {
+	c := NewConverter(CurrencyUSD)
+	rate := &ExchangeRate{Rate: 0.85}
+	step := NewMoney(0.10, CurrencyUSD, 2)
+
+	total := NewMoney(0, CurrencyUSD, 2)
+	floatTotal := 0.0
+	var converted Money
+	for i := 0; i < 1000; i++ {
+		var err error
+		total, err = total.Add(step)
+		require.NoError(t, err)
+		converted = c.ConvertMoney(total, rate, CurrencyEUR, RoundingModeStandard, 2)
+		floatTotal += 0.10
+	}
+
+	assert.NotEqual(t, 100.0, floatTotal, "float addition drifts")
+	assert.Equal(t, int64(10000), total.AmountMinor)
+	assert.Equal(t, 100.0, total.Amount)
+	assert.Equal(t, int64(8500), converted.AmountMinor)
+	assert.Equal(t, 85.0, converted.Amount)
+}
@@ -0,0 +1,186 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/config"
+	"github.com/richxcame/ride-hailing/pkg/httpclient"
+)
+
+const (
+	fixerBaseURL = "https://data.fixer.io/api"
+	ecbBaseURL   = "https://www.ecb.europa.eu/stats/eurofxref"
+
+	providerTimeout = 10 * time.Second
+)
+
+// RateProvider fetches current exchange rates from an external source.
+// FetchRates returns how many units of each currency one unit of base buys.
+type RateProvider interface {
+	FetchRates(ctx context.Context, base string) (map[string]float64, error)
+}
+
+// sourcedProvider is implemented by providers that label the rates they return
+type sourcedProvider interface {
+	Source() ExchangeRateSource
+}
+
+// NewRateProvider builds the provider selected in config, or nil when auto-refresh is disabled
+func NewRateProvider(cfg config.CurrencyConfig) RateProvider {
+	switch ExchangeRateSource(cfg.RateProvider) {
+	case SourceFixer:
+		if cfg.FixerAPIKey == "" {
+			return nil
+		}
+		return NewFixerProvider(cfg.FixerAPIKey)
+	case SourceECB:
+		return NewECBProvider()
+	default:
+		return nil
+	}
+}
+
+// ========================================
+// FIXER
+// ========================================
+
+// FixerProvider fetches rates from the fixer.io API
+type FixerProvider struct {
+	client *httpclient.Client
+	apiKey string
+}
+
+// NewFixerProvider creates a fixer.io rate provider
+func NewFixerProvider(apiKey string) *FixerProvider {
+	return newFixerProvider(fixerBaseURL, apiKey)
+}
+
+func newFixerProvider(baseURL, apiKey string) *FixerProvider {
+	return &FixerProvider{
+		client: httpclient.NewClient(baseURL, providerTimeout),
+		apiKey: apiKey,
+	}
+}
+
+// Source returns the exchange rate source label
+func (p *FixerProvider) Source() ExchangeRateSource {
+	return SourceFixer
+}
+
+// FetchRates fetches the latest rates and rebases them onto base.
+// Fixer's free plan only serves EUR-based rates, so the base is converted locally.
+func (p *FixerProvider) FetchRates(ctx context.Context, base string) (map[string]float64, error) {
+	body, err := p.client.Get(ctx, "/latest?access_key="+p.apiKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fixer request failed: %w", err)
+	}
+
+	var resp struct {
+		Success bool               `json:"success"`
+		Base    string             `json:"base"`
+		Rates   map[string]float64 `json:"rates"`
+		Error   *struct {
+			Code int    `json:"code"`
+			Type string `json:"type"`
+			Info string `json:"info"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode fixer response: %w", err)
+	}
+	if !resp.Success {
+		if resp.Error != nil {
+			return nil, fmt.Errorf("fixer error %d: %s", resp.Error.Code, resp.Error.Type)
+		}
+		return nil, fmt.Errorf("fixer request was not successful")
+	}
+
+	return rebaseRates(resp.Base, resp.Rates, base)
+}
+
+// ========================================
+// EUROPEAN CENTRAL BANK
+// ========================================
+
+// ECBProvider fetches the European Central Bank daily reference rates
+type ECBProvider struct {
+	client *httpclient.Client
+}
+
+// NewECBProvider creates an ECB rate provider
+func NewECBProvider() *ECBProvider {
+	return newECBProvider(ecbBaseURL)
+}
+
+func newECBProvider(baseURL string) *ECBProvider {
+	return &ECBProvider{client: httpclient.NewClient(baseURL, providerTimeout)}
+}
+
+// Source returns the exchange rate source label
+func (p *ECBProvider) Source() ExchangeRateSource {
+	return SourceECB
+}
+
+// FetchRates fetches the daily EUR reference rates and rebases them onto base
+func (p *ECBProvider) FetchRates(ctx context.Context, base string) (map[string]float64, error) {
+	body, err := p.client.Get(ctx, "/eurofxref-daily.xml", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ecb request failed: %w", err)
+	}
+
+	var envelope struct {
+		Cube struct {
+			Cube struct {
+				Time  string `xml:"time,attr"`
+				Rates []struct {
+					Currency string  `xml:"currency,attr"`
+					Rate     float64 `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode ecb response: %w", err)
+	}
+
+	rates := make(map[string]float64, len(envelope.Cube.Cube.Rates))
+	for _, r := range envelope.Cube.Cube.Rates {
+		rates[r.Currency] = r.Rate
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("ecb response contained no rates")
+	}
+
+	return rebaseRates(CurrencyEUR, rates, base)
+}
+
+// ========================================
+// HELPERS
+// ========================================
+
+// rebaseRates converts rates quoted against from into rates quoted against to
+func rebaseRates(from string, rates map[string]float64, to string) (map[string]float64, error) {
+	if from == to {
+		return rates, nil
+	}
+
+	pivot, ok := rates[to]
+	if !ok || pivot <= 0 {
+		return nil, fmt.Errorf("no %s rate available to rebase %s rates", to, from)
+	}
+
+	rebased := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		if code == to || rate <= 0 {
+			continue
+		}
+		rebased[code] = rate / pivot
+	}
+	rebased[from] = 1 / pivot
+
+	return rebased, nil
+}
@@ -0,0 +1,77 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixerProvider_FetchRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest", r.URL.Path)
+		assert.Equal(t, "test-key", r.URL.Query().Get("access_key"))
+		w.Write([]byte(`{"success":true,"base":"EUR","rates":{"USD":1.25,"GBP":0.85,"EUR":1}}`))
+	}))
+	defer server.Close()
+
+	provider := newFixerProvider(server.URL, "test-key")
+	rates, err := provider.FetchRates(context.Background(), CurrencyUSD)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, rates[CurrencyEUR], 0.0001)
+	assert.InDelta(t, 0.68, rates[CurrencyGBP], 0.0001)
+	assert.NotContains(t, rates, CurrencyUSD)
+}
+
+func TestFixerProvider_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":false,"error":{"code":101,"type":"invalid_access_key"}}`))
+	}))
+	defer server.Close()
+
+	provider := newFixerProvider(server.URL, "bad-key")
+	rates, err := provider.FetchRates(context.Background(), CurrencyEUR)
+
+	assert.Error(t, err)
+	assert.Nil(t, rates)
+	assert.Contains(t, err.Error(), "invalid_access_key")
+}
+
+func TestECBProvider_FetchRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/eurofxref-daily.xml", r.URL.Path)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-10-16">
+			<Cube currency="USD" rate="1.0800"/>
+			<Cube currency="GBP" rate="0.8640"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`))
+	}))
+	defer server.Close()
+
+	provider := newECBProvider(server.URL)
+
+	eurRates, err := provider.FetchRates(context.Background(), CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, 1.08, eurRates[CurrencyUSD])
+
+	usdRates, err := provider.FetchRates(context.Background(), CurrencyUSD)
+	require.NoError(t, err)
+	assert.InDelta(t, 1/1.08, usdRates[CurrencyEUR], 0.0001)
+	assert.InDelta(t, 0.8, usdRates[CurrencyGBP], 0.0001)
+}
+
+func TestRebaseRates_MissingPivot(t *testing.T) {
+	rates, err := rebaseRates(CurrencyEUR, map[string]float64{CurrencyUSD: 1.08}, CurrencyTMT)
+
+	assert.Error(t, err)
+	assert.Nil(t, rates)
+}
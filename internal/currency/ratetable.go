@@ -0,0 +1,196 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RateExport is one stored rate in a rate table snapshot, as ExportRates
+// produces it and ImportRates takes it
+type RateExport struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Rate       float64   `json:"rate"`
+	Source     string    `json:"source"`
+	ValidUntil time.Time `json:"valid_until"`
+	Pinned     bool      `json:"pinned,omitempty"`
+}
+
+// SkippedRate is an imported rate ImportRates didn't apply, and why
+type SkippedRate struct {
+	Pair   string `json:"pair"`
+	Reason string `json:"reason"`
+}
+
+// RateImportResult reports which imported rates were applied and which skipped
+type RateImportResult struct {
+	Applied []string      `json:"applied"`
+	Skipped []SkippedRate `json:"skipped"`
+}
+
+// ExportRates returns every rate currently in effect, one per direction, sorted
+// by pair, for saving and loading into another environment with ImportRates
+func (s *Service) ExportRates(ctx context.Context) ([]RateExport, error) {
+	rates, err := s.repo.GetLatestExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load exchange rates: %w", err)
+	}
+
+	now := time.Now()
+	exported := make([]RateExport, 0, len(rates))
+	for _, rate := range rates {
+		if !rate.IsValidAt(now) {
+			continue
+		}
+		exported = append(exported, RateExport{
+			From:       rate.FromCurrency,
+			To:         rate.ToCurrency,
+			Rate:       rate.Rate,
+			Source:     rate.Source,
+			ValidUntil: rate.ValidUntil,
+			Pinned:     rate.Pinned,
+		})
+	}
+
+	sort.Slice(exported, func(i, j int) bool {
+		if exported[i].From != exported[j].From {
+			return exported[i].From < exported[j].From
+		}
+		return exported[i].To < exported[j].To
+	})
+	return exported, nil
+}
+
+// ImportRates stores a rate table snapshot in one batch. Each entry must be for
+// two distinct existing currencies with a positive rate that hasn't expired;
+// entries that aren't are skipped with the reason. Pairs that already have a
+// rate in effect are skipped unless overwrite is set. Imported rates keep their
+// source, expiry and pin, and bypass the rate change guardrail. Pins already
+// stored aren't released, so an unpinned rate doesn't displace a pinned one.
+func (s *Service) ImportRates(ctx context.Context, entries []RateExport, overwrite bool) (*RateImportResult, error) {
+	result := &RateImportResult{
+		Applied: make([]string, 0),
+		Skipped: make([]SkippedRate, 0),
+	}
+
+	now := time.Now()
+	existing := make(map[string]bool)
+	if !overwrite {
+		rates, err := s.repo.GetLatestExchangeRates(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load exchange rates: %w", err)
+		}
+		for _, rate := range rates {
+			if rate.IsValidAt(now) {
+				existing[rate.FromCurrency+"/"+rate.ToCurrency] = true
+			}
+		}
+	}
+
+	known := make(map[string]bool)
+	seen := make(map[string]bool, len(entries))
+	var rates []*ExchangeRate
+
+	for _, entry := range entries {
+		pair := entry.From + "/" + entry.To
+		reason, err := s.rejectImportedRate(ctx, entry, now, known)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case reason != "":
+		case seen[pair]:
+			reason = "duplicate pair"
+		case existing[pair]:
+			reason = "rate already set"
+		}
+		if reason != "" {
+			result.Skipped = append(result.Skipped, SkippedRate{Pair: pair, Reason: reason})
+			continue
+		}
+		seen[pair] = true
+
+		source := entry.Source
+		if source == "" {
+			source = string(SourceManual)
+		}
+		rates = append(rates, &ExchangeRate{
+			FromCurrency: entry.From,
+			ToCurrency:   entry.To,
+			Rate:         entry.Rate,
+			InverseRate:  1 / entry.Rate,
+			Source:       source,
+			FetchedAt:    now,
+			ValidUntil:   entry.ValidUntil,
+			Pinned:       entry.Pinned,
+		})
+		result.Applied = append(result.Applied, pair)
+	}
+
+	if len(rates) == 0 {
+		return result, nil
+	}
+	if err := s.repo.BulkCreateExchangeRates(ctx, rates); err != nil {
+		return nil, fmt.Errorf("import exchange rates: %w", err)
+	}
+
+	bases := make(map[string]bool)
+	for _, rate := range rates {
+		bases[rate.FromCurrency] = true
+	}
+	for base := range bases {
+		s.invalidateCacheForBase(base)
+	}
+	s.invalidateSupportedPairs()
+	s.publishRateChanges(rates...)
+
+	logger.InfoContext(ctx, "Imported exchange rates",
+		zap.Int("applied", len(result.Applied)),
+		zap.Int("skipped", len(result.Skipped)),
+		zap.Bool("overwrite", overwrite),
+	)
+	s.recordAdminChange(ctx, audit.Entry{
+		Action:     "currency.rates_imported",
+		TargetType: "exchange_rate",
+		After: map[string]interface{}{
+			"applied":   result.Applied,
+			"skipped":   len(result.Skipped),
+			"overwrite": overwrite,
+		},
+	})
+	return result, nil
+}
+
+// rejectImportedRate returns why an imported rate can't be applied, or "" if it
+// can. known caches which currency codes exist across entries.
+func (s *Service) rejectImportedRate(ctx context.Context, entry RateExport, now time.Time, known map[string]bool) (string, error) {
+	if !isCurrencyCode(entry.From) || !isCurrencyCode(entry.To) || entry.From == entry.To {
+		return "invalid currency pair", nil
+	}
+	if entry.Rate <= 0 {
+		return "rate must be positive", nil
+	}
+	if !entry.Pinned && !entry.ValidUntil.After(now) {
+		return "rate has expired", nil
+	}
+	for _, code := range []string{entry.From, entry.To} {
+		exists, ok := known[code]
+		if !ok {
+			var err error
+			if exists, err = s.currencyExists(ctx, code); err != nil {
+				return "", err
+			}
+			known[code] = exists
+		}
+		if !exists {
+			return fmt.Sprintf("currency %s not found", code), nil
+		}
+	}
+	return "", nil
+}
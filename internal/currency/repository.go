@@ -66,22 +66,23 @@ func (r *Repository) GetCurrencyByCode(ctx context.Context, code string) (*Curre
 	return c, nil
 }
 
-// GetLatestExchangeRate retrieves the latest valid exchange rate
+// GetLatestExchangeRate retrieves the latest valid exchange rate. Pinned rates
+// never expire and take precedence over newer unpinned ones.
 func (r *Repository) GetLatestExchangeRate(ctx context.Context, fromCurrency, toCurrency string) (*ExchangeRate, error) {
 	query := `
 		SELECT id, from_currency, to_currency, rate, inverse_rate, source,
-		       fetched_at, valid_until, created_at
+		       fetched_at, valid_until, created_at, pinned
 		FROM exchange_rates
 		WHERE from_currency = $1 AND to_currency = $2
-		  AND valid_until > NOW()
-		ORDER BY fetched_at DESC
+		  AND (pinned OR valid_until > NOW())
+		ORDER BY pinned DESC, fetched_at DESC
 		LIMIT 1
 	`
 
 	rate := &ExchangeRate{}
 	err := r.db.QueryRow(ctx, query, fromCurrency, toCurrency).Scan(
 		&rate.ID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate,
-		&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt,
+		&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt, &rate.Pinned,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
@@ -90,11 +91,37 @@ func (r *Repository) GetLatestExchangeRate(ctx context.Context, fromCurrency, to
 	return rate, nil
 }
 
+// GetExchangeRateAt retrieves the exchange rate that was in effect at a given time:
+// the most recently created rate that had been stored by then and was still valid
+func (r *Repository) GetExchangeRateAt(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*ExchangeRate, error) {
+	query := `
+		SELECT id, from_currency, to_currency, rate, inverse_rate, source,
+		       fetched_at, valid_until, created_at, pinned
+		FROM exchange_rates
+		WHERE from_currency = $1 AND to_currency = $2
+		  AND created_at <= $3
+		  AND (pinned OR valid_until > $3)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	rate := &ExchangeRate{}
+	err := r.db.QueryRow(ctx, query, fromCurrency, toCurrency, at).Scan(
+		&rate.ID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate,
+		&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt, &rate.Pinned,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate at %s: %w", at.Format(time.RFC3339), err)
+	}
+
+	return rate, nil
+}
+
 // GetExchangeRateByID retrieves an exchange rate by ID
 func (r *Repository) GetExchangeRateByID(ctx context.Context, id uuid.UUID) (*ExchangeRate, error) {
 	query := `
 		SELECT id, from_currency, to_currency, rate, inverse_rate, source,
-		       fetched_at, valid_until, created_at
+		       fetched_at, valid_until, created_at, pinned
 		FROM exchange_rates
 		WHERE id = $1
 	`
@@ -102,7 +129,7 @@ func (r *Repository) GetExchangeRateByID(ctx context.Context, id uuid.UUID) (*Ex
 	rate := &ExchangeRate{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&rate.ID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate,
-		&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt,
+		&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt, &rate.Pinned,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
@@ -115,15 +142,15 @@ func (r *Repository) GetExchangeRateByID(ctx context.Context, id uuid.UUID) (*Ex
 func (r *Repository) CreateExchangeRate(ctx context.Context, rate *ExchangeRate) error {
 	query := `
 		INSERT INTO exchange_rates (id, from_currency, to_currency, rate, inverse_rate,
-		                            source, fetched_at, valid_until)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		                            source, fetched_at, valid_until, pinned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING created_at
 	`
 
 	rate.ID = uuid.New()
 	err := r.db.QueryRow(ctx, query,
 		rate.ID, rate.FromCurrency, rate.ToCurrency, rate.Rate,
-		rate.InverseRate, rate.Source, rate.FetchedAt, rate.ValidUntil,
+		rate.InverseRate, rate.Source, rate.FetchedAt, rate.ValidUntil, rate.Pinned,
 	).Scan(&rate.CreatedAt)
 
 	if err != nil {
@@ -150,10 +177,10 @@ func (r *Repository) BulkCreateExchangeRates(ctx context.Context, rates []*Excha
 		rate.ID = uuid.New()
 		_, err := tx.Exec(ctx, `
 			INSERT INTO exchange_rates (id, from_currency, to_currency, rate, inverse_rate,
-			                            source, fetched_at, valid_until)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			                            source, fetched_at, valid_until, pinned)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		`, rate.ID, rate.FromCurrency, rate.ToCurrency, rate.Rate,
-			rate.InverseRate, rate.Source, rate.FetchedAt, rate.ValidUntil)
+			rate.InverseRate, rate.Source, rate.FetchedAt, rate.ValidUntil, rate.Pinned)
 
 		if err != nil {
 			return fmt.Errorf("failed to create exchange rate: %w", err)
@@ -172,10 +199,10 @@ func (r *Repository) GetAllExchangeRatesFromBase(ctx context.Context, baseCurren
 	query := `
 		SELECT DISTINCT ON (to_currency)
 		       id, from_currency, to_currency, rate, inverse_rate, source,
-		       fetched_at, valid_until, created_at
+		       fetched_at, valid_until, created_at, pinned
 		FROM exchange_rates
-		WHERE from_currency = $1 AND valid_until > NOW()
-		ORDER BY to_currency, fetched_at DESC
+		WHERE from_currency = $1 AND (pinned OR valid_until > NOW())
+		ORDER BY to_currency, pinned DESC, fetched_at DESC
 	`
 
 	rows, err := r.db.Query(ctx, query, baseCurrency)
@@ -189,7 +216,40 @@ func (r *Repository) GetAllExchangeRatesFromBase(ctx context.Context, baseCurren
 		rate := &ExchangeRate{}
 		err := rows.Scan(
 			&rate.ID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate,
-			&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt,
+			&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt, &rate.Pinned,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// GetLatestExchangeRates retrieves the current exchange rate of every stored pair
+func (r *Repository) GetLatestExchangeRates(ctx context.Context) ([]*ExchangeRate, error) {
+	query := `
+		SELECT DISTINCT ON (from_currency, to_currency)
+		       id, from_currency, to_currency, rate, inverse_rate, source,
+		       fetched_at, valid_until, created_at, pinned
+		FROM exchange_rates
+		WHERE pinned OR valid_until > NOW()
+		ORDER BY from_currency, to_currency, pinned DESC, fetched_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make([]*ExchangeRate, 0)
+	for rows.Next() {
+		rate := &ExchangeRate{}
+		err := rows.Scan(
+			&rate.ID, &rate.FromCurrency, &rate.ToCurrency, &rate.Rate,
+			&rate.InverseRate, &rate.Source, &rate.FetchedAt, &rate.ValidUntil, &rate.CreatedAt, &rate.Pinned,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
@@ -200,12 +260,12 @@ func (r *Repository) GetAllExchangeRatesFromBase(ctx context.Context, baseCurren
 	return rates, nil
 }
 
-// InvalidateExchangeRates marks exchange rates as expired
+// InvalidateExchangeRates marks exchange rates as expired. Pinned rates are left alone.
 func (r *Repository) InvalidateExchangeRates(ctx context.Context, fromCurrency string) error {
 	query := `
 		UPDATE exchange_rates
 		SET valid_until = NOW()
-		WHERE from_currency = $1 AND valid_until > NOW()
+		WHERE from_currency = $1 AND valid_until > NOW() AND NOT pinned
 	`
 
 	_, err := r.db.Exec(ctx, query, fromCurrency)
@@ -216,6 +276,26 @@ func (r *Repository) InvalidateExchangeRates(ctx context.Context, fromCurrency s
 	return nil
 }
 
+// UnpinExchangeRates releases the pinned rates between two currencies, in either
+// direction, other than keep. A released pin stays valid until now, so historical
+// lookups still find it for the time it was in force.
+func (r *Repository) UnpinExchangeRates(ctx context.Context, fromCurrency, toCurrency string, keep uuid.UUID) error {
+	query := `
+		UPDATE exchange_rates
+		SET pinned = false, valid_until = NOW()
+		WHERE pinned AND id != $3
+		  AND ((from_currency = $1 AND to_currency = $2)
+		    OR (from_currency = $2 AND to_currency = $1))
+	`
+
+	_, err := r.db.Exec(ctx, query, fromCurrency, toCurrency, keep)
+	if err != nil {
+		return fmt.Errorf("failed to unpin exchange rates: %w", err)
+	}
+
+	return nil
+}
+
 // CreateCurrency creates a new currency
 func (r *Repository) CreateCurrency(ctx context.Context, currency *Currency) error {
 	query := `
@@ -255,11 +335,12 @@ func (r *Repository) UpdateCurrency(ctx context.Context, currency *Currency) err
 	return nil
 }
 
-// CleanupExpiredRates removes exchange rates that have been expired for more than the given duration
+// CleanupExpiredRates removes exchange rates that have been expired for more than
+// the given duration. Pinned rates never expire and are kept.
 func (r *Repository) CleanupExpiredRates(ctx context.Context, olderThan time.Duration) (int64, error) {
 	query := `
 		DELETE FROM exchange_rates
-		WHERE valid_until < $1
+		WHERE valid_until < $1 AND NOT pinned
 	`
 
 	cutoff := time.Now().Add(-olderThan)
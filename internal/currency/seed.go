@@ -0,0 +1,234 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// defaultSeedRateValidity is how long seeded rates stay valid when the seed set
+// doesn't say
+const defaultSeedRateValidity = 24 * time.Hour
+
+// SeedCurrency is a currency SeedDefaults creates, active, if it doesn't exist
+type SeedCurrency struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Symbol        string `json:"symbol"`
+	DecimalPlaces int    `json:"decimal_places"`
+}
+
+// SeedRate is an exchange rate SeedDefaults sets when its pair has no valid rate
+// in either direction
+type SeedRate struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// SeedSet is the currencies and rates a deployment starts with
+type SeedSet struct {
+	Currencies     []SeedCurrency `json:"currencies"`
+	Rates          []SeedRate     `json:"rates,omitempty"`
+	RateValidHours int            `json:"rate_valid_hours,omitempty"` // How long seeded rates are valid; 24 if unset
+}
+
+// SeedResult reports what SeedDefaults created and what it found already there
+type SeedResult struct {
+	CurrenciesCreated  []string `json:"currencies_created"`
+	CurrenciesExisting []string `json:"currencies_existing"`
+	RatesSeeded        []string `json:"rates_seeded"`
+	RatesExisting      []string `json:"rates_existing"`
+}
+
+// DefaultSeedSet is the standard set of currencies. It has no rates, which go
+// stale; a deployment without a rate provider sets its own.
+func DefaultSeedSet() SeedSet {
+	return SeedSet{
+		Currencies: []SeedCurrency{
+			{Code: CurrencyUSD, Name: "US Dollar", Symbol: "$", DecimalPlaces: 2},
+			{Code: CurrencyEUR, Name: "Euro", Symbol: "€", DecimalPlaces: 2},
+			{Code: CurrencyGBP, Name: "British Pound", Symbol: "£", DecimalPlaces: 2},
+			{Code: CurrencyTMT, Name: "Turkmenistan Manat", Symbol: "m", DecimalPlaces: 2},
+			{Code: "JPY", Name: "Japanese Yen", Symbol: "¥", DecimalPlaces: 0},
+		},
+	}
+}
+
+// LoadSeedSet reads a seed set from a JSON file
+func LoadSeedSet(path string) (SeedSet, error) {
+	var seed SeedSet
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return seed, fmt.Errorf("read currency seed file: %w", err)
+	}
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return seed, fmt.Errorf("parse currency seed file: %w", err)
+	}
+	if err := seed.validate(); err != nil {
+		return seed, fmt.Errorf("invalid currency seed file: %w", err)
+	}
+	return seed, nil
+}
+
+// validate checks the seed set could be seeded
+func (seed SeedSet) validate() error {
+	for _, c := range seed.Currencies {
+		if !isCurrencyCode(c.Code) {
+			return fmt.Errorf("invalid currency code %q", c.Code)
+		}
+		if c.Name == "" {
+			return fmt.Errorf("currency %s has no name", c.Code)
+		}
+		if c.DecimalPlaces < 0 || c.DecimalPlaces > 8 {
+			return fmt.Errorf("currency %s has invalid decimal places %d", c.Code, c.DecimalPlaces)
+		}
+	}
+	for _, r := range seed.Rates {
+		if !isCurrencyCode(r.From) || !isCurrencyCode(r.To) || r.From == r.To {
+			return fmt.Errorf("invalid rate pair %s/%s", r.From, r.To)
+		}
+		if r.Rate <= 0 {
+			return fmt.Errorf("rate %s/%s must be positive", r.From, r.To)
+		}
+	}
+	if seed.RateValidHours < 0 {
+		return errors.New("rate_valid_hours can't be negative")
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether code looks like an ISO 4217 code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSeedSet replaces the set SeedDefaults seeds, for deployments that start
+// with other currencies than DefaultSeedSet
+func (s *Service) SetSeedSet(seed SeedSet) {
+	s.seed = seed
+}
+
+// SeedDefaults creates the seed set's currencies that don't exist yet and sets
+// its rates for pairs without a valid rate. Existing currencies are left as they
+// are, so it is safe to run on every boot.
+func (s *Service) SeedDefaults(ctx context.Context) (*SeedResult, error) {
+	result := &SeedResult{
+		CurrenciesCreated:  make([]string, 0),
+		CurrenciesExisting: make([]string, 0),
+		RatesSeeded:        make([]string, 0),
+		RatesExisting:      make([]string, 0),
+	}
+
+	for _, c := range s.seed.Currencies {
+		created, err := s.seedCurrency(ctx, c)
+		if err != nil {
+			return result, err
+		}
+		if created {
+			result.CurrenciesCreated = append(result.CurrenciesCreated, c.Code)
+		} else {
+			result.CurrenciesExisting = append(result.CurrenciesExisting, c.Code)
+		}
+	}
+
+	validFor := defaultSeedRateValidity
+	if s.seed.RateValidHours > 0 {
+		validFor = time.Duration(s.seed.RateValidHours) * time.Hour
+	}
+	for _, r := range s.seed.Rates {
+		pair := r.From + "/" + r.To
+		exists, err := s.hasValidRate(ctx, r.From, r.To)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.RatesExisting = append(result.RatesExisting, pair)
+			continue
+		}
+		if err := s.SetExchangeRate(ctx, r.From, r.To, r.Rate, validFor, withSource(SourceSeed)); err != nil {
+			return result, fmt.Errorf("seed rate %s: %w", pair, err)
+		}
+		result.RatesSeeded = append(result.RatesSeeded, pair)
+	}
+
+	if len(result.CurrenciesCreated) > 0 || len(result.RatesSeeded) > 0 {
+		logger.InfoContext(ctx, "Seeded currencies",
+			zap.Strings("currencies", result.CurrenciesCreated),
+			zap.Strings("rates", result.RatesSeeded),
+		)
+	}
+	return result, nil
+}
+
+// seedCurrency creates a currency unless it exists, and reports whether it did.
+// A currency another instance created at the same time counts as existing.
+func (s *Service) seedCurrency(ctx context.Context, c SeedCurrency) (bool, error) {
+	exists, err := s.currencyExists(ctx, c.Code)
+	if err != nil || exists {
+		return false, err
+	}
+
+	err = s.CreateCurrency(ctx, &Currency{
+		Code:          c.Code,
+		Name:          c.Name,
+		Symbol:        c.Symbol,
+		DecimalPlaces: c.DecimalPlaces,
+		IsActive:      true,
+	})
+	if err == nil {
+		return true, nil
+	}
+	if exists, _ := s.currencyExists(ctx, c.Code); exists {
+		return false, nil
+	}
+	return false, fmt.Errorf("seed currency %s: %w", c.Code, err)
+}
+
+// currencyExists reports whether a currency is stored, active or not
+func (s *Service) currencyExists(ctx context.Context, code string) (bool, error) {
+	_, err := s.repo.GetCurrencyByCode(ctx, code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check currency %s: %w", code, err)
+	}
+	return true, nil
+}
+
+// hasValidRate reports whether a valid rate is stored for a pair in either direction
+func (s *Service) hasValidRate(ctx context.Context, from, to string) (bool, error) {
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		_, err := s.repo.GetLatestExchangeRate(ctx, pair[0], pair[1])
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("check rate %s/%s: %w", pair[0], pair[1], err)
+		}
+	}
+	return false, nil
+}
+
+// withSource records where a set rate came from
+func withSource(source ExchangeRateSource) RateOption {
+	return func(r *ExchangeRate) {
+		r.Source = string(source)
+	}
+}
@@ -2,11 +2,18 @@ package currency
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // Service handles currency business logic
@@ -15,6 +22,16 @@ type Service struct {
 	converter    *Converter
 	baseCurrency string
 	cache        *rateCache
+	currencies   *currencyCache
+	provider     RateProvider
+	sweeping     atomic.Bool
+	seed         SeedSet
+	audit        audit.Logger
+
+	maxRateChangePercent float64 // Guardrail on manually set rates; 0 turns it off
+
+	supportedPairs supportedPairsCache
+	subscribers    rateSubscribers
 }
 
 // rateCache provides in-memory caching for exchange rates
@@ -24,13 +41,60 @@ type rateCache struct {
 	ttl   time.Duration
 }
 
-// NewService creates a new currency service
-func NewService(repo RepositoryInterface, baseCurrency string) *Service {
+// currencyCache provides in-memory caching for currency metadata, such as the
+// decimal places every conversion needs
+type currencyCache struct {
+	mu         sync.RWMutex
+	currencies map[string]*cachedCurrency
+	ttl        time.Duration
+}
+
+// cachedCurrency is a currency held by the currency cache until expiresAt
+type cachedCurrency struct {
+	currency  *Currency
+	expiresAt time.Time
+}
+
+// providerRateTTL is how long rates fetched from a RateProvider stay valid
+const providerRateTTL = 1 * time.Hour
+
+// currencyCacheTTL is how long currency metadata is cached. Changes made
+// through the service take effect at once; this bounds changes made elsewhere.
+const currencyCacheTTL = 10 * time.Minute
+
+// ConvertOption modifies how a conversion checks its currencies
+type ConvertOption func(*convertOptions)
+
+// convertOptions holds the options a conversion was called with
+type convertOptions struct {
+	allowInactive bool
+}
+
+// ConvertInactiveCurrencies lets a conversion use inactive currencies, for
+// admins reconciling back-dated records
+func ConvertInactiveCurrencies() ConvertOption {
+	return func(o *convertOptions) {
+		o.allowInactive = true
+	}
+}
+
+// newConvertOptions applies opts to the default conversion options
+func newConvertOptions(opts []ConvertOption) convertOptions {
+	var o convertOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewService creates a new currency service. An optional RateProvider is used to
+// refresh missing or expired rates.
+func NewService(repo RepositoryInterface, baseCurrency string, provider ...RateProvider) *Service {
 	if baseCurrency == "" {
 		baseCurrency = CurrencyUSD
 	}
 
-	return &Service{
+	s := &Service{
 		repo:         repo,
 		converter:    NewConverter(baseCurrency),
 		baseCurrency: baseCurrency,
@@ -38,6 +102,30 @@ func NewService(repo RepositoryInterface, baseCurrency string) *Service {
 			rates: make(map[string]*ExchangeRate),
 			ttl:   5 * time.Minute,
 		},
+		currencies: &currencyCache{
+			currencies: make(map[string]*cachedCurrency),
+			ttl:        currencyCacheTTL,
+		},
+		seed:  DefaultSeedSet(),
+		audit: audit.Nop(),
+	}
+	if len(provider) > 0 {
+		s.provider = provider[0]
+	}
+
+	return s
+}
+
+// SetAuditLogger sets where admin changes to currencies and rates are audited
+func (s *Service) SetAuditLogger(logger audit.Logger) {
+	s.audit = logger
+}
+
+// recordAdminChange audits a change made by an admin. Changes without an actor
+// in ctx, such as provider refreshes and seeding, aren't audited.
+func (s *Service) recordAdminChange(ctx context.Context, entry audit.Entry) {
+	if _, ok := audit.ActorFromContext(ctx); ok {
+		s.audit.Record(ctx, entry)
 	}
 }
 
@@ -64,14 +152,66 @@ func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (*Exchan
 			Source:       "identity",
 			FetchedAt:    time.Now(),
 			ValidUntil:   time.Now().Add(24 * time.Hour),
+			derivation:   DerivationIdentity,
 		}, nil
 	}
 
+	defer s.observeCacheAge()
+
+	// Cached, direct or inverse rate
+	rate, err := s.lookupStoredRate(ctx, from, to)
+	if err == nil {
+		return rate, nil
+	}
+
+	// Missing or expired in storage - fetch fresh rates from the provider
+	if s.provider != nil {
+		rate, err := s.refreshFromProvider(ctx, from, to)
+		if err == nil {
+			return rate, nil
+		}
+		logger.WarnContext(ctx, "Exchange rate provider refresh failed, falling back to triangulation",
+			zap.String("from", from),
+			zap.String("to", to),
+			zap.Error(err),
+		)
+	}
+
+	// Try triangulation via base currency. The provider has already been tried for
+	// this pair, so the legs only use stored rates.
+	if from != s.baseCurrency && to != s.baseCurrency {
+		fromToBase, err := s.lookupStoredRate(ctx, from, s.baseCurrency)
+		if err == nil {
+			baseToTarget, err := s.lookupStoredRate(ctx, s.baseCurrency, to)
+			if err == nil {
+				rate = triangulatedRate(from, to, []*ExchangeRate{fromToBase, baseToTarget})
+				s.cacheRate(rate)
+				return rate, nil
+			}
+		}
+	}
+
+	// Fall back to a bounded search over every stored currency pair
+	path, err := s.findRatePath(ctx, from, to)
+	if err == nil {
+		rate = triangulatedRate(from, to, path)
+		s.cacheRate(rate)
+		return rate, nil
+	}
+
+	if from != s.baseCurrency && to != s.baseCurrency {
+		return nil, fmt.Errorf("no rate path found from %s to %s", from, to)
+	}
+	return nil, fmt.Errorf("no exchange rate found for %s to %s", from, to)
+}
+
+// lookupStoredRate returns a cached rate, or the direct or inverse rate from the database
+func (s *Service) lookupStoredRate(ctx context.Context, from, to string) (*ExchangeRate, error) {
 	// Check cache first
 	cacheKey := fmt.Sprintf("%s-%s", from, to)
 	s.cache.mu.RLock()
 	if cached, ok := s.cache.rates[cacheKey]; ok {
-		if cached.ValidUntil.After(time.Now()) {
+		if cached.IsValidAt(time.Now()) {
 			s.cache.mu.RUnlock()
 			return cached, nil
 		}
@@ -87,62 +227,128 @@ func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (*Exchan
 
 	// Try inverse rate
 	inverseRate, err := s.repo.GetLatestExchangeRate(ctx, to, from)
-	if err == nil {
-		// Create a rate from the inverse
-		rate = &ExchangeRate{
-			ID:           inverseRate.ID,
+	if err != nil {
+		return nil, err
+	}
+
+	rate = invertRate(inverseRate)
+	s.cacheRate(rate)
+	return rate, nil
+}
+
+// invertRate turns a to -> from rate into the equivalent from -> to rate
+func invertRate(inverse *ExchangeRate) *ExchangeRate {
+	return &ExchangeRate{
+		ID:           inverse.ID,
+		FromCurrency: inverse.ToCurrency,
+		ToCurrency:   inverse.FromCurrency,
+		Rate:         inverse.InverseRate,
+		InverseRate:  inverse.Rate,
+		Source:       inverse.Source,
+		FetchedAt:    inverse.FetchedAt,
+		ValidUntil:   inverse.ValidUntil,
+		CreatedAt:    inverse.CreatedAt,
+		Pinned:       inverse.Pinned,
+		derivation:   DerivationInverse,
+	}
+}
+
+// GetRateAt returns the exchange rate that was in effect between two currencies
+// at a given time, for reconciling past charges. It tries the direct and inverse
+// stored rates, then triangulates via the base currency. Historical rates bypass
+// the cache and the rate provider, which only know about current rates. Rates
+// already purged by CleanupExpiredRates can no longer be found.
+func (s *Service) GetRateAt(ctx context.Context, from, to string, at time.Time) (*ExchangeRate, error) {
+	if from == to {
+		return &ExchangeRate{
+			ID:           uuid.Nil,
 			FromCurrency: from,
 			ToCurrency:   to,
-			Rate:         inverseRate.InverseRate,
-			InverseRate:  inverseRate.Rate,
-			Source:       inverseRate.Source,
-			FetchedAt:    inverseRate.FetchedAt,
-			ValidUntil:   inverseRate.ValidUntil,
-			CreatedAt:    inverseRate.CreatedAt,
-		}
-		s.cacheRate(rate)
+			Rate:         1.0,
+			InverseRate:  1.0,
+			Source:       "identity",
+			FetchedAt:    at,
+			ValidUntil:   at.Add(24 * time.Hour),
+			derivation:   DerivationIdentity,
+		}, nil
+	}
+
+	// Only a missing rate falls through to the next lookup; database failures
+	// are returned rather than reported as no rate being in effect.
+	rate, err := s.lookupStoredRateAt(ctx, from, to, at)
+	if err == nil {
 		return rate, nil
 	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
 
-	// Try triangulation via base currency
 	if from != s.baseCurrency && to != s.baseCurrency {
-		fromToBase, err := s.GetExchangeRate(ctx, from, s.baseCurrency)
-		if err != nil {
-			return nil, fmt.Errorf("no rate path found from %s to %s", from, to)
+		fromToBase, err := s.lookupStoredRateAt(ctx, from, s.baseCurrency, at)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
 		}
-
-		baseToTarget, err := s.GetExchangeRate(ctx, s.baseCurrency, to)
-		if err != nil {
-			return nil, fmt.Errorf("no rate path found from %s to %s", from, to)
+		if err == nil {
+			baseToTarget, err := s.lookupStoredRateAt(ctx, s.baseCurrency, to, at)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+			if err == nil {
+				return triangulatedRate(from, to, []*ExchangeRate{fromToBase, baseToTarget}), nil
+			}
 		}
+	}
 
-		// Calculate triangulated rate
-		triangulatedRate := fromToBase.Rate * baseToTarget.Rate
+	return nil, fmt.Errorf("%w: %s to %s at %s", ErrNoRateAtTime, from, to, at.UTC().Format(time.RFC3339))
+}
 
-		rate = &ExchangeRate{
-			ID:           uuid.Nil,
-			FromCurrency: from,
-			ToCurrency:   to,
-			Rate:         triangulatedRate,
-			InverseRate:  1 / triangulatedRate,
-			Source:       "triangulated",
-			FetchedAt:    time.Now(),
-			ValidUntil:   minTime(fromToBase.ValidUntil, baseToTarget.ValidUntil),
-		}
-		s.cacheRate(rate)
+// lookupStoredRateAt returns the direct or inverse rate that was in effect at a
+// given time. It returns an error wrapping pgx.ErrNoRows when neither is stored.
+func (s *Service) lookupStoredRateAt(ctx context.Context, from, to string, at time.Time) (*ExchangeRate, error) {
+	rate, err := s.repo.GetExchangeRateAt(ctx, from, to, at)
+	if err == nil {
 		return rate, nil
 	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewInternalError("failed to get historical exchange rate", err)
+	}
 
-	return nil, fmt.Errorf("no exchange rate found for %s to %s", from, to)
+	inverseRate, err := s.repo.GetExchangeRateAt(ctx, to, from, at)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.NewInternalError("failed to get historical exchange rate", err)
+		}
+		return nil, err
+	}
+	return invertRate(inverseRate), nil
 }
 
-// Convert converts an amount from one currency to another
-func (s *Service) Convert(ctx context.Context, amount float64, from, to string) (*ConversionResult, error) {
+// Convert converts an amount from one currency to another, rounding half up.
+// Conversions from or to an inactive currency fail with ErrInactiveCurrency
+// unless ConvertInactiveCurrencies is passed; the same goes for the other
+// conversion methods.
+func (s *Service) Convert(ctx context.Context, amount float64, from, to string, opts ...ConvertOption) (*ConversionResult, error) {
+	return s.ConvertWithRounding(ctx, amount, from, to, RoundingModeStandard, opts...)
+}
+
+// ConvertWithRounding converts an amount from one currency to another, rounding
+// the result to the target currency's decimal places with the given mode, e.g.
+// RoundingModeBankers to avoid upward bias when summing many conversions
+func (s *Service) ConvertWithRounding(ctx context.Context, amount float64, from, to string, mode RoundingMode, opts ...ConvertOption) (*ConversionResult, error) {
+	if _, ok := roundingModeNames[mode]; !ok || mode == RoundingModeNone {
+		return nil, fmt.Errorf("unsupported rounding mode %s", mode)
+	}
+	if err := s.requireActive(ctx, newConvertOptions(opts), from, to); err != nil {
+		return nil, err
+	}
+
+	original := NewMoney(amount, from, s.decimalPlaces(ctx, from))
 	if from == to {
 		return &ConversionResult{
-			Original:     Money{Amount: amount, Currency: from},
-			Converted:    Money{Amount: amount, Currency: to},
+			Original:     original,
+			Converted:    original,
 			ExchangeRate: 1.0,
+			RoundingMode: mode,
 			ConvertedAt:  time.Now(),
 		}, nil
 	}
@@ -152,24 +358,171 @@ func (s *Service) Convert(ctx context.Context, amount float64, from, to string)
 		return nil, err
 	}
 
-	// Get target currency for proper rounding
-	toCurrency, err := s.repo.GetCurrencyByCode(ctx, to)
+	converted := s.converter.ConvertMoney(original, rate, to, mode, s.decimalPlaces(ctx, to))
+
+	return &ConversionResult{
+		Original:       original,
+		Converted:      converted,
+		ExchangeRate:   rate.Rate,
+		ExchangeRateID: rate.ID,
+		RoundingMode:   mode,
+		ConvertedAt:    time.Now(),
+	}, nil
+}
+
+// ConvertWithRate converts money at a rate the caller supplies, such as the rate
+// stored with a settlement, rounding like Convert to toDecimalPlaces. It makes no
+// repository calls, so replaying a conversion from a stored ExchangeRate always
+// gives the original result. The rate must be positive and convert from the
+// money's currency.
+func (s *Service) ConvertWithRate(amount Money, rate *ExchangeRate, toDecimalPlaces int) (*ConversionResult, error) {
+	if rate == nil {
+		return nil, fmt.Errorf("exchange rate is required")
+	}
+	if rate.Rate <= 0 {
+		return nil, fmt.Errorf("exchange rate must be positive")
+	}
+	if rate.FromCurrency != amount.Currency {
+		return nil, fmt.Errorf("exchange rate converts from %s, not %s", rate.FromCurrency, amount.Currency)
+	}
+	if toDecimalPlaces < 0 {
+		return nil, fmt.Errorf("decimal places must not be negative")
+	}
+
+	converted := s.converter.ConvertMoney(amount, rate, rate.ToCurrency, RoundingModeStandard, toDecimalPlaces)
+
+	return &ConversionResult{
+		Original:       amount,
+		Converted:      converted,
+		ExchangeRate:   rate.Rate,
+		ExchangeRateID: rate.ID,
+		RoundingMode:   RoundingModeStandard,
+		ConvertedAt:    time.Now(),
+	}, nil
+}
+
+// ConvertWithSpread converts an amount using the mid rate adjusted by a spread in
+// basis points. The spread is applied to the unrounded rate, and only the final
+// amount is rounded to the target currency's decimal places.
+func (s *Service) ConvertWithSpread(ctx context.Context, amount float64, from, to string, spreadBps int, opts ...ConvertOption) (*ConversionResult, error) {
+	if spreadBps < 0 || spreadBps > MaxSpreadBps {
+		return nil, fmt.Errorf("spread must be between 0 and %d basis points", MaxSpreadBps)
+	}
+	if err := s.requireActive(ctx, newConvertOptions(opts), from, to); err != nil {
+		return nil, err
+	}
+
+	original := NewMoney(amount, from, s.decimalPlaces(ctx, from))
+	if from == to {
+		return &ConversionResult{
+			Original:     original,
+			Converted:    original,
+			ExchangeRate: 1.0,
+			AppliedRate:  1.0,
+			RoundingMode: RoundingModeStandard,
+			ConvertedAt:  time.Now(),
+		}, nil
+	}
+
+	rate, err := s.GetExchangeRate(ctx, from, to)
 	if err != nil {
-		// Default to 2 decimal places if currency not found
-		toCurrency = &Currency{Code: to, DecimalPlaces: 2}
+		return nil, err
 	}
 
-	convertedAmount := s.converter.Convert(amount, rate, RoundingModeStandard, toCurrency.DecimalPlaces)
+	// Work on a copy so the cached mid rate is left untouched
+	applied := *rate
+	applied.Rate = s.converter.ApplySpread(rate.Rate, spreadBps)
+	applied.InverseRate = 1 / applied.Rate
+	applied.SpreadBps = spreadBps
+
+	converted := s.converter.ConvertMoney(original, &applied, to, RoundingModeStandard, s.decimalPlaces(ctx, to))
 
 	return &ConversionResult{
-		Original:       Money{Amount: amount, Currency: from},
-		Converted:      Money{Amount: convertedAmount, Currency: to},
+		Original:       original,
+		Converted:      converted,
 		ExchangeRate:   rate.Rate,
 		ExchangeRateID: rate.ID,
+		AppliedRate:    applied.Rate,
+		SpreadBps:      spreadBps,
+		RoundingMode:   RoundingModeStandard,
 		ConvertedAt:    time.Now(),
 	}, nil
 }
 
+// ConvertBatch converts several amounts, such as a ride's fare, tip, tolls and
+// fees, into one currency. Each exchange rate and the target currency's decimal
+// places are resolved once for the whole batch. Every item is rounded to the
+// target currency on its own, and Total is the sum of those rounded items, so
+// the line items always add up to the total. Total can therefore differ from
+// converting the unconverted sum by up to half a minor unit per item.
+func (s *Service) ConvertBatch(ctx context.Context, items []Money, to string, opts ...ConvertOption) (*BatchConversionResult, error) {
+	options := newConvertOptions(opts)
+	if err := s.requireActive(ctx, options, to); err != nil {
+		return nil, err
+	}
+	checked := map[string]bool{to: true}
+	for i, item := range items {
+		if checked[item.Currency] {
+			continue
+		}
+		if err := s.requireActive(ctx, options, item.Currency); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		checked[item.Currency] = true
+	}
+
+	decimalPlaces := s.decimalPlaces(ctx, to)
+	now := time.Now()
+
+	rates := make(map[string]*ExchangeRate)
+	results := make([]*ConversionResult, len(items))
+	var totalMinor int64
+
+	for i, item := range items {
+		result := &ConversionResult{Original: item, RoundingMode: RoundingModeStandard, ConvertedAt: now}
+
+		if item.Currency == to && item.DecimalPlaces == decimalPlaces {
+			result.Converted = item
+			result.ExchangeRate = 1.0
+		} else {
+			rate, ok := rates[item.Currency]
+			if !ok {
+				if item.Currency == to {
+					rate = &ExchangeRate{FromCurrency: to, ToCurrency: to, Rate: 1.0, InverseRate: 1.0}
+				} else {
+					var err error
+					if rate, err = s.GetExchangeRate(ctx, item.Currency, to); err != nil {
+						return nil, fmt.Errorf("item %d: %w", i, err)
+					}
+				}
+				rates[item.Currency] = rate
+			}
+			result.Converted = s.converter.ConvertMoney(item, rate, to, RoundingModeStandard, decimalPlaces)
+			result.ExchangeRate = rate.Rate
+			result.ExchangeRateID = rate.ID
+		}
+
+		results[i] = result
+		totalMinor += result.Converted.AmountMinor
+	}
+
+	return &BatchConversionResult{
+		Items:       results,
+		Total:       NewMoneyFromMinor(totalMinor, to, decimalPlaces),
+		ConvertedAt: now,
+	}, nil
+}
+
+// decimalPlaces returns the number of decimal places a currency uses, defaulting
+// to 2 when the currency isn't found
+func (s *Service) decimalPlaces(ctx context.Context, code string) int {
+	currency, err := s.lookupCurrency(ctx, code)
+	if err != nil || currency == nil {
+		return 2
+	}
+	return currency.DecimalPlaces
+}
+
 // ConvertToBase converts an amount to the base currency
 func (s *Service) ConvertToBase(ctx context.Context, amount float64, from string) (*ConversionResult, error) {
 	return s.Convert(ctx, amount, from, s.baseCurrency)
@@ -182,7 +535,7 @@ func (s *Service) ConvertFromBase(ctx context.Context, amount float64, to string
 
 // FormatMoney formats a money amount with currency symbol
 func (s *Service) FormatMoney(ctx context.Context, money Money) (string, error) {
-	currency, err := s.repo.GetCurrencyByCode(ctx, money.Currency)
+	currency, err := s.lookupCurrency(ctx, money.Currency)
 	if err != nil {
 		return fmt.Sprintf("%.2f %s", money.Amount, money.Currency), nil
 	}
@@ -190,23 +543,45 @@ func (s *Service) FormatMoney(ctx context.Context, money Money) (string, error)
 	return s.converter.FormatAmount(money.Amount, currency), nil
 }
 
-// SetExchangeRate manually sets an exchange rate
-func (s *Service) SetExchangeRate(ctx context.Context, from, to string, rate float64, validFor time.Duration) error {
-	if rate <= 0 {
-		return fmt.Errorf("rate must be positive")
+// FormatMoneyLocale formats money for display in a locale, e.g. "1.234,50 €"
+// for de-DE. Unknown locales are formatted like FormatMoney.
+func (s *Service) FormatMoneyLocale(ctx context.Context, money Money, locale string) (string, error) {
+	currency, err := s.lookupCurrency(ctx, money.Currency)
+	if err != nil {
+		return fmt.Sprintf("%.2f %s", money.Amount, money.Currency), nil
 	}
 
-	// Verify both currencies exist
-	_, err := s.repo.GetCurrencyByCode(ctx, from)
-	if err != nil {
-		return fmt.Errorf("from currency %s not found", from)
+	return s.converter.FormatAmountLocale(money.Amount, currency, locale), nil
+}
+
+// RateOption modifies a manually set exchange rate
+type RateOption func(*ExchangeRate)
+
+// AllowInactiveCurrencies sets a rate even when either currency is inactive,
+// for admins reconciling back-dated records
+func AllowInactiveCurrencies() RateOption {
+	return func(r *ExchangeRate) {
+		r.inactiveOK = true
 	}
+}
 
-	_, err = s.repo.GetCurrencyByCode(ctx, to)
-	if err != nil {
-		return fmt.Errorf("to currency %s not found", to)
+// PinRate pins a manually set rate: it never expires, takes precedence over
+// provider rates and is kept by CleanupExpiredRates until another rate is set
+// for the pair
+func PinRate() RateOption {
+	return func(r *ExchangeRate) {
+		r.Pinned = true
 	}
+}
 
+// SetExchangeRate manually sets an exchange rate. Setting a rate for a pair
+// releases any earlier pin on it, in either direction; pass PinRate to pin the
+// new rate instead. Rates for inactive currencies are refused with
+// ErrInactiveCurrency unless AllowInactiveCurrencies is passed, and rates
+// moving past the rate change guardrail with ErrRateChangeTooLarge unless
+// ForceRateChange is passed. Pass SyncInverseRate to store the inverse rate for
+// the opposite direction too.
+func (s *Service) SetExchangeRate(ctx context.Context, from, to string, rate float64, validFor time.Duration, opts ...RateOption) error {
 	exchangeRate := &ExchangeRate{
 		FromCurrency: from,
 		ToCurrency:   to,
@@ -216,22 +591,115 @@ func (s *Service) SetExchangeRate(ctx context.Context, from, to string, rate flo
 		FetchedAt:    time.Now(),
 		ValidUntil:   time.Now().Add(validFor),
 	}
+	for _, opt := range opts {
+		opt(exchangeRate)
+	}
+	if err := s.checkManualRate(ctx, exchangeRate); err != nil {
+		return err
+	}
+
+	previous, err := s.previousRate(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRateChange(ctx, previous, exchangeRate); err != nil {
+		return err
+	}
 
 	err = s.repo.CreateExchangeRate(ctx, exchangeRate)
 	if err != nil {
 		return err
 	}
 
+	if err := s.repo.UnpinExchangeRates(ctx, from, to, exchangeRate.ID); err != nil {
+		return err
+	}
+
+	// Stored after releasing pins, which would release a pinned inverse as well
+	if exchangeRate.syncInverse {
+		inverse := inverseOf(exchangeRate, exchangeRate.FetchedAt)
+		if err := s.repo.CreateExchangeRate(ctx, inverse); err != nil {
+			s.invalidateCache(from, to)
+			s.invalidateSupportedPairs()
+			s.publishRateChanges(exchangeRate)
+			return fmt.Errorf("rate %s/%s set but its inverse was not: %w", from, to, err)
+		}
+		s.publishRateChanges(inverse)
+	}
+
 	// Clear cache for this pair
 	s.invalidateCache(from, to)
+	s.invalidateSupportedPairs()
+	s.publishRateChanges(exchangeRate)
+
+	var before map[string]interface{}
+	if previous != nil {
+		before = map[string]interface{}{"rate": previous.Rate}
+	}
+	s.recordAdminChange(ctx, audit.Entry{
+		Action:     "currency.rate_set",
+		TargetType: "exchange_rate",
+		TargetID:   exchangeRate.ID.String(),
+		Before:     before,
+		After: map[string]interface{}{
+			"from":        from,
+			"to":          to,
+			"rate":        rate,
+			"source":      exchangeRate.Source,
+			"pinned":      exchangeRate.Pinned,
+			"forced":      exchangeRate.forced,
+			"inverse_set": exchangeRate.syncInverse,
+			"valid_until": exchangeRate.ValidUntil,
+		},
+	})
 
 	return nil
 }
 
-// BulkSetExchangeRates sets multiple exchange rates from a base currency
-func (s *Service) BulkSetExchangeRates(ctx context.Context, baseCurrency string, rates map[string]float64, validFor time.Duration) error {
+// checkManualRate refuses a manually set rate that isn't positive, or whose
+// currencies don't exist or, unless it was set with AllowInactiveCurrencies,
+// aren't active
+func (s *Service) checkManualRate(ctx context.Context, rate *ExchangeRate) error {
+	if rate.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+
+	// Verify both currencies exist and are in use
+	fromCurrency, err := s.repo.GetCurrencyByCode(ctx, rate.FromCurrency)
+	if err != nil {
+		return fmt.Errorf("from currency %s not found", rate.FromCurrency)
+	}
+
+	toCurrency, err := s.repo.GetCurrencyByCode(ctx, rate.ToCurrency)
+	if err != nil {
+		return fmt.Errorf("to currency %s not found", rate.ToCurrency)
+	}
+
+	if !rate.inactiveOK {
+		for _, c := range []*Currency{fromCurrency, toCurrency} {
+			if !c.IsActive {
+				return fmt.Errorf("%w: %s", ErrInactiveCurrency, c.Code)
+			}
+		}
+	}
+	return nil
+}
+
+// BulkSetExchangeRates sets multiple exchange rates from a base currency. Each
+// rate is checked as SetExchangeRate checks it: if any isn't positive, is for a
+// missing or inactive currency, or moves past the rate change guardrail without
+// ForceRateChange, none are set and the error lists every such rate.
+// SyncInverseRate stores each rate's inverse as well, and AllowInactiveCurrencies
+// lets them be set for inactive currencies.
+func (s *Service) BulkSetExchangeRates(ctx context.Context, baseCurrency string, rates map[string]float64, validFor time.Duration, opts ...RateOption) error {
 	var exchangeRates []*ExchangeRate
 
+	previous, err := s.previousRatesFrom(ctx, baseCurrency)
+	if err != nil {
+		return err
+	}
+	var rateErrs []error
+
 	now := time.Now()
 	validUntil := now.Add(validFor)
 
@@ -240,7 +708,7 @@ func (s *Service) BulkSetExchangeRates(ctx context.Context, baseCurrency string,
 			continue
 		}
 
-		exchangeRates = append(exchangeRates, &ExchangeRate{
+		exchangeRate := &ExchangeRate{
 			FromCurrency: baseCurrency,
 			ToCurrency:   toCurrency,
 			Rate:         rate,
@@ -248,20 +716,95 @@ func (s *Service) BulkSetExchangeRates(ctx context.Context, baseCurrency string,
 			Source:       string(SourceManual),
 			FetchedAt:    now,
 			ValidUntil:   validUntil,
-		})
+		}
+		for _, opt := range opts {
+			opt(exchangeRate)
+		}
+		if err := s.checkManualRate(ctx, exchangeRate); err != nil {
+			rateErrs = append(rateErrs, fmt.Errorf("%s/%s: %w", baseCurrency, toCurrency, err))
+			continue
+		}
+		if err := s.checkRateChange(ctx, previous[toCurrency], exchangeRate); err != nil {
+			rateErrs = append(rateErrs, err)
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+		if exchangeRate.syncInverse {
+			exchangeRates = append(exchangeRates, inverseOf(exchangeRate, now))
+		}
+	}
+	if len(rateErrs) > 0 {
+		return errors.Join(rateErrs...)
 	}
 
-	err := s.repo.BulkCreateExchangeRates(ctx, exchangeRates)
+	err = s.repo.BulkCreateExchangeRates(ctx, exchangeRates)
 	if err != nil {
 		return err
 	}
 
 	// Clear all cache entries for base currency
 	s.invalidateCacheForBase(baseCurrency)
+	s.invalidateSupportedPairs()
+	s.publishRateChanges(exchangeRates...)
 
 	return nil
 }
 
+// refreshFromProvider fetches rates quoted against from, persists them and returns from -> to
+func (s *Service) refreshFromProvider(ctx context.Context, from, to string) (*ExchangeRate, error) {
+	rates, err := s.provider.FetchRates(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	source := "provider"
+	if sp, ok := s.provider.(sourcedProvider); ok {
+		source = string(sp.Source())
+	}
+
+	now := time.Now()
+	validUntil := now.Add(providerRateTTL)
+
+	var target *ExchangeRate
+	exchangeRates := make([]*ExchangeRate, 0, len(rates))
+	for toCurrency, rate := range rates {
+		if toCurrency == from || rate <= 0 {
+			continue
+		}
+
+		exchangeRate := &ExchangeRate{
+			FromCurrency: from,
+			ToCurrency:   toCurrency,
+			Rate:         rate,
+			InverseRate:  1 / rate,
+			Source:       source,
+			FetchedAt:    now,
+			ValidUntil:   validUntil,
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+		if toCurrency == to {
+			target = exchangeRate
+		}
+	}
+
+	if len(exchangeRates) > 0 {
+		jumps := s.noteProviderRateJumps(ctx, from, exchangeRates)
+		if err := s.repo.BulkCreateExchangeRates(ctx, exchangeRates); err != nil {
+			return nil, fmt.Errorf("failed to store provider rates: %w", err)
+		}
+		s.auditProviderRateJumps(ctx, jumps)
+		s.invalidateCacheForBase(from)
+		s.invalidateSupportedPairs()
+		s.publishRateChanges(exchangeRates...)
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("provider returned no rate for %s to %s", from, to)
+	}
+
+	s.cacheRate(target)
+	return target, nil
+}
+
 // GetBaseCurrency returns the configured base currency
 func (s *Service) GetBaseCurrency() string {
 	return s.baseCurrency
@@ -274,16 +817,61 @@ func (s *Service) GetAllRatesFromBase(ctx context.Context) ([]*ExchangeRate, err
 
 // CreateCurrency creates a new currency
 func (s *Service) CreateCurrency(ctx context.Context, currency *Currency) error {
-	return s.repo.CreateCurrency(ctx, currency)
+	defer s.invalidateCurrency(currency.Code)
+	defer s.invalidateSupportedPairs()
+	if err := s.repo.CreateCurrency(ctx, currency); err != nil {
+		return err
+	}
+
+	s.recordAdminChange(ctx, audit.Entry{
+		Action:     "currency.created",
+		TargetType: "currency",
+		TargetID:   currency.Code,
+		After:      currencyState(currency),
+	})
+	return nil
 }
 
 // UpdateCurrency updates a currency
 func (s *Service) UpdateCurrency(ctx context.Context, currency *Currency) error {
-	return s.repo.UpdateCurrency(ctx, currency)
+	defer s.invalidateCurrency(currency.Code)
+	defer s.invalidateSupportedPairs()
+
+	var before map[string]interface{}
+	if _, ok := audit.ActorFromContext(ctx); ok {
+		if current, err := s.repo.GetCurrencyByCode(ctx, currency.Code); err == nil && current != nil {
+			before = currencyState(current)
+		}
+	}
+	if err := s.repo.UpdateCurrency(ctx, currency); err != nil {
+		return err
+	}
+
+	s.recordAdminChange(ctx, audit.Entry{
+		Action:     "currency.updated",
+		TargetType: "currency",
+		TargetID:   currency.Code,
+		Before:     before,
+		After:      currencyState(currency),
+	})
+	return nil
+}
+
+// currencyState summarises a currency for its audit trail
+func currencyState(c *Currency) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           c.Name,
+		"symbol":         c.Symbol,
+		"decimal_places": c.DecimalPlaces,
+		"is_active":      c.IsActive,
+	}
 }
 
 // ValidateConversion validates that a conversion can be performed
-func (s *Service) ValidateConversion(ctx context.Context, from, to string) error {
+func (s *Service) ValidateConversion(ctx context.Context, from, to string, opts ...ConvertOption) error {
+	if err := s.requireActive(ctx, newConvertOptions(opts), from, to); err != nil {
+		return err
+	}
 	_, err := s.GetExchangeRate(ctx, from, to)
 	return err
 }
@@ -302,6 +890,57 @@ func (s *Service) invalidateCache(from, to string) {
 	delete(s.cache.rates, fmt.Sprintf("%s-%s", from, to))
 	delete(s.cache.rates, fmt.Sprintf("%s-%s", to, from))
 	s.cache.mu.Unlock()
+	s.observeCacheAge()
+}
+
+// lookupCurrency returns a currency from the currency cache, loading it from the
+// database when missing or expired. Failed lookups aren't cached.
+func (s *Service) lookupCurrency(ctx context.Context, code string) (*Currency, error) {
+	s.currencies.mu.RLock()
+	cached, ok := s.currencies.currencies[code]
+	s.currencies.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.currency, nil
+	}
+
+	currency, err := s.repo.GetCurrencyByCode(ctx, code)
+	if err != nil || currency == nil {
+		return currency, err
+	}
+
+	s.currencies.mu.Lock()
+	s.currencies.currencies[code] = &cachedCurrency{
+		currency:  currency,
+		expiresAt: time.Now().Add(s.currencies.ttl),
+	}
+	s.currencies.mu.Unlock()
+	return currency, nil
+}
+
+// requireActive returns ErrInactiveCurrency for the first of codes that is
+// inactive, unless the conversion allows inactive currencies. Currencies that
+// can't be found are left for the rate lookup to reject.
+func (s *Service) requireActive(ctx context.Context, options convertOptions, codes ...string) error {
+	if options.allowInactive {
+		return nil
+	}
+	for _, code := range codes {
+		currency, err := s.lookupCurrency(ctx, code)
+		if err != nil || currency == nil {
+			continue
+		}
+		if !currency.IsActive {
+			return fmt.Errorf("%w: %s", ErrInactiveCurrency, code)
+		}
+	}
+	return nil
+}
+
+// invalidateCurrency removes a currency from the currency cache
+func (s *Service) invalidateCurrency(code string) {
+	s.currencies.mu.Lock()
+	delete(s.currencies.currencies, code)
+	s.currencies.mu.Unlock()
 }
 
 // invalidateCacheForBase removes all cache entries involving a base currency
@@ -314,6 +953,7 @@ func (s *Service) invalidateCacheForBase(base string) {
 		}
 	}
 	s.cache.mu.Unlock()
+	s.observeCacheAge()
 }
 
 // ToCurrencyResponse converts Currency to API response
@@ -339,6 +979,7 @@ func ToExchangeRateResponse(r *ExchangeRate) *ExchangeRateResponse {
 		ToCurrency:   r.ToCurrency,
 		Rate:         r.Rate,
 		ValidUntil:   r.ValidUntil,
+		Pinned:       r.Pinned,
 	}
 }
 
@@ -2,11 +2,22 @@ package currency
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/richxcame/ride-hailing/pkg/audit/audittest"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -41,6 +52,14 @@ func (m *MockRepository) GetLatestExchangeRate(ctx context.Context, fromCurrency
 	return args.Get(0).(*ExchangeRate), args.Error(1)
 }
 
+func (m *MockRepository) GetExchangeRateAt(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*ExchangeRate, error) {
+	args := m.Called(ctx, fromCurrency, toCurrency, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ExchangeRate), args.Error(1)
+}
+
 func (m *MockRepository) GetExchangeRateByID(ctx context.Context, id uuid.UUID) (*ExchangeRate, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -67,11 +86,24 @@ func (m *MockRepository) GetAllExchangeRatesFromBase(ctx context.Context, baseCu
 	return args.Get(0).([]*ExchangeRate), args.Error(1)
 }
 
+func (m *MockRepository) GetLatestExchangeRates(ctx context.Context) ([]*ExchangeRate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*ExchangeRate), args.Error(1)
+}
+
 func (m *MockRepository) InvalidateExchangeRates(ctx context.Context, fromCurrency string) error {
 	args := m.Called(ctx, fromCurrency)
 	return args.Error(0)
 }
 
+func (m *MockRepository) UnpinExchangeRates(ctx context.Context, fromCurrency, toCurrency string, keep uuid.UUID) error {
+	args := m.Called(ctx, fromCurrency, toCurrency, keep)
+	return args.Error(0)
+}
+
 func (m *MockRepository) CreateCurrency(ctx context.Context, curr *Currency) error {
 	args := m.Called(ctx, curr)
 	return args.Error(0)
@@ -242,6 +274,8 @@ func TestGetExchangeRate_NoRateFound(t *testing.T) {
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyGBP, CurrencyEUR).Return(nil, errors.New("not found"))
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, errors.New("not found"))
+	// Multi-hop search finds no stored pairs either
+	mockRepo.On("GetActiveCurrencies", ctx).Return([]*Currency{}, nil)
 
 	rate, err := service.GetExchangeRate(ctx, CurrencyEUR, CurrencyGBP)
 
@@ -328,6 +362,7 @@ func TestConvert_SameCurrency(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	result, err := service.Convert(ctx, 100.50, CurrencyUSD, CurrencyUSD)
 
@@ -343,6 +378,7 @@ func TestConvert_DifferentCurrency(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -356,6 +392,7 @@ func TestConvert_DifferentCurrency(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -420,6 +457,7 @@ func TestConvert_PrecisionRounding(t *testing.T) {
 			mockRepo := new(MockRepository)
 			service := NewService(mockRepo, CurrencyUSD)
 			ctx := context.Background()
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 			rate := &ExchangeRate{
 				ID:           uuid.New(),
@@ -433,6 +471,7 @@ func TestConvert_PrecisionRounding(t *testing.T) {
 			toCurrency := &Currency{
 				Code:          CurrencyEUR,
 				DecimalPlaces: tt.decimalPlaces,
+				IsActive:      true,
 			}
 
 			mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -450,6 +489,7 @@ func TestConvert_CurrencyNotFound_DefaultsTo2DecimalPlaces(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -472,8 +512,11 @@ func TestConvert_CurrencyNotFound_DefaultsTo2DecimalPlaces(t *testing.T) {
 
 func TestConvert_RateNotFound(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(nil, errors.New("not found"))
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, errors.New("not found"))
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
@@ -488,6 +531,7 @@ func TestConvert_ZeroAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -501,6 +545,7 @@ func TestConvert_ZeroAmount(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -516,6 +561,7 @@ func TestConvert_NegativeAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -529,6 +575,7 @@ func TestConvert_NegativeAmount(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -548,6 +595,7 @@ func TestConvertToBase(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -561,6 +609,7 @@ func TestConvertToBase(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyUSD,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(rate, nil)
@@ -578,6 +627,7 @@ func TestConvertFromBase(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -591,6 +641,7 @@ func TestConvertFromBase(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -604,6 +655,235 @@ func TestConvertFromBase(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// =============================================================================
+// Test ConvertWithRate
+// =============================================================================
+
+func TestConvertWithRate_MatchesConvert(t *testing.T) {
+	tests := []struct {
+		name          string
+		amount        float64
+		rate          float64
+		decimalPlaces int
+	}{
+		{name: "round down", amount: 100.00, rate: 0.333333, decimalPlaces: 2},
+		{name: "round up", amount: 100.00, rate: 0.666666, decimalPlaces: 2},
+		{name: "zero decimal places", amount: 100.50, rate: 110.5, decimalPlaces: 0},
+		{name: "3 decimal places", amount: 19.99, rate: 0.12345, decimalPlaces: 3},
+		{name: "boundary value", amount: 100.00, rate: 0.125, decimalPlaces: 0},
+		{name: "negative amount", amount: -42.15, rate: 1.0873, decimalPlaces: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewService(mockRepo, CurrencyUSD)
+			ctx := context.Background()
+
+			rate := &ExchangeRate{
+				ID:           uuid.New(),
+				FromCurrency: CurrencyUSD,
+				ToCurrency:   CurrencyEUR,
+				Rate:         tt.rate,
+				InverseRate:  1.0 / tt.rate,
+				ValidUntil:   time.Now().Add(1 * time.Hour),
+			}
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: tt.decimalPlaces, IsActive: true}, nil)
+			mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
+
+			converted, err := service.Convert(ctx, tt.amount, CurrencyUSD, CurrencyEUR)
+			require.NoError(t, err)
+
+			// Replay from the stored rate on a service with no repository behind it
+			replayed, err := NewService(new(MockRepository), CurrencyUSD).
+				ConvertWithRate(converted.Original, rate, tt.decimalPlaces)
+			require.NoError(t, err)
+
+			assert.Equal(t, converted.Original, replayed.Original)
+			assert.Equal(t, converted.Converted, replayed.Converted)
+			assert.Equal(t, converted.ExchangeRate, replayed.ExchangeRate)
+			assert.Equal(t, converted.ExchangeRateID, replayed.ExchangeRateID)
+			assert.Equal(t, converted.RoundingMode, replayed.RoundingMode)
+		})
+	}
+}
+
+func TestConvertWithRate_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	amount := NewMoney(100, CurrencyUSD, 2)
+	rate := func(from string, value float64) *ExchangeRate {
+		return &ExchangeRate{FromCurrency: from, ToCurrency: CurrencyEUR, Rate: value}
+	}
+
+	_, err := service.ConvertWithRate(amount, nil, 2)
+	assert.Error(t, err)
+
+	_, err = service.ConvertWithRate(amount, rate(CurrencyUSD, 0), 2)
+	assert.ErrorContains(t, err, "positive")
+
+	_, err = service.ConvertWithRate(amount, rate(CurrencyUSD, -0.85), 2)
+	assert.ErrorContains(t, err, "positive")
+
+	_, err = service.ConvertWithRate(amount, rate(CurrencyGBP, 0.85), 2)
+	assert.ErrorContains(t, err, "converts from GBP, not USD")
+
+	_, err = service.ConvertWithRate(amount, rate(CurrencyUSD, 0.85), -1)
+	assert.Error(t, err)
+
+	result, err := service.ConvertWithRate(amount, rate(CurrencyUSD, 0.85), 2)
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(85, CurrencyEUR, 2), result.Converted)
+	mockRepo.AssertExpectations(t)
+}
+
+// =============================================================================
+// Test ConvertBatch
+// =============================================================================
+
+func newBatchTestService(t *testing.T, rate float64, toDecimals int) (*Service, *MockRepository) {
+	t.Helper()
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: toDecimals, IsActive: true}, nil).Once()
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil).Once()
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, CurrencyUSD, CurrencyEUR).Return(&ExchangeRate{
+		ID:           uuid.New(),
+		FromCurrency: CurrencyUSD,
+		ToCurrency:   CurrencyEUR,
+		Rate:         rate,
+		InverseRate:  1.0 / rate,
+		ValidUntil:   time.Now().Add(time.Hour),
+	}, nil).Once()
+	return service, mockRepo
+}
+
+func TestConvertBatch_ResolvesRateAndDecimalsOnce(t *testing.T) {
+	service, mockRepo := newBatchTestService(t, 0.85, 2)
+
+	items := []Money{
+		NewMoney(24.60, CurrencyUSD, 2), // Fare
+		NewMoney(5.00, CurrencyUSD, 2),  // Tip
+		NewMoney(3.25, CurrencyUSD, 2),  // Tolls
+		NewMoney(1.99, CurrencyUSD, 2),  // Booking fee
+	}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.NoError(t, err)
+	require.Len(t, result.Items, 4)
+	assert.Equal(t, int64(2091), result.Items[0].Converted.AmountMinor) // 20.91
+	assert.Equal(t, int64(425), result.Items[1].Converted.AmountMinor)
+	assert.Equal(t, int64(276), result.Items[2].Converted.AmountMinor) // 2.7625
+	assert.Equal(t, int64(169), result.Items[3].Converted.AmountMinor) // 1.6915
+	assert.Equal(t, int64(2091+425+276+169), result.Total.AmountMinor)
+	assert.Equal(t, CurrencyEUR, result.Total.Currency)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestConvertBatch_TotalIsSumOfRoundedItems(t *testing.T) {
+	// Each 0.01 converts to 0.005 and rounds up to 0.01, while converting the
+	// 0.03 sum gives 0.015 → 0.02. The total follows the line items.
+	service, _ := newBatchTestService(t, 0.5, 2)
+
+	items := []Money{
+		NewMoney(0.01, CurrencyUSD, 2),
+		NewMoney(0.01, CurrencyUSD, 2),
+		NewMoney(0.01, CurrencyUSD, 2),
+	}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.NoError(t, err)
+	var sum int64
+	for _, item := range result.Items {
+		assert.Equal(t, int64(1), item.Converted.AmountMinor)
+		sum += item.Converted.AmountMinor
+	}
+	assert.Equal(t, sum, result.Total.AmountMinor)
+	assert.Equal(t, 0.03, result.Total.Amount)
+}
+
+func TestConvertBatch_ZeroDecimalTarget(t *testing.T) {
+	service, _ := newBatchTestService(t, 0.5, 0)
+
+	items := []Money{
+		NewMoney(1.01, CurrencyUSD, 2), // 0.505 → 1
+		NewMoney(1.01, CurrencyUSD, 2), // 0.505 → 1
+		NewMoney(0.99, CurrencyUSD, 2), // 0.495 → 0
+	}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Items[0].Converted.AmountMinor)
+	assert.Equal(t, int64(0), result.Items[2].Converted.AmountMinor)
+	assert.Equal(t, int64(2), result.Total.AmountMinor)
+	assert.Equal(t, 0, result.Total.DecimalPlaces)
+}
+
+func TestConvertBatch_NegativeItemsOffset(t *testing.T) {
+	service, _ := newBatchTestService(t, 0.85, 2)
+
+	items := []Money{
+		NewMoney(10.00, CurrencyUSD, 2),
+		NewMoney(-2.50, CurrencyUSD, 2), // Promo discount
+	}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(-213), result.Items[1].Converted.AmountMinor) // -2.125 rounds away from zero
+	assert.Equal(t, int64(850-213), result.Total.AmountMinor)
+}
+
+func TestConvertBatch_SameCurrencyItemsPassThrough(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil).Once()
+
+	items := []Money{NewMoney(12.34, CurrencyEUR, 2), NewMoney(0.66, CurrencyEUR, 2)}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, result.Items[0].ExchangeRate)
+	assert.Equal(t, items[0], result.Items[0].Converted)
+	assert.Equal(t, int64(1300), result.Total.AmountMinor)
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConvertBatch_Empty(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil)
+
+	result, err := service.ConvertBatch(context.Background(), nil, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+	assert.Equal(t, int64(0), result.Total.AmountMinor)
+	assert.Equal(t, CurrencyEUR, result.Total.Currency)
+}
+
+func TestConvertBatch_RateErrorNamesItem(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyGBP).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil)
+
+	items := []Money{NewMoney(1, CurrencyEUR, 2), NewMoney(1, CurrencyGBP, 2)}
+
+	result, err := service.ConvertBatch(context.Background(), items, CurrencyEUR)
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "item 1")
+}
+
 // =============================================================================
 // Test SetExchangeRate
 // =============================================================================
@@ -613,13 +893,14 @@ func TestSetExchangeRate_Success(t *testing.T) {
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
-	toCurrency := &Currency{Code: CurrencyEUR}
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
+	toCurrency := &Currency{Code: CurrencyEUR, IsActive: true}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(toCurrency, nil)
 	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil)
 
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, mock.Anything, mock.Anything).Return(nil)
 	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.85, 24*time.Hour)
 
 	require.NoError(t, err)
@@ -667,7 +948,7 @@ func TestSetExchangeRate_ToCurrencyNotFound(t *testing.T) {
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, "XXX").Return(nil, errors.New("not found"))
@@ -683,8 +964,8 @@ func TestSetExchangeRate_CreateError(t *testing.T) {
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
-	toCurrency := &Currency{Code: CurrencyEUR}
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
+	toCurrency := &Currency{Code: CurrencyEUR, IsActive: true}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(toCurrency, nil)
@@ -710,13 +991,14 @@ func TestSetExchangeRate_InvalidatesCache(t *testing.T) {
 	}
 	service.cacheRate(cachedRate)
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
-	toCurrency := &Currency{Code: CurrencyEUR}
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
+	toCurrency := &Currency{Code: CurrencyEUR, IsActive: true}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(toCurrency, nil)
 	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil)
 
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, mock.Anything, mock.Anything).Return(nil)
 	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.85, 24*time.Hour)
 	require.NoError(t, err)
 
@@ -727,99 +1009,433 @@ func TestSetExchangeRate_InvalidatesCache(t *testing.T) {
 	assert.False(t, exists, "Cache should be invalidated after SetExchangeRate")
 }
 
-// =============================================================================
-// Test BulkSetExchangeRates
-// =============================================================================
-
-func TestBulkSetExchangeRates_Success(t *testing.T) {
+func setupRateGuardrail(t *testing.T, previousRate float64) (*Service, *MockRepository, context.Context) {
+	t.Helper()
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
+	service.SetMaxRateChange(20)
 	ctx := context.Background()
 
-	rates := map[string]float64{
-		CurrencyEUR: 0.85,
-		CurrencyGBP: 0.75,
-		CurrencyTMT: 3.50,
-	}
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, IsActive: true}, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(&ExchangeRate{
+		FromCurrency: CurrencyUSD,
+		ToCurrency:   CurrencyEUR,
+		Rate:         previousRate,
+	}, nil)
+	return service, mockRepo, ctx
+}
 
-	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(nil)
+func TestSetExchangeRate_WithinGuardrail(t *testing.T) {
+	service, mockRepo, ctx := setupRateGuardrail(t, 0.85)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil).Once()
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyEUR, mock.Anything).Return(nil).Once()
 
-	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.95, 24*time.Hour)
 
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestBulkSetExchangeRates_SkipsBaseCurrency(t *testing.T) {
+func TestSetExchangeRate_OverGuardrailRejected(t *testing.T) {
+	service, mockRepo, ctx := setupRateGuardrail(t, 0.85)
+
+	// 0.85 typed as 85
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 85, 24*time.Hour)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRateChangeTooLarge)
+	assert.Contains(t, err.Error(), "from 0.85 to 85")
+	assert.Contains(t, err.Error(), "9900.0% change")
+	mockRepo.AssertNotCalled(t, "CreateExchangeRate", mock.Anything, mock.Anything)
+}
+
+func TestSetExchangeRate_ForcedPastGuardrail(t *testing.T) {
+	service, mockRepo, ctx := setupRateGuardrail(t, 0.85)
+	mockRepo.On("CreateExchangeRate", ctx, mock.MatchedBy(func(r *ExchangeRate) bool {
+		return r.Rate == 1.7
+	})).Return(nil).Once()
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyEUR, mock.Anything).Return(nil).Once()
+
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 1.7, 24*time.Hour, ForceRateChange())
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetExchangeRate_GuardrailSkipsPairWithoutRate(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
+	service.SetMaxRateChange(20)
 	ctx := context.Background()
 
-	rates := map[string]float64{
-		CurrencyUSD: 1.00, // Should be skipped
-		CurrencyEUR: 0.85,
-	}
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, IsActive: true}, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, pgx.ErrNoRows)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil).Once()
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyEUR, mock.Anything).Return(nil).Once()
+
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 85, 24*time.Hour)
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_OverGuardrailSetsNothing(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	service.SetMaxRateChange(20)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyGBP, CurrencyUSD)
+
+	mockRepo.On("GetAllExchangeRatesFromBase", ctx, CurrencyUSD).Return([]*ExchangeRate{
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyEUR, Rate: 0.85},
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyGBP, Rate: 0.75},
+	}, nil)
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{
+		CurrencyEUR: 0.86,
+		CurrencyGBP: 75,
+	}, 24*time.Hour)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRateChangeTooLarge)
+	assert.Contains(t, err.Error(), "USD/GBP")
+	assert.NotContains(t, err.Error(), "USD/EUR")
+	mockRepo.AssertNotCalled(t, "BulkCreateExchangeRates", mock.Anything, mock.Anything)
+}
+
+func TestSetExchangeRate_SyncInverseRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, IsActive: true}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.MatchedBy(func(r *ExchangeRate) bool {
+		return r.FromCurrency == CurrencyUSD && r.Rate == 0.8
+	})).Return(nil).Once()
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyEUR, mock.Anything).Return(nil).Once()
+	mockRepo.On("CreateExchangeRate", ctx, mock.MatchedBy(func(r *ExchangeRate) bool {
+		return r.FromCurrency == CurrencyEUR && r.ToCurrency == CurrencyUSD && r.Rate == 1.25 && r.Pinned
+	})).Return(nil).Once()
+
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.8, 24*time.Hour, PinRate(), SyncInverseRate())
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_SyncInverseRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
 
 	mockRepo.On("BulkCreateExchangeRates", ctx, mock.MatchedBy(func(rates []*ExchangeRate) bool {
-		// Should only have 1 rate (EUR), USD should be skipped
-		return len(rates) == 1 && rates[0].ToCurrency == CurrencyEUR
+		return len(rates) == 2 &&
+			rates[0].FromCurrency == CurrencyUSD && rates[0].Rate == 0.8 &&
+			rates[1].FromCurrency == CurrencyEUR && rates[1].Rate == 1.25
 	})).Return(nil)
 
-	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{CurrencyEUR: 0.8}, 24*time.Hour, SyncInverseRate())
 
 	require.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestBulkSetExchangeRates_Error(t *testing.T) {
+func TestReconcileInverses_FixesSkewedPair(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	now := time.Now()
 
-	rates := map[string]float64{
-		CurrencyEUR: 0.85,
-	}
+	mockRepo.On("GetLatestExchangeRates", ctx).Return([]*ExchangeRate{
+		{FromCurrency: CurrencyEUR, ToCurrency: CurrencyUSD, Rate: 1.30, FetchedAt: now.Add(-time.Hour)}, // Stale and skewed
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyEUR, Rate: 0.80, FetchedAt: now},
+		{FromCurrency: CurrencyGBP, ToCurrency: CurrencyUSD, Rate: 1.25, FetchedAt: now},
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyGBP, Rate: 0.80, FetchedAt: now},
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyTMT, Rate: 3.50, FetchedAt: now}, // No reverse to compare with
+	}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.MatchedBy(func(r *ExchangeRate) bool {
+		return r.FromCurrency == CurrencyEUR && r.ToCurrency == CurrencyUSD && r.Rate == 1.25
+	})).Return(nil).Once()
 
-	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(errors.New("db error"))
+	result, err := service.ReconcileInverses(ctx)
 
-	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.PairsChecked)
+	require.Len(t, result.Mismatches, 1)
+	mismatch := result.Mismatches[0]
+	assert.Equal(t, CurrencyEUR, mismatch.FromCurrency)
+	assert.Equal(t, CurrencyUSD, mismatch.ToCurrency)
+	assert.InDelta(t, 0.04, mismatch.Deviation, 1e-9)
+	assert.Equal(t, "USD/EUR", mismatch.Kept)
+	assert.True(t, mismatch.Fixed)
+	mockRepo.AssertExpectations(t)
+}
 
-	assert.Error(t, err)
+func TestReconcileInverses_KeepsPinnedRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	now := time.Now()
+
+	mockRepo.On("GetLatestExchangeRates", ctx).Return([]*ExchangeRate{
+		{FromCurrency: CurrencyEUR, ToCurrency: CurrencyUSD, Rate: 1.30, FetchedAt: now.Add(-time.Hour), Pinned: true},
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyEUR, Rate: 0.80, FetchedAt: now},
+	}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.MatchedBy(func(r *ExchangeRate) bool {
+		return r.FromCurrency == CurrencyUSD && r.ToCurrency == CurrencyEUR && math.Abs(r.Rate*1.30-1) < 1e-12 && r.Pinned
+	})).Return(nil).Once()
+
+	result, err := service.ReconcileInverses(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, result.Mismatches, 1)
+	assert.Equal(t, "EUR/USD", result.Mismatches[0].Kept)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestBulkSetExchangeRates_InvalidatesCacheForBase(t *testing.T) {
+func TestReconcileInverses_ReportsFailedFix(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	// Pre-populate cache with USD-based rates
-	service.cacheRate(&ExchangeRate{
-		FromCurrency: CurrencyUSD,
-		ToCurrency:   CurrencyEUR,
-		Rate:         0.80,
-		ValidUntil:   time.Now().Add(1 * time.Hour),
-	})
+	mockRepo.On("GetLatestExchangeRates", ctx).Return([]*ExchangeRate{
+		{FromCurrency: CurrencyEUR, ToCurrency: CurrencyUSD, Rate: 1.30},
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyEUR, Rate: 0.80},
+	}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(errors.New("db error"))
 
-	rates := map[string]float64{
-		CurrencyEUR: 0.85,
+	result, err := service.ReconcileInverses(ctx)
+
+	require.Error(t, err)
+	require.NotNil(t, result)
+	require.Len(t, result.Mismatches, 1)
+	assert.False(t, result.Mismatches[0].Fixed)
+}
+
+// =============================================================================
+// Test ExportRates / ImportRates
+// =============================================================================
+
+func TestExportImportRates_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	pinned := testRate(CurrencyEUR, CurrencyTRY, 35, -48*time.Hour)
+	pinned.Pinned = true
+	stored := []*ExchangeRate{
+		testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour),
+		pinned,
+		testRate(CurrencyUSD, CurrencyGBP, 0.8, -time.Hour), // Expired, not exported
 	}
 
-	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(nil)
+	source := new(MockRepository)
+	source.On("GetLatestExchangeRates", ctx).Return(stored, nil)
+	exported, err := NewService(source, CurrencyUSD).ExportRates(ctx)
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	assert.Equal(t, CurrencyEUR, exported[0].From, "sorted by pair")
+	assert.True(t, exported[0].Pinned)
+
+	// Load into an empty environment and read the rates back
+	var imported []*ExchangeRate
+	target := new(MockRepository)
+	target.On("GetLatestExchangeRates", ctx).Return([]*ExchangeRate{}, nil).Once()
+	target.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
+	target.On("BulkCreateExchangeRates", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		imported = args.Get(1).([]*ExchangeRate)
+	}).Return(nil)
+	service := NewService(target, CurrencyUSD)
+
+	result, err := service.ImportRates(ctx, exported, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EUR/TRY", "USD/EUR"}, result.Applied)
+	assert.Empty(t, result.Skipped)
 
-	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+	target.On("GetLatestExchangeRates", ctx).Return(imported, nil)
+	reexported, err := service.ExportRates(ctx)
 	require.NoError(t, err)
+	assert.Equal(t, exported, reexported)
+}
 
-	// Verify cache was invalidated
-	service.cache.mu.RLock()
-	_, exists := service.cache.rates["USD-EUR"]
-	service.cache.mu.RUnlock()
-	assert.False(t, exists, "Cache should be invalidated after BulkSetExchangeRates")
+func TestImportRates_SkipsInvalidAndExisting(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	validUntil := time.Now().Add(time.Hour)
+
+	mockRepo.On("GetLatestExchangeRates", ctx).Return([]*ExchangeRate{
+		testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour),
+	}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, "XXX").Return(nil, pgx.ErrNoRows)
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.MatchedBy(func(rates []*ExchangeRate) bool {
+		return len(rates) == 1 && rates[0].FromCurrency == CurrencyUSD && rates[0].ToCurrency == CurrencyGBP
+	})).Return(nil).Once()
+
+	result, err := service.ImportRates(ctx, []RateExport{
+		{From: CurrencyUSD, To: CurrencyEUR, Rate: 0.95, ValidUntil: validUntil},
+		{From: CurrencyUSD, To: CurrencyGBP, Rate: 0.8, ValidUntil: validUntil},
+		{From: CurrencyUSD, To: CurrencyGBP, Rate: 0.7, ValidUntil: validUntil},
+		{From: CurrencyUSD, To: CurrencyTMT, Rate: 0, ValidUntil: validUntil},
+		{From: CurrencyUSD, To: CurrencyTRY, Rate: 30, ValidUntil: time.Now().Add(-time.Hour)},
+		{From: CurrencyUSD, To: "XXX", Rate: 2, ValidUntil: validUntil},
+	}, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD/GBP"}, result.Applied)
+	assert.Equal(t, []SkippedRate{
+		{Pair: "USD/EUR", Reason: "rate already set"},
+		{Pair: "USD/GBP", Reason: "duplicate pair"},
+		{Pair: "USD/TMT", Reason: "rate must be positive"},
+		{Pair: "USD/TRY", Reason: "rate has expired"},
+		{Pair: "USD/XXX", Reason: "currency XXX not found"},
+	}, result.Skipped)
+	mockRepo.AssertExpectations(t)
 }
 
-// =============================================================================
-// Test FormatMoney
-// =============================================================================
+func TestImportRates_OverwriteReplacesAndInvalidatesCache(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	service.cacheRate(testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour))
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(nil).Once()
+
+	result, err := service.ImportRates(ctx, []RateExport{
+		{From: CurrencyUSD, To: CurrencyEUR, Rate: 0.95, ValidUntil: time.Now().Add(time.Hour)},
+	}, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD/EUR"}, result.Applied)
+	assert.NotContains(t, service.cache.rates, "USD-EUR")
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRates", ctx)
+}
+
+// =============================================================================
+// Test BulkSetExchangeRates
+// =============================================================================
+
+func TestBulkSetExchangeRates_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyGBP, CurrencyTMT, CurrencyUSD)
+
+	rates := map[string]float64{
+		CurrencyEUR: 0.85,
+		CurrencyGBP: 0.75,
+		CurrencyTMT: 3.50,
+	}
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(nil)
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_SkipsBaseCurrency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
+
+	rates := map[string]float64{
+		CurrencyUSD: 1.00, // Should be skipped
+		CurrencyEUR: 0.85,
+	}
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.MatchedBy(func(rates []*ExchangeRate) bool {
+		// Should only have 1 rate (EUR), USD should be skipped
+		return len(rates) == 1 && rates[0].ToCurrency == CurrencyEUR
+	})).Return(nil)
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_Error(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
+
+	rates := map[string]float64{
+		CurrencyEUR: 0.85,
+	}
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(errors.New("db error"))
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_RefusesInvalidRatesAndInactiveCurrencies(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyUSD, CurrencyEUR)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTMT).Return(&Currency{Code: CurrencyTMT, IsActive: false}, nil)
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{
+		CurrencyEUR: -0.85,
+		CurrencyTMT: 3.5,
+	}, 24*time.Hour)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInactiveCurrency)
+	assert.Contains(t, err.Error(), "USD/EUR: rate must be positive")
+	mockRepo.AssertNotCalled(t, "BulkCreateExchangeRates", mock.Anything, mock.Anything)
+
+	// Admins reconciling back-dated records may still set rates for TMT
+	mockRepo.On("BulkCreateExchangeRates", mock.Anything, mock.MatchedBy(func(rates []*ExchangeRate) bool {
+		return len(rates) == 1 && rates[0].ToCurrency == CurrencyTMT
+	})).Return(nil).Once()
+	require.NoError(t, service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{CurrencyTMT: 3.5}, 24*time.Hour, AllowInactiveCurrencies()))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkSetExchangeRates_InvalidatesCacheForBase(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
+
+	// Pre-populate cache with USD-based rates
+	service.cacheRate(&ExchangeRate{
+		FromCurrency: CurrencyUSD,
+		ToCurrency:   CurrencyEUR,
+		Rate:         0.80,
+		ValidUntil:   time.Now().Add(1 * time.Hour),
+	})
+
+	rates := map[string]float64{
+		CurrencyEUR: 0.85,
+	}
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.AnythingOfType("[]*currency.ExchangeRate")).Return(nil)
+
+	err := service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, 24*time.Hour)
+	require.NoError(t, err)
+
+	// Verify cache was invalidated
+	service.cache.mu.RLock()
+	_, exists := service.cache.rates["USD-EUR"]
+	service.cache.mu.RUnlock()
+	assert.False(t, exists, "Cache should be invalidated after BulkSetExchangeRates")
+}
+
+// =============================================================================
+// Test FormatMoney
+// =============================================================================
 
 func TestFormatMoney_Success(t *testing.T) {
 	tests := []struct {
@@ -835,6 +1451,7 @@ func TestFormatMoney_Success(t *testing.T) {
 				Code:          CurrencyUSD,
 				Symbol:        "$",
 				DecimalPlaces: 2,
+				IsActive:      true,
 			},
 			expected: "$100.50",
 		},
@@ -845,6 +1462,7 @@ func TestFormatMoney_Success(t *testing.T) {
 				Code:          CurrencyEUR,
 				Symbol:        "E",
 				DecimalPlaces: 2,
+				IsActive:      true,
 			},
 			expected: "E99.99",
 		},
@@ -855,6 +1473,7 @@ func TestFormatMoney_Success(t *testing.T) {
 				Code:          "JPY",
 				Symbol:        "Y",
 				DecimalPlaces: 0,
+				IsActive:      true,
 			},
 			expected: "Y1000",
 		},
@@ -891,6 +1510,61 @@ func TestFormatMoney_CurrencyNotFound_FallbackFormat(t *testing.T) {
 	assert.Equal(t, "100.50 XYZ", formatted)
 }
 
+func TestFormatMoneyLocale(t *testing.T) {
+	usd := &Currency{Code: CurrencyUSD, Symbol: "$", DecimalPlaces: 2, IsActive: true}
+	eur := &Currency{Code: CurrencyEUR, Symbol: "\u20ac", DecimalPlaces: 2, IsActive: true}
+	jpy := &Currency{Code: "JPY", Symbol: "\u00a5", DecimalPlaces: 0, IsActive: true}
+
+	tests := []struct {
+		name     string
+		money    Money
+		currency *Currency
+		locale   string
+		expected string
+	}{
+		{"USD en-US groups thousands", Money{Amount: 1234567.891, Currency: CurrencyUSD}, usd, "en-US", "$1,234,567.89"},
+		{"JPY en-US has no decimals", Money{Amount: 1234567.6, Currency: "JPY"}, jpy, "en-US", "\u00a51,234,568"},
+		{"JPY ja-JP has no decimals", Money{Amount: 980, Currency: "JPY"}, jpy, "ja-JP", "\u00a5980"},
+		{"JPY de-DE has no decimal separator", Money{Amount: 1500, Currency: "JPY"}, jpy, "de-DE", "1.500\u00a0\u00a5"},
+		{"EUR de-DE uses comma decimals", Money{Amount: 1234.5, Currency: CurrencyEUR}, eur, "de-DE", "1.234,50\u00a0\u20ac"},
+		{"EUR nl-NL places symbol first", Money{Amount: 1234.5, Currency: CurrencyEUR}, eur, "nl-NL", "\u20ac\u00a01.234,50"},
+		{"EUR fr-FR places symbol after", Money{Amount: 1234.5, Currency: CurrencyEUR}, eur, "fr-FR", "1\u202f234,50\u00a0\u20ac"},
+		{"underscore locale and region fallback", Money{Amount: 99.99, Currency: CurrencyEUR}, eur, "de_AT", "99,99\u00a0\u20ac"},
+		{"negative amount", Money{Amount: -1234.5, Currency: CurrencyEUR}, eur, "de-DE", "-1.234,50\u00a0\u20ac"},
+		{"rounds to zero without sign", Money{Amount: -0.001, Currency: CurrencyUSD}, usd, "en-US", "$0.00"},
+		{"unknown locale uses FormatMoney", Money{Amount: 1234.5, Currency: CurrencyUSD}, usd, "xx-XX", "$1234.50"},
+		{"empty locale uses FormatMoney", Money{Amount: 100.5, Currency: CurrencyUSD}, usd, "", "$100.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewService(mockRepo, CurrencyUSD)
+			ctx := context.Background()
+
+			mockRepo.On("GetCurrencyByCode", ctx, tt.money.Currency).Return(tt.currency, nil)
+
+			formatted, err := service.FormatMoneyLocale(ctx, tt.money, tt.locale)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, formatted)
+		})
+	}
+}
+
+func TestFormatMoneyLocale_CurrencyNotFound_FallbackFormat(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, "XYZ").Return(nil, errors.New("not found"))
+
+	formatted, err := service.FormatMoneyLocale(ctx, Money{Amount: 100.50, Currency: "XYZ"}, "de-DE")
+
+	require.NoError(t, err)
+	assert.Equal(t, "100.50 XYZ", formatted)
+}
+
 // =============================================================================
 // Test Cache Management
 // =============================================================================
@@ -1025,9 +1699,10 @@ func TestGetCurrency(t *testing.T) {
 	ctx := context.Background()
 
 	currency := &Currency{
-		Code:   CurrencyUSD,
-		Name:   "US Dollar",
-		Symbol: "$",
+		Code:     CurrencyUSD,
+		Name:     "US Dollar",
+		Symbol:   "$",
+		IsActive: true,
 	}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(currency, nil)
@@ -1125,6 +1800,7 @@ func TestValidateConversion_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		FromCurrency: CurrencyUSD,
@@ -1143,8 +1819,10 @@ func TestValidateConversion_Success(t *testing.T) {
 
 func TestValidateConversion_Error(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, errors.New("not found"))
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
@@ -1165,6 +1843,7 @@ func TestToCurrencyResponse(t *testing.T) {
 			Name:          "US Dollar",
 			Symbol:        "$",
 			DecimalPlaces: 2,
+			IsActive:      true,
 		}
 
 		response := ToCurrencyResponse(currency)
@@ -1297,6 +1976,7 @@ func TestConvert_FinancialPrecision_BoundaryValues(t *testing.T) {
 			mockRepo := new(MockRepository)
 			service := NewService(mockRepo, CurrencyUSD)
 			ctx := context.Background()
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 			rate := &ExchangeRate{
 				ID:           uuid.New(),
@@ -1310,6 +1990,7 @@ func TestConvert_FinancialPrecision_BoundaryValues(t *testing.T) {
 			toCurrency := &Currency{
 				Code:          CurrencyEUR,
 				DecimalPlaces: tt.decimalPlaces,
+				IsActive:      true,
 			}
 
 			mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -1580,6 +2261,7 @@ func TestGetExchangeRate_TriangulationValidUntilUsesMin(t *testing.T) {
 
 func TestGetExchangeRate_DirectToBase_NoTriangulation(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
@@ -1597,6 +2279,7 @@ func TestGetExchangeRate_DirectToBase_NoTriangulation(t *testing.T) {
 
 func TestGetExchangeRate_BaseToTarget_NoTriangulation(t *testing.T) {
 	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
@@ -1620,6 +2303,7 @@ func TestConvert_VeryLargeAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -1633,6 +2317,7 @@ func TestConvert_VeryLargeAmount(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -1649,6 +2334,7 @@ func TestConvert_VerySmallAmount(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -1662,6 +2348,7 @@ func TestConvert_VerySmallAmount(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -1723,6 +2410,7 @@ func TestConvert_PrecisionWithDifferentDecimalPlaces(t *testing.T) {
 			mockRepo := new(MockRepository)
 			service := NewService(mockRepo, CurrencyUSD)
 			ctx := context.Background()
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 			rate := &ExchangeRate{
 				ID:           uuid.New(),
@@ -1736,6 +2424,7 @@ func TestConvert_PrecisionWithDifferentDecimalPlaces(t *testing.T) {
 			toCurrency := &Currency{
 				Code:          tt.targetCurrency,
 				DecimalPlaces: tt.decimalPlaces,
+				IsActive:      true,
 			}
 
 			mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, tt.targetCurrency).Return(rate, nil)
@@ -1754,6 +2443,7 @@ func TestConvert_RoundingConsistency(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rate := &ExchangeRate{
 		ID:           uuid.New(),
@@ -1767,6 +2457,7 @@ func TestConvert_RoundingConsistency(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -1896,6 +2587,7 @@ func TestConvert_ResultContainsCorrectMetadata(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
 
 	rateID := uuid.New()
 	rate := &ExchangeRate{
@@ -1910,6 +2602,7 @@ func TestConvert_ResultContainsCorrectMetadata(t *testing.T) {
 	toCurrency := &Currency{
 		Code:          CurrencyEUR,
 		DecimalPlaces: 2,
+		IsActive:      true,
 	}
 
 	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(rate, nil)
@@ -1946,6 +2639,7 @@ func TestBulkSetExchangeRates_VerifiesRateValues(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyGBP, CurrencyTMT, CurrencyUSD)
 
 	rates := map[string]float64{
 		CurrencyEUR: 0.85,
@@ -1983,6 +2677,7 @@ func TestBulkSetExchangeRates_ValidUntilSet(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
 
 	rates := map[string]float64{
 		CurrencyEUR: 0.85,
@@ -2017,8 +2712,8 @@ func TestSetExchangeRate_VerySmallRate(t *testing.T) {
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
-	toCurrency := &Currency{Code: CurrencyEUR}
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
+	toCurrency := &Currency{Code: CurrencyEUR, IsActive: true}
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(toCurrency, nil)
@@ -2026,6 +2721,7 @@ func TestSetExchangeRate_VerySmallRate(t *testing.T) {
 		return r.Rate == 0.000001 && r.InverseRate == 1.0/0.000001
 	})).Return(nil)
 
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, mock.Anything, mock.Anything).Return(nil)
 	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.000001, 24*time.Hour)
 
 	require.NoError(t, err)
@@ -2037,8 +2733,8 @@ func TestSetExchangeRate_VeryLargeRate(t *testing.T) {
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
 
-	fromCurrency := &Currency{Code: CurrencyUSD}
-	toCurrency := &Currency{Code: "UZS"} // Uzbek Som has high rate vs USD
+	fromCurrency := &Currency{Code: CurrencyUSD, IsActive: true}
+	toCurrency := &Currency{Code: "UZS", IsActive: true} // Uzbek Som has high rate vs USD
 
 	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(fromCurrency, nil)
 	mockRepo.On("GetCurrencyByCode", ctx, "UZS").Return(toCurrency, nil)
@@ -2046,6 +2742,7 @@ func TestSetExchangeRate_VeryLargeRate(t *testing.T) {
 		return r.Rate == 12500.00 && r.InverseRate == 1.0/12500.00
 	})).Return(nil)
 
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, mock.Anything, mock.Anything).Return(nil)
 	err := service.SetExchangeRate(ctx, CurrencyUSD, "UZS", 12500.00, 24*time.Hour)
 
 	require.NoError(t, err)
@@ -2130,6 +2827,54 @@ func TestUpdateCurrency_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestConvert_CachesCurrencyMetadata(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	rate := &ExchangeRate{
+		FromCurrency: CurrencyUSD,
+		ToCurrency:   CurrencyUZS,
+		Rate:         12500,
+		InverseRate:  1.0 / 12500,
+		ValidUntil:   time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyUZS).Return(rate, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUZS).Return(&Currency{Code: CurrencyUZS, DecimalPlaces: 0, IsActive: true}, nil)
+
+	first, err := service.ConvertFromBase(ctx, 10, CurrencyUZS)
+	require.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "GetCurrencyByCode", 2)
+
+	second, err := service.ConvertFromBase(ctx, 10, CurrencyUZS)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Converted, second.Converted)
+	assert.Equal(t, 0, second.Converted.DecimalPlaces)
+	// The second conversion makes no further currency lookups
+	mockRepo.AssertNumberOfCalls(t, "GetCurrencyByCode", 2)
+}
+
+func TestUpdateCurrency_InvalidatesCachedCurrency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, Symbol: "€", DecimalPlaces: 2, IsActive: true}, nil).Once()
+	assert.Equal(t, 2, service.decimalPlaces(ctx, CurrencyEUR))
+	assert.Equal(t, 2, service.decimalPlaces(ctx, CurrencyEUR))
+	mockRepo.AssertNumberOfCalls(t, "GetCurrencyByCode", 1)
+
+	updated := &Currency{Code: CurrencyEUR, Symbol: "€", DecimalPlaces: 3, IsActive: true}
+	mockRepo.On("UpdateCurrency", ctx, updated).Return(nil)
+	require.NoError(t, service.UpdateCurrency(ctx, updated))
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(updated, nil).Once()
+	assert.Equal(t, 3, service.decimalPlaces(ctx, CurrencyEUR))
+	mockRepo.AssertNumberOfCalls(t, "GetCurrencyByCode", 2)
+}
+
 // =============================================================================
 // ValidateConversion - Additional Tests
 // =============================================================================
@@ -2138,8 +2883,9 @@ func TestValidateConversion_SameCurrency(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
 
-	// Same currency should always be valid (no DB call needed)
+	// Same currency needs no exchange rate
 	err := service.ValidateConversion(ctx, CurrencyUSD, CurrencyUSD)
 
 	require.NoError(t, err)
@@ -2150,6 +2896,7 @@ func TestValidateConversion_ViaTriangulation(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo, CurrencyUSD)
 	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, mock.Anything).Return(&Currency{IsActive: true}, nil)
 
 	eurToUsd := &ExchangeRate{
 		FromCurrency: CurrencyEUR,
@@ -2330,6 +3077,7 @@ func TestGetExchangeRate_FallbackScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockRepository)
+			mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
 			service := NewService(mockRepo, tt.baseCurrency)
 			ctx := context.Background()
 
@@ -2357,3 +3105,1410 @@ func TestGetExchangeRate_FallbackScenarios(t *testing.T) {
 		})
 	}
 }
+
+// =============================================================================
+// Rate Provider Refresh
+// =============================================================================
+
+type mockRateProvider struct {
+	mock.Mock
+}
+
+func (m *mockRateProvider) FetchRates(ctx context.Context, base string) (map[string]float64, error) {
+	args := m.Called(ctx, base)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]float64), args.Error(1)
+}
+
+func TestGetExchangeRate_ProviderRefreshesMissingRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
+	provider.On("FetchRates", ctx, CurrencyUSD).Return(map[string]float64{
+		CurrencyUSD: 1.0,
+		CurrencyEUR: 0.92,
+		CurrencyGBP: 0.79,
+	}, nil).Once()
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.MatchedBy(func(rates []*ExchangeRate) bool {
+		// Base currency is skipped
+		return len(rates) == 2
+	})).Return(nil).Once()
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, rate.Rate)
+	assert.InDelta(t, 1/0.92, rate.InverseRate, 0.0001)
+	assert.Equal(t, "provider", rate.Source)
+	assert.True(t, rate.ValidUntil.After(time.Now()))
+
+	// Second lookup is served from cache
+	_, err = service.GetExchangeRate(ctx, CurrencyUSD, CurrencyEUR)
+	require.NoError(t, err)
+	provider.AssertNumberOfCalls(t, "FetchRates", 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetExchangeRate_ProviderRateAppliedPastGuardrail(t *testing.T) {
+	mockRepo := new(MockRepository)
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	service.SetMaxRateChange(20)
+	auditLog := &audittest.Recorder{}
+	service.SetAuditLogger(auditLog)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTMT).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyTMT, CurrencyUSD).Return(nil, errors.New("not found"))
+	mockRepo.On("GetAllExchangeRatesFromBase", ctx, CurrencyUSD).Return([]*ExchangeRate{
+		{FromCurrency: CurrencyUSD, ToCurrency: CurrencyTMT, Rate: 3.5},
+	}, nil).Once()
+	provider.On("FetchRates", ctx, CurrencyUSD).Return(map[string]float64{
+		CurrencyTMT: 19.5, // Devaluation
+	}, nil).Once()
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.MatchedBy(func(rates []*ExchangeRate) bool {
+		return len(rates) == 1 && rates[0].Rate == 19.5
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).([]*ExchangeRate)[0].ID = uuid.New()
+	}).Return(nil).Once()
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyTMT)
+
+	require.NoError(t, err)
+	assert.Equal(t, 19.5, rate.Rate)
+	mockRepo.AssertExpectations(t)
+
+	// Nobody made the change, so the jump is audited as a system entry
+	require.Len(t, auditLog.Entries(), 1)
+	entry := auditLog.Entries()[0]
+	assert.Equal(t, "currency.rate_jump", entry.Action)
+	assert.True(t, entry.System)
+	assert.Equal(t, rate.ID.String(), entry.TargetID)
+	assert.Equal(t, 3.5, entry.Before["rate"])
+	assert.Equal(t, 19.5, entry.After["rate"])
+}
+
+// expectActiveCurrencies lets rates be set for the given active currencies
+func expectActiveCurrencies(mockRepo *MockRepository, ctx context.Context, codes ...string) {
+	for _, code := range codes {
+		mockRepo.On("GetCurrencyByCode", ctx, code).Return(&Currency{Code: code, IsActive: true}, nil).Maybe()
+	}
+}
+
+func TestGetExchangeRate_ProviderErrorFallsBackToTriangulation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyGBP).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyGBP, CurrencyEUR).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(&ExchangeRate{
+		FromCurrency: CurrencyEUR, ToCurrency: CurrencyUSD, Rate: 1.10, InverseRate: 1 / 1.10,
+		ValidUntil: time.Now().Add(time.Hour),
+	}, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyGBP).Return(&ExchangeRate{
+		FromCurrency: CurrencyUSD, ToCurrency: CurrencyGBP, Rate: 0.75, InverseRate: 1 / 0.75,
+		ValidUntil: time.Now().Add(time.Hour),
+	}, nil)
+	provider.On("FetchRates", ctx, CurrencyEUR).Return(nil, errors.New("provider unavailable")).Once()
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyEUR, CurrencyGBP)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 0.825, rate.Rate, 0.0001)
+	assert.Equal(t, "triangulated", rate.Source)
+	// Triangulation legs do not call the provider again
+	provider.AssertNumberOfCalls(t, "FetchRates", 1)
+	mockRepo.AssertNotCalled(t, "BulkCreateExchangeRates", mock.Anything, mock.Anything)
+}
+
+func TestGetExchangeRate_ProviderMissingPairAndNoTriangulation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTMT).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyTMT, CurrencyUSD).Return(nil, errors.New("not found"))
+	provider.On("FetchRates", ctx, CurrencyUSD).Return(map[string]float64{CurrencyEUR: 0.92}, nil).Once()
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(nil).Once()
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyTMT)
+
+	assert.Error(t, err)
+	assert.Nil(t, rate)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetExchangeRate_ProviderStoreError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return([]*Currency{}, nil).Maybe()
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
+	provider.On("FetchRates", ctx, CurrencyUSD).Return(map[string]float64{CurrencyEUR: 0.92}, nil).Once()
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(errors.New("db error")).Once()
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyEUR)
+
+	assert.Error(t, err)
+	assert.Nil(t, rate)
+}
+
+func TestNewRateProvider(t *testing.T) {
+	assert.Nil(t, NewRateProvider(config.CurrencyConfig{}))
+	assert.Nil(t, NewRateProvider(config.CurrencyConfig{RateProvider: "fixer"}), "fixer requires an API key")
+	assert.IsType(t, &FixerProvider{}, NewRateProvider(config.CurrencyConfig{RateProvider: "fixer", FixerAPIKey: "key"}))
+	assert.IsType(t, &ECBProvider{}, NewRateProvider(config.CurrencyConfig{RateProvider: "ecb"}))
+	assert.Nil(t, NewRateProvider(config.CurrencyConfig{RateProvider: "unknown"}))
+}
+
+// =============================================================================
+// Multi-hop Triangulation
+// =============================================================================
+
+// setupNoStoredPair makes direct, inverse and base-currency lookups fail for from -> to
+func setupNoStoredPair(mockRepo *MockRepository, from, to string) {
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, from, to).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, to, from).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, from, CurrencyUSD).Return(nil, errors.New("not found")).Maybe()
+	mockRepo.On("GetLatestExchangeRate", mock.Anything, CurrencyUSD, from).Return(nil, errors.New("not found")).Maybe()
+}
+
+// setupRateGraph stores the given rates, keyed by their from currency
+func setupRateGraph(mockRepo *MockRepository, codes []string, rates ...*ExchangeRate) {
+	currencies := make([]*Currency, 0, len(codes))
+	for _, code := range codes {
+		currencies = append(currencies, &Currency{Code: code, IsActive: true})
+		fromCode := make([]*ExchangeRate, 0)
+		for _, r := range rates {
+			if r.FromCurrency == code {
+				fromCode = append(fromCode, r)
+			}
+		}
+		mockRepo.On("GetAllExchangeRatesFromBase", mock.Anything, code).Return(fromCode, nil)
+	}
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return(currencies, nil)
+}
+
+func testRate(from, to string, rate float64, validFor time.Duration) *ExchangeRate {
+	return &ExchangeRate{
+		ID:           uuid.New(),
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         rate,
+		InverseRate:  1 / rate,
+		Source:       string(SourceManual),
+		ValidUntil:   time.Now().Add(validFor),
+	}
+}
+
+func TestGetExchangeRate_MultiHop_OneIntermediate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// No USD legs: EUR -> RUB and TMT -> RUB are the only stored pairs
+	setupNoStoredPair(mockRepo, CurrencyEUR, CurrencyTMT)
+	setupRateGraph(mockRepo, []string{CurrencyEUR, CurrencyRUB, CurrencyTMT},
+		testRate(CurrencyEUR, CurrencyRUB, 100, 2*time.Hour),
+		testRate(CurrencyTMT, CurrencyRUB, 25, time.Hour),
+	)
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyEUR, CurrencyTMT)
+
+	require.NoError(t, err)
+	// EUR -> RUB (100) -> TMT (1/25) = 4
+	assert.InDelta(t, 4.0, rate.Rate, 0.0001)
+	assert.InDelta(t, 0.25, rate.InverseRate, 0.0001)
+	assert.Equal(t, "triangulated", rate.Source)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), rate.ValidUntil, time.Second)
+}
+
+func TestGetExchangeRate_MultiHop_TwoIntermediates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// EUR -> TRY -> KZT -> TMT
+	setupNoStoredPair(mockRepo, CurrencyEUR, CurrencyTMT)
+	setupRateGraph(mockRepo, []string{CurrencyEUR, CurrencyKZT, CurrencyTMT, CurrencyTRY},
+		testRate(CurrencyEUR, CurrencyTRY, 40, time.Hour),
+		testRate(CurrencyTRY, CurrencyKZT, 15, 30*time.Minute),
+		testRate(CurrencyKZT, CurrencyTMT, 0.007, 3*time.Hour),
+	)
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyEUR, CurrencyTMT)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 40*15*0.007, rate.Rate, 0.0001)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), rate.ValidUntil, time.Second)
+}
+
+func TestGetExchangeRate_MultiHop_PathTooLong(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// EUR -> TRY -> KZT -> RUB -> TMT needs three intermediates
+	setupNoStoredPair(mockRepo, CurrencyEUR, CurrencyTMT)
+	setupRateGraph(mockRepo, []string{CurrencyEUR, CurrencyKZT, CurrencyRUB, CurrencyTMT, CurrencyTRY},
+		testRate(CurrencyEUR, CurrencyTRY, 40, time.Hour),
+		testRate(CurrencyTRY, CurrencyKZT, 15, time.Hour),
+		testRate(CurrencyKZT, CurrencyRUB, 0.2, time.Hour),
+		testRate(CurrencyRUB, CurrencyTMT, 0.04, time.Hour),
+	)
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyEUR, CurrencyTMT)
+
+	assert.Error(t, err)
+	assert.Nil(t, rate)
+	assert.Contains(t, err.Error(), "no rate path found")
+}
+
+func TestFindRatePath_DenseGraphWithCycles(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// Fully connected cluster with cycles, plus a single exit to TMT
+	codes := []string{CurrencyAED, CurrencyEUR, CurrencyGBP, CurrencyINR, CurrencyKZT, CurrencyTMT, CurrencyTRY}
+	cluster := []string{CurrencyAED, CurrencyEUR, CurrencyGBP, CurrencyINR, CurrencyTRY}
+	var rates []*ExchangeRate
+	for i, a := range cluster {
+		for _, b := range cluster[i+1:] {
+			rates = append(rates, testRate(a, b, 2, time.Hour))
+		}
+	}
+	rates = append(rates,
+		testRate(CurrencyTRY, CurrencyKZT, 15, time.Hour),
+		testRate(CurrencyKZT, CurrencyTMT, 0.007, time.Hour),
+	)
+	setupRateGraph(mockRepo, codes, rates...)
+
+	path, err := service.findRatePath(ctx, CurrencyEUR, CurrencyTMT)
+
+	require.NoError(t, err)
+	require.Len(t, path, 3)
+	assert.Equal(t, CurrencyEUR, path[0].FromCurrency)
+	assert.Equal(t, CurrencyTRY, path[0].ToCurrency)
+	assert.Equal(t, CurrencyKZT, path[1].ToCurrency)
+	assert.Equal(t, CurrencyTMT, path[2].ToCurrency)
+	// Each currency is loaded exactly once regardless of graph density
+	mockRepo.AssertNumberOfCalls(t, "GetAllExchangeRatesFromBase", len(codes))
+}
+
+func TestFindRatePath_LoadError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetActiveCurrencies", ctx).Return(nil, errors.New("db error"))
+
+	path, err := service.findRatePath(ctx, CurrencyEUR, CurrencyTMT)
+
+	assert.Error(t, err)
+	assert.Nil(t, path)
+}
+
+// =============================================================================
+// Test ConvertWithSpread
+// =============================================================================
+
+func TestConvertWithSpread_AppliesBidSide(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(testRate(CurrencyUSD, CurrencyEUR, 0.85, time.Hour), nil).Once()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil)
+
+	result, err := service.ConvertWithSpread(ctx, 100.00, CurrencyUSD, CurrencyEUR, 50)
+
+	require.NoError(t, err)
+	// 50 bps spread -> 25 bps below mid: 0.85 * 0.9975 = 0.847875
+	assert.InDelta(t, 0.847875, result.AppliedRate, 1e-9)
+	assert.Equal(t, 0.85, result.ExchangeRate)
+	assert.Equal(t, 50, result.SpreadBps)
+	assert.Equal(t, 84.79, result.Converted.Amount)
+
+	// The cached mid rate is not modified, so Convert stays spread-free
+	plain, err := service.Convert(ctx, 100.00, CurrencyUSD, CurrencyEUR)
+	require.NoError(t, err)
+	assert.Equal(t, 85.00, plain.Converted.Amount)
+	assert.Zero(t, plain.SpreadBps)
+	assert.Zero(t, plain.AppliedRate)
+}
+
+func TestConvertWithSpread_RoundsAfterSpread(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, "JPY").Return(testRate(CurrencyUSD, "JPY", 150.123, time.Hour), nil)
+	mockRepo.On("GetCurrencyByCode", ctx, "JPY").Return(&Currency{Code: "JPY", DecimalPlaces: 0, IsActive: true}, nil)
+
+	result, err := service.ConvertWithSpread(ctx, 1000, CurrencyUSD, "JPY", 30)
+
+	require.NoError(t, err)
+	// 150.123 * 0.9985 = 149.8978155 -> 149897.8155 -> 149898
+	// (rounding the rate first would give 149.90 -> 149900)
+	assert.Equal(t, 149898.0, result.Converted.Amount)
+}
+
+func TestConvertWithSpread_ZeroSpreadMatchesConvert(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyGBP).Return(testRate(CurrencyUSD, CurrencyGBP, 0.7891, time.Hour), nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyGBP).Return(&Currency{Code: CurrencyGBP, DecimalPlaces: 2, IsActive: true}, nil)
+
+	withSpread, err := service.ConvertWithSpread(ctx, 123.45, CurrencyUSD, CurrencyGBP, 0)
+	require.NoError(t, err)
+	plain, err := service.Convert(ctx, 123.45, CurrencyUSD, CurrencyGBP)
+	require.NoError(t, err)
+
+	assert.Equal(t, plain.Converted.Amount, withSpread.Converted.Amount)
+	assert.Equal(t, withSpread.ExchangeRate, withSpread.AppliedRate)
+}
+
+func TestConvertWithSpread_InvalidSpread(t *testing.T) {
+	service := NewService(new(MockRepository), CurrencyUSD)
+	ctx := context.Background()
+
+	for _, bps := range []int{-1, MaxSpreadBps + 1} {
+		result, err := service.ConvertWithSpread(ctx, 100, CurrencyUSD, CurrencyEUR, bps)
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	}
+}
+
+func TestConvertWithSpread_SameCurrency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+
+	result, err := service.ConvertWithSpread(context.Background(), 42.5, CurrencyUSD, CurrencyUSD, 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, result.Converted.Amount)
+	assert.Equal(t, 1.0, result.AppliedRate)
+}
+
+func TestConverter_BidAsk(t *testing.T) {
+	converter := NewConverter(CurrencyUSD)
+
+	bid, ask := converter.BidAsk(1.2, 100)
+
+	assert.InDelta(t, 1.194, bid, 1e-9)
+	assert.InDelta(t, 1.206, ask, 1e-9)
+	assert.InDelta(t, 1.2, (bid+ask)/2, 1e-9, "spread is symmetric around mid")
+	assert.Equal(t, bid, converter.ApplySpread(1.2, 100))
+}
+
+// =============================================================================
+// Test expired rate cleanup
+// =============================================================================
+
+func TestCleanupExpiredRates_PurgesRepositoryAndCache(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	stale := testRate(CurrencyUSD, CurrencyEUR, 0.85, -48*time.Hour)
+	recent := testRate(CurrencyUSD, CurrencyGBP, 0.75, -time.Hour)
+	fresh := testRate(CurrencyUSD, CurrencyTRY, 32, time.Hour)
+	service.cacheRate(stale)
+	service.cacheRate(recent)
+	service.cacheRate(fresh)
+
+	mockRepo.On("CleanupExpiredRates", ctx, 24*time.Hour).Return(int64(3), nil).Once()
+
+	assert.True(t, service.CleanupExpiredRates(ctx, 24*time.Hour))
+
+	assert.NotContains(t, service.cache.rates, "USD-EUR")
+	assert.Contains(t, service.cache.rates, "USD-GBP")
+	assert.Contains(t, service.cache.rates, "USD-TRY")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCleanupExpiredRates_RepositoryErrorStillPurgesCache(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	service.cacheRate(testRate(CurrencyUSD, CurrencyEUR, 0.85, -48*time.Hour))
+	mockRepo.On("CleanupExpiredRates", ctx, time.Hour).Return(int64(0), errors.New("db down")).Once()
+
+	assert.True(t, service.CleanupExpiredRates(ctx, time.Hour))
+	assert.Empty(t, service.cache.rates)
+}
+
+func TestCleanupExpiredRates_SkipsWhileSweepRunning(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockRepo.On("CleanupExpiredRates", ctx, time.Hour).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(int64(1), nil).Once()
+
+	done := make(chan bool)
+	go func() { done <- service.CleanupExpiredRates(ctx, time.Hour) }()
+	<-started
+
+	assert.False(t, service.CleanupExpiredRates(ctx, time.Hour))
+
+	close(release)
+	assert.True(t, <-done)
+	mockRepo.AssertNumberOfCalls(t, "CleanupExpiredRates", 1)
+}
+
+func TestStartCleanupLoop_RunsUntilCancelled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	swept := make(chan struct{}, 1)
+	mockRepo.On("CleanupExpiredRates", mock.Anything, time.Hour).
+		Run(func(mock.Arguments) {
+			select {
+			case swept <- struct{}{}:
+			default:
+			}
+		}).
+		Return(int64(0), nil)
+
+	service.StartCleanupLoop(ctx, 10*time.Millisecond, time.Hour)
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup loop did not run")
+	}
+	cancel()
+}
+
+// =============================================================================
+// Test SubscribeRateChanges
+// =============================================================================
+
+func TestSubscribeRateChanges_ReceivesSetRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, IsActive: true}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil)
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyEUR, mock.Anything).Return(nil)
+
+	type change struct {
+		from, to string
+		rate     float64
+	}
+	received := make(chan change, 2)
+	service.SubscribeRateChanges(func(from, to string, newRate float64) {
+		received <- change{from, to, newRate}
+	})
+
+	require.NoError(t, service.SetExchangeRate(ctx, CurrencyUSD, CurrencyEUR, 0.8, time.Hour, SyncInverseRate()))
+
+	var got []change
+	for len(got) < 2 {
+		select {
+		case c := <-received:
+			got = append(got, c)
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber received %d of 2 rate changes", len(got))
+		}
+	}
+	assert.ElementsMatch(t, []change{
+		{CurrencyUSD, CurrencyEUR, 0.8},
+		{CurrencyEUR, CurrencyUSD, 1.25},
+	}, got)
+}
+
+func TestSubscribeRateChanges_SlowSubscriberDoesNotBlockWrites(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(nil)
+
+	release := make(chan struct{})
+	defer close(release)
+	service.SubscribeRateChanges(func(string, string, float64) {
+		<-release
+	})
+	fast := make(chan string, 1)
+	service.SubscribeRateChanges(func(from, to string, newRate float64) {
+		select {
+		case fast <- from + "/" + to:
+		default:
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Far more changes than the stuck subscriber gets through
+		for i := 0; i < 500; i++ {
+			_ = service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{CurrencyEUR: 0.8}, time.Hour)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("rate writes blocked on a slow subscriber")
+	}
+	select {
+	case pair := <-fast:
+		assert.Equal(t, "USD/EUR", pair)
+	case <-time.After(time.Second):
+		t.Fatal("other subscriber was held up by the slow one")
+	}
+}
+
+func TestSubscribeRateChanges_SlowSubscriberGetsLatestRates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyGBP, CurrencyUSD)
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(nil)
+
+	type change struct {
+		pair string
+		rate float64
+	}
+	release := make(chan struct{})
+	received := make(chan change, 10)
+	service.SubscribeRateChanges(func(from, to string, newRate float64) {
+		<-release
+		received <- change{from + "/" + to, newRate}
+	})
+
+	require.NoError(t, service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{CurrencyEUR: 0.8}, time.Hour))
+	// Wait for the subscriber to be stuck on the first change
+	service.subscribers.mu.RLock()
+	subscriber := service.subscribers.subscribers[0]
+	service.subscribers.mu.RUnlock()
+	require.Eventually(t, func() bool {
+		subscriber.mu.Lock()
+		defer subscriber.mu.Unlock()
+		return len(subscriber.order) == 0
+	}, time.Second, time.Millisecond)
+
+	for i := 1; i <= 300; i++ {
+		rates := map[string]float64{CurrencyEUR: 0.8 + float64(i)/1000}
+		if i == 300 {
+			rates[CurrencyGBP] = 0.7
+		}
+		require.NoError(t, service.BulkSetExchangeRates(ctx, CurrencyUSD, rates, time.Hour))
+	}
+	close(release)
+
+	var got []change
+	for len(got) < 3 {
+		select {
+		case c := <-received:
+			got = append(got, c)
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber received %d of 3 rate changes: %v", len(got), got)
+		}
+	}
+	assert.Equal(t, change{"USD/EUR", 0.8}, got[0])
+	assert.ElementsMatch(t, []change{{"USD/EUR", 1.1}, {"USD/GBP", 0.7}}, got[1:])
+	select {
+	case c := <-received:
+		t.Fatalf("unexpected extra change %v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// =============================================================================
+// Test GetRateAt
+// =============================================================================
+
+func TestGetRateAt_DirectRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyUSD, CurrencyEUR, at).Return(testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour), nil)
+
+	rate, err := service.GetRateAt(ctx, CurrencyUSD, CurrencyEUR, at)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, rate.Rate)
+	// Historical lookups never populate the cache or consult the latest rate
+	assert.Empty(t, service.cache.rates)
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetRateAt_InverseRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyUSD, CurrencyEUR, at).Return(nil, pgx.ErrNoRows)
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyEUR, CurrencyUSD, at).Return(testRate(CurrencyEUR, CurrencyUSD, 1.25, time.Hour), nil)
+
+	rate, err := service.GetRateAt(ctx, CurrencyUSD, CurrencyEUR, at)
+
+	require.NoError(t, err)
+	assert.Equal(t, CurrencyUSD, rate.FromCurrency)
+	assert.Equal(t, CurrencyEUR, rate.ToCurrency)
+	assert.InDelta(t, 0.8, rate.Rate, 1e-9)
+}
+
+func TestGetRateAt_TriangulatesViaBase(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyEUR, CurrencyGBP, at).Return(nil, pgx.ErrNoRows)
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyGBP, CurrencyEUR, at).Return(nil, pgx.ErrNoRows)
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyEUR, CurrencyUSD, at).Return(testRate(CurrencyEUR, CurrencyUSD, 1.1, time.Hour), nil)
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyUSD, CurrencyGBP, at).Return(testRate(CurrencyUSD, CurrencyGBP, 0.8, time.Hour), nil)
+
+	rate, err := service.GetRateAt(ctx, CurrencyEUR, CurrencyGBP, at)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 0.88, rate.Rate, 1e-9)
+}
+
+func TestGetRateAt_NoRateInEffect(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetExchangeRateAt", ctx, mock.Anything, mock.Anything, at).Return(nil, pgx.ErrNoRows)
+
+	rate, err := service.GetRateAt(ctx, CurrencyUSD, CurrencyEUR, at)
+
+	assert.Nil(t, rate)
+	assert.ErrorIs(t, err, ErrNoRateAtTime)
+	assert.Contains(t, err.Error(), "2020-01-01T00:00:00Z")
+}
+
+func TestGetRateAt_DatabaseErrorIsNotReportedAsMissingRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetExchangeRateAt", ctx, CurrencyEUR, CurrencyGBP, at).Return(nil, errors.New("connection refused"))
+
+	rate, err := service.GetRateAt(ctx, CurrencyEUR, CurrencyGBP, at)
+
+	assert.Nil(t, rate)
+	assert.NotErrorIs(t, err, ErrNoRateAtTime)
+	var appErr *common.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, http.StatusInternalServerError, appErr.Code)
+	// The inverse and triangulated lookups are not tried
+	mockRepo.AssertNumberOfCalls(t, "GetExchangeRateAt", 1)
+}
+
+// =============================================================================
+// Test pinned rates
+// =============================================================================
+
+func TestSetExchangeRate_Pinned(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	var created *ExchangeRate
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, IsActive: true}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).
+		Run(func(args mock.Arguments) {
+			created = args.Get(1).(*ExchangeRate)
+			created.ID = uuid.New()
+		}).Return(nil)
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyTRY, mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.Equal(t, created.ID, args.Get(3), "the new pin must be kept")
+		}).Return(nil)
+
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyTRY, 32, time.Hour, PinRate())
+
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	assert.True(t, created.Pinned)
+	assert.Equal(t, string(SourceManual), created.Source)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetExchangeRate_PinnedRateNeverExpires(t *testing.T) {
+	mockRepo := new(MockRepository)
+	provider := new(mockRateProvider)
+	service := NewService(mockRepo, CurrencyUSD, provider)
+	ctx := context.Background()
+
+	pinned := testRate(CurrencyUSD, CurrencyTRY, 30, -48*time.Hour)
+	pinned.Pinned = true
+	service.cacheRate(pinned)
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyTRY)
+
+	require.NoError(t, err)
+	assert.Same(t, pinned, rate)
+	provider.AssertNotCalled(t, "FetchRates", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetExchangeRate_PinnedRatePreferredOverFresherRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// A fresh provider rate is cached when the operator pins an older, lower rate
+	service.cacheRate(testRate(CurrencyUSD, CurrencyTRY, 34, time.Hour))
+
+	pinned := testRate(CurrencyUSD, CurrencyTRY, 30, -48*time.Hour)
+	pinned.Pinned = true
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, IsActive: true}, nil)
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil)
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyTRY, mock.Anything).Return(nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTRY).Return(pinned, nil).Once()
+
+	require.NoError(t, service.SetExchangeRate(ctx, CurrencyUSD, CurrencyTRY, 30, time.Hour, PinRate()))
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyTRY)
+	require.NoError(t, err)
+	assert.True(t, rate.Pinned)
+	assert.Equal(t, 30.0, rate.Rate)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCleanupExpiredRates_KeepsPinnedRates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	pinned := testRate(CurrencyUSD, CurrencyTRY, 30, -30*24*time.Hour)
+	pinned.Pinned = true
+	service.cacheRate(pinned)
+	service.cacheRate(testRate(CurrencyUSD, CurrencyEUR, 0.85, -30*24*time.Hour))
+
+	mockRepo.On("CleanupExpiredRates", ctx, 24*time.Hour).Return(int64(1), nil).Once()
+
+	assert.True(t, service.CleanupExpiredRates(ctx, 24*time.Hour))
+
+	assert.NotContains(t, service.cache.rates, "USD-EUR")
+	assert.Contains(t, service.cache.rates, "USD-TRY")
+
+	rate, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyTRY)
+	require.NoError(t, err)
+	assert.Same(t, pinned, rate)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTriangulatedRate_IgnoresPinnedLegExpiry(t *testing.T) {
+	pinned := testRate(CurrencyEUR, CurrencyUSD, 1.1, -48*time.Hour)
+	pinned.Pinned = true
+	leg := testRate(CurrencyUSD, CurrencyGBP, 0.8, 2*time.Hour)
+
+	rate := triangulatedRate(CurrencyEUR, CurrencyGBP, []*ExchangeRate{pinned, leg})
+	assert.Equal(t, leg.ValidUntil, rate.ValidUntil)
+
+	rate = triangulatedRate(CurrencyEUR, CurrencyUSD, []*ExchangeRate{pinned})
+	assert.True(t, rate.ValidUntil.After(time.Now()), "pinned-only paths stay valid")
+}
+
+// =============================================================================
+// Test ConvertWithRounding
+// =============================================================================
+
+func TestConvertWithRounding_HalfBoundary(t *testing.T) {
+	// Each rate lands the converted amount exactly half a minor unit past an
+	// odd (first case) or even (second case) value
+	tests := []struct {
+		name          string
+		rate          float64
+		decimalPlaces int
+		mode          RoundingMode
+		expectedMinor int64
+	}{
+		{"0dp half up from odd", 2.5, 0, RoundingModeStandard, 3},
+		{"0dp half even from odd", 2.5, 0, RoundingModeBankers, 2},
+		{"0dp down from odd", 2.5, 0, RoundingModeDown, 2},
+		{"0dp half up from even", 3.5, 0, RoundingModeStandard, 4},
+		{"0dp half even from even", 3.5, 0, RoundingModeBankers, 4},
+		{"0dp down from even", 3.5, 0, RoundingModeDown, 3},
+		{"2dp half up from odd", 0.025, 2, RoundingModeStandard, 3},
+		{"2dp half even from odd", 0.025, 2, RoundingModeBankers, 2},
+		{"2dp down from odd", 0.025, 2, RoundingModeDown, 2},
+		{"2dp half up from even", 0.035, 2, RoundingModeStandard, 4},
+		{"2dp half even from even", 0.035, 2, RoundingModeBankers, 4},
+		{"2dp down from even", 0.035, 2, RoundingModeDown, 3},
+		{"3dp half up from odd", 0.0025, 3, RoundingModeStandard, 3},
+		{"3dp half even from odd", 0.0025, 3, RoundingModeBankers, 2},
+		{"3dp down from odd", 0.0025, 3, RoundingModeDown, 2},
+		{"3dp half up from even", 0.0035, 3, RoundingModeStandard, 4},
+		{"3dp half even from even", 0.0035, 3, RoundingModeBankers, 4},
+		{"3dp down from even", 0.0035, 3, RoundingModeDown, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewService(mockRepo, CurrencyUSD)
+			ctx := context.Background()
+
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+			mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: tt.decimalPlaces, IsActive: true}, nil)
+			mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(&ExchangeRate{
+				ID:           uuid.New(),
+				FromCurrency: CurrencyUSD,
+				ToCurrency:   CurrencyEUR,
+				Rate:         tt.rate,
+				InverseRate:  1.0 / tt.rate,
+				ValidUntil:   time.Now().Add(1 * time.Hour),
+			}, nil)
+
+			result, err := service.ConvertWithRounding(ctx, 1.00, CurrencyUSD, CurrencyEUR, tt.mode)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMinor, result.Converted.AmountMinor)
+			assert.Equal(t, tt.decimalPlaces, result.Converted.DecimalPlaces)
+			assert.Equal(t, tt.mode, result.RoundingMode)
+		})
+	}
+}
+
+func TestConvert_DefaultsToHalfUp(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+
+	result, err := service.Convert(ctx, 10.00, CurrencyUSD, CurrencyUSD)
+
+	require.NoError(t, err)
+	assert.Equal(t, RoundingModeStandard, result.RoundingMode)
+}
+
+func TestConvertWithRounding_RejectsUnsupportedMode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+
+	for _, mode := range []RoundingMode{RoundingModeNone, RoundingMode(99)} {
+		_, err := service.ConvertWithRounding(context.Background(), 1.00, CurrencyUSD, CurrencyEUR, mode)
+		assert.Error(t, err, "mode %s", mode)
+	}
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConversionResult_RoundingModeJSON(t *testing.T) {
+	data, err := json.Marshal(&ConversionResult{RoundingMode: RoundingModeBankers})
+
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"rounding_mode":"half_even"`)
+}
+
+// =============================================================================
+// Test GetRateMetadata
+// =============================================================================
+
+func TestGetRateMetadata_DirectThenCached(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	stored := testRate(CurrencyUSD, CurrencyEUR, 0.92, 30*time.Minute)
+	stored.FetchedAt = time.Now().Add(-10 * time.Minute)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(stored, nil).Once()
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyUSD, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.Same(t, stored, meta.Rate)
+	assert.Equal(t, DerivationDirect, meta.Derivation)
+	assert.False(t, meta.FromCache)
+	assert.InDelta(t, 600, meta.AgeSeconds, 5)
+	assert.InDelta(t, 1800, meta.ExpiresInSeconds, 5)
+	assert.Empty(t, meta.Legs)
+	assert.Nil(t, meta.ExpiringLeg)
+
+	meta, err = service.GetRateMetadata(ctx, CurrencyUSD, CurrencyEUR)
+
+	require.NoError(t, err)
+	assert.True(t, meta.FromCache)
+	assert.Equal(t, DerivationDirect, meta.Derivation)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRateMetadata_Inverse(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(testRate(CurrencyUSD, CurrencyEUR, 0.8, time.Hour), nil)
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyEUR, CurrencyUSD)
+
+	require.NoError(t, err)
+	assert.Equal(t, DerivationInverse, meta.Derivation)
+	assert.InDelta(t, 1.25, meta.Rate.Rate, 0.0001)
+	assert.False(t, meta.FromCache)
+}
+
+func TestGetRateMetadata_TriangulatedReportsExpiringLeg(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	eurToUsd := testRate(CurrencyEUR, CurrencyUSD, 1.10, 2*time.Hour)
+	eurToUsd.FetchedAt = time.Now().Add(-5 * time.Minute)
+	usdToGbp := testRate(CurrencyUSD, CurrencyGBP, 0.75, 20*time.Minute)
+	usdToGbp.FetchedAt = time.Now().Add(-40 * time.Minute)
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyGBP).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyGBP, CurrencyEUR).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(eurToUsd, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyGBP).Return(usdToGbp, nil)
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyEUR, CurrencyGBP)
+
+	require.NoError(t, err)
+	assert.Equal(t, DerivationTriangulated, meta.Derivation)
+	require.Len(t, meta.Legs, 2)
+	require.NotNil(t, meta.ExpiringLeg)
+	assert.Equal(t, CurrencyUSD, meta.ExpiringLeg.FromCurrency)
+	assert.Equal(t, CurrencyGBP, meta.ExpiringLeg.ToCurrency)
+	assert.Equal(t, usdToGbp.ValidUntil, meta.Rate.ValidUntil)
+	assert.InDelta(t, 1200, meta.ExpiresInSeconds, 5)
+	// As old as the oldest leg, not the moment it was triangulated
+	assert.Equal(t, usdToGbp.FetchedAt, meta.FetchedAt)
+	assert.InDelta(t, 2400, meta.AgeSeconds, 5)
+
+	// Served from the cache with the legs it was derived from
+	meta, err = service.GetRateMetadata(ctx, CurrencyEUR, CurrencyGBP)
+
+	require.NoError(t, err)
+	assert.True(t, meta.FromCache)
+	assert.Equal(t, DerivationTriangulated, meta.Derivation)
+	assert.Equal(t, CurrencyGBP, meta.ExpiringLeg.ToCurrency)
+}
+
+func TestGetRateMetadata_TriangulatedSkipsPinnedLegs(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	eurToUsd := testRate(CurrencyEUR, CurrencyUSD, 1.10, -time.Hour) // Expired, but pinned
+	eurToUsd.Pinned = true
+	eurToUsd.FetchedAt = time.Now().Add(-30 * 24 * time.Hour)
+	usdToGbp := testRate(CurrencyUSD, CurrencyGBP, 0.75, time.Hour)
+	usdToGbp.FetchedAt = time.Now().Add(-time.Minute)
+	service.cacheRate(eurToUsd)
+	service.cacheRate(usdToGbp)
+
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyGBP).Return(nil, errors.New("not found"))
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyGBP, CurrencyEUR).Return(nil, errors.New("not found"))
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyEUR, CurrencyGBP)
+
+	require.NoError(t, err)
+	require.NotNil(t, meta.ExpiringLeg)
+	assert.Equal(t, CurrencyGBP, meta.ExpiringLeg.ToCurrency)
+	assert.InDelta(t, 60, meta.AgeSeconds, 5)
+}
+
+func TestGetRateMetadata_PinnedAndIdentity(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	pinned := testRate(CurrencyUSD, CurrencyTRY, 30, -48*time.Hour)
+	pinned.Pinned = true
+	service.cacheRate(pinned)
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyUSD, CurrencyTRY)
+
+	require.NoError(t, err)
+	assert.True(t, meta.FromCache)
+	assert.Zero(t, meta.ExpiresInSeconds)
+
+	meta, err = service.GetRateMetadata(ctx, CurrencyUSD, CurrencyUSD)
+
+	require.NoError(t, err)
+	assert.Equal(t, DerivationIdentity, meta.Derivation)
+	assert.False(t, meta.FromCache)
+}
+
+func TestGetRateMetadata_NoRatePath(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetLatestExchangeRate", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	mockRepo.On("GetActiveCurrencies", ctx).Return([]*Currency{}, nil)
+
+	meta, err := service.GetRateMetadata(ctx, CurrencyEUR, CurrencyGBP)
+
+	assert.Nil(t, meta)
+	assert.ErrorContains(t, err, "no rate path found")
+}
+
+func TestRateCacheOldestAgeGauge(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	stale := testRate(CurrencyUSD, CurrencyEUR, 0.92, time.Hour)
+	stale.FetchedAt = time.Now().Add(-3 * time.Hour)
+	pinned := testRate(CurrencyUSD, CurrencyTRY, 30, time.Hour)
+	pinned.Pinned = true
+	pinned.FetchedAt = time.Now().Add(-30 * 24 * time.Hour)
+	fresh := testRate(CurrencyUSD, CurrencyGBP, 0.79, time.Hour)
+	fresh.FetchedAt = time.Now()
+	service.cacheRate(stale)
+	service.cacheRate(pinned)
+	service.cacheRate(fresh)
+
+	_, err := service.GetExchangeRate(ctx, CurrencyUSD, CurrencyGBP)
+	require.NoError(t, err)
+	assert.InDelta(t, 3*3600, testutil.ToFloat64(rateCacheOldestAge), 5)
+
+	service.invalidateCache(CurrencyUSD, CurrencyEUR)
+	assert.InDelta(t, 0, testutil.ToFloat64(rateCacheOldestAge), 5)
+}
+
+// =============================================================================
+// Inactive currencies
+// =============================================================================
+
+func TestConvert_InactiveTargetRejectedUntilReactivated(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, DecimalPlaces: 2, IsActive: false}, nil).Once()
+
+	result, err := service.Convert(ctx, 100, CurrencyUSD, CurrencyTRY)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrInactiveCurrency)
+	assert.ErrorContains(t, err, CurrencyTRY)
+	mockRepo.AssertNotCalled(t, "GetLatestExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+
+	reactivated := &Currency{Code: CurrencyTRY, DecimalPlaces: 2, IsActive: true}
+	mockRepo.On("UpdateCurrency", ctx, reactivated).Return(nil)
+	require.NoError(t, service.UpdateCurrency(ctx, reactivated))
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(reactivated, nil).Once()
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTRY).Return(testRate(CurrencyUSD, CurrencyTRY, 30, time.Hour), nil)
+
+	result, err = service.Convert(ctx, 100, CurrencyUSD, CurrencyTRY)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, result.Converted.Amount)
+}
+
+func TestConvert_InactiveSourceRejected(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, DecimalPlaces: 2}, nil)
+
+	_, err := service.Convert(ctx, 100, CurrencyTRY, CurrencyUSD)
+
+	assert.ErrorIs(t, err, ErrInactiveCurrency)
+}
+
+func TestConvert_ConvertInactiveCurrenciesOverride(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, DecimalPlaces: 2}, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTRY).Return(testRate(CurrencyUSD, CurrencyTRY, 30, time.Hour), nil)
+
+	result, err := service.Convert(ctx, 10, CurrencyUSD, CurrencyTRY, ConvertInactiveCurrencies())
+
+	require.NoError(t, err)
+	assert.Equal(t, 300.0, result.Converted.Amount)
+}
+
+func TestConvertBatch_InactiveItemCurrencyNamesItem(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyEUR).Return(&Currency{Code: CurrencyEUR, DecimalPlaces: 2, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTRY).Return(&Currency{Code: CurrencyTRY, DecimalPlaces: 2}, nil)
+
+	items := []Money{NewMoney(1, CurrencyEUR, 2), NewMoney(1, CurrencyTRY, 2)}
+	_, err := service.ConvertBatch(ctx, items, CurrencyEUR)
+
+	assert.ErrorIs(t, err, ErrInactiveCurrency)
+	assert.ErrorContains(t, err, "item 1")
+}
+
+func TestSetExchangeRate_RefusesInactiveCurrency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", mock.Anything, CurrencyTRY).Return(&Currency{Code: CurrencyTRY}, nil)
+
+	err := service.SetExchangeRate(ctx, CurrencyUSD, CurrencyTRY, 30, time.Hour)
+
+	assert.ErrorIs(t, err, ErrInactiveCurrency)
+	mockRepo.AssertNotCalled(t, "CreateExchangeRate", mock.Anything, mock.Anything)
+
+	// An admin reconciling back-dated records may still set it
+	mockRepo.On("CreateExchangeRate", mock.Anything, mock.AnythingOfType("*currency.ExchangeRate")).Return(nil)
+	mockRepo.On("UnpinExchangeRates", mock.Anything, CurrencyUSD, CurrencyTRY, mock.Anything).Return(nil)
+
+	err = service.SetExchangeRate(ctx, CurrencyUSD, CurrencyTRY, 30, time.Hour, AllowInactiveCurrencies())
+
+	require.NoError(t, err)
+	mockRepo.AssertCalled(t, "CreateExchangeRate", mock.Anything, mock.Anything)
+}
+
+// =============================================================================
+// Supported Conversions
+// =============================================================================
+
+func findTarget(t *testing.T, pairs *SupportedPairs, from, to string) *SupportedTarget {
+	t.Helper()
+	for _, source := range pairs.Sources {
+		if source.Currency != from {
+			continue
+		}
+		for _, target := range source.Targets {
+			if target.Currency == to {
+				return target
+			}
+		}
+	}
+	return nil
+}
+
+func TestGetSupportedPairs_DescribesEachConversion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	// USD -> EUR stored both ways, USD -> GBP, GBP -> TRY -> KZT; TMT is isolated
+	setupRateGraph(mockRepo, []string{CurrencyEUR, CurrencyGBP, CurrencyKZT, CurrencyTMT, CurrencyTRY, CurrencyUSD},
+		testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour),
+		testRate(CurrencyEUR, CurrencyUSD, 1.1, time.Hour),
+		testRate(CurrencyUSD, CurrencyGBP, 0.8, time.Hour),
+		testRate(CurrencyGBP, CurrencyTRY, 40, time.Hour),
+		testRate(CurrencyTRY, CurrencyKZT, 15, time.Hour),
+	)
+
+	pairs, err := service.GetSupportedPairs(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, CurrencyUSD, pairs.BaseCurrency)
+	require.Len(t, pairs.Sources, 6)
+
+	direct := findTarget(t, pairs, CurrencyEUR, CurrencyUSD)
+	require.NotNil(t, direct)
+	assert.Equal(t, DerivationDirect, direct.Derivation)
+	assert.Equal(t, 1, direct.Hops)
+
+	inverse := findTarget(t, pairs, CurrencyGBP, CurrencyUSD)
+	require.NotNil(t, inverse)
+	assert.Equal(t, DerivationInverse, inverse.Derivation)
+
+	twoHops := findTarget(t, pairs, CurrencyEUR, CurrencyGBP)
+	require.NotNil(t, twoHops)
+	assert.Equal(t, DerivationTriangulated, twoHops.Derivation)
+	assert.Equal(t, 2, twoHops.Hops)
+	assert.Equal(t, []string{CurrencyUSD}, twoHops.Via)
+
+	threeHops := findTarget(t, pairs, CurrencyUSD, CurrencyKZT)
+	require.NotNil(t, threeHops)
+	assert.Equal(t, 3, threeHops.Hops)
+	assert.Equal(t, []string{CurrencyGBP, CurrencyTRY}, threeHops.Via)
+
+	// EUR -> USD -> GBP -> TRY -> KZT is beyond the triangulation limit
+	assert.Nil(t, findTarget(t, pairs, CurrencyEUR, CurrencyKZT))
+	assert.Equal(t, []string{CurrencyTMT}, pairs.NoPathToBase)
+	for _, source := range pairs.Sources {
+		if source.Currency == CurrencyTMT {
+			assert.Empty(t, source.Targets)
+			assert.Len(t, source.Unreachable, 5)
+		}
+	}
+}
+
+func TestGetSupportedPairs_CachedUntilRatesChange(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+	expectActiveCurrencies(mockRepo, ctx, CurrencyEUR, CurrencyUSD)
+
+	setupRateGraph(mockRepo, []string{CurrencyEUR, CurrencyUSD},
+		testRate(CurrencyUSD, CurrencyEUR, 0.9, time.Hour),
+	)
+
+	first, err := service.GetSupportedPairs(ctx)
+	require.NoError(t, err)
+	second, err := service.GetSupportedPairs(ctx)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	mockRepo.AssertNumberOfCalls(t, "GetActiveCurrencies", 2) // Once for the currencies, once for the graph
+
+	mockRepo.On("BulkCreateExchangeRates", ctx, mock.Anything).Return(nil)
+	require.NoError(t, service.BulkSetExchangeRates(ctx, CurrencyUSD, map[string]float64{CurrencyEUR: 0.95}, time.Hour))
+
+	third, err := service.GetSupportedPairs(ctx)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+	mockRepo.AssertNumberOfCalls(t, "GetActiveCurrencies", 4)
+}
+
+func TestGetSupportedPairs_LoadError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+
+	mockRepo.On("GetActiveCurrencies", mock.Anything).Return(nil, errors.New("db error"))
+
+	pairs, err := service.GetSupportedPairs(context.Background())
+
+	assert.Error(t, err)
+	assert.Nil(t, pairs)
+}
+
+// =============================================================================
+// Test SeedDefaults
+// =============================================================================
+
+func TestSeedDefaults_SecondRunCreatesNothing(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	defaults := DefaultSeedSet().Currencies
+	for _, c := range defaults {
+		// Missing until the first run creates it
+		mockRepo.On("GetCurrencyByCode", ctx, c.Code).Return(nil, fmt.Errorf("failed to get currency: %w", pgx.ErrNoRows)).Once()
+		mockRepo.On("GetCurrencyByCode", ctx, c.Code).Return(&Currency{Code: c.Code, IsActive: true}, nil)
+	}
+	mockRepo.On("CreateCurrency", ctx, mock.AnythingOfType("*currency.Currency")).Return(nil)
+
+	first, err := service.SeedDefaults(ctx)
+	require.NoError(t, err)
+	assert.Len(t, first.CurrenciesCreated, len(defaults))
+	assert.Empty(t, first.CurrenciesExisting)
+
+	second, err := service.SeedDefaults(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, second.CurrenciesCreated)
+	assert.Len(t, second.CurrenciesExisting, len(defaults))
+
+	mockRepo.AssertNumberOfCalls(t, "CreateCurrency", len(defaults))
+	for _, call := range mockRepo.Calls {
+		if call.Method == "CreateCurrency" {
+			created := call.Arguments.Get(1).(*Currency)
+			assert.True(t, created.IsActive)
+			if created.Code == "JPY" {
+				assert.Equal(t, 0, created.DecimalPlaces)
+			}
+		}
+	}
+}
+
+func TestSeedDefaults_CustomSetSeedsMissingRates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	service.SetSeedSet(SeedSet{
+		Currencies: []SeedCurrency{{Code: CurrencyTMT, Name: "Turkmenistan Manat", Symbol: "m", DecimalPlaces: 2}},
+		Rates: []SeedRate{
+			{From: CurrencyUSD, To: CurrencyTMT, Rate: 3.5},
+			{From: CurrencyUSD, To: CurrencyEUR, Rate: 0.9},
+		},
+	})
+
+	noRows := fmt.Errorf("failed to get exchange rate: %w", pgx.ErrNoRows)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyUSD).Return(&Currency{Code: CurrencyUSD, IsActive: true}, nil)
+	mockRepo.On("GetCurrencyByCode", ctx, CurrencyTMT).Return(&Currency{Code: CurrencyTMT, IsActive: true}, nil)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyTMT).Return(nil, noRows)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyTMT, CurrencyUSD).Return(nil, noRows)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyUSD, CurrencyEUR).Return(nil, noRows)
+	mockRepo.On("GetLatestExchangeRate", ctx, CurrencyEUR, CurrencyUSD).Return(testRate(CurrencyEUR, CurrencyUSD, 1.1, time.Hour), nil)
+
+	var seeded *ExchangeRate
+	mockRepo.On("CreateExchangeRate", ctx, mock.AnythingOfType("*currency.ExchangeRate")).Run(func(args mock.Arguments) {
+		seeded = args.Get(1).(*ExchangeRate)
+	}).Return(nil)
+	mockRepo.On("UnpinExchangeRates", ctx, CurrencyUSD, CurrencyTMT, mock.Anything).Return(nil)
+
+	result, err := service.SeedDefaults(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{CurrencyTMT}, result.CurrenciesExisting)
+	assert.Equal(t, []string{"USD/TMT"}, result.RatesSeeded)
+	assert.Equal(t, []string{"USD/EUR"}, result.RatesExisting, "a rate stored the other way round counts")
+	mockRepo.AssertNotCalled(t, "CreateCurrency", mock.Anything, mock.Anything)
+
+	require.NotNil(t, seeded)
+	assert.Equal(t, 3.5, seeded.Rate)
+	assert.Equal(t, string(SourceSeed), seeded.Source)
+	assert.False(t, seeded.Pinned)
+}
+
+func TestLoadSeedSet(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{
+		"currencies": [{"code": "UZS", "name": "Uzbekistani Som", "symbol": "so'm", "decimal_places": 0}],
+		"rates": [{"from": "USD", "to": "UZS", "rate": 12650}],
+		"rate_valid_hours": 48
+	}`), 0o600))
+	seed, err := LoadSeedSet(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "UZS", seed.Currencies[0].Code)
+	assert.Equal(t, 12650.0, seed.Rates[0].Rate)
+	assert.Equal(t, 48, seed.RateValidHours)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"currencies": [{"code": "usd", "name": "US Dollar"}]}`), 0o600))
+	_, err = LoadSeedSet(invalid)
+	assert.ErrorContains(t, err, "invalid currency code")
+}
@@ -0,0 +1,125 @@
+package currency
+
+import (
+	"sync"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RateChangeFunc is told the rate stored for a pair, e.g. so a service caching
+// converted prices can drop the ones derived from it
+type RateChangeFunc func(from, to string, newRate float64)
+
+// rateChange is a stored rate waiting to be handed to a subscriber
+type rateChange struct {
+	from, to string
+	rate     float64
+}
+
+// rateSubscriber is one subscriber's pending changes. Changes are coalesced by
+// pair: a pair changed again before the subscriber got to it is delivered once,
+// with its latest rate, so a slow subscriber falls behind but never misses a
+// pair and the backlog is bounded by the number of pairs.
+type rateSubscriber struct {
+	mu      sync.Mutex
+	pending map[string]rateChange
+	order   []string      // Pairs in pending, in the order they first changed
+	notify  chan struct{} // Signalled when pending has changes
+}
+
+func newRateSubscriber() *rateSubscriber {
+	return &rateSubscriber{
+		pending: make(map[string]rateChange),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// add records a change as pending, replacing any pending change for the pair,
+// and wakes the subscriber without blocking
+func (r *rateSubscriber) add(change rateChange) {
+	key := change.from + "/" + change.to
+
+	r.mu.Lock()
+	if _, ok := r.pending[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.pending[key] = change
+	r.mu.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take empties the pending changes, returning them in the order they came in
+func (r *rateSubscriber) take() []rateChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := make([]rateChange, 0, len(r.order))
+	for _, key := range r.order {
+		changes = append(changes, r.pending[key])
+	}
+	r.pending = make(map[string]rateChange)
+	r.order = nil
+	return changes
+}
+
+// rateSubscribers holds the subscribers to rate changes
+type rateSubscribers struct {
+	mu          sync.RWMutex
+	subscribers []*rateSubscriber
+}
+
+// SubscribeRateChanges calls fn for every rate stored once the write has
+// committed: rates set manually, in bulk, imported, fetched from the rate
+// provider or replaced when reconciling inverses. Each subscriber is called in
+// the background, so a slow subscriber holds up neither rate writes nor other
+// subscribers. Changes a slow subscriber hasn't got to yet are coalesced by
+// pair, so it may skip intermediate rates but always gets the latest rate for
+// every pair that changed. Call it during startup.
+func (s *Service) SubscribeRateChanges(fn RateChangeFunc) {
+	subscriber := newRateSubscriber()
+
+	s.subscribers.mu.Lock()
+	s.subscribers.subscribers = append(s.subscribers.subscribers, subscriber)
+	s.subscribers.mu.Unlock()
+
+	go func() {
+		for range subscriber.notify {
+			for _, change := range subscriber.take() {
+				runRateChangeSubscriber(fn, change)
+			}
+		}
+	}()
+}
+
+// runRateChangeSubscriber calls the subscriber for one change, containing any
+// panic so that one bad delivery doesn't stop the rest
+func runRateChangeSubscriber(fn RateChangeFunc, change rateChange) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Rate change subscriber panicked",
+				zap.String("from", change.from),
+				zap.String("to", change.to),
+				zap.Any("panic", r),
+			)
+		}
+	}()
+
+	fn(change.from, change.to, change.rate)
+}
+
+// publishRateChanges hands stored rates to every subscriber without blocking
+func (s *Service) publishRateChanges(rates ...*ExchangeRate) {
+	s.subscribers.mu.RLock()
+	defer s.subscribers.mu.RUnlock()
+
+	for _, subscriber := range s.subscribers.subscribers {
+		for _, rate := range rates {
+			subscriber.add(rateChange{from: rate.FromCurrency, to: rate.ToCurrency, rate: rate.Rate})
+		}
+	}
+}
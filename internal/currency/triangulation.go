@@ -0,0 +1,135 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxTriangulationHops caps a triangulated path at two intermediate currencies
+const maxTriangulationHops = 3
+
+// rateEdge is a directed conversion between two currencies
+type rateEdge struct {
+	to   string
+	rate *ExchangeRate // Oriented so that rate.FromCurrency is the edge source
+}
+
+// findRatePath searches every stored currency pair for the shortest conversion path
+// from -> to with at most maxTriangulationHops legs. The search is breadth-first with a
+// visited set, so each currency is expanded once and cycles are never followed.
+func (s *Service) findRatePath(ctx context.Context, from, to string) ([]*ExchangeRate, error) {
+	graph, err := s.loadRateGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type node struct {
+		currency string
+		path     []*ExchangeRate
+	}
+
+	visited := map[string]bool{from: true}
+	queue := []node{{currency: from}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if len(current.path) >= maxTriangulationHops {
+			continue
+		}
+
+		for _, edge := range graph[current.currency] {
+			if visited[edge.to] {
+				continue
+			}
+
+			path := make([]*ExchangeRate, len(current.path), len(current.path)+1)
+			copy(path, current.path)
+			path = append(path, edge.rate)
+
+			if edge.to == to {
+				return path, nil
+			}
+
+			visited[edge.to] = true
+			queue = append(queue, node{currency: edge.to, path: path})
+		}
+	}
+
+	return nil, fmt.Errorf("no rate path within %d hops from %s to %s", maxTriangulationHops, from, to)
+}
+
+// loadRateGraph builds an adjacency list of valid rates between active currencies.
+// Every stored rate is usable in both directions.
+func (s *Service) loadRateGraph(ctx context.Context) (map[string][]rateEdge, error) {
+	currencies, err := s.repo.GetActiveCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]rateEdge)
+	seen := make(map[string]bool)
+	for _, c := range currencies {
+		rates, err := s.repo.GetAllExchangeRatesFromBase(ctx, c.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range rates {
+			if r.Rate <= 0 || r.InverseRate <= 0 {
+				continue
+			}
+			key := r.FromCurrency + "-" + r.ToCurrency
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			graph[r.FromCurrency] = append(graph[r.FromCurrency], rateEdge{to: r.ToCurrency, rate: r})
+			graph[r.ToCurrency] = append(graph[r.ToCurrency], rateEdge{to: r.FromCurrency, rate: invertRate(r)})
+		}
+	}
+
+	// Stable neighbour order keeps the chosen path deterministic
+	for code := range graph {
+		edges := graph[code]
+		sort.Slice(edges, func(i, j int) bool { return edges[i].to < edges[j].to })
+	}
+
+	return graph, nil
+}
+
+// triangulatedRate combines a path of rates into a single from -> to rate that
+// expires with the earliest unpinned leg. A path of pinned legs only is re-derived
+// after providerRateTTL, so changes to the pins are picked up.
+func triangulatedRate(from, to string, path []*ExchangeRate) *ExchangeRate {
+	rate := 1.0
+	var validUntil time.Time
+	for _, leg := range path {
+		rate *= leg.Rate
+		if !leg.Pinned && (validUntil.IsZero() || leg.ValidUntil.Before(validUntil)) {
+			validUntil = leg.ValidUntil
+		}
+	}
+	if validUntil.IsZero() {
+		validUntil = time.Now().Add(providerRateTTL)
+	}
+
+	return &ExchangeRate{
+		ID:           uuid.Nil,
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         rate,
+		InverseRate:  1 / rate,
+		Source:       "triangulated",
+		FetchedAt:    time.Now(),
+		ValidUntil:   validUntil,
+		derivation:   DerivationTriangulated,
+		legs:         path,
+	}
+}
@@ -0,0 +1,149 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// fileCleanupBatchSize is how many deleted documents each cleanup pass retries
+const fileCleanupBatchSize = 100
+
+// DeleteDocument soft-deletes a document, keeping its record and history, then
+// removes its files from storage and re-evaluates the driver. Drivers may only
+// delete their own documents. An approved required document keeps the driver
+// verified, so it can only be deleted by a reviewer with force.
+//
+// The record is marked deleted before its files are removed, so a live document
+// never loses its files. If removing them fails, the document stays marked as
+// having files in storage and CleanupDeletedDocumentFiles retries later.
+func (s *Service) DeleteDocument(ctx context.Context, documentID, actorID uuid.UUID, requester DocumentRequester, force bool) error {
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || doc.Status == StatusDeleted {
+		return common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "document not found", nil)
+	}
+
+	if !requester.IsReviewer && doc.DriverID != requester.DriverID {
+		return common.NewErrorWithCode(http.StatusForbidden, common.ErrCodeForbidden, "not your document", nil)
+	}
+
+	if doc.Status == StatusApproved && doc.DocumentType != nil && doc.DocumentType.IsRequired && !(requester.IsReviewer && force) {
+		return common.NewErrorWithCode(http.StatusConflict, common.ErrCodeDocumentInUse,
+			"approved required documents can only be deleted by an admin with force", common.ErrConflict)
+	}
+
+	if err := s.repo.SoftDeleteDocument(ctx, documentID, actorID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "document not found", nil)
+		}
+		return common.NewInternalError("failed to delete document", err)
+	}
+
+	notes := "Deleted by driver"
+	if requester.IsReviewer {
+		notes = "Deleted by admin"
+		if force {
+			notes = "Deleted by admin (forced)"
+		}
+	}
+	s.logHistory(ctx, documentID, "deleted", string(doc.Status), string(StatusDeleted), &actorID, false, notes)
+	if requester.IsReviewer {
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    actorID,
+			Action:     "document.deleted",
+			TargetType: "driver_document",
+			TargetID:   documentID.String(),
+			Before:     map[string]interface{}{"status": string(doc.Status)},
+			After:      map[string]interface{}{"status": string(StatusDeleted), "forced": force},
+		})
+	}
+
+	s.deleteDocumentFiles(ctx, doc)
+
+	_, _ = s.recomputeVerification(ctx, doc.DriverID, map[uuid.UUID]DocumentStatus{documentID: doc.Status})
+
+	logger.InfoContext(ctx, "Document deleted",
+		zap.String("document_id", documentID.String()),
+		zap.String("driver_id", doc.DriverID.String()),
+		zap.String("actor_id", actorID.String()),
+		zap.Bool("forced", force),
+	)
+
+	return nil
+}
+
+// CleanupDeletedDocumentFiles retries removing the files of deleted documents
+// whose earlier removal failed, and returns how many documents were cleaned up
+func (s *Service) CleanupDeletedDocumentFiles(ctx context.Context) (int, error) {
+	docs, err := s.repo.GetDocumentsPendingFileCleanup(ctx, fileCleanupBatchSize)
+	if err != nil {
+		return 0, common.NewInternalError("failed to get deleted documents", err)
+	}
+
+	cleaned := 0
+	for _, doc := range docs {
+		if s.deleteDocumentFiles(ctx, doc) {
+			cleaned++
+		}
+	}
+
+	if len(docs) > 0 {
+		logger.InfoContext(ctx, "Cleaned up deleted document files",
+			zap.Int("documents", len(docs)),
+			zap.Int("cleaned", cleaned),
+		)
+	}
+
+	return cleaned, nil
+}
+
+// deleteDocumentFiles removes every stored file of a deleted document and records
+// that they are gone. It reports false, leaving the document for a later
+// cleanup pass, if any file could not be removed.
+func (s *Service) deleteDocumentFiles(ctx context.Context, doc *DriverDocument) bool {
+	return s.removeDocumentFiles(ctx, doc, documentFileKeys(doc))
+}
+
+// documentFileKeys returns the storage keys of every file stored for a document
+func documentFileKeys(doc *DriverDocument) []string {
+	var keys []string
+	for _, key := range []*string{&doc.FileKey, doc.BackFileKey, doc.OriginalFileKey, doc.ThumbnailKey} {
+		if key != nil && *key != "" {
+			keys = append(keys, *key)
+		}
+	}
+	return keys
+}
+
+// removeDocumentFiles removes the given files of a document from storage and
+// records that the document's files are gone. It reports false, leaving the
+// document for a later pass, if any file could not be removed.
+func (s *Service) removeDocumentFiles(ctx context.Context, doc *DriverDocument, keys []string) bool {
+	ok := true
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			logger.WarnContext(ctx, "Failed to delete document file, will retry",
+				zap.String("document_id", doc.ID.String()),
+				zap.Error(err),
+			)
+			ok = false
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	if err := s.repo.MarkDocumentFilesDeleted(ctx, doc.ID); err != nil {
+		logger.WarnContext(ctx, "Failed to record deleted document files",
+			zap.String("document_id", doc.ID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// maxSharedFileMatches bounds how many other drivers' documents a flagged
+// upload names in its history
+const maxSharedFileMatches = 5
+
+// contentHasher hashes an upload as it is read, so hashing needs no copy of the file
+type contentHasher struct {
+	reader io.Reader
+	hash   hash.Hash
+	read   int64
+}
+
+func newContentHasher(reader io.Reader) *contentHasher {
+	return &contentHasher{reader: reader, hash: sha256.New()}
+}
+
+func (h *contentHasher) Read(p []byte) (int, error) {
+	n, err := h.reader.Read(p)
+	h.hash.Write(p[:n])
+	h.read += int64(n)
+	return n, err
+}
+
+// Seek lets a storage retry replay the upload when the underlying reader can
+// seek. Rewinding to the start of the upload starts the hash over.
+func (h *contentHasher) Seek(offset int64, whence int) (int64, error) {
+	pos, restarted, err := seekPassThrough(h.reader, h.read, offset, whence)
+	if restarted {
+		h.hash.Reset()
+		h.read = 0
+	}
+	return pos, err
+}
+
+// sum reads whatever of the upload is still unread and returns the SHA-256 of
+// the whole upload, hex encoded
+func (h *contentHasher) sum() (string, error) {
+	if _, err := io.Copy(io.Discard, h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.hash.Sum(nil)), nil
+}
+
+// repeatsRejectedFile reports whether an upload is the very file of the rejected
+// document it replaces, which a reviewer has already turned down
+func repeatsRejectedFile(replaced *DriverDocument, contentHash string) bool {
+	return replaced != nil && replaced.Status == StatusRejected &&
+		replaced.ContentHash != nil && *replaced.ContentHash == contentHash
+}
+
+// errRepeatedRejectedFile is returned for an upload of a file already rejected
+func errRepeatedRejectedFile() error {
+	return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDuplicateFile, "this file was already rejected, please upload a new photo of the document", nil)
+}
+
+// sharedFileMatches returns other drivers' documents with the same file as an
+// upload. A failed lookup is logged and treated as no match, so it never fails
+// the upload.
+func (s *Service) sharedFileMatches(ctx context.Context, doc *DriverDocument) []*DriverDocument {
+	matches, err := s.repo.GetDocumentsByContentHash(ctx, *doc.ContentHash, doc.DriverID, maxSharedFileMatches)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to check upload against other drivers' documents",
+			zap.String("driver_id", doc.DriverID.String()), zap.Error(err))
+		return nil
+	}
+	return matches
+}
+
+// flagSharedFile records on a document's history that its file is the same as
+// other drivers' documents, for a reviewer to look into
+func (s *Service) flagSharedFile(ctx context.Context, doc *DriverDocument, matches []*DriverDocument) {
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, fmt.Sprintf("%s (driver %s)", match.ID, match.DriverID))
+	}
+	note := "Same file as another driver's document: " + strings.Join(ids, ", ")
+	s.logHistory(ctx, doc.ID, "duplicate_flagged", "", "", nil, true, note)
+
+	logger.WarnContext(ctx, "Uploaded document file matches another driver's document",
+		zap.String("document_id", doc.ID.String()),
+		zap.String("driver_id", doc.DriverID.String()),
+		zap.Int("matches", len(matches)),
+	)
+}
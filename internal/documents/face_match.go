@@ -0,0 +1,148 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultFaceMatchThreshold is used when FaceMatchThreshold is not set
+	defaultFaceMatchThreshold = 0.8
+	// defaultSelfieDocumentType is used when SelfieDocumentType is not set
+	defaultSelfieDocumentType = "profile_photo"
+	// defaultIDDocumentType is used when IDDocumentType is not set
+	defaultIDDocumentType = "drivers_license"
+)
+
+// errFaceMatchingDisabled is returned by the no-op face matcher
+var errFaceMatchingDisabled = errors.New("face matching is not enabled")
+
+// FaceMatcher compares the face in a selfie with the photo on an ID document,
+// both given by storage key, and scores the likeness from 0 (different people)
+// to 1 (same person)
+type FaceMatcher interface {
+	Compare(ctx context.Context, selfieKey, idPhotoKey string) (float64, error)
+}
+
+// NoopFaceMatcher compares nothing, leaving identity matching off until a real
+// matcher is set
+type NoopFaceMatcher struct{}
+
+func (NoopFaceMatcher) Compare(ctx context.Context, selfieKey, idPhotoKey string) (float64, error) {
+	return 0, errFaceMatchingDisabled
+}
+
+// SetFaceMatcher sets the matcher VerifyIdentityMatch uses. A nil matcher turns
+// identity matching off.
+func (s *Service) SetFaceMatcher(matcher FaceMatcher) {
+	s.faceMatcher = matcher
+}
+
+// faceMatchThreshold returns the score a selfie must reach to match the ID
+func (s *Service) faceMatchThreshold() float64 {
+	if s.config.FaceMatchThreshold <= 0 {
+		return defaultFaceMatchThreshold
+	}
+	return s.config.FaceMatchThreshold
+}
+
+// identityDocumentTypes returns the codes of the selfie and ID document types
+func (s *Service) identityDocumentTypes() (string, string) {
+	selfie, id := s.config.SelfieDocumentType, s.config.IDDocumentType
+	if selfie == "" {
+		selfie = defaultSelfieDocumentType
+	}
+	if id == "" {
+		id = defaultIDDocumentType
+	}
+	return selfie, id
+}
+
+// VerifyIdentityMatch compares a driver's approved selfie with the photo on their
+// approved ID and stores the score on the selfie. A score below the threshold
+// sends the selfie back to the review queue, so a reviewer decides whether the
+// driver is who their ID says; the driver's verification status is recomputed
+// accordingly. Either way the result is recorded in the selfie's history.
+func (s *Service) VerifyIdentityMatch(ctx context.Context, driverID uuid.UUID) (*IdentityMatchResult, error) {
+	selfieType, idType := s.identityDocumentTypes()
+
+	documents, err := s.repo.GetDriverDocuments(ctx, driverID)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get driver documents", err)
+	}
+
+	var selfie, id *DriverDocument
+	for _, doc := range documents {
+		if doc.Status != StatusApproved || doc.DocumentType == nil {
+			continue
+		}
+		switch doc.DocumentType.Code {
+		case selfieType:
+			selfie = doc
+		case idType:
+			id = doc
+		}
+	}
+	if selfie == nil {
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, fmt.Sprintf("driver has no approved %s document", selfieType), nil)
+	}
+	if id == nil {
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, fmt.Sprintf("driver has no approved %s document", idType), nil)
+	}
+
+	matcher := s.faceMatcher
+	if matcher == nil {
+		matcher = NoopFaceMatcher{}
+	}
+	score, err := matcher.Compare(ctx, selfie.FileKey, id.FileKey)
+	if errors.Is(err, errFaceMatchingDisabled) {
+		return nil, common.NewServiceUnavailableError("face matching is not enabled")
+	}
+	if err != nil {
+		return nil, common.NewInternalError("failed to compare selfie with ID photo", err)
+	}
+
+	if err := s.repo.UpdateDocumentFaceMatch(ctx, selfie.ID, score, time.Now()); err != nil {
+		return nil, common.NewInternalError("failed to store face match score", err)
+	}
+
+	threshold := s.faceMatchThreshold()
+	result := &IdentityMatchResult{
+		DriverID:         driverID,
+		SelfieDocumentID: selfie.ID,
+		IDDocumentID:     id.ID,
+		Score:            score,
+		Threshold:        threshold,
+		Matched:          score >= threshold,
+	}
+
+	if result.Matched {
+		s.logHistory(ctx, selfie.ID, "face_match_passed", "", "", nil, true,
+			fmt.Sprintf("Selfie matches ID photo with score %.2f", score))
+		return result, nil
+	}
+
+	note := fmt.Sprintf("Selfie matches ID photo with score %.2f, below %.2f", score, threshold)
+	if err := s.repo.UpdateDocumentStatus(ctx, selfie.ID, StatusPending, nil, &note, nil); err != nil {
+		return nil, common.NewInternalError("failed to flag selfie for review", err)
+	}
+	s.logHistory(ctx, selfie.ID, "face_match_flagged", string(StatusApproved), string(StatusPending), nil, true, note)
+	result.FlaggedForReview = true
+
+	_, _ = s.recomputeVerification(ctx, driverID, map[uuid.UUID]DocumentStatus{selfie.ID: StatusApproved})
+
+	logger.WarnContext(ctx, "Driver selfie does not match ID photo",
+		zap.String("driver_id", driverID.String()),
+		zap.String("document_id", selfie.ID.String()),
+		zap.Float64("score", score),
+	)
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package documents
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ForceApproveDocument approves a document an admin has verified out-of-band,
+// skipping the review queue and claims. Pending, under review, rejected and
+// expired documents can be force approved; superseded and deleted ones cannot.
+// The justification is required and is kept in the document's history and the
+// audit log. A document whose expiry, as supplied or as stored, is in the past
+// is refused unless AllowExpired is set. The driver's verification status is
+// recomputed afterwards.
+func (s *Service) ForceApproveDocument(ctx context.Context, documentID, adminID uuid.UUID, req *ForceApproveRequest) error {
+	justification := strings.TrimSpace(req.Justification)
+	if justification == "" {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "justification is required", nil)
+	}
+
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || doc.Status == StatusDeleted {
+		return common.NewNotFoundError("document not found", err)
+	}
+
+	switch doc.Status {
+	case StatusApproved:
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentNotReviewable, "document is already approved", nil)
+	case StatusSuperseded:
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentNotReviewable, "document has been superseded", nil)
+	}
+
+	expiryDate := doc.ExpiryDate
+	if req.ExpiryDate != nil {
+		t, err := time.Parse("2006-01-02", *req.ExpiryDate)
+		if err != nil {
+			return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "expiry_date must be in YYYY-MM-DD format", nil)
+		}
+		expiryDate = &t
+	}
+	expired := isExpired(expiryDate, time.Now())
+	if expired && !req.AllowExpired {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentExpired, "document has already expired", nil)
+	}
+
+	if req.DocumentNumber != nil || req.ExpiryDate != nil {
+		if err := s.repo.UpdateDocumentDetails(ctx, documentID, req.DocumentNumber, nil, expiryDate, nil); err != nil {
+			return common.NewInternalError("failed to update document", err)
+		}
+	}
+
+	if err := s.repo.UpdateDocumentStatus(ctx, documentID, StatusApproved, &adminID, &justification, nil); err != nil {
+		return common.NewInternalError("failed to update document", err)
+	}
+
+	previousStatus := string(doc.Status)
+	s.logHistory(ctx, documentID, "force_approved", previousStatus, string(StatusApproved), &adminID, false, justification)
+
+	after := map[string]interface{}{
+		"status":        string(StatusApproved),
+		"justification": justification,
+	}
+	if expired {
+		after["expiry_overridden"] = true
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    adminID,
+		Action:     "document.force_approve",
+		TargetType: "driver_document",
+		TargetID:   documentID.String(),
+		Before:     map[string]interface{}{"status": previousStatus},
+		After:      after,
+	})
+
+	_, _ = s.recomputeVerification(ctx, doc.DriverID, map[uuid.UUID]DocumentStatus{documentID: doc.Status})
+
+	logger.WarnContext(ctx, "Document force approved",
+		zap.String("document_id", documentID.String()),
+		zap.String("previous_status", previousStatus),
+		zap.String("admin_id", adminID.String()),
+		zap.Bool("expiry_overridden", expired),
+	)
+
+	return nil
+}
@@ -2,8 +2,11 @@ package documents
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,8 +19,9 @@ import (
 
 // Handler handles HTTP requests for documents
 type Handler struct {
-	service       *Service
-	driverService DriverServiceInterface
+	service           *Service
+	driverService     DriverServiceInterface
+	ocrCallbackSecret string
 }
 
 // DriverServiceInterface defines methods needed from driver service
@@ -33,6 +37,12 @@ func NewHandler(service *Service, driverService DriverServiceInterface) *Handler
 	}
 }
 
+// SetOCRCallbackSecret sets the secret external OCR providers sign their
+// callbacks with. Callbacks are refused while it is empty.
+func (h *Handler) SetOCRCallbackSecret(secret string) {
+	h.ocrCallbackSecret = secret
+}
+
 // getDriverID gets the driver ID from the authenticated user
 func (h *Handler) getDriverID(c *gin.Context) (uuid.UUID, error) {
 	userID, err := middleware.GetUserID(c)
@@ -65,6 +75,18 @@ func (h *Handler) GetDocumentTypes(c *gin.Context) {
 	common.SuccessResponse(c, DocumentTypeListResponse{DocumentTypes: types})
 }
 
+// GetVerificationRequirements lists the documents a prospective driver will need
+// GET /api/v1/documents/requirements?country=US
+func (h *Handler) GetVerificationRequirements(c *gin.Context) {
+	requirements, err := h.service.GetVerificationRequirements(c.Request.Context(), c.Query("country"))
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, requirements)
+}
+
 // ========================================
 // DRIVER DOCUMENT ENDPOINTS
 // ========================================
@@ -88,7 +110,7 @@ func (h *Handler) GetMyDocuments(c *gin.Context) {
 }
 
 // GetMyVerificationStatus gets the driver's verification status
-// GET /api/v1/documents/verification-status
+// GET /api/v1/documents/verification-status?recompute=true
 func (h *Handler) GetMyVerificationStatus(c *gin.Context) {
 	driverID, err := h.getDriverID(c)
 	if err != nil {
@@ -96,13 +118,9 @@ func (h *Handler) GetMyVerificationStatus(c *gin.Context) {
 		return
 	}
 
-	status, err := h.service.GetDriverVerificationStatus(c.Request.Context(), driverID)
+	status, err := h.verificationStatus(c, driverID)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get verification status")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -140,6 +158,15 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 		IssuingAuthority: c.PostForm("issuing_authority"),
 	}
 
+	if resubmitFor := c.PostForm("resubmit_for_document_id"); resubmitFor != "" {
+		documentID, err := uuid.Parse(resubmitFor)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid resubmit_for_document_id")
+			return
+		}
+		req.ResubmitForDocumentID = &documentID
+	}
+
 	// Parse dates if provided
 	if issueDate := c.PostForm("issue_date"); issueDate != "" {
 		// Parse as time.Time
@@ -160,11 +187,7 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 	)
 
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to upload document")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -215,11 +238,7 @@ func (h *Handler) UploadDocumentBackSide(c *gin.Context) {
 		header.Filename,
 		header.Header.Get("Content-Type"),
 	); err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to upload back side")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -243,11 +262,7 @@ func (h *Handler) GetPresignedUploadURL(c *gin.Context) {
 
 	response, err := h.service.GetPresignedUploadURL(c.Request.Context(), driverID, &req)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to generate upload URL")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -271,11 +286,7 @@ func (h *Handler) CompleteDirectUpload(c *gin.Context) {
 
 	response, err := h.service.CompleteDirectUpload(c.Request.Context(), driverID, &req)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to complete upload")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -311,16 +322,142 @@ func (h *Handler) GetDocument(c *gin.Context) {
 	common.SuccessResponse(c, doc)
 }
 
+// GetDocumentDownloadURL returns a short-lived download link for a document file
+// GET /api/v1/documents/:id/download?side=front|back|thumbnail
+func (h *Handler) GetDocumentDownloadURL(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid document ID")
+		return
+	}
+
+	var requester DocumentRequester
+	role, _ := middleware.GetUserRole(c)
+	if role == models.RoleAdmin {
+		requester.IsReviewer = true
+	} else {
+		driverID, err := h.getDriverID(c)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusForbidden, "not your document")
+			return
+		}
+		requester.DriverID = driverID
+	}
+
+	side := DocumentSide(c.DefaultQuery("side", string(DocumentSideFront)))
+	response, err := h.service.GetDocumentDownloadURL(c.Request.Context(), documentID, side, requester)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, response)
+}
+
+// GetDocumentHistory returns a document's history as a timeline
+// GET /api/v1/documents/:id/history
+func (h *Handler) GetDocumentHistory(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid document ID")
+		return
+	}
+
+	var requester DocumentRequester
+	role, _ := middleware.GetUserRole(c)
+	if role == models.RoleAdmin {
+		requester.IsReviewer = true
+	} else {
+		driverID, err := h.getDriverID(c)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusForbidden, "not your document")
+			return
+		}
+		requester.DriverID = driverID
+	}
+
+	response, err := h.service.GetDocumentHistory(c.Request.Context(), documentID, requester)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, response)
+}
+
+// DeleteDocument deletes a document uploaded by mistake. Admins may pass
+// force=true to delete an approved document the driver relies on.
+// DELETE /api/v1/documents/:id
+// DELETE /api/v1/admin/documents/:id?force=true
+func (h *Handler) DeleteDocument(c *gin.Context) {
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid document ID")
+		return
+	}
+
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var requester DocumentRequester
+	force := false
+	role, _ := middleware.GetUserRole(c)
+	if role == models.RoleAdmin {
+		requester.IsReviewer = true
+		force = c.Query("force") == "true"
+	} else {
+		driverID, err := h.getDriverID(c)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusForbidden, "not your document")
+			return
+		}
+		requester.DriverID = driverID
+	}
+
+	if err := h.service.DeleteDocument(c.Request.Context(), documentID, actorID, requester, force); err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"message": "Document deleted"})
+}
+
 // ========================================
 // ADMIN ENDPOINTS
 // ========================================
 
-// GetPendingReviews gets documents pending review
-// GET /api/v1/admin/documents/pending
+// GetPendingReviews gets documents pending review. With ?unclaimed=true,
+// documents other reviewers are currently reviewing are left out. With a cursor
+// parameter (empty for the first page) it pages by cursor instead of offset.
+// GET /api/v1/admin/documents/pending?limit=20&offset=0
+// GET /api/v1/admin/documents/pending?limit=20&cursor=
 func (h *Handler) GetPendingReviews(c *gin.Context) {
 	params := pagination.ParseParams(c)
 
-	reviews, total, err := h.service.GetPendingReviews(c.Request.Context(), params.Limit, params.Offset)
+	var excludeClaimedFor *uuid.UUID
+	if c.Query("unclaimed") == "true" {
+		reviewerID, err := middleware.GetUserID(c)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		excludeClaimedFor = &reviewerID
+	}
+
+	if pagination.WantsCursor(c) {
+		page, err := h.service.GetPendingReviewsCursor(c.Request.Context(), c.Query(pagination.CursorParam), params.Limit, excludeClaimedFor)
+		if err != nil {
+			common.RespondError(c, err)
+			return
+		}
+		common.SuccessResponse(c, page)
+		return
+	}
+
+	reviews, total, err := h.service.GetPendingReviews(c.Request.Context(), params.Limit, params.Offset, excludeClaimedFor)
 	if err != nil {
 		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get pending reviews")
 		return
@@ -346,6 +483,49 @@ func (h *Handler) GetExpiringDocuments(c *gin.Context) {
 	common.SuccessResponse(c, gin.H{"documents": expiring})
 }
 
+// GetVerificationFunnel gets aggregate verification funnel metrics
+// GET /api/v1/admin/documents/funnel
+func (h *Handler) GetVerificationFunnel(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid since date, expected YYYY-MM-DD")
+			return
+		}
+		since = parsed
+	}
+
+	funnel, err := h.service.GetVerificationFunnel(c.Request.Context(), since)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, funnel)
+}
+
+// GetReviewMetrics gets review times, reviewer throughput and the review queue against the SLA
+// GET /api/v1/admin/documents/review-metrics?window_days=7
+func (h *Handler) GetReviewMetrics(c *gin.Context) {
+	windowDays := 0
+	if raw := c.Query("window_days"); raw != "" {
+		var err error
+		if windowDays, err = strconv.Atoi(raw); err != nil || windowDays <= 0 {
+			common.ErrorResponse(c, http.StatusBadRequest, "window_days must be a positive integer")
+			return
+		}
+	}
+
+	metrics, err := h.service.GetReviewMetrics(c.Request.Context(), time.Duration(windowDays)*24*time.Hour)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, metrics)
+}
+
 // StartDocumentReview marks a document as under review
 // POST /api/v1/admin/documents/:id/start-review
 func (h *Handler) StartDocumentReview(c *gin.Context) {
@@ -363,11 +543,7 @@ func (h *Handler) StartDocumentReview(c *gin.Context) {
 	}
 
 	if err := h.service.StartReview(c.Request.Context(), documentID, reviewerID); err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to start review")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -397,17 +573,67 @@ func (h *Handler) ReviewDocument(c *gin.Context) {
 	}
 
 	if err := h.service.ReviewDocument(c.Request.Context(), documentID, reviewerID, &req); err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to review document")
+		common.RespondError(c, err)
 		return
 	}
 
 	common.SuccessResponse(c, gin.H{"message": "Document reviewed successfully"})
 }
 
+// ForceApproveDocument approves a document that was verified out-of-band
+// POST /api/v1/admin/documents/:id/force-approve
+func (h *Handler) ForceApproveDocument(c *gin.Context) {
+	documentIDStr := c.Param("id")
+	documentID, err := uuid.Parse(documentIDStr)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid document ID")
+		return
+	}
+
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ForceApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.ForceApproveDocument(c.Request.Context(), documentID, adminID, &req); err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"message": "Document approved"})
+}
+
+// BulkReviewDocuments reviews several documents in one request
+// POST /api/v1/admin/documents/bulk-review
+func (h *Handler) BulkReviewDocuments(c *gin.Context) {
+	reviewerID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req BulkReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.service.BulkReviewDocuments(c.Request.Context(), reviewerID, req.Items)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, response)
+}
+
 // GetDriverDocuments gets all documents for a specific driver (admin)
 // GET /api/v1/admin/drivers/:driver_id/documents
 func (h *Handler) GetDriverDocumentsAdmin(c *gin.Context) {
@@ -428,7 +654,7 @@ func (h *Handler) GetDriverDocumentsAdmin(c *gin.Context) {
 }
 
 // GetDriverVerificationStatusAdmin gets verification status for a driver (admin)
-// GET /api/v1/admin/drivers/:driver_id/verification-status
+// GET /api/v1/admin/drivers/:driver_id/verification-status?recompute=true
 func (h *Handler) GetDriverVerificationStatusAdmin(c *gin.Context) {
 	driverIDStr := c.Param("driver_id")
 	driverID, err := uuid.Parse(driverIDStr)
@@ -437,7 +663,7 @@ func (h *Handler) GetDriverVerificationStatusAdmin(c *gin.Context) {
 		return
 	}
 
-	status, err := h.service.GetDriverVerificationStatus(c.Request.Context(), driverID)
+	status, err := h.verificationStatus(c, driverID)
 	if err != nil {
 		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get verification status")
 		return
@@ -446,6 +672,79 @@ func (h *Handler) GetDriverVerificationStatusAdmin(c *gin.Context) {
 	common.SuccessResponse(c, status)
 }
 
+// VerifyIdentityMatch matches a driver's selfie against their ID photo (admin)
+// POST /api/v1/admin/drivers/:driver_id/identity-match
+func (h *Handler) VerifyIdentityMatch(c *gin.Context) {
+	driverIDStr := c.Param("driver_id")
+	driverID, err := uuid.Parse(driverIDStr)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid driver ID")
+		return
+	}
+
+	result, err := h.service.VerifyIdentityMatch(c.Request.Context(), driverID)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
+// verificationStatus returns a driver's stored verification status, recomputing
+// it from their documents first when the request has recompute=true
+func (h *Handler) verificationStatus(c *gin.Context, driverID uuid.UUID) (*VerificationStatusResponse, error) {
+	if recompute, _ := strconv.ParseBool(c.Query("recompute")); recompute {
+		return h.service.RecomputeAndPersistVerificationStatus(c.Request.Context(), driverID)
+	}
+	return h.service.GetDriverVerificationStatus(c.Request.Context(), driverID)
+}
+
+// ========================================
+// OCR PROVIDER CALLBACKS
+// ========================================
+
+// HandleOCRCallback records an OCR result posted by an external provider
+// POST /api/v1/internal/ocr/callback
+func (h *Handler) HandleOCRCallback(c *gin.Context) {
+	if h.ocrCallbackSecret == "" {
+		common.ErrorResponse(c, http.StatusServiceUnavailable, "OCR callbacks are not enabled")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxOCRCallbackBytes+1))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if len(body) > maxOCRCallbackBytes {
+		common.ErrorResponse(c, http.StatusRequestEntityTooLarge, "OCR callback is too large")
+		return
+	}
+	if !validOCRSignature(h.ocrCallbackSecret, body, c.GetHeader(ocrSignatureHeader)) {
+		common.ErrorResponse(c, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	var req OCRCallbackRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid OCR callback payload")
+		return
+	}
+
+	duplicate, err := h.service.ProcessOCRCallback(c.Request.Context(), &req)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	status := "processed"
+	if duplicate {
+		status = "duplicate"
+	}
+	common.SuccessResponse(c, gin.H{"status": status})
+}
+
 // ========================================
 // ROUTE REGISTRATION
 // ========================================
@@ -456,6 +755,7 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 	docs := r.Group("/api/v1/documents")
 	{
 		docs.GET("/types", h.GetDocumentTypes)
+		docs.GET("/requirements", h.GetVerificationRequirements)
 	}
 
 	// Driver routes (authenticated)
@@ -469,7 +769,10 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 		driverDocs.POST("/presigned-upload", h.GetPresignedUploadURL)
 		driverDocs.POST("/upload-complete", h.CompleteDirectUpload)
 		driverDocs.GET("/:id", h.GetDocument)
+		driverDocs.GET("/:id/download", h.GetDocumentDownloadURL)
+		driverDocs.GET("/:id/history", h.GetDocumentHistory)
 		driverDocs.POST("/:id/back", h.UploadDocumentBackSide)
+		driverDocs.DELETE("/:id", h.DeleteDocument)
 	}
 
 	// Admin routes
@@ -479,8 +782,15 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 	{
 		adminDocs.GET("/pending", h.GetPendingReviews)
 		adminDocs.GET("/expiring", h.GetExpiringDocuments)
+		adminDocs.GET("/funnel", h.GetVerificationFunnel)
+		adminDocs.GET("/review-metrics", h.GetReviewMetrics)
 		adminDocs.POST("/:id/start-review", h.StartDocumentReview)
 		adminDocs.POST("/:id/review", h.ReviewDocument)
+		adminDocs.POST("/:id/force-approve", h.ForceApproveDocument)
+		adminDocs.POST("/bulk-review", h.BulkReviewDocuments)
+		adminDocs.GET("/:id/download", h.GetDocumentDownloadURL)
+		adminDocs.GET("/:id/history", h.GetDocumentHistory)
+		adminDocs.DELETE("/:id", h.DeleteDocument)
 	}
 
 	// Admin driver documents
@@ -490,7 +800,16 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 	{
 		adminDriverDocs.GET("/:driver_id/documents", h.GetDriverDocumentsAdmin)
 		adminDriverDocs.GET("/:driver_id/verification-status", h.GetDriverVerificationStatusAdmin)
+		adminDriverDocs.POST("/:driver_id/identity-match", h.VerifyIdentityMatch)
 	}
+
+	h.RegisterOCRCallbackRoutes(r)
+}
+
+// RegisterOCRCallbackRoutes registers the OCR provider callback. It is
+// authenticated by its signature rather than a user token.
+func (h *Handler) RegisterOCRCallbackRoutes(r gin.IRouter) {
+	r.POST("/api/v1/internal/ocr/callback", h.HandleOCRCallback)
 }
 
 // RegisterAdminRoutes registers only admin document routes on an existing router group.
@@ -499,10 +818,18 @@ func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
 	{
 		documents.GET("/pending", h.GetPendingReviews)
 		documents.GET("/expiring", h.GetExpiringDocuments)
+		documents.GET("/funnel", h.GetVerificationFunnel)
+		documents.GET("/review-metrics", h.GetReviewMetrics)
 		documents.POST("/:id/start-review", h.StartDocumentReview)
 		documents.POST("/:id/review", h.ReviewDocument)
+		documents.POST("/:id/force-approve", h.ForceApproveDocument)
+		documents.POST("/bulk-review", h.BulkReviewDocuments)
+		documents.GET("/:id/download", h.GetDocumentDownloadURL)
+		documents.GET("/:id/history", h.GetDocumentHistory)
+		documents.DELETE("/:id", h.DeleteDocument)
 		documents.GET("/drivers/:driver_id", h.GetDriverDocumentsAdmin)
 		documents.GET("/drivers/:driver_id/verification-status", h.GetDriverVerificationStatusAdmin)
+		documents.POST("/drivers/:driver_id/identity-match", h.VerifyIdentityMatch)
 	}
 }
 
@@ -510,6 +837,7 @@ func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
 func (h *Handler) RegisterRoutesOnGroup(rg *gin.RouterGroup) {
 	// Document types (public within API)
 	rg.GET("/documents/types", h.GetDocumentTypes)
+	rg.GET("/documents/requirements", h.GetVerificationRequirements)
 
 	// Driver document routes
 	docs := rg.Group("/documents")
@@ -521,6 +849,9 @@ func (h *Handler) RegisterRoutesOnGroup(rg *gin.RouterGroup) {
 		docs.POST("/presigned-upload", h.GetPresignedUploadURL)
 		docs.POST("/upload-complete", h.CompleteDirectUpload)
 		docs.GET("/:id", h.GetDocument)
+		docs.GET("/:id/download", h.GetDocumentDownloadURL)
+		docs.GET("/:id/history", h.GetDocumentHistory)
 		docs.POST("/:id/back", h.UploadDocumentBackSide)
+		docs.DELETE("/:id", h.DeleteDocument)
 	}
 }
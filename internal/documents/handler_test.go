@@ -3,6 +3,9 @@ package documents
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
@@ -15,9 +18,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 	"github.com/richxcame/ride-hailing/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // ============================================================================
@@ -50,6 +55,14 @@ func (m *MockStorageHandler) Upload(ctx context.Context, key string, reader io.R
 	return args.Get(0).(*storage.UploadResult), args.Error(1)
 }
 
+func (m *MockStorageHandler) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*storage.UploadResult, error) {
+	args := m.Called(ctx, key, reader, contentType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.UploadResult), args.Error(1)
+}
+
 func (m *MockStorageHandler) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	args := m.Called(ctx, key)
 	if args.Get(0) == nil {
@@ -172,11 +185,81 @@ func (m *MockRepositoryTestify) SupersedeDocument(ctx context.Context, documentI
 	return args.Error(0)
 }
 
+func (m *MockRepositoryTestify) SoftDeleteDocument(ctx context.Context, documentID, deletedBy uuid.UUID) error {
+	args := m.Called(ctx, documentID, deletedBy)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) MarkDocumentFilesDeleted(ctx context.Context, documentID uuid.UUID) error {
+	args := m.Called(ctx, documentID)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) GetDocumentsPendingFileCleanup(ctx context.Context, limit int) ([]*DriverDocument, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DriverDocument), args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetDocumentVersionsWithFiles(ctx context.Context, driverID uuid.UUID) ([]*DriverDocument, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DriverDocument), args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetDocumentsByContentHash(ctx context.Context, contentHash string, excludeDriverID uuid.UUID, limit int) ([]*DriverDocument, error) {
+	args := m.Called(ctx, contentHash, excludeDriverID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DriverDocument), args.Error(1)
+}
+
+func (m *MockRepositoryTestify) ClaimDocumentForReview(ctx context.Context, documentID, reviewerID uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, documentID, reviewerID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) ReleaseStaleClaims(ctx context.Context, claimedBefore time.Time) ([]*DriverDocument, error) {
+	args := m.Called(ctx, claimedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DriverDocument), args.Error(1)
+}
+
 func (m *MockRepositoryTestify) UpdateDocumentBackFile(ctx context.Context, documentID uuid.UUID, backFileURL, backFileKey string) error {
 	args := m.Called(ctx, documentID, backFileURL, backFileKey)
 	return args.Error(0)
 }
 
+func (m *MockRepositoryTestify) UpdateDocumentThumbnail(ctx context.Context, documentID uuid.UUID, thumbnailURL, thumbnailKey string) error {
+	args := m.Called(ctx, documentID, thumbnailURL, thumbnailKey)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) UpdateDocumentFaceMatch(ctx context.Context, documentID uuid.UUID, score float64, matchedAt time.Time) error {
+	args := m.Called(ctx, documentID, score, matchedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) ExpireOverdueDocuments(ctx context.Context) ([]*DriverDocument, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*DriverDocument), args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetDriverCountryCode(ctx context.Context, driverID uuid.UUID) (string, error) {
+	args := m.Called(ctx, driverID)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockRepositoryTestify) GetDriverVerificationStatus(ctx context.Context, driverID uuid.UUID) (*DriverVerificationStatus, error) {
 	args := m.Called(ctx, driverID)
 	if args.Get(0) == nil {
@@ -185,14 +268,27 @@ func (m *MockRepositoryTestify) GetDriverVerificationStatus(ctx context.Context,
 	return args.Get(0).(*DriverVerificationStatus), args.Error(1)
 }
 
-func (m *MockRepositoryTestify) GetPendingReviews(ctx context.Context, limit, offset int) ([]*PendingReviewDocument, int, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockRepositoryTestify) UpsertDriverVerificationStatus(ctx context.Context, status *DriverVerificationStatus) error {
+	args := m.Called(ctx, status)
+	return args.Error(0)
+}
+
+func (m *MockRepositoryTestify) GetPendingReviews(ctx context.Context, limit, offset int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, int, error) {
+	args := m.Called(ctx, limit, offset, excludeClaimedFor)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
 	return args.Get(0).([]*PendingReviewDocument), args.Int(1), args.Error(2)
 }
 
+func (m *MockRepositoryTestify) GetPendingReviewsAfter(ctx context.Context, after *pagination.Cursor, limit int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, error) {
+	args := m.Called(ctx, after, limit, excludeClaimedFor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*PendingReviewDocument), args.Error(1)
+}
+
 func (m *MockRepositoryTestify) GetExpiringDocuments(ctx context.Context, daysAhead int) ([]*ExpiringDocument, error) {
 	args := m.Called(ctx, daysAhead)
 	if args.Get(0) == nil {
@@ -201,6 +297,11 @@ func (m *MockRepositoryTestify) GetExpiringDocuments(ctx context.Context, daysAh
 	return args.Get(0).([]*ExpiringDocument), args.Error(1)
 }
 
+func (m *MockRepositoryTestify) MarkExpiryWarningSent(ctx context.Context, driverID uuid.UUID, sentAt time.Time) error {
+	args := m.Called(ctx, driverID, sentAt)
+	return args.Error(0)
+}
+
 func (m *MockRepositoryTestify) CreateHistory(ctx context.Context, history *DocumentVerificationHistory) error {
 	args := m.Called(ctx, history)
 	return args.Error(0)
@@ -214,6 +315,14 @@ func (m *MockRepositoryTestify) GetDocumentHistory(ctx context.Context, document
 	return args.Get(0).([]*DocumentVerificationHistory), args.Error(1)
 }
 
+func (m *MockRepositoryTestify) GetUserDisplayNames(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	args := m.Called(ctx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]string), args.Error(1)
+}
+
 func (m *MockRepositoryTestify) CreateOCRJob(ctx context.Context, job *OCRProcessingQueue) error {
 	args := m.Called(ctx, job)
 	return args.Error(0)
@@ -227,6 +336,14 @@ func (m *MockRepositoryTestify) GetPendingOCRJobs(ctx context.Context, limit int
 	return args.Get(0).([]*OCRProcessingQueue), args.Error(1)
 }
 
+func (m *MockRepositoryTestify) GetOCRJob(ctx context.Context, jobID uuid.UUID) (*OCRProcessingQueue, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*OCRProcessingQueue), args.Error(1)
+}
+
 func (m *MockRepositoryTestify) UpdateOCRJobStatus(ctx context.Context, jobID uuid.UUID, status string, result, errorMsg *string) error {
 	args := m.Called(ctx, jobID, status, result, errorMsg)
 	return args.Error(0)
@@ -242,11 +359,40 @@ func (m *MockRepositoryTestify) FailOCRJob(ctx context.Context, jobID uuid.UUID,
 	return args.Error(0)
 }
 
+func (m *MockRepositoryTestify) ExhaustOCRJob(ctx context.Context, jobID uuid.UUID, errorMessage string) error {
+	args := m.Called(ctx, jobID, errorMessage)
+	return args.Error(0)
+}
+
 func (m *MockRepositoryTestify) UpdateOCRJobRetry(ctx context.Context, jobID uuid.UUID, retryCount int, nextRetry time.Time) error {
 	args := m.Called(ctx, jobID, retryCount, nextRetry)
 	return args.Error(0)
 }
 
+func (m *MockRepositoryTestify) GetVerificationFunnelDrivers(ctx context.Context, since time.Time) ([]*DriverFunnelProgress, error) {
+	args := m.Called(ctx, since)
+	v, _ := args.Get(0).([]*DriverFunnelProgress)
+	return v, args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetReviewTimes(ctx context.Context, since time.Time) ([]*DocumentTypeReviewTimes, error) {
+	args := m.Called(ctx, since)
+	v, _ := args.Get(0).([]*DocumentTypeReviewTimes)
+	return v, args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetPendingReviewBacklog(ctx context.Context, slaCutoff time.Time) ([]*PendingReviewBacklog, error) {
+	args := m.Called(ctx, slaCutoff)
+	v, _ := args.Get(0).([]*PendingReviewBacklog)
+	return v, args.Error(1)
+}
+
+func (m *MockRepositoryTestify) GetReviewerThroughput(ctx context.Context, since time.Time) ([]*ReviewerThroughput, error) {
+	args := m.Called(ctx, since)
+	v, _ := args.Get(0).([]*ReviewerThroughput)
+	return v, args.Error(1)
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -333,7 +479,19 @@ func createTestDriverDocument(driverID uuid.UUID, docType *DocumentType) *Driver
 	}
 }
 
+// expectVerificationRecompute allows the verification status recomputation that
+// follows reviews and uploads
+func expectVerificationRecompute(mockRepo *MockRepositoryTestify) {
+	mockRepo.On("GetDriverCountryCode", mock.Anything, mock.Anything).Return("", nil).Maybe()
+	mockRepo.On("GetRequiredDocumentTypes", mock.Anything).Return([]*DocumentType{}, nil).Maybe()
+	mockRepo.On("GetDriverDocuments", mock.Anything, mock.Anything).Return([]*DriverDocument{}, nil).Maybe()
+	mockRepo.On("GetDriverVerificationStatus", mock.Anything, mock.Anything).Return(nil, errors.New("not found")).Maybe()
+}
+
 func createTestHandler(mockRepo *MockRepositoryTestify, mockStorage *MockStorageHandler, mockDriverService *MockDriverService) *Handler {
+	// Persisting the computed verification status is incidental to most handler tests
+	mockRepo.On("UpsertDriverVerificationStatus", mock.Anything, mock.Anything).Return(nil).Maybe()
+
 	service := NewService(mockRepo, mockStorage, ServiceConfig{
 		MaxFileSizeMB:    10,
 		AllowedMimeTypes: []string{"image/jpeg", "image/png", "application/pdf"},
@@ -570,6 +728,7 @@ func TestHandler_GetMyVerificationStatus_Success(t *testing.T) {
 	docType := createTestDocumentTypeHandler()
 
 	mockDriverService.On("GetDriverByUserID", mock.Anything, userID).Return(driver, nil)
+	mockRepo.On("GetDriverCountryCode", mock.Anything, mock.Anything).Return("", nil)
 	mockRepo.On("GetRequiredDocumentTypes", mock.Anything).Return([]*DocumentType{docType}, nil)
 	mockRepo.On("GetDriverDocuments", mock.Anything, driver.ID).Return([]*DriverDocument{}, nil)
 	mockRepo.On("GetDriverVerificationStatus", mock.Anything, driver.ID).Return(nil, errors.New("not found"))
@@ -584,6 +743,37 @@ func TestHandler_GetMyVerificationStatus_Success(t *testing.T) {
 	assert.True(t, response["success"].(bool))
 }
 
+func TestHandler_GetMyVerificationStatus_Recompute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	mockStorage := new(MockStorageHandler)
+	mockDriverService := new(MockDriverService)
+	handler := createTestHandler(mockRepo, mockStorage, mockDriverService)
+
+	userID := uuid.New()
+	driver := createTestDriver(userID)
+	docType := createTestDocumentTypeHandler()
+	stored := &DriverVerificationStatus{DriverID: driver.ID, VerificationStatus: VerificationPendingReview}
+
+	mockDriverService.On("GetDriverByUserID", mock.Anything, userID).Return(driver, nil)
+	mockRepo.On("GetDriverCountryCode", mock.Anything, mock.Anything).Return("", nil)
+	mockRepo.On("GetRequiredDocumentTypes", mock.Anything).Return([]*DocumentType{docType}, nil)
+	mockRepo.On("GetDriverDocuments", mock.Anything, driver.ID).Return([]*DriverDocument{}, nil)
+	mockRepo.On("GetDriverVerificationStatus", mock.Anything, driver.ID).Return(stored, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/documents/verification-status?recompute=true", nil)
+	setUserContext(c, userID, models.RoleDriver)
+
+	handler.GetMyVerificationStatus(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, string(VerificationIncomplete), data["status"])
+	mockRepo.AssertCalled(t, "UpsertDriverVerificationStatus", mock.Anything, mock.Anything)
+}
+
 func TestHandler_GetMyVerificationStatus_Unauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -775,7 +965,7 @@ func TestHandler_GetPendingReviews_Success(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(pendingDocs, 1, nil)
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return(pendingDocs, 1, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -788,6 +978,64 @@ func TestHandler_GetPendingReviews_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+
+func TestHandler_GetPendingReviews_ByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+
+	after := pagination.NewCursor(time.Now().Add(-time.Hour), uuid.New())
+	doc := createTestDriverDocument(uuid.New(), createTestDocumentTypeHandler())
+	resumesAfter := mock.MatchedBy(func(c *pagination.Cursor) bool { return c.ID == after.ID && c.At.Equal(after.At) })
+	mockRepo.On("GetPendingReviewsAfter", mock.Anything, resumesAfter, 11, (*uuid.UUID)(nil)).
+		Return([]*PendingReviewDocument{{Document: doc}}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?limit=10&cursor="+after.Encode(), nil)
+	setUserContext(c, uuid.New(), models.RoleAdmin)
+
+	handler.GetPendingReviews(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Len(t, data["documents"], 1)
+	assert.Nil(t, data["next_cursor"])
+	mockRepo.AssertNotCalled(t, "GetPendingReviews", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_GetPendingReviews_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+
+	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?cursor=garbage", nil)
+	setUserContext(c, uuid.New(), models.RoleAdmin)
+
+	handler.GetPendingReviews(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+func TestHandler_GetPendingReviews_UnclaimedOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	mockStorage := new(MockStorageHandler)
+	mockDriverService := new(MockDriverService)
+	handler := createTestHandler(mockRepo, mockStorage, mockDriverService)
+
+	adminID := uuid.New()
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), &adminID).Return([]*PendingReviewDocument{}, 0, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?unclaimed=true", nil)
+	setUserContext(c, adminID, models.RoleAdmin)
+
+	handler.GetPendingReviews(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestHandler_GetPendingReviews_WithPagination(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -798,7 +1046,7 @@ func TestHandler_GetPendingReviews_WithPagination(t *testing.T) {
 
 	adminID := uuid.New()
 
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PendingReviewDocument{}, 50, nil)
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return([]*PendingReviewDocument{}, 50, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?limit=10&offset=20", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -822,7 +1070,7 @@ func TestHandler_GetPendingReviews_EmptyList(t *testing.T) {
 
 	adminID := uuid.New()
 
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PendingReviewDocument{}, 0, nil)
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return([]*PendingReviewDocument{}, 0, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -842,7 +1090,7 @@ func TestHandler_GetPendingReviews_ServiceError(t *testing.T) {
 
 	adminID := uuid.New()
 
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 0, errors.New("database error"))
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return(nil, 0, errors.New("database error"))
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -927,6 +1175,52 @@ func TestHandler_GetExpiringDocuments_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+// ============================================================================
+// GetVerificationFunnel Handler Tests
+// ============================================================================
+
+func TestHandler_GetVerificationFunnel_WithSince(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	mockStorage := new(MockStorageHandler)
+	mockDriverService := new(MockDriverService)
+	handler := createTestHandler(mockRepo, mockStorage, mockDriverService)
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetRequiredDocumentTypes", mock.Anything).Return([]*DocumentType{createTestDocumentTypeHandler()}, nil)
+	mockRepo.On("GetVerificationFunnelDrivers", mock.Anything, since).Return([]*DriverFunnelProgress{
+		{DriverID: uuid.New(), RequiredDocumentsSubmitted: 1, SubmittedForReview: true, Approved: true},
+	}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/documents/funnel?since=2026-01-01", nil)
+	setUserContext(c, uuid.New(), models.RoleAdmin)
+
+	handler.GetVerificationFunnel(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	assert.True(t, response["success"].(bool))
+	data := response["data"].(map[string]interface{})
+	assert.Len(t, data["stages"], 4)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetVerificationFunnel_InvalidSince(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+
+	c, w := setupTestContext("GET", "/api/v1/admin/documents/funnel?since=yesterday", nil)
+	setUserContext(c, uuid.New(), models.RoleAdmin)
+
+	handler.GetVerificationFunnel(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetVerificationFunnelDrivers", mock.Anything, mock.Anything)
+}
+
 // ============================================================================
 // StartDocumentReview Handler Tests
 // ============================================================================
@@ -946,7 +1240,7 @@ func TestHandler_StartDocumentReview_Success(t *testing.T) {
 	doc.Status = StatusPending
 
 	mockRepo.On("GetDocument", mock.Anything, doc.ID).Return(doc, nil)
-	mockRepo.On("UpdateDocumentStatus", mock.Anything, doc.ID, StatusUnderReview, mock.AnythingOfType("*uuid.UUID"), (*string)(nil), (*string)(nil)).Return(nil)
+	mockRepo.On("ClaimDocumentForReview", mock.Anything, doc.ID, adminID, mock.AnythingOfType("time.Time")).Return(nil)
 	mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
 
 	c, w := setupTestContext("POST", "/api/v1/admin/documents/"+doc.ID.String()+"/start-review", nil)
@@ -1072,6 +1366,8 @@ func TestHandler_ReviewDocument_Approve_Success(t *testing.T) {
 	mockRepo.On("UpdateDocumentStatus", mock.Anything, doc.ID, StatusApproved, mock.AnythingOfType("*uuid.UUID"), mock.AnythingOfType("*string"), (*string)(nil)).Return(nil)
 	mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
 
+	expectVerificationRecompute(mockRepo)
+
 	c, w := setupTestContext("POST", "/api/v1/admin/documents/"+doc.ID.String()+"/review", reqBody)
 	c.Params = gin.Params{{Key: "id", Value: doc.ID.String()}}
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -1106,6 +1402,8 @@ func TestHandler_ReviewDocument_Reject_Success(t *testing.T) {
 	mockRepo.On("UpdateDocumentStatus", mock.Anything, doc.ID, StatusRejected, mock.AnythingOfType("*uuid.UUID"), (*string)(nil), mock.AnythingOfType("*string")).Return(nil)
 	mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
 
+	expectVerificationRecompute(mockRepo)
+
 	c, w := setupTestContext("POST", "/api/v1/admin/documents/"+doc.ID.String()+"/review", reqBody)
 	c.Params = gin.Params{{Key: "id", Value: doc.ID.String()}}
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -1167,6 +1465,57 @@ func TestHandler_ReviewDocument_InvalidBody(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandler_BulkReviewDocuments_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepositoryTestify)
+	mockStorage := new(MockStorageHandler)
+	mockDriverService := new(MockDriverService)
+	handler := createTestHandler(mockRepo, mockStorage, mockDriverService)
+
+	adminID := uuid.New()
+	docType := createTestDocumentTypeHandler()
+	doc := createTestDriverDocument(uuid.New(), docType)
+	doc.Status = StatusPending
+	missingID := uuid.New()
+
+	reqBody := BulkReviewRequest{Items: []BulkReviewItem{
+		{DocumentID: doc.ID, Review: ReviewDocumentRequest{Action: "approve"}},
+		{DocumentID: missingID, Review: ReviewDocumentRequest{Action: "approve"}},
+	}}
+
+	mockRepo.On("GetDocument", mock.Anything, doc.ID).Return(doc, nil)
+	mockRepo.On("GetDocument", mock.Anything, missingID).Return(nil, errors.New("not found"))
+	mockRepo.On("UpdateDocumentStatus", mock.Anything, doc.ID, StatusApproved, mock.AnythingOfType("*uuid.UUID"), (*string)(nil), (*string)(nil)).Return(nil)
+	mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
+
+	expectVerificationRecompute(mockRepo)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/documents/bulk-review", reqBody)
+	setUserContext(c, adminID, models.RoleAdmin)
+
+	handler.BulkReviewDocuments(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["succeeded"])
+	assert.Equal(t, float64(1), data["failed"])
+}
+
+func TestHandler_BulkReviewDocuments_EmptyItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := createTestHandler(new(MockRepositoryTestify), new(MockStorageHandler), new(MockDriverService))
+
+	c, w := setupTestContext("POST", "/api/v1/admin/documents/bulk-review", BulkReviewRequest{Items: []BulkReviewItem{}})
+	setUserContext(c, uuid.New(), models.RoleAdmin)
+
+	handler.BulkReviewDocuments(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestHandler_ReviewDocument_InvalidDocumentID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -1335,6 +1684,7 @@ func TestHandler_GetDriverVerificationStatusAdmin_Success(t *testing.T) {
 	driverID := uuid.New()
 	docType := createTestDocumentTypeHandler()
 
+	mockRepo.On("GetDriverCountryCode", mock.Anything, mock.Anything).Return("", nil)
 	mockRepo.On("GetRequiredDocumentTypes", mock.Anything).Return([]*DocumentType{docType}, nil)
 	mockRepo.On("GetDriverDocuments", mock.Anything, driverID).Return([]*DriverDocument{}, nil)
 	mockRepo.On("GetDriverVerificationStatus", mock.Anything, driverID).Return(nil, errors.New("not found"))
@@ -1500,20 +1850,28 @@ func TestHandler_CompleteDirectUpload_Success(t *testing.T) {
 	driver := createTestDriver(userID)
 	docType := createTestDocumentTypeHandler()
 
+	uploadKey := storage.DocumentKeyPrefix(driver.ID, "drivers_license") + "20260101_abcd1234.jpg"
 	reqBody := UploadCompleteRequest{
-		FileKey:          "documents/driver123/license.jpg",
+		FileKey:          uploadKey,
 		DocumentTypeCode: "drivers_license",
 		IsFrontSide:      true,
 	}
 
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
 	mockDriverService.On("GetDriverByUserID", mock.Anything, userID).Return(driver, nil)
-	mockStorage.On("Exists", mock.Anything, "documents/driver123/license.jpg").Return(true, nil)
+	mockStorage.On("Exists", mock.Anything, uploadKey).Return(true, nil)
+	mockStorage.On("Download", mock.Anything, uploadKey).Return(io.NopCloser(bytes.NewReader(jpeg)), nil)
 	mockRepo.On("GetDocumentTypeByCode", mock.Anything, "drivers_license").Return(docType, nil)
 	mockRepo.On("GetLatestDocumentByType", mock.Anything, driver.ID, docType.ID).Return(nil, errors.New("not found"))
-	mockStorage.On("GetURL", "documents/driver123/license.jpg").Return("https://storage.example.com/documents/driver123/license.jpg")
+	mockStorage.On("Upload", mock.Anything, mock.MatchedBy(func(key string) bool { return key != uploadKey }), mock.Anything, int64(len(jpeg)), "image/jpeg").
+		Return(&storage.UploadResult{Key: "stored.jpg", URL: "https://storage.example.com/stored.jpg"}, nil)
+	mockRepo.On("GetDocumentsByContentHash", mock.Anything, mock.Anything, driver.ID, mock.Anything).Return(nil, nil)
 	mockRepo.On("CreateDocument", mock.Anything, mock.AnythingOfType("*documents.DriverDocument")).Return(nil)
+	mockStorage.On("Delete", mock.Anything, uploadKey).Return(nil)
 	mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
 
+	expectVerificationRecompute(mockRepo)
+
 	c, w := setupTestContext("POST", "/api/v1/documents/upload-complete", reqBody)
 	setUserContext(c, userID, models.RoleDriver)
 
@@ -1558,14 +1916,16 @@ func TestHandler_CompleteDirectUpload_FileNotFound(t *testing.T) {
 	userID := uuid.New()
 	driver := createTestDriver(userID)
 
+	uploadKey := storage.DocumentKeyPrefix(driver.ID, "drivers_license") + "20260101_abcd1234.jpg"
 	reqBody := UploadCompleteRequest{
-		FileKey:          "documents/nonexistent.jpg",
+		FileKey:          uploadKey,
 		DocumentTypeCode: "drivers_license",
 		IsFrontSide:      true,
 	}
 
 	mockDriverService.On("GetDriverByUserID", mock.Anything, userID).Return(driver, nil)
-	mockStorage.On("Exists", mock.Anything, "documents/nonexistent.jpg").Return(false, nil)
+	mockRepo.On("GetDocumentTypeByCode", mock.Anything, "drivers_license").Return(createTestDocumentTypeHandler(), nil)
+	mockStorage.On("Exists", mock.Anything, uploadKey).Return(false, nil)
 
 	c, w := setupTestContext("POST", "/api/v1/documents/upload-complete", reqBody)
 	setUserContext(c, userID, models.RoleDriver)
@@ -1791,6 +2151,8 @@ func TestHandler_ReviewDocument_ActionCases(t *testing.T) {
 				mockRepo.On("CreateHistory", mock.Anything, mock.AnythingOfType("*documents.DocumentVerificationHistory")).Return(nil)
 			}
 
+			expectVerificationRecompute(mockRepo)
+
 			c, w := setupTestContext("POST", "/api/v1/admin/documents/"+doc.ID.String()+"/review", reqBody)
 			c.Params = gin.Params{{Key: "id", Value: doc.ID.String()}}
 			setUserContext(c, adminID, models.RoleAdmin)
@@ -1839,7 +2201,7 @@ func TestHandler_GetPendingReviews_NegativeOffset(t *testing.T) {
 	adminID := uuid.New()
 
 	// Should handle invalid offset parameter
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PendingReviewDocument{}, 0, nil)
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return([]*PendingReviewDocument{}, 0, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?offset=-10", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -1860,7 +2222,7 @@ func TestHandler_GetPendingReviews_ExcessiveLimit(t *testing.T) {
 	adminID := uuid.New()
 
 	// Should handle excessive limit parameter
-	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PendingReviewDocument{}, 0, nil)
+	mockRepo.On("GetPendingReviews", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int"), mock.Anything).Return([]*PendingReviewDocument{}, 0, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/admin/documents/pending?limit=1000", nil)
 	setUserContext(c, adminID, models.RoleAdmin)
@@ -1945,3 +2307,106 @@ func TestHandler_ErrorResponse_Format(t *testing.T) {
 	assert.NotNil(t, errorInfo["code"])
 	assert.NotNil(t, errorInfo["message"])
 }
+
+// ============================================================================
+// HandleOCRCallback Tests
+// ============================================================================
+
+func newSignedOCRCallback(t *testing.T, secret string, payload interface{}) (*gin.Context, *httptest.ResponseRecorder) {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	c, w := setupTestContext("POST", "/api/v1/internal/ocr/callback", nil)
+	c.Request = httptest.NewRequest("POST", "/api/v1/internal/ocr/callback", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	c.Request.Header.Set(ocrSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return c, w
+}
+
+func testOCRCallbackPayload(jobID uuid.UUID) gin.H {
+	return gin.H{
+		"job_id":     jobID,
+		"provider":   "acme-ocr",
+		"confidence": 0.9,
+		"fields": gin.H{
+			"document_number": gin.H{"value": "DL-555", "confidence": 0.95},
+		},
+	}
+}
+
+func TestHandler_HandleOCRCallback_Success(t *testing.T) {
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+	handler.SetOCRCallbackSecret("callback-secret")
+
+	job := &OCRProcessingQueue{ID: uuid.New(), DocumentID: uuid.New(), Status: "processing"}
+	mockRepo.On("GetOCRJob", mock.Anything, job.ID).Return(job, nil)
+	mockRepo.On("CompleteOCRJob", mock.Anything, job.ID, mock.Anything, 0.9, mock.Anything).Return(nil)
+	mockRepo.On("GetDocument", mock.Anything, job.DocumentID).Return(nil, errors.New("not found"))
+	mockRepo.On("UpdateDocumentOCRData", mock.Anything, job.DocumentID, mock.Anything, 0.9).Return(nil)
+	mockRepo.On("UpdateDocumentDetails", mock.Anything, job.DocumentID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateHistory", mock.Anything, mock.Anything).Return(nil)
+
+	c, w := newSignedOCRCallback(t, "callback-secret", testOCRCallbackPayload(job.ID))
+	handler.HandleOCRCallback(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Equal(t, "processed", data["status"])
+	mockRepo.AssertCalled(t, "CompleteOCRJob", mock.Anything, job.ID, mock.Anything, 0.9, mock.Anything)
+}
+
+func TestHandler_HandleOCRCallback_BadSignature(t *testing.T) {
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+	handler.SetOCRCallbackSecret("callback-secret")
+
+	c, w := newSignedOCRCallback(t, "wrong-secret", testOCRCallbackPayload(uuid.New()))
+	handler.HandleOCRCallback(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockRepo.AssertNotCalled(t, "GetOCRJob", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleOCRCallback_DisabledWithoutSecret(t *testing.T) {
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+
+	c, w := newSignedOCRCallback(t, "", testOCRCallbackPayload(uuid.New()))
+	handler.HandleOCRCallback(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestHandler_HandleOCRCallback_CompletedJobConflict(t *testing.T) {
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+	handler.SetOCRCallbackSecret("callback-secret")
+
+	job := &OCRProcessingQueue{ID: uuid.New(), DocumentID: uuid.New(), Status: "completed",
+		ExtractedData: map[string]interface{}{"metadata": map[string]interface{}{"callback_digest": "other"}}}
+	mockRepo.On("GetOCRJob", mock.Anything, job.ID).Return(job, nil)
+
+	c, w := newSignedOCRCallback(t, "callback-secret", testOCRCallbackPayload(job.ID))
+	handler.HandleOCRCallback(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockRepo.AssertNotCalled(t, "CompleteOCRJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleOCRCallback_MalformedConfidence(t *testing.T) {
+	mockRepo := new(MockRepositoryTestify)
+	handler := createTestHandler(mockRepo, new(MockStorageHandler), new(MockDriverService))
+	handler.SetOCRCallbackSecret("callback-secret")
+
+	payload := testOCRCallbackPayload(uuid.New())
+	payload["confidence"] = "high"
+	c, w := newSignedOCRCallback(t, "callback-secret", payload)
+	handler.HandleOCRCallback(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetOCRJob", mock.Anything, mock.Anything)
+}
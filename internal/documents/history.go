@@ -0,0 +1,169 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// unknownActorName is shown for entries whose performer no longer exists
+const unknownActorName = "Unknown user"
+
+// historyActionLabels are the display labels of history actions
+var historyActionLabels = map[string]string{
+	"submitted":              "Submitted",
+	"duplicate_flagged":      "Flagged as another driver's file",
+	"resubmitted":            "Resubmitted",
+	"superseded":             "Replaced by a newer upload",
+	"ocr_processed":          "Scanned by OCR",
+	"auto_approved":          "Automatically approved",
+	"manual_review_required": "Sent to manual review",
+	"review_started":         "Review started",
+	"review_claim_expired":   "Review abandoned",
+	"approve":                "Approved",
+	"reject":                 "Rejected",
+	"request_resubmit":       "Resubmission requested",
+	"expired":                "Expired",
+	"expiry_reminder_sent":   "Expiry reminder sent",
+	"files_pruned":           "Old files removed",
+	"deleted":                "Deleted",
+}
+
+// GetDocumentHistory returns a document's history as a timeline, oldest entry
+// first. Only the owning driver or a reviewer may request it.
+func (s *Service) GetDocumentHistory(ctx context.Context, documentID uuid.UUID, requester DocumentRequester) (*DocumentTimelineResponse, error) {
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "document not found", nil)
+	}
+	if !requester.IsReviewer && doc.DriverID != requester.DriverID {
+		return nil, common.NewErrorWithCode(http.StatusForbidden, common.ErrCodeForbidden, "not your document", nil)
+	}
+
+	history, err := s.repo.GetDocumentHistory(ctx, documentID)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get document history", err)
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.Before(history[j].CreatedAt)
+	})
+
+	names := s.actorNames(ctx, history)
+	entries := make([]*DocumentTimelineEntry, 0, len(history))
+	for _, h := range history {
+		entries = append(entries, newTimelineEntry(h, names))
+	}
+
+	return &DocumentTimelineResponse{DocumentID: documentID, Entries: entries}, nil
+}
+
+// actorNames looks up the names of the people who performed the entries. The
+// timeline is still worth showing without them, so a failed lookup is logged
+// and the actors are shown as unknown.
+func (s *Service) actorNames(ctx context.Context, history []*DocumentVerificationHistory) map[uuid.UUID]string {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, h := range history {
+		if h.IsSystemAction || h.PerformedBy == nil || seen[*h.PerformedBy] {
+			continue
+		}
+		seen[*h.PerformedBy] = true
+		ids = append(ids, *h.PerformedBy)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	names, err := s.repo.GetUserDisplayNames(ctx, ids)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to get names for document history", zap.Error(err))
+		return nil
+	}
+	return names
+}
+
+// newTimelineEntry describes a history entry for display
+func newTimelineEntry(h *DocumentVerificationHistory, names map[uuid.UUID]string) *DocumentTimelineEntry {
+	entry := &DocumentTimelineEntry{
+		ID:             h.ID,
+		Action:         h.Action,
+		ActionLabel:    historyActionLabel(h.Action),
+		PreviousStatus: h.PreviousStatus,
+		NewStatus:      h.NewStatus,
+		Transition:     statusTransition(h.PreviousStatus, h.NewStatus),
+		PerformedBy:    h.PerformedBy,
+		ActorName:      SystemActorName,
+		IsSystemAction: h.IsSystemAction,
+		Notes:          h.Notes,
+		CreatedAt:      h.CreatedAt,
+	}
+
+	switch {
+	case h.IsSystemAction:
+		// Keeps the system as the actor
+	case h.PerformedBy == nil:
+		// Uploads are logged without a performer; the driver made them
+		entry.ActorName = DriverActorName
+	default:
+		entry.ActorName = unknownActorName
+		if name := names[*h.PerformedBy]; name != "" {
+			entry.ActorName = name
+		}
+	}
+
+	if h.Action == "ocr_processed" {
+		if confidence, ok := h.Metadata["confidence"].(float64); ok {
+			entry.OCRConfidence = &confidence
+		}
+	}
+
+	return entry
+}
+
+// historyActionLabel returns the display label of an action, falling back to
+// the action itself with underscores as spaces
+func historyActionLabel(action string) string {
+	if label, ok := historyActionLabels[action]; ok {
+		return label
+	}
+	label := strings.ReplaceAll(action, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// statusTransition describes a status change as "previous → new", or just the
+// new status when there was none before
+func statusTransition(previous, next *string) string {
+	switch {
+	case previous != nil && next != nil:
+		return *previous + " → " + *next
+	case next != nil:
+		return *next
+	default:
+		return ""
+	}
+}
+
+// ocrHistoryEntry records that OCR was run on a document, keeping the result's
+// confidence in the metadata for the timeline
+func ocrHistoryEntry(documentID uuid.UUID, result *OCRResult) *DocumentVerificationHistory {
+	notes := fmt.Sprintf("OCR processed with %.0f%% confidence", result.Confidence*100)
+	return &DocumentVerificationHistory{
+		ID:             uuid.New(),
+		DocumentID:     documentID,
+		Action:         "ocr_processed",
+		IsSystemAction: true,
+		Notes:          &notes,
+		Metadata:       map[string]interface{}{"confidence": result.Confidence},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 )
 
 // RepositoryInterface defines the interface for document repository operations
@@ -24,27 +25,59 @@ type RepositoryInterface interface {
 	UpdateDocumentOCRData(ctx context.Context, documentID uuid.UUID, ocrData map[string]interface{}, confidence float64) error
 	UpdateDocumentDetails(ctx context.Context, documentID uuid.UUID, documentNumber *string, issueDate, expiryDate *time.Time, issuingAuthority *string) error
 	SupersedeDocument(ctx context.Context, documentID uuid.UUID) error
+	SoftDeleteDocument(ctx context.Context, documentID, deletedBy uuid.UUID) error
+	MarkDocumentFilesDeleted(ctx context.Context, documentID uuid.UUID) error
+	GetDocumentsPendingFileCleanup(ctx context.Context, limit int) ([]*DriverDocument, error)
+	GetDocumentVersionsWithFiles(ctx context.Context, driverID uuid.UUID) ([]*DriverDocument, error)
+	GetDocumentsByContentHash(ctx context.Context, contentHash string, excludeDriverID uuid.UUID, limit int) ([]*DriverDocument, error)
+	ClaimDocumentForReview(ctx context.Context, documentID, reviewerID uuid.UUID, expiresAt time.Time) error
+	ReleaseStaleClaims(ctx context.Context, claimedBefore time.Time) ([]*DriverDocument, error)
 	UpdateDocumentBackFile(ctx context.Context, documentID uuid.UUID, backFileURL, backFileKey string) error
+	UpdateDocumentThumbnail(ctx context.Context, documentID uuid.UUID, thumbnailURL, thumbnailKey string) error
+	UpdateDocumentFaceMatch(ctx context.Context, documentID uuid.UUID, score float64, matchedAt time.Time) error
+	ExpireOverdueDocuments(ctx context.Context) ([]*DriverDocument, error)
 
 	// Verification Status
+	GetDriverCountryCode(ctx context.Context, driverID uuid.UUID) (string, error)
 	GetDriverVerificationStatus(ctx context.Context, driverID uuid.UUID) (*DriverVerificationStatus, error)
+	UpsertDriverVerificationStatus(ctx context.Context, status *DriverVerificationStatus) error
 
 	// Pending Reviews (Admin)
-	GetPendingReviews(ctx context.Context, limit, offset int) ([]*PendingReviewDocument, int, error)
+	GetPendingReviews(ctx context.Context, limit, offset int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, int, error)
+	GetPendingReviewsAfter(ctx context.Context, after *pagination.Cursor, limit int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, error)
 	GetExpiringDocuments(ctx context.Context, daysAhead int) ([]*ExpiringDocument, error)
+	MarkExpiryWarningSent(ctx context.Context, driverID uuid.UUID, sentAt time.Time) error
+
+	// Analytics (Admin)
+	GetVerificationFunnelDrivers(ctx context.Context, since time.Time) ([]*DriverFunnelProgress, error)
+	GetReviewTimes(ctx context.Context, since time.Time) ([]*DocumentTypeReviewTimes, error)
+	GetPendingReviewBacklog(ctx context.Context, slaCutoff time.Time) ([]*PendingReviewBacklog, error)
+	GetReviewerThroughput(ctx context.Context, since time.Time) ([]*ReviewerThroughput, error)
 
 	// History
 	CreateHistory(ctx context.Context, history *DocumentVerificationHistory) error
 	GetDocumentHistory(ctx context.Context, documentID uuid.UUID) ([]*DocumentVerificationHistory, error)
+	GetUserDisplayNames(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error)
 
 	// OCR Queue
 	CreateOCRJob(ctx context.Context, job *OCRProcessingQueue) error
 	GetPendingOCRJobs(ctx context.Context, limit int) ([]*OCRProcessingQueue, error)
+	GetOCRJob(ctx context.Context, jobID uuid.UUID) (*OCRProcessingQueue, error)
 	UpdateOCRJobStatus(ctx context.Context, jobID uuid.UUID, status string, result, errorMsg *string) error
 	CompleteOCRJob(ctx context.Context, jobID uuid.UUID, extractedData map[string]interface{}, confidence float64, processingTimeMs int) error
 	FailOCRJob(ctx context.Context, jobID uuid.UUID, errorMessage string) error
+	ExhaustOCRJob(ctx context.Context, jobID uuid.UUID, errorMessage string) error
 	UpdateOCRJobRetry(ctx context.Context, jobID uuid.UUID, retryCount int, nextRetry time.Time) error
 }
 
+// Notifier delivers document notifications to drivers
+type Notifier interface {
+	NotifyDocumentExpiring(ctx context.Context, reminder *ExpiryReminder) error
+}
+
+// StatusChangeHook is told when a driver's computed verification status changes,
+// e.g. so dispatch can start or stop offering them rides
+type StatusChangeHook func(ctx context.Context, change *VerificationStatusChange)
+
 // Ensure Repository implements RepositoryInterface
 var _ RepositoryInterface = (*Repository)(nil)
@@ -1,9 +1,11 @@
 package documents
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/httputil"
 )
 
 // DocumentStatus represents the status of a document
@@ -16,6 +18,7 @@ const (
 	StatusRejected    DocumentStatus = "rejected"
 	StatusExpired     DocumentStatus = "expired"
 	StatusSuperseded  DocumentStatus = "superseded"
+	StatusDeleted     DocumentStatus = "deleted" // Removed by the driver or an admin; kept for history
 )
 
 // VerificationStatus represents the overall driver verification status
@@ -42,7 +45,9 @@ type DocumentType struct {
 	RenewalReminderDays   int       `json:"renewal_reminder_days" db:"renewal_reminder_days"`
 	RequiresManualReview  bool      `json:"requires_manual_review" db:"requires_manual_review"`
 	AutoOCREnabled        bool      `json:"auto_ocr_enabled" db:"auto_ocr_enabled"`
+	OCRApproveThreshold   *float64  `json:"ocr_approve_threshold,omitempty" db:"ocr_approve_threshold"` // OCR confidence to auto-approve above; nil uses the service default
 	CountryCodes          []string  `json:"country_codes" db:"country_codes"`
+	AllowedMimeTypes      []string  `json:"allowed_mime_types,omitempty" db:"allowed_mime_types"`
 	DisplayOrder          int       `json:"display_order" db:"display_order"`
 	IsActive              bool      `json:"is_active" db:"is_active"`
 	CreatedAt             time.Time `json:"created_at" db:"created_at"`
@@ -60,8 +65,16 @@ type DriverDocument struct {
 	FileName           string                 `json:"file_name" db:"file_name"`
 	FileSizeBytes      *int64                 `json:"file_size_bytes" db:"file_size_bytes"`
 	FileMimeType       *string                `json:"file_mime_type" db:"file_mime_type"`
+	PageCount          *int                   `json:"page_count,omitempty" db:"page_count"` // Pages in a PDF upload
 	BackFileURL        *string                `json:"back_file_url" db:"back_file_url"`
 	BackFileKey        *string                `json:"-" db:"back_file_key"`
+	OriginalFileKey    *string                `json:"-" db:"original_file_key"` // Upload as received, when the stored image was processed
+	ThumbnailURL       *string                `json:"thumbnail_url,omitempty" db:"thumbnail_url"`
+	ThumbnailKey       *string                `json:"-" db:"thumbnail_key"`
+	ContentHash        *string                `json:"-" db:"content_hash"`      // SHA-256 of the file as uploaded
+	DuplicateFlagged   bool                   `json:"-" db:"duplicate_flagged"` // Same file as another driver's document, so held for manual review
+	FaceMatchScore     *float64               `json:"face_match_score,omitempty" db:"face_match_score"`
+	FaceMatchedAt      *time.Time             `json:"face_matched_at,omitempty" db:"face_matched_at"`
 	DocumentNumber     *string                `json:"document_number" db:"document_number"`
 	IssueDate          *time.Time             `json:"issue_date" db:"issue_date"`
 	ExpiryDate         *time.Time             `json:"expiry_date" db:"expiry_date"`
@@ -73,6 +86,9 @@ type DriverDocument struct {
 	ReviewedAt         *time.Time             `json:"reviewed_at" db:"reviewed_at"`
 	ReviewNotes        *string                `json:"review_notes" db:"review_notes"`
 	RejectionReason    *string                `json:"rejection_reason" db:"rejection_reason"`
+	ClaimedBy          *uuid.UUID             `json:"claimed_by,omitempty" db:"claimed_by"` // Reviewer currently reviewing the document
+	ClaimedAt          *time.Time             `json:"claimed_at,omitempty" db:"claimed_at"`
+	ClaimExpiresAt     *time.Time             `json:"claim_expires_at,omitempty" db:"claim_expires_at"`
 	Version            int                    `json:"version" db:"version"`
 	PreviousDocumentID *uuid.UUID             `json:"previous_document_id" db:"previous_document_id"`
 	SubmittedAt        time.Time              `json:"submitted_at" db:"submitted_at"`
@@ -152,6 +168,9 @@ type UploadDocumentRequest struct {
 	IssueDate        *time.Time `json:"issue_date"`
 	ExpiryDate       *time.Time `json:"expiry_date"`
 	IssuingAuthority string     `json:"issuing_authority"`
+
+	// ResubmitForDocumentID links the upload to a rejected document it replaces
+	ResubmitForDocumentID *uuid.UUID `json:"resubmit_for_document_id,omitempty"`
 }
 
 // UploadDocumentResponse represents the response after upload
@@ -172,12 +191,98 @@ type ReviewDocumentRequest struct {
 	ExpiryDate      *string `json:"expiry_date"`
 }
 
+// ForceApproveRequest represents an admin approving a document verified
+// out-of-band. AllowExpired approves it even though its expiry is in the past.
+type ForceApproveRequest struct {
+	Justification  string  `json:"justification" binding:"required"`
+	DocumentNumber *string `json:"document_number"`
+	ExpiryDate     *string `json:"expiry_date"`
+	AllowExpired   bool    `json:"allow_expired"`
+}
+
+// DocumentSide selects which file of a document to fetch: the front, the back or
+// the front's thumbnail
+type DocumentSide string
+
+const (
+	DocumentSideFront     DocumentSide = "front"
+	DocumentSideBack      DocumentSide = "back"
+	DocumentSideThumbnail DocumentSide = "thumbnail" // Small preview of the front
+)
+
+// DocumentRequester identifies who is asking for a document
+type DocumentRequester struct {
+	DriverID   uuid.UUID // Driver making the request, if any
+	IsReviewer bool      // Reviewers may access any driver's documents
+}
+
+// DocumentDownloadResponse is a short-lived link to a document file
+type DocumentDownloadResponse struct {
+	DocumentID uuid.UUID    `json:"document_id"`
+	Side       DocumentSide `json:"side"`
+	URL        string       `json:"url"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+}
+
+// Actor names shown for history entries without a performing user
+const (
+	SystemActorName = "System" // Automated entries
+	DriverActorName = "Driver" // Entries from the driver's own uploads
+)
+
+// DocumentTimelineEntry is a history entry described for display
+type DocumentTimelineEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	Action         string     `json:"action"`
+	ActionLabel    string     `json:"action_label"`
+	PreviousStatus *string    `json:"previous_status,omitempty"`
+	NewStatus      *string    `json:"new_status,omitempty"`
+	Transition     string     `json:"transition,omitempty"` // e.g. "pending → approved"
+	PerformedBy    *uuid.UUID `json:"performed_by,omitempty"`
+	ActorName      string     `json:"actor_name"`
+	IsSystemAction bool       `json:"is_system_action"`
+	Notes          *string    `json:"notes,omitempty"`
+	OCRConfidence  *float64   `json:"ocr_confidence,omitempty"` // Set on ocr_processed entries
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DocumentTimelineResponse is a document's history, oldest entry first
+type DocumentTimelineResponse struct {
+	DocumentID uuid.UUID                `json:"document_id"`
+	Entries    []*DocumentTimelineEntry `json:"entries"`
+}
+
+// BulkReviewItem is one document in a bulk review
+type BulkReviewItem struct {
+	DocumentID uuid.UUID             `json:"document_id" binding:"required"`
+	Review     ReviewDocumentRequest `json:"review"`
+}
+
+// BulkReviewRequest represents a request to review several documents at once.
+// Items are validated individually so one bad item doesn't reject the batch.
+type BulkReviewRequest struct {
+	Items []BulkReviewItem `json:"items" binding:"required,min=1"`
+}
+
+// BulkReviewResult is the outcome of reviewing one document in a bulk review
+type BulkReviewResult struct {
+	DocumentID uuid.UUID `json:"document_id"`
+	Action     string    `json:"action"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// BulkReviewResponse represents the outcome of a bulk review
+type BulkReviewResponse struct {
+	Results   []BulkReviewResult `json:"results"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+}
+
 // DocumentListResponse represents a paginated list of documents
 type DocumentListResponse struct {
 	Documents []*DriverDocument `json:"documents"`
-	Total     int               `json:"total"`
-	Limit     int               `json:"limit"`
-	Offset    int               `json:"offset"`
+	httputil.PageInfo
 }
 
 // DocumentTypeListResponse represents available document types
@@ -185,6 +290,27 @@ type DocumentTypeListResponse struct {
 	DocumentTypes []*DocumentType `json:"document_types"`
 }
 
+// VerificationRequirement is a document a prospective driver will need to
+// upload, as shown on an onboarding checklist
+type VerificationRequirement struct {
+	Code                  string   `json:"code"`
+	Name                  string   `json:"name"`
+	Description           *string  `json:"description,omitempty"`
+	RequiresFrontBack     bool     `json:"requires_front_back"`
+	RequiresExpiry        bool     `json:"requires_expiry"`
+	AcceptedMimeTypes     []string `json:"accepted_mime_types"`
+	DefaultValidityMonths int      `json:"default_validity_months,omitempty"` // How long an approved document stays valid when it has no expiry date
+	RenewalReminderDays   int      `json:"renewal_reminder_days,omitempty"`   // How long before expiry the driver is reminded to renew
+	RequiresManualReview  bool     `json:"requires_manual_review"`
+}
+
+// VerificationRequirementsResponse is the onboarding checklist for a country,
+// or for documents required everywhere when no country is given
+type VerificationRequirementsResponse struct {
+	Country      string                     `json:"country,omitempty"`
+	Requirements []*VerificationRequirement `json:"requirements"`
+}
+
 // VerificationStatusResponse represents the driver's verification status
 type VerificationStatusResponse struct {
 	Status             VerificationStatus        `json:"status"`
@@ -212,11 +338,24 @@ type PendingReviewDocument struct {
 	DocumentType   string          `json:"document_type"`
 	HoursPending   float64         `json:"hours_pending"`
 	OCRConfidence  *float64        `json:"ocr_confidence"`
+	ThumbnailURL   *string         `json:"thumbnail_url,omitempty"` // Load this rather than the full file in review lists
+	ThumbnailKey   *string         `json:"-"`
+	ClaimedBy      *uuid.UUID      `json:"claimed_by"` // Reviewer holding a live claim, if any
+	ClaimExpiresAt *time.Time      `json:"claim_expires_at"`
+}
+
+// PendingReviewsPage is a page of the review queue fetched by cursor.
+// NextCursor fetches the page after it and is empty on the last page.
+type PendingReviewsPage struct {
+	Documents  []*PendingReviewDocument `json:"documents"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+	Limit      int                      `json:"limit"`
 }
 
 // ExpiringDocument represents an expiring document (for admin)
 type ExpiringDocument struct {
 	Document        *DriverDocument `json:"document"`
+	DriverUserID    uuid.UUID       `json:"driver_user_id"`
 	DriverName      string          `json:"driver_name"`
 	DriverEmail     string          `json:"driver_email"`
 	DriverPhone     string          `json:"driver_phone"`
@@ -225,6 +364,155 @@ type ExpiringDocument struct {
 	Urgency         string          `json:"urgency"` // 'ok', 'warning', 'critical', 'expired'
 }
 
+// ExpirySweepResult summarises a run of the document expiry sweeper
+type ExpirySweepResult struct {
+	ExpiredDocuments int         `json:"expired_documents"`
+	DriversAffected  int         `json:"drivers_affected"`
+	DriversBlocked   []uuid.UUID `json:"drivers_blocked"` // Drivers who can no longer drive
+}
+
+// VerificationStatusChange describes a transition of a driver's computed verification status
+type VerificationStatusChange struct {
+	DriverID  uuid.UUID          `json:"driver_id"`
+	OldStatus VerificationStatus `json:"old_status"`
+	NewStatus VerificationStatus `json:"new_status"`
+	CanDrive  bool               `json:"can_drive"`
+	ChangedAt time.Time          `json:"changed_at"`
+}
+
+// ReminderSeverity indicates how urgent an expiry reminder is
+type ReminderSeverity string
+
+const (
+	ReminderSeverityInfo     ReminderSeverity = "info"
+	ReminderSeverityWarning  ReminderSeverity = "warning"
+	ReminderSeverityCritical ReminderSeverity = "critical"
+)
+
+// ExpiryReminder is a notification that a driver's document is about to expire
+type ExpiryReminder struct {
+	DriverID        uuid.UUID        `json:"driver_id"`
+	UserID          uuid.UUID        `json:"user_id"`
+	DocumentID      uuid.UUID        `json:"document_id"`
+	DocumentType    string           `json:"document_type"`
+	ExpiryDate      *time.Time       `json:"expiry_date"`
+	DaysUntilExpiry int              `json:"days_until_expiry"`
+	Severity        ReminderSeverity `json:"severity"`
+	Title           string           `json:"title"`
+	Message         string           `json:"message"`
+}
+
+// ExpiryReminderResult summarises a run of SendExpiryReminders
+type ExpiryReminderResult struct {
+	Sent            int `json:"sent"`
+	SkippedCooldown int `json:"skipped_cooldown"` // Driver was reminded within the cooldown window
+	AlreadyReminded int `json:"already_reminded"` // Document was reminded about in the last day
+	Failed          int `json:"failed"`
+}
+
+// FunnelStage represents a stage of the driver verification funnel
+type FunnelStage string
+
+const (
+	FunnelStageStarted            FunnelStage = "started_uploading"
+	FunnelStageDocumentsCompleted FunnelStage = "documents_completed"
+	FunnelStageSubmittedForReview FunnelStage = "submitted_for_review"
+	FunnelStageApproved           FunnelStage = "approved"
+)
+
+// DriverFunnelProgress represents how far a single driver got through verification
+type DriverFunnelProgress struct {
+	DriverID                   uuid.UUID `json:"driver_id"`
+	FirstSubmittedAt           time.Time `json:"first_submitted_at"`
+	RequiredDocumentsSubmitted int       `json:"required_documents_submitted"`
+	SubmittedForReview         bool      `json:"submitted_for_review"`
+	Approved                   bool      `json:"approved"`
+}
+
+// FunnelStageMetrics represents the driver count and conversion for one funnel stage
+type FunnelStageMetrics struct {
+	Stage          FunnelStage `json:"stage"`
+	Drivers        int         `json:"drivers"`
+	ConversionRate float64     `json:"conversion_rate"` // % of the previous stage that reached this one
+	DropOff        int         `json:"drop_off"`        // Drivers lost since the previous stage
+}
+
+// VerificationFunnelResponse represents aggregate verification funnel metrics (for admin)
+type VerificationFunnelResponse struct {
+	Since                 time.Time            `json:"since"`
+	Stages                []FunnelStageMetrics `json:"stages"`
+	OverallConversionRate float64              `json:"overall_conversion_rate"`
+}
+
+// IdentityMatchResult is the outcome of matching a driver's selfie against their ID photo
+type IdentityMatchResult struct {
+	DriverID         uuid.UUID `json:"driver_id"`
+	SelfieDocumentID uuid.UUID `json:"selfie_document_id"`
+	IDDocumentID     uuid.UUID `json:"id_document_id"`
+	Score            float64   `json:"score"`
+	Threshold        float64   `json:"threshold"`
+	Matched          bool      `json:"matched"`
+	FlaggedForReview bool      `json:"flagged_for_review"` // Selfie sent back to the review queue
+}
+
+// ReviewTimes summarises how long reviewed documents waited, from submission to
+// a reviewer's decision
+type ReviewTimes struct {
+	Reviewed int     `json:"reviewed"`
+	AvgHours float64 `json:"avg_hours"`
+	P50Hours float64 `json:"p50_hours"`
+	P90Hours float64 `json:"p90_hours"`
+	P95Hours float64 `json:"p95_hours"`
+}
+
+// DocumentTypeReviewTimes is ReviewTimes for one document type; an empty
+// DocumentType covers all types
+type DocumentTypeReviewTimes struct {
+	DocumentType     string `json:"document_type"`
+	DocumentTypeName string `json:"document_type_name"`
+	ReviewTimes
+}
+
+// PendingReviewBacklog is the review queue for one document type
+type PendingReviewBacklog struct {
+	DocumentType       string  `json:"document_type"`
+	DocumentTypeName   string  `json:"document_type_name"`
+	Pending            int     `json:"pending"`
+	OverSLA            int     `json:"over_sla"` // Pending for longer than the review SLA
+	OldestPendingHours float64 `json:"oldest_pending_hours"`
+}
+
+// ReviewerThroughput is how many documents a reviewer decided on
+type ReviewerThroughput struct {
+	ReviewerID   uuid.UUID `json:"reviewer_id"`
+	ReviewerName string    `json:"reviewer_name"`
+	Approved     int       `json:"approved"`
+	Rejected     int       `json:"rejected"` // Including resubmission requests
+	Total        int       `json:"total"`
+}
+
+// DocumentTypeReviewMetrics is the review times and queue for one document type
+type DocumentTypeReviewMetrics struct {
+	DocumentType     string `json:"document_type"`
+	DocumentTypeName string `json:"document_type_name"`
+	ReviewTimes
+	Pending            int     `json:"pending"`
+	OverSLA            int     `json:"over_sla"`
+	OldestPendingHours float64 `json:"oldest_pending_hours"`
+}
+
+// ReviewMetricsResponse represents reviewer productivity and review SLA metrics (for admin).
+// Review times and throughput cover decisions since Since; the queue is as it stands.
+type ReviewMetricsResponse struct {
+	Since    time.Time `json:"since"`
+	SLAHours float64   `json:"sla_hours"`
+	ReviewTimes
+	Pending        int                          `json:"pending"`
+	OverSLA        int                          `json:"over_sla"`
+	Reviewers      []*ReviewerThroughput        `json:"reviewers"`
+	ByDocumentType []*DocumentTypeReviewMetrics `json:"by_document_type"`
+}
+
 // OCRResult represents the result of OCR processing
 type OCRResult struct {
 	DocumentNumber   string                 `json:"document_number"`
@@ -241,6 +529,23 @@ type OCRResult struct {
 	Metadata         map[string]interface{} `json:"metadata"`
 }
 
+// OCRCallbackRequest is the result an external OCR provider posts for a queued
+// OCR job
+type OCRCallbackRequest struct {
+	JobID            uuid.UUID                   `json:"job_id"`
+	Provider         string                      `json:"provider"`
+	Confidence       *float64                    `json:"confidence"`
+	Fields           map[string]OCRCallbackField `json:"fields"`
+	RawText          string                      `json:"raw_text"`
+	ProcessingTimeMs int                         `json:"processing_time_ms"`
+}
+
+// OCRCallbackField is one field an external OCR provider extracted
+type OCRCallbackField struct {
+	Value      json.RawMessage `json:"value"`
+	Confidence *float64        `json:"confidence"`
+}
+
 // PresignedUploadRequest represents a request for presigned upload URL
 type PresignedUploadRequest struct {
 	DocumentTypeCode string `json:"document_type_code" binding:"required"`
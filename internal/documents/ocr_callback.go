@@ -0,0 +1,220 @@
+package documents
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// ocrSignatureHeader carries the hex HMAC-SHA256 of an OCR callback's body,
+	// keyed with the shared callback secret
+	ocrSignatureHeader = "X-OCR-Signature"
+	// maxOCRCallbackBytes caps the size of an OCR callback body
+	maxOCRCallbackBytes = 1 << 20
+)
+
+// ocrTextFields are the callback fields that map onto OCRResult's text fields
+var ocrTextFields = map[string]func(*OCRResult, string){
+	"document_number":   func(r *OCRResult, v string) { r.DocumentNumber = v },
+	"full_name":         func(r *OCRResult, v string) { r.FullName = v },
+	"issuing_authority": func(r *OCRResult, v string) { r.IssuingAuthority = v },
+	"address":           func(r *OCRResult, v string) { r.Address = v },
+	"vehicle_plate":     func(r *OCRResult, v string) { r.VehiclePlate = v },
+	"vehicle_vin":       func(r *OCRResult, v string) { r.VehicleVIN = v },
+}
+
+// ocrDateFields are the callback fields that map onto OCRResult's dates, given
+// as YYYY-MM-DD
+var ocrDateFields = map[string]func(*OCRResult, *time.Time){
+	"date_of_birth": func(r *OCRResult, v *time.Time) { r.DateOfBirth = v },
+	"issue_date":    func(r *OCRResult, v *time.Time) { r.IssueDate = v },
+	"expiry_date":   func(r *OCRResult, v *time.Time) { r.ExpiryDate = v },
+}
+
+// validOCRSignature reports whether signature is the hex HMAC-SHA256 of body
+// keyed with secret. An optional "sha256=" prefix is accepted.
+func validOCRSignature(secret string, body []byte, signature string) bool {
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(given) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(given, mac.Sum(nil))
+}
+
+// ocrCallbackDigest identifies a callback's content, so a provider resending
+// the same result can be told apart from a different result for the same job
+func ocrCallbackDigest(req *OCRCallbackRequest) string {
+	canonical, _ := json.Marshal(req)
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// validOCRConfidence reports whether a confidence is on the 0 to 1 scale
+func validOCRConfidence(confidence float64) bool {
+	return confidence >= 0 && confidence <= 1
+}
+
+// ocrResultFromCallback validates a provider's callback and maps it to an
+// OCRResult. Fields the result has no place for are kept in its metadata.
+func ocrResultFromCallback(req *OCRCallbackRequest, digest string) (*OCRResult, error) {
+	if req.JobID == uuid.Nil {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "job_id is required", nil)
+	}
+	if req.Confidence == nil {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "confidence is required", nil)
+	}
+	if !validOCRConfidence(*req.Confidence) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "confidence must be between 0 and 1", nil)
+	}
+	if req.ProcessingTimeMs < 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "processing_time_ms must not be negative", nil)
+	}
+
+	result := &OCRResult{
+		Confidence: *req.Confidence,
+		RawText:    req.RawText,
+	}
+	fieldConfidence := make(map[string]interface{})
+	extraFields := make(map[string]interface{})
+
+	for name, field := range req.Fields {
+		if field.Confidence != nil {
+			if !validOCRConfidence(*field.Confidence) {
+				return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("fields.%s.confidence must be between 0 and 1", name), nil)
+			}
+			fieldConfidence[name] = *field.Confidence
+		}
+
+		setText, isText := ocrTextFields[name]
+		setDate, isDate := ocrDateFields[name]
+		if !isText && !isDate {
+			var value interface{}
+			if err := json.Unmarshal(field.Value, &value); err != nil {
+				return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("fields.%s.value is not valid JSON", name), nil)
+			}
+			extraFields[name] = value
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(field.Value, &value); err != nil {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("fields.%s.value must be a string", name), nil)
+		}
+		value = strings.TrimSpace(value)
+		if isText {
+			setText(result, value)
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("fields.%s.value must be a date in YYYY-MM-DD format", name), nil)
+		}
+		setDate(result, &date)
+	}
+
+	result.Metadata = map[string]interface{}{
+		"source":          "callback",
+		"provider":        req.Provider,
+		"callback_digest": digest,
+	}
+	if len(fieldConfidence) > 0 {
+		result.Metadata["field_confidence"] = fieldConfidence
+	}
+	if len(extraFields) > 0 {
+		result.Metadata["extra_fields"] = extraFields
+	}
+	return result, nil
+}
+
+// ProcessOCRCallback records a result an external OCR provider posted for a
+// queued job and reports whether it was a duplicate of the result already
+// recorded. A duplicate is accepted without being processed again; a different
+// result for a job that is already completed or failed is refused.
+//
+// Unlike the worker, the job is completed before the result is applied to the
+// document, so two deliveries of the same callback can't both apply it. If
+// applying it fails the job is reopened, so the provider's retry is accepted.
+func (s *Service) ProcessOCRCallback(ctx context.Context, req *OCRCallbackRequest) (bool, error) {
+	digest := ocrCallbackDigest(req)
+	result, err := ocrResultFromCallback(req, digest)
+	if err != nil {
+		return false, err
+	}
+
+	job, err := s.repo.GetOCRJob(ctx, req.JobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "OCR job not found", nil)
+		}
+		return false, common.NewInternalError("failed to get OCR job", err)
+	}
+	if job.Status != "pending" && job.Status != "processing" {
+		return closedOCRJobOutcome(job, digest)
+	}
+
+	processingTimeMs := req.ProcessingTimeMs
+	if processingTimeMs == 0 {
+		processingTimeMs = int(time.Since(job.CreatedAt).Milliseconds())
+	}
+
+	err = s.repo.CompleteOCRJob(ctx, job.ID, buildOCRData(result), result.Confidence, processingTimeMs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Closed since it was loaded, most likely by the same callback
+		// delivered twice at once
+		if job, err = s.repo.GetOCRJob(ctx, req.JobID); err != nil {
+			return false, common.NewInternalError("failed to get OCR job", err)
+		}
+		return closedOCRJobOutcome(job, digest)
+	}
+	if err != nil {
+		return false, common.NewInternalError("failed to complete OCR job", err)
+	}
+
+	if err := s.ProcessOCRResult(ctx, job.DocumentID, result); err != nil {
+		msg := fmt.Sprintf("failed to apply OCR callback: %v", err)
+		if reopenErr := s.repo.UpdateOCRJobStatus(ctx, job.ID, "processing", nil, &msg); reopenErr != nil {
+			logger.ErrorContext(ctx, "Failed to reopen OCR job", zap.String("job_id", job.ID.String()), zap.Error(reopenErr))
+		}
+		return false, common.NewInternalError("failed to apply OCR result", err)
+	}
+
+	logger.InfoContext(ctx, "OCR job completed by provider callback",
+		zap.String("job_id", job.ID.String()),
+		zap.String("document_id", job.DocumentID.String()),
+		zap.String("provider", req.Provider),
+		zap.Float64("confidence", result.Confidence),
+	)
+	return false, nil
+}
+
+// closedOCRJobOutcome accepts a callback for a completed job as a duplicate when
+// it carries the result already recorded, and refuses it otherwise
+func closedOCRJobOutcome(job *OCRProcessingQueue, digest string) (bool, error) {
+	if job.Status == "completed" {
+		if metadata, ok := job.ExtractedData["metadata"].(map[string]interface{}); ok && metadata["callback_digest"] == digest {
+			return true, nil
+		}
+		return false, common.NewErrorWithCode(http.StatusConflict, common.ErrCodeOCRJobClosed, "OCR job is already completed", nil)
+	}
+	return false, common.NewErrorWithCode(http.StatusConflict, common.ErrCodeOCRJobClosed,
+		fmt.Sprintf("OCR job is %s and no longer accepts results", job.Status), nil)
+}
@@ -0,0 +1,121 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ocrReviewDecision is whether an OCR result lets a document skip manual review
+type ocrReviewDecision struct {
+	AutoApprove bool
+	Reason      string
+}
+
+// ocrApproveThreshold returns the OCR confidence a document of docType must
+// exceed to be approved automatically. Zero means it never is.
+func (s *Service) ocrApproveThreshold(docType *DocumentType) float64 {
+	if docType != nil && docType.OCRApproveThreshold != nil {
+		return *docType.OCRApproveThreshold
+	}
+	return s.config.OCRApproveThreshold
+}
+
+// decideOCRReview checks whether a pending document can be approved on its OCR
+// result alone: its type must not require manual review, its file must not be
+// flagged as another driver's, the confidence must exceed the type's threshold,
+// and the number and expiry read from the document must agree with what the
+// driver submitted and not be expired
+func (s *Service) decideOCRReview(doc *DriverDocument, result *OCRResult, now time.Time) ocrReviewDecision {
+	manual := func(format string, args ...interface{}) ocrReviewDecision {
+		return ocrReviewDecision{Reason: fmt.Sprintf(format, args...)}
+	}
+
+	docType := doc.DocumentType
+	if docType == nil || docType.RequiresManualReview {
+		return manual("document type requires manual review")
+	}
+	if doc.DuplicateFlagged {
+		return manual("file matches another driver's document")
+	}
+
+	threshold := s.ocrApproveThreshold(docType)
+	if threshold <= 0 {
+		return manual("OCR auto-approval is not enabled for this document type")
+	}
+	if result.Confidence <= threshold {
+		return manual("OCR confidence %.0f%% does not exceed %.0f%%", result.Confidence*100, threshold*100)
+	}
+
+	if doc.DocumentNumber != nil && *doc.DocumentNumber != "" {
+		if result.DocumentNumber == "" {
+			return manual("OCR did not read the document number")
+		}
+		if normalizeDocumentNumber(result.DocumentNumber) != normalizeDocumentNumber(*doc.DocumentNumber) {
+			return manual("OCR document number does not match the submitted number")
+		}
+	}
+
+	if result.ExpiryDate == nil {
+		if docType.RequiresExpiry || doc.ExpiryDate != nil {
+			return manual("OCR did not read the expiry date")
+		}
+	} else {
+		if isExpired(result.ExpiryDate, now) {
+			return manual("OCR expiry date %s has passed", result.ExpiryDate.Format("2006-01-02"))
+		}
+		if doc.ExpiryDate != nil && !sameDate(*doc.ExpiryDate, *result.ExpiryDate) {
+			return manual("OCR expiry date does not match the submitted expiry date")
+		}
+	}
+
+	return ocrReviewDecision{
+		AutoApprove: true,
+		Reason:      fmt.Sprintf("Auto-approved with %.0f%% OCR confidence", result.Confidence*100),
+	}
+}
+
+// routeOCRReview approves a pending document whose OCR result passes
+// decideOCRReview, and otherwise records why it stays in the manual review queue
+func (s *Service) routeOCRReview(ctx context.Context, doc *DriverDocument, result *OCRResult) {
+	if doc.Status != StatusPending {
+		return // Already with a reviewer or decided
+	}
+
+	decision := s.decideOCRReview(doc, result, time.Now())
+	if !decision.AutoApprove {
+		s.logHistory(ctx, doc.ID, "manual_review_required", "", "", nil, true, decision.Reason)
+		return
+	}
+
+	if err := s.repo.UpdateDocumentStatus(ctx, doc.ID, StatusApproved, nil, &decision.Reason, nil); err != nil {
+		logger.WarnContext(ctx, "Failed to auto-approve document", zap.String("document_id", doc.ID.String()), zap.Error(err))
+		return
+	}
+	s.logHistory(ctx, doc.ID, "auto_approved", string(doc.Status), string(StatusApproved), nil, true, decision.Reason)
+
+	_, _ = s.recomputeVerification(ctx, doc.DriverID, map[uuid.UUID]DocumentStatus{doc.ID: doc.Status})
+
+	logger.InfoContext(ctx, "Document auto-approved from OCR",
+		zap.String("document_id", doc.ID.String()),
+		zap.Float64("confidence", result.Confidence),
+	)
+}
+
+// normalizeDocumentNumber ignores case, spaces and dashes, which OCR and drivers
+// render inconsistently
+func normalizeDocumentNumber(number string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(number))
+}
+
+// sameDate reports whether a and b fall on the same calendar day (UTC)
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -1,19 +1,22 @@
 package documents
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/resilience"
-	"github.com/richxcame/ride-hailing/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -23,26 +26,31 @@ type OCRProvider string
 const (
 	OCRProviderGoogleVision OCRProvider = "google_vision"
 	OCRProviderAWSTextract  OCRProvider = "aws_textract"
+	OCRProviderTesseract    OCRProvider = "tesseract"
+	OCRProviderNoop         OCRProvider = "noop"
 	OCRProviderMock         OCRProvider = "mock" // For testing
 )
 
 // OCRWorkerConfig holds worker configuration
 type OCRWorkerConfig struct {
-	Provider         OCRProvider
+	Provider         OCRProvider // Defaults to the service's OCRProvider, then noop
 	BatchSize        int
+	Concurrency      int // Most jobs of a batch processed in parallel
 	PollInterval     time.Duration
-	MaxRetries       int
+	MaxRetries       int // Applies to jobs queued without their own retry limit
 	GoogleProjectID  string
 	GoogleLocation   string
 	AWSRegion        string
+	TesseractPath    string
+	TesseractLang    string
 	MinConfidence    float64
 	ProcessorTimeout time.Duration
 }
 
-// OCRWorker processes documents from the OCR queue
+// OCRWorker drains the OCR queue, running each job's document through the
+// configured processor and storing the result through the documents service
 type OCRWorker struct {
-	repo        *Repository
-	storage     storage.Storage
+	service     *Service
 	config      OCRWorkerConfig
 	processor   OCRProcessor
 	stopCh      chan struct{}
@@ -55,11 +63,25 @@ type OCRProcessor interface {
 	Name() string
 }
 
-// NewOCRWorker creates a new OCR worker
-func NewOCRWorker(repo *Repository, storage storage.Storage, config OCRWorkerConfig) *OCRWorker {
+// permanentOCRError marks a failure that retrying cannot fix
+type permanentOCRError struct {
+	err error
+}
+
+func (e *permanentOCRError) Error() string { return e.err.Error() }
+func (e *permanentOCRError) Unwrap() error { return e.err }
+
+// NewOCRWorker creates a new OCR worker for the given documents service
+func NewOCRWorker(service *Service, config OCRWorkerConfig) *OCRWorker {
+	if config.Provider == "" {
+		config.Provider = OCRProvider(service.config.OCRProvider)
+	}
 	if config.BatchSize == 0 {
 		config.BatchSize = 10
 	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
 	if config.PollInterval == 0 {
 		config.PollInterval = 30 * time.Second
 	}
@@ -73,17 +95,17 @@ func NewOCRWorker(repo *Repository, storage storage.Storage, config OCRWorkerCon
 		config.ProcessorTimeout = 60 * time.Second
 	}
 
-	// Configure retry with jittered exponential backoff for OCR processing
+	// Space out attempts of failed jobs with jittered exponential backoff
 	retryConfig := resilience.DefaultRetryConfig()
 	retryConfig.MaxAttempts = config.MaxRetries
-	retryConfig.InitialBackoff = 2 * time.Second
-	retryConfig.MaxBackoff = 30 * time.Second
+	retryConfig.InitialBackoff = 30 * time.Second
+	retryConfig.MaxBackoff = 30 * time.Minute
+	retryConfig.BackoffMultiplier = 2.0
 	retryConfig.EnableJitter = true
 	retryConfig.RetryableChecker = isOCRRetryable
 
 	worker := &OCRWorker{
-		repo:        repo,
-		storage:     storage,
+		service:     service,
 		config:      config,
 		stopCh:      make(chan struct{}),
 		retryConfig: retryConfig,
@@ -95,10 +117,12 @@ func NewOCRWorker(repo *Repository, storage storage.Storage, config OCRWorkerCon
 		worker.processor = NewGoogleVisionProcessor(config.GoogleProjectID, config.GoogleLocation)
 	case OCRProviderAWSTextract:
 		worker.processor = NewAWSTextractProcessor(config.AWSRegion)
+	case OCRProviderTesseract:
+		worker.processor = NewTesseractProcessor(config.TesseractPath, config.TesseractLang)
 	case OCRProviderMock:
 		worker.processor = NewMockOCRProcessor()
 	default:
-		worker.processor = NewMockOCRProcessor()
+		worker.processor = NewNoopOCRProcessor()
 	}
 
 	return worker
@@ -106,7 +130,11 @@ func NewOCRWorker(repo *Repository, storage storage.Storage, config OCRWorkerCon
 
 // Start begins processing OCR jobs
 func (w *OCRWorker) Start(ctx context.Context) {
-	logger.Info("OCR Worker started", zap.String("provider", w.processor.Name()))
+	logger.InfoContext(ctx, "OCR Worker started",
+		zap.String("provider", w.processor.Name()),
+		zap.Duration("poll_interval", w.config.PollInterval),
+		zap.Int("concurrency", w.config.Concurrency),
+	)
 
 	ticker := time.NewTicker(w.config.PollInterval)
 	defer ticker.Stop()
@@ -117,10 +145,10 @@ func (w *OCRWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("OCR Worker stopping due to context cancellation")
+			logger.InfoContext(ctx, "OCR Worker stopping due to context cancellation")
 			return
 		case <-w.stopCh:
-			logger.Info("OCR Worker stopped")
+			logger.InfoContext(ctx, "OCR Worker stopped")
 			return
 		case <-ticker.C:
 			w.processBatch(ctx)
@@ -133,104 +161,121 @@ func (w *OCRWorker) Stop() {
 	close(w.stopCh)
 }
 
-// processBatch processes a batch of OCR jobs
-func (w *OCRWorker) processBatch(ctx context.Context) {
-	jobs, err := w.repo.GetPendingOCRJobs(ctx, w.config.BatchSize)
+// processBatch processes a batch of OCR jobs, up to Concurrency at a time, and
+// returns the number of jobs picked up
+func (w *OCRWorker) processBatch(ctx context.Context) int {
+	jobs, err := w.service.repo.GetPendingOCRJobs(ctx, w.config.BatchSize)
 	if err != nil {
-		logger.Error("Failed to get pending OCR jobs", zap.Error(err))
-		return
+		logger.ErrorContext(ctx, "Failed to get pending OCR jobs", zap.Error(err))
+		return 0
 	}
 
 	if len(jobs) == 0 {
-		return
+		return 0
 	}
 
-	logger.Info("Processing OCR batch", zap.Int("count", len(jobs)))
+	logger.InfoContext(ctx, "Processing OCR batch", zap.Int("count", len(jobs)))
 
+	sem := make(chan struct{}, w.config.Concurrency)
+	var wg sync.WaitGroup
 	for _, job := range jobs {
 		select {
 		case <-ctx.Done():
-			return
-		default:
-			w.processJob(ctx, job)
+			wg.Wait()
+			return len(jobs)
+		case sem <- struct{}{}:
 		}
+
+		wg.Add(1)
+		go func(job *OCRProcessingQueue) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.processJob(ctx, job)
+		}(job)
 	}
+	wg.Wait()
+
+	return len(jobs)
 }
 
-// processJob processes a single OCR job
+// processJob processes a single OCR job and records the outcome
 func (w *OCRWorker) processJob(ctx context.Context, job *OCRProcessingQueue) {
-	jobCtx, cancel := context.WithTimeout(ctx, w.config.ProcessorTimeout)
-	defer cancel()
-
 	// Mark as processing
-	if err := w.repo.UpdateOCRJobStatus(ctx, job.ID, "processing", nil, nil); err != nil {
-		logger.Error("Failed to mark OCR job as processing", zap.Error(err))
+	if err := w.service.repo.UpdateOCRJobStatus(ctx, job.ID, "processing", nil, nil); err != nil {
+		logger.ErrorContext(ctx, "Failed to mark OCR job as processing", zap.String("job_id", job.ID.String()), zap.Error(err))
 		return
 	}
 
-	// Get document
-	doc, err := w.repo.GetDocument(jobCtx, job.DocumentID)
-	if err != nil {
-		w.failJob(ctx, job, fmt.Sprintf("document not found: %v", err))
-		return
-	}
+	// Record the outcome even if the worker is shutting down, so the job isn't
+	// left stuck in processing
+	storeCtx := context.WithoutCancel(ctx)
+	started := time.Now()
 
-	// Download document from storage
-	imageData, err := w.downloadDocument(jobCtx, doc.FileKey)
+	result, err := w.recognizeDocument(ctx, job)
+	if err == nil {
+		if result.Confidence < w.config.MinConfidence {
+			logger.WarnContext(ctx, "OCR result below confidence threshold",
+				zap.String("document_id", job.DocumentID.String()),
+				zap.Float64("confidence", result.Confidence),
+				zap.Float64("threshold", w.config.MinConfidence),
+			)
+		}
+		err = w.service.ProcessOCRResult(storeCtx, job.DocumentID, result)
+	}
 	if err != nil {
-		w.failJob(ctx, job, fmt.Sprintf("failed to download document: %v", err))
+		w.failJob(storeCtx, job, err)
 		return
 	}
 
-	// Process with OCR
-	mimeType := "image/jpeg"
-	if doc.FileMimeType != nil {
-		mimeType = *doc.FileMimeType
-	}
-
-	result, err := w.processor.ProcessDocument(jobCtx, imageData, mimeType)
-	if err != nil {
-		w.handleProcessingError(ctx, job, err)
+	processingTimeMs := int(time.Since(started).Milliseconds())
+	if err := w.service.repo.CompleteOCRJob(storeCtx, job.ID, buildOCRData(result), result.Confidence, processingTimeMs); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// A provider callback closed the job while it was processed here
+			logger.WarnContext(ctx, "OCR job was already closed", zap.String("job_id", job.ID.String()))
+			return
+		}
+		logger.ErrorContext(ctx, "Failed to mark OCR job as completed", zap.String("job_id", job.ID.String()), zap.Error(err))
 		return
 	}
 
-	// Validate confidence
-	if result.Confidence < w.config.MinConfidence {
-		logger.Warn("OCR result below confidence threshold",
-			zap.String("document_id", doc.ID.String()),
-			zap.Float64("confidence", result.Confidence),
-			zap.Float64("threshold", w.config.MinConfidence),
-		)
-	}
+	logger.InfoContext(ctx, "OCR job completed",
+		zap.String("job_id", job.ID.String()),
+		zap.String("document_id", job.DocumentID.String()),
+		zap.Float64("confidence", result.Confidence),
+		zap.Int("processing_time_ms", processingTimeMs),
+	)
+}
 
-	// Save result
-	ocrData := w.buildOCRData(result)
-	if err := w.repo.UpdateDocumentOCRData(ctx, doc.ID, ocrData, result.Confidence); err != nil {
-		w.failJob(ctx, job, fmt.Sprintf("failed to save OCR data: %v", err))
-		return
-	}
+// recognizeDocument downloads a job's document and runs it through the OCR processor
+func (w *OCRWorker) recognizeDocument(ctx context.Context, job *OCRProcessingQueue) (*OCRResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.config.ProcessorTimeout)
+	defer cancel()
 
-	// Update document details from OCR
-	w.updateDocumentFromOCR(ctx, doc.ID, result)
+	doc, err := w.service.repo.GetDocument(ctx, job.DocumentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &permanentOCRError{fmt.Errorf("document not found: %w", err)}
+		}
+		return nil, err
+	}
 
-	// Mark job as completed
-	resultJSON, _ := json.Marshal(result)
-	resultStr := string(resultJSON)
-	if err := w.repo.UpdateOCRJobStatus(ctx, job.ID, "completed", &resultStr, nil); err != nil {
-		logger.Error("Failed to mark OCR job as completed", zap.Error(err))
+	// Download document from storage
+	fileKey, mimeType := ocrSource(doc, w.service.config.OCRUseOriginalImages)
+	imageData, err := w.downloadDocument(ctx, fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download document: %w", err)
 	}
+	mimeType = ocrMimeType(imageData, mimeType)
 
-	// Log history
-	w.logOCRHistory(ctx, doc.ID, result)
+	// Documents whose file wasn't read into memory on upload get their
+	// thumbnail here
+	w.service.ensureThumbnail(ctx, doc, imageData, mimeType)
 
-	logger.Info("OCR job completed",
-		zap.String("document_id", doc.ID.String()),
-		zap.Float64("confidence", result.Confidence),
-	)
+	return w.processor.ProcessDocument(ctx, imageData, mimeType)
 }
 
 func (w *OCRWorker) downloadDocument(ctx context.Context, fileKey string) ([]byte, error) {
-	reader, err := w.storage.Download(ctx, fileKey)
+	reader, err := w.service.storage.Download(ctx, fileKey)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +284,7 @@ func (w *OCRWorker) downloadDocument(ctx context.Context, fileKey string) ([]byt
 	return io.ReadAll(reader)
 }
 
-func (w *OCRWorker) buildOCRData(result *OCRResult) map[string]interface{} {
+func buildOCRData(result *OCRResult) map[string]interface{} {
 	data := map[string]interface{}{
 		"raw_text":   result.RawText,
 		"confidence": result.Confidence,
@@ -277,65 +322,45 @@ func (w *OCRWorker) buildOCRData(result *OCRResult) map[string]interface{} {
 	return data
 }
 
-func (w *OCRWorker) updateDocumentFromOCR(ctx context.Context, documentID uuid.UUID, result *OCRResult) {
-	var docNum, authority *string
-	if result.DocumentNumber != "" {
-		docNum = &result.DocumentNumber
-	}
-	if result.IssuingAuthority != "" {
-		authority = &result.IssuingAuthority
-	}
-
-	if err := w.repo.UpdateDocumentDetails(ctx, documentID, docNum, result.IssueDate, result.ExpiryDate, authority); err != nil {
-		logger.Warn("Failed to update document details from OCR", zap.Error(err))
-	}
-}
-
-func (w *OCRWorker) logOCRHistory(ctx context.Context, documentID uuid.UUID, result *OCRResult) {
-	notes := fmt.Sprintf("OCR processed with %.0f%% confidence", result.Confidence*100)
-	history := &DocumentVerificationHistory{
-		ID:             uuid.New(),
-		DocumentID:     documentID,
-		Action:         "ocr_processed",
-		IsSystemAction: true,
-		Notes:          &notes,
+// failJob schedules a failed job for another attempt with exponential backoff,
+// or marks it permanently failed once its retries are used up or the error can't
+// be fixed by retrying
+func (w *OCRWorker) failJob(ctx context.Context, job *OCRProcessingQueue, err error) {
+	attempt := job.RetryCount + 1
+	maxRetries := job.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = w.config.MaxRetries
 	}
 
-	if err := w.repo.CreateHistory(ctx, history); err != nil {
-		logger.Warn("Failed to create OCR history entry", zap.Error(err))
-	}
-}
-
-func (w *OCRWorker) failJob(ctx context.Context, job *OCRProcessingQueue, errMsg string) {
-	if err := w.repo.UpdateOCRJobStatus(ctx, job.ID, "failed", nil, &errMsg); err != nil {
-		logger.Error("Failed to mark OCR job as failed", zap.Error(err))
+	var permanent *permanentOCRError
+	if attempt >= maxRetries || errors.As(err, &permanent) || !isOCRRetryable(err) {
+		if exhaustErr := w.service.repo.ExhaustOCRJob(ctx, job.ID, err.Error()); exhaustErr != nil {
+			logger.ErrorContext(ctx, "Failed to mark OCR job as failed", zap.String("job_id", job.ID.String()), zap.Error(exhaustErr))
+		}
+		logger.ErrorContext(ctx, "OCR job failed permanently",
+			zap.String("job_id", job.ID.String()),
+			zap.String("document_id", job.DocumentID.String()),
+			zap.Int("attempts", attempt),
+			zap.Error(err),
+		)
+		return
 	}
-	logger.Error("OCR job failed",
-		zap.String("job_id", job.ID.String()),
-		zap.String("document_id", job.DocumentID.String()),
-		zap.String("error", errMsg),
-	)
-}
 
-func (w *OCRWorker) handleProcessingError(ctx context.Context, job *OCRProcessingQueue, err error) {
-	job.RetryCount++
-	if job.RetryCount >= w.config.MaxRetries {
-		w.failJob(ctx, job, fmt.Sprintf("max retries exceeded: %v", err))
-		return
+	if failErr := w.service.repo.FailOCRJob(ctx, job.ID, err.Error()); failErr != nil {
+		logger.ErrorContext(ctx, "Failed to record OCR job failure", zap.String("job_id", job.ID.String()), zap.Error(failErr))
 	}
 
 	// Schedule retry with jittered exponential backoff using resilience package pattern
-	backoffDuration := calculateOCRBackoff(job.RetryCount, w.retryConfig)
-	nextRetry := time.Now().Add(backoffDuration)
-
-	if updateErr := w.repo.UpdateOCRJobRetry(ctx, job.ID, job.RetryCount, nextRetry); updateErr != nil {
-		logger.Error("Failed to update OCR job for retry", zap.Error(updateErr))
+	backoff := calculateOCRBackoff(attempt, w.retryConfig)
+	nextRetry := time.Now().Add(backoff)
+	if retryErr := w.service.repo.UpdateOCRJobRetry(ctx, job.ID, attempt, nextRetry); retryErr != nil {
+		logger.ErrorContext(ctx, "Failed to schedule OCR job retry", zap.String("job_id", job.ID.String()), zap.Error(retryErr))
 	}
 
-	logger.Warn("OCR job will be retried",
+	logger.WarnContext(ctx, "OCR job will be retried",
 		zap.String("job_id", job.ID.String()),
-		zap.Int("retry_count", job.RetryCount),
-		zap.Duration("backoff", backoffDuration),
+		zap.Int("retry_count", attempt),
+		zap.Duration("backoff", backoff),
 		zap.Time("next_retry", nextRetry),
 		zap.Error(err),
 	)
@@ -470,6 +495,135 @@ func (p *MockOCRProcessor) ProcessDocument(ctx context.Context, imageData []byte
 	}, nil
 }
 
+// ========================================
+// NOOP OCR PROCESSOR
+// ========================================
+
+// NoopOCRProcessor extracts nothing. It lets the OCR queue drain when no OCR
+// backend is configured, leaving documents for manual review.
+type NoopOCRProcessor struct{}
+
+func NewNoopOCRProcessor() *NoopOCRProcessor {
+	return &NoopOCRProcessor{}
+}
+
+func (p *NoopOCRProcessor) Name() string {
+	return "noop"
+}
+
+func (p *NoopOCRProcessor) ProcessDocument(ctx context.Context, imageData []byte, mimeType string) (*OCRResult, error) {
+	return &OCRResult{
+		Metadata: map[string]interface{}{
+			"processor": "noop",
+		},
+	}, nil
+}
+
+// ========================================
+// TESSERACT PROCESSOR
+// ========================================
+
+// TesseractProcessor runs the tesseract CLI locally
+type TesseractProcessor struct {
+	binary   string
+	language string
+}
+
+// NewTesseractProcessor creates a processor that runs the given tesseract binary
+// (default "tesseract" on PATH) with the given language (default "eng")
+func NewTesseractProcessor(binary, language string) *TesseractProcessor {
+	if binary == "" {
+		binary = "tesseract"
+	}
+	if language == "" {
+		language = "eng"
+	}
+	return &TesseractProcessor{
+		binary:   binary,
+		language: language,
+	}
+}
+
+func (p *TesseractProcessor) Name() string {
+	return "tesseract"
+}
+
+func (p *TesseractProcessor) ProcessDocument(ctx context.Context, imageData []byte, mimeType string) (*OCRResult, error) {
+	if mimeType == "application/pdf" {
+		return nil, fmt.Errorf("unsupported format for tesseract: %s", mimeType)
+	}
+
+	// Read the image from stdin and write word-level TSV to stdout
+	cmd := exec.CommandContext(ctx, p.binary, "stdin", "stdout", "-l", p.language, "tsv")
+	cmd.Stdin = bytes.NewReader(imageData)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("tesseract timeout: %w", ctx.Err())
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("tesseract not configured: %w", err)
+		}
+		return nil, fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	text, confidence := parseTesseractTSV(stdout.String())
+
+	return &OCRResult{
+		DocumentNumber: extractDocumentNumber(text),
+		ExpiryDate:     extractExpiryDate(text),
+		Confidence:     confidence,
+		RawText:        text,
+		Metadata: map[string]interface{}{
+			"processor": "tesseract",
+			"language":  p.language,
+		},
+	}, nil
+}
+
+// parseTesseractTSV rebuilds the recognised text line by line from tesseract's
+// TSV output and returns it with the mean word confidence (0-1)
+func parseTesseractTSV(tsv string) (string, float64) {
+	var text strings.Builder
+	var lastLine string
+	var confSum float64
+	var words int
+
+	for _, row := range strings.Split(tsv, "\n") {
+		// level page block par line word left top width height conf text
+		cols := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		word := strings.TrimSpace(cols[11])
+		if err != nil || conf < 0 || word == "" {
+			continue
+		}
+
+		line := strings.Join(cols[1:5], ":")
+		switch {
+		case words == 0:
+		case line != lastLine:
+			text.WriteByte('\n')
+		default:
+			text.WriteByte(' ')
+		}
+		text.WriteString(word)
+		lastLine = line
+		confSum += conf
+		words++
+	}
+
+	if words == 0 {
+		return "", 0
+	}
+	return text.String(), confSum / float64(words) / 100
+}
+
 // ========================================
 // GOOGLE VISION PROCESSOR
 // ========================================
@@ -518,13 +672,13 @@ func (p *GoogleVisionProcessor) ProcessDocument(ctx context.Context, imageData [
 	}
 
 	// Extract document fields from raw text
-	result.DocumentNumber = p.extractDocumentNumber(result.RawText)
-	result.ExpiryDate = p.extractExpiryDate(result.RawText)
+	result.DocumentNumber = extractDocumentNumber(result.RawText)
+	result.ExpiryDate = extractExpiryDate(result.RawText)
 
 	return result, nil
 }
 
-func (p *GoogleVisionProcessor) extractDocumentNumber(text string) string {
+func extractDocumentNumber(text string) string {
 	// Common patterns for document numbers
 	patterns := []string{
 		`(?i)(?:license|licence|dl|no|number)[:\s]*([A-Z0-9-]+)`,
@@ -540,7 +694,7 @@ func (p *GoogleVisionProcessor) extractDocumentNumber(text string) string {
 	return ""
 }
 
-func (p *GoogleVisionProcessor) extractExpiryDate(text string) *time.Time {
+func extractExpiryDate(text string) *time.Time {
 	// Common date patterns
 	patterns := []string{
 		`(?i)exp(?:iry|ires)?[:\s]*(\d{2}[/-]\d{2}[/-]\d{4})`,
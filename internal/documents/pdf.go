@@ -0,0 +1,260 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// defaultMaxPDFPages is the most pages a PDF upload may have when no limit is configured
+	defaultMaxPDFPages = 20
+
+	// pdfMaxInflatedBytes caps how much a PDF's object streams may decompress to
+	// in total, so a compression bomb can't exhaust memory
+	pdfMaxInflatedBytes = 32 << 20
+	// pdfMaxObjectStreams caps how many object streams are decompressed
+	pdfMaxObjectStreams = 1000
+	// pdfMaxPageTreeNodes caps how many page tree nodes are read in each part of
+	// the file, bounding the time a crafted file can take
+	pdfMaxPageTreeNodes = 5000
+	// pdfMaxDictScan is how far from a match a dictionary's brackets are looked for
+	pdfMaxDictScan = 16 << 10
+	// pdfHeaderWindow is how near the start of the file the header must be
+	pdfHeaderWindow = 1024
+	// pdfTrailerWindow is how near the end of the file %%EOF must be
+	pdfTrailerWindow = 2048
+)
+
+var (
+	errPDFInvalid   = errors.New("invalid PDF")
+	errPDFEncrypted = errors.New("PDF is encrypted")
+)
+
+var (
+	pdfPagesTypePattern  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfCountPattern      = regexp.MustCompile(`/Count\s+(\d{1,9})`)
+	pdfEncryptPattern    = regexp.MustCompile(`/Encrypt\b`)
+	pdfObjStmTypePattern = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pdfFlatePattern      = regexp.MustCompile(`/FlateDecode\b`)
+)
+
+// pdfInfo is what inspecting a PDF found out about it
+type pdfInfo struct {
+	Pages int
+}
+
+// inspectPDF checks a PDF is complete and unencrypted and counts its pages,
+// without a full parse. It reads the page count from the page tree, including
+// page trees in compressed object streams. Work is linear in the file size and
+// decompression is capped at pdfMaxInflatedBytes, so a crafted file can't stall
+// or exhaust the service.
+func inspectPDF(data []byte) (*pdfInfo, error) {
+	scanner := newPDFScanner()
+	_, _ = scanner.Write(data)
+	return scanner.finish()
+}
+
+// pdfScanner inspects a PDF as it's written, so an upload can be checked while
+// it streams to storage. It holds the start of the file and a sliding window
+// over the rest, growing the window only while an object stream is incomplete.
+type pdfScanner struct {
+	head       []byte
+	window     []byte
+	offset     int64 // Position of window[0] in the file
+	scanAt     int   // Window length that triggers the next scan
+	nextObjStm int64 // Object streams before this position have been handled
+	objStreams int
+	budget     int64 // Bytes object streams may still decompress to
+	encrypted  bool
+	pages      int
+	err        error
+}
+
+// pdfScanChunk is how much is written to a pdfScanner between scans
+const pdfScanChunk = 256 << 10
+
+func newPDFScanner() *pdfScanner {
+	return &pdfScanner{scanAt: pdfScanChunk, budget: pdfMaxInflatedBytes}
+}
+
+// Write adds the next part of the file. It never fails; problems with the file
+// are reported by finish.
+func (p *pdfScanner) Write(b []byte) (int, error) {
+	if len(p.head) < pdfHeaderWindow {
+		p.head = append(p.head, b[:min(len(b), pdfHeaderWindow-len(p.head))]...)
+	}
+	p.window = append(p.window, b...)
+	if len(p.window) >= p.scanAt {
+		p.scan(false)
+	}
+	return len(b), nil
+}
+
+// finish scans the rest of the file and returns what was found out about it
+func (p *pdfScanner) finish() (*pdfInfo, error) {
+	p.scan(true)
+	if !bytes.Contains(p.head, []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: no PDF header", errPDFInvalid)
+	}
+	if !bytes.Contains(p.window[max(0, len(p.window)-pdfTrailerWindow):], []byte("%%EOF")) {
+		return nil, fmt.Errorf("%w: file is truncated", errPDFInvalid)
+	}
+	if p.encrypted {
+		return nil, errPDFEncrypted
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pages <= 0 {
+		return nil, fmt.Errorf("%w: no pages found", errPDFInvalid)
+	}
+	return &pdfInfo{Pages: p.pages}, nil
+}
+
+// scan inspects the window, then drops all of it but the overlap the next scan
+// needs to see dictionaries that straddle the boundary. An object stream that
+// doesn't end within the window yet is kept for the next scan.
+func (p *pdfScanner) scan(final bool) {
+	data := p.window
+	if pdfEncryptPattern.Match(data) {
+		p.encrypted = true
+	}
+	p.pages = max(p.pages, pdfPageTreeCount(data))
+
+	keepFrom := max(0, len(data)-2*pdfMaxDictScan)
+	for _, match := range pdfObjStmTypePattern.FindAllIndex(data, -1) {
+		if p.err != nil || p.objStreams >= pdfMaxObjectStreams {
+			break
+		}
+		if p.offset+int64(match[0]) < p.nextObjStm {
+			continue
+		}
+		if !p.inflateObjectStream(data, match[0], final) {
+			keepFrom = min(keepFrom, max(0, match[0]-pdfMaxDictScan))
+			break
+		}
+		p.objStreams++
+		p.nextObjStm = p.offset + int64(match[0]) + 1
+	}
+
+	p.offset += int64(keepFrom)
+	p.window = append([]byte(nil), data[keepFrom:]...)
+	p.scanAt = len(p.window) + max(pdfScanChunk, len(p.window))
+}
+
+// inflateObjectStream counts the pages in the Flate-encoded object stream whose
+// type is at pos. Streams that fail to decompress are skipped; object streams
+// that decompress to more than pdfMaxInflatedBytes in total fail the file. It
+// returns false when the stream doesn't end within data yet.
+func (p *pdfScanner) inflateObjectStream(data []byte, pos int, final bool) bool {
+	start, end, ok := pdfEnclosingDict(data, pos)
+	if !ok {
+		return final || len(data)-pos > pdfMaxDictScan
+	}
+	if !pdfFlatePattern.Match(data[start:end]) {
+		return true
+	}
+	body := pdfStreamBody(data[end:])
+	if body == nil {
+		// A stream bigger than the budget can't be inflated within it anyway
+		return final || int64(len(data)-end) > p.budget
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return true
+	}
+	objects, _ := io.ReadAll(io.LimitReader(zr, p.budget+1))
+	zr.Close()
+	if int64(len(objects)) > p.budget {
+		p.err = fmt.Errorf("%w: object streams decompress to more than %d MB", errPDFInvalid, pdfMaxInflatedBytes>>20)
+		return true
+	}
+	p.budget -= int64(len(objects))
+	p.pages = max(p.pages, pdfPageTreeCount(objects))
+	return true
+}
+
+// pdfPageTreeCount returns the largest /Count of the page tree nodes in data.
+// The root node counts every page; nodes replaced by an incremental update
+// count no more than the current root.
+func pdfPageTreeCount(data []byte) int {
+	count := 0
+	for _, match := range pdfPagesTypePattern.FindAllIndex(data, pdfMaxPageTreeNodes) {
+		start, end, ok := pdfEnclosingDict(data, match[0])
+		if !ok {
+			continue
+		}
+		if m := pdfCountPattern.FindSubmatch(data[start:end]); m != nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil {
+				count = max(count, n)
+			}
+		}
+	}
+	return count
+}
+
+// pdfEnclosingDict returns the bounds of the << >> dictionary around pos, if its
+// brackets are found within pdfMaxDictScan bytes
+func pdfEnclosingDict(data []byte, pos int) (int, int, bool) {
+	start := -1
+	depth := 0
+	for i := pos - 1; i > 0 && pos-i < pdfMaxDictScan; i-- {
+		if data[i-1] == '>' && data[i] == '>' {
+			depth++
+			i--
+		} else if data[i-1] == '<' && data[i] == '<' {
+			if depth == 0 {
+				start = i - 1
+				break
+			}
+			depth--
+			i--
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+
+	depth = 0
+	for i := start; i+1 < len(data) && i-start < pdfMaxDictScan; i++ {
+		if data[i] == '<' && data[i+1] == '<' {
+			depth++
+			i++
+		} else if data[i] == '>' && data[i+1] == '>' {
+			depth--
+			i++
+			if depth == 0 {
+				return start, i + 1, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// pdfStreamBody returns the bytes between the stream and endstream keywords
+// that directly follow a stream's dictionary, or nil if they don't
+func pdfStreamBody(data []byte) []byte {
+	head := data[:min(len(data), 64)]
+	start := len(head) - len(bytes.TrimLeft(head, " \t\r\n"))
+	if !bytes.HasPrefix(data[start:], []byte("stream")) {
+		return nil
+	}
+	start += len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil
+	}
+	return data[start : start+end]
+}
@@ -0,0 +1,319 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // Register the PNG decoder
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/richxcame/ride-hailing/pkg/storage"
+)
+
+const (
+	// defaultImageMaxDimension is used when ImageMaxDimension is not set
+	defaultImageMaxDimension = 2048
+	// defaultImageMaxPixels is used when ImageMaxPixels is not set
+	defaultImageMaxPixels = 40_000_000
+	// defaultImageJPEGQuality is used when ImageJPEGQuality is not set
+	defaultImageJPEGQuality = 85
+)
+
+// errImageTooLarge is returned for images whose declared size exceeds the pixel
+// limit, before any of their pixels are decoded
+var errImageTooLarge = errors.New("image has too many pixels")
+
+// ImagePreprocessor normalizes uploaded images before they are stored
+type ImagePreprocessor interface {
+	// Preprocess returns the image to store in place of data, or nil to store
+	// data as uploaded
+	Preprocess(data []byte, mimeType string) (*ProcessedImage, error)
+}
+
+// ProcessedImage is a normalized image ready to store
+type ProcessedImage struct {
+	Data     []byte
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// jpegPreprocessor auto-orients images using their EXIF orientation, scales
+// them down to fit maxDimension and re-encodes them as JPEG. Only the decoders
+// in the standard library are available, so WebP uploads are left as they are.
+type jpegPreprocessor struct {
+	maxDimension int
+	maxPixels    int64
+	quality      int
+}
+
+func newImagePreprocessor(config ServiceConfig) ImagePreprocessor {
+	return &jpegPreprocessor{
+		maxDimension: config.ImageMaxDimension,
+		maxPixels:    config.ImageMaxPixels,
+		quality:      config.ImageJPEGQuality,
+	}
+}
+
+// Preprocess implements ImagePreprocessor
+func (p *jpegPreprocessor) Preprocess(data []byte, mimeType string) (*ProcessedImage, error) {
+	mimeType = strings.ToLower(mimeType)
+	if mimeType != "image/jpeg" && mimeType != "image/png" {
+		return nil, nil
+	}
+
+	// Check the dimensions from the header first so a small file claiming a
+	// huge image can't make us allocate its pixels
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > p.maxPixels {
+		return nil, errImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	orientation := 1
+	if mimeType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	bounds := img.Bounds()
+	needsResize := bounds.Dx() > p.maxDimension || bounds.Dy() > p.maxDimension
+	if mimeType == "image/jpeg" && orientation == 1 && !needsResize {
+		// Already normalized; re-encoding would only cost quality
+		return nil, nil
+	}
+
+	rgba := toRGBA(img)
+	rgba = orient(rgba, orientation)
+	rgba = downscale(rgba, p.maxDimension)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: p.quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return &ProcessedImage{
+		Data:     buf.Bytes(),
+		MimeType: "image/jpeg",
+		Width:    rgba.Bounds().Dx(),
+		Height:   rgba.Bounds().Dy(),
+	}, nil
+}
+
+// toRGBA copies an image into an RGBA image with its origin at (0, 0)
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// orient turns an image the way its EXIF orientation says it should be displayed
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Orientations 5-8 swap width and height
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Needs a 90° clockwise turn
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Needs a 90° counter-clockwise turn
+				sx, sy = w-1-y, x
+			}
+			si := sy*src.Stride + sx*4
+			di := y*dst.Stride + x*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// downscale shrinks an image so neither side exceeds maxDimension, averaging the
+// source pixels behind each target pixel. Smaller images are returned unchanged.
+func downscale(src *image.RGBA, maxDimension int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxDimension && h <= maxDimension {
+		return src
+	}
+
+	dw, dh := maxDimension, h*maxDimension/w
+	if h > w {
+		dw, dh = w*maxDimension/h, maxDimension
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := sy * src.Stride
+				for sx := x0; sx < x1; sx++ {
+					i := row + sx*4
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					n++
+				}
+			}
+
+			di := y*dst.Stride + x*4
+			dst.Pix[di] = uint8(r / n)
+			dst.Pix[di+1] = uint8(g / n)
+			dst.Pix[di+2] = uint8(b / n)
+			dst.Pix[di+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 when it has none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data starts; EXIF always comes before it
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		const orientationTag = 0x0112
+		if order.Uint16(tiff[entry:entry+2]) != orientationTag {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// processedFileName gives an upload's file name the extension of the format it
+// was re-encoded to, so its storage key matches its content
+func processedFileName(fileName, mimeType string) string {
+	if mimeType != "image/jpeg" {
+		return fileName
+	}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".jpg", ".jpeg":
+		return fileName
+	}
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + ".jpg"
+}
+
+// originalFileKey is where the upload behind a processed file is kept, next to
+// the processed file and with the uploaded file's extension
+func originalFileKey(fileKey, fileName string) string {
+	return strings.TrimSuffix(fileKey, path.Ext(fileKey)) + "_original" + path.Ext(fileName)
+}
+
+// ocrSource returns the key of the file OCR should read for a document and its
+// MIME type, if known. With preferOriginal, OCR reads the high-resolution
+// original of a processed image when one was kept.
+func ocrSource(doc *DriverDocument, preferOriginal bool) (string, string) {
+	if preferOriginal && doc.OriginalFileKey != nil {
+		// The original's type isn't recorded; it is sniffed once downloaded
+		return *doc.OriginalFileKey, ""
+	}
+	if doc.FileMimeType != nil {
+		return doc.FileKey, *doc.FileMimeType
+	}
+	return doc.FileKey, "image/jpeg"
+}
+
+// ocrMimeType returns the MIME type of a file read for OCR, sniffing it when unknown
+func ocrMimeType(data []byte, known string) string {
+	if known != "" {
+		return known
+	}
+	return storage.BaseMimeType(http.DetectContentType(data))
+}
@@ -3,11 +3,14 @@ package documents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 )
 
 // Repository handles database operations for documents
@@ -29,7 +32,8 @@ func (r *Repository) GetDocumentTypes(ctx context.Context) ([]*DocumentType, err
 	query := `
 		SELECT id, code, name, description, is_required, requires_expiry, requires_front_back,
 			   default_validity_months, renewal_reminder_days, requires_manual_review,
-			   auto_ocr_enabled, country_codes, display_order, is_active, created_at, updated_at
+			   auto_ocr_enabled, ocr_approve_threshold, country_codes, allowed_mime_types, display_order,
+			   is_active, created_at, updated_at
 		FROM document_types
 		WHERE is_active = true
 		ORDER BY display_order, name
@@ -47,8 +51,8 @@ func (r *Repository) GetDocumentTypes(ctx context.Context) ([]*DocumentType, err
 		if err := rows.Scan(
 			&dt.ID, &dt.Code, &dt.Name, &dt.Description, &dt.IsRequired, &dt.RequiresExpiry,
 			&dt.RequiresFrontBack, &dt.DefaultValidityMonths, &dt.RenewalReminderDays,
-			&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.CountryCodes, &dt.DisplayOrder,
-			&dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
+			&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.OCRApproveThreshold, &dt.CountryCodes, &dt.AllowedMimeTypes,
+			&dt.DisplayOrder, &dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan document type: %w", err)
 		}
@@ -63,7 +67,8 @@ func (r *Repository) GetDocumentTypeByCode(ctx context.Context, code string) (*D
 	query := `
 		SELECT id, code, name, description, is_required, requires_expiry, requires_front_back,
 			   default_validity_months, renewal_reminder_days, requires_manual_review,
-			   auto_ocr_enabled, country_codes, display_order, is_active, created_at, updated_at
+			   auto_ocr_enabled, ocr_approve_threshold, country_codes, allowed_mime_types, display_order,
+			   is_active, created_at, updated_at
 		FROM document_types
 		WHERE code = $1 AND is_active = true
 	`
@@ -72,8 +77,8 @@ func (r *Repository) GetDocumentTypeByCode(ctx context.Context, code string) (*D
 	err := r.db.QueryRow(ctx, query, code).Scan(
 		&dt.ID, &dt.Code, &dt.Name, &dt.Description, &dt.IsRequired, &dt.RequiresExpiry,
 		&dt.RequiresFrontBack, &dt.DefaultValidityMonths, &dt.RenewalReminderDays,
-		&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.CountryCodes, &dt.DisplayOrder,
-		&dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
+		&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.OCRApproveThreshold, &dt.CountryCodes, &dt.AllowedMimeTypes,
+		&dt.DisplayOrder, &dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
 	)
 
 	if err != nil {
@@ -88,7 +93,8 @@ func (r *Repository) GetRequiredDocumentTypes(ctx context.Context) ([]*DocumentT
 	query := `
 		SELECT id, code, name, description, is_required, requires_expiry, requires_front_back,
 			   default_validity_months, renewal_reminder_days, requires_manual_review,
-			   auto_ocr_enabled, country_codes, display_order, is_active, created_at, updated_at
+			   auto_ocr_enabled, ocr_approve_threshold, country_codes, allowed_mime_types, display_order,
+			   is_active, created_at, updated_at
 		FROM document_types
 		WHERE is_required = true AND is_active = true
 		ORDER BY display_order, name
@@ -106,8 +112,8 @@ func (r *Repository) GetRequiredDocumentTypes(ctx context.Context) ([]*DocumentT
 		if err := rows.Scan(
 			&dt.ID, &dt.Code, &dt.Name, &dt.Description, &dt.IsRequired, &dt.RequiresExpiry,
 			&dt.RequiresFrontBack, &dt.DefaultValidityMonths, &dt.RenewalReminderDays,
-			&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.CountryCodes, &dt.DisplayOrder,
-			&dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
+			&dt.RequiresManualReview, &dt.AutoOCREnabled, &dt.OCRApproveThreshold, &dt.CountryCodes, &dt.AllowedMimeTypes,
+			&dt.DisplayOrder, &dt.IsActive, &dt.CreatedAt, &dt.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan document type: %w", err)
 		}
@@ -130,9 +136,10 @@ func (r *Repository) CreateDocument(ctx context.Context, doc *DriverDocument) er
 			id, driver_id, document_type_id, status, file_url, file_key, file_name,
 			file_size_bytes, file_mime_type, back_file_url, back_file_key,
 			document_number, issue_date, expiry_date, issuing_authority,
-			ocr_data, version, previous_document_id, submitted_at
+			ocr_data, version, previous_document_id, submitted_at, original_file_key,
+			thumbnail_url, thumbnail_key, content_hash, duplicate_flagged, page_count
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
 		RETURNING created_at, updated_at
 	`
 
@@ -140,7 +147,8 @@ func (r *Repository) CreateDocument(ctx context.Context, doc *DriverDocument) er
 		doc.ID, doc.DriverID, doc.DocumentTypeID, doc.Status, doc.FileURL, doc.FileKey,
 		doc.FileName, doc.FileSizeBytes, doc.FileMimeType, doc.BackFileURL, doc.BackFileKey,
 		doc.DocumentNumber, doc.IssueDate, doc.ExpiryDate, doc.IssuingAuthority,
-		ocrDataJSON, doc.Version, doc.PreviousDocumentID, doc.SubmittedAt,
+		ocrDataJSON, doc.Version, doc.PreviousDocumentID, doc.SubmittedAt, doc.OriginalFileKey,
+		doc.ThumbnailURL, doc.ThumbnailKey, doc.ContentHash, doc.DuplicateFlagged, doc.PageCount,
 	).Scan(&doc.CreatedAt, &doc.UpdatedAt)
 
 	if err != nil {
@@ -158,8 +166,11 @@ func (r *Repository) GetDocument(ctx context.Context, documentID uuid.UUID) (*Dr
 			   dd.document_number, dd.issue_date, dd.expiry_date, dd.issuing_authority,
 			   dd.ocr_data, dd.ocr_confidence, dd.ocr_processed_at, dd.reviewed_by, dd.reviewed_at,
 			   dd.review_notes, dd.rejection_reason, dd.version, dd.previous_document_id,
-			   dd.submitted_at, dd.created_at, dd.updated_at,
-			   dt.id, dt.code, dt.name, dt.requires_expiry, dt.requires_front_back
+			   dd.submitted_at, dd.created_at, dd.updated_at, dd.original_file_key,
+			   dd.claimed_by, dd.claimed_at, dd.claim_expires_at, dd.thumbnail_url, dd.thumbnail_key,
+			   dd.content_hash, dd.duplicate_flagged, dd.page_count, dd.face_match_score, dd.face_matched_at,
+			   dt.id, dt.code, dt.name, dt.requires_expiry, dt.requires_front_back,
+			   dt.allowed_mime_types, dt.is_required, dt.requires_manual_review, dt.ocr_approve_threshold
 		FROM driver_documents dd
 		JOIN document_types dt ON dd.document_type_id = dt.id
 		WHERE dd.id = $1
@@ -175,8 +186,11 @@ func (r *Repository) GetDocument(ctx context.Context, documentID uuid.UUID) (*Dr
 		&doc.DocumentNumber, &doc.IssueDate, &doc.ExpiryDate, &doc.IssuingAuthority,
 		&ocrDataJSON, &doc.OCRConfidence, &doc.OCRProcessedAt, &doc.ReviewedBy, &doc.ReviewedAt,
 		&doc.ReviewNotes, &doc.RejectionReason, &doc.Version, &doc.PreviousDocumentID,
-		&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt,
+		&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt, &doc.OriginalFileKey,
+		&doc.ClaimedBy, &doc.ClaimedAt, &doc.ClaimExpiresAt, &doc.ThumbnailURL, &doc.ThumbnailKey,
+		&doc.ContentHash, &doc.DuplicateFlagged, &doc.PageCount, &doc.FaceMatchScore, &doc.FaceMatchedAt,
 		&dt.ID, &dt.Code, &dt.Name, &dt.RequiresExpiry, &dt.RequiresFrontBack,
+		&dt.AllowedMimeTypes, &dt.IsRequired, &dt.RequiresManualReview, &dt.OCRApproveThreshold,
 	)
 
 	if err != nil {
@@ -199,11 +213,12 @@ func (r *Repository) GetDriverDocuments(ctx context.Context, driverID uuid.UUID)
 			   dd.document_number, dd.issue_date, dd.expiry_date, dd.issuing_authority,
 			   dd.ocr_data, dd.ocr_confidence, dd.ocr_processed_at, dd.reviewed_by, dd.reviewed_at,
 			   dd.review_notes, dd.rejection_reason, dd.version, dd.previous_document_id,
-			   dd.submitted_at, dd.created_at, dd.updated_at,
+			   dd.submitted_at, dd.created_at, dd.updated_at, dd.thumbnail_url, dd.thumbnail_key,
+			   dd.page_count, dd.face_match_score, dd.face_matched_at,
 			   dt.id, dt.code, dt.name, dt.requires_expiry, dt.requires_front_back
 		FROM driver_documents dd
 		JOIN document_types dt ON dd.document_type_id = dt.id
-		WHERE dd.driver_id = $1 AND dd.status != 'superseded'
+		WHERE dd.driver_id = $1 AND dd.status NOT IN ('superseded', 'deleted')
 		ORDER BY dt.display_order, dd.submitted_at DESC
 	`
 
@@ -225,7 +240,8 @@ func (r *Repository) GetDriverDocuments(ctx context.Context, driverID uuid.UUID)
 			&doc.DocumentNumber, &doc.IssueDate, &doc.ExpiryDate, &doc.IssuingAuthority,
 			&ocrDataJSON, &doc.OCRConfidence, &doc.OCRProcessedAt, &doc.ReviewedBy, &doc.ReviewedAt,
 			&doc.ReviewNotes, &doc.RejectionReason, &doc.Version, &doc.PreviousDocumentID,
-			&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt,
+			&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt, &doc.ThumbnailURL, &doc.ThumbnailKey,
+			&doc.PageCount, &doc.FaceMatchScore, &doc.FaceMatchedAt,
 			&dt.ID, &dt.Code, &dt.Name, &dt.RequiresExpiry, &dt.RequiresFrontBack,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan document: %w", err)
@@ -249,9 +265,9 @@ func (r *Repository) GetLatestDocumentByType(ctx context.Context, driverID, docu
 			   document_number, issue_date, expiry_date, issuing_authority,
 			   ocr_data, ocr_confidence, ocr_processed_at, reviewed_by, reviewed_at,
 			   review_notes, rejection_reason, version, previous_document_id,
-			   submitted_at, created_at, updated_at
+			   submitted_at, created_at, updated_at, content_hash
 		FROM driver_documents
-		WHERE driver_id = $1 AND document_type_id = $2 AND status != 'superseded'
+		WHERE driver_id = $1 AND document_type_id = $2 AND status NOT IN ('superseded', 'deleted')
 		ORDER BY submitted_at DESC
 		LIMIT 1
 	`
@@ -265,7 +281,7 @@ func (r *Repository) GetLatestDocumentByType(ctx context.Context, driverID, docu
 		&doc.DocumentNumber, &doc.IssueDate, &doc.ExpiryDate, &doc.IssuingAuthority,
 		&ocrDataJSON, &doc.OCRConfidence, &doc.OCRProcessedAt, &doc.ReviewedBy, &doc.ReviewedAt,
 		&doc.ReviewNotes, &doc.RejectionReason, &doc.Version, &doc.PreviousDocumentID,
-		&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt,
+		&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt, &doc.ContentHash,
 	)
 
 	if err != nil {
@@ -279,11 +295,12 @@ func (r *Repository) GetLatestDocumentByType(ctx context.Context, driverID, docu
 	return doc, nil
 }
 
-// UpdateDocumentStatus updates a document's status
+// UpdateDocumentStatus updates a document's status, ending any review claim on it
 func (r *Repository) UpdateDocumentStatus(ctx context.Context, documentID uuid.UUID, status DocumentStatus, reviewedBy *uuid.UUID, reviewNotes, rejectionReason *string) error {
 	query := `
 		UPDATE driver_documents
-		SET status = $1, reviewed_by = $2, reviewed_at = $3, review_notes = $4, rejection_reason = $5, updated_at = NOW()
+		SET status = $1, reviewed_by = $2, reviewed_at = $3, review_notes = $4, rejection_reason = $5,
+			claimed_by = NULL, claimed_at = NULL, claim_expires_at = NULL, updated_at = NOW()
 		WHERE id = $6
 	`
 
@@ -301,6 +318,94 @@ func (r *Repository) UpdateDocumentStatus(ctx context.Context, documentID uuid.U
 	return nil
 }
 
+// ClaimDocumentForReview moves a document to under_review and records the
+// reviewer's claim on it until expiresAt. A pending document, one whose claim
+// has expired or one already claimed by the same reviewer can be claimed; for
+// anything else it returns pgx.ErrNoRows.
+func (r *Repository) ClaimDocumentForReview(ctx context.Context, documentID, reviewerID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		UPDATE driver_documents
+		SET status = 'under_review', claimed_by = $2, claimed_at = NOW(), claim_expires_at = $3, updated_at = NOW()
+		WHERE id = $1
+		  AND (status = 'pending'
+		       OR (status = 'under_review'
+		           AND (claimed_by IS NULL OR claimed_by = $2 OR claim_expires_at IS NULL OR claim_expires_at <= NOW())))
+		RETURNING id
+	`
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, query, documentID, reviewerID, expiresAt).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to claim document: %w", err)
+	}
+	return nil
+}
+
+// ReleaseStaleClaims returns documents that have been under review since before
+// claimedBefore to pending and returns them with the claim they held. Documents
+// put under review before claims were recorded are matched by when they were
+// last updated.
+func (r *Repository) ReleaseStaleClaims(ctx context.Context, claimedBefore time.Time) ([]*DriverDocument, error) {
+	query := `
+		UPDATE driver_documents dd
+		SET status = 'pending', claimed_by = NULL, claimed_at = NULL, claim_expires_at = NULL, updated_at = NOW()
+		FROM driver_documents prev
+		WHERE dd.id = prev.id
+		  AND dd.status = 'under_review'
+		  AND COALESCE(dd.claimed_at, dd.updated_at) < $1
+		RETURNING dd.id, dd.driver_id, prev.claimed_by, prev.claimed_at
+	`
+
+	rows, err := r.db.Query(ctx, query, claimedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stale claims: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*DriverDocument
+	for rows.Next() {
+		doc := &DriverDocument{Status: StatusPending}
+		if err := rows.Scan(&doc.ID, &doc.DriverID, &doc.ClaimedBy, &doc.ClaimedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan released document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// ExpireOverdueDocuments marks approved documents whose expiry date has passed as
+// expired and returns them
+func (r *Repository) ExpireOverdueDocuments(ctx context.Context) ([]*DriverDocument, error) {
+	query := `
+		UPDATE driver_documents
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'approved'
+		  AND expiry_date IS NOT NULL
+		  AND expiry_date < CURRENT_DATE
+		RETURNING id, driver_id, document_type_id, expiry_date
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*DriverDocument
+	for rows.Next() {
+		doc := &DriverDocument{Status: StatusExpired}
+		if err := rows.Scan(&doc.ID, &doc.DriverID, &doc.DocumentTypeID, &doc.ExpiryDate); err != nil {
+			return nil, fmt.Errorf("failed to scan expired document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
 // UpdateDocumentOCRData updates the OCR data for a document
 func (r *Repository) UpdateDocumentOCRData(ctx context.Context, documentID uuid.UUID, ocrData map[string]interface{}, confidence float64) error {
 	ocrDataJSON, _ := json.Marshal(ocrData)
@@ -346,6 +451,122 @@ func (r *Repository) SupersedeDocument(ctx context.Context, documentID uuid.UUID
 	return err
 }
 
+// SoftDeleteDocument marks a document as deleted by deletedBy, keeping the record
+// for its history. It returns pgx.ErrNoRows if the document is already deleted.
+// The document's files are left for MarkDocumentFilesDeleted to record.
+func (r *Repository) SoftDeleteDocument(ctx context.Context, documentID, deletedBy uuid.UUID) error {
+	query := `
+		UPDATE driver_documents
+		SET status = 'deleted', deleted_at = NOW(), deleted_by = $2, updated_at = NOW()
+		WHERE id = $1 AND status != 'deleted'
+	`
+	tag, err := r.db.Exec(ctx, query, documentID, deletedBy)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// MarkDocumentFilesDeleted records that a deleted or superseded document's files
+// were removed from storage
+func (r *Repository) MarkDocumentFilesDeleted(ctx context.Context, documentID uuid.UUID) error {
+	query := `UPDATE driver_documents SET files_deleted_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, documentID)
+	return err
+}
+
+// GetDocumentsPendingFileCleanup gets deleted documents whose files are still in
+// storage, oldest deletion first
+func (r *Repository) GetDocumentsPendingFileCleanup(ctx context.Context, limit int) ([]*DriverDocument, error) {
+	query := `
+		SELECT id, driver_id, file_key, back_file_key, original_file_key, thumbnail_key
+		FROM driver_documents
+		WHERE status = 'deleted' AND files_deleted_at IS NULL
+		ORDER BY deleted_at
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents pending file cleanup: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*DriverDocument
+	for rows.Next() {
+		doc := &DriverDocument{Status: StatusDeleted}
+		if err := rows.Scan(&doc.ID, &doc.DriverID, &doc.FileKey, &doc.BackFileKey, &doc.OriginalFileKey, &doc.ThumbnailKey); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// GetDocumentVersionsWithFiles gets every version of a driver's documents whose
+// files are still in storage, other than deleted ones
+func (r *Repository) GetDocumentVersionsWithFiles(ctx context.Context, driverID uuid.UUID) ([]*DriverDocument, error) {
+	query := `
+		SELECT id, driver_id, document_type_id, status, version, submitted_at,
+			   file_key, back_file_key, original_file_key, thumbnail_key
+		FROM driver_documents
+		WHERE driver_id = $1 AND status != 'deleted' AND files_deleted_at IS NULL
+	`
+
+	rows, err := r.db.Query(ctx, query, driverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document versions: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*DriverDocument
+	for rows.Next() {
+		doc := &DriverDocument{}
+		if err := rows.Scan(
+			&doc.ID, &doc.DriverID, &doc.DocumentTypeID, &doc.Status, &doc.Version, &doc.SubmittedAt,
+			&doc.FileKey, &doc.BackFileKey, &doc.OriginalFileKey, &doc.ThumbnailKey,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// GetDocumentsByContentHash gets up to limit documents of other drivers than
+// excludeDriverID whose file has the given content hash, other than deleted ones
+func (r *Repository) GetDocumentsByContentHash(ctx context.Context, contentHash string, excludeDriverID uuid.UUID, limit int) ([]*DriverDocument, error) {
+	query := `
+		SELECT id, driver_id, document_type_id, status, submitted_at
+		FROM driver_documents
+		WHERE content_hash = $1 AND driver_id != $2 AND status != 'deleted'
+		ORDER BY submitted_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, contentHash, excludeDriverID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents by content hash: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []*DriverDocument
+	for rows.Next() {
+		doc := &DriverDocument{}
+		if err := rows.Scan(&doc.ID, &doc.DriverID, &doc.DocumentTypeID, &doc.Status, &doc.SubmittedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
 // UpdateDocumentBackFile updates the back file for a document
 func (r *Repository) UpdateDocumentBackFile(ctx context.Context, documentID uuid.UUID, backFileURL, backFileKey string) error {
 	query := `
@@ -357,10 +578,53 @@ func (r *Repository) UpdateDocumentBackFile(ctx context.Context, documentID uuid
 	return err
 }
 
+// UpdateDocumentThumbnail records the thumbnail generated for a document
+func (r *Repository) UpdateDocumentThumbnail(ctx context.Context, documentID uuid.UUID, thumbnailURL, thumbnailKey string) error {
+	query := `
+		UPDATE driver_documents
+		SET thumbnail_url = $1, thumbnail_key = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(ctx, query, thumbnailURL, thumbnailKey, documentID)
+	return err
+}
+
+// UpdateDocumentFaceMatch records how closely a selfie matched the driver's ID photo
+func (r *Repository) UpdateDocumentFaceMatch(ctx context.Context, documentID uuid.UUID, score float64, matchedAt time.Time) error {
+	query := `
+		UPDATE driver_documents
+		SET face_match_score = $1, face_matched_at = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(ctx, query, score, matchedAt, documentID)
+	return err
+}
+
 // ========================================
 // VERIFICATION STATUS
 // ========================================
 
+// GetDriverCountryCode gets the ISO country code of the driver's account, or ""
+// if the account has no country set
+func (r *Repository) GetDriverCountryCode(ctx context.Context, driverID uuid.UUID) (string, error) {
+	query := `
+		SELECT COALESCE(c.code, '')
+		FROM drivers d
+		JOIN users u ON u.id = d.user_id
+		LEFT JOIN countries c ON c.id = u.country_id
+		WHERE d.id = $1
+	`
+
+	var code string
+	if err := r.db.QueryRow(ctx, query, driverID).Scan(&code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get driver country: %w", err)
+	}
+	return code, nil
+}
+
 // GetDriverVerificationStatus gets the verification status for a driver
 func (r *Repository) GetDriverVerificationStatus(ctx context.Context, driverID uuid.UUID) (*DriverVerificationStatus, error) {
 	query := `
@@ -390,41 +654,140 @@ func (r *Repository) GetDriverVerificationStatus(ctx context.Context, driverID u
 	return status, nil
 }
 
+// UpsertDriverVerificationStatus stores a driver's computed verification status and
+// document counts. A suspension is never overwritten, and the submitted/approved
+// milestones keep the time they were first reached.
+func (r *Repository) UpsertDriverVerificationStatus(ctx context.Context, status *DriverVerificationStatus) error {
+	query := `
+		INSERT INTO driver_verification_status (
+			driver_id, verification_status, required_documents_count,
+			submitted_documents_count, approved_documents_count,
+			documents_submitted_at, documents_approved_at, next_document_expiry
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (driver_id) DO UPDATE SET
+			verification_status = CASE
+				WHEN driver_verification_status.verification_status = 'suspended'
+				THEN driver_verification_status.verification_status
+				ELSE EXCLUDED.verification_status
+			END,
+			required_documents_count = EXCLUDED.required_documents_count,
+			submitted_documents_count = EXCLUDED.submitted_documents_count,
+			approved_documents_count = EXCLUDED.approved_documents_count,
+			documents_submitted_at = COALESCE(driver_verification_status.documents_submitted_at, EXCLUDED.documents_submitted_at),
+			documents_approved_at = COALESCE(driver_verification_status.documents_approved_at, EXCLUDED.documents_approved_at),
+			next_document_expiry = EXCLUDED.next_document_expiry,
+			updated_at = NOW()
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		status.DriverID, status.VerificationStatus, status.RequiredDocumentsCount,
+		status.SubmittedDocumentsCount, status.ApprovedDocumentsCount,
+		status.DocumentsSubmittedAt, status.DocumentsApprovedAt, status.NextDocumentExpiry,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert driver verification status: %w", err)
+	}
+	return nil
+}
+
 // ========================================
 // PENDING REVIEWS (ADMIN)
 // ========================================
 
 // GetPendingReviews gets documents pending review
-func (r *Repository) GetPendingReviews(ctx context.Context, limit, offset int) ([]*PendingReviewDocument, int, error) {
+func (r *Repository) GetPendingReviews(ctx context.Context, limit, offset int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, int, error) {
+	// With excludeClaimedFor set, documents under a live claim by any other
+	// reviewer are left out
 	countQuery := `
-		SELECT COUNT(*) FROM driver_documents WHERE status IN ('pending', 'under_review')
+		SELECT COUNT(*) FROM driver_documents dd
+		WHERE dd.status IN ('pending', 'under_review')
+		  AND ($1::uuid IS NULL OR dd.claimed_by IS NULL OR dd.claimed_by = $1
+		       OR dd.claim_expires_at IS NULL OR dd.claim_expires_at <= NOW())
 	`
 	var total int
-	r.db.QueryRow(ctx, countQuery).Scan(&total)
+	r.db.QueryRow(ctx, countQuery, excludeClaimedFor).Scan(&total)
 
 	query := `
 		SELECT dd.id, dd.driver_id, dd.document_type_id, dd.status, dd.file_url, dd.file_key,
 			   dd.file_name, dd.document_number, dd.expiry_date, dd.ocr_confidence,
-			   dd.submitted_at, dd.created_at, dd.updated_at,
+			   dd.submitted_at, dd.created_at, dd.updated_at, dd.thumbnail_url, dd.thumbnail_key,
+			   dd.page_count,
 			   u.first_name || ' ' || u.last_name AS driver_name,
 			   u.phone_number AS driver_phone, u.email AS driver_email,
 			   dt.name AS document_type_name,
-			   EXTRACT(EPOCH FROM (NOW() - dd.submitted_at)) / 3600 AS hours_pending
+			   EXTRACT(EPOCH FROM (NOW() - dd.submitted_at)) / 3600 AS hours_pending,
+			   CASE WHEN dd.claim_expires_at > NOW() THEN dd.claimed_by END,
+			   CASE WHEN dd.claim_expires_at > NOW() THEN dd.claim_expires_at END
 		FROM driver_documents dd
 		JOIN drivers d ON dd.driver_id = d.id
 		JOIN users u ON d.user_id = u.id
 		JOIN document_types dt ON dd.document_type_id = dt.id
 		WHERE dd.status IN ('pending', 'under_review')
+		  AND ($3::uuid IS NULL OR dd.claimed_by IS NULL OR dd.claimed_by = $3
+		       OR dd.claim_expires_at IS NULL OR dd.claim_expires_at <= NOW())
 		ORDER BY dd.submitted_at ASC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
+	rows, err := r.db.Query(ctx, query, limit, offset, excludeClaimedFor)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get pending reviews: %w", err)
 	}
 	defer rows.Close()
 
+	reviews, err := scanPendingReviews(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// GetPendingReviewsAfter gets up to limit documents pending review, longest
+// waiting first, that come after the cursor; a nil cursor starts from the
+// longest waiting. excludeClaimedFor works as in GetPendingReviews.
+func (r *Repository) GetPendingReviewsAfter(ctx context.Context, after *pagination.Cursor, limit int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, error) {
+	var afterAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterAt, afterID = &after.At, &after.ID
+	}
+
+	query := `
+		SELECT dd.id, dd.driver_id, dd.document_type_id, dd.status, dd.file_url, dd.file_key,
+			   dd.file_name, dd.document_number, dd.expiry_date, dd.ocr_confidence,
+			   dd.submitted_at, dd.created_at, dd.updated_at, dd.thumbnail_url, dd.thumbnail_key,
+			   dd.page_count,
+			   u.first_name || ' ' || u.last_name AS driver_name,
+			   u.phone_number AS driver_phone, u.email AS driver_email,
+			   dt.name AS document_type_name,
+			   EXTRACT(EPOCH FROM (NOW() - dd.submitted_at)) / 3600 AS hours_pending,
+			   CASE WHEN dd.claim_expires_at > NOW() THEN dd.claimed_by END,
+			   CASE WHEN dd.claim_expires_at > NOW() THEN dd.claim_expires_at END
+		FROM driver_documents dd
+		JOIN drivers d ON dd.driver_id = d.id
+		JOIN users u ON d.user_id = u.id
+		JOIN document_types dt ON dd.document_type_id = dt.id
+		WHERE dd.status IN ('pending', 'under_review')
+		  AND ($2::uuid IS NULL OR dd.claimed_by IS NULL OR dd.claimed_by = $2
+		       OR dd.claim_expires_at IS NULL OR dd.claim_expires_at <= NOW())
+		  AND ($3::timestamptz IS NULL OR (dd.submitted_at, dd.id) > ($3, $4::uuid))
+		ORDER BY dd.submitted_at ASC, dd.id ASC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, excludeClaimedFor, afterAt, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending reviews: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPendingReviews(rows)
+}
+
+// scanPendingReviews reads documents pending review selected in the column
+// order of GetPendingReviews
+func scanPendingReviews(rows pgx.Rows) ([]*PendingReviewDocument, error) {
 	var reviews []*PendingReviewDocument
 	for rows.Next() {
 		doc := &DriverDocument{}
@@ -433,18 +796,35 @@ func (r *Repository) GetPendingReviews(ctx context.Context, limit, offset int) (
 		if err := rows.Scan(
 			&doc.ID, &doc.DriverID, &doc.DocumentTypeID, &doc.Status, &doc.FileURL, &doc.FileKey,
 			&doc.FileName, &doc.DocumentNumber, &doc.ExpiryDate, &doc.OCRConfidence,
-			&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt,
+			&doc.SubmittedAt, &doc.CreatedAt, &doc.UpdatedAt, &doc.ThumbnailURL, &doc.ThumbnailKey,
+			&doc.PageCount,
 			&review.DriverName, &review.DriverPhone, &review.DriverEmail,
 			&review.DocumentType, &review.HoursPending,
+			&review.ClaimedBy, &review.ClaimExpiresAt,
 		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan pending review: %w", err)
+			return nil, fmt.Errorf("failed to scan pending review: %w", err)
 		}
 
 		review.OCRConfidence = doc.OCRConfidence
+		review.ThumbnailURL = doc.ThumbnailURL
+		review.ThumbnailKey = doc.ThumbnailKey
 		reviews = append(reviews, review)
 	}
+	return reviews, rows.Err()
+}
 
-	return reviews, total, nil
+// MarkExpiryWarningSent records when a driver was last sent a document expiry reminder
+func (r *Repository) MarkExpiryWarningSent(ctx context.Context, driverID uuid.UUID, sentAt time.Time) error {
+	query := `
+		UPDATE driver_verification_status
+		SET expiry_warning_sent_at = $1, updated_at = NOW()
+		WHERE driver_id = $2
+	`
+	_, err := r.db.Exec(ctx, query, sentAt, driverID)
+	if err != nil {
+		return fmt.Errorf("failed to mark expiry warning sent: %w", err)
+	}
+	return nil
 }
 
 // GetExpiringDocuments gets documents expiring soon
@@ -452,7 +832,7 @@ func (r *Repository) GetExpiringDocuments(ctx context.Context, daysAhead int) ([
 	query := `
 		SELECT dd.id, dd.driver_id, dd.document_type_id, dd.status, dd.file_url,
 			   dd.document_number, dd.expiry_date, dd.created_at, dd.updated_at,
-			   u.first_name || ' ' || u.last_name AS driver_name,
+			   d.user_id, u.first_name || ' ' || u.last_name AS driver_name,
 			   u.email AS driver_email, u.phone_number AS driver_phone,
 			   dt.name AS document_type_name,
 			   (dd.expiry_date - CURRENT_DATE) AS days_until_expiry,
@@ -486,7 +866,7 @@ func (r *Repository) GetExpiringDocuments(ctx context.Context, daysAhead int) ([
 		if err := rows.Scan(
 			&doc.ID, &doc.DriverID, &doc.DocumentTypeID, &doc.Status, &doc.FileURL,
 			&doc.DocumentNumber, &doc.ExpiryDate, &doc.CreatedAt, &doc.UpdatedAt,
-			&exp.DriverName, &exp.DriverEmail, &exp.DriverPhone,
+			&exp.DriverUserID, &exp.DriverName, &exp.DriverEmail, &exp.DriverPhone,
 			&exp.DocumentType, &exp.DaysUntilExpiry, &exp.Urgency,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan expiring document: %w", err)
@@ -498,6 +878,174 @@ func (r *Repository) GetExpiringDocuments(ctx context.Context, daysAhead int) ([
 	return expiring, nil
 }
 
+// ========================================
+// ANALYTICS (ADMIN)
+// ========================================
+
+// GetVerificationFunnelDrivers gets funnel progress for drivers whose first upload was on or after since
+func (r *Repository) GetVerificationFunnelDrivers(ctx context.Context, since time.Time) ([]*DriverFunnelProgress, error) {
+	query := `
+		WITH cohort AS (
+			SELECT driver_id, MIN(submitted_at) AS first_submitted_at
+			FROM driver_documents
+			GROUP BY driver_id
+			HAVING MIN(submitted_at) >= $1
+		)
+		SELECT c.driver_id, c.first_submitted_at,
+			   COUNT(DISTINCT dd.document_type_id) FILTER (
+				   WHERE dt.is_required = true AND dt.is_active = true
+				     AND dd.status NOT IN ('superseded', 'rejected', 'expired', 'deleted')
+			   ) AS required_submitted,
+			   COALESCE(dvs.documents_submitted_at IS NOT NULL
+				   OR dvs.verification_status IN ('pending_review', 'approved'), false) AS submitted_for_review,
+			   COALESCE(dvs.verification_status = 'approved', false) AS approved
+		FROM cohort c
+		JOIN driver_documents dd ON dd.driver_id = c.driver_id
+		JOIN document_types dt ON dd.document_type_id = dt.id
+		LEFT JOIN driver_verification_status dvs ON dvs.driver_id = c.driver_id
+		GROUP BY c.driver_id, c.first_submitted_at, dvs.documents_submitted_at, dvs.verification_status
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verification funnel: %w", err)
+	}
+	defer rows.Close()
+
+	var progress []*DriverFunnelProgress
+	for rows.Next() {
+		p := &DriverFunnelProgress{}
+		if err := rows.Scan(
+			&p.DriverID, &p.FirstSubmittedAt, &p.RequiredDocumentsSubmitted,
+			&p.SubmittedForReview, &p.Approved,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan funnel progress: %w", err)
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, nil
+}
+
+// GetReviewTimes gets how long documents decided on by a reviewer since then
+// waited for the decision, per document type plus a row with an empty
+// DocumentType for all types together
+func (r *Repository) GetReviewTimes(ctx context.Context, since time.Time) ([]*DocumentTypeReviewTimes, error) {
+	query := `
+		WITH decisions AS (
+			SELECT dt.code, dt.name,
+				   EXTRACT(EPOCH FROM (h.created_at - dd.submitted_at)) / 3600 AS hours
+			FROM document_verification_history h
+			JOIN driver_documents dd ON h.document_id = dd.id
+			JOIN document_types dt ON dd.document_type_id = dt.id
+			WHERE h.created_at >= $1
+			  AND h.action IN ('approve', 'reject', 'request_resubmit')
+			  AND NOT h.is_system_action
+		)
+		SELECT code, name, COUNT(*), AVG(hours),
+			   percentile_cont(ARRAY[0.5, 0.9, 0.95]) WITHIN GROUP (ORDER BY hours)
+		FROM decisions
+		GROUP BY GROUPING SETS ((code, name), ())
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review times: %w", err)
+	}
+	defer rows.Close()
+
+	var times []*DocumentTypeReviewTimes
+	for rows.Next() {
+		t := &DocumentTypeReviewTimes{}
+		var code, name *string
+		var avg *float64
+		var percentiles []float64
+		if err := rows.Scan(&code, &name, &t.Reviewed, &avg, &percentiles); err != nil {
+			return nil, fmt.Errorf("failed to scan review times: %w", err)
+		}
+		if code != nil {
+			t.DocumentType, t.DocumentTypeName = *code, *name
+		}
+		if avg != nil {
+			t.AvgHours = *avg
+		}
+		if len(percentiles) == 3 {
+			t.P50Hours, t.P90Hours, t.P95Hours = percentiles[0], percentiles[1], percentiles[2]
+		}
+		times = append(times, t)
+	}
+
+	return times, rows.Err()
+}
+
+// GetPendingReviewBacklog gets the review queue per document type, counting
+// documents submitted before slaCutoff as over the SLA
+func (r *Repository) GetPendingReviewBacklog(ctx context.Context, slaCutoff time.Time) ([]*PendingReviewBacklog, error) {
+	query := `
+		SELECT dt.code, dt.name, COUNT(*),
+			   COUNT(*) FILTER (WHERE dd.submitted_at < $1),
+			   EXTRACT(EPOCH FROM (NOW() - MIN(dd.submitted_at))) / 3600
+		FROM driver_documents dd
+		JOIN document_types dt ON dd.document_type_id = dt.id
+		WHERE dd.status IN ('pending', 'under_review')
+		GROUP BY dt.code, dt.name
+		ORDER BY dt.code
+	`
+
+	rows, err := r.db.Query(ctx, query, slaCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending review backlog: %w", err)
+	}
+	defer rows.Close()
+
+	var backlog []*PendingReviewBacklog
+	for rows.Next() {
+		b := &PendingReviewBacklog{}
+		if err := rows.Scan(&b.DocumentType, &b.DocumentTypeName, &b.Pending, &b.OverSLA, &b.OldestPendingHours); err != nil {
+			return nil, fmt.Errorf("failed to scan pending review backlog: %w", err)
+		}
+		backlog = append(backlog, b)
+	}
+
+	return backlog, rows.Err()
+}
+
+// GetReviewerThroughput gets how many documents each reviewer approved or
+// rejected since then, busiest first
+func (r *Repository) GetReviewerThroughput(ctx context.Context, since time.Time) ([]*ReviewerThroughput, error) {
+	query := `
+		SELECT h.performed_by, COALESCE(u.first_name || ' ' || u.last_name, ''),
+			   COUNT(*) FILTER (WHERE h.action = 'approve'),
+			   COUNT(*) FILTER (WHERE h.action IN ('reject', 'request_resubmit')),
+			   COUNT(*)
+		FROM document_verification_history h
+		LEFT JOIN users u ON h.performed_by = u.id
+		WHERE h.created_at >= $1
+		  AND h.action IN ('approve', 'reject', 'request_resubmit')
+		  AND NOT h.is_system_action
+		  AND h.performed_by IS NOT NULL
+		GROUP BY h.performed_by, u.first_name, u.last_name
+		ORDER BY COUNT(*) DESC, h.performed_by
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviewer throughput: %w", err)
+	}
+	defer rows.Close()
+
+	var throughput []*ReviewerThroughput
+	for rows.Next() {
+		t := &ReviewerThroughput{}
+		if err := rows.Scan(&t.ReviewerID, &t.ReviewerName, &t.Approved, &t.Rejected, &t.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan reviewer throughput: %w", err)
+		}
+		throughput = append(throughput, t)
+	}
+
+	return throughput, rows.Err()
+}
+
 // ========================================
 // HISTORY
 // ========================================
@@ -558,6 +1106,38 @@ func (r *Repository) GetDocumentHistory(ctx context.Context, documentID uuid.UUI
 	return history, nil
 }
 
+// GetUserDisplayNames gets the full names of the given users. Users that don't
+// exist are left out of the map.
+func (r *Repository) GetUserDisplayNames(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	query := `
+		SELECT id, TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, ''))
+		FROM users
+		WHERE id = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user names: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan user name: %w", err)
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
 // ========================================
 // OCR QUEUE
 // ========================================
@@ -582,7 +1162,7 @@ func (r *Repository) GetPendingOCRJobs(ctx context.Context, limit int) ([]*OCRPr
 			   processing_time_ms, raw_response, extracted_data, confidence_score,
 			   error_message, retry_count, max_retries, next_retry_at, created_at, updated_at
 		FROM ocr_processing_queue
-		WHERE status = 'pending'
+		WHERE (status = 'pending' AND (next_retry_at IS NULL OR next_retry_at <= NOW()))
 		   OR (status = 'failed' AND retry_count < max_retries AND (next_retry_at IS NULL OR next_retry_at <= NOW()))
 		ORDER BY priority DESC, created_at ASC
 		LIMIT $1
@@ -621,6 +1201,38 @@ func (r *Repository) GetPendingOCRJobs(ctx context.Context, limit int) ([]*OCRPr
 	return jobs, nil
 }
 
+// GetOCRJob gets an OCR job by ID
+func (r *Repository) GetOCRJob(ctx context.Context, jobID uuid.UUID) (*OCRProcessingQueue, error) {
+	query := `
+		SELECT id, document_id, status, priority, provider, started_at, completed_at,
+			   processing_time_ms, raw_response, extracted_data, confidence_score,
+			   error_message, retry_count, max_retries, next_retry_at, created_at, updated_at
+		FROM ocr_processing_queue
+		WHERE id = $1
+	`
+
+	job := &OCRProcessingQueue{}
+	var rawResponseJSON, extractedDataJSON []byte
+	err := r.db.QueryRow(ctx, query, jobID).Scan(
+		&job.ID, &job.DocumentID, &job.Status, &job.Priority, &job.Provider,
+		&job.StartedAt, &job.CompletedAt, &job.ProcessingTimeMs,
+		&rawResponseJSON, &extractedDataJSON, &job.ConfidenceScore,
+		&job.ErrorMessage, &job.RetryCount, &job.MaxRetries, &job.NextRetryAt,
+		&job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rawResponseJSON) > 0 {
+		json.Unmarshal(rawResponseJSON, &job.RawResponse)
+	}
+	if len(extractedDataJSON) > 0 {
+		json.Unmarshal(extractedDataJSON, &job.ExtractedData)
+	}
+	return job, nil
+}
+
 // UpdateOCRJobStatus updates an OCR job with full status info
 func (r *Repository) UpdateOCRJobStatus(ctx context.Context, jobID uuid.UUID, status string, result, errorMsg *string) error {
 	query := `
@@ -637,18 +1249,27 @@ func (r *Repository) UpdateOCRJobStatus(ctx context.Context, jobID uuid.UUID, st
 	return err
 }
 
-// CompleteOCRJob marks an OCR job as completed
+// CompleteOCRJob marks a pending or processing OCR job as completed. It returns
+// pgx.ErrNoRows when the job doesn't exist or was already completed or failed.
 func (r *Repository) CompleteOCRJob(ctx context.Context, jobID uuid.UUID, extractedData map[string]interface{}, confidence float64, processingTimeMs int) error {
 	extractedDataJSON, _ := json.Marshal(extractedData)
 
 	query := `
 		UPDATE ocr_processing_queue
 		SET status = 'completed', completed_at = NOW(), extracted_data = $1,
-		    confidence_score = $2, processing_time_ms = $3, updated_at = NOW()
-		WHERE id = $4
+		    confidence_score = $2, processing_time_ms = $3, error_message = NULL, updated_at = NOW()
+		WHERE id = $4 AND status IN ('pending', 'processing')
+		RETURNING id
 	`
-	_, err := r.db.Exec(ctx, query, extractedDataJSON, confidence, processingTimeMs, jobID)
-	return err
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, query, extractedDataJSON, confidence, processingTimeMs, jobID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to complete OCR job: %w", err)
+	}
+	return nil
 }
 
 // FailOCRJob marks an OCR job as failed
@@ -663,6 +1284,18 @@ func (r *Repository) FailOCRJob(ctx context.Context, jobID uuid.UUID, errorMessa
 	return err
 }
 
+// ExhaustOCRJob marks an OCR job as permanently failed so it is never picked up again
+func (r *Repository) ExhaustOCRJob(ctx context.Context, jobID uuid.UUID, errorMessage string) error {
+	query := `
+		UPDATE ocr_processing_queue
+		SET status = 'failed', error_message = $1, retry_count = GREATEST(retry_count + 1, max_retries),
+		    next_retry_at = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(ctx, query, errorMessage, jobID)
+	return err
+}
+
 // UpdateOCRJobRetry updates an OCR job for retry
 func (r *Repository) UpdateOCRJobRetry(ctx context.Context, jobID uuid.UUID, retryCount int, nextRetry time.Time) error {
 	query := `
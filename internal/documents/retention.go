@@ -0,0 +1,112 @@
+package documents
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// PruneSupersededFiles removes the stored files of a driver's superseded
+// documents beyond the keepN most recent versions of each document type, and
+// returns how many documents had their files removed. The records and their
+// history are kept. Files still used by a kept version are never removed, and
+// files that could not be removed are logged and left for a later run.
+func (s *Service) PruneSupersededFiles(ctx context.Context, driverID uuid.UUID, keepN int) (int, error) {
+	if keepN < 1 {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "at least one version must be kept", nil)
+	}
+
+	docs, err := s.repo.GetDocumentVersionsWithFiles(ctx, driverID)
+	if err != nil {
+		return 0, common.NewInternalError("failed to get document versions", err)
+	}
+
+	byType := make(map[uuid.UUID][]*DriverDocument)
+	for _, doc := range docs {
+		byType[doc.DocumentTypeID] = append(byType[doc.DocumentTypeID], doc)
+	}
+
+	pruned := 0
+	for _, versions := range byType {
+		pruned += s.pruneVersions(ctx, versions, keepN)
+	}
+
+	if pruned > 0 {
+		logger.InfoContext(ctx, "Pruned superseded document files",
+			zap.String("driver_id", driverID.String()),
+			zap.Int("documents", pruned),
+		)
+	}
+
+	return pruned, nil
+}
+
+// pruneVersions removes the files of the superseded versions of one document
+// type that are older than the keepN most recent, and returns how many were removed
+func (s *Service) pruneVersions(ctx context.Context, versions []*DriverDocument, keepN int) int {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Version != versions[j].Version {
+			return versions[i].Version > versions[j].Version
+		}
+		return versions[i].SubmittedAt.After(versions[j].SubmittedAt)
+	})
+
+	// Anything other than a superseded version is current, so it is kept however
+	// old it is, along with any file it shares with an older version
+	var stale []*DriverDocument
+	inUse := make(map[string]bool)
+	for i, doc := range versions {
+		if i >= keepN && doc.Status == StatusSuperseded {
+			stale = append(stale, doc)
+			continue
+		}
+		for _, key := range documentFileKeys(doc) {
+			inUse[key] = true
+		}
+	}
+
+	pruned := 0
+	for _, doc := range stale {
+		var keys []string
+		for _, key := range documentFileKeys(doc) {
+			if !inUse[key] {
+				keys = append(keys, key)
+			}
+		}
+		if !s.removeDocumentFiles(ctx, doc, keys) {
+			continue
+		}
+		s.logHistory(ctx, doc.ID, "files_pruned", "", "", nil, true, "Files of superseded version removed")
+		pruned++
+	}
+	return pruned
+}
+
+// pruneAfterSupersede applies the configured retention to a document type
+// after one of the driver's documents of it was superseded
+func (s *Service) pruneAfterSupersede(ctx context.Context, driverID, documentTypeID uuid.UUID) {
+	keepN := s.config.SupersededVersionsToKeep
+	if keepN <= 0 {
+		return
+	}
+
+	docs, err := s.repo.GetDocumentVersionsWithFiles(ctx, driverID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to get document versions to prune",
+			zap.String("driver_id", driverID.String()), zap.Error(err))
+		return
+	}
+
+	var versions []*DriverDocument
+	for _, doc := range docs {
+		if doc.DocumentTypeID == documentTypeID {
+			versions = append(versions, doc)
+		}
+	}
+	s.pruneVersions(ctx, versions, keepN)
+}
@@ -0,0 +1,117 @@
+package documents
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+const (
+	defaultReviewMetricsWindow = 7 * 24 * time.Hour
+	maxReviewMetricsWindow     = 90 * 24 * time.Hour
+)
+
+// Updated whenever review metrics are read, so it is as fresh as the last
+// metrics request
+var reviewsOverSLA = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "documents_reviews_over_sla",
+	Help: "Documents waiting for review for longer than the review SLA, by document type",
+}, []string{"document_type"})
+
+// reviewSLA returns how long a document may wait for review
+func (s *Service) reviewSLA() time.Duration {
+	if s.config.ReviewSLA <= 0 {
+		return defaultReviewSLA
+	}
+	return s.config.ReviewSLA
+}
+
+// GetReviewMetrics reports how long reviewers took to decide on documents and
+// how many each decided on over the last window (7 days when zero), and the
+// review queue as it stands with how much of it is over the review SLA, all
+// broken down by document type. Only reviewers' decisions count; auto-approvals
+// don't.
+func (s *Service) GetReviewMetrics(ctx context.Context, window time.Duration) (*ReviewMetricsResponse, error) {
+	if window == 0 {
+		window = defaultReviewMetricsWindow
+	}
+	if window < 0 || window > maxReviewMetricsWindow {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "window must be between 1 and 90 days", nil)
+	}
+
+	now := time.Now()
+	since := now.Add(-window)
+	sla := s.reviewSLA()
+
+	times, err := s.repo.GetReviewTimes(ctx, since)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get review times", err)
+	}
+	backlog, err := s.repo.GetPendingReviewBacklog(ctx, now.Add(-sla))
+	if err != nil {
+		return nil, common.NewInternalError("failed to get review backlog", err)
+	}
+	reviewers, err := s.repo.GetReviewerThroughput(ctx, since)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get reviewer throughput", err)
+	}
+	if reviewers == nil {
+		reviewers = []*ReviewerThroughput{}
+	}
+
+	response := &ReviewMetricsResponse{
+		Since:          since,
+		SLAHours:       sla.Hours(),
+		Reviewers:      reviewers,
+		ByDocumentType: []*DocumentTypeReviewMetrics{},
+	}
+
+	byType := make(map[string]*DocumentTypeReviewMetrics)
+	typeMetrics := func(code, name string) *DocumentTypeReviewMetrics {
+		m, ok := byType[code]
+		if !ok {
+			m = &DocumentTypeReviewMetrics{DocumentType: code, DocumentTypeName: name}
+			byType[code] = m
+			response.ByDocumentType = append(response.ByDocumentType, m)
+		}
+		return m
+	}
+
+	for _, t := range times {
+		if t.DocumentType == "" {
+			response.ReviewTimes = t.ReviewTimes
+			continue
+		}
+		typeMetrics(t.DocumentType, t.DocumentTypeName).ReviewTimes = t.ReviewTimes
+	}
+
+	reviewsOverSLA.Reset()
+	for _, b := range backlog {
+		m := typeMetrics(b.DocumentType, b.DocumentTypeName)
+		m.Pending = b.Pending
+		m.OverSLA = b.OverSLA
+		m.OldestPendingHours = b.OldestPendingHours
+		response.Pending += b.Pending
+		response.OverSLA += b.OverSLA
+		reviewsOverSLA.WithLabelValues(b.DocumentType).Set(float64(b.OverSLA))
+	}
+
+	// Types clogging the queue first
+	sort.Slice(response.ByDocumentType, func(i, j int) bool {
+		a, b := response.ByDocumentType[i], response.ByDocumentType[j]
+		if a.OverSLA != b.OverSLA {
+			return a.OverSLA > b.OverSLA
+		}
+		if a.Pending != b.Pending {
+			return a.Pending > b.Pending
+		}
+		return a.DocumentType < b.DocumentType
+	})
+
+	return response, nil
+}
@@ -1,23 +1,60 @@
 package documents
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/httputil"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 	"github.com/richxcame/ride-hailing/pkg/storage"
 	"go.uber.org/zap"
 )
 
+const (
+	// defaultExpirySweepInterval is used when StartExpirySweeper is given a non-positive interval
+	defaultExpirySweepInterval = 1 * time.Hour
+	// defaultDownloadURLExpiry is used when DownloadURLExpiry is not set
+	defaultDownloadURLExpiry = 5 * time.Minute
+	// defaultMaxBulkApprovals is used when MaxBulkApprovals is not set
+	defaultMaxBulkApprovals = 25
+	// defaultExpiryReminderCooldown is used when ExpiryReminderCooldown is not set
+	defaultExpiryReminderCooldown = 24 * time.Hour
+	// defaultReviewClaimTTL is used when ReviewClaimTTL is not set
+	defaultReviewClaimTTL = 30 * time.Minute
+	// defaultReviewSLA is used when ReviewSLA is not set
+	defaultReviewSLA = 24 * time.Hour
+	// expiryReminderRepeatWindow is how long before the same document is reminded about again
+	expiryReminderRepeatWindow = 24 * time.Hour
+)
+
 // Service handles document verification business logic
 type Service struct {
-	repo    RepositoryInterface
-	storage storage.Storage
-	config  ServiceConfig
+	repo     RepositoryInterface
+	storage  storage.Storage
+	config   ServiceConfig
+	notifier Notifier
+
+	statusHook    StatusChangeHook
+	statusChanges chan *VerificationStatusChange
+
+	imagePreprocessor ImagePreprocessor
+	pdfRasterizer     PDFRasterizer
+	faceMatcher       FaceMatcher
+
+	audit audit.Logger
 }
 
 // ServiceConfig holds service configuration
@@ -25,7 +62,35 @@ type ServiceConfig struct {
 	MaxFileSizeMB    int
 	AllowedMimeTypes []string
 	OCREnabled       bool
-	OCRProvider      string
+	OCRProvider      string // Default OCRWorker provider, e.g. "tesseract"; "noop" when unset
+
+	OCRApproveThreshold float64 // OCR confidence to auto-approve above, for types without their own; 0 disables
+
+	MaxBulkApprovals  int           // Most documents a reviewer may approve in one bulk review
+	DownloadURLExpiry time.Duration // How long presigned document download links stay valid
+	ReviewClaimTTL    time.Duration // How long a reviewer's claim on a document lasts before others may take it over
+	ReviewSLA         time.Duration // How long a document may wait for review before it counts as over the SLA
+
+	ExpiryReminderDays     int           // How many days ahead to remind drivers of expiring documents
+	ExpiryReminderCooldown time.Duration // Minimum time between reminders to the same driver
+
+	StatusHookQueueSize int // Most verification status changes buffered for a slow StatusChangeHook
+
+	ImageMaxDimension    int   // Longest side of a stored image, in pixels; larger uploads are scaled down
+	ImageMaxPixels       int64 // Largest image, in pixels, that will be decoded; guards against decode bombs
+	ImageJPEGQuality     int   // JPEG quality processed images are stored at
+	KeepOriginalImages   bool  // Also store image uploads as received when they were processed
+	OCRUseOriginalImages bool  // Run OCR on the kept original rather than the processed image
+
+	ThumbnailMaxDimension int // Longest side of a document thumbnail, in pixels
+
+	MaxPDFPages int // Most pages a PDF upload may have
+
+	SupersededVersionsToKeep int // Most recent versions of a document whose files are kept when it is replaced; 0 keeps all
+
+	FaceMatchThreshold float64 // Face match score a selfie must reach against the ID photo before it is flagged for review
+	SelfieDocumentType string  // Code of the document type holding the driver's selfie
+	IDDocumentType     string  // Code of the document type whose photo the selfie is matched against
 }
 
 // NewService creates a new documents service
@@ -38,14 +103,43 @@ func NewService(repo RepositoryInterface, storage storage.Storage, config Servic
 			"image/jpeg", "image/png", "image/webp", "application/pdf",
 		}
 	}
+	if config.MaxBulkApprovals == 0 {
+		config.MaxBulkApprovals = defaultMaxBulkApprovals
+	}
+	if config.StatusHookQueueSize <= 0 {
+		config.StatusHookQueueSize = defaultStatusHookQueueSize
+	}
+	if config.ImageMaxDimension <= 0 {
+		config.ImageMaxDimension = defaultImageMaxDimension
+	}
+	if config.ImageMaxPixels <= 0 {
+		config.ImageMaxPixels = defaultImageMaxPixels
+	}
+	if config.ImageJPEGQuality <= 0 || config.ImageJPEGQuality > 100 {
+		config.ImageJPEGQuality = defaultImageJPEGQuality
+	}
 
 	return &Service{
-		repo:    repo,
-		storage: storage,
-		config:  config,
+		repo:              repo,
+		storage:           storage,
+		config:            config,
+		imagePreprocessor: newImagePreprocessor(config),
+		faceMatcher:       NoopFaceMatcher{},
+		audit:             audit.Nop(),
 	}
 }
 
+// SetAuditLogger sets where reviewer and admin actions are audited
+func (s *Service) SetAuditLogger(logger audit.Logger) {
+	s.audit = logger
+}
+
+// SetImagePreprocessor replaces the preprocessor applied to image uploads. A nil
+// preprocessor stores images as uploaded.
+func (s *Service) SetImagePreprocessor(preprocessor ImagePreprocessor) {
+	s.imagePreprocessor = preprocessor
+}
+
 // ========================================
 // DOCUMENT TYPES
 // ========================================
@@ -55,9 +149,82 @@ func (s *Service) GetDocumentTypes(ctx context.Context) ([]*DocumentType, error)
 	return s.repo.GetDocumentTypes(ctx)
 }
 
-// GetRequiredDocumentTypes gets required document types
-func (s *Service) GetRequiredDocumentTypes(ctx context.Context) ([]*DocumentType, error) {
-	return s.repo.GetRequiredDocumentTypes(ctx)
+// GetRequiredDocumentTypes gets the document types required in a country. An
+// empty country code gets every required type.
+func (s *Service) GetRequiredDocumentTypes(ctx context.Context, countryCode string) ([]*DocumentType, error) {
+	types, err := s.repo.GetRequiredDocumentTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return requiredInCountry(types, countryCode), nil
+}
+
+// requiredInCountry keeps the document types that apply in a country: those
+// listing it and those with no country list, which apply everywhere. Drivers
+// whose country isn't known are held to every type.
+func requiredInCountry(types []*DocumentType, countryCode string) []*DocumentType {
+	if countryCode == "" {
+		return types
+	}
+
+	filtered := make([]*DocumentType, 0, len(types))
+	for _, dt := range types {
+		if len(dt.CountryCodes) == 0 {
+			filtered = append(filtered, dt)
+			continue
+		}
+		for _, code := range dt.CountryCodes {
+			if strings.EqualFold(code, countryCode) {
+				filtered = append(filtered, dt)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// GetVerificationRequirements returns the checklist of documents a prospective
+// driver in a country will need, before they have any. Unlike
+// GetRequiredDocumentTypes, an empty country code gets only the types required
+// everywhere, since no country's own requirements can be assumed.
+func (s *Service) GetVerificationRequirements(ctx context.Context, countryCode string) (*VerificationRequirementsResponse, error) {
+	types, err := s.repo.GetRequiredDocumentTypes(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get required document types", err)
+	}
+
+	countryCode = strings.ToUpper(strings.TrimSpace(countryCode))
+	if countryCode == "" {
+		global := make([]*DocumentType, 0, len(types))
+		for _, dt := range types {
+			if len(dt.CountryCodes) == 0 {
+				global = append(global, dt)
+			}
+		}
+		types = global
+	} else {
+		types = requiredInCountry(types, countryCode)
+	}
+
+	requirements := make([]*VerificationRequirement, 0, len(types))
+	for _, dt := range types {
+		requirements = append(requirements, &VerificationRequirement{
+			Code:                  dt.Code,
+			Name:                  dt.Name,
+			Description:           dt.Description,
+			RequiresFrontBack:     dt.RequiresFrontBack,
+			RequiresExpiry:        dt.RequiresExpiry,
+			AcceptedMimeTypes:     s.allowedMimeTypes(dt),
+			DefaultValidityMonths: dt.DefaultValidityMonths,
+			RenewalReminderDays:   dt.RenewalReminderDays,
+			RequiresManualReview:  dt.RequiresManualReview,
+		})
+	}
+
+	return &VerificationRequirementsResponse{
+		Country:      countryCode,
+		Requirements: requirements,
+	}, nil
 }
 
 // ========================================
@@ -69,12 +236,11 @@ func (s *Service) UploadDocument(ctx context.Context, driverID uuid.UUID, req *U
 	// Validate file size
 	maxSize := int64(s.config.MaxFileSizeMB) * 1024 * 1024
 	if fileSize > maxSize {
-		return nil, common.NewBadRequestError(fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
 	}
 
-	// Validate mime type
-	if !storage.ValidateMimeType(contentType, s.config.AllowedMimeTypes) {
-		return nil, common.NewBadRequestError("unsupported file type", nil)
+	if isExpired(req.ExpiryDate, time.Now()) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentExpired, "document has already expired", nil)
 	}
 
 	// Get document type
@@ -83,32 +249,95 @@ func (s *Service) UploadDocument(ctx context.Context, driverID uuid.UUID, req *U
 		return nil, common.NewBadRequestError("invalid document type", err)
 	}
 
+	// Validate mime type
+	allowed := s.allowedMimeTypes(docType)
+	if !storage.ValidateMimeType(contentType, allowed) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeUnsupportedFileType, "unsupported file type", nil)
+	}
+
+	// Check the file content matches what the client declared
+	contentType, reader, err = s.verifyContentType(reader, contentType, allowed)
+	if err != nil {
+		return nil, err
+	}
+	hasher := newContentHasher(reader)
+	reader = hasher
+
+	// Normalize images and check PDFs before anything is replaced
+	upload, err := s.preprocessUpload(reader, fileSize, contentType)
+	if err != nil {
+		return nil, err
+	}
+	reader, fileSize, contentType = upload.reader, upload.size, upload.contentType
+
+	// A resubmission replaces a specific rejected document
+	var resubmitted *DriverDocument
+	if req.ResubmitForDocumentID != nil {
+		resubmitted, err = s.resubmissionTarget(ctx, driverID, docType, *req.ResubmitForDocumentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if there's an existing document of this type that needs to be superseded
 	existing, _ := s.repo.GetLatestDocumentByType(ctx, driverID, docType.ID)
 	version := 1
 	var previousDocID *uuid.UUID
-	if existing != nil && existing.Status != StatusRejected && existing.Status != StatusExpired {
-		// Supersede the existing document
-		if err := s.repo.SupersedeDocument(ctx, existing.ID); err != nil {
-			logger.Warn("Failed to supersede existing document", zap.Error(err))
-		}
+	supersede := false
+	if resubmitted != nil {
+		version = resubmitted.Version + 1
+		previousDocID = &resubmitted.ID
+	} else if existing != nil && existing.Status != StatusRejected && existing.Status != StatusExpired {
 		version = existing.Version + 1
 		previousDocID = &existing.ID
-
-		// Log history
-		s.logHistory(ctx, existing.ID, "superseded", string(existing.Status), string(StatusSuperseded), nil, false, "New document uploaded")
+		supersede = true
+	}
+	replaced := resubmitted
+	if replaced == nil && existing != nil && existing.Status == StatusRejected {
+		replaced = existing
 	}
 
 	// Generate storage key
-	fileKey := storage.GenerateDocumentKey(driverID, req.DocumentTypeCode, fileName)
+	fileKey := storage.GenerateDocumentKey(driverID, req.DocumentTypeCode, processedFileName(fileName, contentType))
 
 	// Upload to storage
-	uploadResult, err := s.storage.Upload(ctx, fileKey, reader, fileSize, contentType)
+	uploadResult, err := s.uploadFile(ctx, fileKey, reader, fileSize, contentType)
+	if err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+		}
+		if upload.pdf != nil && upload.pdf.err != nil {
+			return nil, upload.pdf.err
+		}
+		logger.ErrorContext(ctx, "Failed to upload document to storage", zap.Error(err))
+		return nil, common.NewInternalError("failed to upload document", err)
+	}
+	if fileSize <= 0 {
+		fileSize = uploadResult.Size
+	}
+
+	// A file a reviewer already rejected needs a new photo, not another review
+	contentHash, err := hasher.sum()
 	if err != nil {
-		logger.Error("Failed to upload document to storage", zap.Error(err))
-		return nil, common.NewInternalServerError("failed to upload document")
+		_ = s.storage.Delete(ctx, fileKey)
+		return nil, common.NewBadRequestError("failed to read uploaded file", err)
+	}
+	if repeatsRejectedFile(replaced, contentHash) {
+		_ = s.storage.Delete(ctx, fileKey)
+		return nil, errRepeatedRejectedFile()
+	}
+
+	// Supersede the existing document only once the new file is stored
+	if supersede {
+		if err := s.repo.SupersedeDocument(ctx, existing.ID); err != nil {
+			logger.WarnContext(ctx, "Failed to supersede existing document", zap.Error(err))
+		}
+		s.logHistory(ctx, existing.ID, "superseded", string(existing.Status), string(StatusSuperseded), nil, false, "New document uploaded")
 	}
 
+	originalKey := s.keepOriginal(ctx, upload, fileKey, fileName)
+	thumbnailURL, thumbnailKey := s.storeThumbnail(ctx, uploadResult.Key, upload.data, contentType)
+
 	// Create document record
 	doc := &DriverDocument{
 		ID:                 uuid.New(),
@@ -120,6 +349,11 @@ func (s *Service) UploadDocument(ctx context.Context, driverID uuid.UUID, req *U
 		FileName:           fileName,
 		FileSizeBytes:      &fileSize,
 		FileMimeType:       &contentType,
+		PageCount:          upload.pages(),
+		OriginalFileKey:    originalKey,
+		ThumbnailURL:       thumbnailURL,
+		ThumbnailKey:       thumbnailKey,
+		ContentHash:        &contentHash,
 		DocumentNumber:     nilIfEmpty(req.DocumentNumber),
 		IssueDate:          req.IssueDate,
 		ExpiryDate:         req.ExpiryDate,
@@ -128,21 +362,46 @@ func (s *Service) UploadDocument(ctx context.Context, driverID uuid.UUID, req *U
 		PreviousDocumentID: previousDocID,
 		SubmittedAt:        time.Now(),
 	}
+	sharedWith := s.sharedFileMatches(ctx, doc)
+	doc.DuplicateFlagged = len(sharedWith) > 0
 
 	if err := s.repo.CreateDocument(ctx, doc); err != nil {
-		// Cleanup uploaded file on failure
+		// Cleanup uploaded files on failure
 		_ = s.storage.Delete(ctx, fileKey)
-		return nil, common.NewInternalServerError("failed to save document")
+		for _, key := range []*string{originalKey, thumbnailKey} {
+			if key != nil {
+				_ = s.storage.Delete(ctx, *key)
+			}
+		}
+		return nil, common.NewInternalError("failed to save document", err)
 	}
 
 	// Log history
-	s.logHistory(ctx, doc.ID, "submitted", "", string(StatusPending), nil, false, nil)
+	message := "Document uploaded successfully"
+	if resubmitted != nil {
+		s.logHistory(ctx, doc.ID, "resubmitted", "", string(StatusPending), nil, false, resubmissionNote(resubmitted))
+		message = "Document resubmitted successfully"
+	} else {
+		s.logHistory(ctx, doc.ID, "submitted", "", string(StatusPending), nil, false, nil)
+	}
+	if doc.DuplicateFlagged {
+		s.flagSharedFile(ctx, doc, sharedWith)
+	}
+
+	previous := map[uuid.UUID]DocumentStatus{doc.ID: ""}
+	if resubmitted != nil {
+		previous[resubmitted.ID] = resubmitted.Status
+	} else if previousDocID != nil {
+		previous[existing.ID] = existing.Status
+		s.pruneAfterSupersede(ctx, driverID, docType.ID)
+	}
+	_, _ = s.recomputeVerification(ctx, driverID, previous)
 
 	// Schedule OCR if enabled for this document type
 	ocrScheduled := false
 	if s.config.OCREnabled && docType.AutoOCREnabled {
 		if err := s.scheduleOCR(ctx, doc.ID, 0); err != nil {
-			logger.Warn("Failed to schedule OCR", zap.Error(err))
+			logger.WarnContext(ctx, "Failed to schedule OCR", zap.Error(err))
 		} else {
 			ocrScheduled = true
 		}
@@ -152,11 +411,44 @@ func (s *Service) UploadDocument(ctx context.Context, driverID uuid.UUID, req *U
 		DocumentID:   doc.ID,
 		Status:       doc.Status,
 		FileURL:      doc.FileURL,
-		Message:      "Document uploaded successfully",
+		Message:      message,
 		OCRScheduled: ocrScheduled,
 	}, nil
 }
 
+// resubmissionTarget loads the rejected document an upload is resubmitting and
+// checks that it belongs to the driver, matches the document type and has not
+// already been replaced
+func (s *Service) resubmissionTarget(ctx context.Context, driverID uuid.UUID, docType *DocumentType, documentID uuid.UUID) (*DriverDocument, error) {
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil || doc.DriverID != driverID {
+		return nil, common.NewNotFoundError("document to resubmit not found", err)
+	}
+
+	if doc.DocumentTypeID != docType.ID {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeResubmissionNotAllowed, "resubmission must use the same document type", nil)
+	}
+
+	if doc.Status != StatusRejected {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeResubmissionNotAllowed, "document is not awaiting resubmission", nil)
+	}
+
+	if latest, _ := s.repo.GetLatestDocumentByType(ctx, driverID, docType.ID); latest != nil && latest.ID != doc.ID {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeResubmissionNotAllowed, "document has already been resubmitted", nil)
+	}
+
+	return doc, nil
+}
+
+// resubmissionNote describes the rejected document a resubmission replaces
+func resubmissionNote(rejected *DriverDocument) string {
+	note := fmt.Sprintf("Resubmission of document %s", rejected.ID)
+	if rejected.RejectionReason != nil && *rejected.RejectionReason != "" {
+		note += ", rejected: " + *rejected.RejectionReason
+	}
+	return note
+}
+
 // UploadDocumentBackSide uploads the back side of a document
 func (s *Service) UploadDocumentBackSide(ctx context.Context, documentID uuid.UUID, reader io.Reader, fileSize int64, fileName, contentType string) error {
 	doc, err := s.repo.GetDocument(ctx, documentID)
@@ -165,32 +457,41 @@ func (s *Service) UploadDocumentBackSide(ctx context.Context, documentID uuid.UU
 	}
 
 	if !doc.DocumentType.RequiresFrontBack {
-		return common.NewBadRequestError("this document type does not require a back side", nil)
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "this document type does not require a back side", nil)
 	}
 
 	// Validate file
 	maxSize := int64(s.config.MaxFileSizeMB) * 1024 * 1024
 	if fileSize > maxSize {
-		return common.NewBadRequestError(fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+	}
+
+	allowed := s.allowedMimeTypes(doc.DocumentType)
+	if !storage.ValidateMimeType(contentType, allowed) {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeUnsupportedFileType, "unsupported file type", nil)
 	}
 
-	if !storage.ValidateMimeType(contentType, s.config.AllowedMimeTypes) {
-		return common.NewBadRequestError("unsupported file type", nil)
+	contentType, reader, err = s.verifyContentType(reader, contentType, allowed)
+	if err != nil {
+		return err
 	}
 
 	// Generate storage key for back side
 	fileKey := storage.GenerateDocumentKey(doc.DriverID, doc.DocumentType.Code+"_back", fileName)
 
 	// Upload to storage
-	uploadResult, err := s.storage.Upload(ctx, fileKey, reader, fileSize, contentType)
+	uploadResult, err := s.uploadFile(ctx, fileKey, reader, fileSize, contentType)
 	if err != nil {
-		return common.NewInternalServerError("failed to upload document")
+		if errors.Is(err, errFileTooLarge) {
+			return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+		}
+		return common.NewInternalError("failed to upload document", err)
 	}
 
 	// Update document with back file
 	if err := s.repo.UpdateDocumentBackFile(ctx, documentID, uploadResult.URL, uploadResult.Key); err != nil {
 		_ = s.storage.Delete(ctx, fileKey)
-		return common.NewInternalServerError("failed to update document")
+		return common.NewInternalError("failed to update document", err)
 	}
 
 	return nil
@@ -205,8 +506,8 @@ func (s *Service) GetPresignedUploadURL(ctx context.Context, driverID uuid.UUID,
 	}
 
 	// Validate content type
-	if !storage.ValidateMimeType(req.ContentType, s.config.AllowedMimeTypes) {
-		return nil, common.NewBadRequestError("unsupported file type", nil)
+	if !storage.ValidateMimeType(req.ContentType, s.allowedMimeTypes(docType)) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeUnsupportedFileType, "unsupported file type", nil)
 	}
 
 	// Generate file key
@@ -219,7 +520,7 @@ func (s *Service) GetPresignedUploadURL(ctx context.Context, driverID uuid.UUID,
 	// Get presigned URL
 	presigned, err := s.storage.GetPresignedUploadURL(ctx, fileKey, req.ContentType, 15*time.Minute)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to generate upload URL")
+		return nil, common.NewInternalError("failed to generate upload URL", err)
 	}
 
 	return &PresignedUploadResponse{
@@ -232,92 +533,127 @@ func (s *Service) GetPresignedUploadURL(ctx context.Context, driverID uuid.UUID,
 	}, nil
 }
 
-// CompleteDirectUpload completes the document creation after direct upload
+// CompleteDirectUpload completes the document creation after direct upload. The
+// key must be one GetPresignedUploadURL could have issued to the driver for the
+// document type. The stored file is read back and goes through everything
+// UploadDocument does (content sniffing, PDF and image checks, hashing and
+// thumbnails) before the document is created from a copy under a new key; the
+// uploaded object is then deleted.
 func (s *Service) CompleteDirectUpload(ctx context.Context, driverID uuid.UUID, req *UploadCompleteRequest) (*UploadDocumentResponse, error) {
+	// Get document type
+	docType, err := s.repo.GetDocumentTypeByCode(ctx, req.DocumentTypeCode)
+	if err != nil {
+		return nil, common.NewBadRequestError("invalid document type", err)
+	}
+
+	backSide := !req.IsFrontSide && docType.RequiresFrontBack
+	suffix := ""
+	if backSide {
+		suffix = "_back"
+	}
+	if !isDirectUploadKey(req.FileKey, driverID, req.DocumentTypeCode+suffix) {
+		return nil, common.NewErrorWithCode(http.StatusForbidden, common.ErrCodeForbidden, "file key is not an upload issued to you for this document type", nil)
+	}
+
 	// Verify file exists in storage
 	exists, err := s.storage.Exists(ctx, req.FileKey)
 	if err != nil || !exists {
-		return nil, common.NewBadRequestError("uploaded file not found", nil)
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "uploaded file not found", nil)
 	}
 
-	// Get document type
-	docType, err := s.repo.GetDocumentTypeByCode(ctx, req.DocumentTypeCode)
+	if !backSide && isExpired(req.ExpiryDate, time.Now()) {
+		_ = s.storage.Delete(ctx, req.FileKey)
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentExpired, "document has already expired", nil)
+	}
+
+	data, contentType, err := s.readDirectUpload(ctx, req.FileKey)
 	if err != nil {
-		return nil, common.NewBadRequestError("invalid document type", err)
+		s.discardRejectedUpload(ctx, req.FileKey, err)
+		return nil, err
 	}
+	fileName := path.Base(req.FileKey)
 
-	// If this is a back side upload
-	if !req.IsFrontSide && docType.RequiresFrontBack {
+	var response *UploadDocumentResponse
+	if backSide {
 		// Find the existing front document and update it
 		existing, err := s.repo.GetLatestDocumentByType(ctx, driverID, docType.ID)
 		if err != nil {
 			return nil, common.NewBadRequestError("front side document not found", err)
 		}
 
-		if err := s.repo.UpdateDocumentBackFile(ctx, existing.ID, s.storage.GetURL(req.FileKey), req.FileKey); err != nil {
-			return nil, common.NewInternalServerError("failed to update document")
+		err = s.UploadDocumentBackSide(ctx, existing.ID, bytes.NewReader(data), int64(len(data)), fileName, contentType)
+		if err != nil {
+			s.discardRejectedUpload(ctx, req.FileKey, err)
+			return nil, err
 		}
-
-		return &UploadDocumentResponse{
+		response = &UploadDocumentResponse{
 			DocumentID: existing.ID,
 			Status:     existing.Status,
 			FileURL:    existing.FileURL,
 			Message:    "Back side uploaded successfully",
-		}, nil
-	}
-
-	// Handle front side / regular document
-	existing, _ := s.repo.GetLatestDocumentByType(ctx, driverID, docType.ID)
-	version := 1
-	var previousDocID *uuid.UUID
-	if existing != nil && existing.Status != StatusRejected && existing.Status != StatusExpired {
-		if err := s.repo.SupersedeDocument(ctx, existing.ID); err != nil {
-			logger.Warn("Failed to supersede existing document", zap.Error(err))
 		}
-		version = existing.Version + 1
-		previousDocID = &existing.ID
+	} else {
+		response, err = s.UploadDocument(ctx, driverID, &UploadDocumentRequest{
+			DocumentTypeCode: req.DocumentTypeCode,
+			DocumentNumber:   req.DocumentNumber,
+			IssueDate:        req.IssueDate,
+			ExpiryDate:       req.ExpiryDate,
+			IssuingAuthority: req.IssuingAuthority,
+		}, bytes.NewReader(data), int64(len(data)), fileName, contentType)
+		if err != nil {
+			s.discardRejectedUpload(ctx, req.FileKey, err)
+			return nil, err
+		}
 	}
 
-	doc := &DriverDocument{
-		ID:                 uuid.New(),
-		DriverID:           driverID,
-		DocumentTypeID:     docType.ID,
-		Status:             StatusPending,
-		FileURL:            s.storage.GetURL(req.FileKey),
-		FileKey:            req.FileKey,
-		FileName:           req.FileKey,
-		DocumentNumber:     nilIfEmpty(req.DocumentNumber),
-		IssueDate:          req.IssueDate,
-		ExpiryDate:         req.ExpiryDate,
-		IssuingAuthority:   nilIfEmpty(req.IssuingAuthority),
-		Version:            version,
-		PreviousDocumentID: previousDocID,
-		SubmittedAt:        time.Now(),
+	// The document now has its own copy
+	if err := s.storage.Delete(ctx, req.FileKey); err != nil {
+		logger.WarnContext(ctx, "Failed to delete direct upload", zap.String("file_key", req.FileKey), zap.Error(err))
 	}
+	return response, nil
+}
 
-	if err := s.repo.CreateDocument(ctx, doc); err != nil {
-		return nil, common.NewInternalServerError("failed to save document")
-	}
+// isDirectUploadKey reports whether key is under the driver's upload prefix for
+// a document type, without any path tricks
+func isDirectUploadKey(key string, driverID uuid.UUID, documentType string) bool {
+	prefix := storage.DocumentKeyPrefix(driverID, documentType)
+	return strings.HasPrefix(key, prefix) && len(key) > len(prefix) && path.Clean(key) == key
+}
 
-	s.logHistory(ctx, doc.ID, "submitted", "", string(StatusPending), nil, false, nil)
+// readDirectUpload reads a file the driver uploaded straight to storage, capped
+// at the maximum file size, and returns it with the content type it sniffs as.
+// The type the upload was declared with when the URL was issued isn't trusted;
+// UploadDocument checks the sniffed type against the allow-list.
+func (s *Service) readDirectUpload(ctx context.Context, key string) ([]byte, string, error) {
+	object, err := s.storage.Download(ctx, key)
+	if err != nil {
+		return nil, "", common.NewInternalError("failed to read uploaded file", err)
+	}
+	defer object.Close()
 
-	// Schedule OCR
-	ocrScheduled := false
-	if s.config.OCREnabled && docType.AutoOCREnabled {
-		if err := s.scheduleOCR(ctx, doc.ID, 0); err != nil {
-			logger.Warn("Failed to schedule OCR", zap.Error(err))
-		} else {
-			ocrScheduled = true
+	maxSize := int64(s.config.MaxFileSizeMB) * 1024 * 1024
+	data, err := io.ReadAll(&sizeLimitedReader{reader: object, remaining: maxSize})
+	if err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			return nil, "", common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
 		}
+		return nil, "", common.NewInternalError("failed to read uploaded file", err)
 	}
 
-	return &UploadDocumentResponse{
-		DocumentID:   doc.ID,
-		Status:       doc.Status,
-		FileURL:      doc.FileURL,
-		Message:      "Document uploaded successfully",
-		OCRScheduled: ocrScheduled,
-	}, nil
+	contentType, _, err := storage.DetectContentType(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", common.NewBadRequestError("failed to read uploaded file", err)
+	}
+	return data, contentType, nil
+}
+
+// discardRejectedUpload deletes a direct upload that failed because of the file
+// or the request, so it can't be completed later. Uploads that failed for
+// internal reasons are kept for the driver to retry.
+func (s *Service) discardRejectedUpload(ctx context.Context, key string, err error) {
+	if appErr, ok := common.AsAppError(err); ok && appErr.Code < http.StatusInternalServerError {
+		_ = s.storage.Delete(ctx, key)
+	}
 }
 
 // ========================================
@@ -329,28 +665,246 @@ func (s *Service) GetDocument(ctx context.Context, documentID uuid.UUID) (*Drive
 	return s.repo.GetDocument(ctx, documentID)
 }
 
+// GetDocumentDownloadURL returns a short-lived presigned link to the front or back
+// file of a document, or to the front's thumbnail. Only the owning driver or a
+// reviewer may request it.
+func (s *Service) GetDocumentDownloadURL(ctx context.Context, documentID uuid.UUID, side DocumentSide, requester DocumentRequester) (*DocumentDownloadResponse, error) {
+	if side == "" {
+		side = DocumentSideFront
+	}
+	if side != DocumentSideFront && side != DocumentSideBack && side != DocumentSideThumbnail {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "side must be front, back or thumbnail", nil)
+	}
+
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "document not found", nil)
+	}
+
+	if !requester.IsReviewer && doc.DriverID != requester.DriverID {
+		return nil, common.NewErrorWithCode(http.StatusForbidden, common.ErrCodeForbidden, "not your document", nil)
+	}
+
+	fileKey := doc.FileKey
+	switch side {
+	case DocumentSideBack:
+		fileKey = ""
+		if doc.BackFileKey != nil {
+			fileKey = *doc.BackFileKey
+		}
+	case DocumentSideThumbnail:
+		fileKey = ""
+		if doc.ThumbnailKey != nil {
+			fileKey = *doc.ThumbnailKey
+		}
+	}
+	if fileKey == "" {
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, fmt.Sprintf("document has no %s file", side), nil)
+	}
+
+	expiry := s.config.DownloadURLExpiry
+	if expiry <= 0 {
+		expiry = defaultDownloadURLExpiry
+	}
+
+	presigned, err := s.storage.GetPresignedDownloadURL(ctx, fileKey, expiry)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to generate document download URL",
+			zap.String("document_id", documentID.String()),
+			zap.String("side", string(side)),
+			zap.Error(err),
+		)
+		// The storage error can include the file key, so it is logged but not wrapped
+		return nil, common.NewInternalError("failed to generate download URL", nil)
+	}
+
+	return &DocumentDownloadResponse{
+		DocumentID: documentID,
+		Side:       side,
+		URL:        presigned.URL,
+		ExpiresAt:  presigned.ExpiresAt,
+	}, nil
+}
+
 // GetDriverDocuments gets all documents for a driver
 func (s *Service) GetDriverDocuments(ctx context.Context, driverID uuid.UUID) ([]*DriverDocument, error) {
 	return s.repo.GetDriverDocuments(ctx, driverID)
 }
 
-// GetDriverVerificationStatus gets the overall verification status for a driver
+// GetDriverVerificationStatus gets the overall verification status for a driver.
+// The status is the one last persisted by RecomputeAndPersistVerificationStatus;
+// drivers without a stored status have it computed and persisted now.
 func (s *Service) GetDriverVerificationStatus(ctx context.Context, driverID uuid.UUID) (*VerificationStatusResponse, error) {
+	requiredTypes, documents, stored, err := s.loadVerificationInputs(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := buildVerificationStatus(requiredTypes, documents, stored)
+	if stored == nil {
+		s.persistVerificationStatus(ctx, driverID, response)
+		return response, nil
+	}
+
+	applyStoredStatus(response, stored)
+	return response, nil
+}
+
+// RecomputeAndPersistVerificationStatus derives a driver's verification status
+// from their documents and stores it, so the stored status stays authoritative
+func (s *Service) RecomputeAndPersistVerificationStatus(ctx context.Context, driverID uuid.UUID) (*VerificationStatusResponse, error) {
+	return s.recomputeVerification(ctx, driverID, nil)
+}
+
+// recomputeVerification recomputes and persists a driver's verification status
+// after some of their documents changed, and queues a status change for the hook
+// if the status moved. previous holds the prior status of each changed document,
+// or "" for documents that didn't exist; it is used to work out the earlier status
+// of drivers that have none stored.
+func (s *Service) recomputeVerification(ctx context.Context, driverID uuid.UUID, previous map[uuid.UUID]DocumentStatus) (*VerificationStatusResponse, error) {
+	requiredTypes, documents, stored, err := s.loadVerificationInputs(ctx, driverID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to recompute driver verification status",
+			zap.String("driver_id", driverID.String()), zap.Error(err))
+		return nil, err
+	}
+
+	current := buildVerificationStatus(requiredTypes, documents, stored)
+	s.persistVerificationStatus(ctx, driverID, current)
+
+	if s.statusHook == nil {
+		return current, nil
+	}
+
+	var oldStatus VerificationStatus
+	if stored != nil && stored.VerificationStatus != "" {
+		oldStatus = stored.VerificationStatus
+	} else {
+		oldStatus = buildVerificationStatus(requiredTypes, documentsBefore(documents, previous), stored).Status
+	}
+
+	if oldStatus != current.Status {
+		s.queueStatusChange(&VerificationStatusChange{
+			DriverID:  driverID,
+			OldStatus: oldStatus,
+			NewStatus: current.Status,
+			CanDrive:  current.CanDrive,
+			ChangedAt: time.Now(),
+		})
+	}
+
+	return current, nil
+}
+
+// loadVerificationInputs loads what a driver's verification status is computed
+// from. Only the document types required in the driver's country count. The
+// stored status is nil if the driver has none yet.
+func (s *Service) loadVerificationInputs(ctx context.Context, driverID uuid.UUID) ([]*DocumentType, []*DriverDocument, *DriverVerificationStatus, error) {
+	countryCode, err := s.repo.GetDriverCountryCode(ctx, driverID)
+	if err != nil {
+		return nil, nil, nil, common.NewInternalError("failed to get driver country", err)
+	}
+
 	// Get required document types
-	requiredTypes, err := s.repo.GetRequiredDocumentTypes(ctx)
+	requiredTypes, err := s.GetRequiredDocumentTypes(ctx, countryCode)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to get document types")
+		return nil, nil, nil, common.NewInternalError("failed to get document types", err)
 	}
 
 	// Get driver's documents
 	documents, err := s.repo.GetDriverDocuments(ctx, driverID)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to get documents")
+		return nil, nil, nil, common.NewInternalError("failed to get documents", err)
 	}
 
 	// Get verification status
-	verificationStatus, _ := s.repo.GetDriverVerificationStatus(ctx, driverID)
+	stored, _ := s.repo.GetDriverVerificationStatus(ctx, driverID)
 
+	return requiredTypes, documents, stored, nil
+}
+
+// persistVerificationStatus writes a computed status back to the driver's stored
+// verification record. Failures are logged: the next recomputation catches up.
+func (s *Service) persistVerificationStatus(ctx context.Context, driverID uuid.UUID, response *VerificationStatusResponse) {
+	if err := s.repo.UpsertDriverVerificationStatus(ctx, verificationRecord(driverID, response, time.Now())); err != nil {
+		logger.WarnContext(ctx, "Failed to persist driver verification status",
+			zap.String("driver_id", driverID.String()), zap.Error(err))
+	}
+}
+
+// verificationRecord summarises a computed status as a stored verification record
+func verificationRecord(driverID uuid.UUID, response *VerificationStatusResponse, now time.Time) *DriverVerificationStatus {
+	record := &DriverVerificationStatus{
+		DriverID:               driverID,
+		VerificationStatus:     response.Status,
+		RequiredDocumentsCount: len(response.RequiredDocuments),
+		NextDocumentExpiry:     response.NextExpiry,
+	}
+
+	for _, req := range response.RequiredDocuments {
+		switch req.Status {
+		case "approved":
+			record.ApprovedDocumentsCount++
+			record.SubmittedDocumentsCount++
+		case "not_submitted", "expired":
+		default:
+			record.SubmittedDocumentsCount++
+		}
+	}
+
+	if record.SubmittedDocumentsCount == record.RequiredDocumentsCount {
+		record.DocumentsSubmittedAt = &now
+	}
+	if record.ApprovedDocumentsCount == record.RequiredDocumentsCount {
+		record.DocumentsApprovedAt = &now
+	}
+
+	return record
+}
+
+// documentsBefore returns a driver's documents as they were before the documents
+// in previous changed status
+func documentsBefore(documents []*DriverDocument, previous map[uuid.UUID]DocumentStatus) []*DriverDocument {
+	before := make([]*DriverDocument, 0, len(documents))
+	for _, doc := range documents {
+		status, changed := previous[doc.ID]
+		switch {
+		case !changed:
+			before = append(before, doc)
+		case status != "":
+			prior := *doc
+			prior.Status = status
+			before = append(before, &prior)
+		}
+	}
+	return before
+}
+
+// applyStoredStatus makes the persisted status authoritative over the one computed
+// from the documents. They only differ if documents changed without a recomputation.
+func applyStoredStatus(response *VerificationStatusResponse, stored *DriverVerificationStatus) {
+	if stored.VerificationStatus == "" || stored.VerificationStatus == response.Status {
+		return
+	}
+
+	response.Status = stored.VerificationStatus
+	response.CanDrive = stored.VerificationStatus == VerificationApproved
+
+	switch stored.VerificationStatus {
+	case VerificationApproved:
+		response.Message = "Your verification is complete"
+	case VerificationPendingReview:
+		response.Message = "Your documents are being reviewed"
+	case VerificationRejected:
+		response.Message = "Your verification was rejected"
+	default:
+		response.Message = "Please submit all required documents"
+	}
+}
+
+// buildVerificationStatus computes a driver's verification status from their
+// documents, the required document types and their stored verification record
+func buildVerificationStatus(requiredTypes []*DocumentType, documents []*DriverDocument, verificationStatus *DriverVerificationStatus) *VerificationStatusResponse {
 	// Map documents by type
 	docByType := make(map[uuid.UUID]*DriverDocument)
 	for _, doc := range documents {
@@ -404,19 +958,13 @@ func (s *Service) GetDriverVerificationStatus(ctx context.Context, driverID uuid
 		requirements = append(requirements, req)
 	}
 
-	// Determine overall status
+	// Determine overall status. A rejection stands until documents are approved;
+	// other stored statuses were computed from the documents and are derived again.
 	status := VerificationIncomplete
 	message := "Please submit all required documents"
 
-	if verificationStatus != nil {
-		status = verificationStatus.VerificationStatus
-		if status == VerificationSuspended {
-			canDrive = false
-			message = "Your account has been suspended"
-			if verificationStatus.SuspensionReason != nil {
-				message += ": " + *verificationStatus.SuspensionReason
-			}
-		}
+	if verificationStatus != nil && verificationStatus.VerificationStatus == VerificationRejected {
+		status = VerificationRejected
 	}
 
 	if approvedCount == len(requiredTypes) {
@@ -432,6 +980,16 @@ func (s *Service) GetDriverVerificationStatus(ctx context.Context, driverID uuid
 		message = fmt.Sprintf("Missing documents: %d", len(missingDocs))
 	}
 
+	// A suspension overrides everything, including a full set of approved documents
+	if verificationStatus != nil && verificationStatus.VerificationStatus == VerificationSuspended {
+		status = VerificationSuspended
+		canDrive = false
+		message = "Your account has been suspended"
+		if verificationStatus.SuspensionReason != nil {
+			message += ": " + *verificationStatus.SuspensionReason
+		}
+	}
+
 	return &VerificationStatusResponse{
 		Status:             status,
 		RequiredDocuments:  requirements,
@@ -440,7 +998,7 @@ func (s *Service) GetDriverVerificationStatus(ctx context.Context, driverID uuid
 		NextExpiry:         nextExpiry,
 		CanDrive:           canDrive,
 		Message:            message,
-	}, nil
+	}
 }
 
 // ========================================
@@ -455,7 +1013,10 @@ func (s *Service) ReviewDocument(ctx context.Context, documentID uuid.UUID, revi
 	}
 
 	if doc.Status != StatusPending && doc.Status != StatusUnderReview {
-		return common.NewBadRequestError("document is not pending review", nil)
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentNotReviewable, "document is not pending review", nil)
+	}
+	if claimedByOther(doc, reviewerID, time.Now()) {
+		return documentClaimedError()
 	}
 
 	previousStatus := string(doc.Status)
@@ -481,7 +1042,7 @@ func (s *Service) ReviewDocument(ctx context.Context, documentID uuid.UUID, revi
 	case "reject":
 		newStatus = StatusRejected
 		if req.RejectionReason == "" {
-			return common.NewBadRequestError("rejection reason is required", nil)
+			return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "rejection reason is required", nil)
 		}
 		rejectionReason = &req.RejectionReason
 
@@ -494,19 +1055,34 @@ func (s *Service) ReviewDocument(ctx context.Context, documentID uuid.UUID, revi
 		rejectionReason = &reason
 
 	default:
-		return common.NewBadRequestError("invalid action", nil)
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "invalid action", nil)
 	}
 
 	notes := nilIfEmpty(req.Notes)
 
 	if err := s.repo.UpdateDocumentStatus(ctx, documentID, newStatus, &reviewerID, notes, rejectionReason); err != nil {
-		return common.NewInternalServerError("failed to update document")
+		return common.NewInternalError("failed to update document", err)
 	}
 
 	// Log history
 	s.logHistory(ctx, documentID, req.Action, previousStatus, string(newStatus), &reviewerID, false, notes)
 
-	logger.Info("Document reviewed",
+	after := map[string]interface{}{"status": string(newStatus)}
+	if rejectionReason != nil {
+		after["rejection_reason"] = *rejectionReason
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    reviewerID,
+		Action:     "document." + req.Action,
+		TargetType: "driver_document",
+		TargetID:   documentID.String(),
+		Before:     map[string]interface{}{"status": previousStatus},
+		After:      after,
+	})
+
+	_, _ = s.recomputeVerification(ctx, doc.DriverID, map[uuid.UUID]DocumentStatus{documentID: doc.Status})
+
+	logger.InfoContext(ctx, "Document reviewed",
 		zap.String("document_id", documentID.String()),
 		zap.String("action", req.Action),
 		zap.String("reviewer_id", reviewerID.String()),
@@ -515,15 +1091,88 @@ func (s *Service) ReviewDocument(ctx context.Context, documentID uuid.UUID, revi
 	return nil
 }
 
-// GetPendingReviews gets documents pending review
-func (s *Service) GetPendingReviews(ctx context.Context, limit, offset int) ([]*PendingReviewDocument, int, error) {
-	if limit < 1 || limit > 100 {
-		limit = 20
+// BulkReviewDocuments reviews several documents, applying each item on its own so
+// an invalid item fails alone instead of aborting the batch. Every item follows
+// the same rules as ReviewDocument. Batches approving more documents than
+// MaxBulkApprovals are refused outright to prevent accidental mass approval.
+func (s *Service) BulkReviewDocuments(ctx context.Context, reviewerID uuid.UUID, items []BulkReviewItem) (*BulkReviewResponse, error) {
+	if len(items) == 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "no documents to review", nil)
+	}
+
+	maxApprovals := s.config.MaxBulkApprovals
+	if maxApprovals <= 0 {
+		maxApprovals = defaultMaxBulkApprovals
+	}
+	approvals := 0
+	for _, item := range items {
+		if item.Review.Action == "approve" {
+			approvals++
+		}
+	}
+	if approvals > maxApprovals {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation,
+			fmt.Sprintf("cannot approve more than %d documents at once", maxApprovals), nil)
+	}
+
+	response := &BulkReviewResponse{Results: make([]BulkReviewResult, 0, len(items))}
+	for _, item := range items {
+		review := item.Review
+		result := BulkReviewResult{DocumentID: item.DocumentID, Action: review.Action}
+
+		if err := s.ReviewDocument(ctx, item.DocumentID, reviewerID, &review); err != nil {
+			result.Error = err.Error()
+			if appErr, ok := err.(*common.AppError); ok {
+				result.Error = appErr.Message
+			}
+			response.Failed++
+		} else {
+			result.Success = true
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	logger.InfoContext(ctx, "Bulk document review completed",
+		zap.String("reviewer_id", reviewerID.String()),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// GetPendingReviews gets documents pending review. With excludeClaimedFor set to
+// a reviewer, documents other reviewers currently hold a claim on are left out.
+func (s *Service) GetPendingReviews(ctx context.Context, limit, offset int, excludeClaimedFor *uuid.UUID) ([]*PendingReviewDocument, int, error) {
+	limit, offset = httputil.NormalizePagination(limit, offset)
+	return s.repo.GetPendingReviews(ctx, limit, offset, excludeClaimedFor)
+}
+
+// GetPendingReviewsCursor gets a page of documents pending review, longest
+// waiting first, starting after afterCursor, or from the start when it is
+// empty. Unlike GetPendingReviews, paging by cursor doesn't skip documents when
+// earlier ones are reviewed between pages.
+func (s *Service) GetPendingReviewsCursor(ctx context.Context, afterCursor string, limit int, excludeClaimedFor *uuid.UUID) (*PendingReviewsPage, error) {
+	limit, _ = httputil.NormalizePagination(limit, 0)
+	after, err := pagination.DecodeCursor(afterCursor)
+	if err != nil {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "invalid cursor", nil)
+	}
+
+	reviews, err := s.repo.GetPendingReviewsAfter(ctx, after, limit+1, excludeClaimedFor)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get pending reviews", err)
 	}
-	if offset < 0 {
-		offset = 0
+	reviews, next := pagination.Page(reviews, limit, func(review *PendingReviewDocument) *pagination.Cursor {
+		return pagination.NewCursor(review.Document.SubmittedAt, review.Document.ID)
+	})
+	if reviews == nil {
+		reviews = []*PendingReviewDocument{}
 	}
-	return s.repo.GetPendingReviews(ctx, limit, offset)
+
+	return &PendingReviewsPage{Documents: reviews, NextCursor: next, Limit: limit}, nil
 }
 
 // GetExpiringDocuments gets documents expiring soon
@@ -534,26 +1183,374 @@ func (s *Service) GetExpiringDocuments(ctx context.Context, daysAhead int) ([]*E
 	return s.repo.GetExpiringDocuments(ctx, daysAhead)
 }
 
-// StartReview marks a document as under review
+// GetVerificationFunnel aggregates how many drivers reached each verification stage.
+// Stages are cumulative: a driver who was approved also counts as having started,
+// completed their documents and submitted for review.
+func (s *Service) GetVerificationFunnel(ctx context.Context, since time.Time) (*VerificationFunnelResponse, error) {
+	requiredTypes, err := s.repo.GetRequiredDocumentTypes(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get document types", err)
+	}
+
+	drivers, err := s.repo.GetVerificationFunnelDrivers(ctx, since)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get verification funnel", err)
+	}
+
+	stages := []FunnelStage{
+		FunnelStageStarted,
+		FunnelStageDocumentsCompleted,
+		FunnelStageSubmittedForReview,
+		FunnelStageApproved,
+	}
+	counts := make([]int, len(stages))
+
+	for _, d := range drivers {
+		reached := 0
+		switch {
+		case d.Approved:
+			reached = 3
+		case d.SubmittedForReview:
+			reached = 2
+		case d.RequiredDocumentsSubmitted >= len(requiredTypes):
+			reached = 1
+		}
+		for i := 0; i <= reached; i++ {
+			counts[i]++
+		}
+	}
+
+	metrics := make([]FunnelStageMetrics, len(stages))
+	for i, stage := range stages {
+		m := FunnelStageMetrics{Stage: stage, Drivers: counts[i]}
+		if i == 0 {
+			if counts[0] > 0 {
+				m.ConversionRate = 100
+			}
+		} else {
+			m.DropOff = counts[i-1] - counts[i]
+			m.ConversionRate = percentOf(counts[i], counts[i-1])
+		}
+		metrics[i] = m
+	}
+
+	return &VerificationFunnelResponse{
+		Since:                 since,
+		Stages:                metrics,
+		OverallConversionRate: percentOf(counts[len(counts)-1], counts[0]),
+	}, nil
+}
+
+// StartReview marks a document as under review and claims it for the reviewer
+// for ReviewClaimTTL. While the claim is live other reviewers can neither start
+// nor complete the review. Starting a review again renews the reviewer's own
+// claim, and an expired claim can be taken over.
 func (s *Service) StartReview(ctx context.Context, documentID uuid.UUID, reviewerID uuid.UUID) error {
 	doc, err := s.repo.GetDocument(ctx, documentID)
 	if err != nil {
 		return common.NewNotFoundError("document not found", err)
 	}
 
-	if doc.Status != StatusPending {
-		return common.NewBadRequestError("document is not pending", nil)
+	if doc.Status != StatusPending && doc.Status != StatusUnderReview {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeDocumentNotReviewable, "document is not pending", nil)
 	}
 
-	if err := s.repo.UpdateDocumentStatus(ctx, documentID, StatusUnderReview, &reviewerID, nil, nil); err != nil {
-		return common.NewInternalServerError("failed to update document")
+	now := time.Now()
+	if claimedByOther(doc, reviewerID, now) {
+		return documentClaimedError()
 	}
 
-	s.logHistory(ctx, documentID, "review_started", string(StatusPending), string(StatusUnderReview), &reviewerID, false, nil)
+	if err := s.repo.ClaimDocumentForReview(ctx, documentID, reviewerID, now.Add(s.reviewClaimTTL())); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Another reviewer claimed it since we read it
+			return documentClaimedError()
+		}
+		return common.NewInternalError("failed to update document", err)
+	}
+
+	s.logHistory(ctx, documentID, "review_started", string(doc.Status), string(StatusUnderReview), &reviewerID, false, nil)
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    reviewerID,
+		Action:     "document.review_started",
+		TargetType: "driver_document",
+		TargetID:   documentID.String(),
+		Before:     map[string]interface{}{"status": string(doc.Status)},
+		After:      map[string]interface{}{"status": string(StatusUnderReview)},
+	})
 
 	return nil
 }
 
+// ExpireStaleClaims returns documents that have been under review for longer
+// than maxAge to pending, so reviews abandoned by a reviewer go back into the
+// queue. It returns how many documents were released.
+func (s *Service) ExpireStaleClaims(ctx context.Context, maxAge time.Duration) (int, error) {
+	if maxAge <= 0 {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "max age must be positive", nil)
+	}
+
+	released, err := s.repo.ReleaseStaleClaims(ctx, time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, common.NewInternalError("failed to release stale review claims", err)
+	}
+
+	for _, doc := range released {
+		s.logHistory(ctx, doc.ID, "review_claim_expired", string(StatusUnderReview), string(StatusPending), nil, true, "Review abandoned, returned to queue")
+	}
+
+	if len(released) > 0 {
+		logger.InfoContext(ctx, "Released stale document review claims", zap.Int("documents", len(released)))
+	}
+
+	return len(released), nil
+}
+
+// reviewClaimTTL returns how long a review claim lasts
+func (s *Service) reviewClaimTTL() time.Duration {
+	if s.config.ReviewClaimTTL <= 0 {
+		return defaultReviewClaimTTL
+	}
+	return s.config.ReviewClaimTTL
+}
+
+// claimedByOther reports whether a reviewer other than reviewerID holds a live
+// claim on the document
+func claimedByOther(doc *DriverDocument, reviewerID uuid.UUID, now time.Time) bool {
+	return doc.Status == StatusUnderReview &&
+		doc.ClaimedBy != nil && *doc.ClaimedBy != reviewerID &&
+		doc.ClaimExpiresAt != nil && doc.ClaimExpiresAt.After(now)
+}
+
+func documentClaimedError() error {
+	return common.NewErrorWithCode(http.StatusConflict, common.ErrCodeDocumentClaimed,
+		"document is being reviewed by another reviewer", common.ErrConflict)
+}
+
+// ========================================
+// EXPIRY
+// ========================================
+
+// SetNotifier sets the notifier used for document expiry reminders
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// StartExpirySweeper periodically expires approved documents past their expiry
+// date until ctx is cancelled. If a notifier is set it also sends expiry reminders.
+// Each pass also retries removing the files of deleted documents and returns
+// documents whose review claim has expired to pending.
+func (s *Service) StartExpirySweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultExpirySweepInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.InfoContext(ctx, "Document expiry sweeper started", zap.Duration("interval", interval))
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.InfoContext(ctx, "Document expiry sweeper stopped")
+				return
+			case <-ticker.C:
+				if _, err := s.ExpireOverdueDocuments(ctx); err != nil {
+					logger.WarnContext(ctx, "Failed to expire overdue documents", zap.Error(err))
+				}
+				if s.notifier != nil {
+					if _, err := s.SendExpiryReminders(ctx); err != nil {
+						logger.WarnContext(ctx, "Failed to send expiry reminders", zap.Error(err))
+					}
+				}
+				if _, err := s.CleanupDeletedDocumentFiles(ctx); err != nil {
+					logger.WarnContext(ctx, "Failed to clean up deleted document files", zap.Error(err))
+				}
+				if _, err := s.ExpireStaleClaims(ctx, s.reviewClaimTTL()); err != nil {
+					logger.WarnContext(ctx, "Failed to release stale review claims", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// ExpireOverdueDocuments moves approved documents whose expiry date has passed to
+// expired, records a system history entry for each and re-evaluates the affected
+// drivers. Only approved documents are matched, so running it again is a no-op.
+func (s *Service) ExpireOverdueDocuments(ctx context.Context) (*ExpirySweepResult, error) {
+	expired, err := s.repo.ExpireOverdueDocuments(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to expire documents", err)
+	}
+
+	result := &ExpirySweepResult{ExpiredDocuments: len(expired), DriversBlocked: []uuid.UUID{}}
+	drivers := make(map[uuid.UUID]map[uuid.UUID]DocumentStatus)
+	for _, doc := range expired {
+		s.logHistory(ctx, doc.ID, "expired", string(StatusApproved), string(StatusExpired), nil, true, "Document automatically expired")
+		if drivers[doc.DriverID] == nil {
+			drivers[doc.DriverID] = make(map[uuid.UUID]DocumentStatus)
+		}
+		drivers[doc.DriverID][doc.ID] = StatusApproved
+	}
+	result.DriversAffected = len(drivers)
+
+	for driverID, previous := range drivers {
+		status, err := s.recomputeVerification(ctx, driverID, previous)
+		if err != nil {
+			continue
+		}
+		if !status.CanDrive {
+			result.DriversBlocked = append(result.DriversBlocked, driverID)
+			logger.WarnContext(ctx, "Driver can no longer drive after document expiry", zap.String("driver_id", driverID.String()))
+		}
+	}
+
+	if result.ExpiredDocuments > 0 {
+		logger.InfoContext(ctx, "Expired overdue documents",
+			zap.Int("documents", result.ExpiredDocuments),
+			zap.Int("drivers_affected", result.DriversAffected),
+			zap.Int("drivers_blocked", len(result.DriversBlocked)),
+		)
+	}
+
+	return result, nil
+}
+
+// SendExpiryReminders notifies drivers about approved documents that expire within
+// the configured window. A driver reminded within the cooldown is skipped, and a
+// document is never reminded about twice within a day. All of a driver's expiring
+// documents are handled together, and ExpiryWarningSentAt is stamped once per driver.
+func (s *Service) SendExpiryReminders(ctx context.Context) (*ExpiryReminderResult, error) {
+	if s.notifier == nil {
+		return nil, common.NewInternalError("expiry reminders are not configured", nil)
+	}
+
+	expiring, err := s.GetExpiringDocuments(ctx, s.config.ExpiryReminderDays)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get expiring documents", err)
+	}
+
+	cooldown := s.config.ExpiryReminderCooldown
+	if cooldown <= 0 {
+		cooldown = defaultExpiryReminderCooldown
+	}
+
+	// Group by driver, keeping the soonest-expiring order
+	var driverOrder []uuid.UUID
+	byDriver := make(map[uuid.UUID][]*ExpiringDocument)
+	for _, exp := range expiring {
+		driverID := exp.Document.DriverID
+		if _, ok := byDriver[driverID]; !ok {
+			driverOrder = append(driverOrder, driverID)
+		}
+		byDriver[driverID] = append(byDriver[driverID], exp)
+	}
+
+	now := time.Now()
+	result := &ExpiryReminderResult{}
+	for _, driverID := range driverOrder {
+		docs := byDriver[driverID]
+
+		status, _ := s.repo.GetDriverVerificationStatus(ctx, driverID)
+		if status != nil && status.ExpiryWarningSentAt != nil && now.Sub(*status.ExpiryWarningSentAt) < cooldown {
+			result.SkippedCooldown += len(docs)
+			continue
+		}
+
+		sent := false
+		for _, exp := range docs {
+			if s.remindedSince(ctx, exp.Document.ID, now.Add(-expiryReminderRepeatWindow)) {
+				result.AlreadyReminded++
+				continue
+			}
+
+			reminder := newExpiryReminder(exp)
+			if err := s.notifier.NotifyDocumentExpiring(ctx, reminder); err != nil {
+				logger.WarnContext(ctx, "Failed to send document expiry reminder",
+					zap.String("driver_id", driverID.String()),
+					zap.String("document_id", exp.Document.ID.String()),
+					zap.Error(err),
+				)
+				result.Failed++
+				continue
+			}
+
+			s.logHistory(ctx, exp.Document.ID, "expiry_reminder_sent", "", "", nil, true,
+				fmt.Sprintf("%s reminder sent, %d days until expiry", reminder.Severity, reminder.DaysUntilExpiry))
+			result.Sent++
+			sent = true
+		}
+
+		if sent {
+			if err := s.repo.MarkExpiryWarningSent(ctx, driverID, now); err != nil {
+				logger.WarnContext(ctx, "Failed to record expiry warning", zap.String("driver_id", driverID.String()), zap.Error(err))
+			}
+		}
+	}
+
+	if result.Sent > 0 || result.Failed > 0 {
+		logger.InfoContext(ctx, "Sent document expiry reminders",
+			zap.Int("sent", result.Sent),
+			zap.Int("skipped_cooldown", result.SkippedCooldown),
+			zap.Int("already_reminded", result.AlreadyReminded),
+			zap.Int("failed", result.Failed),
+		)
+	}
+
+	return result, nil
+}
+
+// remindedSince reports whether an expiry reminder was sent for a document after since
+func (s *Service) remindedSince(ctx context.Context, documentID uuid.UUID, since time.Time) bool {
+	history, err := s.repo.GetDocumentHistory(ctx, documentID)
+	if err != nil {
+		return false
+	}
+	for _, h := range history {
+		if h.Action == "expiry_reminder_sent" && h.CreatedAt.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// newExpiryReminder builds the reminder for an expiring document, mapping its
+// urgency bucket to the message severity
+func newExpiryReminder(exp *ExpiringDocument) *ExpiryReminder {
+	reminder := &ExpiryReminder{
+		DriverID:        exp.Document.DriverID,
+		UserID:          exp.DriverUserID,
+		DocumentID:      exp.Document.ID,
+		DocumentType:    exp.DocumentType,
+		ExpiryDate:      exp.Document.ExpiryDate,
+		DaysUntilExpiry: exp.DaysUntilExpiry,
+	}
+
+	switch exp.Urgency {
+	case "expired", "critical":
+		reminder.Severity = ReminderSeverityCritical
+	case "warning":
+		reminder.Severity = ReminderSeverityWarning
+	default:
+		reminder.Severity = ReminderSeverityInfo
+	}
+
+	switch {
+	case exp.DaysUntilExpiry < 0:
+		reminder.Title = fmt.Sprintf("Your %s has expired", exp.DocumentType)
+		reminder.Message = fmt.Sprintf("Upload a renewed %s to keep driving.", exp.DocumentType)
+	case exp.DaysUntilExpiry == 0:
+		reminder.Title = fmt.Sprintf("Your %s expires today", exp.DocumentType)
+		reminder.Message = fmt.Sprintf("Upload a renewed %s today to keep driving.", exp.DocumentType)
+	default:
+		reminder.Title = fmt.Sprintf("Your %s expires in %d days", exp.DocumentType, exp.DaysUntilExpiry)
+		reminder.Message = fmt.Sprintf("Upload a renewed %s before it expires to avoid interruptions.", exp.DocumentType)
+	}
+
+	return reminder
+}
+
 // ========================================
 // OCR
 // ========================================
@@ -571,8 +1568,15 @@ func (s *Service) scheduleOCR(ctx context.Context, documentID uuid.UUID, priorit
 	return s.repo.CreateOCRJob(ctx, job)
 }
 
-// ProcessOCRResult processes the result of OCR and updates the document
+// ProcessOCRResult stores the result of OCR on the document, then approves a
+// pending document the result vouches for or leaves it for manual review
 func (s *Service) ProcessOCRResult(ctx context.Context, documentID uuid.UUID, result *OCRResult) error {
+	// Load before the OCR details overwrite what the driver submitted
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to load document for OCR review", zap.String("document_id", documentID.String()), zap.Error(err))
+	}
+
 	ocrData := map[string]interface{}{
 		"document_number":   result.DocumentNumber,
 		"full_name":         result.FullName,
@@ -595,10 +1599,16 @@ func (s *Service) ProcessOCRResult(ctx context.Context, documentID uuid.UUID, re
 	docNum := nilIfEmpty(result.DocumentNumber)
 	authority := nilIfEmpty(result.IssuingAuthority)
 	if err := s.repo.UpdateDocumentDetails(ctx, documentID, docNum, result.IssueDate, result.ExpiryDate, authority); err != nil {
-		logger.Warn("Failed to update document details from OCR", zap.Error(err))
+		logger.WarnContext(ctx, "Failed to update document details from OCR", zap.Error(err))
+	}
+
+	if err := s.repo.CreateHistory(ctx, ocrHistoryEntry(documentID, result)); err != nil {
+		logger.WarnContext(ctx, "Failed to create history entry", zap.Error(err))
 	}
 
-	s.logHistory(ctx, documentID, "ocr_processed", "", "", nil, true, nil)
+	if doc != nil {
+		s.routeOCRReview(ctx, doc, result)
+	}
 
 	return nil
 }
@@ -637,8 +1647,277 @@ func (s *Service) logHistory(ctx context.Context, documentID uuid.UUID, action,
 	}
 
 	if err := s.repo.CreateHistory(ctx, history); err != nil {
-		logger.Warn("Failed to create history entry", zap.Error(err))
+		logger.WarnContext(ctx, "Failed to create history entry", zap.Error(err))
+	}
+}
+
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+// errFileTooLarge is returned while streaming an upload that exceeds the size limit
+var errFileTooLarge = errors.New("file exceeds maximum size")
+
+// uploadFile stores an uploaded file. When the size isn't known up front the file
+// is streamed, capped at the configured maximum file size.
+func (s *Service) uploadFile(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*storage.UploadResult, error) {
+	if size > 0 {
+		return s.storage.Upload(ctx, key, reader, size, contentType)
+	}
+
+	maxSize := int64(s.config.MaxFileSizeMB) * 1024 * 1024
+	return s.storage.UploadStream(ctx, key, &sizeLimitedReader{reader: reader, remaining: maxSize}, contentType)
+}
+
+// preparedUpload is an upload after image preprocessing
+type preparedUpload struct {
+	reader       io.Reader
+	size         int64
+	contentType  string
+	data         []byte // The file to store, when it was read into memory
+	original     []byte // The upload as received, when it was replaced by a processed image
+	originalType string
+	pageCount    *int              // Pages in a PDF upload
+	pdf          *checkedPDFReader // Checks a streamed PDF as it's stored
+}
+
+// pages returns the page count of a PDF upload, once it has been stored
+func (u *preparedUpload) pages() *int {
+	if u.pdf != nil && u.pdf.checked && u.pdf.err == nil {
+		return &u.pdf.pages
 	}
+	return u.pageCount
+}
+
+// preprocessUpload runs image uploads through the image preprocessor and checks
+// PDFs. Other files pass through untouched, as do images the preprocessor can't
+// read; images beyond the pixel limit are rejected, as are PDFs that are
+// corrupt, encrypted or have too many pages. PDFs are only read into memory
+// when a thumbnail is rasterized from them; otherwise they're checked as they
+// stream to storage, failing the upload.
+func (s *Service) preprocessUpload(reader io.Reader, size int64, contentType string) (*preparedUpload, error) {
+	upload := &preparedUpload{reader: reader, size: size, contentType: contentType}
+	isImage := s.imagePreprocessor != nil && strings.HasPrefix(storage.BaseMimeType(contentType), "image/")
+	isPDF := storage.BaseMimeType(contentType) == "application/pdf"
+	if !isImage && !isPDF {
+		return upload, nil
+	}
+	if isPDF && s.pdfRasterizer == nil {
+		upload.pdf = &checkedPDFReader{reader: reader, size: size, scanner: newPDFScanner(), check: s.checkPDF}
+		upload.reader = upload.pdf
+		return upload, nil
+	}
+
+	maxSize := int64(s.config.MaxFileSizeMB) * 1024 * 1024
+	data, err := io.ReadAll(&sizeLimitedReader{reader: reader, remaining: maxSize})
+	if err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, fmt.Sprintf("file size exceeds maximum of %d MB", s.config.MaxFileSizeMB), nil)
+		}
+		return nil, common.NewBadRequestError("failed to read uploaded file", err)
+	}
+	upload.reader, upload.size, upload.data = bytes.NewReader(data), int64(len(data)), data
+	if isPDF {
+		pages, err := s.checkPDF(inspectPDF(data))
+		if err != nil {
+			return nil, err
+		}
+		upload.pageCount = &pages
+		return upload, nil
+	}
+
+	processed, err := s.imagePreprocessor.Preprocess(data, storage.BaseMimeType(contentType))
+	if err != nil {
+		if errors.Is(err, errImageTooLarge) {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge, "image dimensions are too large", nil)
+		}
+		logger.Warn("Storing image without preprocessing", zap.String("mime_type", contentType), zap.Error(err))
+		return upload, nil
+	}
+	if processed == nil {
+		return upload, nil
+	}
+
+	upload.original, upload.originalType = data, contentType
+	upload.reader, upload.size, upload.contentType = bytes.NewReader(processed.Data), int64(len(processed.Data)), processed.MimeType
+	upload.data = processed.Data
+	return upload, nil
+}
+
+// checkPDF returns the page count of an inspected PDF, refusing PDFs that can't
+// be read, are password protected or have more pages than allowed
+func (s *Service) checkPDF(info *pdfInfo, err error) (int, error) {
+	if err != nil {
+		if errors.Is(err, errPDFEncrypted) {
+			return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeEncryptedPDF, "PDF is password protected; upload a copy without a password", nil)
+		}
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeInvalidPDF, "PDF is damaged or incomplete; upload it again", nil)
+	}
+
+	maxPages := s.config.MaxPDFPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPDFPages
+	}
+	if info.Pages > maxPages {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeTooManyPages, fmt.Sprintf("PDF has %d pages; at most %d are allowed", info.Pages, maxPages), nil)
+	}
+	return info.Pages, nil
+}
+
+// checkedPDFReader passes a PDF upload through a pdfScanner on its way to
+// storage. At the end of the file it runs check, failing the read, and with it
+// the upload, if the PDF is refused.
+type checkedPDFReader struct {
+	reader  io.Reader
+	size    int64 // The upload's size, or 0 when it isn't known
+	read    int64
+	scanner *pdfScanner
+	check   func(*pdfInfo, error) (int, error)
+	checked bool
+	pages   int
+	err     error
+}
+
+func (r *checkedPDFReader) Read(b []byte) (int, error) {
+	if r.checked {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return r.reader.Read(b)
+	}
+
+	n, err := r.reader.Read(b)
+	_, _ = r.scanner.Write(b[:n])
+	r.read += int64(n)
+	// Backends given the size may stop reading without waiting for EOF
+	if err == io.EOF || (r.size > 0 && r.read >= r.size) {
+		r.checked = true
+		r.pages, r.err = r.check(r.scanner.finish())
+		if r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// Seek lets a storage retry replay the upload when the underlying reader can
+// seek. Rewinding to the start of the upload checks the PDF over again.
+func (r *checkedPDFReader) Seek(offset int64, whence int) (int64, error) {
+	pos, restarted, err := seekPassThrough(r.reader, r.read, offset, whence)
+	if restarted {
+		r.read, r.scanner = 0, newPDFScanner()
+		r.checked, r.pages, r.err = false, 0, nil
+	}
+	return pos, err
+}
+
+// seekPassThrough seeks the reader under a pass-through reader that has read
+// read bytes of it. Only the current position and the position the pass-through
+// reader started at can be sought, as it can't make sense of a partial upload;
+// restarted reports a seek back to the start.
+func seekPassThrough(reader io.Reader, read, offset int64, whence int) (pos int64, restarted bool, err error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return 0, false, errors.New("upload can't be rewound")
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false, err
+	}
+	start := current - read
+
+	pos, err = seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, false, err
+	}
+	switch pos {
+	case start:
+		return pos, true, nil
+	case current:
+		return pos, false, nil
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return current, false, fmt.Errorf("upload can only be rewound to its start, not to offset %d", pos-start)
+}
+
+// keepOriginal stores the upload behind a processed image when originals are
+// kept, returning its key. The processed image is enough to review the document,
+// so failing to keep the original doesn't fail the upload.
+func (s *Service) keepOriginal(ctx context.Context, upload *preparedUpload, fileKey, fileName string) *string {
+	if !s.config.KeepOriginalImages || upload.original == nil {
+		return nil
+	}
+
+	key := originalFileKey(fileKey, fileName)
+	if _, err := s.storage.Upload(ctx, key, bytes.NewReader(upload.original), int64(len(upload.original)), upload.originalType); err != nil {
+		logger.WarnContext(ctx, "Failed to keep original image", zap.String("file_key", fileKey), zap.Error(err))
+		return nil
+	}
+	return &key
+}
+
+// sizeLimitedReader fails with errFileTooLarge once more than remaining bytes are read
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, errFileTooLarge
+	}
+	// Read at most one byte past the limit so an oversized file is detected
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, errFileTooLarge
+	}
+	return n, err
+}
+
+// allowedMimeTypes returns the upload allow-list for a document type, falling
+// back to the service-wide list when the type doesn't define its own
+func (s *Service) allowedMimeTypes(docType *DocumentType) []string {
+	if docType != nil && len(docType.AllowedMimeTypes) > 0 {
+		return docType.AllowedMimeTypes
+	}
+	return s.config.AllowedMimeTypes
+}
+
+// verifyContentType sniffs an uploaded file and rejects it when the detected type
+// matches neither the declared type nor the allow-list. It returns the content
+// type to store and a reader that still yields the whole file.
+func (s *Service) verifyContentType(reader io.Reader, declared string, allowed []string) (string, io.Reader, error) {
+	detected, reader, err := storage.DetectContentType(reader)
+	if err != nil {
+		return "", nil, common.NewBadRequestError("failed to read uploaded file", err)
+	}
+
+	if detected == storage.BaseMimeType(declared) {
+		return declared, reader, nil
+	}
+	if storage.ValidateMimeType(detected, allowed) {
+		return detected, reader, nil
+	}
+
+	logger.Warn("Rejected upload with mismatched content type",
+		zap.String("declared", declared),
+		zap.String("detected", detected),
+	)
+	return "", nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileContentMismatch, "file content does not match declared type", nil)
+}
+
+// isExpired reports whether an expiry date falls before today (UTC)
+func isExpired(expiry *time.Time, now time.Time) bool {
+	return expiry != nil && expiry.Before(now.UTC().Truncate(24*time.Hour))
 }
 
 func nilIfEmpty(s string) *string {
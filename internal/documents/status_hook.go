@@ -0,0 +1,75 @@
+package documents
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultStatusHookQueueSize is used when StatusHookQueueSize is not set
+	defaultStatusHookQueueSize = 100
+	// statusHookTimeout bounds a single call of the status change hook
+	statusHookTimeout = 30 * time.Second
+)
+
+var statusChangesDropped = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "documents_verification_status_changes_dropped_total",
+	Help: "Verification status changes dropped because the status change hook queue was full",
+})
+
+// SetStatusChangeHook sets the hook told about driver verification status changes
+// and starts delivering them. Changes are queued and handed to the hook one at a
+// time in the background, so a slow hook never holds up reviews; when the queue
+// is full further changes are dropped, logged and counted. Call it once, during
+// startup.
+func (s *Service) SetStatusChangeHook(hook StatusChangeHook) {
+	queueSize := s.config.StatusHookQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultStatusHookQueueSize
+	}
+
+	s.statusHook = hook
+	s.statusChanges = make(chan *VerificationStatusChange, queueSize)
+
+	go func(changes <-chan *VerificationStatusChange) {
+		for change := range changes {
+			s.runStatusHook(hook, change)
+		}
+	}(s.statusChanges)
+}
+
+// runStatusHook calls the hook for one change, containing any panic so that one
+// bad delivery doesn't stop the rest
+func (s *Service) runStatusHook(hook StatusChangeHook, change *VerificationStatusChange) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusHookTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Verification status hook panicked",
+				zap.String("driver_id", change.DriverID.String()),
+				zap.Any("panic", r),
+			)
+		}
+	}()
+
+	hook(ctx, change)
+}
+
+// queueStatusChange hands a change to the hook without blocking
+func (s *Service) queueStatusChange(change *VerificationStatusChange) {
+	select {
+	case s.statusChanges <- change:
+	default:
+		statusChangesDropped.Inc()
+		logger.Warn("Verification status hook queue is full, dropping status change",
+			zap.String("driver_id", change.DriverID.String()),
+			zap.String("old_status", string(change.OldStatus)),
+			zap.String("new_status", string(change.NewStatus)),
+		)
+	}
+}
@@ -0,0 +1,140 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"path"
+	"strings"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultThumbnailMaxDimension is used when ThumbnailMaxDimension is not set
+	defaultThumbnailMaxDimension = 320
+	// thumbnailJPEGQuality is the quality thumbnails are stored at; they are
+	// only for review lists, so detail matters less than size
+	thumbnailJPEGQuality = 70
+)
+
+// PDFRasterizer renders the first page of a PDF so it can be thumbnailed. The
+// standard library can't read PDFs, so PDFs only get thumbnails when one is set.
+type PDFRasterizer interface {
+	RasterizeFirstPage(data []byte) (image.Image, error)
+}
+
+// SetPDFRasterizer sets the rasterizer used to thumbnail PDF documents. A nil
+// rasterizer leaves PDFs without thumbnails.
+func (s *Service) SetPDFRasterizer(rasterizer PDFRasterizer) {
+	s.pdfRasterizer = rasterizer
+}
+
+// makeThumbnail renders a small JPEG preview of a document file. It returns nil
+// for files it can't thumbnail: WebP images, and PDFs without a rasterizer.
+func (s *Service) makeThumbnail(data []byte, mimeType string) ([]byte, error) {
+	maxDimension := s.config.ThumbnailMaxDimension
+	if maxDimension <= 0 {
+		maxDimension = defaultThumbnailMaxDimension
+	}
+	maxPixels := s.config.ImageMaxPixels
+	if maxPixels <= 0 {
+		maxPixels = defaultImageMaxPixels
+	}
+
+	mimeType = strings.ToLower(storage.BaseMimeType(mimeType))
+	var img image.Image
+	orientation := 1
+	switch mimeType {
+	case "image/jpeg", "image/png":
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image header: %w", err)
+		}
+		if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+			return nil, errImageTooLarge
+		}
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		if mimeType == "image/jpeg" {
+			orientation = jpegOrientation(data)
+		}
+	case "application/pdf":
+		if s.pdfRasterizer == nil {
+			return nil, nil
+		}
+		var err error
+		img, err = s.pdfRasterizer.RasterizeFirstPage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rasterize pdf: %w", err)
+		}
+	default:
+		return nil, nil
+	}
+
+	rgba := toRGBA(img)
+	rgba = orient(rgba, orientation)
+	rgba = downscale(rgba, maxDimension)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnailFileKey is where the thumbnail of a file is kept, next to the file
+func thumbnailFileKey(fileKey string) string {
+	return strings.TrimSuffix(fileKey, path.Ext(fileKey)) + "_thumb.jpg"
+}
+
+// storeThumbnail generates and stores the thumbnail of a document file,
+// returning its URL and key. Reviewers can always open the full file, so a
+// thumbnail that can't be made or stored is logged and skipped.
+func (s *Service) storeThumbnail(ctx context.Context, fileKey string, data []byte, mimeType string) (*string, *string) {
+	if data == nil {
+		return nil, nil
+	}
+
+	thumb, err := s.makeThumbnail(data, mimeType)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to generate document thumbnail", zap.String("file_key", fileKey), zap.Error(err))
+		return nil, nil
+	}
+	if thumb == nil {
+		return nil, nil
+	}
+
+	key := thumbnailFileKey(fileKey)
+	result, err := s.storage.Upload(ctx, key, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg")
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to store document thumbnail", zap.String("file_key", fileKey), zap.Error(err))
+		return nil, nil
+	}
+	return &result.URL, &key
+}
+
+// ensureThumbnail gives a document without a thumbnail one from its file, for
+// documents whose file wasn't buffered on upload
+func (s *Service) ensureThumbnail(ctx context.Context, doc *DriverDocument, data []byte, mimeType string) {
+	if doc.ThumbnailKey != nil {
+		return
+	}
+
+	url, key := s.storeThumbnail(ctx, doc.FileKey, data, mimeType)
+	if key == nil {
+		return
+	}
+	if err := s.repo.UpdateDocumentThumbnail(ctx, doc.ID, *url, *key); err != nil {
+		logger.WarnContext(ctx, "Failed to record document thumbnail", zap.String("document_id", doc.ID.String()), zap.Error(err))
+		_ = s.storage.Delete(ctx, *key)
+		return
+	}
+	doc.ThumbnailURL, doc.ThumbnailKey = url, key
+}
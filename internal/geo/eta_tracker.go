@@ -9,8 +9,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/richxcame/ride-hailing/pkg/httpclient"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/realtime"
 	redisClient "github.com/richxcame/ride-hailing/pkg/redis"
 	"go.uber.org/zap"
 )
@@ -48,16 +48,16 @@ type ETAUpdate struct {
 // ETATracker recalculates and broadcasts ETA when drivers move during active rides.
 type ETATracker struct {
 	redis          redisClient.ClientInterface
-	realtimeClient *httpclient.Client
+	realtimeClient realtime.Client
 	mu             sync.Mutex
 	lastUpdate     map[string]time.Time // driverID -> last ETA broadcast time
 }
 
 // NewETATracker creates a new ETA tracker.
-func NewETATracker(redis redisClient.ClientInterface, realtimeServiceURL string) *ETATracker {
+func NewETATracker(redis redisClient.ClientInterface, realtimeClient realtime.Client) *ETATracker {
 	return &ETATracker{
 		redis:          redis,
-		realtimeClient: httpclient.NewClient(realtimeServiceURL),
+		realtimeClient: realtimeClient,
 		lastUpdate:     make(map[string]time.Time),
 	}
 }
@@ -136,28 +136,18 @@ func (t *ETATracker) OnDriverLocationUpdate(ctx context.Context, driverID uuid.U
 		UpdatedAt:       time.Now().UTC().Format(time.RFC3339),
 	}
 
-	t.broadcastETAUpdate(ctx, rideInfo.RiderID.String(), rideInfo.RideID.String(), update)
+	t.broadcastETAUpdate(ctx, rideInfo.RiderID, rideInfo.RideID, update)
 }
 
-func (t *ETATracker) broadcastETAUpdate(ctx context.Context, riderID, rideID string, update *ETAUpdate) {
+func (t *ETATracker) broadcastETAUpdate(ctx context.Context, riderID, rideID uuid.UUID, update *ETAUpdate) {
 	// Broadcast to rider via realtime service
-	payload := map[string]interface{}{
-		"user_id": riderID,
-		"type":    "eta_update",
-		"data":    update,
-	}
-
-	_, err := t.realtimeClient.Post(ctx, "/api/v1/internal/broadcast/user", payload, nil)
+	err := t.realtimeClient.BroadcastToUser(ctx, riderID, realtime.Event{Type: realtime.EventETAUpdate, Data: update})
 	if err != nil {
 		logger.DebugContext(ctx, "failed to broadcast ETA update", zap.Error(err))
 	}
 
-	// Also broadcast to ride room
-	ridePayload := map[string]interface{}{
-		"ride_id": rideID,
-		"data":    update,
-	}
-	_, err = t.realtimeClient.Post(ctx, "/api/v1/internal/broadcast/ride", ridePayload, nil)
+	// Also broadcast to ride room, as a plain ride update
+	err = t.realtimeClient.BroadcastRideUpdate(ctx, rideID, realtime.Event{Data: update})
 	if err != nil {
 		logger.DebugContext(ctx, "failed to broadcast ETA to ride room", zap.Error(err))
 	}
@@ -346,6 +346,8 @@ func TestHandler_GetDriverLocation_Success(t *testing.T) {
 	locationJSON, _ := json.Marshal(expectedLocation)
 	mockRedis.On("GetString", mock.Anything, "driver:location:"+driverID.String()).
 		Return(string(locationJSON), nil)
+	mockRedis.On("GetString", mock.Anything, "driver:eta:"+driverID.String()).
+		Return("", errors.New("key not found"))
 
 	c, w := setupTestContext("GET", "/api/v1/geo/drivers/"+driverID.String()+"/location", nil)
 	c.Params = gin.Params{{Key: "id", Value: driverID.String()}}
@@ -21,6 +21,7 @@ const (
 	driverLocationTTL    = 5 * time.Minute
 	driverGeoIndexKey    = "drivers:geo:index" // Redis GEO key for all active drivers
 	driverStatusPrefix   = "driver:status:"
+	driverETAPrefix      = "driver:eta:"
 	searchRadiusKm       = 10.0 // Search radius in kilometers
 
 	// H3-based Redis keys
@@ -32,14 +33,25 @@ const (
 
 // DriverLocation represents a driver's location
 type DriverLocation struct {
-	DriverID  uuid.UUID `json:"driver_id"`
-	Latitude  float64   `json:"latitude"`
-	Longitude float64   `json:"longitude"`
-	H3Cell    string    `json:"h3_cell"`             // H3 cell at matching resolution
-	Heading   float64   `json:"heading,omitempty"`   // Direction the driver is facing (0-360)
-	Speed     float64   `json:"speed,omitempty"`     // Speed in km/h
-	Status    string    `json:"status,omitempty"`    // Driver availability status (available, busy, offline)
-	Timestamp time.Time `json:"timestamp"`
+	DriverID  uuid.UUID  `json:"driver_id"`
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	H3Cell    string     `json:"h3_cell"`           // H3 cell at matching resolution
+	Heading   float64    `json:"heading,omitempty"` // Direction the driver is facing (0-360)
+	Speed     float64    `json:"speed,omitempty"`   // Speed in km/h
+	Status    string     `json:"status,omitempty"`  // Driver availability status (available, busy, offline)
+	Timestamp time.Time  `json:"timestamp"`
+	ETA       *DriverETA `json:"eta,omitempty"` // Latest ETA on the driver's active ride
+}
+
+// DriverETA is a driver's latest estimated arrival at the pickup or dropoff of
+// the ride they are on
+type DriverETA struct {
+	RideID      string    `json:"ride_id"`
+	Destination string    `json:"destination"` // pickup or dropoff
+	ETASeconds  int       `json:"eta_seconds"`
+	DistanceKm  float64   `json:"distance_km"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // SurgeInfo represents surge pricing data for a zone
@@ -179,10 +191,45 @@ func (s *Service) GetDriverLocation(ctx context.Context, driverID uuid.UUID) (*D
 	if err != nil {
 		return nil, common.NewInternalServerError("failed to unmarshal location data")
 	}
+	location.ETA = s.getDriverETA(ctx, driverID)
 
 	return location, nil
 }
 
+// SaveDriverETA stores a driver's latest ETA next to their location. It expires
+// with the location, so a driver who stops reporting doesn't keep a stale ETA.
+func (s *Service) SaveDriverETA(ctx context.Context, driverID uuid.UUID, eta *DriverETA) error {
+	data, err := json.Marshal(eta)
+	if err != nil {
+		return common.NewInternalServerError("failed to marshal driver ETA")
+	}
+
+	key := fmt.Sprintf("%s%s", driverETAPrefix, driverID.String())
+	if err := s.redis.SetWithExpiration(ctx, key, data, driverLocationTTL); err != nil {
+		return common.NewInternalServerError("failed to store driver ETA")
+	}
+	return nil
+}
+
+// ClearDriverETA removes a driver's ETA once they are no longer on a ride
+func (s *Service) ClearDriverETA(ctx context.Context, driverID uuid.UUID) error {
+	return s.redis.Delete(ctx, fmt.Sprintf("%s%s", driverETAPrefix, driverID.String()))
+}
+
+// getDriverETA returns a driver's stored ETA, or nil if there is none
+func (s *Service) getDriverETA(ctx context.Context, driverID uuid.UUID) *DriverETA {
+	data, err := s.redis.GetString(ctx, fmt.Sprintf("%s%s", driverETAPrefix, driverID.String()))
+	if err != nil || data == "" {
+		return nil
+	}
+
+	var eta DriverETA
+	if err := json.Unmarshal([]byte(data), &eta); err != nil {
+		return nil
+	}
+	return &eta
+}
+
 // FindNearbyDrivers finds drivers near a given location using Redis GEO,
 // sorted by distance (closest first).
 // Uses batch fetch (MGetStrings) to reduce Redis round-trips from O(n) to O(1).
@@ -12,6 +12,7 @@ import (
 	"github.com/richxcame/ride-hailing/test/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_UpdateDriverLocation_Success(t *testing.T) {
@@ -114,6 +115,8 @@ func TestService_GetDriverLocation_Success(t *testing.T) {
 	locationJSON, _ := json.Marshal(expectedLocation)
 	mockRedis.On("GetString", ctx, "driver:location:"+driverID.String()).
 		Return(string(locationJSON), nil)
+	mockRedis.On("GetString", ctx, "driver:eta:"+driverID.String()).
+		Return("", errors.New("key not found"))
 
 	// Act
 	location, err := service.GetDriverLocation(ctx, driverID)
@@ -124,6 +127,33 @@ func TestService_GetDriverLocation_Success(t *testing.T) {
 	assert.Equal(t, driverID, location.DriverID)
 	assert.Equal(t, 37.7749, location.Latitude)
 	assert.Equal(t, -122.4194, location.Longitude)
+	assert.Nil(t, location.ETA)
+	mockRedis.AssertExpectations(t)
+}
+
+func TestService_GetDriverLocation_IncludesETA(t *testing.T) {
+	// Arrange
+	mockRedis := new(mocks.MockRedisClient)
+	service := NewService(mockRedis)
+	ctx := context.Background()
+	driverID := uuid.New()
+
+	locationJSON, _ := json.Marshal(&DriverLocation{DriverID: driverID, Latitude: 37.7749, Longitude: -122.4194})
+	etaJSON, _ := json.Marshal(&DriverETA{RideID: "ride-1", Destination: "pickup", ETASeconds: 240, DistanceKm: 1.6})
+	mockRedis.On("GetString", ctx, "driver:location:"+driverID.String()).
+		Return(string(locationJSON), nil)
+	mockRedis.On("GetString", ctx, "driver:eta:"+driverID.String()).
+		Return(string(etaJSON), nil)
+
+	// Act
+	location, err := service.GetDriverLocation(ctx, driverID)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, location.ETA)
+	assert.Equal(t, "ride-1", location.ETA.RideID)
+	assert.Equal(t, "pickup", location.ETA.Destination)
+	assert.Equal(t, 240, location.ETA.ETASeconds)
 	mockRedis.AssertExpectations(t)
 }
 
@@ -0,0 +1,106 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxAdjustmentPoints is the largest adjustment allowed without an override when none is configured
+const DefaultMaxAdjustmentPoints = 10000
+
+// AdjustPoints credits (positive delta) or debits (negative delta) a rider's
+// points on behalf of an admin, e.g. as a goodwill gesture or to claw back
+// fraudulently earned points. The adjustment is recorded with the admin and
+// reason, and no tier multiplier is applied. Debits never take the available
+// balance below zero. Adjustments larger than MaxAdjustmentPoints either way
+// are refused unless override is set.
+func (s *Service) AdjustPoints(ctx context.Context, riderID uuid.UUID, delta int, reason string, adminID uuid.UUID, override bool) (*PointsTransaction, error) {
+	reason = strings.TrimSpace(reason)
+	if delta == 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "adjustment must not be zero", nil)
+	}
+	if reason == "" {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "a reason is required", nil)
+	}
+	if adminID == uuid.Nil {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "admin ID is required", nil)
+	}
+
+	magnitude := delta
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	if magnitude > s.config.MaxAdjustmentPoints && !override {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeAdjustmentLimit,
+			fmt.Sprintf("adjustments over %d points require an override", s.config.MaxAdjustmentPoints), nil)
+	}
+
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
+	if err != nil {
+		return nil, common.NewNotFoundError("loyalty account not found", err)
+	}
+	if account.AvailablePoints+delta < 0 {
+		return nil, insufficientPointsForAdjustment(-delta, account.AvailablePoints)
+	}
+
+	tx := &PointsTransaction{
+		ID:              uuid.New(),
+		RiderID:         riderID,
+		TransactionType: TransactionAdjustment,
+		Points:          delta,
+		Source:          SourceAdjustment,
+		Description:     &reason,
+		Metadata: map[string]interface{}{
+			"admin_id": adminID.String(),
+			"reason":   reason,
+			"override": override,
+		},
+	}
+
+	if err := s.repo.ApplyPointsAdjustment(ctx, tx); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The balance dropped between the check above and the update
+			return nil, insufficientPointsForAdjustment(-delta, account.AvailablePoints)
+		}
+		return nil, common.NewInternalError("failed to adjust points", err)
+	}
+
+	logger.InfoContext(ctx, "Points adjusted by admin",
+		zap.String("rider_id", riderID.String()),
+		zap.String("admin_id", adminID.String()),
+		zap.Int("points", delta),
+		zap.Int("balance_after", tx.BalanceAfter),
+		zap.Bool("override", override),
+	)
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    adminID,
+		Action:     "loyalty.points_adjusted",
+		TargetType: "rider_loyalty",
+		TargetID:   riderID.String(),
+		Before:     map[string]interface{}{"available_points": account.AvailablePoints},
+		After: map[string]interface{}{
+			"available_points": tx.BalanceAfter,
+			"points":           delta,
+			"reason":           reason,
+			"override":         override,
+		},
+	})
+
+	return tx, nil
+}
+
+func insufficientPointsForAdjustment(debit, available int) error {
+	return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeInsufficientPoints,
+		fmt.Sprintf("insufficient points: cannot debit %d, have %d", debit, available), nil)
+}
@@ -0,0 +1,58 @@
+package loyalty
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// UseFreeCancellation uses one of the rider's free cancellations for a ride and
+// reports whether it was granted. It isn't granted when the rider's tier has
+// none left this tier period.
+func (s *Service) UseFreeCancellation(ctx context.Context, riderID, rideID uuid.UUID) (bool, error) {
+	return s.useTierBenefit(ctx, riderID, rideID, BenefitFreeCancellation)
+}
+
+// UseFreeUpgrade uses one of the rider's free upgrades for a ride and reports
+// whether it was granted. It isn't granted when the rider's tier has none left
+// this tier period.
+func (s *Service) UseFreeUpgrade(ctx context.Context, riderID, rideID uuid.UUID) (bool, error) {
+	return s.useTierBenefit(ctx, riderID, rideID, BenefitFreeUpgrade)
+}
+
+// useTierBenefit consumes one of a tier benefit's allowance. Asking again for the
+// same ride is granted without using another.
+func (s *Service) useTierBenefit(ctx context.Context, riderID, rideID uuid.UUID, benefit TierBenefit) (bool, error) {
+	if rideID == uuid.Nil {
+		return false, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "ride ID is required", nil)
+	}
+
+	granted, err := s.repo.ConsumeTierBenefit(ctx, riderID, rideID, benefit, time.Now())
+	if err != nil {
+		return false, common.NewInternalError("failed to use tier benefit", err)
+	}
+
+	logger.InfoContext(ctx, "Tier benefit requested",
+		zap.String("rider_id", riderID.String()),
+		zap.String("ride_id", rideID.String()),
+		zap.String("benefit", string(benefit)),
+		zap.Bool("granted", granted),
+	)
+
+	return granted, nil
+}
+
+// benefitsUsed returns the free cancellations and upgrades the rider has used
+// this tier period. Counters from a period that has ended no longer count; they
+// are reset when the next benefit is used.
+func benefitsUsed(account *RiderLoyalty, now time.Time) (int, int) {
+	if !account.TierPeriodEnd.IsZero() && !now.Before(account.TierPeriodEnd) {
+		return 0, 0
+	}
+	return account.FreeCancellationsUsed, account.FreeUpgradesUsed
+}
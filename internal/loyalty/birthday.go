@@ -0,0 +1,192 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// defaultBirthdaySweepInterval is used when StartBirthdayScheduler is given a non-positive interval
+const defaultBirthdaySweepInterval = time.Hour
+
+// DefaultBirthdayBonusPoints are the birthday gifts used when none are configured
+var DefaultBirthdayBonusPoints = map[TierName]int{
+	TierBronze:   100,
+	TierSilver:   200,
+	TierGold:     300,
+	TierPlatinum: 500,
+	TierDiamond:  750,
+}
+
+// birthdayBonusNamespace seeds the per-rider, per-year source IDs of birthday bonuses
+var birthdayBonusNamespace = uuid.MustParse("6f1c2a9e-4b7d-4e39-9a52-3d8f0c1b7e64")
+
+// birthdaySourceID identifies a rider's birthday bonus for a year. Together with
+// the unique index on birthday transactions it stops a second award that year.
+func birthdaySourceID(riderID uuid.UUID, year int) uuid.UUID {
+	return uuid.NewSHA1(birthdayBonusNamespace, []byte(fmt.Sprintf("%s:%d", riderID, year)))
+}
+
+// isLeapYear reports whether year has a Feb 29
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// isBirthday reports whether day is the birthday of someone born on birthDate.
+// Feb 29 birthdays are celebrated on Feb 28 in non-leap years.
+func isBirthday(birthDate, day time.Time) bool {
+	if birthDate.Month() == day.Month() && birthDate.Day() == day.Day() {
+		return true
+	}
+	return birthDate.Month() == time.February && birthDate.Day() == 29 &&
+		day.Month() == time.February && day.Day() == 28 && !isLeapYear(day.Year())
+}
+
+// birthdayDays returns the days of day's month whose birthdays are celebrated on day
+func birthdayDays(day time.Time) []int {
+	days := []int{day.Day()}
+	if day.Month() == time.February && day.Day() == 28 && !isLeapYear(day.Year()) {
+		days = append(days, 29)
+	}
+	return days
+}
+
+// birthdayBonusPoints returns the birthday gift for a tier
+func (s *Service) birthdayBonusPoints(tier *LoyaltyTier) int {
+	name := TierBronze
+	if tier != nil {
+		name = tier.Name
+	}
+	if points, ok := s.config.BirthdayBonusPoints[name]; ok {
+		return points
+	}
+	return s.config.BirthdayBonusPoints[TierBronze]
+}
+
+// SetBirthDate records the birth date used for a rider's birthday bonus
+func (s *Service) SetBirthDate(ctx context.Context, riderID uuid.UUID, birthDate time.Time) error {
+	if birthDate.After(time.Now()) {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "birth date cannot be in the future", nil)
+	}
+
+	if _, err := s.GetOrCreateLoyaltyAccount(ctx, riderID); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetBirthDate(ctx, riderID, calendarDay(birthDate)); err != nil {
+		return common.NewInternalError("failed to set birth date", err)
+	}
+	return nil
+}
+
+// AwardBirthdayBonus gives a rider their birthday gift if today is their birthday.
+// The gift depends on the rider's tier and is awarded at most once per year; it
+// returns the points credited, or 0 if this year's gift was already awarded.
+func (s *Service) AwardBirthdayBonus(ctx context.Context, riderID uuid.UUID, birthDate time.Time) (int, error) {
+	return s.awardBirthdayBonus(ctx, riderID, birthDate, time.Now())
+}
+
+func (s *Service) awardBirthdayBonus(ctx context.Context, riderID uuid.UUID, birthDate, today time.Time) (int, error) {
+	if !isBirthday(birthDate, today) {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "today is not the rider's birthday", nil)
+	}
+
+	sourceID := birthdaySourceID(riderID, today.Year())
+	awarded, err := s.repo.HasPointsTransaction(ctx, riderID, SourceBirthday, sourceID)
+	if err != nil {
+		return 0, common.NewInternalError("failed to check birthday bonus", err)
+	}
+	if awarded {
+		return 0, nil
+	}
+
+	account, err := s.GetOrCreateLoyaltyAccount(ctx, riderID)
+	if err != nil {
+		return 0, err
+	}
+
+	points := s.birthdayBonusPoints(account.CurrentTier)
+	if points <= 0 {
+		return 0, nil
+	}
+
+	return s.earnPoints(ctx, &EarnPointsRequest{
+		RiderID:     riderID,
+		Points:      points,
+		Source:      SourceBirthday,
+		SourceID:    &sourceID,
+		Description: "Happy birthday!",
+	}, s.config.BirthdayApplyMultiplier, nil)
+}
+
+// RunBirthdayBonuses awards birthday gifts to every rider whose birthday is on
+// today's date. Riders who already got this year's gift are skipped, so the
+// sweep can safely be re-run after a partial failure.
+func (s *Service) RunBirthdayBonuses(ctx context.Context, today time.Time) (*BirthdaySweepResult, error) {
+	riders, err := s.repo.GetRidersWithBirthday(ctx, today.Month(), birthdayDays(today))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewInternalError("failed to get riders with birthdays", err)
+	}
+
+	result := &BirthdaySweepResult{Date: calendarDay(today)}
+	for _, rider := range riders {
+		points, err := s.awardBirthdayBonus(ctx, rider.RiderID, rider.BirthDate, today)
+		switch {
+		case err != nil:
+			result.Failed++
+			logger.WarnContext(ctx, "Failed to award birthday bonus",
+				zap.String("rider_id", rider.RiderID.String()),
+				zap.Error(err),
+			)
+		case points == 0:
+			result.AlreadyAwarded++
+		default:
+			result.Awarded++
+			result.PointsAwarded += points
+		}
+	}
+
+	logger.InfoContext(ctx, "Birthday bonus sweep finished",
+		zap.Time("date", result.Date),
+		zap.Int("awarded", result.Awarded),
+		zap.Int("already_awarded", result.AlreadyAwarded),
+		zap.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// StartBirthdayScheduler runs the birthday bonus sweep for the current date every
+// interval until ctx is cancelled. Since sweeps skip riders who were already
+// awarded, running several times a day only retries earlier failures.
+func (s *Service) StartBirthdayScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultBirthdaySweepInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.InfoContext(ctx, "Birthday bonus scheduler started", zap.Duration("interval", interval))
+
+		_, _ = s.RunBirthdayBonuses(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				logger.InfoContext(ctx, "Birthday bonus scheduler stopped")
+				return
+			case <-ticker.C:
+				_, _ = s.RunBirthdayBonuses(ctx, time.Now())
+			}
+		}
+	}()
+}
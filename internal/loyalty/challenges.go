@@ -0,0 +1,164 @@
+package loyalty
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// Challenge types. A challenge's type selects what an activity contributes to it
+// and how its progress advances.
+const (
+	ChallengeTypeRides    = "rides"    // Number of rides
+	ChallengeTypeSpending = "spending" // Total fares, in whole currency units
+	ChallengeTypeDistance = "distance" // Total distance, in whole kilometers
+	ChallengeTypeStreak   = "streak"   // Consecutive days with at least one ride
+)
+
+// ChallengeEvent is a rider activity that can count towards challenges
+type ChallengeEvent struct {
+	Rides      int
+	Fare       float64
+	DistanceKm float64
+	// OccurredAt must be in the rider's local time zone so that streak days match
+	// the rider's calendar. Defaults to now.
+	OccurredAt time.Time
+}
+
+// ProgressFor returns how much an event counts towards a challenge of the given
+// type. Fares and distances are rounded per event to whole units. Unknown types
+// get nothing.
+func ProgressFor(challengeType string, event ChallengeEvent) int {
+	switch challengeType {
+	case ChallengeTypeRides:
+		return event.Rides
+	case ChallengeTypeSpending:
+		return int(math.Round(event.Fare))
+	case ChallengeTypeDistance:
+		return int(math.Round(event.DistanceKm))
+	case ChallengeTypeStreak:
+		if event.Rides > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// challengeEvaluator returns a challenge's new progress value after an activity
+// contributing amount on day
+type challengeEvaluator func(progress *ChallengeProgress, amount int, day time.Time) int
+
+// challengeEvaluators holds the challenge types that don't simply accumulate
+var challengeEvaluators = map[string]challengeEvaluator{
+	ChallengeTypeStreak: advanceStreak,
+}
+
+// accumulate adds an activity's contribution to the running total
+func accumulate(progress *ChallengeProgress, amount int, _ time.Time) int {
+	return progress.CurrentValue + amount
+}
+
+// advanceStreak extends a streak on the day after the last activity, leaves it
+// unchanged on the same day and restarts it at 1 after a missed day
+func advanceStreak(progress *ChallengeProgress, amount int, day time.Time) int {
+	if amount <= 0 {
+		return progress.CurrentValue
+	}
+	if progress.LastActivityDate == nil {
+		return 1
+	}
+
+	// last_activity_date is a DATE column, so its calendar fields are already the rider's local day
+	last := time.Date(progress.LastActivityDate.Year(), progress.LastActivityDate.Month(), progress.LastActivityDate.Day(), 0, 0, 0, 0, time.UTC)
+	switch daysSince := int(calendarDay(day).Sub(last).Hours() / 24); {
+	case daysSince <= 0:
+		return progress.CurrentValue
+	case daysSince == 1:
+		return progress.CurrentValue + 1
+	default:
+		return 1
+	}
+}
+
+// advanceChallenge applies an activity to a rider's progress on a challenge and
+// reports the new value and whether it completes the challenge
+func advanceChallenge(challenge *RiderChallenge, progress *ChallengeProgress, amount int, day time.Time) (int, bool) {
+	evaluate, ok := challengeEvaluators[challenge.ChallengeType]
+	if !ok {
+		evaluate = accumulate
+	}
+	newValue := evaluate(progress, amount, day)
+	return newValue, newValue >= challenge.TargetValue
+}
+
+// RecordChallengeEvent advances every active challenge an activity counts towards,
+// awarding the reward of any challenge it completes
+func (s *Service) RecordChallengeEvent(ctx context.Context, riderID uuid.UUID, event ChallengeEvent) error {
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
+	if err != nil {
+		return nil // No account, skip
+	}
+
+	challenges, err := s.repo.GetActiveChallenges(ctx, account.CurrentTierID)
+	if err != nil {
+		return common.NewInternalError("failed to get challenges", err)
+	}
+
+	day := event.OccurredAt
+	if day.IsZero() {
+		day = time.Now()
+	}
+
+	for _, challenge := range challenges {
+		amount := ProgressFor(challenge.ChallengeType, event)
+		if amount <= 0 {
+			continue
+		}
+		s.progressChallenge(ctx, riderID, challenge, amount, day)
+	}
+
+	return nil
+}
+
+// progressChallenge applies an activity to a rider's progress on one challenge.
+// Failures are skipped so they don't hold up the rider's other challenges.
+func (s *Service) progressChallenge(ctx context.Context, riderID uuid.UUID, challenge *RiderChallenge, amount int, day time.Time) {
+	progress, _ := s.repo.GetChallengeProgress(ctx, riderID, challenge.ID)
+
+	if progress == nil {
+		// Create new progress record
+		progress = &ChallengeProgress{
+			ID:          uuid.New(),
+			RiderID:     riderID,
+			ChallengeID: challenge.ID,
+		}
+		if err := s.repo.CreateChallengeProgress(ctx, progress); err != nil {
+			return
+		}
+	}
+
+	if progress.Completed {
+		return // Already completed
+	}
+
+	newValue, completed := advanceChallenge(challenge, progress, amount, day)
+
+	if err := s.repo.UpdateChallengeProgress(ctx, progress.ID, newValue, completed, calendarDay(day)); err != nil {
+		return
+	}
+
+	// Award points if completed
+	if completed {
+		_ = s.EarnPoints(ctx, &EarnPointsRequest{
+			RiderID:     riderID,
+			Points:      challenge.RewardPoints,
+			Source:      SourceChallenge,
+			SourceID:    &challenge.ID,
+			Description: fmt.Sprintf("Completed challenge: %s", challenge.Name),
+		})
+	}
+}
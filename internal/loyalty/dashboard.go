@@ -0,0 +1,136 @@
+package loyalty
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// DefaultDashboardCacheTTL is how long the dashboard is cached when no TTL is configured
+const DefaultDashboardCacheTTL = time.Minute
+
+const (
+	defaultDashboardWindowDays = 30
+	maxDashboardWindowDays     = 365
+	dashboardTopRewards        = 10
+)
+
+// Updated whenever the program totals are read, so it is as fresh as the last
+// dashboard or stats request
+var pointsLiability = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "loyalty_points_liability",
+	Help: "Available loyalty points across all riders, which the program owes if redeemed",
+})
+
+// dashboardCache holds recently built dashboards by window; the aggregates
+// scan every rider and transaction in the window
+type dashboardCache struct {
+	mu      sync.Mutex
+	entries map[int]*cachedDashboard
+}
+
+type cachedDashboard struct {
+	dashboard *LoyaltyDashboard
+	expiresAt time.Time
+}
+
+func newDashboardCache() *dashboardCache {
+	return &dashboardCache{entries: make(map[int]*cachedDashboard)}
+}
+
+func (c *dashboardCache) get(windowDays int, now time.Time) (*LoyaltyDashboard, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries[windowDays]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(cached.expiresAt) {
+		delete(c.entries, windowDays)
+		return nil, false
+	}
+	return cached.dashboard, true
+}
+
+func (c *dashboardCache) set(windowDays int, dashboard *LoyaltyDashboard, expiresAt time.Time) {
+	c.mu.Lock()
+	c.entries[windowDays] = &cachedDashboard{dashboard: dashboard, expiresAt: expiresAt}
+	c.mu.Unlock()
+}
+
+// GetLoyaltyDashboard returns the admin overview of the program: members per
+// tier and the outstanding points liability as they stand, and the points
+// earned and redeemed, the most redeemed rewards and challenge completion rates
+// over the last windowDays days (30 when zero). Dashboards are cached for
+// DashboardCacheTTL per window.
+func (s *Service) GetLoyaltyDashboard(ctx context.Context, windowDays int) (*LoyaltyDashboard, error) {
+	if windowDays == 0 {
+		windowDays = defaultDashboardWindowDays
+	}
+	if windowDays < 0 || windowDays > maxDashboardWindowDays {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "window must be between 1 and 365 days", nil)
+	}
+
+	now := time.Now()
+	if dashboard, ok := s.dashboards.get(windowDays, now); ok {
+		return dashboard, nil
+	}
+
+	since := now.AddDate(0, 0, -windowDays)
+	stats, err := s.repo.GetLoyaltyStats(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get loyalty stats", err)
+	}
+	flow, err := s.repo.GetPointsFlow(ctx, since)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get points flow", err)
+	}
+	rewards, err := s.repo.GetTopRedeemedRewards(ctx, since, dashboardTopRewards)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get top rewards", err)
+	}
+	challenges, err := s.repo.GetChallengeCompletions(ctx, since)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get challenge completions", err)
+	}
+
+	for _, challenge := range challenges {
+		if challenge.Participants > 0 {
+			challenge.CompletionRate = float64(challenge.Completed) / float64(challenge.Participants) * 100
+		}
+	}
+	if rewards == nil {
+		rewards = []*RewardRedemptionStats{}
+	}
+	if challenges == nil {
+		challenges = []*ChallengeCompletion{}
+	}
+
+	dashboard := &LoyaltyDashboard{
+		WindowDays:   windowDays,
+		Since:        since,
+		TotalMembers: stats.TotalMembers,
+		MembersByTier: map[TierName]int{
+			TierBronze:   stats.BronzeCount,
+			TierSilver:   stats.SilverCount,
+			TierGold:     stats.GoldCount,
+			TierPlatinum: stats.PlatinumCount,
+			TierDiamond:  stats.DiamondCount,
+		},
+		PointsLiability: stats.TotalPointsOutstanding,
+		PointsFlow:      flow,
+		TopRewards:      rewards,
+		Challenges:      challenges,
+		GeneratedAt:     now,
+	}
+	pointsLiability.Set(float64(stats.TotalPointsOutstanding))
+
+	s.dashboards.set(windowDays, dashboard, now.Add(s.config.DashboardCacheTTL))
+	return dashboard, nil
+}
@@ -0,0 +1,270 @@
+package loyalty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// defaultEarningRulesReloadInterval is how often WatchEarningRules checks the
+// rules file when no interval is given
+const defaultEarningRulesReloadInterval = time.Minute
+
+// EarningAttributes describe what earned the points, for the source's earning
+// rule to price. Fare is in Currency, or in the base currency when Currency is
+// empty.
+type EarningAttributes struct {
+	Fare       float64 `json:"fare,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	DistanceKm float64 `json:"distance_km,omitempty"`
+}
+
+// EarningRule is the base-points formula for a point source: FixedPoints plus
+// PointsPerFareUnit for each unit of fare in the base currency and PointsPerKm
+// for each kilometre,
+// rounded with the service's rounding mode and held between MinPoints and
+// MaxPoints. A zero MaxPoints means no cap.
+type EarningRule struct {
+	Source            PointSource `json:"source"`
+	FixedPoints       int         `json:"fixed_points,omitempty"`
+	PointsPerFareUnit float64     `json:"points_per_fare_unit,omitempty"`
+	PointsPerKm       float64     `json:"points_per_km,omitempty"`
+	MinPoints         int         `json:"min_points,omitempty"`
+	MaxPoints         int         `json:"max_points,omitempty"`
+}
+
+// DefaultEarningRules are the rules used when none are configured: a point per
+// unit of fare for rides, and at least one point for any ride
+func DefaultEarningRules() []EarningRule {
+	return []EarningRule{
+		{Source: SourceRide, PointsPerFareUnit: 1, MinPoints: 1},
+	}
+}
+
+// validate checks a rule can price an earning
+func (r EarningRule) validate() error {
+	if r.Source == "" {
+		return fmt.Errorf("earning rule has no source")
+	}
+	if r.FixedPoints < 0 || r.PointsPerFareUnit < 0 || r.PointsPerKm < 0 || r.MinPoints < 0 || r.MaxPoints < 0 {
+		return fmt.Errorf("earning rule for %s has a negative value", r.Source)
+	}
+	if r.FixedPoints == 0 && r.PointsPerFareUnit == 0 && r.PointsPerKm == 0 && r.MinPoints == 0 {
+		return fmt.Errorf("earning rule for %s never awards points", r.Source)
+	}
+	if r.MaxPoints > 0 && r.MaxPoints < r.MinPoints {
+		return fmt.Errorf("earning rule for %s has max_points below min_points", r.Source)
+	}
+	return nil
+}
+
+// validateEarningRules checks every rule, and that no source has two
+func validateEarningRules(rules []EarningRule) error {
+	seen := make(map[PointSource]bool, len(rules))
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		if seen[rule.Source] {
+			return fmt.Errorf("more than one earning rule for %s", rule.Source)
+		}
+		seen[rule.Source] = true
+	}
+	return nil
+}
+
+// LoadEarningRules reads earning rules from a JSON file holding a list of rules
+func LoadEarningRules(path string) ([]EarningRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read earning rules: %w", err)
+	}
+	var rules []EarningRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse earning rules: %w", err)
+	}
+	if err := validateEarningRules(rules); err != nil {
+		return nil, fmt.Errorf("invalid earning rules: %w", err)
+	}
+	return rules, nil
+}
+
+// earningRules holds the current rules by source. They are replaced whole, so
+// an earning is always priced by one consistent set.
+type earningRules struct {
+	mu    sync.RWMutex
+	rules map[PointSource]EarningRule
+}
+
+func newEarningRules(rules []EarningRule) *earningRules {
+	r := &earningRules{}
+	r.replace(rules)
+	return r
+}
+
+func (r *earningRules) replace(rules []EarningRule) {
+	bySource := make(map[PointSource]EarningRule, len(rules))
+	for _, rule := range rules {
+		bySource[rule.Source] = rule
+	}
+	r.mu.Lock()
+	r.rules = bySource
+	r.mu.Unlock()
+}
+
+func (r *earningRules) get(source PointSource) (EarningRule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rule, ok := r.rules[source]
+	return rule, ok
+}
+
+func (r *earningRules) list() []EarningRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules := make([]EarningRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Source < rules[j].Source })
+	return rules
+}
+
+// ComputeBasePoints prices an earning from source with the source's earning
+// rule, before tier and promo multipliers. A fare in another currency is
+// converted to the base currency first, so a ride earns the same whatever
+// currency it was paid in.
+func (s *Service) ComputeBasePoints(ctx context.Context, source PointSource, attrs EarningAttributes) (int, error) {
+	if source == "" {
+		source = SourceRide
+	}
+	rule, ok := s.earningRules.get(source)
+	if !ok {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("no earning rule for source %s", source), nil)
+	}
+	if attrs.Fare < 0 || attrs.DistanceKm < 0 {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "fare and distance can't be negative", nil)
+	}
+
+	fare, err := s.baseCurrencyFare(ctx, attrs)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := float64(rule.FixedPoints) + rule.PointsPerFareUnit*fare + rule.PointsPerKm*attrs.DistanceKm
+	points := s.roundPoints(raw)
+	if points < rule.MinPoints {
+		points = rule.MinPoints
+	}
+	if rule.MaxPoints > 0 && points > rule.MaxPoints {
+		points = rule.MaxPoints
+	}
+	return points, nil
+}
+
+// baseCurrencyFare returns the fare of an earning in the base currency
+func (s *Service) baseCurrencyFare(ctx context.Context, attrs EarningAttributes) (float64, error) {
+	if attrs.Currency == "" || attrs.Fare == 0 {
+		return attrs.Fare, nil
+	}
+	if s.fareConverter == nil {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("can't price a fare in %s without currency conversion", attrs.Currency), nil)
+	}
+	fare, err := s.fareConverter.ConvertToBase(ctx, attrs.Fare, attrs.Currency)
+	if err != nil {
+		return 0, common.NewInternalError("failed to convert fare to the base currency", err)
+	}
+	return fare, nil
+}
+
+// basePoints returns the base points of an earn request: its explicit points,
+// or those its source's rule gives for its attributes
+func (s *Service) basePoints(ctx context.Context, req *EarnPointsRequest) (int, error) {
+	if req.Points == 0 && req.Attributes != nil {
+		return s.ComputeBasePoints(ctx, req.Source, *req.Attributes)
+	}
+	if req.Points <= 0 {
+		return 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "points must be positive", nil)
+	}
+	return req.Points, nil
+}
+
+// PreviewEarningsFor is PreviewEarnings for an earning priced by the source's
+// earning rule, as EarnPoints would price it
+func (s *Service) PreviewEarningsFor(ctx context.Context, riderID uuid.UUID, source PointSource, attrs EarningAttributes) (*PointsPreview, error) {
+	basePoints, err := s.ComputeBasePoints(ctx, source, attrs)
+	if err != nil {
+		return nil, err
+	}
+	return s.PreviewEarnings(ctx, riderID, basePoints, source)
+}
+
+// GetEarningRules returns the earning rules in use, ordered by source
+func (s *Service) GetEarningRules() []EarningRule {
+	return s.earningRules.list()
+}
+
+// ReloadEarningRules replaces the earning rules. Invalid rules are refused and
+// the current ones kept.
+func (s *Service) ReloadEarningRules(rules []EarningRule) error {
+	if err := validateEarningRules(rules); err != nil {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, err.Error(), nil)
+	}
+	s.earningRules.replace(rules)
+	return nil
+}
+
+// WatchEarningRules reloads the earning rules from a JSON file whenever it
+// changes, checking every interval until ctx is done, so earn rates can be tuned
+// without a deploy. A file that can't be loaded is logged and the current rules
+// are kept.
+func (s *Service) WatchEarningRules(ctx context.Context, path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultEarningRulesReloadInterval
+	}
+
+	var loadedAt time.Time
+	reload := func() {
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to check earning rules file", zap.String("path", path), zap.Error(err))
+			return
+		}
+		if !info.ModTime().After(loadedAt) {
+			return
+		}
+		loadedAt = info.ModTime()
+
+		rules, err := LoadEarningRules(path)
+		if err != nil {
+			logger.WarnContext(ctx, "Keeping current earning rules", zap.String("path", path), zap.Error(err))
+			return
+		}
+		s.earningRules.replace(rules)
+		logger.InfoContext(ctx, "Earning rules loaded", zap.String("path", path), zap.Int("rules", len(rules)))
+	}
+
+	reload()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reload()
+			}
+		}
+	}()
+}
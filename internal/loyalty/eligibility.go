@@ -0,0 +1,122 @@
+package loyalty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// RewardIneligibility is a reason a rider can't redeem a reward. Code is the
+// error code RedeemPoints fails with for it; the other fields give the detail
+// a catalog needs to explain it.
+type RewardIneligibility struct {
+	Code               string   `json:"code"`
+	Message            string   `json:"message"`
+	PointsShort        int      `json:"points_short,omitempty"`
+	RequiredTier       TierName `json:"required_tier,omitempty"`
+	RedemptionsUsed    int      `json:"redemptions_used,omitempty"`
+	RedemptionsAllowed int      `json:"redemptions_allowed,omitempty"`
+}
+
+// RewardEligibility is whether a rider can redeem a reward, and if not, every
+// reason why
+type RewardEligibility struct {
+	RewardID uuid.UUID             `json:"reward_id"`
+	Eligible bool                  `json:"eligible"`
+	Reasons  []RewardIneligibility `json:"reasons"`
+}
+
+// CheckRewardEligibility runs the checks RedeemPoints makes before redeeming a
+// reward, without redeeming it, and reports every one that fails
+func (s *Service) CheckRewardEligibility(ctx context.Context, riderID, rewardID uuid.UUID) (*RewardEligibility, error) {
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
+	if err != nil {
+		return nil, common.NewNotFoundError("loyalty account not found", err)
+	}
+
+	reward, err := s.repo.GetReward(ctx, rewardID)
+	if err != nil {
+		return nil, common.NewNotFoundError("reward not found", err)
+	}
+
+	reasons := s.rewardIneligibilities(ctx, account, reward)
+	return &RewardEligibility{
+		RewardID: rewardID,
+		Eligible: len(reasons) == 0,
+		Reasons:  reasons,
+	}, nil
+}
+
+// rewardIneligibilities returns every reason the account can't redeem the
+// reward, in the order RedeemPoints reports them. Stock is judged from the
+// reward as loaded; RedeemPoints still reserves it atomically.
+func (s *Service) rewardIneligibilities(ctx context.Context, account *RiderLoyalty, reward *RewardCatalogItem) []RewardIneligibility {
+	reasons := make([]RewardIneligibility, 0)
+
+	if !reward.IsActive {
+		reasons = append(reasons, RewardIneligibility{
+			Code:    common.ErrCodeRewardUnavailable,
+			Message: "reward is no longer available",
+		})
+	}
+
+	if account.AvailablePoints < reward.PointsRequired {
+		reasons = append(reasons, RewardIneligibility{
+			Code:        common.ErrCodeInsufficientPoints,
+			Message:     fmt.Sprintf("insufficient points: need %d, have %d", reward.PointsRequired, account.AvailablePoints),
+			PointsShort: reward.PointsRequired - account.AvailablePoints,
+		})
+	}
+
+	// Check tier restriction
+	if reward.TierRestriction != nil && account.CurrentTierID != nil {
+		currentTier, _ := s.repo.GetTier(ctx, *account.CurrentTierID)
+		restrictedTier, _ := s.repo.GetTier(ctx, *reward.TierRestriction)
+		if currentTier != nil && restrictedTier != nil && currentTier.MinPoints < restrictedTier.MinPoints {
+			reasons = append(reasons, RewardIneligibility{
+				Code:         common.ErrCodeTierRequired,
+				Message:      fmt.Sprintf("this reward requires a higher tier: %s", restrictedTier.Name),
+				RequiredTier: restrictedTier.Name,
+			})
+		}
+	}
+
+	// Check max redemptions
+	if reward.MaxRedemptionsPerUser != nil {
+		count, _ := s.repo.GetUserRedemptionCount(ctx, account.RiderID, reward.ID)
+		if count >= *reward.MaxRedemptionsPerUser {
+			reasons = append(reasons, RewardIneligibility{
+				Code:               common.ErrCodeRedemptionLimit,
+				Message:            fmt.Sprintf("you have reached the maximum redemptions for this reward: %d of %d used", count, *reward.MaxRedemptionsPerUser),
+				RedemptionsUsed:    count,
+				RedemptionsAllowed: *reward.MaxRedemptionsPerUser,
+			})
+		}
+	}
+
+	if reward.TotalInventory != nil && *reward.TotalInventory <= 0 {
+		reasons = append(reasons, outOfStock())
+	}
+
+	return reasons
+}
+
+// outOfStock is the reason given for a limited reward with no stock left
+func outOfStock() RewardIneligibility {
+	return RewardIneligibility{Code: common.ErrCodeRewardOutOfStock, Message: "reward is out of stock"}
+}
+
+// err is the error RedeemPoints fails with for the reason
+func (r RewardIneligibility) err() error {
+	switch r.Code {
+	case common.ErrCodeTierRequired:
+		return common.NewErrorWithCode(http.StatusForbidden, r.Code, r.Message, nil)
+	case common.ErrCodeRewardOutOfStock:
+		return common.NewErrorWithCode(http.StatusConflict, r.Code, r.Message, common.ErrConflict)
+	default:
+		return common.NewErrorWithCode(http.StatusBadRequest, r.Code, r.Message, nil)
+	}
+}
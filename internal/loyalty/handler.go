@@ -2,9 +2,12 @@ package loyalty
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
@@ -37,19 +40,20 @@ func (h *Handler) GetStatus(c *gin.Context) {
 
 	status, err := h.service.GetLoyaltyStatus(c.Request.Context(), riderID)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get loyalty status")
+		common.RespondError(c, err)
 		return
 	}
 
 	common.SuccessResponse(c, status)
 }
 
-// GetPointsHistory gets the rider's points history
-// GET /api/v1/rider/loyalty/points/history
+// GetPointsHistory gets the rider's points history, optionally narrowed by
+// transaction type, source and a from/to date range (both days included). With
+// a cursor parameter (empty for the first page) it pages by cursor instead of
+// offset, unfiltered.
+// GET /api/v1/rider/loyalty/points/history?limit=20&offset=0
+// GET /api/v1/rider/loyalty/points/history?type=earn&source=challenge&from=2025-01-01&to=2025-01-31
+// GET /api/v1/rider/loyalty/points/history?limit=20&cursor=
 func (h *Handler) GetPointsHistory(c *gin.Context) {
 	riderID, err := h.getRiderID(c)
 	if err != nil {
@@ -59,19 +63,109 @@ func (h *Handler) GetPointsHistory(c *gin.Context) {
 
 	params := pagination.ParseParams(c)
 
-	history, err := h.service.GetPointsHistory(c.Request.Context(), riderID, params.Limit, params.Offset)
-	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
+	if pagination.WantsCursor(c) {
+		page, err := h.service.GetPointsHistoryCursor(c.Request.Context(), riderID, c.Query(pagination.CursorParam), params.Limit)
+		if err != nil {
+			common.RespondError(c, err)
+			return
+		}
+		common.SuccessResponse(c, page)
+		return
+	}
+
+	filter := PointsHistoryFilter{
+		TransactionType: TransactionType(c.Query("type")),
+		Source:          PointSource(c.Query("source")),
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "from must be a date (YYYY-MM-DD)")
+			return
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "to must be a date (YYYY-MM-DD)")
 			return
 		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get points history")
+		end := t.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	history, err := h.service.GetPointsHistory(c.Request.Context(), riderID, filter, params.Limit, params.Offset)
+	if err != nil {
+		common.RespondError(c, err)
 		return
 	}
 
 	common.SuccessResponse(c, history)
 }
 
+// GetRedemptionHistory gets the rewards the rider has redeemed
+// GET /api/v1/rider/loyalty/redemptions?status=active
+func (h *Handler) GetRedemptionHistory(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params := pagination.ParseParams(c)
+
+	history, err := h.service.GetRedemptionHistory(c.Request.Context(), riderID, c.Query("status"), params.Limit, params.Offset)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, history)
+}
+
+// PreviewPoints shows how many points a ride would earn without awarding them.
+// Without base_points, the points are priced from fare and distance_km by the
+// source's earning rule.
+// GET /api/v1/rider/loyalty/points/preview?base_points=100&source=ride
+// GET /api/v1/rider/loyalty/points/preview?fare=25.5&currency=EUR&distance_km=8&source=ride
+func (h *Handler) PreviewPoints(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	source := PointSource(c.Query("source"))
+	var preview *PointsPreview
+	if c.Query("base_points") == "" {
+		var attrs EarningAttributes
+		if attrs.Fare, err = strconv.ParseFloat(c.DefaultQuery("fare", "0"), 64); err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid fare")
+			return
+		}
+		if attrs.DistanceKm, err = strconv.ParseFloat(c.DefaultQuery("distance_km", "0"), 64); err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid distance_km")
+			return
+		}
+		attrs.Currency = c.Query("currency")
+		preview, err = h.service.PreviewEarningsFor(c.Request.Context(), riderID, source, attrs)
+	} else {
+		basePoints, convErr := strconv.Atoi(c.Query("base_points"))
+		if convErr != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid base_points")
+			return
+		}
+		preview, err = h.service.PreviewEarnings(c.Request.Context(), riderID, basePoints, source)
+	}
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, preview)
+}
+
 // GetRewards gets available rewards
 // GET /api/v1/rider/loyalty/rewards
 func (h *Handler) GetRewards(c *gin.Context) {
@@ -83,11 +177,7 @@ func (h *Handler) GetRewards(c *gin.Context) {
 
 	rewards, err := h.service.GetRewardsCatalog(c.Request.Context(), riderID)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get rewards")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -112,21 +202,97 @@ func (h *Handler) RedeemReward(c *gin.Context) {
 	}
 
 	result, err := h.service.RedeemPoints(c.Request.Context(), &RedeemPointsRequest{
-		RiderID:  riderID,
-		RewardID: rewardID,
+		RiderID:        riderID,
+		RewardID:       rewardID,
+		IdempotencyKey: c.GetHeader(middleware.IdempotencyKeyHeader),
 	})
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to redeem reward")
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
+// RedeemRewardPartial redeems a reward paying part of it in points and the rest in cash
+// POST /api/v1/rider/loyalty/rewards/:id/redeem-partial
+func (h *Handler) RedeemRewardPartial(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rewardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid reward ID")
+		return
+	}
+
+	var req RedeemPartialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.RedeemPartial(c.Request.Context(), riderID, rewardID, req.Points)
+	if err != nil {
+		common.RespondError(c, err)
 		return
 	}
 
 	common.SuccessResponse(c, result)
 }
 
+// GetRewardEligibility explains whether the rider can redeem a reward
+// GET /api/v1/rider/loyalty/rewards/:id/eligibility
+func (h *Handler) GetRewardEligibility(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rewardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid reward ID")
+		return
+	}
+
+	eligibility, err := h.service.CheckRewardEligibility(c.Request.Context(), riderID, rewardID)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, eligibility)
+}
+
+// JoinRewardWaitlist joins the waitlist for an out-of-stock reward
+// POST /api/v1/rider/loyalty/rewards/:id/waitlist
+func (h *Handler) JoinRewardWaitlist(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rewardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid reward ID")
+		return
+	}
+
+	if err := h.service.JoinRewardWaitlist(c.Request.Context(), riderID, rewardID); err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"message": "joined reward waitlist",
+	})
+}
+
 // GetChallenges gets active challenges
 // GET /api/v1/rider/loyalty/challenges
 func (h *Handler) GetChallenges(c *gin.Context) {
@@ -138,15 +304,43 @@ func (h *Handler) GetChallenges(c *gin.Context) {
 
 	challenges, err := h.service.GetActiveChallenges(c.Request.Context(), riderID)
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, challenges)
+}
+
+// GetChallengeLeaderboard gets the top riders on a challenge and the rider's own rank
+// GET /api/v1/rider/loyalty/challenges/:id/leaderboard?limit=10
+func (h *Handler) GetChallengeLeaderboard(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	challengeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid challenge ID")
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			common.ErrorResponse(c, http.StatusBadRequest, "limit must be a positive integer")
 			return
 		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get challenges")
+	}
+
+	board, err := h.service.GetChallengeLeaderboard(c.Request.Context(), challengeID, riderID, limit)
+	if err != nil {
+		common.RespondError(c, err)
 		return
 	}
 
-	common.SuccessResponse(c, challenges)
+	common.SuccessResponse(c, board)
 }
 
 // GetTiers gets all loyalty tiers
@@ -154,11 +348,7 @@ func (h *Handler) GetChallenges(c *gin.Context) {
 func (h *Handler) GetTiers(c *gin.Context) {
 	tiers, err := h.service.GetAllTiers(c.Request.Context())
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get tiers")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -167,6 +357,31 @@ func (h *Handler) GetTiers(c *gin.Context) {
 	})
 }
 
+// GetTierComparison compares the rider's tier with the next one up. With
+// avg_points_per_ride, it estimates the rides needed to reach it.
+// GET /api/v1/rider/loyalty/tiers/comparison?avg_points_per_ride=50
+func (h *Handler) GetTierComparison(c *gin.Context) {
+	riderID, err := h.getRiderID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	avgPointsPerRide, err := strconv.ParseFloat(c.DefaultQuery("avg_points_per_ride", "0"), 64)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid avg_points_per_ride")
+		return
+	}
+
+	comparison, err := h.service.GetTierComparison(c.Request.Context(), riderID, avgPointsPerRide)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, comparison)
+}
+
 // ========================================
 // ADMIN ENDPOINTS
 // ========================================
@@ -183,6 +398,27 @@ func (h *Handler) GetLoyaltyStats(c *gin.Context) {
 	common.SuccessResponse(c, stats)
 }
 
+// GetLoyaltyDashboard gets the loyalty program dashboard over a window of days
+// GET /api/v1/admin/loyalty/dashboard?window_days=30
+func (h *Handler) GetLoyaltyDashboard(c *gin.Context) {
+	windowDays := 0
+	if raw := c.Query("window_days"); raw != "" {
+		var err error
+		if windowDays, err = strconv.Atoi(raw); err != nil || windowDays <= 0 {
+			common.ErrorResponse(c, http.StatusBadRequest, "window_days must be a positive integer")
+			return
+		}
+	}
+
+	dashboard, err := h.service.GetLoyaltyDashboard(c.Request.Context(), windowDays)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, dashboard)
+}
+
 // AwardPoints awards points to a rider (admin)
 // POST /api/v1/admin/loyalty/award
 func (h *Handler) AwardPoints(c *gin.Context) {
@@ -198,17 +434,14 @@ func (h *Handler) AwardPoints(c *gin.Context) {
 	}
 
 	err := h.service.EarnPoints(c.Request.Context(), &EarnPointsRequest{
-		RiderID:     req.RiderID,
-		Points:      req.Points,
-		Source:      SourcePromotion,
-		Description: req.Description,
+		RiderID:        req.RiderID,
+		Points:         req.Points,
+		Source:         SourcePromotion,
+		Description:    req.Description,
+		IdempotencyKey: c.GetHeader(middleware.IdempotencyKeyHeader),
 	})
 	if err != nil {
-		if appErr, ok := err.(*common.AppError); ok {
-			common.AppErrorResponse(c, appErr)
-			return
-		}
-		common.ErrorResponse(c, http.StatusInternalServerError, "failed to award points")
+		common.RespondError(c, err)
 		return
 	}
 
@@ -217,6 +450,136 @@ func (h *Handler) AwardPoints(c *gin.Context) {
 	})
 }
 
+// AdjustPoints manually credits or debits a rider's points (admin)
+// POST /api/v1/admin/loyalty/adjust
+func (h *Handler) AdjustPoints(c *gin.Context) {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req AdjustPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	tx, err := h.service.AdjustPoints(c.Request.Context(), req.RiderID, req.Points, req.Reason, adminID, req.Override)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, tx)
+}
+
+// SimulateRideImpact previews a ride's loyalty impact without applying it (admin)
+// POST /api/v1/admin/loyalty/simulate
+func (h *Handler) SimulateRideImpact(c *gin.Context) {
+	var req SimulateRideImpactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := h.service.SimulateRideImpact(c.Request.Context(), req.RiderID, req.BasePoints, req.ChallengeType, req.Increment)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
+// CreatePointPromo schedules a point multiplier promo (admin)
+// POST /api/v1/admin/loyalty/promos
+func (h *Handler) CreatePointPromo(c *gin.Context) {
+	var req CreatePointPromoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	promo, err := h.service.CreatePointPromo(c.Request.Context(), &req)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.CreatedResponse(c, promo)
+}
+
+// GetActivePointPromos lists the point multiplier promos running now (admin)
+// GET /api/v1/admin/loyalty/promos
+func (h *Handler) GetActivePointPromos(c *gin.Context) {
+	promos, err := h.service.GetActivePointPromos(c.Request.Context())
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"promos": promos})
+}
+
+// GetEarningRules lists the point-earning rules in use (admin)
+// GET /api/v1/admin/loyalty/earning-rules
+func (h *Handler) GetEarningRules(c *gin.Context) {
+	common.SuccessResponse(c, gin.H{"rules": h.service.GetEarningRules()})
+}
+
+// ReleaseRewardWaitlist notifies the next riders waiting for a reward (admin)
+// POST /api/v1/admin/loyalty/rewards/:id/waitlist/release
+func (h *Handler) ReleaseRewardWaitlist(c *gin.Context) {
+	rewardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid reward ID")
+		return
+	}
+
+	var req struct {
+		Count int `json:"count" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	released, err := h.service.ReleaseWaitlist(c.Request.Context(), rewardID, req.Count)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"released": released,
+	})
+}
+
+// GetRedemption shows a redemption code's status and reward (admin)
+// GET /api/v1/admin/loyalty/redemptions/:code
+func (h *Handler) GetRedemption(c *gin.Context) {
+	redemption, err := h.service.GetRedemption(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, redemption)
+}
+
+// UseRedemption marks a redemption code as used (admin)
+// POST /api/v1/admin/loyalty/redemptions/:code/use
+func (h *Handler) UseRedemption(c *gin.Context) {
+	redemption, err := h.service.MarkRedemptionUsed(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, redemption)
+}
+
 // ========================================
 // HELPER FUNCTIONS
 // ========================================
@@ -238,19 +601,36 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 	{
 		loyalty.GET("/status", h.GetStatus)
 		loyalty.GET("/points/history", h.GetPointsHistory)
+		loyalty.GET("/points/preview", h.PreviewPoints)
 		loyalty.GET("/rewards", h.GetRewards)
+		loyalty.GET("/rewards/:id/eligibility", h.GetRewardEligibility)
 		loyalty.POST("/rewards/:id/redeem", h.RedeemReward)
+		loyalty.POST("/rewards/:id/redeem-partial", h.RedeemRewardPartial)
+		loyalty.POST("/rewards/:id/waitlist", h.JoinRewardWaitlist)
+		loyalty.GET("/redemptions", h.GetRedemptionHistory)
 		loyalty.GET("/challenges", h.GetChallenges)
+		loyalty.GET("/challenges/:id/leaderboard", h.GetChallengeLeaderboard)
 		loyalty.GET("/tiers", h.GetTiers)
+		loyalty.GET("/tiers/comparison", h.GetTierComparison)
 	}
 
 	// Admin routes
 	adminLoyalty := r.Group("/api/v1/admin/loyalty")
 	adminLoyalty.Use(middleware.AuthMiddlewareWithProvider(jwtProvider))
 	adminLoyalty.Use(middleware.RequireRole(models.RoleAdmin))
+	adminLoyalty.Use(audit.Actor())
 	{
 		adminLoyalty.GET("/stats", h.GetLoyaltyStats)
+		adminLoyalty.GET("/dashboard", h.GetLoyaltyDashboard)
 		adminLoyalty.POST("/award", h.AwardPoints)
+		adminLoyalty.POST("/adjust", h.AdjustPoints)
+		adminLoyalty.POST("/simulate", h.SimulateRideImpact)
+		adminLoyalty.GET("/promos", h.GetActivePointPromos)
+		adminLoyalty.POST("/promos", h.CreatePointPromo)
+		adminLoyalty.GET("/earning-rules", h.GetEarningRules)
+		adminLoyalty.POST("/rewards/:id/waitlist/release", h.ReleaseRewardWaitlist)
+		adminLoyalty.GET("/redemptions/:code", h.GetRedemption)
+		adminLoyalty.POST("/redemptions/:code/use", h.UseRedemption)
 	}
 }
 
@@ -260,9 +640,16 @@ func (h *Handler) RegisterRoutesOnGroup(rg *gin.RouterGroup) {
 	{
 		loyalty.GET("/status", h.GetStatus)
 		loyalty.GET("/points/history", h.GetPointsHistory)
+		loyalty.GET("/points/preview", h.PreviewPoints)
 		loyalty.GET("/rewards", h.GetRewards)
+		loyalty.GET("/rewards/:id/eligibility", h.GetRewardEligibility)
 		loyalty.POST("/rewards/:id/redeem", h.RedeemReward)
+		loyalty.POST("/rewards/:id/redeem-partial", h.RedeemRewardPartial)
+		loyalty.POST("/rewards/:id/waitlist", h.JoinRewardWaitlist)
+		loyalty.GET("/redemptions", h.GetRedemptionHistory)
 		loyalty.GET("/challenges", h.GetChallenges)
+		loyalty.GET("/challenges/:id/leaderboard", h.GetChallengeLeaderboard)
 		loyalty.GET("/tiers", h.GetTiers)
+		loyalty.GET("/tiers/comparison", h.GetTierComparison)
 	}
 }
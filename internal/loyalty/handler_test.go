@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -33,9 +34,9 @@ func (m *MockRepository) GetRiderLoyalty(ctx context.Context, riderID uuid.UUID)
 	return args.Get(0).(*RiderLoyalty), args.Error(1)
 }
 
-func (m *MockRepository) CreateRiderLoyalty(ctx context.Context, account *RiderLoyalty) error {
-	args := m.Called(ctx, account)
-	return args.Error(0)
+func (m *MockRepository) CreateRiderLoyaltyWithSignupBonus(ctx context.Context, account *RiderLoyalty, bonus *PointsTransaction) (bool, error) {
+	args := m.Called(ctx, account, bonus)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockRepository) UpdatePoints(ctx context.Context, riderID uuid.UUID, earnedPoints, tierPoints int) error {
@@ -43,18 +44,13 @@ func (m *MockRepository) UpdatePoints(ctx context.Context, riderID uuid.UUID, ea
 	return args.Error(0)
 }
 
-func (m *MockRepository) DeductPoints(ctx context.Context, riderID uuid.UUID, points int) error {
-	args := m.Called(ctx, riderID, points)
-	return args.Error(0)
-}
-
 func (m *MockRepository) UpdateTier(ctx context.Context, riderID uuid.UUID, tierID uuid.UUID) error {
 	args := m.Called(ctx, riderID, tierID)
 	return args.Error(0)
 }
 
-func (m *MockRepository) UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int) error {
-	args := m.Called(ctx, riderID, streakDays)
+func (m *MockRepository) UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int, lastRideDate time.Time) error {
+	args := m.Called(ctx, riderID, streakDays, lastRideDate)
 	return args.Error(0)
 }
 
@@ -87,14 +83,32 @@ func (m *MockRepository) CreatePointsTransaction(ctx context.Context, tx *Points
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetPointsHistory(ctx context.Context, riderID uuid.UUID, limit, offset int) ([]*PointsTransaction, int, error) {
-	args := m.Called(ctx, riderID, limit, offset)
+func (m *MockRepository) CreatePointsTransactionsBatch(ctx context.Context, riderID uuid.UUID, txs []*PointsTransaction, earnedPoints, tierPoints int) error {
+	args := m.Called(ctx, riderID, txs, earnedPoints, tierPoints)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ApplyPointsAdjustment(ctx context.Context, tx *PointsTransaction) error {
+	args := m.Called(ctx, tx)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPointsHistory(ctx context.Context, riderID uuid.UUID, filter PointsHistoryFilter, limit, offset int) ([]*PointsTransaction, int, error) {
+	args := m.Called(ctx, riderID, filter, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
 	}
 	return args.Get(0).([]*PointsTransaction), args.Int(1), args.Error(2)
 }
 
+func (m *MockRepository) GetPointsHistoryAfter(ctx context.Context, riderID uuid.UUID, after *pagination.Cursor, limit int) ([]*PointsTransaction, error) {
+	args := m.Called(ctx, riderID, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*PointsTransaction), args.Error(1)
+}
+
 func (m *MockRepository) GetReward(ctx context.Context, rewardID uuid.UUID) (*RewardCatalogItem, error) {
 	args := m.Called(ctx, rewardID)
 	if args.Get(0) == nil {
@@ -116,13 +130,63 @@ func (m *MockRepository) GetUserRedemptionCount(ctx context.Context, riderID, re
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockRepository) CreateRedemption(ctx context.Context, redemption *Redemption) error {
-	args := m.Called(ctx, redemption)
+func (m *MockRepository) RedeemReward(ctx context.Context, redemption *Redemption, tx *PointsTransaction) error {
+	args := m.Called(ctx, redemption, tx)
 	return args.Error(0)
 }
 
-func (m *MockRepository) IncrementRewardRedemptionCount(ctx context.Context, rewardID uuid.UUID) error {
-	args := m.Called(ctx, rewardID)
+func (m *MockRepository) GetRedemptionByCode(ctx context.Context, code string) (*Redemption, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Redemption), args.Error(1)
+}
+
+func (m *MockRepository) GetRedemptionHistory(ctx context.Context, riderID uuid.UUID, status string, now time.Time, limit, offset int) ([]*Redemption, int, error) {
+	args := m.Called(ctx, riderID, status, now, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*Redemption), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) MarkRedemptionUsed(ctx context.Context, redemptionID uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, redemptionID, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) AddToRewardWaitlist(ctx context.Context, entry *RewardWaitlistEntry) (bool, error) {
+	args := m.Called(ctx, entry)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ConsumeTierBenefit(ctx context.Context, riderID, rideID uuid.UUID, benefit TierBenefit, now time.Time) (bool, error) {
+	args := m.Called(ctx, riderID, rideID, benefit, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ClaimRewardWaitlist(ctx context.Context, rewardID uuid.UUID, limit int) ([]*RewardWaitlistEntry, error) {
+	args := m.Called(ctx, rewardID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*RewardWaitlistEntry), args.Error(1)
+}
+
+func (m *MockRepository) GetActivePointPromos(ctx context.Context, at time.Time) ([]*PointMultiplierPromo, error) {
+	if !hasExpectation(&m.Mock, "GetActivePointPromos") {
+		return nil, nil
+	}
+	args := m.Called(ctx, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*PointMultiplierPromo), args.Error(1)
+}
+
+func (m *MockRepository) CreatePointPromo(ctx context.Context, promo *PointMultiplierPromo) error {
+	args := m.Called(ctx, promo)
 	return args.Error(0)
 }
 
@@ -155,8 +219,96 @@ func (m *MockRepository) CreateChallengeProgress(ctx context.Context, progress *
 	return args.Error(0)
 }
 
-func (m *MockRepository) UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool) error {
-	args := m.Called(ctx, progressID, currentValue, completed)
+func (m *MockRepository) UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool, activityDate time.Time) error {
+	args := m.Called(ctx, progressID, currentValue, completed, activityDate)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetChallenge(ctx context.Context, challengeID uuid.UUID) (*RiderChallenge, error) {
+	args := m.Called(ctx, challengeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RiderChallenge), args.Error(1)
+}
+
+func (m *MockRepository) GetChallengeLeaderboard(ctx context.Context, challengeID uuid.UUID, tierRestriction *uuid.UUID, limit int) ([]*ChallengeLeaderboardEntry, error) {
+	args := m.Called(ctx, challengeID, tierRestriction, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*ChallengeLeaderboardEntry), args.Error(1)
+}
+
+func (m *MockRepository) GetChallengeRank(ctx context.Context, challengeID, riderID uuid.UUID, tierRestriction *uuid.UUID) (*ChallengeLeaderboardEntry, error) {
+	args := m.Called(ctx, challengeID, riderID, tierRestriction)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ChallengeLeaderboardEntry), args.Error(1)
+}
+
+func (m *MockRepository) GetReferralByReferee(ctx context.Context, refereeID uuid.UUID) (*Referral, error) {
+	args := m.Called(ctx, refereeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Referral), args.Error(1)
+}
+
+func (m *MockRepository) CreateReferral(ctx context.Context, referral *Referral) (bool, error) {
+	args := m.Called(ctx, referral)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreditPointsGuarded(ctx context.Context, tx *PointsTransaction, guard PointsGuard) (bool, error) {
+	args := m.Called(ctx, tx, guard)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) HasLoyaltyHistory(ctx context.Context, riderID, excludeRideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, riderID, excludeRideID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) SetBirthDate(ctx context.Context, riderID uuid.UUID, birthDate time.Time) error {
+	args := m.Called(ctx, riderID, birthDate)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRidersWithBirthday(ctx context.Context, month time.Month, days []int) ([]*BirthdayRider, error) {
+	args := m.Called(ctx, month, days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*BirthdayRider), args.Error(1)
+}
+
+func (m *MockRepository) HasPointsTransaction(ctx context.Context, riderID uuid.UUID, source PointSource, sourceID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, riderID, source, sourceID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) (*IdempotencyKey, error) {
+	args := m.Called(ctx, riderID, operation, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*IdempotencyKey), args.Error(1)
+}
+
+func (m *MockRepository) CompleteIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string, response []byte, expiresAt time.Time) error {
+	args := m.Called(ctx, riderID, operation, key, response, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) error {
+	args := m.Called(ctx, riderID, operation, key)
 	return args.Error(0)
 }
 
@@ -168,6 +320,30 @@ func (m *MockRepository) GetLoyaltyStats(ctx context.Context) (*LoyaltyStats, er
 	return args.Get(0).(*LoyaltyStats), args.Error(1)
 }
 
+func (m *MockRepository) GetPointsFlow(ctx context.Context, since time.Time) (*PointsFlow, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PointsFlow), args.Error(1)
+}
+
+func (m *MockRepository) GetTopRedeemedRewards(ctx context.Context, since time.Time, limit int) ([]*RewardRedemptionStats, error) {
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*RewardRedemptionStats), args.Error(1)
+}
+
+func (m *MockRepository) GetChallengeCompletions(ctx context.Context, since time.Time) ([]*ChallengeCompletion, error) {
+	args := m.Called(ctx, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*ChallengeCompletion), args.Error(1)
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -319,16 +495,11 @@ func TestHandler_GetStatus_NewAccount(t *testing.T) {
 	riderID := uuid.New()
 	tier := createTestLoyaltyTier()
 
-	// First call returns error (no account), second call returns the created account
 	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(nil, errors.New("not found")).Once()
 	mockRepo.On("GetTierByName", mock.Anything, TierBronze).Return(tier, nil)
-	mockRepo.On("CreateRiderLoyalty", mock.Anything, mock.AnythingOfType("*loyalty.RiderLoyalty")).Return(nil)
-	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(createTestRiderLoyalty(riderID, tier), nil).Maybe()
+	mockRepo.On("CreateRiderLoyaltyWithSignupBonus", mock.Anything, mock.AnythingOfType("*loyalty.RiderLoyalty"), mock.AnythingOfType("*loyalty.PointsTransaction")).Return(true, nil)
 	mockRepo.On("GetTier", mock.Anything, mock.Anything).Return(tier, nil).Maybe()
 	mockRepo.On("GetAllTiers", mock.Anything).Return([]*LoyaltyTier{tier}, nil)
-	// For the signup bonus goroutine
-	mockRepo.On("CreatePointsTransaction", mock.Anything, mock.AnythingOfType("*loyalty.PointsTransaction")).Return(nil).Maybe()
-	mockRepo.On("UpdatePoints", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 
 	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/status", nil)
 	setUserContext(c, riderID)
@@ -357,6 +528,64 @@ func TestHandler_GetStatus_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+// ============================================================================
+// PreviewPoints Handler Tests
+// ============================================================================
+
+func TestHandler_PreviewPoints_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	tier := createTestLoyaltyTier()
+	account := createTestRiderLoyalty(riderID, tier)
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
+	mockRepo.On("GetAllTiers", mock.Anything).Return([]*LoyaltyTier{tier}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/preview?base_points=100&source=ride", nil)
+	setUserContext(c, riderID)
+
+	handler.PreviewPoints(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(100), data["base_points"])
+	assert.Equal(t, "ride", data["source"])
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreatePointsTransaction", mock.Anything, mock.Anything)
+}
+
+func TestHandler_PreviewPoints_InvalidBasePoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/preview?base_points=abc", nil)
+	setUserContext(c, uuid.New())
+
+	handler.PreviewPoints(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_PreviewPoints_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/preview?base_points=100", nil)
+
+	handler.PreviewPoints(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 // ============================================================================
 // GetPointsHistory Handler Tests
 // ============================================================================
@@ -380,7 +609,7 @@ func TestHandler_GetPointsHistory_Success(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPointsHistory", mock.Anything, riderID, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(transactions, 1, nil)
+	mockRepo.On("GetPointsHistory", mock.Anything, riderID, PointsHistoryFilter{}, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(transactions, 1, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history", nil)
 	setUserContext(c, riderID)
@@ -393,6 +622,49 @@ func TestHandler_GetPointsHistory_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestHandler_GetPointsHistory_ByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	now := time.Now()
+	transactions := []*PointsTransaction{
+		{ID: uuid.New(), RiderID: riderID, Points: 50, CreatedAt: now},
+		{ID: uuid.New(), RiderID: riderID, Points: 30, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	// A page of 1 fetches 2 to learn that another page follows
+	mockRepo.On("GetPointsHistoryAfter", mock.Anything, riderID, (*pagination.Cursor)(nil), 2).Return(transactions, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history?limit=1&cursor=", nil)
+	setUserContext(c, riderID)
+
+	handler.GetPointsHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Len(t, data["transactions"], 1)
+	assert.Equal(t, pagination.NewCursor(now, transactions[0].ID).Encode(), data["next_cursor"])
+	mockRepo.AssertNotCalled(t, "GetPointsHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetPointsHistory_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history?cursor=garbage", nil)
+	setUserContext(c, uuid.New())
+
+	handler.GetPointsHistory(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestHandler_GetPointsHistory_Unauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -415,7 +687,7 @@ func TestHandler_GetPointsHistory_EmptyList(t *testing.T) {
 
 	riderID := uuid.New()
 
-	mockRepo.On("GetPointsHistory", mock.Anything, riderID, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PointsTransaction{}, 0, nil)
+	mockRepo.On("GetPointsHistory", mock.Anything, riderID, PointsHistoryFilter{}, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return([]*PointsTransaction{}, 0, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history", nil)
 	setUserContext(c, riderID)
@@ -433,7 +705,7 @@ func TestHandler_GetPointsHistory_ServiceError(t *testing.T) {
 
 	riderID := uuid.New()
 
-	mockRepo.On("GetPointsHistory", mock.Anything, riderID, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 0, errors.New("database error"))
+	mockRepo.On("GetPointsHistory", mock.Anything, riderID, PointsHistoryFilter{}, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(nil, 0, errors.New("database error"))
 
 	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history", nil)
 	setUserContext(c, riderID)
@@ -443,6 +715,97 @@ func TestHandler_GetPointsHistory_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestHandler_GetPointsHistory_Filters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC) // The whole of Jan 31
+	filter := PointsHistoryFilter{TransactionType: TransactionEarn, Source: SourceChallenge, From: &from, To: &to}
+
+	mockRepo.On("GetPointsHistory", mock.Anything, riderID, filter, 20, 0).Return([]*PointsTransaction{}, 0, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history?type=earn&source=challenge&from=2025-01-01&to=2025-01-31", nil)
+	c.Request.URL.RawQuery = "type=earn&source=challenge&from=2025-01-01&to=2025-01-31"
+	setUserContext(c, riderID)
+
+	handler.GetPointsHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetPointsHistory_InvalidFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, query := range []string{"type=refund", "from=yesterday", "to=2025-13-01"} {
+		mockRepo := new(MockRepository)
+		handler := createTestHandler(mockRepo)
+
+		c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history?"+query, nil)
+		c.Request.URL.RawQuery = query
+		setUserContext(c, uuid.New())
+
+		handler.GetPointsHistory(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandler_GetRedemptionHistory_FiltersByStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	redemptions := []*Redemption{
+		{
+			ID:             uuid.New(),
+			RiderID:        riderID,
+			RewardName:     "Free Ride",
+			RedemptionCode: "RWD-USED01",
+			Status:         RedemptionStatusUsed,
+			ExpiresAt:      time.Now().Add(time.Hour),
+		},
+	}
+
+	mockRepo.On("GetRedemptionHistory", mock.Anything, riderID, RedemptionStatusUsed, mock.AnythingOfType("time.Time"), 5, 5).Return(redemptions, 6, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/redemptions?status=used&limit=5&offset=5", nil)
+	setUserContext(c, riderID)
+
+	handler.GetRedemptionHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(6), data["total"])
+	items := data["redemptions"].([]interface{})
+	assert.Len(t, items, 1)
+	item := items[0].(map[string]interface{})
+	assert.Equal(t, "Free Ride", item["reward_name"])
+	assert.Equal(t, "used", item["status"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetRedemptionHistory_InvalidStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/redemptions?status=bogus", nil)
+	setUserContext(c, uuid.New())
+
+	handler.GetRedemptionHistory(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // ============================================================================
 // GetRewards Handler Tests
 // ============================================================================
@@ -543,10 +906,7 @@ func TestHandler_RedeemReward_Success(t *testing.T) {
 
 	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
 	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
-	mockRepo.On("CreateRedemption", mock.Anything, mock.AnythingOfType("*loyalty.Redemption")).Return(nil)
-	mockRepo.On("CreatePointsTransaction", mock.Anything, mock.AnythingOfType("*loyalty.PointsTransaction")).Return(nil)
-	mockRepo.On("DeductPoints", mock.Anything, riderID, reward.PointsRequired).Return(nil)
-	mockRepo.On("IncrementRewardRedemptionCount", mock.Anything, reward.ID).Return(nil)
+	mockRepo.On("RedeemReward", mock.Anything, mock.AnythingOfType("*loyalty.Redemption"), mock.AnythingOfType("*loyalty.PointsTransaction")).Return(nil)
 
 	c, w := setupTestContext("POST", "/api/v1/rider/loyalty/rewards/"+reward.ID.String()+"/redeem", nil)
 	c.Params = gin.Params{{Key: "id", Value: reward.ID.String()}}
@@ -670,11 +1030,7 @@ func TestHandler_RedeemReward_InactiveReward(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-// ============================================================================
-// GetChallenges Handler Tests
-// ============================================================================
-
-func TestHandler_GetChallenges_Success(t *testing.T) {
+func TestHandler_RedeemReward_OutOfStock(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockRepo := new(MockRepository)
@@ -683,26 +1039,262 @@ func TestHandler_GetChallenges_Success(t *testing.T) {
 	riderID := uuid.New()
 	tier := createTestLoyaltyTier()
 	account := createTestRiderLoyalty(riderID, tier)
-	challenges := []*RiderChallenge{createTestChallengeHandler()}
+	account.AvailablePoints = 10000
+	reward := createTestRewardHandler()
+	stock := 0
+	reward.TotalInventory = &stock
 
 	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
-	mockRepo.On("GetActiveChallenges", mock.Anything, mock.Anything).Return(challenges, nil)
-	mockRepo.On("GetChallengeProgress", mock.Anything, riderID, mock.Anything).Return(nil, errors.New("not found"))
+	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
+	mockRepo.On("RedeemReward", mock.Anything, mock.Anything, mock.Anything).Return(ErrRewardOutOfStock)
 
-	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/challenges", nil)
+	c, w := setupTestContext("POST", "/api/v1/rider/loyalty/rewards/"+reward.ID.String()+"/redeem", nil)
+	c.Params = gin.Params{{Key: "id", Value: reward.ID.String()}}
 	setUserContext(c, riderID)
 
-	handler.GetChallenges(c)
+	handler.RedeemReward(c)
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	response := parseResponse(w)
-	assert.True(t, response["success"].(bool))
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, http.StatusConflict, w.Code)
 }
 
-func TestHandler_GetChallenges_Unauthorized(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-
+// ============================================================================
+// Reward Waitlist Handler Tests
+// ============================================================================
+
+func TestHandler_JoinRewardWaitlist_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	reward := createTestRewardHandler()
+	stock := 0
+	reward.TotalInventory = &stock
+
+	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
+	mockRepo.On("AddToRewardWaitlist", mock.Anything, mock.AnythingOfType("*loyalty.RewardWaitlistEntry")).Return(true, nil)
+
+	c, w := setupTestContext("POST", "/api/v1/rider/loyalty/rewards/"+reward.ID.String()+"/waitlist", nil)
+	c.Params = gin.Params{{Key: "id", Value: reward.ID.String()}}
+	setUserContext(c, riderID)
+
+	handler.JoinRewardWaitlist(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_JoinRewardWaitlist_InvalidRewardID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("POST", "/api/v1/rider/loyalty/rewards/invalid/waitlist", nil)
+	c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+	setUserContext(c, uuid.New())
+
+	handler.JoinRewardWaitlist(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_ReleaseRewardWaitlist_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	reward := createTestRewardHandler()
+	entry := &RewardWaitlistEntry{ID: uuid.New(), RewardID: reward.ID, RiderID: uuid.New()}
+
+	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
+	mockRepo.On("ClaimRewardWaitlist", mock.Anything, reward.ID, 5).Return([]*RewardWaitlistEntry{entry}, nil)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/rewards/"+reward.ID.String()+"/waitlist/release", map[string]interface{}{
+		"count": 5,
+	})
+	c.Params = gin.Params{{Key: "id", Value: reward.ID.String()}}
+
+	handler.ReleaseRewardWaitlist(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Len(t, data["released"], 1)
+}
+
+func TestHandler_ReleaseRewardWaitlist_InvalidCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	rewardID := uuid.New()
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/rewards/"+rewardID.String()+"/waitlist/release", map[string]interface{}{
+		"count": 0,
+	})
+	c.Params = gin.Params{{Key: "id", Value: rewardID.String()}}
+
+	handler.ReleaseRewardWaitlist(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// ============================================================================
+// Redemption Handler Tests
+// ============================================================================
+
+func TestHandler_GetRedemption_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	reward := createTestRewardHandler()
+	redemption := &Redemption{
+		ID:             uuid.New(),
+		RiderID:        uuid.New(),
+		RewardID:       reward.ID,
+		RedemptionCode: "RDM-1a2b3c4d",
+		Status:         RedemptionStatusActive,
+		ExpiresAt:      time.Now().Add(24 * time.Hour),
+	}
+
+	mockRepo.On("GetRedemptionByCode", mock.Anything, redemption.RedemptionCode).Return(redemption, nil)
+	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/loyalty/redemptions/"+redemption.RedemptionCode, nil)
+	c.Params = gin.Params{{Key: "code", Value: redemption.RedemptionCode}}
+
+	handler.GetRedemption(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, redemption.RedemptionCode, data["redemption_code"])
+	assert.NotNil(t, data["reward"])
+}
+
+func TestHandler_UseRedemption_AlreadyUsed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	reward := createTestRewardHandler()
+	redemption := &Redemption{
+		ID:             uuid.New(),
+		RiderID:        uuid.New(),
+		RewardID:       reward.ID,
+		RedemptionCode: "RDM-1a2b3c4d",
+		Status:         RedemptionStatusUsed,
+		ExpiresAt:      time.Now().Add(24 * time.Hour),
+	}
+
+	mockRepo.On("GetRedemptionByCode", mock.Anything, redemption.RedemptionCode).Return(redemption, nil)
+	mockRepo.On("GetReward", mock.Anything, reward.ID).Return(reward, nil)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/redemptions/"+redemption.RedemptionCode+"/use", nil)
+	c.Params = gin.Params{{Key: "code", Value: redemption.RedemptionCode}}
+
+	handler.UseRedemption(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockRepo.AssertNotCalled(t, "MarkRedemptionUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ============================================================================
+// GetChallenges Handler Tests
+// ============================================================================
+
+func TestHandler_GetChallenges_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	tier := createTestLoyaltyTier()
+	account := createTestRiderLoyalty(riderID, tier)
+	challenges := []*RiderChallenge{createTestChallengeHandler()}
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
+	mockRepo.On("GetActiveChallenges", mock.Anything, mock.Anything).Return(challenges, nil)
+	mockRepo.On("GetChallengeProgress", mock.Anything, riderID, mock.Anything).Return(nil, errors.New("not found"))
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/challenges", nil)
+	setUserContext(c, riderID)
+
+	handler.GetChallenges(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	assert.True(t, response["success"].(bool))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetChallengeLeaderboard_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	challenge := createTestChallengeHandler()
+	entries := []*ChallengeLeaderboardEntry{{Rank: 1, RiderID: uuid.New(), CurrentValue: 4, Status: LeaderboardInProgress}}
+
+	mockRepo.On("GetChallenge", mock.Anything, challenge.ID).Return(challenge, nil)
+	mockRepo.On("GetChallengeLeaderboard", mock.Anything, challenge.ID, challenge.TierRestriction, 5).Return(entries, nil)
+	mockRepo.On("GetChallengeRank", mock.Anything, challenge.ID, riderID, challenge.TierRestriction).
+		Return(&ChallengeLeaderboardEntry{Rank: 7, RiderID: riderID, CurrentValue: 1, Status: LeaderboardInProgress}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/challenges/"+challenge.ID.String()+"/leaderboard?limit=5", nil)
+	c.Params = gin.Params{{Key: "id", Value: challenge.ID.String()}}
+	setUserContext(c, riderID)
+
+	handler.GetChallengeLeaderboard(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Len(t, data["entries"], 1)
+	assert.Equal(t, float64(7), data["rider_entry"].(map[string]interface{})["rank"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetChallengeLeaderboard_InvalidInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		challengeID string
+		query       string
+	}{
+		{"invalid challenge ID", "not-a-uuid", ""},
+		{"non-numeric limit", uuid.New().String(), "?limit=abc"},
+		{"zero limit", uuid.New().String(), "?limit=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := createTestHandler(new(MockRepository))
+
+			c, w := setupTestContext("GET", "/api/v1/rider/loyalty/challenges/"+tt.challengeID+"/leaderboard"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.challengeID}}
+			setUserContext(c, uuid.New())
+
+			handler.GetChallengeLeaderboard(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetChallenges_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
 	mockRepo := new(MockRepository)
 	handler := createTestHandler(mockRepo)
 
@@ -866,6 +1458,46 @@ func TestHandler_GetLoyaltyStats_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestHandler_GetLoyaltyDashboard_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	mockRepo.On("GetLoyaltyStats", mock.Anything).Return(&LoyaltyStats{TotalMembers: 10, GoldCount: 4, TotalPointsOutstanding: 2500}, nil)
+	mockRepo.On("GetPointsFlow", mock.Anything, mock.AnythingOfType("time.Time")).Return(&PointsFlow{Earned: 900, Redeemed: 300}, nil)
+	mockRepo.On("GetTopRedeemedRewards", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(nil, nil)
+	mockRepo.On("GetChallengeCompletions", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/loyalty/dashboard?window_days=7", nil)
+
+	handler.GetLoyaltyDashboard(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Equal(t, float64(7), data["window_days"])
+	assert.Equal(t, float64(2500), data["points_liability"])
+	assert.Equal(t, float64(4), data["members_by_tier"].(map[string]interface{})["gold"])
+	assert.Empty(t, data["top_rewards"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetLoyaltyDashboard_InvalidWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, raw := range []string{"abc", "0", "-3"} {
+		mockRepo := new(MockRepository)
+		handler := createTestHandler(mockRepo)
+
+		c, w := setupTestContext("GET", "/api/v1/admin/loyalty/dashboard?window_days="+raw, nil)
+
+		handler.GetLoyaltyDashboard(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, raw)
+		mockRepo.AssertNotCalled(t, "GetLoyaltyStats", mock.Anything)
+	}
+}
+
 // ============================================================================
 // Admin AwardPoints Handler Tests
 // ============================================================================
@@ -981,6 +1613,221 @@ func TestHandler_AwardPoints_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestHandler_AdjustPoints_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	adminID := uuid.New()
+	account := createTestRiderLoyalty(riderID, createTestLoyaltyTier())
+
+	reqBody := map[string]interface{}{
+		"rider_id": riderID.String(),
+		"points":   -100,
+		"reason":   "Duplicate ride credit",
+	}
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
+	mockRepo.On("ApplyPointsAdjustment", mock.Anything, mock.MatchedBy(func(tx *PointsTransaction) bool {
+		return tx.Points == -100 && tx.Metadata["admin_id"] == adminID.String()
+	})).Return(nil)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/adjust", reqBody)
+	setUserContext(c, adminID)
+
+	handler.AdjustPoints(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_AdjustPoints_OverLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	reqBody := map[string]interface{}{
+		"rider_id": uuid.New().String(),
+		"points":   DefaultMaxAdjustmentPoints + 1,
+		"reason":   "Bulk correction",
+	}
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/adjust", reqBody)
+	setUserContext(c, uuid.New())
+
+	handler.AdjustPoints(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ApplyPointsAdjustment")
+}
+
+func TestHandler_AdjustPoints_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/adjust", map[string]interface{}{})
+
+	handler.AdjustPoints(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// ============================================================================
+// Admin Point Promo Handler Tests
+// ============================================================================
+
+func TestHandler_CreatePointPromo_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	reqBody := map[string]interface{}{
+		"name":       "Double points weekend",
+		"multiplier": 2.0,
+		"sources":    []string{"ride"},
+		"starts_at":  startsAt,
+		"ends_at":    startsAt.Add(48 * time.Hour),
+	}
+
+	mockRepo.On("CreatePointPromo", mock.Anything, mock.MatchedBy(func(p *PointMultiplierPromo) bool {
+		return p.Name == "Double points weekend" && len(p.Sources) == 1 && p.Sources[0] == SourceRide
+	})).Return(nil)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/promos", reqBody)
+	setUserContext(c, uuid.New())
+
+	handler.CreatePointPromo(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_CreatePointPromo_InvalidMultiplier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	startsAt := time.Now()
+	reqBody := map[string]interface{}{
+		"name":       "Not a boost",
+		"multiplier": 0.5,
+		"starts_at":  startsAt,
+		"ends_at":    startsAt.Add(time.Hour),
+	}
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/promos", reqBody)
+	setUserContext(c, uuid.New())
+
+	handler.CreatePointPromo(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "CreatePointPromo", mock.Anything, mock.Anything)
+}
+
+func TestHandler_GetActivePointPromos_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	mockRepo.On("GetActivePointPromos", mock.Anything, mock.Anything).Return([]*PointMultiplierPromo{
+		{ID: uuid.New(), Name: "Double points weekend", Multiplier: 2.0, IsActive: true},
+	}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/admin/loyalty/promos", nil)
+	setUserContext(c, uuid.New())
+
+	handler.GetActivePointPromos(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Len(t, data["promos"], 1)
+}
+
+// ============================================================================
+// Admin SimulateRideImpact Handler Tests
+// ============================================================================
+
+func TestHandler_SimulateRideImpact_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	tier := createTestLoyaltyTier()
+	account := createTestRiderLoyalty(riderID, tier)
+
+	reqBody := map[string]interface{}{
+		"rider_id":    riderID.String(),
+		"base_points": 50,
+	}
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
+	mockRepo.On("GetAllTiers", mock.Anything).Return([]*LoyaltyTier{tier}, nil)
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/simulate", reqBody)
+
+	handler.SimulateRideImpact(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	assert.True(t, response["success"].(bool))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(50), data["base_points"])
+	mockRepo.AssertNotCalled(t, "CreatePointsTransaction", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdatePoints", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_SimulateRideImpact_InvalidBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	reqBody := map[string]interface{}{
+		"rider_id":    uuid.New().String(),
+		"base_points": -10,
+	}
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/simulate", reqBody)
+
+	handler.SimulateRideImpact(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_SimulateRideImpact_AccountNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	reqBody := map[string]interface{}{
+		"rider_id":    riderID.String(),
+		"base_points": 10,
+	}
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(nil, errors.New("not found"))
+
+	c, w := setupTestContext("POST", "/api/v1/admin/loyalty/simulate", reqBody)
+
+	handler.SimulateRideImpact(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 // ============================================================================
 // Table-Driven Tests
 // ============================================================================
@@ -1068,10 +1915,7 @@ func TestHandler_RedeemReward_TableDriven(t *testing.T) {
 				reward.ID = rewardID
 				m.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
 				m.On("GetReward", mock.Anything, rewardID).Return(reward, nil)
-				m.On("CreateRedemption", mock.Anything, mock.AnythingOfType("*loyalty.Redemption")).Return(nil)
-				m.On("CreatePointsTransaction", mock.Anything, mock.AnythingOfType("*loyalty.PointsTransaction")).Return(nil)
-				m.On("DeductPoints", mock.Anything, riderID, reward.PointsRequired).Return(nil)
-				m.On("IncrementRewardRedemptionCount", mock.Anything, reward.ID).Return(nil)
+				m.On("RedeemReward", mock.Anything, mock.AnythingOfType("*loyalty.Redemption"), mock.AnythingOfType("*loyalty.PointsTransaction")).Return(nil)
 			},
 			setUserID:      true,
 			expectedStatus: http.StatusOK,
@@ -1203,7 +2047,7 @@ func TestHandler_GetPointsHistory_WithPagination(t *testing.T) {
 		{ID: uuid.New(), RiderID: riderID, Points: 10, CreatedAt: time.Now()},
 	}
 
-	mockRepo.On("GetPointsHistory", mock.Anything, riderID, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(transactions, 50, nil)
+	mockRepo.On("GetPointsHistory", mock.Anything, riderID, PointsHistoryFilter{}, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(transactions, 50, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/points/history?limit=10&offset=20", nil)
 	c.Request.URL.RawQuery = "limit=10&offset=20"
@@ -1230,3 +2074,61 @@ func TestHandler_RedeemReward_EmptyRewardID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+// ============================================================================
+// GetTierComparison Handler Tests
+// ============================================================================
+
+func TestHandler_GetTierComparison_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	riderID := uuid.New()
+	tier := createTestLoyaltyTier()
+	nextTier := &LoyaltyTier{ID: uuid.New(), Name: TierSilver, MinPoints: tier.MinPoints + 1000, Multiplier: 1.5, IsActive: true}
+	account := createTestRiderLoyalty(riderID, tier)
+
+	mockRepo.On("GetRiderLoyalty", mock.Anything, riderID).Return(account, nil)
+	mockRepo.On("GetAllTiers", mock.Anything).Return([]*LoyaltyTier{tier, nextTier}, nil)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/tiers/comparison?avg_points_per_ride=50", nil)
+	setUserContext(c, riderID)
+
+	handler.GetTierComparison(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Equal(t, false, data["at_max_tier"])
+	assert.NotNil(t, data["differences"])
+	assert.NotNil(t, data["estimated_rides_to_next_tier"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_GetTierComparison_InvalidEarnRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockRepo)
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/tiers/comparison?avg_points_per_ride=lots", nil)
+	setUserContext(c, uuid.New())
+
+	handler.GetTierComparison(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetRiderLoyalty", mock.Anything, mock.Anything)
+}
+
+func TestHandler_GetTierComparison_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := createTestHandler(new(MockRepository))
+
+	c, w := setupTestContext("GET", "/api/v1/rider/loyalty/tiers/comparison", nil)
+
+	handler.GetTierComparison(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,148 @@
+package loyalty
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultIdempotencyTTL is how long idempotency keys are kept when none is configured
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultIdempotencyLease is how long a request holds its idempotency key while
+// it runs when none is configured. It outlasts any request, so a key is only
+// reclaimed once the request holding it has died without releasing it.
+const DefaultIdempotencyLease = 2 * time.Minute
+
+// maxIdempotencyKeyLength matches the idempotency_key column
+const maxIdempotencyKeyLength = 255
+
+// earnPointsResult is what an idempotent EarnPoints call records
+type earnPointsResult struct {
+	Points int `json:"points"`
+}
+
+// runIdempotent runs fn at most once per rider, operation and idempotency key.
+// A retry with the same key and request gets the first call's result back; a
+// retry while the first call is still running, or with a different request,
+// is rejected. Failed calls release the key so they can be retried, and a key
+// left in progress by a call that died is reclaimed once its lease runs out.
+// Completed keys are kept for the idempotency TTL. An empty key runs fn
+// unconditionally.
+func runIdempotent[T any](ctx context.Context, s *Service, riderID uuid.UUID, operation IdempotentOperation, key string, req interface{}, fn func() (T, error)) (T, error) {
+	var zero T
+	if key == "" {
+		return fn()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return zero, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "idempotency key is too long", nil)
+	}
+
+	requestHash, err := hashIdempotentRequest(req)
+	if err != nil {
+		return zero, common.NewInternalError("failed to hash request", err)
+	}
+
+	claimed, err := s.repo.ClaimIdempotencyKey(ctx, &IdempotencyKey{
+		RiderID:     riderID,
+		Operation:   operation,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(s.config.IdempotencyLease),
+	})
+	if err != nil {
+		return zero, common.NewInternalError("failed to claim idempotency key", err)
+	}
+
+	if !claimed {
+		return replayIdempotent[T](ctx, s, riderID, operation, key, requestHash)
+	}
+
+	result, err := fn()
+	if err != nil {
+		if releaseErr := s.repo.ReleaseIdempotencyKey(ctx, riderID, operation, key); releaseErr != nil {
+			logger.WarnContext(ctx, "Failed to release idempotency key",
+				zap.String("rider_id", riderID.String()),
+				zap.String("operation", string(operation)),
+				zap.Error(releaseErr),
+			)
+		}
+		return zero, err
+	}
+
+	response, err := json.Marshal(result)
+	if err == nil {
+		err = s.repo.CompleteIdempotencyKey(ctx, riderID, operation, key, response, time.Now().Add(s.config.IdempotencyTTL))
+	}
+	if err != nil {
+		// The effect was applied, so report success; retries will see the key as
+		// in progress until its lease runs out
+		logger.ErrorContext(ctx, "Failed to record idempotent result",
+			zap.String("rider_id", riderID.String()),
+			zap.String("operation", string(operation)),
+			zap.Error(err),
+		)
+	}
+
+	return result, nil
+}
+
+// replayIdempotent returns the recorded result of the request holding a key
+func replayIdempotent[T any](ctx context.Context, s *Service, riderID uuid.UUID, operation IdempotentOperation, key, requestHash string) (T, error) {
+	var result T
+
+	record, err := s.repo.GetIdempotencyKey(ctx, riderID, operation, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The holder failed and released the key between our claim and this read
+			return result, errIdempotencyInProgress()
+		}
+		return result, common.NewInternalError("failed to get idempotency key", err)
+	}
+
+	if record.RequestHash != requestHash {
+		return result, common.NewErrorWithCode(http.StatusUnprocessableEntity, common.ErrCodeIdempotencyKeyReused,
+			"idempotency key has already been used with a different request", nil)
+	}
+
+	if record.Response == nil {
+		return result, errIdempotencyInProgress()
+	}
+
+	if err := json.Unmarshal(record.Response, &result); err != nil {
+		return result, common.NewInternalError("failed to read idempotent result", err)
+	}
+
+	logger.InfoContext(ctx, "Replayed idempotent loyalty request",
+		zap.String("rider_id", riderID.String()),
+		zap.String("operation", string(operation)),
+	)
+
+	return result, nil
+}
+
+// errIdempotencyInProgress is returned for a retry that arrives while the first call runs
+func errIdempotencyInProgress() error {
+	return common.NewErrorWithCode(http.StatusConflict, common.ErrCodeIdempotencyInProgress,
+		"a request with this idempotency key is already in progress", common.ErrConflict)
+}
+
+// hashIdempotentRequest fingerprints a request so a key can't be reused for a different one
+func hashIdempotentRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -2,19 +2,21 @@ package loyalty
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 )
 
 // RepositoryInterface defines the interface for loyalty repository operations
 type RepositoryInterface interface {
 	// Rider Loyalty Account
 	GetRiderLoyalty(ctx context.Context, riderID uuid.UUID) (*RiderLoyalty, error)
-	CreateRiderLoyalty(ctx context.Context, account *RiderLoyalty) error
+	CreateRiderLoyaltyWithSignupBonus(ctx context.Context, account *RiderLoyalty, bonus *PointsTransaction) (bool, error)
 	UpdatePoints(ctx context.Context, riderID uuid.UUID, earnedPoints, tierPoints int) error
-	DeductPoints(ctx context.Context, riderID uuid.UUID, points int) error
 	UpdateTier(ctx context.Context, riderID uuid.UUID, tierID uuid.UUID) error
-	UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int) error
+	UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int, lastRideDate time.Time) error
+	ConsumeTierBenefit(ctx context.Context, riderID, rideID uuid.UUID, benefit TierBenefit, now time.Time) (bool, error)
 
 	// Loyalty Tiers
 	GetTier(ctx context.Context, tierID uuid.UUID) (*LoyaltyTier, error)
@@ -23,22 +25,68 @@ type RepositoryInterface interface {
 
 	// Points Transactions
 	CreatePointsTransaction(ctx context.Context, tx *PointsTransaction) error
-	GetPointsHistory(ctx context.Context, riderID uuid.UUID, limit, offset int) ([]*PointsTransaction, int, error)
+	CreatePointsTransactionsBatch(ctx context.Context, riderID uuid.UUID, txs []*PointsTransaction, earnedPoints, tierPoints int) error
+	ApplyPointsAdjustment(ctx context.Context, tx *PointsTransaction) error
+	GetPointsHistory(ctx context.Context, riderID uuid.UUID, filter PointsHistoryFilter, limit, offset int) ([]*PointsTransaction, int, error)
+	GetPointsHistoryAfter(ctx context.Context, riderID uuid.UUID, after *pagination.Cursor, limit int) ([]*PointsTransaction, error)
 
 	// Rewards
 	GetReward(ctx context.Context, rewardID uuid.UUID) (*RewardCatalogItem, error)
 	GetAvailableRewards(ctx context.Context, tierID *uuid.UUID) ([]*RewardCatalogItem, error)
 	GetUserRedemptionCount(ctx context.Context, riderID, rewardID uuid.UUID) (int, error)
-	CreateRedemption(ctx context.Context, redemption *Redemption) error
-	IncrementRewardRedemptionCount(ctx context.Context, rewardID uuid.UUID) error
+	RedeemReward(ctx context.Context, redemption *Redemption, tx *PointsTransaction) error
+	GetRedemptionByCode(ctx context.Context, code string) (*Redemption, error)
+	GetRedemptionHistory(ctx context.Context, riderID uuid.UUID, status string, now time.Time, limit, offset int) ([]*Redemption, int, error)
+	MarkRedemptionUsed(ctx context.Context, redemptionID uuid.UUID, usedAt time.Time) (bool, error)
+
+	// Reward Waitlist
+	AddToRewardWaitlist(ctx context.Context, entry *RewardWaitlistEntry) (bool, error)
+	ClaimRewardWaitlist(ctx context.Context, rewardID uuid.UUID, limit int) ([]*RewardWaitlistEntry, error)
+
+	// Point Promos
+	GetActivePointPromos(ctx context.Context, at time.Time) ([]*PointMultiplierPromo, error)
+	CreatePointPromo(ctx context.Context, promo *PointMultiplierPromo) error
 
 	// Challenges
 	GetActiveChallenges(ctx context.Context, tierID *uuid.UUID) ([]*RiderChallenge, error)
 	GetActiveChallengesByType(ctx context.Context, challengeType string, tierID *uuid.UUID) ([]*RiderChallenge, error)
 	GetChallengeProgress(ctx context.Context, riderID, challengeID uuid.UUID) (*ChallengeProgress, error)
 	CreateChallengeProgress(ctx context.Context, progress *ChallengeProgress) error
-	UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool) error
+	UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool, activityDate time.Time) error
+	GetChallenge(ctx context.Context, challengeID uuid.UUID) (*RiderChallenge, error)
+	GetChallengeLeaderboard(ctx context.Context, challengeID uuid.UUID, tierRestriction *uuid.UUID, limit int) ([]*ChallengeLeaderboardEntry, error)
+	GetChallengeRank(ctx context.Context, challengeID, riderID uuid.UUID, tierRestriction *uuid.UUID) (*ChallengeLeaderboardEntry, error)
+
+	// Referrals
+	GetReferralByReferee(ctx context.Context, refereeID uuid.UUID) (*Referral, error)
+	CreateReferral(ctx context.Context, referral *Referral) (bool, error)
+	CreditPointsGuarded(ctx context.Context, tx *PointsTransaction, guard PointsGuard) (bool, error)
+	HasLoyaltyHistory(ctx context.Context, riderID, excludeRideID uuid.UUID) (bool, error)
+
+	// Birthdays
+	SetBirthDate(ctx context.Context, riderID uuid.UUID, birthDate time.Time) error
+	GetRidersWithBirthday(ctx context.Context, month time.Month, days []int) ([]*BirthdayRider, error)
+	HasPointsTransaction(ctx context.Context, riderID uuid.UUID, source PointSource, sourceID uuid.UUID) (bool, error)
+
+	// Idempotency Keys
+	ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error)
+	GetIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) (*IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string, response []byte, expiresAt time.Time) error
+	ReleaseIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) error
 
 	// Admin
 	GetLoyaltyStats(ctx context.Context) (*LoyaltyStats, error)
+	GetPointsFlow(ctx context.Context, since time.Time) (*PointsFlow, error)
+	GetTopRedeemedRewards(ctx context.Context, since time.Time, limit int) ([]*RewardRedemptionStats, error)
+	GetChallengeCompletions(ctx context.Context, since time.Time) ([]*ChallengeCompletion, error)
+}
+
+// WaitlistNotifier tells riders that a reward they are waiting for is back in stock
+type WaitlistNotifier interface {
+	NotifyRewardAvailable(ctx context.Context, riderID uuid.UUID, reward *RewardCatalogItem) error
+}
+
+// FareConverter converts a fare into the base currency earning rules are priced in
+type FareConverter interface {
+	ConvertToBase(ctx context.Context, amount float64, currency string) (float64, error)
 }
@@ -0,0 +1,170 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// DefaultLeaderboardCacheTTL is how long a leaderboard is cached when none is configured
+const DefaultLeaderboardCacheTTL = 30 * time.Second
+
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// leaderboardCache holds recently built leaderboards so a live event doesn't
+// rebuild the ranking on every request
+type leaderboardCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedLeaderboard
+}
+
+type cachedLeaderboard struct {
+	board     *ChallengeLeaderboard
+	expiresAt time.Time
+}
+
+func newLeaderboardCache() *leaderboardCache {
+	return &leaderboardCache{entries: make(map[string]*cachedLeaderboard)}
+}
+
+func leaderboardCacheKey(challengeID uuid.UUID, limit int) string {
+	return fmt.Sprintf("%s:%d", challengeID, limit)
+}
+
+func (c *leaderboardCache) get(key string, now time.Time) (*ChallengeLeaderboard, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(cached.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return cached.board, true
+}
+
+func (c *leaderboardCache) set(key string, board *ChallengeLeaderboard, expiresAt time.Time) {
+	c.mu.Lock()
+	c.entries[key] = &cachedLeaderboard{board: board, expiresAt: expiresAt}
+	c.mu.Unlock()
+}
+
+// GetChallengeLeaderboard returns the top limit riders on a challenge, ranked by
+// progress with ties going to whoever completed it first. Only riders whose tier
+// is eligible for the challenge are ranked, and riders who already claimed the
+// reward are marked as such. If riderID is set, the rider's own position is
+// included even when outside the top entries. The top entries are cached for
+// LeaderboardCacheTTL; the rider's own position is always current.
+func (s *Service) GetChallengeLeaderboard(ctx context.Context, challengeID, riderID uuid.UUID, limit int) (*ChallengeLeaderboard, error) {
+	if limit <= 0 {
+		limit = defaultLeaderboardLimit
+	}
+	if limit > maxLeaderboardLimit {
+		limit = maxLeaderboardLimit
+	}
+
+	board, challenge, err := s.topOfLeaderboard(ctx, challengeID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *board
+	if riderID == uuid.Nil {
+		return &result, nil
+	}
+
+	for _, entry := range board.Entries {
+		if entry.RiderID == riderID {
+			result.RiderEntry = entry
+			return &result, nil
+		}
+	}
+
+	if challenge == nil {
+		// The top entries came from the cache
+		if challenge, err = s.getChallenge(ctx, challengeID); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := s.repo.GetChallengeRank(ctx, challengeID, riderID, challenge.TierRestriction)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &result, nil
+		}
+		return nil, common.NewInternalError("failed to get leaderboard rank", err)
+	}
+	entry.ProgressPercent = progressPercent(entry.CurrentValue, challenge.TargetValue)
+	result.RiderEntry = entry
+
+	return &result, nil
+}
+
+// topOfLeaderboard returns the cached top entries of a challenge, or builds and
+// caches them. The challenge is only returned when it had to be loaded.
+func (s *Service) topOfLeaderboard(ctx context.Context, challengeID uuid.UUID, limit int) (*ChallengeLeaderboard, *RiderChallenge, error) {
+	key := leaderboardCacheKey(challengeID, limit)
+	now := time.Now()
+	if board, ok := s.leaderboards.get(key, now); ok {
+		return board, nil, nil
+	}
+
+	challenge, err := s.getChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := s.repo.GetChallengeLeaderboard(ctx, challengeID, challenge.TierRestriction, limit)
+	if err != nil {
+		return nil, nil, common.NewInternalError("failed to get leaderboard", err)
+	}
+	for _, entry := range entries {
+		entry.ProgressPercent = progressPercent(entry.CurrentValue, challenge.TargetValue)
+	}
+
+	board := &ChallengeLeaderboard{
+		ChallengeID:   challenge.ID,
+		ChallengeName: challenge.Name,
+		TargetValue:   challenge.TargetValue,
+		Entries:       entries,
+		GeneratedAt:   now,
+	}
+	s.leaderboards.set(key, board, now.Add(s.config.LeaderboardCacheTTL))
+
+	return board, challenge, nil
+}
+
+func (s *Service) getChallenge(ctx context.Context, challengeID uuid.UUID) (*RiderChallenge, error) {
+	challenge, err := s.repo.GetChallenge(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.NewNotFoundError("challenge not found", err)
+		}
+		return nil, common.NewInternalError("failed to get challenge", err)
+	}
+	return challenge, nil
+}
+
+// progressPercent is how far value is towards target, capped at 100
+func progressPercent(value, target int) float64 {
+	if target <= 0 {
+		return 0
+	}
+	percent := float64(value) / float64(target) * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
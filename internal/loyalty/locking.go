@@ -0,0 +1,178 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Defaults for RedisPointsLocker
+const (
+	// DefaultPointsLockTTL bounds how long a crashed holder can block a rider's balance
+	DefaultPointsLockTTL = 10 * time.Second
+	// DefaultPointsLockWait is how long a balance change waits for the lock before giving up
+	DefaultPointsLockWait = 5 * time.Second
+
+	pointsLockRetryInterval = 25 * time.Millisecond
+)
+
+// errPointsLockBusy is returned when a rider's lock could not be taken in time
+var errPointsLockBusy = errors.New("points lock is held by another operation")
+
+// PointsLocker serializes changes to one rider's points balance, so the read of
+// the account and the writes based on it can't interleave with another change
+type PointsLocker interface {
+	// Lock blocks until riderID's lock is held or ctx ends, and returns the
+	// function that releases it
+	Lock(ctx context.Context, riderID uuid.UUID) (unlock func(), err error)
+}
+
+// SetPointsLocker replaces the in-process lock with one shared between service
+// instances, such as a RedisPointsLocker
+func (s *Service) SetPointsLocker(locker PointsLocker) {
+	s.locker = locker
+}
+
+// lockPoints takes the rider's points lock for a balance change. Callers must
+// call the returned unlock, typically deferred.
+func (s *Service) lockPoints(ctx context.Context, riderID uuid.UUID) (func(), error) {
+	unlock, err := s.locker.Lock(ctx, riderID)
+	if err != nil {
+		if errors.Is(err, errPointsLockBusy) {
+			return nil, common.NewErrorWithCode(http.StatusConflict, common.ErrCodeConflict,
+				"points balance is being updated, please try again", common.ErrConflict)
+		}
+		return nil, common.NewInternalError("failed to lock points balance", err)
+	}
+	return unlock, nil
+}
+
+// ========================================
+// IN-PROCESS LOCK
+// ========================================
+
+// localPointsLocker locks riders within one process. It is the default and is
+// only enough when a single instance changes balances.
+type localPointsLocker struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*riderLock
+}
+
+type riderLock struct {
+	sem     chan struct{}
+	waiters int
+}
+
+func newLocalPointsLocker() *localPointsLocker {
+	return &localPointsLocker{locks: make(map[uuid.UUID]*riderLock)}
+}
+
+func (l *localPointsLocker) Lock(ctx context.Context, riderID uuid.UUID) (func(), error) {
+	l.mu.Lock()
+	lock, ok := l.locks[riderID]
+	if !ok {
+		lock = &riderLock{sem: make(chan struct{}, 1)}
+		l.locks[riderID] = lock
+	}
+	lock.waiters++
+	l.mu.Unlock()
+
+	select {
+	case lock.sem <- struct{}{}:
+		return func() {
+			<-lock.sem
+			l.release(riderID, lock)
+		}, nil
+	case <-ctx.Done():
+		l.release(riderID, lock)
+		return nil, ctx.Err()
+	}
+}
+
+// release drops a waiter and forgets the rider's lock once nobody holds or wants it
+func (l *localPointsLocker) release(riderID uuid.UUID, lock *riderLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.waiters--
+	if lock.waiters == 0 {
+		delete(l.locks, riderID)
+	}
+}
+
+// ========================================
+// REDIS LOCK
+// ========================================
+
+// releasePointsLockScript deletes the lock only if it still holds our token, so
+// a holder whose lock expired can't release the next holder's
+const releasePointsLockScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+// RedisPointsLocker locks riders across service instances with SET NX and a TTL
+type RedisPointsLocker struct {
+	client *goredis.Client
+	ttl    time.Duration
+	wait   time.Duration
+}
+
+// NewRedisPointsLocker creates a Redis-backed points lock. Zero durations use
+// DefaultPointsLockTTL and DefaultPointsLockWait.
+func NewRedisPointsLocker(client *goredis.Client, ttl, wait time.Duration) *RedisPointsLocker {
+	if ttl <= 0 {
+		ttl = DefaultPointsLockTTL
+	}
+	if wait <= 0 {
+		wait = DefaultPointsLockWait
+	}
+	return &RedisPointsLocker{client: client, ttl: ttl, wait: wait}
+}
+
+func pointsLockKey(riderID uuid.UUID) string {
+	return fmt.Sprintf("loyalty:points_lock:%s", riderID)
+}
+
+// Lock polls SET NX until the lock is taken, ctx ends or the wait runs out
+func (l *RedisPointsLocker) Lock(ctx context.Context, riderID uuid.UUID) (func(), error) {
+	key := pointsLockKey(riderID)
+	token := uuid.NewString()
+	deadline := time.Now().Add(l.wait)
+
+	for {
+		acquired, err := l.client.SetNX(ctx, key, token, l.ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return func() { l.unlock(key, token) }, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errPointsLockBusy
+		}
+
+		select {
+		case <-time.After(pointsLockRetryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *RedisPointsLocker) unlock(key, token string) {
+	// Release even if the request's context was cancelled meanwhile
+	if err := l.client.Eval(context.Background(), releasePointsLockScript, []string{key}, token).Err(); err != nil {
+		logger.Warn("Failed to release points lock", zap.String("key", key), zap.Error(err))
+	}
+}
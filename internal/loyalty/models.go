@@ -1,9 +1,11 @@
 package loyalty
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/httputil"
 )
 
 // TierName represents loyalty tier names
@@ -28,18 +30,37 @@ const (
 	TransactionAdjustment TransactionType = "adjustment"
 )
 
+// TierBenefit is a tier benefit with a per-period allowance
+type TierBenefit string
+
+const (
+	BenefitFreeCancellation TierBenefit = "free_cancellation"
+	BenefitFreeUpgrade      TierBenefit = "free_upgrade"
+)
+
 // PointSource represents where points came from
 type PointSource string
 
 const (
-	SourceRide      PointSource = "ride"
-	SourceReferral  PointSource = "referral"
-	SourcePromo     PointSource = "promo"
-	SourcePromotion PointSource = "promotion"
-	SourceChallenge PointSource = "challenge"
-	SourceBirthday  PointSource = "birthday"
-	SourceStreak    PointSource = "streak"
-	SourceSignup    PointSource = "signup"
+	SourceRide       PointSource = "ride"
+	SourceReferral   PointSource = "referral"
+	SourcePromo      PointSource = "promo"
+	SourcePromotion  PointSource = "promotion"
+	SourceChallenge  PointSource = "challenge"
+	SourceBirthday   PointSource = "birthday"
+	SourceStreak     PointSource = "streak"
+	SourceSignup     PointSource = "signup"
+	SourceAdjustment PointSource = "adjustment" // Manual credit or debit by an admin
+)
+
+// RoundingMode controls how fractional points are resolved after applying a tier multiplier
+type RoundingMode string
+
+const (
+	RoundingTruncate RoundingMode = "truncate" // Drop the fractional part (default)
+	RoundingFloor    RoundingMode = "floor"    // Always round down
+	RoundingRound    RoundingMode = "round"    // Round half away from zero
+	RoundingBanker   RoundingMode = "banker"   // Round half to even
 )
 
 // LoyaltyTier represents a loyalty tier configuration
@@ -95,9 +116,24 @@ type PointsTransaction struct {
 	SourceID        *uuid.UUID      `json:"source_id,omitempty" db:"source_id"`
 	Description     *string         `json:"description,omitempty" db:"description"`
 	ExpiresAt       *time.Time      `json:"expires_at,omitempty" db:"expires_at"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
 	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
 }
 
+// PointMultiplierPromo is a limited-time boost such as "2x points this weekend".
+// While it runs it multiplies earned points on top of the tier multiplier.
+type PointMultiplierPromo struct {
+	ID              uuid.UUID     `json:"id" db:"id"`
+	Name            string        `json:"name" db:"name"`
+	Multiplier      float64       `json:"multiplier" db:"multiplier"`
+	Sources         []PointSource `json:"sources" db:"sources"` // Empty applies to every source
+	TierRestriction *uuid.UUID    `json:"tier_restriction,omitempty" db:"tier_restriction"`
+	StartsAt        time.Time     `json:"starts_at" db:"starts_at"`
+	EndsAt          time.Time     `json:"ends_at" db:"ends_at"` // Exclusive
+	IsActive        bool          `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+}
+
 // RiderChallenge represents a rider challenge
 type RiderChallenge struct {
 	ID              uuid.UUID  `json:"id" db:"id"`
@@ -118,19 +154,49 @@ type RiderChallenge struct {
 
 // ChallengeProgress represents a rider's progress on a challenge
 type ChallengeProgress struct {
-	ID              uuid.UUID        `json:"id" db:"id"`
-	RiderID         uuid.UUID        `json:"rider_id" db:"rider_id"`
-	ChallengeID     uuid.UUID        `json:"challenge_id" db:"challenge_id"`
-	Challenge       *RiderChallenge  `json:"challenge,omitempty"`
-	CurrentValue    int              `json:"current_value" db:"current_value"`
-	TargetValue     int              `json:"target_value"`
-	ProgressPercent float64          `json:"progress_percent"`
-	Completed       bool             `json:"completed" db:"completed"`
-	CompletedAt     *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
-	RewardClaimed   bool             `json:"reward_claimed" db:"reward_claimed"`
-	RewardClaimedAt *time.Time       `json:"reward_claimed_at,omitempty" db:"reward_claimed_at"`
-	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID       `json:"id" db:"id"`
+	RiderID          uuid.UUID       `json:"rider_id" db:"rider_id"`
+	ChallengeID      uuid.UUID       `json:"challenge_id" db:"challenge_id"`
+	Challenge        *RiderChallenge `json:"challenge,omitempty"`
+	CurrentValue     int             `json:"current_value" db:"current_value"`
+	TargetValue      int             `json:"target_value"`
+	ProgressPercent  float64         `json:"progress_percent"`
+	Completed        bool            `json:"completed" db:"completed"`
+	CompletedAt      *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	LastActivityDate *time.Time      `json:"last_activity_date,omitempty" db:"last_activity_date"`
+	RewardClaimed    bool            `json:"reward_claimed" db:"reward_claimed"`
+	RewardClaimedAt  *time.Time      `json:"reward_claimed_at,omitempty" db:"reward_claimed_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// LeaderboardStatus is where a rider stands in a challenge
+type LeaderboardStatus string
+
+const (
+	LeaderboardInProgress LeaderboardStatus = "in_progress"
+	LeaderboardCompleted  LeaderboardStatus = "completed"
+	LeaderboardClaimed    LeaderboardStatus = "claimed" // Completed and reward claimed; no longer competing
+)
+
+// ChallengeLeaderboardEntry is one rider's position on a challenge leaderboard
+type ChallengeLeaderboardEntry struct {
+	Rank            int               `json:"rank"`
+	RiderID         uuid.UUID         `json:"rider_id"`
+	CurrentValue    int               `json:"current_value"`
+	ProgressPercent float64           `json:"progress_percent"`
+	Status          LeaderboardStatus `json:"status"`
+	CompletedAt     *time.Time        `json:"completed_at,omitempty"`
+}
+
+// ChallengeLeaderboard ranks the riders eligible for a challenge by progress
+type ChallengeLeaderboard struct {
+	ChallengeID   uuid.UUID                    `json:"challenge_id"`
+	ChallengeName string                       `json:"challenge_name"`
+	TargetValue   int                          `json:"target_value"`
+	Entries       []*ChallengeLeaderboardEntry `json:"entries"`
+	RiderEntry    *ChallengeLeaderboardEntry   `json:"rider_entry,omitempty"` // The requesting rider, even outside the top entries
+	GeneratedAt   time.Time                    `json:"generated_at"`
 }
 
 // RewardCatalogItem represents an item in the rewards catalog
@@ -146,6 +212,7 @@ type RewardCatalogItem struct {
 	ValidDays            int        `json:"valid_days" db:"valid_days"`
 	MaxRedemptionsPerUser *int      `json:"max_redemptions_per_user,omitempty" db:"max_redemptions_per_user"`
 	TotalAvailable       *int       `json:"total_available,omitempty" db:"total_available"`
+	TotalInventory       *int       `json:"total_inventory,omitempty" db:"total_inventory"` // Remaining stock; nil means unlimited
 	RedeemedCount        int        `json:"redeemed_count" db:"redeemed_count"`
 	TierRestriction      *uuid.UUID `json:"tier_restriction,omitempty" db:"tier_restriction"`
 	IsActive             bool       `json:"is_active" db:"is_active"`
@@ -158,7 +225,9 @@ type Redemption struct {
 	RiderID        uuid.UUID  `json:"rider_id" db:"rider_id"`
 	RewardID       uuid.UUID  `json:"reward_id" db:"reward_id"`
 	Reward         *RewardCatalogItem `json:"reward,omitempty"`
+	RewardName     string     `json:"reward_name,omitempty" db:"reward_name"`
 	PointsSpent    int        `json:"points_spent" db:"points_spent"`
+	CashAmount     float64    `json:"cash_amount,omitempty" db:"cash_amount"` // Owed in cash when only part was paid in points
 	RedemptionCode string     `json:"redemption_code" db:"redemption_code"`
 	Status         string     `json:"status" db:"status"`
 	UsedAt         *time.Time `json:"used_at,omitempty" db:"used_at"`
@@ -166,6 +235,79 @@ type Redemption struct {
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 }
 
+// Redemption statuses
+const (
+	RedemptionStatusActive    = "active"
+	RedemptionStatusUsed      = "used"
+	RedemptionStatusExpired   = "expired"
+	RedemptionStatusCancelled = "cancelled"
+)
+
+// RewardWaitlistEntry records a rider's interest in an out-of-stock reward
+type RewardWaitlistEntry struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	RewardID   uuid.UUID  `json:"reward_id" db:"reward_id"`
+	RiderID    uuid.UUID  `json:"rider_id" db:"rider_id"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IdempotentOperation names the loyalty operations that accept idempotency keys
+type IdempotentOperation string
+
+const (
+	OperationEarnPoints   IdempotentOperation = "earn"
+	OperationRedeemPoints IdempotentOperation = "redeem"
+)
+
+// IdempotencyKey records a request made with an idempotency key and, once it
+// has completed, its result
+type IdempotencyKey struct {
+	RiderID     uuid.UUID           `json:"rider_id" db:"rider_id"`
+	Operation   IdempotentOperation `json:"operation" db:"operation"`
+	Key         string              `json:"idempotency_key" db:"idempotency_key"`
+	RequestHash string              `json:"request_hash" db:"request_hash"`
+	Response    json.RawMessage     `json:"response,omitempty" db:"response"` // nil while in progress
+	CreatedAt   time.Time           `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time           `json:"expires_at" db:"expires_at"`
+}
+
+// Referral links a referrer to a referee whose first ride earned both a bonus
+type Referral struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	ReferrerID         uuid.UUID  `json:"referrer_id" db:"referrer_id"`
+	RefereeID          uuid.UUID  `json:"referee_id" db:"referee_id"`
+	FirstRideID        uuid.UUID  `json:"first_ride_id" db:"first_ride_id"`
+	ReferrerPoints     int        `json:"referrer_points" db:"referrer_points"`
+	RefereePoints      int        `json:"referee_points" db:"referee_points"`
+	ReferrerRewardedAt *time.Time `json:"referrer_rewarded_at,omitempty" db:"referrer_rewarded_at"`
+	RefereeRewardedAt  *time.Time `json:"referee_rewarded_at,omitempty" db:"referee_rewarded_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReferralSide identifies which participant of a referral was rewarded
+type ReferralSide string
+
+const (
+	ReferralSideReferrer ReferralSide = "referrer"
+	ReferralSideReferee  ReferralSide = "referee"
+)
+
+// BirthdayRider is a rider whose birthday falls on a sweep's date
+type BirthdayRider struct {
+	RiderID   uuid.UUID `json:"rider_id" db:"rider_id"`
+	BirthDate time.Time `json:"birth_date" db:"birth_date"`
+}
+
+// BirthdaySweepResult summarizes one run of the birthday bonus sweep
+type BirthdaySweepResult struct {
+	Date           time.Time `json:"date"`
+	Awarded        int       `json:"awarded"`
+	AlreadyAwarded int       `json:"already_awarded"`
+	Failed         int       `json:"failed"`
+	PointsAwarded  int       `json:"points_awarded"`
+}
+
 // ========================================
 // REQUEST/RESPONSE TYPES
 // ========================================
@@ -193,12 +335,43 @@ type EarnPointsRequest struct {
 	Source      PointSource `json:"source"`
 	SourceID    *uuid.UUID  `json:"source_id,omitempty"`
 	Description string      `json:"description,omitempty"`
+	// Attributes price the points with the source's earning rule when Points
+	// is zero
+	Attributes *EarningAttributes `json:"attributes,omitempty"`
+	// IdempotencyKey makes retries with the same key return the original result
+	// instead of awarding the points again
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// EarnPointsBatchResponse represents the result of awarding several point entries at once
+type EarnPointsBatchResponse struct {
+	RiderID      uuid.UUID               `json:"rider_id"`
+	Entries      []EarnPointsBatchResult `json:"entries"`
+	TotalAwarded int                     `json:"total_awarded"`
+	BalanceAfter int                     `json:"balance_after"`
+}
+
+// EarnPointsBatchResult represents the points awarded for a single batch entry
+type EarnPointsBatchResult struct {
+	Source        PointSource `json:"source"`
+	SourceID      *uuid.UUID  `json:"source_id,omitempty"`
+	BasePoints    int         `json:"base_points"`
+	AwardedPoints int         `json:"awarded_points"`
 }
 
 // RedeemPointsRequest represents a request to redeem points
 type RedeemPointsRequest struct {
 	RiderID  uuid.UUID `json:"rider_id"`
 	RewardID uuid.UUID `json:"reward_id"`
+	// IdempotencyKey makes retries with the same key return the original
+	// redemption instead of deducting the points again
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// RedeemPartialRequest is the points a rider applies towards a reward, paying
+// the rest in cash
+type RedeemPartialRequest struct {
+	Points int `json:"points" binding:"required,gt=0"`
 }
 
 // RedeemPointsResponse represents the response after redeeming points
@@ -206,17 +379,38 @@ type RedeemPointsResponse struct {
 	RedemptionID   uuid.UUID  `json:"redemption_id"`
 	RedemptionCode string     `json:"redemption_code"`
 	PointsSpent    int        `json:"points_spent"`
+	CashAmount     float64    `json:"cash_amount,omitempty"` // Left to charge in cash for a partial redemption
 	BalanceAfter   int        `json:"balance_after"`
 	ExpiresAt      time.Time  `json:"expires_at"`
 	Instructions   string     `json:"instructions,omitempty"`
 }
 
+// PointsHistoryFilter narrows a rider's points history. Zero fields don't filter.
+type PointsHistoryFilter struct {
+	TransactionType TransactionType
+	Source          PointSource
+	From            *time.Time // Inclusive
+	To              *time.Time // Exclusive
+}
+
 // PointsHistoryResponse represents points history
 type PointsHistoryResponse struct {
 	Transactions []PointsTransaction `json:"transactions"`
-	Total        int                 `json:"total"`
+	httputil.PageInfo
+}
+
+// PointsHistoryPage is a page of a rider's points history fetched by cursor.
+// NextCursor fetches the page after it and is empty on the last page.
+type PointsHistoryPage struct {
+	Transactions []PointsTransaction `json:"transactions"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
 	Limit        int                 `json:"limit"`
-	Offset       int                 `json:"offset"`
+}
+
+// RedemptionHistoryResponse represents a rider's redeemed rewards
+type RedemptionHistoryResponse struct {
+	Redemptions []Redemption `json:"redemptions"`
+	httputil.PageInfo
 }
 
 // ActiveChallengesResponse represents active challenges for a rider
@@ -232,3 +426,129 @@ type ChallengeWithProgress struct {
 	Completed       bool           `json:"completed"`
 	DaysRemaining   int            `json:"days_remaining"`
 }
+
+// StreakMilestone awards bonus points when a ride streak reaches a given length
+type StreakMilestone struct {
+	Days        int `json:"days"`
+	BonusPoints int `json:"bonus_points"`
+}
+
+// StreakUpdateResult describes the outcome of recording a ride against a streak
+type StreakUpdateResult struct {
+	RiderID     uuid.UUID `json:"rider_id"`
+	StreakDays  int       `json:"streak_days"`
+	StreakReset bool      `json:"streak_reset"`
+	BonusPoints int       `json:"bonus_points"`
+}
+
+// AdjustPointsRequest is an admin's manual credit (positive points) or debit (negative points)
+type AdjustPointsRequest struct {
+	RiderID  uuid.UUID `json:"rider_id" binding:"required"`
+	Points   int       `json:"points" binding:"required"`
+	Reason   string    `json:"reason" binding:"required"`
+	Override bool      `json:"override"` // Confirms an adjustment above the configured limit
+}
+
+// CreatePointPromoRequest is the admin request to schedule a point multiplier promo
+type CreatePointPromoRequest struct {
+	Name            string        `json:"name" binding:"required"`
+	Multiplier      float64       `json:"multiplier" binding:"required"`
+	Sources         []PointSource `json:"sources"`
+	TierRestriction *uuid.UUID    `json:"tier_restriction"`
+	StartsAt        time.Time     `json:"starts_at" binding:"required"`
+	EndsAt          time.Time     `json:"ends_at" binding:"required"`
+}
+
+// SimulateRideImpactRequest is the admin request to preview a ride's loyalty impact
+type SimulateRideImpactRequest struct {
+	RiderID       uuid.UUID `json:"rider_id" binding:"required"`
+	BasePoints    int       `json:"base_points" binding:"gte=0"`
+	ChallengeType string    `json:"challenge_type"`
+	Increment     int       `json:"increment" binding:"gte=0"`
+}
+
+// PointsPreview is what an earn would credit a rider, computed the same way
+// EarnPoints does but without writing anything
+type PointsPreview struct {
+	RiderID              uuid.UUID             `json:"rider_id"`
+	Source               PointSource           `json:"source"`
+	BasePoints           int                   `json:"base_points"`
+	Multiplier           float64               `json:"multiplier"` // Tier multiplier
+	Promo                *PointMultiplierPromo `json:"promo,omitempty"`
+	RoundingMode         RoundingMode          `json:"rounding_mode"`
+	ProjectedPoints      int                   `json:"projected_points"`
+	AvailablePointsAfter int                   `json:"available_points_after"`
+	TierPointsAfter      int                   `json:"tier_points_after"`
+	CurrentTier          *LoyaltyTier          `json:"current_tier,omitempty"`
+	ProjectedTier        *LoyaltyTier          `json:"projected_tier,omitempty"`
+	TierChanged          bool                  `json:"tier_changed"`
+	NextTier             *LoyaltyTier          `json:"next_tier,omitempty"`
+	PointsToNextTier     int                   `json:"points_to_next_tier"`
+	TierProgress         float64               `json:"tier_progress_percent"`
+}
+
+// SimulatedChallengeProgress is the projected effect of a ride on one challenge
+type SimulatedChallengeProgress struct {
+	ChallengeID    uuid.UUID `json:"challenge_id"`
+	Name           string    `json:"name"`
+	CurrentValue   int       `json:"current_value"`
+	ProjectedValue int       `json:"projected_value"`
+	TargetValue    int       `json:"target_value"`
+	WillComplete   bool      `json:"will_complete"`
+	RewardPoints   int       `json:"reward_points"` // After tier multiplier, 0 unless completed
+}
+
+// RideImpactSimulation is the projected loyalty impact of a ride. Nothing is persisted.
+type RideImpactSimulation struct {
+	RiderID              uuid.UUID                     `json:"rider_id"`
+	BasePoints           int                           `json:"base_points"`
+	Multiplier           float64                       `json:"multiplier"`
+	Promo                *PointMultiplierPromo         `json:"promo,omitempty"` // Promo applied to the ride points
+	RidePoints           int                           `json:"ride_points"`
+	ChallengePoints      int                           `json:"challenge_points"`
+	TotalPoints          int                           `json:"total_points"`
+	AvailablePointsAfter int                           `json:"available_points_after"`
+	TierPointsAfter      int                           `json:"tier_points_after"`
+	Challenges           []*SimulatedChallengeProgress `json:"challenges"`
+	CurrentTier          *LoyaltyTier                  `json:"current_tier,omitempty"`
+	ProjectedTier        *LoyaltyTier                  `json:"projected_tier,omitempty"`
+	TierChanged          bool                          `json:"tier_changed"`
+}
+
+// LoyaltyDashboard is the admin overview of the loyalty program. Membership and
+// the outstanding points are current; the rest covers the window before GeneratedAt.
+type LoyaltyDashboard struct {
+	WindowDays      int                      `json:"window_days"`
+	Since           time.Time                `json:"since"`
+	TotalMembers    int                      `json:"total_members"`
+	MembersByTier   map[TierName]int         `json:"members_by_tier"`
+	PointsLiability int64                    `json:"points_liability"` // Available points across all riders
+	PointsFlow      *PointsFlow              `json:"points_flow"`
+	TopRewards      []*RewardRedemptionStats `json:"top_rewards"`
+	Challenges      []*ChallengeCompletion   `json:"challenges"`
+	GeneratedAt     time.Time                `json:"generated_at"`
+}
+
+// PointsFlow is how many points moved in a period, each as a positive amount
+type PointsFlow struct {
+	Earned   int64 `json:"earned"` // Earns and bonuses
+	Redeemed int64 `json:"redeemed"`
+	Expired  int64 `json:"expired"`
+}
+
+// RewardRedemptionStats is how often a reward was redeemed in a period
+type RewardRedemptionStats struct {
+	RewardID    uuid.UUID `json:"reward_id"`
+	Name        string    `json:"name"`
+	Redemptions int       `json:"redemptions"`
+	PointsSpent int64     `json:"points_spent"`
+}
+
+// ChallengeCompletion is how many riders who joined a challenge completed it
+type ChallengeCompletion struct {
+	ChallengeID    uuid.UUID `json:"challenge_id"`
+	Name           string    `json:"name"`
+	Participants   int       `json:"participants"`
+	Completed      int       `json:"completed"`
+	CompletionRate float64   `json:"completion_rate"` // Percent of participants
+}
@@ -0,0 +1,56 @@
+package loyalty
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// RedeemPartial redeems a reward paying pointsToApply of its points price in
+// points and the rest in cash. The cash owed is the unpaid share of the
+// reward's value, rounded to the cent, and is recorded on the redemption for
+// the payment service to charge. Only rewards with a value can be split, and
+// the rider needs just the points applied, not the full price.
+func (s *Service) RedeemPartial(ctx context.Context, riderID, rewardID uuid.UUID, pointsToApply int) (*RedeemPointsResponse, error) {
+	return s.redeemReward(ctx, riderID, rewardID, &pointsToApply)
+}
+
+// splitRewardPrice returns the points to deduct and the cash owed when the
+// account pays pointsToApply towards the reward
+func splitRewardPrice(account *RiderLoyalty, reward *RewardCatalogItem, pointsToApply int) (int, float64, error) {
+	if pointsToApply < 1 {
+		return 0, 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "points to apply must be at least 1", nil)
+	}
+	if pointsToApply > reward.PointsRequired {
+		return 0, 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation,
+			fmt.Sprintf("cannot apply %d points, the reward costs %d", pointsToApply, reward.PointsRequired), nil)
+	}
+	if account.AvailablePoints < pointsToApply {
+		return 0, 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeInsufficientPoints,
+			fmt.Sprintf("insufficient points: need %d, have %d", pointsToApply, account.AvailablePoints), nil)
+	}
+	if pointsToApply == reward.PointsRequired {
+		return pointsToApply, 0, nil
+	}
+	if reward.Value == nil || *reward.Value <= 0 {
+		return 0, 0, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "this reward can only be redeemed in full with points", nil)
+	}
+
+	unpaid := float64(reward.PointsRequired-pointsToApply) / float64(reward.PointsRequired)
+	return pointsToApply, math.Round(*reward.Value*unpaid*100) / 100, nil
+}
+
+// withoutReason drops the reasons with a code
+func withoutReason(reasons []RewardIneligibility, code string) []RewardIneligibility {
+	kept := reasons[:0]
+	for _, r := range reasons {
+		if r.Code != code {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,182 @@
+package loyalty
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/audit"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Point multiplier promos stack on the tier multiplier but not on each other:
+// when several promos cover an earn, only the one with the highest multiplier
+// applies, and a tie goes to the promo that started first (then the lowest ID),
+// so the same earn always picks the same promo. Points are rounded once, after
+// both multipliers.
+
+// CreatePointPromo schedules a point multiplier promo (admin)
+func (s *Service) CreatePointPromo(ctx context.Context, req *CreatePointPromoRequest) (*PointMultiplierPromo, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "name is required", nil)
+	}
+	if req.Multiplier <= 1 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "multiplier must be greater than 1", nil)
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "ends_at must be after starts_at", nil)
+	}
+	if req.TierRestriction != nil {
+		if _, err := s.repo.GetTier(ctx, *req.TierRestriction); err != nil {
+			return nil, common.NewNotFoundError("tier not found", err)
+		}
+	}
+
+	promo := &PointMultiplierPromo{
+		ID:              uuid.New(),
+		Name:            name,
+		Multiplier:      req.Multiplier,
+		Sources:         req.Sources,
+		TierRestriction: req.TierRestriction,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		IsActive:        true,
+	}
+	if err := s.repo.CreatePointPromo(ctx, promo); err != nil {
+		return nil, common.NewInternalError("failed to create promo", err)
+	}
+
+	logger.InfoContext(ctx, "Point promo created",
+		zap.String("promo_id", promo.ID.String()),
+		zap.String("name", promo.Name),
+		zap.Float64("multiplier", promo.Multiplier),
+		zap.Time("starts_at", promo.StartsAt),
+		zap.Time("ends_at", promo.EndsAt),
+	)
+
+	s.audit.Record(ctx, audit.Entry{
+		Action:     "loyalty.promo_created",
+		TargetType: "point_promo",
+		TargetID:   promo.ID.String(),
+		After: map[string]interface{}{
+			"name":       promo.Name,
+			"multiplier": promo.Multiplier,
+			"starts_at":  promo.StartsAt,
+			"ends_at":    promo.EndsAt,
+		},
+	})
+
+	return promo, nil
+}
+
+// GetActivePointPromos returns the point multiplier promos running now
+func (s *Service) GetActivePointPromos(ctx context.Context) ([]*PointMultiplierPromo, error) {
+	promos, err := s.activePointPromos(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if promos == nil {
+		promos = []*PointMultiplierPromo{}
+	}
+	return promos, nil
+}
+
+func (s *Service) activePointPromos(ctx context.Context, at time.Time) ([]*PointMultiplierPromo, error) {
+	promos, err := s.repo.GetActivePointPromos(ctx, at)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get point promos", err)
+	}
+	return promos, nil
+}
+
+// promoApplies reports whether promo covers an earn from source, at the given
+// time, by a rider in tierID
+func promoApplies(promo *PointMultiplierPromo, source PointSource, tierID *uuid.UUID, at time.Time) bool {
+	if !promo.IsActive || at.Before(promo.StartsAt) || !at.Before(promo.EndsAt) {
+		return false
+	}
+	if promo.TierRestriction != nil && (tierID == nil || *tierID != *promo.TierRestriction) {
+		return false
+	}
+	if len(promo.Sources) == 0 {
+		return true
+	}
+	for _, s := range promo.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// bestPromo picks the promo that applies to an earn, or nil if none does
+func bestPromo(promos []*PointMultiplierPromo, source PointSource, tierID *uuid.UUID, at time.Time) *PointMultiplierPromo {
+	var best *PointMultiplierPromo
+	for _, promo := range promos {
+		if !promoApplies(promo, source, tierID, at) {
+			continue
+		}
+		if best == nil || promoPrecedes(promo, best) {
+			best = promo
+		}
+	}
+	return best
+}
+
+// promoPrecedes reports whether a takes precedence over b
+func promoPrecedes(a, b *PointMultiplierPromo) bool {
+	if a.Multiplier != b.Multiplier {
+		return a.Multiplier > b.Multiplier
+	}
+	if !a.StartsAt.Equal(b.StartsAt) {
+		return a.StartsAt.Before(b.StartsAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+// pointsEarning is how the points of one earn were worked out
+type pointsEarning struct {
+	BasePoints     int
+	TierMultiplier float64
+	Promo          *PointMultiplierPromo
+	Points         int
+}
+
+// earning works out the points an earn of basePoints from source credits to
+// account: the tier multiplier, then the promo that applies at the given time.
+// Every earn path and the previews go through here so a preview always matches
+// the award. Without applyMultiplier the base points are credited as they are.
+func (s *Service) earning(account *RiderLoyalty, basePoints int, source PointSource, promos []*PointMultiplierPromo, at time.Time, applyMultiplier bool) pointsEarning {
+	e := pointsEarning{BasePoints: basePoints, TierMultiplier: 1.0}
+	multiplier := 1.0
+	if applyMultiplier {
+		e.TierMultiplier = tierMultiplier(account)
+		e.Promo = bestPromo(promos, source, account.CurrentTierID, at)
+		multiplier = e.TierMultiplier
+		if e.Promo != nil {
+			multiplier *= e.Promo.Multiplier
+		}
+	}
+	e.Points = s.applyMultiplier(basePoints, multiplier)
+	return e
+}
+
+// earningMetadata records how an earn transaction was computed for audits
+func (s *Service) earningMetadata(e pointsEarning) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"base_points":   e.BasePoints,
+		"multiplier":    e.TierMultiplier,
+		"rounding_mode": string(s.config.RoundingMode),
+	}
+	if e.Promo != nil {
+		metadata["promo_id"] = e.Promo.ID.String()
+		metadata["promo_name"] = e.Promo.Name
+		metadata["promo_multiplier"] = e.Promo.Multiplier
+	}
+	return metadata
+}
@@ -2,16 +2,25 @@ package loyalty
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/outbox"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 )
 
+// EventTierUpgraded is published to the outbox when a rider moves to a new tier
+const EventTierUpgraded = "loyalty.tier_upgraded"
+
 // Repository handles database operations for loyalty
 type Repository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	events *outbox.Publisher
 }
 
 // NewRepository creates a new loyalty repository
@@ -19,6 +28,12 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
+// SetOutbox sets the publisher tier changes are recorded with, in the same
+// transaction as the change. Without one no events are written.
+func (r *Repository) SetOutbox(events *outbox.Publisher) {
+	r.events = events
+}
+
 // ========================================
 // RIDER LOYALTY ACCOUNT
 // ========================================
@@ -62,88 +77,188 @@ func (r *Repository) GetRiderLoyalty(ctx context.Context, riderID uuid.UUID) (*R
 	return account, nil
 }
 
-// CreateRiderLoyalty creates a new rider loyalty account
-func (r *Repository) CreateRiderLoyalty(ctx context.Context, account *RiderLoyalty) error {
-	query := `
+// CreateRiderLoyaltyWithSignupBonus creates a rider's loyalty account and
+// records its signup bonus in one database transaction, so an account never
+// exists without its bonus. The account's balances must already include the
+// bonus. It returns false, creating nothing, if the rider already has an account.
+func (r *Repository) CreateRiderLoyaltyWithSignupBonus(ctx context.Context, account *RiderLoyalty, bonus *PointsTransaction) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer dbTx.Rollback(ctx)
+
+	accountQuery := `
 		INSERT INTO rider_loyalty (
 			rider_id, current_tier_id, total_points, available_points,
 			lifetime_points, tier_points, tier_period_start, tier_period_end, joined_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (rider_id) DO NOTHING
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	tag, err := dbTx.Exec(ctx, accountQuery,
 		account.RiderID, account.CurrentTierID, account.TotalPoints, account.AvailablePoints,
 		account.LifetimePoints, account.TierPoints, account.TierPeriodStart, account.TierPeriodEnd,
 		account.JoinedAt,
 	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
 
-	return err
+	if _, err := dbTx.Exec(ctx, insertPointsTransactionQuery, pointsTransactionArgs(bonus)...); err != nil {
+		return false, err
+	}
+
+	return true, dbTx.Commit(ctx)
 }
 
+// creditPointsQuery adds earned points ($1) and tier points ($2) to a rider's
+// account ($3)
+const creditPointsQuery = `
+	UPDATE rider_loyalty
+	SET available_points = available_points + $1,
+	    total_points = total_points + $1,
+	    lifetime_points = lifetime_points + $1,
+	    tier_points = tier_points + $2,
+	    updated_at = NOW()
+	WHERE rider_id = $3
+`
+
 // UpdatePoints updates a rider's points balance
 func (r *Repository) UpdatePoints(ctx context.Context, riderID uuid.UUID, earnedPoints, tierPoints int) error {
-	query := `
-		UPDATE rider_loyalty
-		SET available_points = available_points + $1,
-		    total_points = total_points + $1,
-		    lifetime_points = lifetime_points + $1,
-		    tier_points = tier_points + $2,
-		    updated_at = NOW()
-		WHERE rider_id = $3
-	`
-
-	_, err := r.db.Exec(ctx, query, earnedPoints, tierPoints, riderID)
+	_, err := r.db.Exec(ctx, creditPointsQuery, earnedPoints, tierPoints, riderID)
 	return err
 }
 
-// DeductPoints deducts points from a rider's balance
-func (r *Repository) DeductPoints(ctx context.Context, riderID uuid.UUID, points int) error {
+// UpdateTier updates a rider's tier
+func (r *Repository) UpdateTier(ctx context.Context, riderID uuid.UUID, tierID uuid.UUID) error {
 	query := `
 		UPDATE rider_loyalty
-		SET available_points = available_points - $1,
+		SET current_tier_id = $1,
+		    free_cancellations_used = 0,
+		    free_upgrades_used = 0,
 		    updated_at = NOW()
-		WHERE rider_id = $2 AND available_points >= $1
+		WHERE rider_id = $2
+		RETURNING (SELECT name FROM loyalty_tiers WHERE id = $1)
 	`
 
-	result, err := r.db.Exec(ctx, query, points, riderID)
+	if r.events == nil {
+		_, err := r.db.Exec(ctx, query, tierID, riderID)
+		return err
+	}
+
+	dbTx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer dbTx.Rollback(ctx)
 
-	if result.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+	var tierName *string
+	if err := dbTx.QueryRow(ctx, query, tierID, riderID).Scan(&tierName); err != nil {
+		return err
 	}
 
-	return nil
+	payload := map[string]interface{}{
+		"rider_id":  riderID,
+		"tier_id":   tierID,
+		"tier_name": tierName,
+	}
+	if _, err := r.events.Publish(ctx, dbTx, EventTierUpgraded, &riderID, payload); err != nil {
+		return err
+	}
+
+	return dbTx.Commit(ctx)
 }
 
-// UpdateTier updates a rider's tier
-func (r *Repository) UpdateTier(ctx context.Context, riderID uuid.UUID, tierID uuid.UUID) error {
+// UpdateStreak updates a rider's streak and the local date of their last ride
+func (r *Repository) UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int, lastRideDate time.Time) error {
 	query := `
 		UPDATE rider_loyalty
-		SET current_tier_id = $1,
-		    free_cancellations_used = 0,
-		    free_upgrades_used = 0,
+		SET streak_days = $1,
+		    last_ride_date = $2,
 		    updated_at = NOW()
-		WHERE rider_id = $2
+		WHERE rider_id = $3
 	`
 
-	_, err := r.db.Exec(ctx, query, tierID, riderID)
+	_, err := r.db.Exec(ctx, query, streakDays, lastRideDate, riderID)
 	return err
 }
 
-// UpdateStreak updates a rider's streak
-func (r *Repository) UpdateStreak(ctx context.Context, riderID uuid.UUID, streakDays int) error {
-	query := `
-		UPDATE rider_loyalty
-		SET streak_days = $1,
-		    last_ride_date = NOW(),
+// tierBenefitColumns are the used-counter and tier allowance columns of each benefit
+var tierBenefitColumns = map[TierBenefit][2]string{
+	BenefitFreeCancellation: {"free_cancellations_used", "free_cancellations"},
+	BenefitFreeUpgrade:      {"free_upgrades_used", "free_upgrades"},
+}
+
+// ConsumeTierBenefit uses one of the rider's tier benefit for a ride if their
+// tier's allowance for the period has any left, and reports whether it did. A
+// tier period that ended is first rolled forward by whole years, resetting both
+// used counters. The counter is checked and incremented in one statement, so
+// concurrent rides can't overdraw the allowance, and a ride that already used
+// the benefit is granted it again without counting it twice.
+func (r *Repository) ConsumeTierBenefit(ctx context.Context, riderID, rideID uuid.UUID, benefit TierBenefit, now time.Time) (bool, error) {
+	columns, ok := tierBenefitColumns[benefit]
+	if !ok {
+		return false, fmt.Errorf("unknown tier benefit %q", benefit)
+	}
+	used, allowance := columns[0], columns[1]
+
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer dbTx.Rollback(ctx)
+
+	result, err := dbTx.Exec(ctx, `
+		INSERT INTO loyalty_benefit_usages (rider_id, ride_id, benefit, used_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (rider_id, ride_id, benefit) DO NOTHING
+	`, riderID, rideID, benefit, now)
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return true, nil
+	}
+
+	// $2 is today; a period has ended once its end date is reached
+	query := fmt.Sprintf(`
+		UPDATE rider_loyalty rl
+		SET %[1]s = CASE WHEN rl.tier_period_end <= $2::date THEN 1 ELSE rl.%[1]s + 1 END,
+		    %[2]s = CASE WHEN rl.tier_period_end <= $2::date THEN 0 ELSE rl.%[2]s END,
+		    tier_period_start = CASE WHEN rl.tier_period_end <= $2::date
+		        THEN (rl.tier_period_end + make_interval(years => EXTRACT(YEAR FROM age($2::date, rl.tier_period_end))::int))::date
+		        ELSE rl.tier_period_start END,
+		    tier_period_end = CASE WHEN rl.tier_period_end <= $2::date
+		        THEN (rl.tier_period_end + make_interval(years => EXTRACT(YEAR FROM age($2::date, rl.tier_period_end))::int + 1))::date
+		        ELSE rl.tier_period_end END,
 		    updated_at = NOW()
-		WHERE rider_id = $2
-	`
+		FROM loyalty_tiers t
+		WHERE rl.rider_id = $1
+		  AND t.id = rl.current_tier_id
+		  AND COALESCE(t.%[3]s, 0) > CASE WHEN rl.tier_period_end <= $2::date THEN 0 ELSE rl.%[1]s END
+	`, used, otherBenefitUsedColumn(benefit), allowance)
 
-	_, err := r.db.Exec(ctx, query, streakDays, riderID)
-	return err
+	result, err = dbTx.Exec(ctx, query, riderID, now)
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	return true, dbTx.Commit(ctx)
+}
+
+// otherBenefitUsedColumn is the used counter a period rollover resets alongside benefit's
+func otherBenefitUsedColumn(benefit TierBenefit) string {
+	if benefit == BenefitFreeCancellation {
+		return tierBenefitColumns[BenefitFreeUpgrade][0]
+	}
+	return tierBenefitColumns[BenefitFreeCancellation][0]
 }
 
 // ========================================
@@ -229,29 +344,105 @@ func (r *Repository) GetAllTiers(ctx context.Context) ([]*LoyaltyTier, error) {
 // POINTS TRANSACTIONS
 // ========================================
 
+// insertPointsTransactionQuery records a points transaction; see
+// pointsTransactionArgs for its arguments
+const insertPointsTransactionQuery = `
+	INSERT INTO loyalty_points_transactions (
+		id, rider_id, transaction_type, points, balance_after,
+		source, source_id, description, expires_at, metadata
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+// pointsTransactionArgs are the arguments to insertPointsTransactionQuery for tx
+func pointsTransactionArgs(tx *PointsTransaction) []interface{} {
+	metadataJSON, _ := json.Marshal(tx.Metadata)
+	return []interface{}{
+		tx.ID, tx.RiderID, tx.TransactionType, tx.Points, tx.BalanceAfter,
+		tx.Source, tx.SourceID, tx.Description, tx.ExpiresAt, metadataJSON,
+	}
+}
+
 // CreatePointsTransaction creates a new points transaction
 func (r *Repository) CreatePointsTransaction(ctx context.Context, tx *PointsTransaction) error {
-	query := `
-		INSERT INTO loyalty_points_transactions (
-			id, rider_id, transaction_type, points, balance_after,
-			source, source_id, description, expires_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	_, err := r.db.Exec(ctx, insertPointsTransactionQuery, pointsTransactionArgs(tx)...)
+	return err
+}
+
+// CreatePointsTransactionsBatch records several points transactions and applies
+// the combined balance change in a single database transaction
+func (r *Repository) CreatePointsTransactionsBatch(ctx context.Context, riderID uuid.UUID, txs []*PointsTransaction, earnedPoints, tierPoints int) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback(ctx)
+
+	for _, tx := range txs {
+		if _, err := dbTx.Exec(ctx, insertPointsTransactionQuery, pointsTransactionArgs(tx)...); err != nil {
+			return err
+		}
+	}
+
+	result, err := dbTx.Exec(ctx, creditPointsQuery, earnedPoints, tierPoints, riderID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return dbTx.Commit(ctx)
+}
+
+// ApplyPointsAdjustment changes a rider's available points by tx.Points and
+// records tx with the resulting balance in a single database transaction.
+// Credits also count towards total and lifetime points but not tier points.
+// It returns pgx.ErrNoRows when a debit would leave the balance negative.
+func (r *Repository) ApplyPointsAdjustment(ctx context.Context, tx *PointsTransaction) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback(ctx)
+
+	updateQuery := `
+		UPDATE rider_loyalty
+		SET available_points = available_points + $1,
+		    total_points = total_points + GREATEST($1, 0),
+		    lifetime_points = lifetime_points + GREATEST($1, 0),
+		    updated_at = NOW()
+		WHERE rider_id = $2 AND available_points + $1 >= 0
+		RETURNING available_points
 	`
 
-	_, err := r.db.Exec(ctx, query,
-		tx.ID, tx.RiderID, tx.TransactionType, tx.Points, tx.BalanceAfter,
-		tx.Source, tx.SourceID, tx.Description, tx.ExpiresAt,
-	)
+	if err := dbTx.QueryRow(ctx, updateQuery, tx.Points, tx.RiderID).Scan(&tx.BalanceAfter); err != nil {
+		return err
+	}
 
-	return err
+	insertQuery := insertPointsTransactionQuery + "RETURNING created_at"
+	if err := dbTx.QueryRow(ctx, insertQuery, pointsTransactionArgs(tx)...).Scan(&tx.CreatedAt); err != nil {
+		return err
+	}
+
+	return dbTx.Commit(ctx)
 }
 
 // GetPointsHistory gets points transaction history for a rider
-func (r *Repository) GetPointsHistory(ctx context.Context, riderID uuid.UUID, limit, offset int) ([]*PointsTransaction, int, error) {
+func (r *Repository) GetPointsHistory(ctx context.Context, riderID uuid.UUID, filter PointsHistoryFilter, limit, offset int) ([]*PointsTransaction, int, error) {
+	where := `
+		WHERE rider_id = $1
+		  AND ($2 = '' OR transaction_type = $2)
+		  AND ($3 = '' OR source = $3)
+		  AND ($4::timestamptz IS NULL OR created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR created_at < $5)
+	`
+	args := []interface{}{riderID, string(filter.TransactionType), string(filter.Source), filter.From, filter.To}
+
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM loyalty_points_transactions WHERE rider_id = $1`
+	countQuery := `SELECT COUNT(*) FROM loyalty_points_transactions` + where
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, riderID).Scan(&total)
+	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -259,33 +450,75 @@ func (r *Repository) GetPointsHistory(ctx context.Context, riderID uuid.UUID, li
 	// Get transactions
 	query := `
 		SELECT id, rider_id, transaction_type, points, balance_after,
-		       source, source_id, description, expires_at, created_at
-		FROM loyalty_points_transactions
-		WHERE rider_id = $1
+		       source, source_id, description, expires_at, metadata, created_at
+		FROM loyalty_points_transactions` + where + `
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $6 OFFSET $7
 	`
 
-	rows, err := r.db.Query(ctx, query, riderID, limit, offset)
+	rows, err := r.db.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
+	transactions, err := scanPointsTransactions(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// GetPointsHistoryAfter gets up to limit of a rider's points transactions, newest
+// first, that come after the cursor; a nil cursor starts from the newest
+func (r *Repository) GetPointsHistoryAfter(ctx context.Context, riderID uuid.UUID, after *pagination.Cursor, limit int) ([]*PointsTransaction, error) {
+	var afterAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterAt, afterID = &after.At, &after.ID
+	}
+
+	query := `
+		SELECT id, rider_id, transaction_type, points, balance_after,
+		       source, source_id, description, expires_at, metadata, created_at
+		FROM loyalty_points_transactions
+		WHERE rider_id = $1
+		  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, riderID, afterAt, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPointsTransactions(rows)
+}
+
+// scanPointsTransactions reads points transactions selected in the column order
+// of GetPointsHistory
+func scanPointsTransactions(rows pgx.Rows) ([]*PointsTransaction, error) {
 	var transactions []*PointsTransaction
 	for rows.Next() {
 		tx := &PointsTransaction{}
+		var metadataJSON []byte
 		err := rows.Scan(
 			&tx.ID, &tx.RiderID, &tx.TransactionType, &tx.Points, &tx.BalanceAfter,
-			&tx.Source, &tx.SourceID, &tx.Description, &tx.ExpiresAt, &tx.CreatedAt,
+			&tx.Source, &tx.SourceID, &tx.Description, &tx.ExpiresAt, &metadataJSON, &tx.CreatedAt,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
+		}
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &tx.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata on points transaction %s: %w", tx.ID, err)
+			}
 		}
 		transactions = append(transactions, tx)
 	}
-
-	return transactions, total, nil
+	return transactions, rows.Err()
 }
 
 // ========================================
@@ -297,8 +530,8 @@ func (r *Repository) GetReward(ctx context.Context, rewardID uuid.UUID) (*Reward
 	query := `
 		SELECT id, name, description, reward_type, points_required, value,
 		       tier_restriction, max_redemptions_per_user, redeemed_count, total_available,
-		       valid_days, partner_name, partner_logo_url, is_active, created_at
-		FROM loyalty_rewards
+		       total_inventory, valid_days, partner_name, partner_logo_url, is_active, created_at
+		FROM loyalty_rewards_catalog
 		WHERE id = $1
 	`
 
@@ -306,7 +539,7 @@ func (r *Repository) GetReward(ctx context.Context, rewardID uuid.UUID) (*Reward
 	err := r.db.QueryRow(ctx, query, rewardID).Scan(
 		&reward.ID, &reward.Name, &reward.Description, &reward.RewardType, &reward.PointsRequired,
 		&reward.Value, &reward.TierRestriction, &reward.MaxRedemptionsPerUser,
-		&reward.RedeemedCount, &reward.TotalAvailable, &reward.ValidDays, &reward.PartnerName,
+		&reward.RedeemedCount, &reward.TotalAvailable, &reward.TotalInventory, &reward.ValidDays, &reward.PartnerName,
 		&reward.PartnerLogoURL, &reward.IsActive, &reward.CreatedAt,
 	)
 
@@ -322,8 +555,8 @@ func (r *Repository) GetAvailableRewards(ctx context.Context, tierID *uuid.UUID)
 	query := `
 		SELECT id, name, description, reward_type, points_required, value,
 		       tier_restriction, max_redemptions_per_user, redeemed_count, total_available,
-		       valid_days, partner_name, partner_logo_url, is_active, created_at
-		FROM loyalty_rewards
+		       total_inventory, valid_days, partner_name, partner_logo_url, is_active, created_at
+		FROM loyalty_rewards_catalog
 		WHERE is_active = true
 		  AND (total_available IS NULL OR redeemed_count < total_available)
 		  AND (tier_restriction IS NULL OR tier_restriction = $1 OR $1 IS NULL)
@@ -342,7 +575,7 @@ func (r *Repository) GetAvailableRewards(ctx context.Context, tierID *uuid.UUID)
 		err := rows.Scan(
 			&reward.ID, &reward.Name, &reward.Description, &reward.RewardType, &reward.PointsRequired,
 			&reward.Value, &reward.TierRestriction, &reward.MaxRedemptionsPerUser,
-			&reward.RedeemedCount, &reward.TotalAvailable, &reward.ValidDays, &reward.PartnerName,
+			&reward.RedeemedCount, &reward.TotalAvailable, &reward.TotalInventory, &reward.ValidDays, &reward.PartnerName,
 			&reward.PartnerLogoURL, &reward.IsActive, &reward.CreatedAt,
 		)
 		if err != nil {
@@ -366,34 +599,535 @@ func (r *Repository) GetUserRedemptionCount(ctx context.Context, riderID, reward
 	return count, err
 }
 
-// CreateRedemption creates a new redemption record
-func (r *Repository) CreateRedemption(ctx context.Context, redemption *Redemption) error {
-	query := `
-		INSERT INTO loyalty_redemptions (
-			id, rider_id, reward_id, points_spent, redemption_code, status, expires_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+// ErrRewardOutOfStock is returned by RedeemReward when a limited reward has no
+// stock left
+var ErrRewardOutOfStock = errors.New("reward out of stock")
+
+// RedeemReward takes a unit of the reward's stock if it is limited, deducts
+// tx's points if the rider still has them, and records the redemption and its
+// ledger entry with the resulting balance, all in a single database
+// transaction. It returns ErrRewardOutOfStock when a limited reward has none
+// left and pgx.ErrNoRows when the rider no longer has the points; either way
+// nothing is changed.
+func (r *Repository) RedeemReward(ctx context.Context, redemption *Redemption, tx *PointsTransaction) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback(ctx)
+
+	result, err := dbTx.Exec(ctx, `
+		UPDATE loyalty_rewards_catalog
+		SET total_inventory = total_inventory - 1,
+		    redeemed_count = redeemed_count + 1
+		WHERE id = $1 AND (total_inventory IS NULL OR total_inventory > 0)
+	`, redemption.RewardID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRewardOutOfStock
+	}
+
+	deductQuery := `
+		UPDATE rider_loyalty
+		SET available_points = available_points - $1,
+		    updated_at = NOW()
+		WHERE rider_id = $2 AND available_points >= $1
+		RETURNING available_points
 	`
+	if err := dbTx.QueryRow(ctx, deductQuery, redemption.PointsSpent, redemption.RiderID).Scan(&tx.BalanceAfter); err != nil {
+		return err
+	}
 
-	_, err := r.db.Exec(ctx, query,
+	if _, err := dbTx.Exec(ctx, `
+		INSERT INTO loyalty_redemptions (
+			id, rider_id, reward_id, points_spent, cash_amount, redemption_code, status, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
 		redemption.ID, redemption.RiderID, redemption.RewardID, redemption.PointsSpent,
-		redemption.RedemptionCode, redemption.Status, redemption.ExpiresAt,
+		redemption.CashAmount, redemption.RedemptionCode, redemption.Status, redemption.ExpiresAt,
+	); err != nil {
+		return err
+	}
+
+	if _, err := dbTx.Exec(ctx, insertPointsTransactionQuery, pointsTransactionArgs(tx)...); err != nil {
+		return err
+	}
+
+	return dbTx.Commit(ctx)
+}
+
+// GetRedemptionByCode gets a redemption by its redemption code
+func (r *Repository) GetRedemptionByCode(ctx context.Context, code string) (*Redemption, error) {
+	query := `
+		SELECT id, rider_id, reward_id, points_spent, cash_amount, redemption_code, status,
+		       used_at, expires_at, created_at
+		FROM loyalty_redemptions
+		WHERE redemption_code = $1
+	`
+
+	redemption := &Redemption{}
+	err := r.db.QueryRow(ctx, query, code).Scan(
+		&redemption.ID, &redemption.RiderID, &redemption.RewardID, &redemption.PointsSpent,
+		&redemption.CashAmount, &redemption.RedemptionCode, &redemption.Status, &redemption.UsedAt,
+		&redemption.ExpiresAt, &redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+// redemptionHistoryCTE lists a rider's redemptions ($1) with their reward names.
+// Active codes past their expiry at $2 are reported as expired.
+const redemptionHistoryCTE = `
+	WITH history AS (
+		SELECT lr.id, lr.rider_id, lr.reward_id, rw.name AS reward_name, lr.points_spent,
+		       lr.cash_amount, lr.redemption_code,
+		       CASE WHEN lr.status = 'active' AND lr.expires_at <= $2 THEN 'expired' ELSE lr.status END AS status,
+		       lr.used_at, lr.expires_at, lr.created_at
+		FROM loyalty_redemptions lr
+		JOIN loyalty_rewards_catalog rw ON rw.id = lr.reward_id
+		WHERE lr.rider_id = $1
+	)
+`
+
+// GetRedemptionHistory gets a rider's redemptions, newest first, optionally
+// only those with a status
+func (r *Repository) GetRedemptionHistory(ctx context.Context, riderID uuid.UUID, status string, now time.Time, limit, offset int) ([]*Redemption, int, error) {
+	// Get total count
+	countQuery := redemptionHistoryCTE + `SELECT COUNT(*) FROM history WHERE ($3 = '' OR status = $3)`
+	var total int
+	if err := r.db.QueryRow(ctx, countQuery, riderID, now, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Get redemptions
+	query := redemptionHistoryCTE + `
+		SELECT id, rider_id, reward_id, reward_name, points_spent, cash_amount, redemption_code, status,
+		       used_at, expires_at, created_at
+		FROM history
+		WHERE ($3 = '' OR status = $3)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.Query(ctx, query, riderID, now, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var redemptions []*Redemption
+	for rows.Next() {
+		redemption := &Redemption{}
+		if err := rows.Scan(
+			&redemption.ID, &redemption.RiderID, &redemption.RewardID, &redemption.RewardName,
+			&redemption.PointsSpent, &redemption.CashAmount, &redemption.RedemptionCode, &redemption.Status,
+			&redemption.UsedAt, &redemption.ExpiresAt, &redemption.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, total, rows.Err()
+}
+
+// MarkRedemptionUsed flips an active, unexpired redemption to used. The status
+// guard makes it safe against concurrent scans; it returns false if the
+// redemption was no longer active or had expired.
+func (r *Repository) MarkRedemptionUsed(ctx context.Context, redemptionID uuid.UUID, usedAt time.Time) (bool, error) {
+	query := `
+		UPDATE loyalty_redemptions
+		SET status = 'used', used_at = $2
+		WHERE id = $1 AND status = 'active' AND expires_at > $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, redemptionID, usedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ========================================
+// REWARD WAITLIST
+// ========================================
+
+// AddToRewardWaitlist adds a rider to a reward's waitlist. It returns false if
+// the rider is already waiting for the reward.
+func (r *Repository) AddToRewardWaitlist(ctx context.Context, entry *RewardWaitlistEntry) (bool, error) {
+	query := `
+		INSERT INTO loyalty_reward_waitlist (id, reward_id, rider_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (reward_id, rider_id) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query, entry.ID, entry.RewardID, entry.RiderID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClaimRewardWaitlist marks the longest-waiting riders who haven't been notified
+// yet as notified and returns them. Locked rows are skipped, so concurrent
+// releases never claim the same rider twice.
+func (r *Repository) ClaimRewardWaitlist(ctx context.Context, rewardID uuid.UUID, limit int) ([]*RewardWaitlistEntry, error) {
+	query := `
+		UPDATE loyalty_reward_waitlist
+		SET notified_at = NOW()
+		WHERE id IN (
+			SELECT id FROM loyalty_reward_waitlist
+			WHERE reward_id = $1 AND notified_at IS NULL
+			ORDER BY created_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, reward_id, rider_id, notified_at, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, rewardID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*RewardWaitlistEntry
+	for rows.Next() {
+		entry := &RewardWaitlistEntry{}
+		if err := rows.Scan(&entry.ID, &entry.RewardID, &entry.RiderID, &entry.NotifiedAt, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// ========================================
+// REFERRALS
+// ========================================
+
+// GetReferralByReferee gets the referral that brought in a referee
+func (r *Repository) GetReferralByReferee(ctx context.Context, refereeID uuid.UUID) (*Referral, error) {
+	query := `
+		SELECT id, referrer_id, referee_id, first_ride_id, referrer_points, referee_points,
+		       referrer_rewarded_at, referee_rewarded_at, created_at
+		FROM loyalty_referrals
+		WHERE referee_id = $1
+	`
+
+	referral := &Referral{}
+	err := r.db.QueryRow(ctx, query, refereeID).Scan(
+		&referral.ID, &referral.ReferrerID, &referral.RefereeID, &referral.FirstRideID,
+		&referral.ReferrerPoints, &referral.RefereePoints,
+		&referral.ReferrerRewardedAt, &referral.RefereeRewardedAt, &referral.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return referral, nil
+}
+
+// CreateReferral records a referral. It returns false without error if the
+// referee has already been referred.
+func (r *Repository) CreateReferral(ctx context.Context, referral *Referral) (bool, error) {
+	query := `
+		INSERT INTO loyalty_referrals (
+			id, referrer_id, referee_id, first_ride_id, referrer_points, referee_points
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (referee_id) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query,
+		referral.ID, referral.ReferrerID, referral.RefereeID, referral.FirstRideID,
+		referral.ReferrerPoints, referral.RefereePoints,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// PointsGuard is a condition checked in the database transaction that credits
+// earned points, before anything is written. If it reports false nothing is
+// credited, so a guard that claims something (such as marking a reward as paid)
+// lets only one of several concurrent earnings through.
+type PointsGuard func(ctx context.Context, db outbox.Execer) (bool, error)
+
+// CreditPointsGuarded records tx and credits its points to the rider, as earned
+// and tier points, in a single database transaction that only goes ahead if
+// guard holds. It returns false, writing nothing, when the guard doesn't.
+func (r *Repository) CreditPointsGuarded(ctx context.Context, tx *PointsTransaction, guard PointsGuard) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer dbTx.Rollback(ctx)
+
+	ok, err := guard(ctx, dbTx)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if _, err := dbTx.Exec(ctx, insertPointsTransactionQuery, pointsTransactionArgs(tx)...); err != nil {
+		return false, err
+	}
+	if _, err := dbTx.Exec(ctx, creditPointsQuery, tx.Points, tx.Points, tx.RiderID); err != nil {
+		return false, err
+	}
+
+	return true, dbTx.Commit(ctx)
+}
+
+// referralRewardGuard marks one side of a referral as paid, holding only if
+// that side hadn't been paid yet
+func referralRewardGuard(referralID uuid.UUID, side ReferralSide) PointsGuard {
+	return func(ctx context.Context, db outbox.Execer) (bool, error) {
+		query := `
+			UPDATE loyalty_referrals SET referrer_rewarded_at = NOW()
+			WHERE id = $1 AND referrer_rewarded_at IS NULL
+		`
+		if side == ReferralSideReferee {
+			query = `
+				UPDATE loyalty_referrals SET referee_rewarded_at = NOW()
+				WHERE id = $1 AND referee_rewarded_at IS NULL
+			`
+		}
+
+		marked, err := db.Exec(ctx, query, referralID)
+		if err != nil {
+			return false, err
+		}
+		return marked.RowsAffected() == 1, nil
+	}
+}
+
+// HasLoyaltyHistory reports whether a rider has earned or spent points other
+// than the signup bonus and points for the given ride
+func (r *Repository) HasLoyaltyHistory(ctx context.Context, riderID, excludeRideID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM loyalty_points_transactions
+			WHERE rider_id = $1
+			  AND source <> $2
+			  AND NOT (source = $3 AND source_id IS NOT DISTINCT FROM $4)
+		)
+	`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query, riderID, SourceSignup, SourceRide, excludeRideID).Scan(&exists)
+	return exists, err
+}
+
+// ========================================
+// BIRTHDAYS
+// ========================================
+
+// SetBirthDate sets the birth date used for a rider's birthday bonus
+func (r *Repository) SetBirthDate(ctx context.Context, riderID uuid.UUID, birthDate time.Time) error {
+	query := `
+		UPDATE rider_loyalty
+		SET birth_date = $2, updated_at = NOW()
+		WHERE rider_id = $1
+	`
+
+	tag, err := r.db.Exec(ctx, query, riderID, birthDate)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetRidersWithBirthday gets riders born in the given month on any of the given days
+func (r *Repository) GetRidersWithBirthday(ctx context.Context, month time.Month, days []int) ([]*BirthdayRider, error) {
+	query := `
+		SELECT rider_id, birth_date
+		FROM rider_loyalty
+		WHERE birth_date IS NOT NULL
+		  AND EXTRACT(MONTH FROM birth_date) = $1
+		  AND EXTRACT(DAY FROM birth_date) = ANY($2)
+	`
+
+	rows, err := r.db.Query(ctx, query, int(month), days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var riders []*BirthdayRider
+	for rows.Next() {
+		rider := &BirthdayRider{}
+		if err := rows.Scan(&rider.RiderID, &rider.BirthDate); err != nil {
+			return nil, err
+		}
+		riders = append(riders, rider)
+	}
+
+	return riders, rows.Err()
+}
+
+// HasPointsTransaction reports whether a rider already has a transaction for a source
+func (r *Repository) HasPointsTransaction(ctx context.Context, riderID uuid.UUID, source PointSource, sourceID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM loyalty_points_transactions
+			WHERE rider_id = $1 AND source = $2 AND source_id = $3
+		)
+	`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query, riderID, source, sourceID).Scan(&exists)
+	return exists, err
+}
+
+// ========================================
+// IDEMPOTENCY KEYS
+// ========================================
+
+// ClaimIdempotencyKey claims a key for a new request until key.ExpiresAt. Only
+// one of several concurrent claims can insert the row; an expired key, whether
+// completed or left in progress, is claimed afresh. It returns false if the key
+// is held by an earlier request.
+func (r *Repository) ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error) {
+	query := `
+		INSERT INTO loyalty_idempotency_keys (
+			rider_id, operation, idempotency_key, request_hash, expires_at
+		) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (rider_id, operation, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, response = NULL,
+		    created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE loyalty_idempotency_keys.expires_at <= NOW()
+	`
+
+	tag, err := r.db.Exec(ctx, query,
+		key.RiderID, key.Operation, key.Key, key.RequestHash, key.ExpiresAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetIdempotencyKey gets a claimed idempotency key
+func (r *Repository) GetIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) (*IdempotencyKey, error) {
+	query := `
+		SELECT rider_id, operation, idempotency_key, request_hash, response, created_at, expires_at
+		FROM loyalty_idempotency_keys
+		WHERE rider_id = $1 AND operation = $2 AND idempotency_key = $3
+	`
+
+	record := &IdempotencyKey{}
+	var response []byte
+	err := r.db.QueryRow(ctx, query, riderID, operation, key).Scan(
+		&record.RiderID, &record.Operation, &record.Key, &record.RequestHash,
+		&response, &record.CreatedAt, &record.ExpiresAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	record.Response = response
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the result of the request that claimed a key
+// and keeps the key until expiresAt
+func (r *Repository) CompleteIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string, response []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE loyalty_idempotency_keys
+		SET response = $4, expires_at = $5
+		WHERE rider_id = $1 AND operation = $2 AND idempotency_key = $3
+	`
 
+	_, err := r.db.Exec(ctx, query, riderID, operation, key, response, expiresAt)
 	return err
 }
 
-// IncrementRewardRedemptionCount increments the redemption count for a reward
-func (r *Repository) IncrementRewardRedemptionCount(ctx context.Context, rewardID uuid.UUID) error {
+// ReleaseIdempotencyKey drops an in-progress key after its request failed, so
+// that a retry with the same key can run
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, riderID uuid.UUID, operation IdempotentOperation, key string) error {
 	query := `
-		UPDATE loyalty_rewards
-		SET redeemed_count = redeemed_count + 1, updated_at = NOW()
-		WHERE id = $1
+		DELETE FROM loyalty_idempotency_keys
+		WHERE rider_id = $1 AND operation = $2 AND idempotency_key = $3 AND response IS NULL
 	`
 
-	_, err := r.db.Exec(ctx, query, rewardID)
+	_, err := r.db.Exec(ctx, query, riderID, operation, key)
 	return err
 }
 
+// ========================================
+// POINT PROMOS
+// ========================================
+
+// GetActivePointPromos gets the point multiplier promos running at the given time
+func (r *Repository) GetActivePointPromos(ctx context.Context, at time.Time) ([]*PointMultiplierPromo, error) {
+	query := `
+		SELECT id, name, multiplier, sources, tier_restriction, starts_at, ends_at, is_active, created_at
+		FROM loyalty_point_promos
+		WHERE is_active = true
+		  AND starts_at <= $1
+		  AND ends_at > $1
+		ORDER BY starts_at ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promos []*PointMultiplierPromo
+	for rows.Next() {
+		p := &PointMultiplierPromo{}
+		var sources []string
+		err := rows.Scan(
+			&p.ID, &p.Name, &p.Multiplier, &sources, &p.TierRestriction,
+			&p.StartsAt, &p.EndsAt, &p.IsActive, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			p.Sources = append(p.Sources, PointSource(source))
+		}
+		promos = append(promos, p)
+	}
+
+	return promos, rows.Err()
+}
+
+// CreatePointPromo creates a point multiplier promo
+func (r *Repository) CreatePointPromo(ctx context.Context, promo *PointMultiplierPromo) error {
+	sources := make([]string, 0, len(promo.Sources))
+	for _, source := range promo.Sources {
+		sources = append(sources, string(source))
+	}
+
+	query := `
+		INSERT INTO loyalty_point_promos (id, name, multiplier, sources, tier_restriction, starts_at, ends_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRow(ctx, query,
+		promo.ID, promo.Name, promo.Multiplier, sources, promo.TierRestriction,
+		promo.StartsAt, promo.EndsAt, promo.IsActive,
+	).Scan(&promo.CreatedAt)
+}
+
 // ========================================
 // CHALLENGES
 // ========================================
@@ -471,7 +1205,7 @@ func (r *Repository) GetActiveChallengesByType(ctx context.Context, challengeTyp
 // GetChallengeProgress gets a rider's progress on a challenge
 func (r *Repository) GetChallengeProgress(ctx context.Context, riderID, challengeID uuid.UUID) (*ChallengeProgress, error) {
 	query := `
-		SELECT id, rider_id, challenge_id, current_value, completed, completed_at, created_at
+		SELECT id, rider_id, challenge_id, current_value, completed, completed_at, last_activity_date, created_at
 		FROM rider_challenge_progress
 		WHERE rider_id = $1 AND challenge_id = $2
 	`
@@ -479,7 +1213,7 @@ func (r *Repository) GetChallengeProgress(ctx context.Context, riderID, challeng
 	progress := &ChallengeProgress{}
 	err := r.db.QueryRow(ctx, query, riderID, challengeID).Scan(
 		&progress.ID, &progress.RiderID, &progress.ChallengeID,
-		&progress.CurrentValue, &progress.Completed, &progress.CompletedAt, &progress.CreatedAt,
+		&progress.CurrentValue, &progress.Completed, &progress.CompletedAt, &progress.LastActivityDate, &progress.CreatedAt,
 	)
 
 	if err != nil {
@@ -503,8 +1237,8 @@ func (r *Repository) CreateChallengeProgress(ctx context.Context, progress *Chal
 	return err
 }
 
-// UpdateChallengeProgress updates challenge progress
-func (r *Repository) UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool) error {
+// UpdateChallengeProgress updates challenge progress and the day of the activity behind it
+func (r *Repository) UpdateChallengeProgress(ctx context.Context, progressID uuid.UUID, currentValue int, completed bool, activityDate time.Time) error {
 	var completedAt *time.Time
 	if completed {
 		now := time.Now()
@@ -513,14 +1247,102 @@ func (r *Repository) UpdateChallengeProgress(ctx context.Context, progressID uui
 
 	query := `
 		UPDATE rider_challenge_progress
-		SET current_value = $1, completed = $2, completed_at = $3, updated_at = NOW()
-		WHERE id = $4
+		SET current_value = $1, completed = $2, completed_at = $3, last_activity_date = $4, updated_at = NOW()
+		WHERE id = $5
 	`
 
-	_, err := r.db.Exec(ctx, query, currentValue, completed, completedAt, progressID)
+	_, err := r.db.Exec(ctx, query, currentValue, completed, completedAt, activityDate, progressID)
 	return err
 }
 
+// GetChallenge gets a challenge by ID
+func (r *Repository) GetChallenge(ctx context.Context, challengeID uuid.UUID) (*RiderChallenge, error) {
+	query := `
+		SELECT id, name, description, challenge_type, target_value, reward_points,
+		       start_date, end_date, tier_restriction, is_active, created_at
+		FROM rider_challenges
+		WHERE id = $1
+	`
+
+	c := &RiderChallenge{}
+	err := r.db.QueryRow(ctx, query, challengeID).Scan(
+		&c.ID, &c.Name, &c.Description, &c.ChallengeType, &c.TargetValue, &c.RewardPoints,
+		&c.StartDate, &c.EndDate, &c.TierRestriction, &c.IsActive, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// challengeRankingQuery ranks the progress of riders eligible for a challenge: the
+// highest value first, then whoever completed it first, then whoever got there first
+const challengeRankingQuery = `
+	WITH ranked AS (
+		SELECT p.rider_id, p.current_value, p.completed_at,
+		       CASE
+		           WHEN p.completed AND p.reward_claimed THEN 'claimed'
+		           WHEN p.completed THEN 'completed'
+		           ELSE 'in_progress'
+		       END AS status,
+		       ROW_NUMBER() OVER (
+		           ORDER BY p.current_value DESC, p.completed_at ASC NULLS LAST,
+		                    p.updated_at ASC, p.rider_id
+		       ) AS rank
+		FROM rider_challenge_progress p
+		JOIN rider_loyalty rl ON rl.rider_id = p.rider_id
+		WHERE p.challenge_id = $1
+		  AND ($2::uuid IS NULL OR rl.current_tier_id = $2)
+	)
+	SELECT rank, rider_id, current_value, status, completed_at
+	FROM ranked
+`
+
+// GetChallengeLeaderboard gets the top riders on a challenge. Only riders in
+// tierRestriction are ranked when it is set.
+func (r *Repository) GetChallengeLeaderboard(ctx context.Context, challengeID uuid.UUID, tierRestriction *uuid.UUID, limit int) ([]*ChallengeLeaderboardEntry, error) {
+	query := challengeRankingQuery + `
+		ORDER BY rank
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, challengeID, tierRestriction, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*ChallengeLeaderboardEntry, 0, limit)
+	for rows.Next() {
+		e := &ChallengeLeaderboardEntry{}
+		if err := rows.Scan(&e.Rank, &e.RiderID, &e.CurrentValue, &e.Status, &e.CompletedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetChallengeRank gets one rider's position on a challenge leaderboard. It
+// returns pgx.ErrNoRows if the rider has no progress or isn't eligible.
+func (r *Repository) GetChallengeRank(ctx context.Context, challengeID, riderID uuid.UUID, tierRestriction *uuid.UUID) (*ChallengeLeaderboardEntry, error) {
+	query := challengeRankingQuery + `
+		WHERE rider_id = $3
+	`
+
+	e := &ChallengeLeaderboardEntry{}
+	err := r.db.QueryRow(ctx, query, challengeID, tierRestriction, riderID).Scan(
+		&e.Rank, &e.RiderID, &e.CurrentValue, &e.Status, &e.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
 // ========================================
 // ADMIN OPERATIONS
 // ========================================
@@ -535,8 +1357,8 @@ func (r *Repository) GetLoyaltyStats(ctx context.Context) (*LoyaltyStats, error)
 			COUNT(*) FILTER (WHERE lt.name = 'gold') as gold_count,
 			COUNT(*) FILTER (WHERE lt.name = 'platinum') as platinum_count,
 			COUNT(*) FILTER (WHERE lt.name = 'diamond') as diamond_count,
-			SUM(rl.lifetime_points) as total_points_earned,
-			SUM(rl.available_points) as total_points_outstanding
+			COALESCE(SUM(rl.lifetime_points), 0) as total_points_earned,
+			COALESCE(SUM(rl.available_points), 0) as total_points_outstanding
 		FROM rider_loyalty rl
 		LEFT JOIN loyalty_tiers lt ON rl.current_tier_id = lt.id
 	`
@@ -555,6 +1377,83 @@ func (r *Repository) GetLoyaltyStats(ctx context.Context) (*LoyaltyStats, error)
 	return stats, nil
 }
 
+// GetPointsFlow sums the points earned, redeemed and expired since a time
+func (r *Repository) GetPointsFlow(ctx context.Context, since time.Time) (*PointsFlow, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(points) FILTER (WHERE transaction_type IN ('earn', 'bonus')), 0),
+			COALESCE(-SUM(points) FILTER (WHERE transaction_type = 'redeem'), 0),
+			COALESCE(-SUM(points) FILTER (WHERE transaction_type = 'expire'), 0)
+		FROM loyalty_points_transactions
+		WHERE created_at >= $1
+	`
+
+	flow := &PointsFlow{}
+	if err := r.db.QueryRow(ctx, query, since).Scan(&flow.Earned, &flow.Redeemed, &flow.Expired); err != nil {
+		return nil, err
+	}
+	return flow, nil
+}
+
+// GetTopRedeemedRewards gets the rewards redeemed most often since a time.
+// Cancelled redemptions don't count.
+func (r *Repository) GetTopRedeemedRewards(ctx context.Context, since time.Time, limit int) ([]*RewardRedemptionStats, error) {
+	query := `
+		SELECT lr.reward_id, rw.name, COUNT(*), COALESCE(SUM(lr.points_spent), 0)
+		FROM loyalty_redemptions lr
+		JOIN loyalty_rewards_catalog rw ON rw.id = lr.reward_id
+		WHERE lr.created_at >= $1 AND lr.status != 'cancelled'
+		GROUP BY lr.reward_id, rw.name
+		ORDER BY COUNT(*) DESC, SUM(lr.points_spent) DESC, lr.reward_id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rewards []*RewardRedemptionStats
+	for rows.Next() {
+		reward := &RewardRedemptionStats{}
+		if err := rows.Scan(&reward.RewardID, &reward.Name, &reward.Redemptions, &reward.PointsSpent); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, reward)
+	}
+	return rewards, rows.Err()
+}
+
+// GetChallengeCompletions counts the participants and completions of the
+// challenges that ran at any point since a time
+func (r *Repository) GetChallengeCompletions(ctx context.Context, since time.Time) ([]*ChallengeCompletion, error) {
+	query := `
+		SELECT c.id, c.name, COUNT(p.id), COUNT(p.id) FILTER (WHERE p.completed)
+		FROM rider_challenges c
+		LEFT JOIN rider_challenge_progress p ON p.challenge_id = c.id
+		WHERE c.end_date >= $1
+		GROUP BY c.id, c.name, c.start_date
+		ORDER BY c.start_date DESC, c.id
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var challenges []*ChallengeCompletion
+	for rows.Next() {
+		challenge := &ChallengeCompletion{}
+		if err := rows.Scan(&challenge.ChallengeID, &challenge.Name, &challenge.Participants, &challenge.Completed); err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges, rows.Err()
+}
+
 // LoyaltyStats represents loyalty program statistics
 type LoyaltyStats struct {
 	TotalMembers           int   `json:"total_members"`
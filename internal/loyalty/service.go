@@ -4,30 +4,179 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/audit"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/httputil"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/pagination"
 	"go.uber.org/zap"
 )
 
+// ServiceConfig holds tunable loyalty settings
+type ServiceConfig struct {
+	// RoundingMode is applied to base points * tier multiplier * promo multiplier. Defaults to truncation.
+	RoundingMode RoundingMode
+	// StreakMilestones award bonus points when a ride streak reaches the given length.
+	// Defaults to DefaultStreakMilestones when nil.
+	StreakMilestones []StreakMilestone
+	// ReferrerPoints and RefereePoints are awarded for a referee's first ride.
+	// They default to DefaultReferrerPoints and DefaultRefereePoints when zero.
+	ReferrerPoints int
+	RefereePoints  int
+	// BirthdayBonusPoints is the birthday gift per tier; tiers without an entry
+	// get the bronze amount. Defaults to DefaultBirthdayBonusPoints when nil.
+	BirthdayBonusPoints map[TierName]int
+	// BirthdayApplyMultiplier applies the rider's tier multiplier to the birthday gift.
+	BirthdayApplyMultiplier bool
+	// IdempotencyTTL is how long idempotency keys are remembered. Defaults to
+	// DefaultIdempotencyTTL when zero.
+	IdempotencyTTL time.Duration
+	// IdempotencyLease is how long a request in progress holds its key before a
+	// retry may reclaim it. Defaults to DefaultIdempotencyLease when zero.
+	IdempotencyLease time.Duration
+	// LeaderboardCacheTTL is how long challenge leaderboards are cached. Defaults
+	// to DefaultLeaderboardCacheTTL when zero.
+	LeaderboardCacheTTL time.Duration
+	// MaxAdjustmentPoints is the largest manual adjustment, either way, an admin can
+	// make without an override. Defaults to DefaultMaxAdjustmentPoints when zero.
+	MaxAdjustmentPoints int
+	// DashboardCacheTTL is how long the admin dashboard is cached. Defaults to
+	// DefaultDashboardCacheTTL when zero.
+	DashboardCacheTTL time.Duration
+	// EarningRules price earnings sent without explicit points. Defaults to
+	// DefaultEarningRules when nil; ReloadEarningRules replaces them.
+	EarningRules []EarningRule
+}
+
+// Default referral bonuses used when none are configured
+const (
+	DefaultReferrerPoints = 500
+	DefaultRefereePoints  = 250
+)
+
+// SignupBonusPoints are the base points a new loyalty account starts with
+const SignupBonusPoints = 100
+
+// Signup bonus outcomes
+const (
+	signupBonusAwarded        = "awarded"
+	signupBonusAlreadyAwarded = "already_awarded"
+	signupBonusFailed         = "failed"
+)
+
+var signupBonuses = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "loyalty_signup_bonuses_total",
+	Help: "Total number of loyalty signup bonus attempts by result",
+}, []string{"result"})
+
+// DefaultStreakMilestones are the streak bonuses used when none are configured
+var DefaultStreakMilestones = []StreakMilestone{
+	{Days: 7, BonusPoints: 100},
+	{Days: 30, BonusPoints: 500},
+}
+
 // Service handles loyalty business logic
 type Service struct {
-	repo RepositoryInterface
+	repo          RepositoryInterface
+	config        ServiceConfig
+	notifier      WaitlistNotifier
+	fareConverter FareConverter
+	leaderboards  *leaderboardCache
+	dashboards    *dashboardCache
+	locker        PointsLocker
+	earningRules  *earningRules
+	audit         audit.Logger
 }
 
-// NewService creates a new loyalty service
+// NewService creates a new loyalty service with default settings
 func NewService(repo RepositoryInterface) *Service {
-	return &Service{repo: repo}
+	return NewServiceWithConfig(repo, ServiceConfig{})
+}
+
+// NewServiceWithConfig creates a new loyalty service with the given settings
+func NewServiceWithConfig(repo RepositoryInterface, config ServiceConfig) *Service {
+	if config.RoundingMode == "" {
+		config.RoundingMode = RoundingTruncate
+	}
+	if config.StreakMilestones == nil {
+		config.StreakMilestones = DefaultStreakMilestones
+	}
+	if config.ReferrerPoints <= 0 {
+		config.ReferrerPoints = DefaultReferrerPoints
+	}
+	if config.RefereePoints <= 0 {
+		config.RefereePoints = DefaultRefereePoints
+	}
+	if config.BirthdayBonusPoints == nil {
+		config.BirthdayBonusPoints = DefaultBirthdayBonusPoints
+	}
+	if config.IdempotencyTTL <= 0 {
+		config.IdempotencyTTL = DefaultIdempotencyTTL
+	}
+	if config.IdempotencyLease <= 0 {
+		config.IdempotencyLease = DefaultIdempotencyLease
+	}
+	if config.LeaderboardCacheTTL <= 0 {
+		config.LeaderboardCacheTTL = DefaultLeaderboardCacheTTL
+	}
+	if config.MaxAdjustmentPoints <= 0 {
+		config.MaxAdjustmentPoints = DefaultMaxAdjustmentPoints
+	}
+	if config.DashboardCacheTTL <= 0 {
+		config.DashboardCacheTTL = DefaultDashboardCacheTTL
+	}
+	if config.EarningRules == nil {
+		config.EarningRules = DefaultEarningRules()
+	}
+	return &Service{
+		repo:         repo,
+		config:       config,
+		leaderboards: newLeaderboardCache(),
+		dashboards:   newDashboardCache(),
+		locker:       newLocalPointsLocker(),
+		earningRules: newEarningRules(config.EarningRules),
+		audit:        audit.Nop(),
+	}
+}
+
+// SetAuditLogger sets where admin changes to points, promos, redemptions and
+// waitlists are audited
+func (s *Service) SetAuditLogger(logger audit.Logger) {
+	s.audit = logger
+}
+
+// SetWaitlistNotifier sets the notifier used to tell waitlisted riders that a
+// reward is back in stock. Without one, ReleaseWaitlist only returns the riders.
+func (s *Service) SetWaitlistNotifier(notifier WaitlistNotifier) {
+	s.notifier = notifier
+}
+
+// SetFareConverter sets how fares in other currencies are converted to the base
+// currency earning rules are priced in. Without one, only fares without a
+// currency can be priced.
+func (s *Service) SetFareConverter(converter FareConverter) {
+	s.fareConverter = converter
 }
 
 // ========================================
 // LOYALTY ACCOUNT MANAGEMENT
 // ========================================
 
-// GetOrCreateLoyaltyAccount gets or creates a loyalty account for a rider
+// GetOrCreateLoyaltyAccount gets or creates a loyalty account for a rider. A new
+// account comes with the signup bonus, recorded together with the account so
+// the bonus is awarded exactly once however creation is retried.
 func (s *Service) GetOrCreateLoyaltyAccount(ctx context.Context, riderID uuid.UUID) (*RiderLoyalty, error) {
 	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
 	if err == nil {
@@ -37,40 +186,81 @@ func (s *Service) GetOrCreateLoyaltyAccount(ctx context.Context, riderID uuid.UU
 	// Create new account
 	bronzeTier, err := s.repo.GetTierByName(ctx, TierBronze)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to get default tier")
+		return nil, common.NewInternalError("failed to get default tier", err)
 	}
 
+	now := time.Now()
 	account = &RiderLoyalty{
 		RiderID:         riderID,
 		CurrentTierID:   &bronzeTier.ID,
-		TotalPoints:     0,
-		AvailablePoints: 0,
-		LifetimePoints:  0,
-		TierPoints:      0,
-		TierPeriodStart: time.Now(),
-		TierPeriodEnd:   time.Now().AddDate(1, 0, 0),
-		JoinedAt:        time.Now(),
+		CurrentTier:     bronzeTier,
+		TierPeriodStart: now,
+		TierPeriodEnd:   now.AddDate(1, 0, 0),
+		JoinedAt:        now,
 	}
 
-	if err := s.repo.CreateRiderLoyalty(ctx, account); err != nil {
-		return nil, common.NewInternalServerError("failed to create loyalty account")
+	bonus := s.signupBonus(ctx, account, now)
+	account.TotalPoints = bonus.Points
+	account.AvailablePoints = bonus.Points
+	account.LifetimePoints = bonus.Points
+	account.TierPoints = bonus.Points
+
+	created, err := s.repo.CreateRiderLoyaltyWithSignupBonus(ctx, account, bonus)
+	if err != nil {
+		signupBonuses.WithLabelValues(signupBonusFailed).Inc()
+		logger.ErrorContext(ctx, "Failed to create loyalty account with signup bonus",
+			zap.String("rider_id", riderID.String()),
+			zap.Error(err),
+		)
+		return nil, common.NewInternalError("failed to create loyalty account", err)
 	}
 
-	account.CurrentTier = bronzeTier
+	if !created {
+		// A concurrent request created the account, and awarded its bonus, first
+		signupBonuses.WithLabelValues(signupBonusAlreadyAwarded).Inc()
+		existing, err := s.repo.GetRiderLoyalty(ctx, riderID)
+		if err != nil {
+			return nil, common.NewInternalError("failed to get loyalty account", err)
+		}
+		return existing, nil
+	}
 
-	// Award signup bonus
-	go func() {
-		_ = s.EarnPoints(context.Background(), &EarnPointsRequest{
-			RiderID:     riderID,
-			Points:      100,
-			Source:      SourceSignup,
-			Description: "Welcome bonus!",
-		})
-	}()
+	signupBonuses.WithLabelValues(signupBonusAwarded).Inc()
+	logger.InfoContext(ctx, "Signup bonus awarded",
+		zap.String("rider_id", riderID.String()),
+		zap.Int("points", bonus.Points),
+	)
 
 	return account, nil
 }
 
+// signupBonus builds the signup bonus for a new account, scaled like any other
+// earn. A promo lookup failure isn't worth failing the signup over, so the
+// bonus is then awarded without promos.
+func (s *Service) signupBonus(ctx context.Context, account *RiderLoyalty, now time.Time) *PointsTransaction {
+	promos, err := s.activePointPromos(ctx, now)
+	if err != nil {
+		logger.WarnContext(ctx, "Awarding signup bonus without promos",
+			zap.String("rider_id", account.RiderID.String()),
+			zap.Error(err),
+		)
+	}
+
+	earning := s.earning(account, SignupBonusPoints, SourceSignup, promos, now, true)
+	description := "Welcome bonus!"
+	return &PointsTransaction{
+		ID:              uuid.New(),
+		RiderID:         account.RiderID,
+		TransactionType: TransactionEarn,
+		Points:          earning.Points,
+		BalanceAfter:    earning.Points,
+		Source:          SourceSignup,
+		Description:     &description,
+		ExpiresAt:       timePtr(now.AddDate(1, 0, 0)), // Points expire in 1 year
+		Metadata:        s.earningMetadata(earning),
+	}
+}
+
 // GetLoyaltyStatus gets the full loyalty status for a rider
 func (s *Service) GetLoyaltyStatus(ctx context.Context, riderID uuid.UUID) (*LoyaltyStatusResponse, error) {
 	account, err := s.GetOrCreateLoyaltyAccount(ctx, riderID)
@@ -86,27 +276,15 @@ func (s *Service) GetLoyaltyStatus(ctx context.Context, riderID uuid.UUID) (*Loy
 
 	// Get next tier
 	tiers, _ := s.repo.GetAllTiers(ctx)
-	var nextTier *LoyaltyTier
-	pointsToNext := 0
-	tierProgress := 100.0
-
-	for _, t := range tiers {
-		if t.MinPoints > account.TierPoints {
-			nextTier = t
-			pointsToNext = t.MinPoints - account.TierPoints
-			if currentTier != nil && nextTier != nil {
-				tierProgress = float64(account.TierPoints-currentTier.MinPoints) / float64(nextTier.MinPoints-currentTier.MinPoints) * 100
-			}
-			break
-		}
-	}
+	nextTier, pointsToNext, tierProgress := nextTierProgress(tiers, currentTier, account.TierPoints)
 
 	// Calculate remaining benefits
 	freeCancellations := 0
 	freeUpgrades := 0
 	if currentTier != nil {
-		freeCancellations = currentTier.FreeCancellations - account.FreeCancellationsUsed
-		freeUpgrades = currentTier.FreeUpgrades - account.FreeUpgradesUsed
+		cancellationsUsed, upgradesUsed := benefitsUsed(account, time.Now())
+		freeCancellations = currentTier.FreeCancellations - cancellationsUsed
+		freeUpgrades = currentTier.FreeUpgrades - upgradesUsed
 		if freeCancellations < 0 {
 			freeCancellations = 0
 		}
@@ -140,23 +318,50 @@ func (s *Service) GetLoyaltyStatus(ctx context.Context, riderID uuid.UUID) (*Loy
 // POINTS MANAGEMENT
 // ========================================
 
-// EarnPoints adds points to a rider's account
+// EarnPoints adds points to a rider's account. With an idempotency key, the
+// points are awarded only once however often the request is retried.
 func (s *Service) EarnPoints(ctx context.Context, req *EarnPointsRequest) error {
-	if req.Points <= 0 {
-		return common.NewBadRequestError("points must be positive", nil)
+	_, err := runIdempotent(ctx, s, req.RiderID, OperationEarnPoints, req.IdempotencyKey, req, func() (earnPointsResult, error) {
+		points, err := s.earnPoints(ctx, req, true, nil)
+		return earnPointsResult{Points: points}, err
+	})
+	return err
+}
+
+// errEarningDeclined is returned by earnPoints when its guard doesn't hold
+var errEarningDeclined = errors.New("points guard declined the earning")
+
+// earnPoints adds points to a rider's account, optionally scaled by the rider's
+// tier multiplier and the promo running for the source, and returns the number
+// of points credited. With a guard, the points are credited in one database
+// transaction that only goes ahead if the guard holds; otherwise nothing is
+// written and errEarningDeclined is returned.
+func (s *Service) earnPoints(ctx context.Context, req *EarnPointsRequest, applyMultiplier bool, guard PointsGuard) (int, error) {
+	basePoints, err := s.basePoints(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := s.lockPoints(ctx, req.RiderID)
+	if err != nil {
+		return 0, err
 	}
+	defer unlock()
 
 	account, err := s.GetOrCreateLoyaltyAccount(ctx, req.RiderID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// Apply tier multiplier
-	multiplier := 1.0
-	if account.CurrentTier != nil {
-		multiplier = account.CurrentTier.Multiplier
+	now := time.Now()
+	var promos []*PointMultiplierPromo
+	if applyMultiplier {
+		if promos, err = s.activePointPromos(ctx, now); err != nil {
+			return 0, err
+		}
 	}
-	earnedPoints := int(float64(req.Points) * multiplier)
+	earning := s.earning(account, basePoints, req.Source, promos, now, applyMultiplier)
+	earnedPoints := earning.Points
 
 	// Update balance
 	newBalance := account.AvailablePoints + earnedPoints
@@ -170,146 +375,349 @@ func (s *Service) EarnPoints(ctx context.Context, req *EarnPointsRequest) error
 		BalanceAfter:    newBalance,
 		Source:          req.Source,
 		SourceID:        req.SourceID,
-		ExpiresAt:       timePtr(time.Now().AddDate(1, 0, 0)), // Points expire in 1 year
+		ExpiresAt:       timePtr(now.AddDate(1, 0, 0)), // Points expire in 1 year
+		Metadata:        s.earningMetadata(earning),
 	}
 
 	if req.Description != "" {
 		tx.Description = &req.Description
 	}
 
-	if err := s.repo.CreatePointsTransaction(ctx, tx); err != nil {
-		return common.NewInternalServerError("failed to record points")
-	}
+	if guard != nil {
+		credited, err := s.repo.CreditPointsGuarded(ctx, tx, guard)
+		if err != nil {
+			return 0, common.NewInternalError("failed to record points", err)
+		}
+		if !credited {
+			return 0, errEarningDeclined
+		}
+	} else {
+		if err := s.repo.CreatePointsTransaction(ctx, tx); err != nil {
+			return 0, common.NewInternalError("failed to record points", err)
+		}
 
-	// Update account
-	if err := s.repo.UpdatePoints(ctx, req.RiderID, earnedPoints, earnedPoints); err != nil {
-		return common.NewInternalServerError("failed to update points")
+		// Update account
+		if err := s.repo.UpdatePoints(ctx, req.RiderID, earnedPoints, earnedPoints); err != nil {
+			return 0, common.NewInternalError("failed to update points", err)
+		}
 	}
 
-	// Check for tier upgrade
+	// Check for tier upgrade, under the same request ID but not its deadline
+	tierCtx := context.WithoutCancel(ctx)
 	go func() {
-		_ = s.checkTierUpgrade(context.Background(), req.RiderID)
+		_ = s.checkTierUpgrade(tierCtx, req.RiderID)
 	}()
 
-	logger.Info("Points earned",
+	logger.InfoContext(ctx, "Points earned",
 		zap.String("rider_id", req.RiderID.String()),
 		zap.Int("points", earnedPoints),
 		zap.String("source", string(req.Source)),
 	)
+	if earning.Promo != nil {
+		logger.InfoContext(ctx, "Point promo applied",
+			zap.String("rider_id", req.RiderID.String()),
+			zap.String("promo_id", earning.Promo.ID.String()),
+			zap.Float64("promo_multiplier", earning.Promo.Multiplier),
+		)
+	}
 
-	return nil
+	// Points awarded by an admin are audited; those earned by riding aren't
+	if _, ok := audit.ActorFromContext(ctx); ok {
+		s.audit.Record(ctx, audit.Entry{
+			Action:     "loyalty.points_awarded",
+			TargetType: "rider_loyalty",
+			TargetID:   req.RiderID.String(),
+			Before:     map[string]interface{}{"available_points": account.AvailablePoints},
+			After: map[string]interface{}{
+				"available_points": newBalance,
+				"points":           earnedPoints,
+				"source":           string(req.Source),
+			},
+		})
+	}
+
+	return earnedPoints, nil
 }
 
-// RedeemPoints redeems points for a reward
-func (s *Service) RedeemPoints(ctx context.Context, req *RedeemPointsRequest) (*RedeemPointsResponse, error) {
-	account, err := s.repo.GetRiderLoyalty(ctx, req.RiderID)
+// PreviewEarnings projects what EarnPoints would credit for basePoints from
+// source, and where that leaves the rider's tier progress. Nothing is written;
+// a rider without an account is previewed as the new account EarnPoints would
+// create.
+func (s *Service) PreviewEarnings(ctx context.Context, riderID uuid.UUID, basePoints int, source PointSource) (*PointsPreview, error) {
+	if basePoints <= 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "points must be positive", nil)
+	}
+	if source == "" {
+		source = SourceRide
+	}
+
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
 	if err != nil {
-		return nil, common.NewNotFoundError("loyalty account not found", err)
+		bronzeTier, err := s.repo.GetTierByName(ctx, TierBronze)
+		if err != nil {
+			return nil, common.NewInternalError("failed to get default tier", err)
+		}
+		account = &RiderLoyalty{RiderID: riderID, CurrentTierID: &bronzeTier.ID, CurrentTier: bronzeTier}
 	}
 
-	reward, err := s.repo.GetReward(ctx, req.RewardID)
+	now := time.Now()
+	promos, err := s.activePointPromos(ctx, now)
 	if err != nil {
-		return nil, common.NewNotFoundError("reward not found", err)
+		return nil, err
+	}
+	earning := s.earning(account, basePoints, source, promos, now, true)
+	points := earning.Points
+
+	preview := &PointsPreview{
+		RiderID:              riderID,
+		Source:               source,
+		BasePoints:           basePoints,
+		Multiplier:           earning.TierMultiplier,
+		Promo:                earning.Promo,
+		RoundingMode:         s.config.RoundingMode,
+		ProjectedPoints:      points,
+		AvailablePointsAfter: account.AvailablePoints + points,
+		TierPointsAfter:      account.TierPoints + points,
+		CurrentTier:          account.CurrentTier,
+		ProjectedTier:        account.CurrentTier,
 	}
 
-	if !reward.IsActive {
-		return nil, common.NewBadRequestError("reward is no longer available", nil)
+	tiers, err := s.repo.GetAllTiers(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get tiers", err)
 	}
+	if tier := qualifyingTier(tiers, preview.TierPointsAfter); tier != nil {
+		preview.ProjectedTier = tier
+		preview.TierChanged = account.CurrentTierID == nil || *account.CurrentTierID != tier.ID
+	}
+	preview.NextTier, preview.PointsToNextTier, preview.TierProgress =
+		nextTierProgress(tiers, preview.ProjectedTier, preview.TierPointsAfter)
 
-	if account.AvailablePoints < reward.PointsRequired {
-		return nil, common.NewBadRequestError(
-			fmt.Sprintf("insufficient points: need %d, have %d", reward.PointsRequired, account.AvailablePoints),
-			nil,
-		)
+	return preview, nil
+}
+
+// EarnPointsBatch awards several point entries to one rider in a single pass.
+// The account is loaded once, every entry gets the tier multiplier and any promo
+// for its source, and all transactions plus the balance update are committed
+// atomically.
+func (s *Service) EarnPointsBatch(ctx context.Context, riderID uuid.UUID, reqs []EarnPointsRequest) (*EarnPointsBatchResponse, error) {
+	if len(reqs) == 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "at least one entry is required", nil)
+	}
+
+	basePoints := make([]int, len(reqs))
+	for i := range reqs {
+		req := &reqs[i]
+		points, err := s.basePoints(ctx, req)
+		if err != nil {
+			// Say which entry is invalid; other failures keep their own error
+			if appErr, ok := common.AsAppError(err); ok && appErr.Code == http.StatusBadRequest {
+				return nil, common.NewErrorWithCode(http.StatusBadRequest, appErr.ErrorCode, fmt.Sprintf("entry %d: %s", i, appErr.Message), nil)
+			}
+			return nil, err
+		}
+		basePoints[i] = points
+		if req.RiderID != uuid.Nil && req.RiderID != riderID {
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, fmt.Sprintf("entry %d: rider_id does not match batch rider", i), nil)
+		}
 	}
 
-	// Check tier restriction
-	if reward.TierRestriction != nil && account.CurrentTierID != nil {
-		currentTier, _ := s.repo.GetTier(ctx, *account.CurrentTierID)
-		restrictedTier, _ := s.repo.GetTier(ctx, *reward.TierRestriction)
-		if currentTier != nil && restrictedTier != nil && currentTier.MinPoints < restrictedTier.MinPoints {
-			return nil, common.NewForbiddenError("this reward requires a higher tier")
+	unlock, err := s.lockPoints(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	account, err := s.GetOrCreateLoyaltyAccount(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	promos, err := s.activePointPromos(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.AddDate(1, 0, 0) // Points expire in 1 year
+	balance := account.AvailablePoints
+	totalAwarded := 0
+
+	txs := make([]*PointsTransaction, 0, len(reqs))
+	entries := make([]EarnPointsBatchResult, 0, len(reqs))
+	for i, req := range reqs {
+		earning := s.earning(account, basePoints[i], req.Source, promos, now, true)
+		earnedPoints := earning.Points
+		balance += earnedPoints
+		totalAwarded += earnedPoints
+
+		tx := &PointsTransaction{
+			ID:              uuid.New(),
+			RiderID:         riderID,
+			TransactionType: TransactionEarn,
+			Points:          earnedPoints,
+			BalanceAfter:    balance,
+			Source:          req.Source,
+			SourceID:        req.SourceID,
+			ExpiresAt:       timePtr(expiresAt),
+			Metadata:        s.earningMetadata(earning),
+		}
+		if req.Description != "" {
+			description := req.Description
+			tx.Description = &description
 		}
+
+		txs = append(txs, tx)
+		entries = append(entries, EarnPointsBatchResult{
+			Source:        req.Source,
+			SourceID:      req.SourceID,
+			BasePoints:    basePoints[i],
+			AwardedPoints: earnedPoints,
+		})
+	}
+
+	if err := s.repo.CreatePointsTransactionsBatch(ctx, riderID, txs, totalAwarded, totalAwarded); err != nil {
+		return nil, common.NewInternalError("failed to record points", err)
+	}
+
+	// Check for tier upgrade once for the whole batch
+	tierCtx := context.WithoutCancel(ctx)
+	go func() {
+		_ = s.checkTierUpgrade(tierCtx, riderID)
+	}()
+
+	logger.InfoContext(ctx, "Points earned in batch",
+		zap.String("rider_id", riderID.String()),
+		zap.Int("entries", len(entries)),
+		zap.Int("points", totalAwarded),
+	)
+
+	return &EarnPointsBatchResponse{
+		RiderID:      riderID,
+		Entries:      entries,
+		TotalAwarded: totalAwarded,
+		BalanceAfter: balance,
+	}, nil
+}
+
+// RedeemPoints redeems points for a reward. With an idempotency key, retries
+// return the original redemption instead of deducting the points again.
+func (s *Service) RedeemPoints(ctx context.Context, req *RedeemPointsRequest) (*RedeemPointsResponse, error) {
+	return runIdempotent(ctx, s, req.RiderID, OperationRedeemPoints, req.IdempotencyKey, req, func() (*RedeemPointsResponse, error) {
+		return s.redeemPoints(ctx, req)
+	})
+}
+
+func (s *Service) redeemPoints(ctx context.Context, req *RedeemPointsRequest) (*RedeemPointsResponse, error) {
+	return s.redeemReward(ctx, req.RiderID, req.RewardID, nil)
+}
+
+// redeemReward redeems a reward for its full points price, or with
+// pointsToApply set, for that many points and the rest in cash
+func (s *Service) redeemReward(ctx context.Context, riderID, rewardID uuid.UUID, pointsToApply *int) (*RedeemPointsResponse, error) {
+	unlock, err := s.lockPoints(ctx, riderID)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	// Check max redemptions
-	if reward.MaxRedemptionsPerUser != nil {
-		count, _ := s.repo.GetUserRedemptionCount(ctx, req.RiderID, req.RewardID)
-		if count >= *reward.MaxRedemptionsPerUser {
-			return nil, common.NewBadRequestError("you have reached the maximum redemptions for this reward", nil)
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
+	if err != nil {
+		return nil, common.NewNotFoundError("loyalty account not found", err)
+	}
+
+	reward, err := s.repo.GetReward(ctx, rewardID)
+	if err != nil {
+		return nil, common.NewNotFoundError("reward not found", err)
+	}
+
+	points := reward.PointsRequired
+	var cashAmount float64
+	reasons := s.rewardIneligibilities(ctx, account, reward)
+	if pointsToApply != nil {
+		if points, cashAmount, err = splitRewardPrice(account, reward, *pointsToApply); err != nil {
+			return nil, err
 		}
+		// Only the points applied need to be available
+		reasons = withoutReason(reasons, common.ErrCodeInsufficientPoints)
+	}
+	if len(reasons) > 0 {
+		return nil, reasons[0].err()
 	}
 
 	// Generate redemption code
 	code := generateRedemptionCode()
-	newBalance := account.AvailablePoints - reward.PointsRequired
 
 	// Create redemption
 	redemption := &Redemption{
 		ID:             uuid.New(),
-		RiderID:        req.RiderID,
-		RewardID:       req.RewardID,
-		PointsSpent:    reward.PointsRequired,
+		RiderID:        riderID,
+		RewardID:       rewardID,
+		PointsSpent:    points,
+		CashAmount:     cashAmount,
 		RedemptionCode: code,
-		Status:         "active",
+		Status:         RedemptionStatusActive,
 		ExpiresAt:      time.Now().AddDate(0, 0, reward.ValidDays),
 	}
 
-	if err := s.repo.CreateRedemption(ctx, redemption); err != nil {
-		return nil, common.NewInternalServerError("failed to create redemption")
-	}
-
 	// Create debit transaction
 	tx := &PointsTransaction{
 		ID:              uuid.New(),
-		RiderID:         req.RiderID,
+		RiderID:         riderID,
 		TransactionType: TransactionRedeem,
-		Points:          -reward.PointsRequired,
-		BalanceAfter:    newBalance,
+		Points:          -points,
+		BalanceAfter:    account.AvailablePoints - points,
 		Source:          PointSource("redemption"),
 		SourceID:        &redemption.ID,
 	}
 
-	if err := s.repo.CreatePointsTransaction(ctx, tx); err != nil {
-		return nil, common.NewInternalServerError("failed to record redemption")
-	}
-
-	// Update balance
-	if err := s.repo.DeductPoints(ctx, req.RiderID, reward.PointsRequired); err != nil {
-		return nil, common.NewInternalServerError("failed to deduct points")
+	// Stock, balance, redemption and ledger change together or not at all
+	if err := s.repo.RedeemReward(ctx, redemption, tx); err != nil {
+		switch {
+		case errors.Is(err, ErrRewardOutOfStock):
+			return nil, outOfStock().err()
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeInsufficientPoints,
+				fmt.Sprintf("insufficient points: need %d", points), nil)
+		default:
+			return nil, common.NewInternalError("failed to redeem reward", err)
+		}
 	}
 
-	// Increment redemption count
-	_ = s.repo.IncrementRewardRedemptionCount(ctx, req.RewardID)
-
-	logger.Info("Points redeemed",
-		zap.String("rider_id", req.RiderID.String()),
-		zap.String("reward_id", req.RewardID.String()),
-		zap.Int("points", reward.PointsRequired),
+	logger.InfoContext(ctx, "Points redeemed",
+		zap.String("rider_id", riderID.String()),
+		zap.String("reward_id", rewardID.String()),
+		zap.Int("points", points),
+		zap.Float64("cash_amount", cashAmount),
 	)
 
 	return &RedeemPointsResponse{
 		RedemptionID:   redemption.ID,
 		RedemptionCode: code,
-		PointsSpent:    reward.PointsRequired,
-		BalanceAfter:   newBalance,
+		PointsSpent:    points,
+		CashAmount:     cashAmount,
+		BalanceAfter:   tx.BalanceAfter,
 		ExpiresAt:      redemption.ExpiresAt,
 		Instructions:   fmt.Sprintf("Use code %s at checkout. Valid until %s", code, redemption.ExpiresAt.Format("Jan 2, 2006")),
 	}, nil
 }
 
-// GetPointsHistory gets points transaction history
-func (s *Service) GetPointsHistory(ctx context.Context, riderID uuid.UUID, limit, offset int) (*PointsHistoryResponse, error) {
-	if limit < 1 || limit > 100 {
-		limit = 20
+// GetPointsHistory gets a page of points transaction history, newest first,
+// narrowed by filter. Total counts the transactions matching the filter.
+func (s *Service) GetPointsHistory(ctx context.Context, riderID uuid.UUID, filter PointsHistoryFilter, limit, offset int) (*PointsHistoryResponse, error) {
+	switch filter.TransactionType {
+	case "", TransactionEarn, TransactionRedeem, TransactionExpire, TransactionBonus, TransactionAdjustment:
+	default:
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "type must be earn, redeem, expire, bonus or adjustment", nil)
 	}
-	if offset < 0 {
-		offset = 0
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "from must be before to", nil)
 	}
+	limit, offset = httputil.NormalizePagination(limit, offset)
 
-	transactions, total, err := s.repo.GetPointsHistory(ctx, riderID, limit, offset)
+	transactions, total, err := s.repo.GetPointsHistory(ctx, riderID, filter, limit, offset)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to get points history")
+		return nil, common.NewInternalError("failed to get points history", err)
 	}
 
 	// Convert pointers to values
@@ -320,9 +728,38 @@ func (s *Service) GetPointsHistory(ctx context.Context, riderID uuid.UUID, limit
 
 	return &PointsHistoryResponse{
 		Transactions: txList,
-		Total:        total,
+		PageInfo:     httputil.NewPageInfo(total, limit, offset),
+	}, nil
+}
+
+// GetPointsHistoryCursor gets a page of points transaction history, newest
+// first, starting after afterCursor, or from the newest when it is empty.
+// Unlike GetPointsHistory, paging by cursor doesn't repeat or skip transactions
+// when new ones are recorded between pages.
+func (s *Service) GetPointsHistoryCursor(ctx context.Context, riderID uuid.UUID, afterCursor string, limit int) (*PointsHistoryPage, error) {
+	limit, _ = httputil.NormalizePagination(limit, 0)
+	after, err := pagination.DecodeCursor(afterCursor)
+	if err != nil {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "invalid cursor", nil)
+	}
+
+	transactions, err := s.repo.GetPointsHistoryAfter(ctx, riderID, after, limit+1)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get points history", err)
+	}
+	transactions, next := pagination.Page(transactions, limit, func(tx *PointsTransaction) *pagination.Cursor {
+		return pagination.NewCursor(tx.CreatedAt, tx.ID)
+	})
+
+	txList := make([]PointsTransaction, len(transactions))
+	for i, tx := range transactions {
+		txList[i] = *tx
+	}
+
+	return &PointsHistoryPage{
+		Transactions: txList,
+		NextCursor:   next,
 		Limit:        limit,
-		Offset:       offset,
 	}, nil
 }
 
@@ -339,7 +776,7 @@ func (s *Service) GetActiveChallenges(ctx context.Context, riderID uuid.UUID) (*
 
 	challenges, err := s.repo.GetActiveChallenges(ctx, account.CurrentTierID)
 	if err != nil {
-		return nil, common.NewInternalServerError("failed to get challenges")
+		return nil, common.NewInternalError("failed to get challenges", err)
 	}
 
 	var result []ChallengeWithProgress
@@ -356,10 +793,7 @@ func (s *Service) GetActiveChallenges(ctx context.Context, riderID uuid.UUID) (*
 		if progress != nil {
 			cwp.CurrentValue = progress.CurrentValue
 			cwp.Completed = progress.Completed
-			cwp.ProgressPercent = float64(progress.CurrentValue) / float64(c.TargetValue) * 100
-			if cwp.ProgressPercent > 100 {
-				cwp.ProgressPercent = 100
-			}
+			cwp.ProgressPercent = progressPercent(progress.CurrentValue, c.TargetValue)
 		}
 
 		result = append(result, cwp)
@@ -368,7 +802,8 @@ func (s *Service) GetActiveChallenges(ctx context.Context, riderID uuid.UUID) (*
 	return &ActiveChallengesResponse{Challenges: result}, nil
 }
 
-// UpdateChallengeProgress updates a rider's progress on challenges
+// UpdateChallengeProgress applies increment to a rider's active challenges of
+// one type. Use RecordChallengeEvent to update every type a ride counts towards.
 func (s *Service) UpdateChallengeProgress(ctx context.Context, riderID uuid.UUID, challengeType string, increment int) error {
 	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
 	if err != nil {
@@ -381,47 +816,282 @@ func (s *Service) UpdateChallengeProgress(ctx context.Context, riderID uuid.UUID
 	}
 
 	for _, challenge := range challenges {
-		progress, _ := s.repo.GetChallengeProgress(ctx, riderID, challenge.ID)
-
-		if progress == nil {
-			// Create new progress record
-			progress = &ChallengeProgress{
-				ID:          uuid.New(),
-				RiderID:     riderID,
-				ChallengeID: challenge.ID,
-			}
-			if err := s.repo.CreateChallengeProgress(ctx, progress); err != nil {
-				continue
-			}
-		}
+		s.progressChallenge(ctx, riderID, challenge, increment, time.Now())
+	}
+
+	return nil
+}
+
+// ========================================
+// STREAKS
+// ========================================
 
-		if progress.Completed {
-			continue // Already completed
+// RecordRideForStreak updates a rider's daily ride streak. rideDate must be in the
+// rider's local time zone so that day boundaries match the rider's calendar.
+// Consecutive-day rides extend the streak, same-day rides leave it unchanged and a
+// gap of more than one day resets it to 1. Reaching a milestone awards bonus points.
+func (s *Service) RecordRideForStreak(ctx context.Context, riderID uuid.UUID, rideDate time.Time) (*StreakUpdateResult, error) {
+	account, err := s.GetOrCreateLoyaltyAccount(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+
+	rideDay := calendarDay(rideDate)
+	result := &StreakUpdateResult{
+		RiderID:    riderID,
+		StreakDays: account.StreakDays,
+	}
+
+	if account.LastRideDate != nil {
+		// last_ride_date is a DATE column, so its calendar fields are already the rider's local day
+		lastDay := time.Date(account.LastRideDate.Year(), account.LastRideDate.Month(), account.LastRideDate.Day(), 0, 0, 0, 0, time.UTC)
+		daysSince := int(rideDay.Sub(lastDay).Hours() / 24)
+
+		switch {
+		case daysSince <= 0:
+			// Same day (or an out-of-order earlier ride) doesn't change the streak
+			return result, nil
+		case daysSince == 1:
+			result.StreakDays = account.StreakDays + 1
+		default:
+			result.StreakDays = 1
+			result.StreakReset = account.StreakDays > 0
 		}
+	} else {
+		result.StreakDays = 1
+	}
 
-		// Update progress
-		newValue := progress.CurrentValue + increment
-		completed := newValue >= challenge.TargetValue
+	if err := s.repo.UpdateStreak(ctx, riderID, result.StreakDays, rideDay); err != nil {
+		return nil, common.NewInternalError("failed to update streak", err)
+	}
 
-		if err := s.repo.UpdateChallengeProgress(ctx, progress.ID, newValue, completed); err != nil {
+	for _, milestone := range s.config.StreakMilestones {
+		if milestone.Days != result.StreakDays || milestone.BonusPoints <= 0 {
 			continue
 		}
 
-		// Award points if completed
-		if completed && !progress.Completed {
-			_ = s.EarnPoints(ctx, &EarnPointsRequest{
-				RiderID:     riderID,
-				Points:      challenge.RewardPoints,
-				Source:      SourceChallenge,
-				SourceID:    &challenge.ID,
-				Description: fmt.Sprintf("Completed challenge: %s", challenge.Name),
-			})
+		if err := s.EarnPoints(ctx, &EarnPointsRequest{
+			RiderID:     riderID,
+			Points:      milestone.BonusPoints,
+			Source:      SourceStreak,
+			Description: fmt.Sprintf("%d-day ride streak bonus", milestone.Days),
+		}); err != nil {
+			logger.WarnContext(ctx, "Failed to award streak bonus",
+				zap.String("rider_id", riderID.String()),
+				zap.Int("streak_days", result.StreakDays),
+				zap.Error(err),
+			)
+			continue
 		}
+		result.BonusPoints += milestone.BonusPoints
 	}
 
+	return result, nil
+}
+
+// ========================================
+// REFERRALS
+// ========================================
+
+// ProcessReferral awards the referral bonus to both the referrer and the referee
+// once the referee completes a qualifying first ride. A referee can only be
+// referred once, so calling it again for the same pair only pays a side that a
+// previous call failed to pay, while a second referrer is rejected. Self-referrals
+// and referees with existing loyalty history are rejected too.
+func (s *Service) ProcessReferral(ctx context.Context, referrerID, refereeID, firstRideID uuid.UUID) (*Referral, error) {
+	if referrerID == refereeID {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "riders cannot refer themselves", nil)
+	}
+
+	referral, err := s.repo.GetReferralByReferee(ctx, refereeID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewInternalError("failed to get referral", err)
+	}
+
+	if referral == nil {
+		hasHistory, err := s.repo.HasLoyaltyHistory(ctx, refereeID, firstRideID)
+		if err != nil {
+			return nil, common.NewInternalError("failed to check loyalty history", err)
+		}
+		if hasHistory {
+			return nil, common.NewConflictError("referee already has loyalty history")
+		}
+
+		referral = &Referral{
+			ID:             uuid.New(),
+			ReferrerID:     referrerID,
+			RefereeID:      refereeID,
+			FirstRideID:    firstRideID,
+			ReferrerPoints: s.config.ReferrerPoints,
+			RefereePoints:  s.config.RefereePoints,
+		}
+		created, err := s.repo.CreateReferral(ctx, referral)
+		if err != nil {
+			return nil, common.NewInternalError("failed to record referral", err)
+		}
+		if !created {
+			// A concurrent call recorded the referee first; continue with its record
+			if referral, err = s.repo.GetReferralByReferee(ctx, refereeID); err != nil {
+				return nil, common.NewInternalError("failed to get referral", err)
+			}
+		}
+	}
+	if referral.ReferrerID != referrerID {
+		return nil, common.NewConflictError("referee was already referred by another rider")
+	}
+
+	if referral.ReferrerRewardedAt == nil {
+		if err := s.awardReferral(ctx, referral, ReferralSideReferrer); err != nil {
+			return nil, err
+		}
+	}
+	if referral.RefereeRewardedAt == nil {
+		if err := s.awardReferral(ctx, referral, ReferralSideReferee); err != nil {
+			return nil, err
+		}
+	}
+
+	return referral, nil
+}
+
+// awardReferral pays one side of a referral through earnPoints, guarded by
+// marking the side as paid in the same database transaction, so a concurrent
+// call for the same referral can't pay it twice
+func (s *Service) awardReferral(ctx context.Context, referral *Referral, side ReferralSide) error {
+	req := &EarnPointsRequest{
+		RiderID:     referral.ReferrerID,
+		Points:      referral.ReferrerPoints,
+		Source:      SourceReferral,
+		SourceID:    &referral.FirstRideID,
+		Description: "Referral bonus for inviting a friend",
+	}
+	if side == ReferralSideReferee {
+		req.RiderID = referral.RefereeID
+		req.Points = referral.RefereePoints
+		req.Description = "Welcome bonus for joining through a referral"
+	}
+
+	points, err := s.earnPoints(ctx, req, true, referralRewardGuard(referral.ID, side))
+	if err != nil && !errors.Is(err, errEarningDeclined) {
+		return err
+	}
+
+	now := time.Now()
+	if side == ReferralSideReferee {
+		referral.RefereeRewardedAt = &now
+	} else {
+		referral.ReferrerRewardedAt = &now
+	}
+
+	if err != nil {
+		logger.InfoContext(ctx, "Referral bonus already awarded",
+			zap.String("referral_id", referral.ID.String()),
+			zap.String("side", string(side)),
+		)
+		return nil
+	}
+
+	logger.InfoContext(ctx, "Referral bonus awarded",
+		zap.String("referral_id", referral.ID.String()),
+		zap.String("rider_id", req.RiderID.String()),
+		zap.String("side", string(side)),
+		zap.Int("points", points),
+	)
+
 	return nil
 }
 
+// ========================================
+// SIMULATION
+// ========================================
+
+// SimulateRideImpact projects what a ride would do to a rider's loyalty account:
+// points earned with the tier multiplier and any running promo, challenge
+// progress (and completion rewards) and any resulting tier change. It only reads
+// state and never writes.
+func (s *Service) SimulateRideImpact(ctx context.Context, riderID uuid.UUID, basePoints int, challengeType string, increment int) (*RideImpactSimulation, error) {
+	if basePoints < 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "base points cannot be negative", nil)
+	}
+	if increment < 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "increment cannot be negative", nil)
+	}
+
+	account, err := s.repo.GetRiderLoyalty(ctx, riderID)
+	if err != nil {
+		return nil, common.NewNotFoundError("loyalty account not found", err)
+	}
+
+	now := time.Now()
+	promos, err := s.activePointPromos(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &RideImpactSimulation{
+		RiderID:     riderID,
+		BasePoints:  basePoints,
+		Multiplier:  tierMultiplier(account),
+		Challenges:  []*SimulatedChallengeProgress{},
+		CurrentTier: account.CurrentTier,
+	}
+
+	// EarnPoints rejects non-positive points, so a zero-point ride earns nothing
+	if basePoints > 0 {
+		earning := s.earning(account, basePoints, SourceRide, promos, now, true)
+		sim.RidePoints = earning.Points
+		sim.Promo = earning.Promo
+	}
+
+	if challengeType != "" && increment > 0 {
+		challenges, err := s.repo.GetActiveChallengesByType(ctx, challengeType, account.CurrentTierID)
+		if err != nil {
+			return nil, common.NewInternalError("failed to get challenges", err)
+		}
+
+		for _, challenge := range challenges {
+			progress, _ := s.repo.GetChallengeProgress(ctx, riderID, challenge.ID)
+			if progress == nil {
+				progress = &ChallengeProgress{}
+			}
+			if progress.Completed {
+				continue // Already completed
+			}
+
+			newValue, completed := advanceChallenge(challenge, progress, increment, time.Now())
+			projected := &SimulatedChallengeProgress{
+				ChallengeID:    challenge.ID,
+				Name:           challenge.Name,
+				CurrentValue:   progress.CurrentValue,
+				ProjectedValue: newValue,
+				TargetValue:    challenge.TargetValue,
+				WillComplete:   completed,
+			}
+			if completed && challenge.RewardPoints > 0 {
+				projected.RewardPoints = s.earning(account, challenge.RewardPoints, SourceChallenge, promos, now, true).Points
+				sim.ChallengePoints += projected.RewardPoints
+			}
+			sim.Challenges = append(sim.Challenges, projected)
+		}
+	}
+
+	sim.TotalPoints = sim.RidePoints + sim.ChallengePoints
+	sim.AvailablePointsAfter = account.AvailablePoints + sim.TotalPoints
+	sim.TierPointsAfter = account.TierPoints + sim.TotalPoints
+
+	tiers, err := s.repo.GetAllTiers(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get tiers", err)
+	}
+	if newTier := qualifyingTier(tiers, sim.TierPointsAfter); newTier != nil &&
+		(account.CurrentTierID == nil || *account.CurrentTierID != newTier.ID) {
+		sim.ProjectedTier = newTier
+		sim.TierChanged = true
+	}
+
+	return sim, nil
+}
+
 // ========================================
 // TIER MANAGEMENT
 // ========================================
@@ -438,13 +1108,7 @@ func (s *Service) checkTierUpgrade(ctx context.Context, riderID uuid.UUID) error
 		return err
 	}
 
-	// Find the highest tier the rider qualifies for
-	var newTier *LoyaltyTier
-	for _, t := range tiers {
-		if account.TierPoints >= t.MinPoints {
-			newTier = t
-		}
-	}
+	newTier := qualifyingTier(tiers, account.TierPoints)
 
 	if newTier == nil || (account.CurrentTierID != nil && *account.CurrentTierID == newTier.ID) {
 		return nil // No change
@@ -455,7 +1119,7 @@ func (s *Service) checkTierUpgrade(ctx context.Context, riderID uuid.UUID) error
 		return err
 	}
 
-	logger.Info("Tier upgraded",
+	logger.InfoContext(ctx, "Tier upgraded",
 		zap.String("rider_id", riderID.String()),
 		zap.String("new_tier", string(newTier.Name)),
 	)
@@ -486,7 +1150,219 @@ func (s *Service) GetAllTiers(ctx context.Context) ([]*LoyaltyTier, error) {
 
 // GetLoyaltyStats gets loyalty program statistics (admin)
 func (s *Service) GetLoyaltyStats(ctx context.Context) (*LoyaltyStats, error) {
-	return s.repo.GetLoyaltyStats(ctx)
+	stats, err := s.repo.GetLoyaltyStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pointsLiability.Set(float64(stats.TotalPointsOutstanding))
+	return stats, nil
+}
+
+// ========================================
+// REDEMPTIONS
+// ========================================
+
+// GetRedemption looks up a redemption by its code, with the reward it was
+// redeemed for, so it can be shown before the code is used
+func (s *Service) GetRedemption(ctx context.Context, code string) (*Redemption, error) {
+	redemption, err := s.repo.GetRedemptionByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.NewNotFoundError("redemption code not found", err)
+		}
+		return nil, common.NewInternalError("failed to get redemption", err)
+	}
+
+	reward, err := s.repo.GetReward(ctx, redemption.RewardID)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get redeemed reward", err)
+	}
+	redemption.Reward = reward
+
+	return redemption, nil
+}
+
+// GetRedemptionHistory gets the rewards a rider has redeemed, newest first, with
+// their codes and expiry. An empty status lists every redemption; otherwise
+// only those with that status, where active codes past their expiry count as
+// expired.
+func (s *Service) GetRedemptionHistory(ctx context.Context, riderID uuid.UUID, status string, limit, offset int) (*RedemptionHistoryResponse, error) {
+	switch status {
+	case "", RedemptionStatusActive, RedemptionStatusUsed, RedemptionStatusExpired, RedemptionStatusCancelled:
+	default:
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "status must be active, used, expired or cancelled", nil)
+	}
+	limit, offset = httputil.NormalizePagination(limit, offset)
+
+	redemptions, total, err := s.repo.GetRedemptionHistory(ctx, riderID, status, time.Now(), limit, offset)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get redemption history", err)
+	}
+
+	// Convert pointers to values
+	list := make([]Redemption, len(redemptions))
+	for i, redemption := range redemptions {
+		list[i] = *redemption
+	}
+
+	return &RedemptionHistoryResponse{
+		Redemptions: list,
+		PageInfo:    httputil.NewPageInfo(total, limit, offset),
+	}, nil
+}
+
+// MarkRedemptionUsed consumes a redemption code. The code must be active and
+// not past its expiry, which is set from the reward's ValidDays when redeemed.
+// Used, expired and cancelled codes are rejected with distinct error codes, and
+// concurrent attempts to use the same code succeed exactly once.
+func (s *Service) MarkRedemptionUsed(ctx context.Context, code string) (*Redemption, error) {
+	redemption, err := s.GetRedemption(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := redemptionUnusableError(redemption, now); err != nil {
+		return nil, err
+	}
+
+	marked, err := s.repo.MarkRedemptionUsed(ctx, redemption.ID, now)
+	if err != nil {
+		return nil, common.NewInternalError("failed to mark redemption used", err)
+	}
+	if !marked {
+		// Another scan used the code, or it expired, between the read and the update
+		current, err := s.repo.GetRedemptionByCode(ctx, redemption.RedemptionCode)
+		if err != nil {
+			return nil, common.NewInternalError("failed to get redemption", err)
+		}
+		if err := redemptionUnusableError(current, now); err != nil {
+			return nil, err
+		}
+		return nil, common.NewErrorWithCode(http.StatusConflict, common.ErrCodeRedemptionNotActive,
+			"redemption code can no longer be used", nil)
+	}
+
+	redemption.Status = RedemptionStatusUsed
+	redemption.UsedAt = &now
+
+	logger.InfoContext(ctx, "Redemption used",
+		zap.String("redemption_id", redemption.ID.String()),
+		zap.String("rider_id", redemption.RiderID.String()),
+		zap.String("reward_id", redemption.RewardID.String()),
+	)
+
+	s.audit.Record(ctx, audit.Entry{
+		Action:     "loyalty.redemption_used",
+		TargetType: "redemption",
+		TargetID:   redemption.ID.String(),
+		Before:     map[string]interface{}{"status": string(RedemptionStatusActive)},
+		After:      map[string]interface{}{"status": string(RedemptionStatusUsed)},
+	})
+
+	return redemption, nil
+}
+
+// redemptionUnusableError explains why a redemption can't be used at now, or
+// returns nil if it can
+func redemptionUnusableError(redemption *Redemption, now time.Time) error {
+	switch {
+	case redemption.Status == RedemptionStatusUsed:
+		return common.NewErrorWithCode(http.StatusConflict, common.ErrCodeRedemptionUsed,
+			"redemption code has already been used", nil)
+	case redemption.Status == RedemptionStatusExpired || !now.Before(redemption.ExpiresAt):
+		return common.NewErrorWithCode(http.StatusGone, common.ErrCodeRedemptionExpired,
+			"redemption code has expired", nil)
+	case redemption.Status != RedemptionStatusActive:
+		return common.NewErrorWithCode(http.StatusConflict, common.ErrCodeRedemptionNotActive,
+			fmt.Sprintf("redemption code is %s", redemption.Status), nil)
+	}
+	return nil
+}
+
+// ========================================
+// REWARD WAITLIST
+// ========================================
+
+// JoinRewardWaitlist records a rider's interest in an out-of-stock reward.
+// Joining a waitlist the rider is already on is a no-op.
+func (s *Service) JoinRewardWaitlist(ctx context.Context, riderID, rewardID uuid.UUID) error {
+	reward, err := s.repo.GetReward(ctx, rewardID)
+	if err != nil {
+		return common.NewNotFoundError("reward not found", err)
+	}
+
+	if !reward.IsActive {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeRewardUnavailable, "reward is no longer available", nil)
+	}
+
+	if reward.TotalInventory == nil || *reward.TotalInventory > 0 {
+		return common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "reward is in stock", nil)
+	}
+
+	added, err := s.repo.AddToRewardWaitlist(ctx, &RewardWaitlistEntry{
+		ID:       uuid.New(),
+		RewardID: rewardID,
+		RiderID:  riderID,
+	})
+	if err != nil {
+		return common.NewInternalError("failed to join reward waitlist", err)
+	}
+
+	if added {
+		logger.InfoContext(ctx, "Rider joined reward waitlist",
+			zap.String("rider_id", riderID.String()),
+			zap.String("reward_id", rewardID.String()),
+		)
+	}
+
+	return nil
+}
+
+// ReleaseWaitlist notifies the next n riders waiting for a reward, typically
+// after its stock has been replenished, and returns them. Each rider is
+// released at most once; a failed notification is logged but not retried.
+func (s *Service) ReleaseWaitlist(ctx context.Context, rewardID uuid.UUID, n int) ([]*RewardWaitlistEntry, error) {
+	if n <= 0 {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "number of riders to release must be positive", nil)
+	}
+
+	reward, err := s.repo.GetReward(ctx, rewardID)
+	if err != nil {
+		return nil, common.NewNotFoundError("reward not found", err)
+	}
+
+	entries, err := s.repo.ClaimRewardWaitlist(ctx, rewardID, n)
+	if err != nil {
+		return nil, common.NewInternalError("failed to release reward waitlist", err)
+	}
+
+	if s.notifier != nil {
+		for _, entry := range entries {
+			if err := s.notifier.NotifyRewardAvailable(ctx, entry.RiderID, reward); err != nil {
+				logger.WarnContext(ctx, "Failed to notify waitlisted rider",
+					zap.String("rider_id", entry.RiderID.String()),
+					zap.String("reward_id", rewardID.String()),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	logger.InfoContext(ctx, "Reward waitlist released",
+		zap.String("reward_id", rewardID.String()),
+		zap.Int("requested", n),
+		zap.Int("released", len(entries)),
+	)
+
+	s.audit.Record(ctx, audit.Entry{
+		Action:     "loyalty.waitlist_released",
+		TargetType: "reward",
+		TargetID:   rewardID.String(),
+		After:      map[string]interface{}{"requested": n, "released": len(entries)},
+	})
+
+	return entries, nil
 }
 
 // ========================================
@@ -502,3 +1378,86 @@ func generateRedemptionCode() string {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+// qualifyingTier returns the highest tier reached with the given tier points.
+// Tiers may be given in any order.
+func qualifyingTier(tiers []*LoyaltyTier, tierPoints int) *LoyaltyTier {
+	var tier *LoyaltyTier
+	for _, t := range sortedTiers(tiers) {
+		if tierPoints >= t.MinPoints {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// nextTierProgress returns the first tier above tierPoints, the points still
+// needed to reach it and the progress towards it from currentTier. With no
+// tier above, progress is 100.
+func nextTierProgress(tiers []*LoyaltyTier, currentTier *LoyaltyTier, tierPoints int) (*LoyaltyTier, int, float64) {
+	for _, t := range sortedTiers(tiers) {
+		if t.MinPoints > tierPoints {
+			progress := 100.0
+			if currentTier != nil {
+				progress = tierProgressPercent(tierPoints, currentTier.MinPoints, t.MinPoints)
+			}
+			return t, t.MinPoints - tierPoints, progress
+		}
+	}
+	return nil, 0, 100
+}
+
+// sortedTiers returns a copy of tiers in ascending min_points order, so callers
+// don't depend on the order the repository returns them in
+func sortedTiers(tiers []*LoyaltyTier) []*LoyaltyTier {
+	sorted := make([]*LoyaltyTier, len(tiers))
+	copy(sorted, tiers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MinPoints < sorted[j].MinPoints
+	})
+	return sorted
+}
+
+// tierProgressPercent returns how far tierPoints is from currentMin towards
+// nextMin, clamped to 0-100. A next tier that starts no higher than the current
+// one (misconfigured tiers) counts as reached.
+func tierProgressPercent(tierPoints, currentMin, nextMin int) float64 {
+	span := nextMin - currentMin
+	if span <= 0 {
+		return 100
+	}
+	progress := float64(tierPoints-currentMin) / float64(span) * 100
+	return math.Max(0, math.Min(100, progress))
+}
+
+// calendarDay returns t's calendar date in its own location as a UTC midnight
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// tierMultiplier returns the points multiplier of the account's current tier
+func tierMultiplier(account *RiderLoyalty) float64 {
+	if account.CurrentTier != nil {
+		return account.CurrentTier.Multiplier
+	}
+	return 1.0
+}
+
+// applyMultiplier scales base points by a multiplier using the configured rounding mode
+func (s *Service) applyMultiplier(points int, multiplier float64) int {
+	return s.roundPoints(float64(points) * multiplier)
+}
+
+// roundPoints resolves fractional points with the configured rounding mode
+func (s *Service) roundPoints(raw float64) int {
+	switch s.config.RoundingMode {
+	case RoundingFloor:
+		return int(math.Floor(raw))
+	case RoundingRound:
+		return int(math.Round(raw))
+	case RoundingBanker:
+		return int(math.RoundToEven(raw))
+	default: // RoundingTruncate
+		return int(raw)
+	}
+}
@@ -0,0 +1,135 @@
+package loyalty
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// TierComparison sets a rider's current tier beside the next one, for nudging
+// them towards an upgrade
+type TierComparison struct {
+	RiderID          uuid.UUID        `json:"rider_id"`
+	CurrentTier      *LoyaltyTier     `json:"current_tier"`
+	NextTier         *LoyaltyTier     `json:"next_tier,omitempty"`
+	AtMaxTier        bool             `json:"at_max_tier"` // No tier is above the current one
+	PointsToNextTier int              `json:"points_to_next_tier"`
+	TierProgress     float64          `json:"tier_progress_percent"`
+	RidesToNextTier  *int             `json:"estimated_rides_to_next_tier,omitempty"` // Set when an earn rate is given
+	Differences      *TierDifferences `json:"differences,omitempty"`
+}
+
+// TierDifferences is what moving up to the next tier changes
+type TierDifferences struct {
+	Multiplier        FloatChange `json:"multiplier"`
+	DiscountPercent   FloatChange `json:"discount_percent"`
+	FreeCancellations IntChange   `json:"free_cancellations"`
+	FreeUpgrades      IntChange   `json:"free_upgrades"`
+	BenefitsGained    []string    `json:"benefits_gained"`
+	BenefitsLost      []string    `json:"benefits_lost"`
+}
+
+// FloatChange is a value in the current and next tier
+type FloatChange struct {
+	Current float64 `json:"current"`
+	Next    float64 `json:"next"`
+	Delta   float64 `json:"delta"`
+}
+
+// IntChange is a count in the current and next tier
+type IntChange struct {
+	Current int `json:"current"`
+	Next    int `json:"next"`
+	Delta   int `json:"delta"`
+}
+
+// GetTierComparison compares the rider's tier with the next one up. Given the
+// points the rider earns on an average ride, it also estimates how many rides
+// it takes to get there; with zero the estimate is left out. A rider without a
+// tier is compared as if on a tier with no benefits.
+func (s *Service) GetTierComparison(ctx context.Context, riderID uuid.UUID, avgPointsPerRide float64) (*TierComparison, error) {
+	if avgPointsPerRide < 0 || math.IsNaN(avgPointsPerRide) || math.IsInf(avgPointsPerRide, 0) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeValidation, "average points per ride must not be negative", nil)
+	}
+
+	account, err := s.GetOrCreateLoyaltyAccount(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike the status, a comparison without the tiers would wrongly claim the
+	// rider is at the top, so failing to load them is an error
+	tiers, err := s.repo.GetAllTiers(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get loyalty tiers", err)
+	}
+
+	var currentTier *LoyaltyTier
+	if account.CurrentTierID != nil {
+		for _, tier := range tiers {
+			if tier.ID == *account.CurrentTierID {
+				currentTier = tier
+				break
+			}
+		}
+	}
+	nextTier, pointsToNext, progress := nextTierProgress(tiers, currentTier, account.TierPoints)
+
+	comparison := &TierComparison{
+		RiderID:          riderID,
+		CurrentTier:      currentTier,
+		NextTier:         nextTier,
+		AtMaxTier:        nextTier == nil,
+		PointsToNextTier: pointsToNext,
+		TierProgress:     progress,
+	}
+	if nextTier == nil {
+		return comparison, nil
+	}
+
+	comparison.Differences = compareTiers(currentTier, nextTier)
+	if avgPointsPerRide > 0 {
+		rides := int(math.Ceil(float64(pointsToNext) / avgPointsPerRide))
+		comparison.RidesToNextTier = &rides
+	}
+	return comparison, nil
+}
+
+// compareTiers returns what changes going from current to next. A nil current
+// tier has no benefits and a multiplier of 1.
+func compareTiers(current, next *LoyaltyTier) *TierDifferences {
+	from := current
+	if from == nil {
+		from = &LoyaltyTier{Multiplier: 1}
+	}
+
+	return &TierDifferences{
+		Multiplier:        floatChange(from.Multiplier, next.Multiplier),
+		DiscountPercent:   floatChange(from.DiscountPercent, next.DiscountPercent),
+		FreeCancellations: IntChange{Current: from.FreeCancellations, Next: next.FreeCancellations, Delta: next.FreeCancellations - from.FreeCancellations},
+		FreeUpgrades:      IntChange{Current: from.FreeUpgrades, Next: next.FreeUpgrades, Delta: next.FreeUpgrades - from.FreeUpgrades},
+		BenefitsGained:    missingFrom(next.Benefits, from.Benefits),
+		BenefitsLost:      missingFrom(from.Benefits, next.Benefits),
+	}
+}
+
+func floatChange(current, next float64) FloatChange {
+	return FloatChange{Current: current, Next: next, Delta: math.Round((next-current)*100) / 100}
+}
+
+// missingFrom returns the benefits in list that other doesn't have, in list's order
+func missingFrom(list, other []string) []string {
+	have := make(map[string]bool, len(other))
+	for _, benefit := range other {
+		have[benefit] = true
+	}
+	missing := make([]string, 0)
+	for _, benefit := range list {
+		if !have[benefit] {
+			missing = append(missing, benefit)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,240 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultAttachmentMaxBytes is the largest chat attachment accepted by default
+	DefaultAttachmentMaxBytes = 5 << 20
+	// DefaultAttachmentUploadURLTTL is how long a presigned attachment upload URL stays valid
+	DefaultAttachmentUploadURLTTL = 15 * time.Minute
+	// DefaultAttachmentDownloadURLTTL is how long a presigned attachment download URL stays valid
+	DefaultAttachmentDownloadURLTTL = 15 * time.Minute
+
+	// pendingAttachmentTTL is how long an issued upload can be referenced by a chat message
+	pendingAttachmentTTL = 1 * time.Hour
+)
+
+// attachmentExtensions are the allowed chat attachment types and the extension
+// their storage keys get
+var attachmentExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// AttachmentPolicy limits the files that can be attached to chat messages
+type AttachmentPolicy struct {
+	MaxBytes       int64
+	UploadURLTTL   time.Duration
+	DownloadURLTTL time.Duration
+}
+
+// DefaultAttachmentPolicy allows images up to DefaultAttachmentMaxBytes
+func DefaultAttachmentPolicy() AttachmentPolicy {
+	return AttachmentPolicy{
+		MaxBytes:       DefaultAttachmentMaxBytes,
+		UploadURLTTL:   DefaultAttachmentUploadURLTTL,
+		DownloadURLTTL: DefaultAttachmentDownloadURLTTL,
+	}
+}
+
+// ChatAttachment is a file sent with a chat message. DownloadURL is filled in
+// when the message is delivered or read from history, never persisted.
+type ChatAttachment struct {
+	FileKey              string     `json:"file_key"`
+	ContentType          string     `json:"content_type"`
+	SizeBytes            int64      `json:"size_bytes"`
+	DownloadURL          string     `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+}
+
+// AttachmentUpload is where a client uploads a chat attachment before sending a
+// chat_message that references FileKey
+type AttachmentUpload struct {
+	FileKey string                      `json:"file_key"`
+	Upload  *storage.PresignedURLResult `json:"upload"`
+}
+
+// pendingAttachment records who an upload was issued to, so a message can only
+// reference attachments its sender uploaded for that ride
+type pendingAttachment struct {
+	SenderID    string `json:"sender_id"`
+	RideID      string `json:"ride_id"`
+	ContentType string `json:"content_type"`
+}
+
+func pendingAttachmentKey(fileKey string) string {
+	return "ride:chat:attachment:" + fileKey
+}
+
+// SetAttachmentStorage enables image attachments on chat messages, stored in
+// store. Without it, messages with attachments are rejected.
+func (s *Service) SetAttachmentStorage(store storage.Storage, policy AttachmentPolicy) {
+	if policy.MaxBytes <= 0 {
+		policy.MaxBytes = DefaultAttachmentMaxBytes
+	}
+	if policy.UploadURLTTL <= 0 {
+		policy.UploadURLTTL = DefaultAttachmentUploadURLTTL
+	}
+	if policy.DownloadURLTTL <= 0 {
+		policy.DownloadURLTTL = DefaultAttachmentDownloadURLTTL
+	}
+	s.attachments = store
+	s.attachmentPolicy = policy
+}
+
+// RequestAttachmentUpload issues a presigned URL the sender uploads a chat
+// attachment to directly. The declared type and size are checked here and the
+// uploaded file is checked again when a message references it.
+func (s *Service) RequestAttachmentUpload(ctx context.Context, rideID, senderID, contentType string, sizeBytes int64) (*AttachmentUpload, error) {
+	if s.attachments == nil {
+		return nil, common.NewServiceUnavailableError("chat attachments are not available")
+	}
+
+	ext, ok := attachmentExtensions[contentType]
+	if !ok {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeUnsupportedFileType,
+			fmt.Sprintf("unsupported attachment type %q", contentType), nil)
+	}
+	if sizeBytes <= 0 || sizeBytes > s.attachmentPolicy.MaxBytes {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeFileTooLarge,
+			fmt.Sprintf("attachments must be between 1 and %d bytes", s.attachmentPolicy.MaxBytes), nil)
+	}
+
+	fileKey := path.Join("chat", rideID, senderID, uuid.NewString()+ext)
+	upload, err := s.attachments.GetPresignedUploadURL(ctx, fileKey, contentType, s.attachmentPolicy.UploadURLTTL)
+	if err != nil {
+		return nil, common.NewInternalError("failed to create attachment upload", err)
+	}
+
+	pending, _ := json.Marshal(pendingAttachment{SenderID: senderID, RideID: rideID, ContentType: contentType})
+	if err := s.redis.SetWithExpiration(ctx, pendingAttachmentKey(fileKey), string(pending), pendingAttachmentTTL); err != nil {
+		return nil, common.NewInternalError("failed to record attachment upload", err)
+	}
+
+	return &AttachmentUpload{FileKey: fileKey, Upload: upload}, nil
+}
+
+// resolveAttachment checks that the sender uploaded fileKey for this ride and
+// that the stored file is an allowed image within the size limit. A file that
+// fails the checks is deleted.
+func (s *Service) resolveAttachment(ctx context.Context, rideID, senderID, fileKey string) (*ChatAttachment, error) {
+	if s.attachments == nil {
+		return nil, errors.New("chat attachments are not available")
+	}
+
+	raw, err := s.redis.GetString(ctx, pendingAttachmentKey(fileKey))
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			return nil, fmt.Errorf("failed to look up attachment: %w", err)
+		}
+		return nil, errors.New("unknown attachment")
+	}
+	var pending pendingAttachment
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil || pending.SenderID != senderID || pending.RideID != rideID {
+		return nil, errors.New("unknown attachment")
+	}
+
+	exists, err := s.attachments.Exists(ctx, fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check attachment: %w", err)
+	}
+	if !exists {
+		return nil, errors.New("attachment has not been uploaded")
+	}
+
+	contentType, size, err := s.inspectAttachment(ctx, fileKey)
+	if err != nil {
+		s.discardAttachment(ctx, fileKey)
+		return nil, err
+	}
+
+	// An attachment is sent once; its upload can't be referenced again
+	s.redis.Delete(ctx, pendingAttachmentKey(fileKey))
+
+	return &ChatAttachment{FileKey: fileKey, ContentType: contentType, SizeBytes: size}, nil
+}
+
+// inspectAttachment reads at most one byte past the size limit of a stored file
+// and returns its sniffed content type and size
+func (s *Service) inspectAttachment(ctx context.Context, fileKey string) (string, int64, error) {
+	reader, err := s.attachments.Download(ctx, fileKey)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	size, err := io.Copy(&buf, io.LimitReader(reader, s.attachmentPolicy.MaxBytes+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if size == 0 {
+		return "", 0, errors.New("attachment is empty")
+	}
+	if size > s.attachmentPolicy.MaxBytes {
+		return "", 0, fmt.Errorf("attachment exceeds %d bytes", s.attachmentPolicy.MaxBytes)
+	}
+
+	contentType := http.DetectContentType(buf.Bytes())
+	if _, ok := attachmentExtensions[contentType]; !ok {
+		return "", 0, fmt.Errorf("unsupported attachment type %q", contentType)
+	}
+	return contentType, size, nil
+}
+
+// discardAttachment removes a rejected upload so it can't be retried or linger in storage
+func (s *Service) discardAttachment(ctx context.Context, fileKey string) {
+	s.redis.Delete(ctx, pendingAttachmentKey(fileKey))
+	if err := s.attachments.Delete(ctx, fileKey); err != nil {
+		s.logger.Warn("failed to delete rejected chat attachment", zap.String("file_key", fileKey), zap.Error(err))
+	}
+}
+
+// withDownloadURL returns a copy of the attachment with a fresh presigned download URL
+func (s *Service) withDownloadURL(ctx context.Context, attachment ChatAttachment) ChatAttachment {
+	if s.attachments == nil {
+		return attachment
+	}
+	presigned, err := s.attachments.GetPresignedDownloadURL(ctx, attachment.FileKey, s.attachmentPolicy.DownloadURLTTL)
+	if err != nil {
+		s.logger.Warn("failed to presign chat attachment", zap.String("file_key", attachment.FileKey), zap.Error(err))
+		return attachment
+	}
+	attachment.DownloadURL = presigned.URL
+	attachment.DownloadURLExpiresAt = &presigned.ExpiresAt
+	return attachment
+}
+
+// recordAttachment returns the attachment of a persisted chat record with a
+// download URL, or nil if the message had none
+func (s *Service) recordAttachment(ctx context.Context, record map[string]interface{}) *ChatAttachment {
+	raw, ok := record["attachment"]
+	if !ok || raw == nil {
+		return nil
+	}
+	data, _ := json.Marshal(raw)
+	var attachment ChatAttachment
+	if err := json.Unmarshal(data, &attachment); err != nil || strings.TrimSpace(attachment.FileKey) == "" {
+		return nil
+	}
+	withURL := s.withDownloadURL(ctx, attachment)
+	return &withURL
+}
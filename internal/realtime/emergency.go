@@ -0,0 +1,370 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// emergencyMessageType is the inbound message a rider or driver sends to raise an SOS
+	emergencyMessageType = "emergency"
+	// activeEmergenciesKey is the Redis hash of unresolved emergencies by ID. It
+	// has no expiry: an emergency stays until a dispatcher resolves it.
+	activeEmergenciesKey = "emergencies:active"
+	// DefaultEmergencyCooldown is how long after raising an emergency the same
+	// sender's further SOS messages for the ride are treated as repeats of it
+	DefaultEmergencyCooldown = 30 * time.Second
+)
+
+// Counted as each emergency is raised, before it is persisted or delivered
+var sosEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "realtime_sos_events_total",
+	Help: "Total number of SOS events raised by riders and drivers on active rides",
+}, []string{"role"})
+
+// EmergencyLocation is where someone was when an emergency was raised
+type EmergencyLocation struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Emergency is an SOS raised by a rider or driver on an active ride
+type Emergency struct {
+	ID               string             `json:"id"`
+	RideID           string             `json:"ride_id"`
+	SenderID         string             `json:"sender_id"`
+	SenderRole       string             `json:"sender_role"`
+	DriverID         string             `json:"driver_id,omitempty"`
+	Message          string             `json:"message,omitempty"`
+	Location         *EmergencyLocation `json:"location,omitempty"`          // Driver's last known location
+	ReportedLocation *EmergencyLocation `json:"reported_location,omitempty"` // Where the sender's device said it was
+	RaisedAt         time.Time          `json:"raised_at"`
+}
+
+// emergencyCooldown remembers the last emergency each sender raised on a ride.
+// Emergencies skip the inbound rate limit, so this is what stops a sender
+// pressing SOS repeatedly from flooding the dispatchers.
+type emergencyCooldown struct {
+	mu     sync.Mutex
+	period time.Duration
+	raised map[string]raisedEmergency
+}
+
+// raisedEmergency is the last emergency a sender raised on a ride
+type raisedEmergency struct {
+	id string
+	at time.Time
+}
+
+func newEmergencyCooldown(period time.Duration) *emergencyCooldown {
+	return &emergencyCooldown{period: period, raised: make(map[string]raisedEmergency)}
+}
+
+// claim records a new emergency from a sender on a ride. If the sender raised
+// one within the cooldown it returns that emergency's ID and false instead.
+func (c *emergencyCooldown) claim(senderID, rideID, emergencyID string, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := senderID + ":" + rideID
+	if last, ok := c.raised[key]; ok && now.Sub(last.at) < c.period {
+		return last.id, false
+	}
+	for k, last := range c.raised {
+		if now.Sub(last.at) >= c.period {
+			delete(c.raised, k)
+		}
+	}
+	c.raised[key] = raisedEmergency{id: emergencyID, at: now}
+	return emergencyID, true
+}
+
+// handleEmergency raises an SOS from a rider or driver on an active ride. The
+// ride and the driver's last known location are attached, the emergency is
+// stored whatever the persistence policy, and it goes to every dispatcher on
+// their priority queue. Emergencies skip the inbound rate limit; a repeat from
+// the same sender within the cooldown is acknowledged with the emergency
+// already raised and not sent on again.
+func (s *Service) handleEmergency(client *ws.Client, msg *ws.Message) {
+	if client.Role != ws.RoleRider && client.Role != ws.RoleDriver {
+		s.logger.Warn("emergency from client that is neither rider nor driver", zap.String("client_id", client.ID))
+		return
+	}
+
+	rideID := client.GetRide()
+	if rideID == "" {
+		rideID = msg.RideID
+	}
+
+	ctx := context.Background()
+	driverID, err := s.activeRideDriver(ctx, rideID, client.ID)
+	if err != nil {
+		s.logger.Warn("emergency raised outside an active ride",
+			zap.String("client_id", client.ID),
+			zap.String("ride_id", rideID),
+			zap.Error(err),
+		)
+		client.SendPriority(&ws.Message{
+			Type:      "error",
+			RideID:    rideID,
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"message": "Emergency alerts can only be raised on an active ride",
+			},
+		})
+		return
+	}
+
+	emergency := &Emergency{
+		ID:         uuid.NewString(),
+		RideID:     rideID,
+		SenderID:   client.ID,
+		SenderRole: client.Role,
+		DriverID:   driverID,
+		RaisedAt:   time.Now(),
+	}
+	if raisedID, ok := s.emergencyCooldown.claim(client.ID, rideID, emergency.ID, emergency.RaisedAt); !ok {
+		client.SendPriority(&ws.Message{
+			Type:      "emergency_received",
+			RideID:    rideID,
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"emergency_id": raisedID,
+				"duplicate":    true,
+			},
+		})
+		return
+	}
+	emergency.Message, _ = msg.Data["message"].(string)
+	if latitude, ok := msg.Data["latitude"].(float64); ok {
+		if longitude, ok := msg.Data["longitude"].(float64); ok {
+			emergency.ReportedLocation = &EmergencyLocation{Latitude: latitude, Longitude: longitude, Timestamp: emergency.RaisedAt}
+		}
+	}
+	emergency.Location = s.lastKnownLocation(ctx, driverID)
+
+	sosEvents.WithLabelValues(client.Role).Inc()
+	s.storeEmergency(ctx, emergency)
+
+	notified := s.hub.SendToDispatchers(&ws.Message{
+		Type:      "emergency_alert",
+		RideID:    rideID,
+		UserID:    client.ID,
+		Timestamp: emergency.RaisedAt,
+		Data:      emergencyData(emergency),
+	})
+
+	s.logger.Warn("emergency raised",
+		zap.String("emergency_id", emergency.ID),
+		zap.String("ride_id", rideID),
+		zap.String("sender_id", client.ID),
+		zap.String("sender_role", client.Role),
+		zap.Int("dispatchers_notified", notified),
+	)
+
+	client.SendPriority(&ws.Message{
+		Type:      "emergency_received",
+		RideID:    rideID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"emergency_id":         emergency.ID,
+			"dispatchers_notified": notified,
+		},
+	})
+}
+
+// activeRideDriver checks that userID takes part in rideID and the ride is
+// active, returning the ride's driver ID, empty until a driver accepts
+func (s *Service) activeRideDriver(ctx context.Context, rideID, userID string) (string, error) {
+	if rideID == "" {
+		return "", errors.New("not in a ride")
+	}
+	if s.db == nil {
+		return "", errors.New("ride lookup unavailable")
+	}
+
+	var driverID sql.NullString
+	query := `
+		SELECT driver_id FROM rides
+		WHERE id = $1 AND (rider_id = $2 OR driver_id = $2)
+		  AND status IN ('requested', 'accepted', 'in_progress')
+	`
+	if err := s.db.QueryRowContext(ctx, query, rideID, userID).Scan(&driverID); err != nil {
+		return "", err
+	}
+	return driverID.String, nil
+}
+
+// lastKnownLocation returns the driver's location as the geo service last
+// stored it, or nil if there is none
+func (s *Service) lastKnownLocation(ctx context.Context, driverID string) *EmergencyLocation {
+	if s.geoService == nil || driverID == "" {
+		return nil
+	}
+	id, err := uuid.Parse(driverID)
+	if err != nil {
+		return nil
+	}
+	location, err := s.geoService.GetDriverLocation(ctx, id)
+	if err != nil {
+		s.logger.Warn("no driver location for emergency", zap.String("driver_id", driverID), zap.Error(err))
+		return nil
+	}
+	return &EmergencyLocation{Latitude: location.Latitude, Longitude: location.Longitude, Timestamp: location.Timestamp}
+}
+
+// storeEmergency records an emergency in the database, the ride's history and
+// among the active emergencies. Failures are logged; the alert still goes out.
+func (s *Service) storeEmergency(ctx context.Context, emergency *Emergency) {
+	if err := s.insertEmergency(ctx, emergency); err != nil {
+		s.logger.Error("failed to persist emergency",
+			zap.String("emergency_id", emergency.ID),
+			zap.String("ride_id", emergency.RideID),
+			zap.Error(err),
+		)
+	}
+
+	record := emergencyData(emergency)
+	record["type"] = emergencyMessageType
+	s.appendHistory(ctx, emergencyMessageType, emergency.RideID, record)
+
+	data, _ := json.Marshal(emergency)
+	if err := s.redis.HSet(ctx, activeEmergenciesKey, emergency.ID, string(data)); err != nil {
+		s.logger.Error("failed to store active emergency",
+			zap.String("emergency_id", emergency.ID),
+			zap.String("ride_id", emergency.RideID),
+			zap.Error(err),
+		)
+	}
+}
+
+// insertEmergency writes an emergency to the ride_emergencies table
+func (s *Service) insertEmergency(ctx context.Context, emergency *Emergency) error {
+	if s.db == nil {
+		return errors.New("database unavailable")
+	}
+
+	var latitude, longitude, reportedLatitude, reportedLongitude sql.NullFloat64
+	var locatedAt sql.NullTime
+	if emergency.Location != nil {
+		latitude = sql.NullFloat64{Float64: emergency.Location.Latitude, Valid: true}
+		longitude = sql.NullFloat64{Float64: emergency.Location.Longitude, Valid: true}
+		locatedAt = sql.NullTime{Time: emergency.Location.Timestamp, Valid: true}
+	}
+	if emergency.ReportedLocation != nil {
+		reportedLatitude = sql.NullFloat64{Float64: emergency.ReportedLocation.Latitude, Valid: true}
+		reportedLongitude = sql.NullFloat64{Float64: emergency.ReportedLocation.Longitude, Valid: true}
+	}
+
+	query := `
+		INSERT INTO ride_emergencies (
+			id, ride_id, sender_id, sender_role, driver_id, message,
+			latitude, longitude, located_at, reported_latitude, reported_longitude, raised_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		emergency.ID, emergency.RideID, emergency.SenderID, emergency.SenderRole,
+		sql.NullString{String: emergency.DriverID, Valid: emergency.DriverID != ""},
+		sql.NullString{String: emergency.Message, Valid: emergency.Message != ""},
+		latitude, longitude, locatedAt, reportedLatitude, reportedLongitude, emergency.RaisedAt,
+	)
+	return err
+}
+
+// emergencyData is an emergency as message data
+func emergencyData(emergency *Emergency) map[string]interface{} {
+	data := map[string]interface{}{
+		"emergency_id": emergency.ID,
+		"ride_id":      emergency.RideID,
+		"sender_id":    emergency.SenderID,
+		"sender_role":  emergency.SenderRole,
+		"raised_at":    emergency.RaisedAt.Unix(),
+	}
+	if emergency.DriverID != "" {
+		data["driver_id"] = emergency.DriverID
+	}
+	if emergency.Message != "" {
+		data["message"] = emergency.Message
+	}
+	if emergency.Location != nil {
+		data["location"] = emergency.Location
+	}
+	if emergency.ReportedLocation != nil {
+		data["reported_location"] = emergency.ReportedLocation
+	}
+	return data
+}
+
+// GetActiveEmergencies lists the emergencies no dispatcher has resolved yet,
+// oldest first
+func (s *Service) GetActiveEmergencies(ctx context.Context) ([]*Emergency, error) {
+	entries, err := s.redis.HGetAll(ctx, activeEmergenciesKey)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get active emergencies", err)
+	}
+
+	emergencies := make([]*Emergency, 0, len(entries))
+	for id, entry := range entries {
+		var emergency Emergency
+		if err := json.Unmarshal([]byte(entry), &emergency); err != nil {
+			s.logger.Warn("skipping unreadable emergency", zap.String("emergency_id", id), zap.Error(err))
+			continue
+		}
+		emergencies = append(emergencies, &emergency)
+	}
+
+	sort.Slice(emergencies, func(i, j int) bool {
+		return emergencies[i].RaisedAt.Before(emergencies[j].RaisedAt)
+	})
+	return emergencies, nil
+}
+
+// ResolveEmergency records who resolved an emergency, removes it from the
+// active list and tells the dispatchers it has been dealt with
+func (s *Service) ResolveEmergency(ctx context.Context, emergencyID, resolvedBy string) error {
+	if _, err := s.redis.HGet(ctx, activeEmergenciesKey, emergencyID); err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return common.NewNotFoundError("emergency not found", err)
+		}
+		return common.NewInternalError("failed to get emergency", err)
+	}
+
+	resolvedAt := time.Now()
+	if s.db != nil {
+		query := `
+			UPDATE ride_emergencies SET resolved_at = $2, resolved_by = $3
+			WHERE id = $1 AND resolved_at IS NULL
+		`
+		if _, err := s.db.ExecContext(ctx, query, emergencyID, resolvedAt, resolvedBy); err != nil {
+			return common.NewInternalError("failed to record emergency resolution", err)
+		}
+	}
+	if err := s.redis.HDel(ctx, activeEmergenciesKey, emergencyID); err != nil {
+		return common.NewInternalError("failed to resolve emergency", err)
+	}
+
+	s.hub.SendToDispatchers(&ws.Message{
+		Type:      "emergency_resolved",
+		Timestamp: resolvedAt,
+		Data: map[string]interface{}{
+			"emergency_id": emergencyID,
+			"resolved_by":  resolvedBy,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,258 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/geo"
+	"github.com/richxcame/ride-hailing/internal/maps"
+	realtimeapi "github.com/richxcame/ride-hailing/pkg/realtime"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultETAMinChange is how much a driver's ETA must change before a new
+	// ETA is broadcast to the ride
+	DefaultETAMinChange = 30 * time.Second
+	// DefaultETAAverageSpeedKmh is the speed a straight-line ETA assumes when the
+	// driver reports no useful speed
+	DefaultETAAverageSpeedKmh = 30.0
+
+	// minReportedSpeedKmh is the slowest reported speed trusted for an ETA; below
+	// it the driver is stopped or crawling and the average speed is used
+	minReportedSpeedKmh = 5.0
+
+	etaDestinationPickup  = "pickup"
+	etaDestinationDropoff = "dropoff"
+)
+
+// ETAEstimate is how long a driver needs to reach a point, and how far it is
+type ETAEstimate struct {
+	Duration   time.Duration
+	DistanceKm float64
+}
+
+// ETAEstimator estimates a driver's time to a destination from their position
+// and reported speed in km/h
+type ETAEstimator interface {
+	EstimateETA(ctx context.Context, latitude, longitude, destLatitude, destLongitude, speedKmh float64) (*ETAEstimate, error)
+}
+
+// StraightLineETAEstimator estimates ETAs from the straight-line distance, at the
+// driver's reported speed or, when they are barely moving, an average speed
+type StraightLineETAEstimator struct {
+	AverageSpeedKmh float64
+}
+
+// EstimateETA implements ETAEstimator
+func (e StraightLineETAEstimator) EstimateETA(ctx context.Context, latitude, longitude, destLatitude, destLongitude, speedKmh float64) (*ETAEstimate, error) {
+	distanceKm := haversineMeters(latitude, longitude, destLatitude, destLongitude) / 1000
+
+	if speedKmh <= minReportedSpeedKmh {
+		speedKmh = e.AverageSpeedKmh
+		if speedKmh <= 0 {
+			speedKmh = DefaultETAAverageSpeedKmh
+		}
+	}
+
+	hours := distanceKm / speedKmh
+	return &ETAEstimate{
+		Duration:   time.Duration(hours * float64(time.Hour)),
+		DistanceKm: distanceKm,
+	}, nil
+}
+
+// RoutingETAEstimator estimates ETAs along the road network with a maps
+// provider, falling back to a straight-line estimate when the provider fails
+type RoutingETAEstimator struct {
+	provider maps.ETAProvider
+	fallback ETAEstimator
+}
+
+// NewRoutingETAEstimator creates an estimator backed by a maps ETA provider
+func NewRoutingETAEstimator(provider maps.ETAProvider) *RoutingETAEstimator {
+	return &RoutingETAEstimator{
+		provider: provider,
+		fallback: StraightLineETAEstimator{AverageSpeedKmh: DefaultETAAverageSpeedKmh},
+	}
+}
+
+// EstimateETA implements ETAEstimator
+func (e *RoutingETAEstimator) EstimateETA(ctx context.Context, latitude, longitude, destLatitude, destLongitude, speedKmh float64) (*ETAEstimate, error) {
+	result, err := e.provider.GetETA(ctx, latitude, longitude, destLatitude, destLongitude)
+	if err != nil || result == nil {
+		return e.fallback.EstimateETA(ctx, latitude, longitude, destLatitude, destLongitude, speedKmh)
+	}
+	return &ETAEstimate{
+		Duration:   time.Duration(result.DurationSeconds) * time.Second,
+		DistanceKm: result.DistanceKm,
+	}, nil
+}
+
+// etaFilter suppresses ETA broadcasts that barely differ from the last one sent
+// for a driver. A new ride or destination is always broadcast.
+type etaFilter struct {
+	mu        sync.Mutex
+	minChange time.Duration
+	last      map[string]sentETA
+}
+
+// sentETA is the last ETA broadcast for a driver
+type sentETA struct {
+	rideID      string
+	destination string
+	eta         time.Duration
+}
+
+func newETAFilter(minChange time.Duration) *etaFilter {
+	return &etaFilter{minChange: minChange, last: make(map[string]sentETA)}
+}
+
+// shouldBroadcast reports whether an ETA should be sent and, if so, records it
+func (f *etaFilter) shouldBroadcast(driverID string, eta sentETA) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prev, ok := f.last[driverID]
+	if ok && prev.rideID == eta.rideID && prev.destination == eta.destination {
+		change := eta.eta - prev.eta
+		if change < 0 {
+			change = -change
+		}
+		if change < f.minChange {
+			return false
+		}
+	}
+
+	f.last[driverID] = eta
+	return true
+}
+
+// forget drops the last ETA sent for a driver
+func (f *etaFilter) forget(driverID string) {
+	f.mu.Lock()
+	delete(f.last, driverID)
+	f.mu.Unlock()
+}
+
+// SetETAEstimator replaces how driver ETAs are estimated
+func (s *Service) SetETAEstimator(estimator ETAEstimator) {
+	s.etaEstimator = estimator
+}
+
+// SetETAMinChange sets how much a driver's ETA must change before it is
+// broadcast again. Zero broadcasts every ETA.
+func (s *Service) SetETAMinChange(minChange time.Duration) {
+	s.etaFilter = newETAFilter(minChange)
+}
+
+// UpdateDriverETA recomputes a driver's ETA on their ride from a location update:
+// to the pickup until the ride starts, then to the dropoff. The ETA is stored
+// with the driver's location and broadcast to the ride as an eta_update when it
+// moved by at least the minimum change. Once the ride is over the stored ETA is
+// cleared.
+func (s *Service) UpdateDriverETA(ctx context.Context, driverID, rideID string, latitude, longitude, speed float64) {
+	destination, destLatitude, destLongitude, err := s.rideDestination(ctx, driverID, rideID)
+	if err != nil {
+		s.logger.Warn("failed to look up ride destination for ETA", zap.String("ride_id", rideID), zap.Error(err))
+		return
+	}
+	if destination == "" {
+		s.etaFilter.forget(driverID)
+		s.clearDriverETA(ctx, driverID)
+		return
+	}
+
+	estimate, err := s.etaEstimator.EstimateETA(ctx, latitude, longitude, destLatitude, destLongitude, speed)
+	if err != nil {
+		s.logger.Warn("failed to estimate driver ETA", zap.String("ride_id", rideID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	eta := &geo.DriverETA{
+		RideID:      rideID,
+		Destination: destination,
+		ETASeconds:  int(math.Ceil(estimate.Duration.Seconds())),
+		DistanceKm:  math.Round(estimate.DistanceKm*100) / 100,
+		UpdatedAt:   now,
+	}
+	s.saveDriverETA(ctx, driverID, eta)
+
+	if !s.etaFilter.shouldBroadcast(driverID, sentETA{rideID: rideID, destination: destination, eta: estimate.Duration}) {
+		return
+	}
+
+	s.BroadcastRideEvent(rideID, realtimeapi.EventETAUpdate, map[string]interface{}{
+		"ride_id":     rideID,
+		"driver_id":   driverID,
+		"destination": destination,
+		"eta_seconds": eta.ETASeconds,
+		"eta_minutes": int(math.Ceil(estimate.Duration.Minutes())),
+		"distance_km": eta.DistanceKm,
+		"updated_at":  now.Unix(),
+	})
+}
+
+// rideDestination returns where the driver on a ride is headed, or an empty
+// destination if the ride isn't theirs or is no longer active
+func (s *Service) rideDestination(ctx context.Context, driverID, rideID string) (string, float64, float64, error) {
+	var status string
+	var pickupLatitude, pickupLongitude, dropoffLatitude, dropoffLongitude float64
+	query := `
+		SELECT status, pickup_latitude, pickup_longitude, dropoff_latitude, dropoff_longitude
+		FROM rides
+		WHERE id = $1 AND driver_id = $2
+	`
+	err := s.db.QueryRowContext(ctx, query, rideID, driverID).Scan(
+		&status, &pickupLatitude, &pickupLongitude, &dropoffLatitude, &dropoffLongitude,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, 0, nil
+	}
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	switch status {
+	case "accepted":
+		return etaDestinationPickup, pickupLatitude, pickupLongitude, nil
+	case "in_progress":
+		return etaDestinationDropoff, dropoffLatitude, dropoffLongitude, nil
+	default:
+		return "", 0, 0, nil
+	}
+}
+
+// saveDriverETA stores the ETA with the driver's location in the geo service
+func (s *Service) saveDriverETA(ctx context.Context, driverID string, eta *geo.DriverETA) {
+	if s.geoService == nil {
+		return
+	}
+	id, err := uuid.Parse(driverID)
+	if err != nil {
+		return
+	}
+	if err := s.geoService.SaveDriverETA(ctx, id, eta); err != nil {
+		s.logger.Warn("failed to store driver ETA", zap.String("driver_id", driverID), zap.Error(err))
+	}
+}
+
+// clearDriverETA removes the driver's stored ETA
+func (s *Service) clearDriverETA(ctx context.Context, driverID string) {
+	if s.geoService == nil {
+		return
+	}
+	id, err := uuid.Parse(driverID)
+	if err != nil {
+		return
+	}
+	if err := s.geoService.ClearDriverETA(ctx, id); err != nil {
+		s.logger.Warn("failed to clear driver ETA", zap.String("driver_id", driverID), zap.Error(err))
+	}
+}
@@ -9,6 +9,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/health"
+	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	realtimeapi "github.com/richxcame/ride-hailing/pkg/realtime"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
 	"go.uber.org/zap"
 )
@@ -47,6 +52,8 @@ var upgrader = websocket.Upgrader{
 type Handler struct {
 	service *Service
 	logger  *zap.Logger
+
+	healthChecker *health.HealthChecker
 }
 
 // NewHandler creates a new handler
@@ -75,24 +82,40 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	// Auth middleware stores user_id as uuid.UUID and user_role as models.UserRole.
 	userIDStr := fmt.Sprintf("%v", userID)
 
-	roleStr := fmt.Sprintf("%v", role)
+	roleStr := ws.ClientRole(models.UserRole(fmt.Sprintf("%v", role)))
+
+	// Refuse new connections while the hub drains for shutdown
+	hub := h.service.GetHub()
+	if hub.ShuttingDown() {
+		common.ErrorResponse(c, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+
+	// Cap concurrent connections per user
+	if !hub.AcquireConnection(userIDStr) {
+		common.ErrorResponse(c, http.StatusTooManyRequests, "Too many connections")
+		return
+	}
 
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
+		hub.ReleaseConnection(userIDStr)
 		h.logger.Error("failed to upgrade connection", zap.Error(err))
 		return
 	}
 
 	// Create new WebSocket client
-	client := ws.NewClient(userIDStr, conn, h.service.GetHub(), roleStr, h.logger)
+	client := ws.NewClient(userIDStr, conn, hub, roleStr, h.logger)
 
 	// Register client with hub
-	h.service.GetHub().Register <- client
+	hub.Register <- client
+
+	// Replay messages sent while the user was disconnected
+	h.service.DeliverPendingMessages(client)
 
 	// Start client goroutines
-	go client.WritePump()
-	go client.ReadPump()
+	hub.StartPumps(client, func() { hub.ReleaseConnection(userIDStr) })
 
 	h.logger.Info("WebSocket connection established", zap.Any("user_id", userID), zap.Any("role", role))
 }
@@ -113,13 +136,7 @@ func (h *Handler) GetChatHistory(c *gin.Context) {
 	}
 
 	// Verify user is part of this ride
-	var count int
-	query := `
-		SELECT COUNT(*) FROM rides
-		WHERE id = $1 AND (rider_id = $2 OR driver_id = $2)
-	`
-	err := h.service.db.QueryRow(query, rideID, userID).Scan(&count)
-	if err != nil || count == 0 {
+	if !h.service.isRideParticipant(rideID, fmt.Sprint(userID)) {
 		common.ErrorResponse(c, http.StatusForbidden, "Not authorized for this ride")
 		return
 	}
@@ -137,31 +154,131 @@ func (h *Handler) GetChatHistory(c *gin.Context) {
 	})
 }
 
-// BroadcastRideUpdate broadcasts a ride update (called by other services)
-func (h *Handler) BroadcastRideUpdate(c *gin.Context) {
+// RequestChatAttachmentUpload issues a presigned URL for uploading an image to
+// attach to a chat message
+func (h *Handler) RequestChatAttachmentUpload(c *gin.Context) {
+	rideID := c.Param("ride_id")
+	if rideID == "" {
+		common.ErrorResponse(c, http.StatusBadRequest, "ride_id is required")
+		return
+	}
+
+	// Extract user ID from JWT token
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	var req struct {
-		RideID string                 `json:"ride_id" binding:"required"`
-		Data   map[string]interface{} `json:"data" binding:"required"`
+		ContentType string `json:"content_type" binding:"required"`
+		SizeBytes   int64  `json:"size_bytes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Verify user is part of this ride
+	if !h.service.isRideParticipant(rideID, fmt.Sprint(userID)) {
+		common.ErrorResponse(c, http.StatusForbidden, "Not authorized for this ride")
+		return
+	}
+
+	upload, err := h.service.RequestAttachmentUpload(c.Request.Context(), rideID, fmt.Sprintf("%v", userID), req.ContentType, req.SizeBytes)
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, upload)
+}
+
+// GetRidePresence returns the current presence and typing state of a ride's
+// participants so a late-joining client can sync
+func (h *Handler) GetRidePresence(c *gin.Context) {
+	rideID := c.Param("ride_id")
+	if rideID == "" {
+		common.ErrorResponse(c, http.StatusBadRequest, "ride_id is required")
+		return
 	}
 
+	// Extract user ID from JWT token
+	userID, exists := c.Get("user_id")
+	if !exists {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Verify user is part of this ride
+	if !h.service.isRideParticipant(rideID, fmt.Sprint(userID)) {
+		common.ErrorResponse(c, http.StatusForbidden, "Not authorized for this ride")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"ride_id":      rideID,
+		"participants": h.service.GetRidePresence(rideID),
+	})
+}
+
+// GetRoomMembers lists the clients in a ride's room (admin diagnostics)
+func (h *Handler) GetRoomMembers(c *gin.Context) {
+	rideID := c.Param("ride_id")
+	if rideID == "" {
+		common.ErrorResponse(c, http.StatusBadRequest, "ride_id is required")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"ride_id": rideID,
+		"room":    ws.RideRoom(rideID),
+		"members": h.service.GetRoomMembers(rideID),
+	})
+}
+
+// GetActiveEmergencies lists the unresolved SOS events for the dispatch console
+func (h *Handler) GetActiveEmergencies(c *gin.Context) {
+	emergencies, err := h.service.GetActiveEmergencies(c.Request.Context())
+	if err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"emergencies": emergencies,
+		"count":       len(emergencies),
+		"dispatchers": h.service.hub.GetDispatcherCount(),
+	})
+}
+
+// ResolveEmergency marks an SOS event as dealt with
+func (h *Handler) ResolveEmergency(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if err := h.service.ResolveEmergency(c.Request.Context(), c.Param("id"), fmt.Sprintf("%v", userID)); err != nil {
+		common.RespondError(c, err)
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"message": "Emergency resolved"})
+}
+
+// BroadcastRideUpdate broadcasts a ride update (called by other services)
+func (h *Handler) BroadcastRideUpdate(c *gin.Context) {
+	var req realtimeapi.RideBroadcast
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	h.service.BroadcastRideUpdate(req.RideID, req.Data)
+	h.service.BroadcastRideEvent(req.RideID, req.Type, req.Data)
 
 	common.SuccessResponse(c, gin.H{"message": "Broadcast sent"})
 }
 
 // BroadcastToUser broadcasts a message to a specific user (called by other services)
 func (h *Handler) BroadcastToUser(c *gin.Context) {
-	var req struct {
-		UserID string                 `json:"user_id" binding:"required"`
-		Type   string                 `json:"type" binding:"required"`
-		Data   map[string]interface{} `json:"data" binding:"required"`
-	}
-
+	var req realtimeapi.UserBroadcast
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -178,12 +295,87 @@ func (h *Handler) GetStats(c *gin.Context) {
 	common.SuccessResponse(c, stats)
 }
 
-// HealthCheck returns service health status
+// HealthCheck returns service health status, with the status of each
+// dependency when a health checker is set. It always answers 200; ReadyCheck is
+// the probe that fails.
 func (h *Handler) HealthCheck(c *gin.Context) {
 	stats := h.service.GetStats()
-	common.SuccessResponse(c, gin.H{
+	response := gin.H{
 		"status":  "healthy",
 		"service": "realtime",
 		"stats":   stats,
+	}
+	if h.healthChecker != nil {
+		report := h.healthChecker.Check(c.Request.Context())
+		response["status"] = report.Status
+		response["dependencies"] = report.Dependencies
+	}
+	common.SuccessResponse(c, response)
+}
+
+// SetHealthChecker sets the dependencies HealthCheck and ReadyCheck probe.
+// Without one the service reports itself healthy and ready.
+func (h *Handler) SetHealthChecker(checker *health.HealthChecker) {
+	h.healthChecker = checker
+}
+
+// ReadyCheck reports whether the service can take traffic, answering 503 while
+// a critical dependency is down
+func (h *Handler) ReadyCheck(c *gin.Context) {
+	if h.healthChecker == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy", "ready": true, "service": "realtime"})
+		return
+	}
+
+	report := h.healthChecker.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"status":       report.Status,
+		"ready":        report.Ready,
+		"service":      "realtime",
+		"dependencies": report.Dependencies,
+		"checked_at":   report.CheckedAt,
 	})
 }
+
+// RegisterRoutes registers the real-time API routes. Stats are restricted to
+// admins and the broadcast endpoints to other services holding the internal API key.
+func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider, afterAuth ...gin.HandlerFunc) {
+	api := r.Group("/api/v1")
+	{
+		// Client routes require authentication. afterAuth (e.g. a per-user
+		// rate limiter) runs once the caller is known.
+		authed := api.Group("", append([]gin.HandlerFunc{middleware.AuthMiddlewareWithProvider(jwtProvider)}, afterAuth...)...)
+
+		// WebSocket connection
+		authed.GET("/ws", h.HandleWebSocket)
+
+		// Chat history
+		authed.GET("/rides/:ride_id/chat", h.GetChatHistory)
+		authed.POST("/rides/:ride_id/chat/attachments", h.RequestChatAttachmentUpload)
+
+		// Presence and typing state for late joiners
+		authed.GET("/rides/:ride_id/presence", h.GetRidePresence)
+
+		// Stats (admin only)
+		authed.GET("/stats", middleware.RequireAdmin(), h.GetStats)
+
+		// Ride room membership (admin only)
+		authed.GET("/rides/:ride_id/room", middleware.RequireAdmin(), h.GetRoomMembers)
+
+		// Active SOS events for the dispatch console (admin only)
+		authed.GET("/emergencies", middleware.RequireAdmin(), h.GetActiveEmergencies)
+		authed.POST("/emergencies/:id/resolve", middleware.RequireAdmin(), h.ResolveEmergency)
+
+		// Internal endpoints (for other services to broadcast)
+		internal := api.Group("/internal")
+		internal.Use(middleware.InternalAPIKey())
+		{
+			internal.POST("/broadcast/ride", h.BroadcastRideUpdate)
+			internal.POST("/broadcast/user", h.BroadcastToUser)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -15,7 +16,13 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redismock/v9"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/richxcame/ride-hailing/pkg/health"
+	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/pkg/redis"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
 	"github.com/stretchr/testify/assert"
@@ -173,6 +180,37 @@ func TestHandleWebSocket_Unauthorized_NoUserID(t *testing.T) {
 	assert.Contains(t, response["error"].(map[string]interface{})["message"], "Unauthorized")
 }
 
+func TestHandleWebSocket_TooManyConnections(t *testing.T) {
+	handler, service, _, _ := setupTestHandler(t)
+
+	hub := service.GetHub()
+	hub.SetLimits(ws.Limits{MaxConnectionsPerUser: 1})
+	require.True(t, hub.AcquireConnection("user-123"))
+
+	c, w := setupTestContext("GET", "/api/v1/ws", nil)
+	setUserContext(c, "user-123", "rider")
+
+	handler.HandleWebSocket(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, 1, hub.GetConnectionCount("user-123"))
+}
+
+func TestHandleWebSocket_RejectsWhileShuttingDown(t *testing.T) {
+	handler, service, _, _ := setupTestHandler(t)
+
+	hub := service.GetHub()
+	require.NoError(t, hub.Shutdown(context.Background()))
+
+	c, w := setupTestContext("GET", "/api/v1/ws", nil)
+	setUserContext(c, "user-123", "rider")
+
+	handler.HandleWebSocket(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, 0, hub.GetConnectionCount("user-123"))
+}
+
 func TestHandleWebSocket_DefaultRole(t *testing.T) {
 	handler, service, _, _ := setupTestHandler(t)
 
@@ -772,6 +810,76 @@ func TestHealthCheck_WithConnectedClients(t *testing.T) {
 	assert.GreaterOrEqual(t, stats["connected_clients"].(float64), float64(1))
 }
 
+func newTestHealthChecker(redisErr error) *health.HealthChecker {
+	return health.NewHealthChecker(health.CheckerConfig{Timeout: time.Second},
+		health.Dependency{Name: "database", Critical: true, Check: func(ctx context.Context) error { return nil }},
+		health.Dependency{Name: "redis", Critical: true, Check: func(ctx context.Context) error { return redisErr }},
+	)
+}
+
+func TestHealthCheck_ReportsDependencies(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	handler.SetHealthChecker(newTestHealthChecker(errors.New("connection refused")))
+
+	c, w := setupTestContext("GET", "/healthz", nil)
+
+	handler.HealthCheck(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := parseResponse(w)["data"].(map[string]interface{})
+	assert.Equal(t, "unhealthy", data["status"])
+	deps := data["dependencies"].(map[string]interface{})
+	assert.Equal(t, "healthy", deps["database"].(map[string]interface{})["status"])
+	assert.Equal(t, "unhealthy", deps["redis"].(map[string]interface{})["status"])
+}
+
+func TestReadyCheck_Ready(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	handler.SetHealthChecker(newTestHealthChecker(nil))
+
+	c, w := setupTestContext("GET", "/readyz", nil)
+
+	handler.ReadyCheck(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	assert.Equal(t, "healthy", response["status"])
+	assert.Equal(t, true, response["ready"])
+}
+
+func TestReadyCheck_CriticalDependencyDown(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	handler.SetHealthChecker(newTestHealthChecker(errors.New("connection refused")))
+
+	c, w := setupTestContext("GET", "/readyz", nil)
+
+	handler.ReadyCheck(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	response := parseResponse(w)
+	assert.Equal(t, "unhealthy", response["status"])
+	assert.Equal(t, false, response["ready"])
+	assert.Equal(t, "realtime", response["service"])
+	deps := response["dependencies"].(map[string]interface{})
+	redisDep := deps["redis"].(map[string]interface{})
+	assert.Equal(t, "unhealthy", redisDep["status"])
+	assert.Equal(t, "connection refused", redisDep["error"])
+	assert.Equal(t, true, redisDep["critical"])
+	assert.Contains(t, redisDep, "latency_ms")
+	assert.Equal(t, "healthy", deps["database"].(map[string]interface{})["status"])
+}
+
+func TestReadyCheck_WithoutHealthChecker(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	c, w := setupTestContext("GET", "/readyz", nil)
+
+	handler.ReadyCheck(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, parseResponse(w)["ready"])
+}
+
 // ============================================================================
 // Table-Driven Tests
 // ============================================================================
@@ -1132,7 +1240,7 @@ func TestWebSocket_FullConnection(t *testing.T) {
 
 	// Verify client is registered
 	stats := service.GetStats()
-	assert.GreaterOrEqual(t, stats["connected_clients"].(int), 1)
+	assert.GreaterOrEqual(t, stats.ConnectedClients, 1)
 }
 
 func TestWebSocket_MultipleClients(t *testing.T) {
@@ -1196,7 +1304,7 @@ func TestWebSocket_MultipleClients(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	stats := service.GetStats()
-	assert.GreaterOrEqual(t, stats["connected_clients"].(int), 5)
+	assert.GreaterOrEqual(t, stats.ConnectedClients, 5)
 }
 
 func TestWebSocket_DisconnectCleanup(t *testing.T) {
@@ -1244,14 +1352,14 @@ func TestWebSocket_DisconnectCleanup(t *testing.T) {
 
 	time.Sleep(20 * time.Millisecond)
 
-	initialCount := service.GetStats()["connected_clients"].(int)
+	initialCount := service.GetStats().ConnectedClients
 
 	// Disconnect
 	conn.Close()
 
 	time.Sleep(100 * time.Millisecond)
 
-	finalCount := service.GetStats()["connected_clients"].(int)
+	finalCount := service.GetStats().ConnectedClients
 	assert.Less(t, finalCount, initialCount)
 }
 
@@ -1324,3 +1432,180 @@ func TestConcurrentStats(t *testing.T) {
 
 	// No panics = success
 }
+
+func TestGetRidePresence_Success(t *testing.T) {
+	handler, service, dbMock, _ := setupTestHandler(t)
+
+	rideID := "ride-123"
+	userID := "user-456"
+	service.presence.setStatus(rideID, "driver-789", "driver", PresenceAway)
+
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(1)
+	dbMock.ExpectQuery("SELECT COUNT.*FROM rides").
+		WithArgs(rideID, userID).
+		WillReturnRows(rows)
+
+	c, w := setupTestContext("GET", "/api/v1/rides/"+rideID+"/presence", nil)
+	c.Params = gin.Params{{Key: "ride_id", Value: rideID}}
+	setUserContext(c, userID, "rider")
+
+	handler.GetRidePresence(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := parseResponse(w)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, rideID, data["ride_id"])
+	participants := data["participants"].([]interface{})
+	require.Len(t, participants, 1)
+	participant := participants[0].(map[string]interface{})
+	assert.Equal(t, "driver-789", participant["user_id"])
+	assert.Equal(t, PresenceAway, participant["status"])
+	assert.Equal(t, false, participant["is_typing"])
+}
+
+func TestGetRidePresence_Unauthorized(t *testing.T) {
+	handler, _, dbMock, _ := setupTestHandler(t)
+
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(0)
+	dbMock.ExpectQuery("SELECT COUNT.*FROM rides").
+		WithArgs("ride-123", "user-456").
+		WillReturnRows(rows)
+
+	c, w := setupTestContext("GET", "/api/v1/rides/ride-123/presence", nil)
+	c.Params = gin.Params{{Key: "ride_id", Value: "ride-123"}}
+	setUserContext(c, "user-456", "rider")
+
+	handler.GetRidePresence(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetRidePresence_NoUser(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	c, w := setupTestContext("GET", "/api/v1/rides/ride-123/presence", nil)
+	c.Params = gin.Params{{Key: "ride_id", Value: "ride-123"}}
+
+	handler.GetRidePresence(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// ============================================================================
+// Route Authorization Tests
+// ============================================================================
+
+const testJWTSecret = "realtime-test-secret"
+
+// setupAuthRouter registers the real-time routes behind a static JWT provider
+func setupAuthRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	t.Setenv("INTERNAL_API_KEY", "internal-test-key")
+
+	handler, _, _, _ := setupTestHandler(t)
+	router := gin.New()
+	handler.RegisterRoutes(router, jwtkeys.NewStaticProvider(testJWTSecret))
+	return router
+}
+
+// generateTestToken signs a token for a user with the given role
+func generateTestToken(t *testing.T, role models.UserRole) string {
+	t.Helper()
+	claims := &middleware.Claims{
+		UserID: uuid.New(),
+		Email:  "user@example.com",
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestRoutes_AfterAuthMiddlewareSeesUser(t *testing.T) {
+	t.Setenv("INTERNAL_API_KEY", "internal-test-key")
+
+	handler, _, _, _ := setupTestHandler(t)
+	router := gin.New()
+	var keys []string
+	handler.RegisterRoutes(router, jwtkeys.NewStaticProvider(testJWTSecret), func(c *gin.Context) {
+		keys = append(keys, middleware.UserOrIPKey(c))
+		c.Next()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, models.RoleAdmin))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Unauthenticated requests are rejected before reaching the middleware
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil))
+
+	require.Len(t, keys, 1)
+	assert.True(t, strings.HasPrefix(keys[0], "user:"))
+}
+
+func TestRoutes_StatsRequiresAdmin(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "no token", token: "", expectedStatus: http.StatusUnauthorized},
+		{name: "rider token", token: generateTestToken(t, models.RoleRider), expectedStatus: http.StatusForbidden},
+		{name: "driver token", token: generateTestToken(t, models.RoleDriver), expectedStatus: http.StatusForbidden},
+		{name: "admin token", token: generateTestToken(t, models.RoleAdmin), expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRoutes_InternalBroadcastRequiresAPIKey(t *testing.T) {
+	router := setupAuthRouter(t)
+	body := `{"ride_id":"ride-123","data":{"status":"arrived"}}`
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		bearer         string
+		expectedStatus int
+	}{
+		{name: "no credentials", expectedStatus: http.StatusUnauthorized},
+		{name: "admin token is not enough", bearer: generateTestToken(t, models.RoleAdmin), expectedStatus: http.StatusUnauthorized},
+		{name: "wrong key", apiKey: "wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "internal key", apiKey: "internal-test-key", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/internal/broadcast/ride", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set("X-Internal-API-Key", tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package realtime
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLocationMinDistance is how far (in meters) a driver must move before
+	// a new location is broadcast to the rider
+	DefaultLocationMinDistance = 25.0
+	// DefaultLocationMaxInterval is the longest a rider goes without a location
+	// broadcast while the driver keeps reporting
+	DefaultLocationMaxInterval = 10 * time.Second
+)
+
+// LocationThrottle suppresses driver location broadcasts that carry no useful
+// movement. An update is broadcast when the driver moved more than the distance
+// threshold since the last broadcast, when the time threshold has elapsed, or
+// when the driver is on a different ride than at the last broadcast.
+//
+// GPS fixes with poor accuracy jitter around the true position even when the
+// driver is stationary. When an update reports its accuracy radius (in meters)
+// and that radius is larger than the distance threshold, the driver must move
+// further than the accuracy radius instead, so noise alone never triggers a
+// broadcast. Riders still get the time-based heartbeat in that case.
+//
+// The throttle only gates broadcasts; every raw update is still stored.
+type LocationThrottle struct {
+	mu          sync.Mutex
+	minDistance float64
+	maxInterval time.Duration
+	last        map[string]broadcastPoint
+}
+
+// broadcastPoint is the last location broadcast for a driver
+type broadcastPoint struct {
+	rideID    string
+	latitude  float64
+	longitude float64
+	at        time.Time
+}
+
+// NewLocationThrottle creates a throttle with the given distance (meters) and time
+// thresholds. A zero distance broadcasts on any movement; a zero interval disables
+// the time-based heartbeat.
+func NewLocationThrottle(minDistanceMeters float64, maxInterval time.Duration) *LocationThrottle {
+	return &LocationThrottle{
+		minDistance: minDistanceMeters,
+		maxInterval: maxInterval,
+		last:        make(map[string]broadcastPoint),
+	}
+}
+
+// ShouldBroadcast reports whether a driver's update should be sent to the ride and,
+// if so, records it as the latest broadcast. accuracy is the reported GPS accuracy
+// radius in meters, or zero if unknown.
+func (t *LocationThrottle) ShouldBroadcast(driverID, rideID string, latitude, longitude, accuracy float64, now time.Time) bool {
+	if t == nil {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, ok := t.last[driverID]
+	if ok && prev.rideID == rideID {
+		threshold := math.Max(t.minDistance, accuracy)
+		moved := haversineMeters(prev.latitude, prev.longitude, latitude, longitude)
+		stale := t.maxInterval > 0 && now.Sub(prev.at) >= t.maxInterval
+		if moved <= threshold && !stale {
+			return false
+		}
+	}
+
+	t.last[driverID] = broadcastPoint{rideID: rideID, latitude: latitude, longitude: longitude, at: now}
+	return true
+}
+
+// Forget drops the throttle state for a driver
+func (t *LocationThrottle) Forget(driverID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.last, driverID)
+	t.mu.Unlock()
+}
+
+// SetLocationThrottle replaces the driver location broadcast thresholds
+func (s *Service) SetLocationThrottle(minDistanceMeters float64, maxInterval time.Duration) {
+	s.locationThrottle = NewLocationThrottle(minDistanceMeters, maxInterval)
+}
+
+// haversineMeters calculates great-circle distance in meters.
+func haversineMeters(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	const earthRadius = 6371000.0
+	deltaLatitude := (latitude2 - latitude1) * math.Pi / 180.0
+	deltaLongitude := (longitude2 - longitude1) * math.Pi / 180.0
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(latitude1*math.Pi/180.0)*math.Cos(latitude2*math.Pi/180.0)*
+			math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadius * c
+}
@@ -0,0 +1,181 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultOfflineBufferSize is how many undelivered messages are kept per user
+	DefaultOfflineBufferSize = 50
+	// DefaultOfflineBufferTTL is how long undelivered messages are kept
+	DefaultOfflineBufferTTL = 24 * time.Hour
+)
+
+// pendingKey is the sorted set of undelivered messages for a user, scored by sequence
+func pendingKey(userID string) string {
+	return "user:pending:" + userID
+}
+
+// seqKey holds the last sequence number assigned to a user's messages
+func seqKey(userID string) string {
+	return "user:msgseq:" + userID
+}
+
+// ackKey holds the highest sequence number a user has acknowledged
+func ackKey(userID string) string {
+	return "user:msgack:" + userID
+}
+
+// acknowledgeScript moves a user's acknowledged sequence forward to ARGV[1] and
+// removes the messages it covers, doing nothing if it is already at or past it
+const acknowledgeScript = `
+local acked = tonumber(redis.call("GET", KEYS[1]) or "0")
+local seq = tonumber(ARGV[1])
+if seq <= acked then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1])
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", ARGV[1])
+return 1
+`
+
+// SetOfflineBuffer configures how many undelivered messages are kept per user and
+// for how long. A size of zero disables buffering.
+func (s *Service) SetOfflineBuffer(size int, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultOfflineBufferTTL
+	}
+	s.offlineBufferSize = size
+	s.offlineBufferTTL = ttl
+}
+
+// nextSeq assigns the next per-user sequence number. It returns 0 if Redis is unavailable.
+func (s *Service) nextSeq(ctx context.Context, userID string) int64 {
+	seq, err := s.redis.Incr(ctx, seqKey(userID))
+	if err != nil {
+		s.logger.Warn("failed to assign message sequence", zap.String("user_id", userID), zap.Error(err))
+		return 0
+	}
+	return seq
+}
+
+// bufferMessage stores a message for a user until they acknowledge it, keeping
+// only the most recent messages
+func (s *Service) bufferMessage(ctx context.Context, userID string, msg *ws.Message) {
+	// Messages without a sequence number can't be acknowledged, so don't buffer them
+	if s.offlineBufferSize <= 0 || msg.Seq == 0 {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		s.logger.Error("failed to encode undelivered message", zap.String("user_id", userID), zap.Error(err))
+		return
+	}
+
+	key := pendingKey(userID)
+	if err := s.redis.ZAdd(ctx, key, float64(msg.Seq), string(data)); err != nil {
+		s.logger.Error("failed to buffer undelivered message", zap.String("user_id", userID), zap.Error(err))
+		return
+	}
+
+	// Drop the oldest messages beyond the buffer size
+	s.redis.Client.ZRemRangeByRank(ctx, key, 0, -int64(s.offlineBufferSize)-1)
+	s.redis.Expire(ctx, key, s.offlineBufferTTL)
+}
+
+// ackedSeq returns the highest sequence number the user has acknowledged
+func (s *Service) ackedSeq(ctx context.Context, userID string) (int64, error) {
+	val, err := s.redis.GetString(ctx, ackKey(userID))
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
+
+// DeliverPendingMessages sends a newly connected client the messages it hasn't
+// acknowledged, in sequence order. Messages stay buffered until the client
+// acknowledges them, so a connection that drops again gets them replayed.
+// It returns the number of messages sent.
+func (s *Service) DeliverPendingMessages(client *ws.Client) int {
+	ctx := context.Background()
+
+	acked, err := s.ackedSeq(ctx, client.ID)
+	if err != nil {
+		s.logger.Warn("failed to read acknowledged sequence", zap.String("user_id", client.ID), zap.Error(err))
+		return 0
+	}
+
+	entries, err := s.redis.ZRange(ctx, pendingKey(client.ID), 0, -1)
+	if err != nil {
+		s.logger.Warn("failed to read undelivered messages", zap.String("user_id", client.ID), zap.Error(err))
+		return 0
+	}
+
+	sent := 0
+	for _, entry := range entries {
+		var msg ws.Message
+		if err := json.Unmarshal([]byte(entry), &msg); err != nil {
+			continue
+		}
+		if msg.Seq <= acked {
+			continue
+		}
+		client.SendMessage(&msg)
+		sent++
+	}
+
+	if sent > 0 {
+		s.logger.Info("delivered buffered messages", zap.String("user_id", client.ID), zap.Int("count", sent))
+	}
+	return sent
+}
+
+// AcknowledgeMessages marks every message up to seq as delivered so it is not
+// replayed. The check and update run as one script, so concurrent acks from
+// several connections can't move the acknowledged sequence backwards.
+func (s *Service) AcknowledgeMessages(ctx context.Context, userID string, seq int64) error {
+	return s.redis.Client.Eval(ctx, acknowledgeScript, []string{ackKey(userID), pendingKey(userID)}, seq).Err()
+}
+
+// handleAck handles a client acknowledging delivered messages: either every
+// buffered message up to a sequence number, or a single chat message by ID
+func (s *Service) handleAck(client *ws.Client, msg *ws.Message) {
+	if messageID, ok := msg.Data["message_id"].(string); ok && messageID != "" {
+		rideID := receiptRide(client, msg)
+		if rideID == "" {
+			s.logger.Warn("chat ack without a ride", zap.String("client_id", client.ID))
+			return
+		}
+		if !s.inReceiptRide(client, rideID) {
+			s.logger.Warn("chat ack for a ride the client isn't in",
+				zap.String("client_id", client.ID),
+				zap.String("ride_id", rideID),
+			)
+			return
+		}
+		s.markChatDelivered(context.Background(), client, rideID, messageID)
+		return
+	}
+
+	seq, ok := msg.Data["seq"].(float64)
+	if !ok || seq <= 0 {
+		s.logger.Warn("invalid ack from client", zap.String("client_id", client.ID))
+		return
+	}
+
+	if err := s.AcknowledgeMessages(context.Background(), client.ID, int64(seq)); err != nil {
+		s.logger.Error("failed to acknowledge messages", zap.String("client_id", client.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// historyTTL is how long persisted ride messages are kept
+const historyTTL = 24 * time.Hour
+
+// PersistencePolicy decides which inbound message types are written to ride history.
+// Types that are not listed are treated as ephemeral and never persisted.
+type PersistencePolicy struct {
+	durable map[string]bool
+}
+
+// NewPersistencePolicy creates a policy that persists only the given message types.
+// Surrounding whitespace is trimmed and empty types are ignored.
+func NewPersistencePolicy(durableTypes ...string) *PersistencePolicy {
+	p := &PersistencePolicy{durable: make(map[string]bool, len(durableTypes))}
+	for _, t := range durableTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			p.durable[t] = true
+		}
+	}
+	return p
+}
+
+// DefaultPersistencePolicy persists chat and ride status messages. Typing indicators,
+// presence (join/leave) and location/ETA updates are ephemeral.
+func DefaultPersistencePolicy() *PersistencePolicy {
+	return NewPersistencePolicy("chat_message", "ride_status")
+}
+
+// ShouldPersist reports whether messages of the given type are durable
+func (p *PersistencePolicy) ShouldPersist(msgType string) bool {
+	if p == nil {
+		return false
+	}
+	return p.durable[msgType]
+}
+
+// SetPersistencePolicy replaces the message persistence policy
+func (s *Service) SetPersistencePolicy(policy *PersistencePolicy) {
+	s.persistence = policy
+}
+
+// historyKey returns the Redis list a message type is persisted to
+func historyKey(msgType, rideID string) string {
+	if msgType == "chat_message" {
+		return "ride:chat:" + rideID
+	}
+	return "ride:history:" + rideID
+}
+
+// persistMessage appends a message to the ride's history if the policy marks its type
+// as durable. It returns true when the message was written.
+func (s *Service) persistMessage(ctx context.Context, msgType, rideID string, record map[string]interface{}) bool {
+	if !s.persistence.ShouldPersist(msgType) {
+		return false
+	}
+	return s.appendHistory(ctx, msgType, rideID, record)
+}
+
+// appendHistory appends a message to the ride's history whatever the policy,
+// returning true when it was written
+func (s *Service) appendHistory(ctx context.Context, msgType, rideID string, record map[string]interface{}) bool {
+	key := historyKey(msgType, rideID)
+	data, _ := json.Marshal(record)
+	if err := s.redis.RPush(ctx, key, string(data)); err != nil {
+		s.logger.Error("failed to persist message",
+			zap.String("type", msgType),
+			zap.String("ride_id", rideID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	// Set expiry on ride history
+	s.redis.Expire(ctx, key, historyTTL)
+	return true
+}
@@ -0,0 +1,302 @@
+package realtime
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+// DefaultTypingTTL is how long a typing indicator stays active without a refresh
+const DefaultTypingTTL = 5 * time.Second
+
+// Presence statuses
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// ParticipantPresence is the presence and typing state of one ride participant
+type ParticipantPresence struct {
+	UserID   string    `json:"user_id"`
+	Role     string    `json:"role"`
+	Status   string    `json:"status"`
+	IsTyping bool      `json:"is_typing"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// presenceEntry tracks a participant along with its typing expiry timer
+type presenceEntry struct {
+	ParticipantPresence
+	typingTimer *time.Timer
+	typingGen   uint64
+}
+
+// presenceTracker holds in-memory presence and typing state per ride
+type presenceTracker struct {
+	mu        sync.Mutex
+	rides     map[string]map[string]*presenceEntry
+	typingTTL time.Duration
+}
+
+// newPresenceTracker creates an empty presence tracker
+func newPresenceTracker(typingTTL time.Duration) *presenceTracker {
+	if typingTTL <= 0 {
+		typingTTL = DefaultTypingTTL
+	}
+	return &presenceTracker{
+		rides:     make(map[string]map[string]*presenceEntry),
+		typingTTL: typingTTL,
+	}
+}
+
+// entry returns the participant's entry, creating it if needed. Caller must hold mu.
+func (p *presenceTracker) entry(rideID, userID, role string) *presenceEntry {
+	ride, ok := p.rides[rideID]
+	if !ok {
+		ride = make(map[string]*presenceEntry)
+		p.rides[rideID] = ride
+	}
+	e, ok := ride[userID]
+	if !ok {
+		e = &presenceEntry{ParticipantPresence: ParticipantPresence{UserID: userID, Role: role, Status: PresenceOnline}}
+		ride[userID] = e
+	}
+	e.LastSeen = time.Now()
+	return e
+}
+
+// clearTyping stops the typing timer and reports whether the participant was typing.
+// Caller must hold mu.
+func (e *presenceEntry) clearTyping() bool {
+	wasTyping := e.IsTyping
+	e.IsTyping = false
+	e.typingGen++
+	if e.typingTimer != nil {
+		e.typingTimer.Stop()
+		e.typingTimer = nil
+	}
+	return wasTyping
+}
+
+// setStatus records a participant's presence status. Going offline clears any
+// typing state and forgets the participant; it reports whether typing was cleared.
+func (p *presenceTracker) setStatus(rideID, userID, role, status string) (ParticipantPresence, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.entry(rideID, userID, role)
+	e.Status = status
+	if status != PresenceOffline {
+		return e.ParticipantPresence, false
+	}
+
+	wasTyping := e.clearTyping()
+	delete(p.rides[rideID], userID)
+	if len(p.rides[rideID]) == 0 {
+		delete(p.rides, rideID)
+	}
+	return e.ParticipantPresence, wasTyping
+}
+
+// startTyping marks a participant as typing and (re)arms the expiry timer.
+// onExpire runs if no refresh or stop arrives within the typing TTL. It reports
+// whether the participant was not already typing.
+func (p *presenceTracker) startTyping(rideID, userID, role string, onExpire func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.entry(rideID, userID, role)
+	wasTyping := e.clearTyping()
+	e.IsTyping = true
+	e.Status = PresenceOnline
+
+	gen := e.typingGen
+	e.typingTimer = time.AfterFunc(p.typingTTL, func() {
+		if p.expireTyping(rideID, userID, gen) {
+			onExpire()
+		}
+	})
+	return !wasTyping
+}
+
+// stopTyping clears a participant's typing state and reports whether it was set
+func (p *presenceTracker) stopTyping(rideID, userID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.rides[rideID][userID]
+	if !ok {
+		return false
+	}
+	e.LastSeen = time.Now()
+	return e.clearTyping()
+}
+
+// expireTyping clears typing state set by the given generation, ignoring timers
+// that were superseded by a later start or stop
+func (p *presenceTracker) expireTyping(rideID, userID string, gen uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.rides[rideID][userID]
+	if !ok || e.typingGen != gen || !e.IsTyping {
+		return false
+	}
+	e.IsTyping = false
+	e.typingTimer = nil
+	return true
+}
+
+// snapshot returns the current participants of a ride ordered by user ID
+func (p *presenceTracker) snapshot(rideID string) []ParticipantPresence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	participants := make([]ParticipantPresence, 0, len(p.rides[rideID]))
+	for _, e := range p.rides[rideID] {
+		participants = append(participants, e.ParticipantPresence)
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].UserID < participants[j].UserID
+	})
+	return participants
+}
+
+// SetTypingTTL overrides how long typing indicators last without a refresh.
+// It only affects indicators started after the call.
+func (s *Service) SetTypingTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTypingTTL
+	}
+	s.presence.mu.Lock()
+	s.presence.typingTTL = ttl
+	s.presence.mu.Unlock()
+}
+
+// GetRidePresence returns the presence and typing state of a ride's participants
+func (s *Service) GetRidePresence(rideID string) []ParticipantPresence {
+	return s.presence.snapshot(rideID)
+}
+
+// handleTypingStart handles a participant starting to type
+func (s *Service) handleTypingStart(client *ws.Client, msg *ws.Message) {
+	rideID := s.eventRide(client, msg)
+	if rideID == "" {
+		return
+	}
+
+	userID, role := client.ID, client.Role
+	started := s.presence.startTyping(rideID, userID, role, func() {
+		s.sendTypingEvent("typing_stop", rideID, userID, role, true)
+	})
+	if started {
+		s.sendTypingEvent("typing_start", rideID, userID, role, false)
+	}
+}
+
+// handleTypingStop handles a participant that stopped typing
+func (s *Service) handleTypingStop(client *ws.Client, msg *ws.Message) {
+	rideID := s.eventRide(client, msg)
+	if rideID == "" {
+		return
+	}
+
+	if s.presence.stopTyping(rideID, client.ID) {
+		s.sendTypingEvent("typing_stop", rideID, client.ID, client.Role, false)
+	}
+}
+
+// handlePresence handles a participant's explicit presence update
+func (s *Service) handlePresence(client *ws.Client, msg *ws.Message) {
+	rideID := s.eventRide(client, msg)
+	if rideID == "" {
+		return
+	}
+
+	status, _ := msg.Data["status"].(string)
+	switch status {
+	case PresenceOnline, PresenceAway, PresenceOffline:
+	default:
+		s.logger.Warn("invalid presence status", zap.String("client_id", client.ID), zap.String("status", status))
+		return
+	}
+
+	s.updatePresence(rideID, client.ID, client.Role, status)
+}
+
+// handleDisconnect marks a disconnected client offline in its ride and drops its
+// location throttle state
+func (s *Service) handleDisconnect(client *ws.Client) {
+	s.locationThrottle.Forget(client.ID)
+	if rideID := client.GetRide(); rideID != "" {
+		s.updatePresence(rideID, client.ID, client.Role, PresenceOffline)
+	}
+}
+
+// updatePresence records a presence change and notifies the other participants
+func (s *Service) updatePresence(rideID, userID, role, status string) {
+	state, typingCleared := s.presence.setStatus(rideID, userID, role, status)
+	if typingCleared {
+		s.sendTypingEvent("typing_stop", rideID, userID, role, false)
+	}
+
+	s.sendToOthersInRide(rideID, userID, &ws.Message{
+		Type:      "presence",
+		RideID:    rideID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"user_id":   userID,
+			"role":      role,
+			"status":    state.Status,
+			"last_seen": state.LastSeen,
+		},
+	})
+}
+
+// sendTypingEvent notifies the other participants of a typing change
+func (s *Service) sendTypingEvent(msgType, rideID, userID, role string, expired bool) {
+	data := map[string]interface{}{
+		"user_id": userID,
+		"role":    role,
+	}
+	if expired {
+		data["expired"] = true
+	}
+
+	s.sendToOthersInRide(rideID, userID, &ws.Message{
+		Type:      msgType,
+		RideID:    rideID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+}
+
+// sendToOthersInRide sends a message to every client in the ride except the sender
+func (s *Service) sendToOthersInRide(rideID, senderID string, msg *ws.Message) {
+	for _, c := range s.hub.GetClientsInRide(rideID) {
+		if c.ID != senderID {
+			c.SendMessage(msg)
+		}
+	}
+}
+
+// eventRide returns the ride a presence or typing event applies to. Clients may
+// only send events for the ride they have joined.
+func (s *Service) eventRide(client *ws.Client, msg *ws.Message) string {
+	rideID := client.GetRide()
+	if rideID == "" {
+		return ""
+	}
+	if msg.RideID != "" && msg.RideID != rideID {
+		s.logger.Warn("client sent event for a ride it has not joined",
+			zap.String("client_id", client.ID), zap.String("ride_id", msg.RideID))
+		return ""
+	}
+	return rideID
+}
@@ -0,0 +1,267 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+// Chat message delivery states, as shown in chat history
+const (
+	ChatStatusSent      = "sent"
+	ChatStatusDelivered = "delivered"
+	ChatStatusRead      = "read"
+)
+
+// receiptsKey is the hash holding the sender and delivery/read times of a ride's
+// chat messages, with fields "<message_id>:sender", ":delivered_at" and ":read_at"
+func receiptsKey(rideID string) string {
+	return "ride:chat:receipts:" + rideID
+}
+
+func senderField(messageID string) string {
+	return messageID + ":sender"
+}
+
+func deliveredField(messageID string) string {
+	return messageID + ":delivered_at"
+}
+
+func readField(messageID string) string {
+	return messageID + ":read_at"
+}
+
+// trackChatMessage records the sender of a chat message so receipts can be relayed
+// back to them. Until a recipient acknowledges it the message is undelivered.
+func (s *Service) trackChatMessage(ctx context.Context, rideID, messageID, senderID string) {
+	key := receiptsKey(rideID)
+	if err := s.redis.HSet(ctx, key, senderField(messageID), senderID); err != nil {
+		s.logger.Error("failed to track chat message",
+			zap.String("ride_id", rideID),
+			zap.String("message_id", messageID),
+			zap.Error(err),
+		)
+		return
+	}
+	s.redis.Expire(ctx, key, historyTTL)
+}
+
+// markChatDelivered records that a recipient received a chat message and tells the sender
+func (s *Service) markChatDelivered(ctx context.Context, client *ws.Client, rideID, messageID string) {
+	sender, ok := s.chatSender(ctx, client, rideID, messageID)
+	if !ok {
+		return
+	}
+	s.recordReceipt(ctx, client, rideID, messageID, sender, deliveredField(messageID), "delivery_receipt", "delivered_at")
+}
+
+// markChatRead records that a recipient read a chat message and tells the sender. A
+// read message has also been delivered, even if the delivery ack never arrived.
+func (s *Service) markChatRead(ctx context.Context, client *ws.Client, rideID, messageID string) {
+	sender, ok := s.chatSender(ctx, client, rideID, messageID)
+	if !ok {
+		return
+	}
+	s.recordReceipt(ctx, client, rideID, messageID, sender, deliveredField(messageID), "delivery_receipt", "delivered_at")
+	s.recordReceipt(ctx, client, rideID, messageID, sender, readField(messageID), "read_receipt", "read_at")
+}
+
+// chatSender returns the sender of a chat message. Unknown messages and receipts
+// for the client's own messages are ignored.
+func (s *Service) chatSender(ctx context.Context, client *ws.Client, rideID, messageID string) (string, bool) {
+	sender, err := s.redis.HGet(ctx, receiptsKey(rideID), senderField(messageID))
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			s.logger.Warn("failed to look up chat message", zap.String("message_id", messageID), zap.Error(err))
+		}
+		return "", false
+	}
+	if sender == client.ID {
+		return "", false
+	}
+	return sender, true
+}
+
+// recordReceipt stores the time of a delivery or read the first time it is reported
+// and relays a receipt to the sender. Repeated acks are ignored.
+func (s *Service) recordReceipt(ctx context.Context, client *ws.Client, rideID, messageID, senderID, field, receiptType, timeKey string) {
+	now := time.Now().Unix()
+	key := receiptsKey(rideID)
+
+	set, err := s.redis.Client.HSetNX(ctx, key, field, now).Result()
+	if err != nil {
+		s.logger.Error("failed to record chat receipt",
+			zap.String("type", receiptType),
+			zap.String("message_id", messageID),
+			zap.Error(err),
+		)
+		return
+	}
+	if !set {
+		return
+	}
+	s.redis.Expire(ctx, key, historyTTL)
+
+	// Buffered like any other user message if the sender has gone offline
+	s.BroadcastToUser(senderID, receiptType, map[string]interface{}{
+		"message_id": messageID,
+		"ride_id":    rideID,
+		"user_id":    client.ID,
+		timeKey:      now,
+	})
+}
+
+// handleRead handles a client reporting that it has read a chat message
+func (s *Service) handleRead(client *ws.Client, msg *ws.Message) {
+	messageID, _ := msg.Data["message_id"].(string)
+	rideID := receiptRide(client, msg)
+	if messageID == "" || rideID == "" {
+		s.logger.Warn("invalid read receipt from client", zap.String("client_id", client.ID))
+		return
+	}
+
+	if !s.inReceiptRide(client, rideID) {
+		s.logger.Warn("read receipt for a ride the client isn't in",
+			zap.String("client_id", client.ID),
+			zap.String("ride_id", rideID),
+		)
+		return
+	}
+
+	s.markChatRead(context.Background(), client, rideID, messageID)
+}
+
+// inReceiptRide reports whether a client may send receipts for a ride's chat. Only
+// the ride's rider and driver may: a client's current ride was checked when it
+// joined, and any other ride is looked up. Observers such as dispatchers are
+// rejected, so they can't forge receipts for the participants' messages.
+func (s *Service) inReceiptRide(client *ws.Client, rideID string) bool {
+	return client.GetRide() == rideID || s.isRideParticipant(rideID, client.ID)
+}
+
+// receiptRide is the ride an ack or read refers to: the one named in the frame, or
+// else the client's current ride
+func receiptRide(client *ws.Client, msg *ws.Message) string {
+	if msg.RideID != "" {
+		return msg.RideID
+	}
+	if rideID, ok := msg.Data["ride_id"].(string); ok && rideID != "" {
+		return rideID
+	}
+	return client.GetRide()
+}
+
+// redeliverChat sends a client that (re)joined a ride the chat messages addressed
+// to it that were never acknowledged, e.g. because it was offline when they were sent
+func (s *Service) redeliverChat(ctx context.Context, client *ws.Client, rideID string) int {
+	entries, err := s.redis.LRange(ctx, historyKey("chat_message", rideID), 0, -1)
+	if err != nil || len(entries) == 0 {
+		return 0
+	}
+
+	receipts, err := s.redis.HGetAll(ctx, receiptsKey(rideID))
+	if err != nil {
+		s.logger.Warn("failed to read chat receipts", zap.String("ride_id", rideID), zap.Error(err))
+		return 0
+	}
+
+	sent := 0
+	for _, entry := range entries {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(entry), &record); err != nil {
+			continue
+		}
+		messageID, _ := record["message_id"].(string)
+		senderID, _ := record["sender_id"].(string)
+		if messageID == "" || senderID == client.ID {
+			continue
+		}
+		if _, delivered := receipts[deliveredField(messageID)]; delivered {
+			continue
+		}
+
+		sentAt := time.Now()
+		if ts, ok := record["timestamp"].(float64); ok {
+			sentAt = time.Unix(int64(ts), 0)
+		}
+		data := map[string]interface{}{
+			"message_id":  messageID,
+			"message":     record["message"],
+			"sender_id":   senderID,
+			"sender_role": record["sender_role"],
+		}
+		if attachment := s.recordAttachment(ctx, record); attachment != nil {
+			data["attachment"] = attachment
+		}
+		client.SendMessage(&ws.Message{
+			Type:      "chat_message",
+			RideID:    rideID,
+			UserID:    senderID,
+			Timestamp: sentAt,
+			Data:      data,
+		})
+		sent++
+	}
+
+	if sent > 0 {
+		s.logger.Info("redelivered undelivered chat messages",
+			zap.String("user_id", client.ID),
+			zap.String("ride_id", rideID),
+			zap.Int("count", sent),
+		)
+	}
+	return sent
+}
+
+// applyReceipts adds the delivery state of each chat message to its history record
+func (s *Service) applyReceipts(ctx context.Context, rideID string, history []map[string]interface{}) {
+	tracked := false
+	for _, record := range history {
+		if id, _ := record["message_id"].(string); id != "" {
+			tracked = true
+			break
+		}
+	}
+	// Messages stored before receipts existed have no ID and nothing to look up
+	if !tracked {
+		return
+	}
+
+	receipts, err := s.redis.HGetAll(ctx, receiptsKey(rideID))
+	if err != nil {
+		s.logger.Warn("failed to read chat receipts", zap.String("ride_id", rideID), zap.Error(err))
+		return
+	}
+
+	for _, record := range history {
+		messageID, _ := record["message_id"].(string)
+		if messageID == "" {
+			continue
+		}
+
+		record["status"] = ChatStatusSent
+		if deliveredAt, ok := receiptTime(receipts, deliveredField(messageID)); ok {
+			record["status"] = ChatStatusDelivered
+			record["delivered_at"] = deliveredAt
+		}
+		if readAt, ok := receiptTime(receipts, readField(messageID)); ok {
+			record["status"] = ChatStatusRead
+			record["read_at"] = readAt
+		}
+	}
+}
+
+func receiptTime(receipts map[string]string, field string) (int64, bool) {
+	val, ok := receipts[field]
+	if !ok {
+		return 0, false
+	}
+	ts, err := strconv.ParseInt(val, 10, 64)
+	return ts, err == nil
+}
@@ -4,33 +4,66 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/internal/geo"
+	realtimeapi "github.com/richxcame/ride-hailing/pkg/realtime"
 	"github.com/richxcame/ride-hailing/pkg/redis"
+	"github.com/richxcame/ride-hailing/pkg/storage"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
 	"go.uber.org/zap"
 )
 
 // Service handles real-time communication
 type Service struct {
-	hub        *ws.Hub
-	db         *sql.DB
-	redis      *redis.Client
-	geoService *geo.Service
-	logger     *zap.Logger
+	hub         *ws.Hub
+	db          *sql.DB
+	redis       *redis.Client
+	geoService  *geo.Service
+	logger      *zap.Logger
+	persistence *PersistencePolicy
+	presence    *presenceTracker
+
+	offlineBufferSize int
+	offlineBufferTTL  time.Duration
+
+	locationThrottle *LocationThrottle
+	etaEstimator     ETAEstimator
+	etaFilter        *etaFilter
+
+	emergencyCooldown *emergencyCooldown
+
+	attachments      storage.Storage
+	attachmentPolicy AttachmentPolicy
+
+	// activeRide finds the ride a user is taking part in, returning
+	// sql.ErrNoRows when there is none
+	activeRide func(userID string) (string, error)
 }
 
 // NewService creates a new real-time service
 func NewService(hub *ws.Hub, db *sql.DB, redisClient *redis.Client, geoService *geo.Service, logger *zap.Logger) *Service {
 	s := &Service{
-		hub:        hub,
-		db:         db,
-		redis:      redisClient,
-		geoService: geoService,
-		logger:     logger,
+		hub:         hub,
+		db:          db,
+		redis:       redisClient,
+		geoService:  geoService,
+		logger:      logger,
+		persistence: DefaultPersistencePolicy(),
+		presence:    newPresenceTracker(DefaultTypingTTL),
+
+		offlineBufferSize: DefaultOfflineBufferSize,
+		offlineBufferTTL:  DefaultOfflineBufferTTL,
+
+		locationThrottle:  NewLocationThrottle(DefaultLocationMinDistance, DefaultLocationMaxInterval),
+		etaEstimator:      StraightLineETAEstimator{AverageSpeedKmh: DefaultETAAverageSpeedKmh},
+		etaFilter:         newETAFilter(DefaultETAMinChange),
+		emergencyCooldown: newEmergencyCooldown(DefaultEmergencyCooldown),
+		attachmentPolicy:  DefaultAttachmentPolicy(),
 	}
+	s.activeRide = s.findActiveRide
 
 	// Register message handlers
 	s.registerHandlers()
@@ -44,14 +77,24 @@ func (s *Service) registerHandlers() {
 	s.hub.RegisterHandler("ride_status", s.handleRideStatus)
 	s.hub.RegisterHandler("chat_message", s.handleChatMessage)
 	s.hub.RegisterHandler("typing", s.handleTyping)
+	s.hub.RegisterHandler("typing_start", s.handleTypingStart)
+	s.hub.RegisterHandler("typing_stop", s.handleTypingStop)
+	s.hub.RegisterHandler("presence", s.handlePresence)
 	s.hub.RegisterHandler("join_ride", s.handleJoinRide)
 	s.hub.RegisterHandler("leave_ride", s.handleLeaveRide)
+	s.hub.RegisterHandler("watch_ride", s.handleWatchRide)
+	s.hub.RegisterHandler("unwatch_ride", s.handleUnwatchRide)
+	s.hub.RegisterHandler("ack", s.handleAck)
+	s.hub.RegisterHandler("read", s.handleRead)
+	s.hub.RegisterPriorityHandler(emergencyMessageType, s.handleEmergency)
+	s.hub.OnConnect(s.handleConnect)
+	s.hub.OnDisconnect(s.handleDisconnect)
 }
 
 // handleLocationUpdate handles driver location updates
 func (s *Service) handleLocationUpdate(client *ws.Client, msg *ws.Message) {
 	// Only drivers can update location
-	if client.Role != "driver" {
+	if client.Role != ws.RoleDriver {
 		s.logger.Warn("non-driver attempted location update", zap.String("client_id", client.ID))
 		return
 	}
@@ -66,6 +109,7 @@ func (s *Service) handleLocationUpdate(client *ws.Client, msg *ws.Message) {
 
 	heading, _ := msg.Data["heading"].(float64)
 	speed, _ := msg.Data["speed"].(float64)
+	accuracy, _ := msg.Data["accuracy"].(float64)
 
 	ctx := context.Background()
 
@@ -92,12 +136,12 @@ func (s *Service) handleLocationUpdate(client *ws.Client, msg *ws.Message) {
 		}
 	}
 
-	// If driver is in a ride, broadcast to rider
+	// If driver is in a ride, broadcast to rider unless the driver barely moved
 	rideID := client.GetRide()
-	if rideID != "" {
+	if rideID != "" && s.locationThrottle.ShouldBroadcast(client.ID, rideID, latitude, longitude, accuracy, time.Now()) {
 		clients := s.hub.GetClientsInRide(rideID)
 		for _, c := range clients {
-			if c.Role == "rider" {
+			if c.Role == ws.RoleRider {
 				c.SendMessage(&ws.Message{
 					Type:      "driver_location",
 					RideID:    rideID,
@@ -112,6 +156,10 @@ func (s *Service) handleLocationUpdate(client *ws.Client, msg *ws.Message) {
 				})
 			}
 		}
+
+		if s.db != nil {
+			go s.UpdateDriverETA(context.Background(), client.ID, rideID, latitude, longitude, speed)
+		}
 	}
 }
 
@@ -128,6 +176,14 @@ func (s *Service) handleRideStatus(client *ws.Client, msg *ws.Message) {
 		return
 	}
 
+	s.persistMessage(context.Background(), "ride_status", msg.RideID, map[string]interface{}{
+		"type":        "ride_status",
+		"status":      status,
+		"sender_id":   client.ID,
+		"sender_role": client.Role,
+		"timestamp":   time.Now().Unix(),
+	})
+
 	// Broadcast status update to all clients in the ride
 	s.hub.SendToRide(msg.RideID, &ws.Message{
 		Type:      "ride_status_update",
@@ -142,7 +198,8 @@ func (s *Service) handleRideStatus(client *ws.Client, msg *ws.Message) {
 	})
 }
 
-// handleChatMessage handles chat messages between rider and driver
+// handleChatMessage handles chat messages between rider and driver. A message
+// carries text, an image attachment the sender uploaded (file_key), or both.
 func (s *Service) handleChatMessage(client *ws.Client, msg *ws.Message) {
 	rideID := client.GetRide()
 	if rideID == "" {
@@ -150,31 +207,83 @@ func (s *Service) handleChatMessage(client *ws.Client, msg *ws.Message) {
 		return
 	}
 
-	message, ok := msg.Data["message"].(string)
-	if !ok || message == "" {
+	message, _ := msg.Data["message"].(string)
+	fileKey, _ := msg.Data["file_key"].(string)
+	if message == "" && fileKey == "" {
 		s.logger.Warn("invalid chat message from client", zap.String("client_id", client.ID))
 		return
 	}
 
-	// Store message in Redis for chat history
 	ctx := context.Background()
-	chatKey := "ride:chat:" + rideID
-	chatMsg := map[string]interface{}{
+
+	var attachment *ChatAttachment
+	if fileKey != "" {
+		var err error
+		attachment, err = s.resolveAttachment(ctx, rideID, client.ID, fileKey)
+		if err != nil {
+			s.logger.Warn("rejected chat attachment",
+				zap.String("client_id", client.ID),
+				zap.String("file_key", fileKey),
+				zap.Error(err),
+			)
+			data := map[string]interface{}{
+				"message": "Attachment rejected: " + err.Error(),
+			}
+			if clientMessageID, ok := msg.Data["client_message_id"].(string); ok {
+				data["client_message_id"] = clientMessageID
+			}
+			client.SendMessage(&ws.Message{
+				Type:      "error",
+				RideID:    rideID,
+				Timestamp: time.Now(),
+				Data:      data,
+			})
+			return
+		}
+	}
+
+	messageID := uuid.NewString()
+
+	// Store message in Redis for chat history
+	record := map[string]interface{}{
+		"message_id":  messageID,
 		"sender_id":   client.ID,
 		"sender_role": client.Role,
 		"message":     message,
 		"timestamp":   time.Now().Unix(),
 	}
-
-	data, _ := json.Marshal(chatMsg)
-	if err := s.redis.RPush(ctx, chatKey, string(data)); err != nil {
-		s.logger.Error("failed to store chat message", zap.Error(err))
+	if attachment != nil {
+		record["attachment"] = attachment
 	}
+	s.persistMessage(ctx, "chat_message", rideID, record)
+	s.trackChatMessage(ctx, rideID, messageID, client.ID)
 
-	// Set expiry on chat history (24 hours)
-	s.redis.Expire(ctx, chatKey, 24*time.Hour)
+	// Tell the sender the ID its receipts will refer to
+	confirmation := map[string]interface{}{
+		"message_id": messageID,
+	}
+	if clientMessageID, ok := msg.Data["client_message_id"].(string); ok {
+		confirmation["client_message_id"] = clientMessageID
+	}
+	client.SendMessage(&ws.Message{
+		Type:      "chat_sent",
+		RideID:    rideID,
+		Timestamp: time.Now(),
+		Data:      confirmation,
+	})
 
-	// Broadcast to other clients in the ride
+	// Broadcast to other clients in the ride. Recipients that are offline get the
+	// message when they rejoin, since it stays undelivered until acknowledged.
+	data := map[string]interface{}{
+		"message_id":  messageID,
+		"message":     message,
+		"sender_id":   client.ID,
+		"sender_role": client.Role,
+	}
+	if attachment != nil {
+		withURL := s.withDownloadURL(ctx, *attachment)
+		data["attachment"] = &withURL
+	}
 	clients := s.hub.GetClientsInRide(rideID)
 	for _, c := range clients {
 		if c.ID != client.ID {
@@ -183,11 +292,7 @@ func (s *Service) handleChatMessage(client *ws.Client, msg *ws.Message) {
 				RideID:    rideID,
 				UserID:    client.ID,
 				Timestamp: time.Now(),
-				Data: map[string]interface{}{
-					"message":     message,
-					"sender_id":   client.ID,
-					"sender_role": client.Role,
-				},
+				Data:      data,
 			})
 		}
 	}
@@ -205,6 +310,15 @@ func (s *Service) handleTyping(client *ws.Client, msg *ws.Message) {
 		return
 	}
 
+	// Typing indicators are ephemeral unless the policy says otherwise
+	s.persistMessage(context.Background(), "typing", rideID, map[string]interface{}{
+		"type":        "typing",
+		"is_typing":   isTyping,
+		"sender_id":   client.ID,
+		"sender_role": client.Role,
+		"timestamp":   time.Now().Unix(),
+	})
+
 	// Broadcast typing indicator to other clients in the ride
 	clients := s.hub.GetClientsInRide(rideID)
 	for _, c := range clients {
@@ -232,13 +346,7 @@ func (s *Service) handleJoinRide(client *ws.Client, msg *ws.Message) {
 	}
 
 	// Verify the client is part of this ride (check database)
-	var count int
-	query := `
-		SELECT COUNT(*) FROM rides
-		WHERE id = $1 AND (rider_id = $2 OR driver_id = $2)
-	`
-	err := s.db.QueryRow(query, msg.RideID, client.ID).Scan(&count)
-	if err != nil || count == 0 {
+	if !s.isRideParticipant(msg.RideID, client.ID) {
 		s.logger.Warn("client not authorized for ride", zap.String("client_id", client.ID), zap.String("ride_id", msg.RideID))
 		client.SendMessage(&ws.Message{
 			Type:      "error",
@@ -250,23 +358,42 @@ func (s *Service) handleJoinRide(client *ws.Client, msg *ws.Message) {
 		return
 	}
 
-	// Add client to ride room
-	s.hub.AddClientToRide(client.ID, msg.RideID)
+	s.joinRide(client, msg.RideID)
+}
+
+// isRideParticipant reports whether a user is the rider or driver of a ride
+func (s *Service) isRideParticipant(rideID, userID string) bool {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM rides
+		WHERE id = $1 AND (rider_id = $2 OR driver_id = $2)
+	`
+	err := s.db.QueryRow(query, rideID, userID).Scan(&count)
+	return err == nil && count > 0
+}
+
+// joinRide adds a participant to a ride room and tells the others in the ride
+func (s *Service) joinRide(client *ws.Client, rideID string) {
+	s.hub.AddClientToRide(client.ID, rideID)
+	s.updatePresence(rideID, client.ID, client.Role, PresenceOnline)
 
 	// Send confirmation
 	client.SendMessage(&ws.Message{
 		Type:      "joined_ride",
-		RideID:    msg.RideID,
+		RideID:    rideID,
 		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"room": ws.RideRoom(rideID),
+		},
 	})
 
 	// Notify other clients in the ride
-	clients := s.hub.GetClientsInRide(msg.RideID)
+	clients := s.hub.GetClientsInRide(rideID)
 	for _, c := range clients {
 		if c.ID != client.ID {
 			c.SendMessage(&ws.Message{
 				Type:      "user_joined",
-				RideID:    msg.RideID,
+				RideID:    rideID,
 				UserID:    client.ID,
 				Timestamp: time.Now(),
 				Data: map[string]interface{}{
@@ -276,6 +403,98 @@ func (s *Service) handleJoinRide(client *ws.Client, msg *ws.Message) {
 			})
 		}
 	}
+
+	// Catch up on chat sent while the client was away
+	s.redeliverChat(context.Background(), client, rideID)
+}
+
+// handleConnect puts a newly connected rider or driver into the room of their
+// active ride, if they have one. The lookup runs off the hub's goroutine.
+func (s *Service) handleConnect(client *ws.Client) {
+	if s.db == nil || (client.Role != ws.RoleRider && client.Role != ws.RoleDriver) {
+		return
+	}
+	go s.joinActiveRide(client)
+}
+
+// joinActiveRide joins the ride the client is currently taking part in
+func (s *Service) joinActiveRide(client *ws.Client) {
+	rideID, err := s.activeRide(client.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("failed to look up active ride", zap.String("client_id", client.ID), zap.Error(err))
+		}
+		return
+	}
+
+	// The client may have disconnected, or been replaced, during the lookup
+	if current, ok := s.hub.GetClient(client.ID); !ok || current != client {
+		return
+	}
+
+	s.joinRide(client, rideID)
+}
+
+// findActiveRide returns the user's most recent ride that is still under way
+func (s *Service) findActiveRide(userID string) (string, error) {
+	var rideID string
+	query := `
+		SELECT id FROM rides
+		WHERE (rider_id = $1 OR driver_id = $1)
+		  AND status IN ('requested', 'accepted', 'in_progress')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := s.db.QueryRow(query, userID).Scan(&rideID)
+	return rideID, err
+}
+
+// handleWatchRide lets a dispatcher observe a ride's updates without joining it
+func (s *Service) handleWatchRide(client *ws.Client, msg *ws.Message) {
+	if client.Role != ws.RoleDispatcher {
+		s.logger.Warn("non-dispatcher attempted to watch ride", zap.String("client_id", client.ID), zap.String("ride_id", msg.RideID))
+		client.SendMessage(&ws.Message{
+			Type:      "error",
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"message": "Not authorized to watch rides",
+			},
+		})
+		return
+	}
+
+	if msg.RideID == "" {
+		s.logger.Warn("missing ride_id in watch_ride request")
+		return
+	}
+
+	if !s.hub.WatchRide(client.ID, msg.RideID) {
+		return
+	}
+
+	client.SendMessage(&ws.Message{
+		Type:      "watching_ride",
+		RideID:    msg.RideID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"room": ws.RideRoom(msg.RideID),
+		},
+	})
+}
+
+// handleUnwatchRide stops a dispatcher observing a ride
+func (s *Service) handleUnwatchRide(client *ws.Client, msg *ws.Message) {
+	if client.Role != ws.RoleDispatcher || msg.RideID == "" {
+		return
+	}
+
+	s.hub.RemoveClientFromRide(client.ID, msg.RideID)
+
+	client.SendMessage(&ws.Message{
+		Type:      "unwatched_ride",
+		RideID:    msg.RideID,
+		Timestamp: time.Now(),
+	})
 }
 
 // handleLeaveRide handles client leaving a ride room
@@ -302,6 +521,8 @@ func (s *Service) handleLeaveRide(client *ws.Client, msg *ws.Message) {
 		}
 	}
 
+	s.updatePresence(rideID, client.ID, client.Role, PresenceOffline)
+
 	// Remove client from ride room
 	s.hub.RemoveClientFromRide(client.ID, rideID)
 
@@ -316,27 +537,47 @@ func (s *Service) handleLeaveRide(client *ws.Client, msg *ws.Message) {
 	})
 }
 
-// BroadcastRideUpdate broadcasts a ride update to all clients in the ride
+// BroadcastRideUpdate broadcasts a ride update to the members of the ride's
+// room: its rider and driver, and any dispatchers watching it
 func (s *Service) BroadcastRideUpdate(rideID string, data map[string]interface{}) {
+	s.BroadcastRideEvent(rideID, realtimeapi.EventRideUpdate, data)
+}
+
+// BroadcastRideEvent broadcasts a message of the given type to the members of
+// the ride's room, as a ride update when the type is empty
+func (s *Service) BroadcastRideEvent(rideID, msgType string, data map[string]interface{}) {
+	if msgType == "" {
+		msgType = realtimeapi.EventRideUpdate
+	}
 	s.hub.SendToRide(rideID, &ws.Message{
-		Type:      "ride_update",
+		Type:      msgType,
 		RideID:    rideID,
 		Timestamp: time.Now(),
 		Data:      data,
 	})
 }
 
-// BroadcastToUser sends a message to a specific user
+// BroadcastToUser sends a message to a specific user. The message is buffered
+// until the user acknowledges it, whether or not they are connected, so one
+// lost on the way (the user disconnecting meanwhile, or their queue dropping it
+// because they weren't keeping up) is replayed when they reconnect.
 func (s *Service) BroadcastToUser(userID string, msgType string, data map[string]interface{}) {
-	s.hub.SendToUser(userID, &ws.Message{
+	ctx := context.Background()
+	msg := &ws.Message{
 		Type:      msgType,
 		UserID:    userID,
 		Timestamp: time.Now(),
+		Seq:       s.nextSeq(ctx, userID),
 		Data:      data,
-	})
+	}
+
+	// Buffered before sending, so a client connecting in between gets it replayed
+	s.bufferMessage(ctx, userID, msg)
+	s.hub.SendToUser(userID, msg)
 }
 
-// GetChatHistory retrieves chat history for a ride
+// GetChatHistory retrieves chat history for a ride. Attachments come with a
+// presigned download URL.
 func (s *Service) GetChatHistory(rideID string) ([]map[string]interface{}, error) {
 	ctx := context.Background()
 	chatKey := "ride:chat:" + rideID
@@ -351,21 +592,28 @@ func (s *Service) GetChatHistory(rideID string) ([]map[string]interface{}, error
 		if err := json.Unmarshal([]byte(msg), &chatMsg); err != nil {
 			continue
 		}
+		if attachment := s.recordAttachment(ctx, chatMsg); attachment != nil {
+			chatMsg["attachment"] = attachment
+		}
 		history = append(history, chatMsg)
 	}
 
+	s.applyReceipts(ctx, rideID, history)
+
 	return history, nil
 }
 
+// GetRoomMembers lists the clients in a ride's room, for diagnostics
+func (s *Service) GetRoomMembers(rideID string) []ws.RoomMember {
+	return s.hub.GetRoomMembers(rideID)
+}
+
 // GetHub returns the WebSocket hub
 func (s *Service) GetHub() *ws.Hub {
 	return s.hub
 }
 
-// GetStats returns connection statistics
-func (s *Service) GetStats() map[string]interface{} {
-	return map[string]interface{}{
-		"connected_clients": s.hub.GetClientCount(),
-		"active_rides":      s.hub.GetRideCount(),
-	}
+// GetStats returns connection and traffic statistics
+func (s *Service) GetStats() ws.HubStats {
+	return s.hub.Stats()
 }
@@ -1,9 +1,12 @@
 package realtime
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,7 +16,12 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-redis/redismock/v9"
 	"github.com/gorilla/websocket"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/internal/geo"
+	"github.com/richxcame/ride-hailing/internal/maps"
+	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/redis"
+	"github.com/richxcame/ride-hailing/pkg/storage"
 	ws "github.com/richxcame/ride-hailing/pkg/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -324,6 +332,7 @@ func TestHandleChatMessage(t *testing.T) {
 			go hub.Run()
 
 			service := NewService(hub, db, redisClient, nil, zap.NewNop())
+			service.activeRide = noActiveRide
 
 			conn := createTestWebSocketConn(t)
 			client := ws.NewClient("user-123", conn, hub, "rider", zap.NewNop())
@@ -338,6 +347,15 @@ func TestHandleChatMessage(t *testing.T) {
 				// Use regex to match any value (chat message includes timestamp)
 				redisMock.Regexp().ExpectRPush("ride:chat:"+tt.clientRide, `.*`).SetVal(1)
 				redisMock.ExpectExpire("ride:chat:"+tt.clientRide, 24*time.Hour).SetVal(true)
+				redisMock.CustomMatch(func(expected, actual []interface{}) error {
+					// hset key <message_id>:sender sender
+					if len(actual) != 4 || actual[1] != "ride:chat:receipts:"+tt.clientRide ||
+						!strings.HasSuffix(actual[2].(string), ":sender") || actual[3] != "user-123" {
+						return fmt.Errorf("unexpected hset %v", actual)
+					}
+					return nil
+				}).ExpectHSet("ride:chat:receipts:"+tt.clientRide, "", "").SetVal(1)
+				redisMock.ExpectExpire("ride:chat:receipts:"+tt.clientRide, 24*time.Hour).SetVal(true)
 			}
 
 			// Execute
@@ -346,6 +364,10 @@ func TestHandleChatMessage(t *testing.T) {
 			// Verify
 			if tt.expectRedis {
 				assert.NoError(t, redisMock.ExpectationsWereMet())
+
+				sent := <-client.Send
+				assert.Equal(t, "chat_sent", sent.Type)
+				assert.NotEmpty(t, sent.Data["message_id"])
 			}
 		})
 	}
@@ -459,6 +481,7 @@ func TestHandleJoinRide(t *testing.T) {
 			go hub.Run()
 
 			service := NewService(hub, db, redisClient, nil, zap.NewNop())
+			service.activeRide = noActiveRide
 
 			conn := createTestWebSocketConn(t)
 			client := ws.NewClient("user-123", conn, hub, "rider", zap.NewNop())
@@ -556,6 +579,104 @@ func TestBroadcastRideUpdate(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 }
 
+// TestAutoJoinActiveRideOnConnect tests riders are put in their active ride's room on connect
+func TestAutoJoinActiveRideOnConnect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, _ := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+
+	service := NewService(hub, db, redisClient, nil, zap.NewNop())
+
+	mock.ExpectQuery("SELECT id FROM rides").
+		WithArgs("rider-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ride-1"))
+
+	rider := ws.NewClient("rider-1", createTestWebSocketConn(t), hub, ws.RoleRider, zap.NewNop())
+	hub.Register <- rider
+
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "joined_ride", msg.Type)
+	assert.Equal(t, "ride-1", msg.RideID)
+	assert.Equal(t, "ride:ride-1", msg.Data["room"])
+	assert.Equal(t, "ride-1", rider.GetRide())
+
+	members := service.GetRoomMembers("ride-1")
+	require.Len(t, members, 1)
+	assert.Equal(t, "rider-1", members[0].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestNoAutoJoinWithoutActiveRide tests connecting without an active ride joins nothing
+func TestNoAutoJoinWithoutActiveRide(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, _ := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+
+	NewService(hub, db, redisClient, nil, zap.NewNop())
+
+	mock.ExpectQuery("SELECT id FROM rides").
+		WithArgs("driver-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	driver := ws.NewClient("driver-1", createTestWebSocketConn(t), hub, ws.RoleDriver, zap.NewNop())
+	hub.Register <- driver
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, "", driver.GetRide())
+	assert.Empty(t, driver.Send)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestHandleWatchRide tests only dispatchers can observe rides
+func TestHandleWatchRide(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	outsider := ws.NewClient("rider-2", createTestWebSocketConn(t), service.hub, ws.RoleRider, zap.NewNop())
+	dispatcher := ws.NewClient("admin-1", createTestWebSocketConn(t), service.hub, ws.RoleDispatcher, zap.NewNop())
+	service.hub.Register <- outsider
+	service.hub.Register <- dispatcher
+	time.Sleep(10 * time.Millisecond)
+
+	service.handleWatchRide(outsider, &ws.Message{Type: "watch_ride", RideID: "ride-1"})
+	assert.Equal(t, "error", nextMessage(t, outsider).Type)
+
+	service.handleWatchRide(dispatcher, &ws.Message{Type: "watch_ride", RideID: "ride-1"})
+	service.handleWatchRide(dispatcher, &ws.Message{Type: "watch_ride", RideID: "ride-2"})
+	assert.Equal(t, "watching_ride", nextMessage(t, dispatcher).Type)
+	assert.Equal(t, "watching_ride", nextMessage(t, dispatcher).Type)
+
+	service.BroadcastRideUpdate("ride-1", map[string]interface{}{"status": "arrived"})
+
+	for _, c := range []*ws.Client{rider, driver, dispatcher} {
+		msg := nextMessage(t, c)
+		assert.Equal(t, "ride_update", msg.Type)
+		assert.Equal(t, "ride-1", msg.RideID)
+	}
+	assert.Empty(t, outsider.Send, "non-members should not receive ride updates")
+
+	members := service.GetRoomMembers("ride-1")
+	require.Len(t, members, 3)
+	assert.Equal(t, "admin-1", members[0].UserID)
+	assert.True(t, members[0].Observer)
+
+	service.handleUnwatchRide(dispatcher, &ws.Message{Type: "unwatch_ride", RideID: "ride-1"})
+	assert.Equal(t, "unwatched_ride", nextMessage(t, dispatcher).Type)
+	assert.Len(t, service.GetRoomMembers("ride-1"), 2)
+	assert.Len(t, service.GetRoomMembers("ride-2"), 1)
+}
+
 // TestBroadcastToUser tests broadcasting to specific user
 func TestBroadcastToUser(t *testing.T) {
 	// Setup
@@ -698,8 +819,8 @@ func TestGetStats(t *testing.T) {
 	stats := service.GetStats()
 
 	// Verify
-	assert.Equal(t, 2, stats["connected_clients"])
-	assert.Equal(t, 1, stats["active_rides"])
+	assert.Equal(t, 2, stats.ConnectedClients)
+	assert.Equal(t, 1, stats.ActiveRides)
 }
 
 // TestGetHub tests getting the hub instance
@@ -800,7 +921,7 @@ func TestConcurrentClientOperations(t *testing.T) {
 
 	// Verify stats
 	stats := service.GetStats()
-	assert.GreaterOrEqual(t, stats["connected_clients"].(int), 0)
+	assert.GreaterOrEqual(t, stats.ConnectedClients, 0)
 }
 
 // TestDatabaseError tests handling of database errors
@@ -817,6 +938,7 @@ func TestDatabaseError(t *testing.T) {
 	go hub.Run()
 
 	service := NewService(hub, db, redisClient, nil, zap.NewNop())
+	service.activeRide = noActiveRide
 
 	conn := createTestWebSocketConn(t)
 	client := ws.NewClient("user-123", conn, hub, "rider", zap.NewNop())
@@ -871,3 +993,1277 @@ func TestRedisError(t *testing.T) {
 	assert.Nil(t, history)
 	assert.NoError(t, redisMock.ExpectationsWereMet())
 }
+
+// TestDefaultPersistencePolicy tests which message types are durable by default
+func TestDefaultPersistencePolicy(t *testing.T) {
+	policy := DefaultPersistencePolicy()
+
+	assert.True(t, policy.ShouldPersist("chat_message"))
+	assert.True(t, policy.ShouldPersist("ride_status"))
+	assert.False(t, policy.ShouldPersist("typing"))
+	assert.False(t, policy.ShouldPersist("join_ride"))
+	assert.False(t, policy.ShouldPersist("leave_ride"))
+	assert.False(t, policy.ShouldPersist("location_update"))
+
+	var nilPolicy *PersistencePolicy
+	assert.False(t, nilPolicy.ShouldPersist("chat_message"))
+}
+
+// TestNewPersistencePolicy_TrimsTypes tests that a comma-separated list with
+// spaces and empty entries, as read from the environment, is cleaned up
+func TestNewPersistencePolicy_TrimsTypes(t *testing.T) {
+	policy := NewPersistencePolicy(strings.Split(" chat_message, ride_status,,", ",")...)
+
+	assert.True(t, policy.ShouldPersist("chat_message"))
+	assert.True(t, policy.ShouldPersist("ride_status"))
+	assert.False(t, policy.ShouldPersist(""))
+	assert.Len(t, policy.durable, 2)
+}
+
+// TestPersistMessage tests that only durable message types are written to history
+func TestPersistMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		msgType     string
+		key         string
+		wantPersist bool
+	}{
+		{
+			name:        "Chat message is persisted",
+			msgType:     "chat_message",
+			key:         "ride:chat:ride-123",
+			wantPersist: true,
+		},
+		{
+			name:        "Ride status is persisted",
+			msgType:     "ride_status",
+			key:         "ride:history:ride-123",
+			wantPersist: true,
+		},
+		{
+			name:        "Typing indicator is not persisted",
+			msgType:     "typing",
+			wantPersist: false,
+		},
+		{
+			name:        "Presence is not persisted",
+			msgType:     "join_ride",
+			wantPersist: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			redisDB, redisMock := redismock.NewClientMock()
+			redisClient := &redis.Client{Client: redisDB}
+
+			service := NewService(ws.NewHub(), db, redisClient, nil, zap.NewNop())
+
+			if tt.wantPersist {
+				redisMock.Regexp().ExpectRPush(tt.key, `.*`).SetVal(1)
+				redisMock.ExpectExpire(tt.key, 24*time.Hour).SetVal(true)
+			}
+
+			persisted := service.persistMessage(context.Background(), tt.msgType, "ride-123", map[string]interface{}{
+				"sender_id": "user-123",
+			})
+
+			assert.Equal(t, tt.wantPersist, persisted)
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestSetPersistencePolicy tests opting a message type out of persistence
+func TestSetPersistencePolicy(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, redisMock := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+
+	service := NewService(hub, db, redisClient, nil, zap.NewNop())
+	service.SetPersistencePolicy(NewPersistencePolicy("chat_message"))
+
+	conn := createTestWebSocketConn(t)
+	client := ws.NewClient("driver-123", conn, hub, "driver", zap.NewNop())
+
+	// No Redis expectations: the status update must not be persisted
+	service.handleRideStatus(client, &ws.Message{
+		Type:   "ride_status",
+		RideID: "ride-123",
+		Data: map[string]interface{}{
+			"status": "in_progress",
+		},
+	})
+
+	assert.False(t, service.persistMessage(context.Background(), "ride_status", "ride-123", nil))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+// setupPresenceRide creates a service with a rider and driver joined to the same ride
+// noActiveRide stands in for the active ride lookup on connect, so a test's
+// database expectations aren't raced by it
+func noActiveRide(userID string) (string, error) {
+	return "", sql.ErrNoRows
+}
+
+func setupPresenceRide(t *testing.T) (*Service, *ws.Client, *ws.Client) {
+	t.Helper()
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	redisDB, _ := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+
+	service := NewService(hub, db, redisClient, nil, zap.NewNop())
+
+	rider := ws.NewClient("rider-1", createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	driver := ws.NewClient("driver-1", createTestWebSocketConn(t), hub, "driver", zap.NewNop())
+
+	hub.Register <- rider
+	hub.Register <- driver
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(rider.ID, "ride-1")
+	hub.AddClientToRide(driver.ID, "ride-1")
+
+	return service, rider, driver
+}
+
+// nextMessage waits for the next message sent to a client
+func nextMessage(t *testing.T, client *ws.Client) *ws.Message {
+	t.Helper()
+	select {
+	case msg := <-client.Send:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("no message delivered to %s", client.ID)
+		return nil
+	}
+}
+
+func TestTypingStartAndStop(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start", RideID: "ride-1"})
+
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "typing_start", msg.Type)
+	assert.Equal(t, "ride-1", msg.RideID)
+	assert.Equal(t, "driver-1", msg.Data["user_id"])
+	assert.Equal(t, "driver", msg.Data["role"])
+	assert.Empty(t, driver.Send, "sender should not receive its own typing event")
+
+	// Refreshing an active indicator does not re-broadcast
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start"})
+	assert.Empty(t, rider.Send)
+
+	presence := service.GetRidePresence("ride-1")
+	require.Len(t, presence, 1)
+	assert.True(t, presence[0].IsTyping)
+
+	service.handleTypingStop(driver, &ws.Message{Type: "typing_stop"})
+	msg = nextMessage(t, rider)
+	assert.Equal(t, "typing_stop", msg.Type)
+	assert.Nil(t, msg.Data["expired"])
+
+	// Stopping again is a no-op
+	service.handleTypingStop(driver, &ws.Message{Type: "typing_stop"})
+	assert.Empty(t, rider.Send)
+}
+
+func TestTypingAutoExpires(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+	service.SetTypingTTL(20 * time.Millisecond)
+
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start"})
+	assert.Equal(t, "typing_start", nextMessage(t, rider).Type)
+
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "typing_stop", msg.Type)
+	assert.Equal(t, true, msg.Data["expired"])
+
+	presence := service.GetRidePresence("ride-1")
+	require.Len(t, presence, 1)
+	assert.False(t, presence[0].IsTyping)
+}
+
+func TestTypingStopCancelsExpiry(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+	service.SetTypingTTL(20 * time.Millisecond)
+
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start"})
+	nextMessage(t, rider)
+	service.handleTypingStop(driver, &ws.Message{Type: "typing_stop"})
+	nextMessage(t, rider)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, rider.Send, "a stopped indicator must not expire again")
+}
+
+func TestTypingRejectsOtherRide(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start", RideID: "ride-2"})
+
+	assert.Empty(t, rider.Send)
+	assert.Empty(t, service.GetRidePresence("ride-1"))
+	assert.Empty(t, service.GetRidePresence("ride-2"))
+}
+
+func TestHandlePresence(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	service.handlePresence(rider, &ws.Message{Type: "presence", Data: map[string]interface{}{"status": "away"}})
+
+	msg := nextMessage(t, driver)
+	assert.Equal(t, "presence", msg.Type)
+	assert.Equal(t, "rider-1", msg.Data["user_id"])
+	assert.Equal(t, PresenceAway, msg.Data["status"])
+	assert.Empty(t, rider.Send)
+
+	presence := service.GetRidePresence("ride-1")
+	require.Len(t, presence, 1)
+	assert.Equal(t, PresenceAway, presence[0].Status)
+
+	// Invalid statuses are ignored
+	service.handlePresence(rider, &ws.Message{Type: "presence", Data: map[string]interface{}{"status": "busy"}})
+	assert.Empty(t, driver.Send)
+}
+
+func TestPresenceOfflineClearsTyping(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	service.handleTypingStart(driver, &ws.Message{Type: "typing_start"})
+	nextMessage(t, rider)
+
+	service.handleDisconnect(driver)
+
+	assert.Equal(t, "typing_stop", nextMessage(t, rider).Type)
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "presence", msg.Type)
+	assert.Equal(t, PresenceOffline, msg.Data["status"])
+	assert.Empty(t, service.GetRidePresence("ride-1"))
+}
+
+func TestGetRidePresence_Ordered(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+
+	service.handlePresence(rider, &ws.Message{Type: "presence", Data: map[string]interface{}{"status": "online"}})
+	service.handlePresence(driver, &ws.Message{Type: "presence", Data: map[string]interface{}{"status": "online"}})
+
+	presence := service.GetRidePresence("ride-1")
+	require.Len(t, presence, 2)
+	assert.Equal(t, "driver-1", presence[0].UserID)
+	assert.Equal(t, "rider-1", presence[1].UserID)
+}
+
+// newOfflineTestService creates a service with a running hub and a mocked Redis
+func newOfflineTestService(t *testing.T) (*Service, redismock.ClientMock) {
+	t.Helper()
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	redisDB, redisMock := redismock.NewClientMock()
+	hub := ws.NewHub()
+	go hub.Run()
+
+	return NewService(hub, db, &redis.Client{Client: redisDB}, nil, zap.NewNop()), redisMock
+}
+
+func TestBroadcastToUser_BuffersWhenOffline(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	service.SetOfflineBuffer(2, time.Hour)
+
+	redisMock.ExpectIncr("user:msgseq:user-1").SetVal(7)
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		// zadd key score member
+		if len(actual) != 4 || actual[1] != "user:pending:user-1" || actual[2] != float64(7) ||
+			!strings.Contains(actual[3].(string), `"seq":7`) {
+			return fmt.Errorf("unexpected zadd %v", actual)
+		}
+		return nil
+	}).ExpectZAdd("user:pending:user-1", goredis.Z{}).SetVal(1)
+	redisMock.ExpectZRemRangeByRank("user:pending:user-1", 0, -3).SetVal(0)
+	redisMock.ExpectExpire("user:pending:user-1", time.Hour).SetVal(true)
+
+	service.BroadcastToUser("user-1", "notification", map[string]interface{}{"message": "hi"})
+
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestBroadcastToUser_OnlineBufferedUntilAcked(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	service.SetOfflineBuffer(2, time.Hour)
+
+	client := ws.NewClient("user-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	service.hub.Register <- client
+	time.Sleep(10 * time.Millisecond)
+
+	redisMock.ExpectIncr("user:msgseq:user-1").SetVal(3)
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		if len(actual) != 4 || actual[1] != "user:pending:user-1" || actual[2] != float64(3) {
+			return fmt.Errorf("unexpected zadd %v", actual)
+		}
+		return nil
+	}).ExpectZAdd("user:pending:user-1", goredis.Z{}).SetVal(1)
+	redisMock.ExpectZRemRangeByRank("user:pending:user-1", 0, -3).SetVal(0)
+	redisMock.ExpectExpire("user:pending:user-1", time.Hour).SetVal(true)
+
+	service.BroadcastToUser("user-1", "notification", map[string]interface{}{"message": "hi"})
+
+	msg := nextMessage(t, client)
+	assert.Equal(t, int64(3), msg.Seq)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestDeliverPendingMessages_SkipsAcknowledged(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	client := ws.NewClient("user-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+
+	encode := func(seq int64) string {
+		data, err := json.Marshal(&ws.Message{Type: "notification", UserID: "user-1", Seq: seq, Timestamp: time.Now()})
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	redisMock.ExpectGet("user:msgack:user-1").SetVal("4")
+	redisMock.ExpectZRange("user:pending:user-1", 0, -1).SetVal([]string{encode(4), encode(5), encode(6)})
+
+	assert.Equal(t, 2, service.DeliverPendingMessages(client))
+	assert.Equal(t, int64(5), nextMessage(t, client).Seq)
+	assert.Equal(t, int64(6), nextMessage(t, client).Seq)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestDeliverPendingMessages_NothingAcknowledged(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	client := ws.NewClient("user-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+
+	data, err := json.Marshal(&ws.Message{Type: "notification", Seq: 1, Timestamp: time.Now()})
+	require.NoError(t, err)
+
+	redisMock.ExpectGet("user:msgack:user-1").RedisNil()
+	redisMock.ExpectZRange("user:pending:user-1", 0, -1).SetVal([]string{string(data)})
+
+	assert.Equal(t, 1, service.DeliverPendingMessages(client))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestAcknowledgeMessages(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	ctx := context.Background()
+
+	keys := []string{"user:msgack:user-1", "user:pending:user-1"}
+	redisMock.ExpectEval(acknowledgeScript, keys, int64(9)).SetVal(int64(1))
+	require.NoError(t, service.AcknowledgeMessages(ctx, "user-1", 9))
+
+	// An older ack is left to the script, which never moves the mark backwards
+	redisMock.ExpectEval(acknowledgeScript, keys, int64(5)).SetVal(int64(0))
+	require.NoError(t, service.AcknowledgeMessages(ctx, "user-1", 5))
+
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestLocationThrottle_Distance(t *testing.T) {
+	throttle := NewLocationThrottle(25, time.Minute)
+	now := time.Now()
+
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now), "first update is always sent")
+	// ~11m north
+	assert.False(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7750, -122.4194, 0, now.Add(time.Second)))
+	// ~33m from the last broadcast
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7752, -122.4194, 0, now.Add(2*time.Second)))
+}
+
+func TestLocationThrottle_Interval(t *testing.T) {
+	throttle := NewLocationThrottle(25, 10*time.Second)
+	now := time.Now()
+
+	require.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now))
+	assert.False(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now.Add(9*time.Second)))
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now.Add(10*time.Second)))
+}
+
+func TestLocationThrottle_PoorAccuracy(t *testing.T) {
+	throttle := NewLocationThrottle(25, time.Minute)
+	now := time.Now()
+
+	require.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now))
+	// ~33m jump reported with a 50m accuracy radius is treated as noise
+	assert.False(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7752, -122.4194, 50, now.Add(time.Second)))
+	// ~67m is beyond the accuracy radius
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7755, -122.4194, 50, now.Add(2*time.Second)))
+}
+
+func TestLocationThrottle_NewRideAndForget(t *testing.T) {
+	throttle := NewLocationThrottle(25, time.Minute)
+	now := time.Now()
+
+	require.True(t, throttle.ShouldBroadcast("driver-1", "ride-1", 37.7749, -122.4194, 0, now))
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-2", 37.7749, -122.4194, 0, now), "a new ride is sent immediately")
+
+	throttle.Forget("driver-1")
+	assert.True(t, throttle.ShouldBroadcast("driver-1", "ride-2", 37.7749, -122.4194, 0, now))
+}
+
+func TestHandleLocationUpdate_Throttled(t *testing.T) {
+	service, rider, driver := setupPresenceRide(t)
+	service.SetLocationThrottle(25, time.Minute)
+
+	update := func(latitude float64) {
+		service.handleLocationUpdate(driver, &ws.Message{
+			Type: "location_update",
+			Data: map[string]interface{}{"latitude": latitude, "longitude": -122.4194},
+		})
+	}
+
+	update(37.7749)
+	assert.Equal(t, "driver_location", nextMessage(t, rider).Type)
+
+	update(37.7750)
+	assert.Empty(t, rider.Send, "small movement should not be broadcast")
+
+	update(37.7752)
+	msg := nextMessage(t, rider)
+	assert.Equal(t, 37.7752, msg.Data["latitude"])
+}
+
+// expectHSetNX matches an HSETNX of a receipt field, whatever time it records
+func expectHSetNX(redisMock redismock.ClientMock, key, field string, set bool) {
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		if len(actual) != 4 || actual[1] != key || actual[2] != field {
+			return fmt.Errorf("unexpected hsetnx %v", actual)
+		}
+		return nil
+	}).ExpectHSetNX(key, field, nil).SetVal(set)
+}
+
+func TestChatAck_RelaysDeliveryReceipt(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+
+	sender := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	recipient := ws.NewClient("driver-1", createTestWebSocketConn(t), service.hub, "driver", zap.NewNop())
+	service.hub.Register <- sender
+	service.hub.Register <- recipient
+	time.Sleep(10 * time.Millisecond)
+	service.hub.AddClientToRide(recipient.ID, "ride-1")
+
+	key := receiptsKey("ride-1")
+	redisMock.ExpectHGet(key, "msg-1:sender").SetVal("rider-1")
+	expectHSetNX(redisMock, key, "msg-1:delivered_at", true)
+	redisMock.ExpectExpire(key, historyTTL).SetVal(true)
+	redisMock.ExpectIncr("user:msgseq:rider-1").SetVal(1)
+
+	service.handleAck(recipient, &ws.Message{
+		Type:   "ack",
+		RideID: "ride-1",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	msg := nextMessage(t, sender)
+	assert.Equal(t, "delivery_receipt", msg.Type)
+	assert.Equal(t, "msg-1", msg.Data["message_id"])
+	assert.Equal(t, "driver-1", msg.Data["user_id"])
+	assert.NotNil(t, msg.Data["delivered_at"])
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+
+	// A repeated ack is not relayed again
+	redisMock.ExpectHGet(key, "msg-1:sender").SetVal("rider-1")
+	expectHSetNX(redisMock, key, "msg-1:delivered_at", false)
+
+	service.handleAck(recipient, &ws.Message{
+		Type:   "ack",
+		RideID: "ride-1",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+	assert.Empty(t, sender.Send)
+}
+
+func TestChatRead_ImpliesDelivery(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+
+	sender := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	recipient := ws.NewClient("driver-1", createTestWebSocketConn(t), service.hub, "driver", zap.NewNop())
+	recipient.SetRide("ride-1")
+	service.hub.Register <- sender
+	service.hub.Register <- recipient
+	time.Sleep(10 * time.Millisecond)
+
+	key := receiptsKey("ride-1")
+	redisMock.ExpectHGet(key, "msg-1:sender").SetVal("rider-1")
+	expectHSetNX(redisMock, key, "msg-1:delivered_at", true)
+	redisMock.ExpectExpire(key, historyTTL).SetVal(true)
+	redisMock.ExpectIncr("user:msgseq:rider-1").SetVal(1)
+	expectHSetNX(redisMock, key, "msg-1:read_at", true)
+	redisMock.ExpectExpire(key, historyTTL).SetVal(true)
+	redisMock.ExpectIncr("user:msgseq:rider-1").SetVal(2)
+
+	service.handleRead(recipient, &ws.Message{
+		Type: "read",
+		Data: map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	assert.Equal(t, "delivery_receipt", nextMessage(t, sender).Type)
+	read := nextMessage(t, sender)
+	assert.Equal(t, "read_receipt", read.Type)
+	assert.Equal(t, "ride-1", read.Data["ride_id"])
+	assert.NotNil(t, read.Data["read_at"])
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestChatAck_IgnoresOwnMessages(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	sender := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	sender.SetRide("ride-1")
+
+	redisMock.ExpectHGet(receiptsKey("ride-1"), "msg-1:sender").SetVal("rider-1")
+
+	service.handleAck(sender, &ws.Message{
+		Type:   "ack",
+		RideID: "ride-1",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+	assert.Empty(t, sender.Send)
+}
+
+func TestChatReceipts_RejectOtherRides(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+
+	sender := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	client := ws.NewClient("driver-1", createTestWebSocketConn(t), service.hub, "driver", zap.NewNop())
+	client.SetRide("ride-1")
+	service.hub.Register <- sender
+	service.hub.Register <- client
+	time.Sleep(10 * time.Millisecond)
+
+	// Would relay a receipt if the frames were let through
+	key := receiptsKey("ride-2")
+	redisMock.ExpectHGet(key, "msg-1:sender").SetVal("rider-1")
+	expectHSetNX(redisMock, key, "msg-1:delivered_at", true)
+
+	service.handleAck(client, &ws.Message{
+		Type:   "ack",
+		RideID: "ride-2",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+	service.handleRead(client, &ws.Message{
+		Type:   "read",
+		RideID: "ride-2",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	assert.Error(t, redisMock.ExpectationsWereMet(), "receipts for another ride must not be looked up")
+	assert.Empty(t, sender.Send)
+}
+
+func TestChatReceipts_RejectDispatcherObservers(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	service.db = db
+	service.activeRide = noActiveRide
+
+	sender := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	dispatcher := ws.NewClient("dispatcher-1", createTestWebSocketConn(t), service.hub, ws.RoleDispatcher, zap.NewNop())
+	service.hub.Register <- sender
+	service.hub.Register <- dispatcher
+	time.Sleep(10 * time.Millisecond)
+	require.True(t, service.hub.WatchRide(dispatcher.ID, "ride-1"))
+
+	// Would relay receipts if the frames were let through
+	key := receiptsKey("ride-1")
+	redisMock.ExpectHGet(key, "msg-1:sender").SetVal("rider-1")
+	expectHSetNX(redisMock, key, "msg-1:delivered_at", true)
+	for i := 0; i < 2; i++ {
+		dbMock.ExpectQuery("SELECT COUNT").
+			WithArgs("ride-1", "dispatcher-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	service.handleAck(dispatcher, &ws.Message{
+		Type:   "ack",
+		RideID: "ride-1",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+	service.handleRead(dispatcher, &ws.Message{
+		Type:   "read",
+		RideID: "ride-1",
+		Data:   map[string]interface{}{"message_id": "msg-1"},
+	})
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.Error(t, redisMock.ExpectationsWereMet(), "an observer's receipts must not be recorded")
+	assert.Empty(t, sender.Send)
+}
+
+func TestGetChatHistory_IncludesReceipts(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+
+	record := func(id string) string {
+		data, _ := json.Marshal(map[string]interface{}{
+			"message_id": id,
+			"sender_id":  "rider-1",
+			"message":    "hi",
+		})
+		return string(data)
+	}
+
+	redisMock.ExpectLRange("ride:chat:ride-1", 0, -1).
+		SetVal([]string{record("msg-1"), record("msg-2"), record("msg-3")})
+	redisMock.ExpectHGetAll(receiptsKey("ride-1")).SetVal(map[string]string{
+		"msg-1:sender":       "rider-1",
+		"msg-1:delivered_at": "100",
+		"msg-1:read_at":      "120",
+		"msg-2:sender":       "rider-1",
+		"msg-2:delivered_at": "110",
+		"msg-3:sender":       "rider-1",
+	})
+
+	history, err := service.GetChatHistory("ride-1")
+
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, ChatStatusRead, history[0]["status"])
+	assert.Equal(t, int64(120), history[0]["read_at"])
+	assert.Equal(t, ChatStatusDelivered, history[1]["status"])
+	assert.Equal(t, int64(110), history[1]["delivered_at"])
+	assert.Equal(t, ChatStatusSent, history[2]["status"])
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestRedeliverChat_SendsUndeliveredMessages(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	driver := ws.NewClient("driver-1", createTestWebSocketConn(t), service.hub, "driver", zap.NewNop())
+
+	record := func(id, sender string) string {
+		data, _ := json.Marshal(map[string]interface{}{
+			"message_id": id,
+			"sender_id":  sender,
+			"message":    "message " + id,
+			"timestamp":  1700000000,
+		})
+		return string(data)
+	}
+
+	redisMock.ExpectLRange("ride:chat:ride-1", 0, -1).SetVal([]string{
+		record("msg-1", "rider-1"),
+		record("msg-2", "driver-1"),
+		record("msg-3", "rider-1"),
+	})
+	redisMock.ExpectHGetAll(receiptsKey("ride-1")).SetVal(map[string]string{
+		"msg-1:delivered_at": "100",
+	})
+
+	sent := service.redeliverChat(context.Background(), driver, "ride-1")
+
+	assert.Equal(t, 1, sent)
+	msg := nextMessage(t, driver)
+	assert.Equal(t, "chat_message", msg.Type)
+	assert.Equal(t, "msg-3", msg.Data["message_id"])
+	assert.Equal(t, int64(1700000000), msg.Timestamp.Unix())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+// fakeAttachmentStorage keeps uploaded chat attachments in memory
+type fakeAttachmentStorage struct {
+	files   map[string][]byte
+	deleted []string
+}
+
+func newFakeAttachmentStorage() *fakeAttachmentStorage {
+	return &fakeAttachmentStorage{files: make(map[string][]byte)}
+}
+
+func (f *fakeAttachmentStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*storage.UploadResult, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	f.files[key] = data
+	return &storage.UploadResult{Key: key, Size: int64(len(data))}, nil
+}
+
+func (f *fakeAttachmentStorage) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*storage.UploadResult, error) {
+	return f.Upload(ctx, key, reader, -1, contentType)
+}
+
+func (f *fakeAttachmentStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := f.files[key]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeAttachmentStorage) Delete(ctx context.Context, key string) error {
+	delete(f.files, key)
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *fakeAttachmentStorage) GetURL(key string) string {
+	return "https://files.example.com/" + key
+}
+
+func (f *fakeAttachmentStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (*storage.PresignedURLResult, error) {
+	return &storage.PresignedURLResult{URL: "https://upload.example.com/" + key, Method: http.MethodPut, ExpiresAt: time.Now().Add(expiresIn)}, nil
+}
+
+func (f *fakeAttachmentStorage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (*storage.PresignedURLResult, error) {
+	return &storage.PresignedURLResult{URL: "https://download.example.com/" + key, Method: http.MethodGet, ExpiresAt: time.Now().Add(expiresIn)}, nil
+}
+
+func (f *fakeAttachmentStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := f.files[key]
+	return ok, nil
+}
+
+func (f *fakeAttachmentStorage) Copy(ctx context.Context, sourceKey, destKey string) error {
+	f.files[destKey] = f.files[sourceKey]
+	return nil
+}
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func pendingAttachmentRecord(senderID, rideID string) string {
+	data, _ := json.Marshal(pendingAttachment{SenderID: senderID, RideID: rideID, ContentType: "image/png"})
+	return string(data)
+}
+
+// setupAttachmentRide puts a rider and driver in ride-1 with attachments stored in memory
+func setupAttachmentRide(t *testing.T) (*Service, redismock.ClientMock, *fakeAttachmentStorage, *ws.Client, *ws.Client) {
+	t.Helper()
+
+	service, redisMock := newOfflineTestService(t)
+	store := newFakeAttachmentStorage()
+	service.SetAttachmentStorage(store, AttachmentPolicy{MaxBytes: 64})
+
+	rider := ws.NewClient("rider-1", createTestWebSocketConn(t), service.hub, "rider", zap.NewNop())
+	driver := ws.NewClient("driver-1", createTestWebSocketConn(t), service.hub, "driver", zap.NewNop())
+	service.hub.Register <- rider
+	service.hub.Register <- driver
+	time.Sleep(10 * time.Millisecond)
+	service.hub.AddClientToRide(rider.ID, "ride-1")
+	service.hub.AddClientToRide(driver.ID, "ride-1")
+
+	return service, redisMock, store, rider, driver
+}
+
+func TestRequestAttachmentUpload(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	service.SetAttachmentStorage(newFakeAttachmentStorage(), AttachmentPolicy{MaxBytes: 1024})
+
+	redisMock.Regexp().ExpectSet(`ride:chat:attachment:chat/ride-1/rider-1/.+\.png`,
+		pendingAttachmentRecord("rider-1", "ride-1"), pendingAttachmentTTL).SetVal("OK")
+
+	upload, err := service.RequestAttachmentUpload(context.Background(), "ride-1", "rider-1", "image/png", 512)
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upload.FileKey, "chat/ride-1/rider-1/"))
+	assert.True(t, strings.HasSuffix(upload.FileKey, ".png"))
+	assert.Equal(t, http.MethodPut, upload.Upload.Method)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestRequestAttachmentUpload_Rejected(t *testing.T) {
+	service, _ := newOfflineTestService(t)
+
+	_, err := service.RequestAttachmentUpload(context.Background(), "ride-1", "rider-1", "image/png", 512)
+	assert.Error(t, err, "attachments are disabled without storage")
+
+	service.SetAttachmentStorage(newFakeAttachmentStorage(), AttachmentPolicy{MaxBytes: 1024})
+
+	_, err = service.RequestAttachmentUpload(context.Background(), "ride-1", "rider-1", "application/pdf", 512)
+	assert.Equal(t, common.ErrCodeUnsupportedFileType, common.ErrorCodeOf(err))
+
+	_, err = service.RequestAttachmentUpload(context.Background(), "ride-1", "rider-1", "image/png", 2048)
+	assert.Equal(t, common.ErrCodeFileTooLarge, common.ErrorCodeOf(err))
+}
+
+func TestHandleChatMessage_WithAttachment(t *testing.T) {
+	service, redisMock, store, rider, driver := setupAttachmentRide(t)
+	fileKey := "chat/ride-1/rider-1/photo.png"
+	store.files[fileKey] = pngHeader
+
+	redisMock.ExpectGet(pendingAttachmentKey(fileKey)).SetVal(pendingAttachmentRecord("rider-1", "ride-1"))
+	redisMock.ExpectDel(pendingAttachmentKey(fileKey)).SetVal(1)
+
+	service.handleChatMessage(rider, &ws.Message{
+		Type: "chat_message",
+		Data: map[string]interface{}{"file_key": fileKey},
+	})
+
+	assert.Equal(t, "chat_sent", nextMessage(t, rider).Type)
+	msg := nextMessage(t, driver)
+	assert.Equal(t, "chat_message", msg.Type)
+	attachment, ok := msg.Data["attachment"].(*ChatAttachment)
+	require.True(t, ok)
+	assert.Equal(t, fileKey, attachment.FileKey)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(pngHeader)), attachment.SizeBytes)
+	assert.Equal(t, "https://download.example.com/"+fileKey, attachment.DownloadURL)
+}
+
+func TestHandleChatMessage_RejectsAttachmentFromAnotherSender(t *testing.T) {
+	service, redisMock, store, rider, driver := setupAttachmentRide(t)
+	fileKey := "chat/ride-1/driver-1/photo.png"
+	store.files[fileKey] = pngHeader
+
+	redisMock.ExpectGet(pendingAttachmentKey(fileKey)).SetVal(pendingAttachmentRecord("driver-1", "ride-1"))
+
+	service.handleChatMessage(rider, &ws.Message{
+		Type: "chat_message",
+		Data: map[string]interface{}{"file_key": fileKey, "client_message_id": "c-1"},
+	})
+
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "error", msg.Type)
+	assert.Equal(t, "c-1", msg.Data["client_message_id"])
+	assert.Empty(t, driver.Send)
+	assert.Contains(t, store.files, fileKey, "another sender's upload is left alone")
+}
+
+func TestHandleChatMessage_RejectsInvalidAttachmentContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"not an image", []byte("<html><body>hello</body></html>")},
+		{"over the size limit", append(append([]byte{}, pngHeader...), make([]byte, 64)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, redisMock, store, rider, driver := setupAttachmentRide(t)
+			fileKey := "chat/ride-1/rider-1/photo.png"
+			store.files[fileKey] = tt.content
+
+			redisMock.ExpectGet(pendingAttachmentKey(fileKey)).SetVal(pendingAttachmentRecord("rider-1", "ride-1"))
+			redisMock.ExpectDel(pendingAttachmentKey(fileKey)).SetVal(1)
+
+			service.handleChatMessage(rider, &ws.Message{
+				Type: "chat_message",
+				Data: map[string]interface{}{"file_key": fileKey},
+			})
+
+			assert.Equal(t, "error", nextMessage(t, rider).Type)
+			assert.Empty(t, driver.Send)
+			assert.Equal(t, []string{fileKey}, store.deleted)
+		})
+	}
+}
+
+func TestHandleChatMessage_RejectsAttachmentNotUploaded(t *testing.T) {
+	service, redisMock, _, rider, driver := setupAttachmentRide(t)
+	fileKey := "chat/ride-1/rider-1/photo.png"
+
+	redisMock.ExpectGet(pendingAttachmentKey(fileKey)).SetVal(pendingAttachmentRecord("rider-1", "ride-1"))
+
+	service.handleChatMessage(rider, &ws.Message{
+		Type: "chat_message",
+		Data: map[string]interface{}{"file_key": fileKey},
+	})
+
+	assert.Equal(t, "error", nextMessage(t, rider).Type)
+	assert.Empty(t, driver.Send)
+}
+
+func TestGetChatHistory_IncludesAttachmentDownloadURL(t *testing.T) {
+	service, redisMock := newOfflineTestService(t)
+	service.SetAttachmentStorage(newFakeAttachmentStorage(), DefaultAttachmentPolicy())
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"sender_id": "rider-1",
+		"attachment": &ChatAttachment{
+			FileKey:     "chat/ride-1/rider-1/photo.png",
+			ContentType: "image/png",
+			SizeBytes:   2048,
+		},
+	})
+	redisMock.ExpectLRange("ride:chat:ride-1", 0, -1).SetVal([]string{string(data)})
+
+	history, err := service.GetChatHistory("ride-1")
+
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	attachment, ok := history[0]["attachment"].(*ChatAttachment)
+	require.True(t, ok)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(2048), attachment.SizeBytes)
+	assert.Equal(t, "https://download.example.com/chat/ride-1/rider-1/photo.png", attachment.DownloadURL)
+	require.NotNil(t, attachment.DownloadURLExpiresAt)
+}
+
+// setupETARide puts a driver and rider in ride-1 and returns the service with
+// its database mock
+func setupETARide(t *testing.T) (*Service, sqlmock.Sqlmock, *ws.Client) {
+	t.Helper()
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	redisDB, _ := redismock.NewClientMock()
+	hub := ws.NewHub()
+	go hub.Run()
+
+	service := NewService(hub, db, &redis.Client{Client: redisDB}, nil, zap.NewNop())
+	service.activeRide = noActiveRide
+
+	rider := ws.NewClient("rider-1", createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	hub.Register <- rider
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(rider.ID, "ride-1")
+
+	return service, dbMock, rider
+}
+
+// expectRide returns the ride's status, a pickup at the origin and a dropoff
+// about 11km north of it
+func expectRide(dbMock sqlmock.Sqlmock, status string) {
+	dbMock.ExpectQuery("SELECT status, pickup_latitude").
+		WithArgs("ride-1", "driver-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "pickup_latitude", "pickup_longitude", "dropoff_latitude", "dropoff_longitude"}).
+			AddRow(status, 0.0, 0.0, 0.1, 0.0))
+}
+
+func TestUpdateDriverETA_BroadcastsToPickupThenDropoff(t *testing.T) {
+	service, dbMock, rider := setupETARide(t)
+
+	// About 1.1km from the pickup, at the average speed of 30km/h
+	expectRide(dbMock, "accepted")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", -0.01, 0, 0)
+
+	msg := nextMessage(t, rider)
+	assert.Equal(t, "eta_update", msg.Type)
+	assert.Equal(t, "pickup", msg.Data["destination"])
+	assert.InDelta(t, 134, msg.Data["eta_seconds"], 2)
+	assert.Equal(t, 1.11, msg.Data["distance_km"])
+
+	expectRide(dbMock, "in_progress")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", 0, 0, 60)
+
+	msg = nextMessage(t, rider)
+	assert.Equal(t, "dropoff", msg.Data["destination"])
+	assert.InDelta(t, 667, msg.Data["eta_seconds"], 2)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestUpdateDriverETA_SkipsSmallChanges(t *testing.T) {
+	service, dbMock, rider := setupETARide(t)
+	service.SetETAMinChange(time.Minute)
+
+	expectRide(dbMock, "accepted")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", -0.05, 0, 0)
+	nextMessage(t, rider)
+
+	// About 13s closer
+	expectRide(dbMock, "accepted")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", -0.049, 0, 0)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, rider.Send, "a small ETA change should not be broadcast")
+
+	// About 80s closer than the last broadcast
+	expectRide(dbMock, "accepted")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", -0.044, 0, 0)
+	assert.Equal(t, "eta_update", nextMessage(t, rider).Type)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestUpdateDriverETA_InactiveRide(t *testing.T) {
+	service, dbMock, rider := setupETARide(t)
+
+	expectRide(dbMock, "completed")
+	service.UpdateDriverETA(context.Background(), "driver-1", "ride-1", -0.01, 0, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, rider.Send)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+// failingETAProvider is a maps provider that is always down
+type failingETAProvider struct{}
+
+func (failingETAProvider) GetETA(ctx context.Context, originLatitude, originLongitude, destLatitude, destLongitude float64) (*maps.ETAResult, error) {
+	return nil, fmt.Errorf("provider unavailable")
+}
+
+func (failingETAProvider) GetTrafficLevel(ctx context.Context, latitude, longitude float64) (maps.TrafficLevel, error) {
+	return maps.TrafficModerate, nil
+}
+
+func TestRoutingETAEstimator(t *testing.T) {
+	ctx := context.Background()
+
+	routed, err := NewRoutingETAEstimator(maps.NewHaversineETAProvider(60)).EstimateETA(ctx, 0, 0, 0.1, 0, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, 11.1, routed.DistanceKm, 0.1)
+	assert.InDelta(t, (11 * time.Minute).Seconds(), routed.Duration.Seconds(), 15)
+
+	// A failing provider falls back to the straight line at the average speed
+	fallback, err := NewRoutingETAEstimator(failingETAProvider{}).EstimateETA(ctx, 0, 0, 0.1, 0, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, (22 * time.Minute).Seconds(), fallback.Duration.Seconds(), 30)
+}
+
+// createRecordingWebSocketConn creates a test WebSocket connection whose peer
+// passes on every message written to it
+func createRecordingWebSocketConn(t *testing.T) (*websocket.Conn, <-chan *ws.Message) {
+	t.Helper()
+
+	received := make(chan *ws.Message, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg ws.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			received <- &msg
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	return conn, received
+}
+
+// startWritingClient connects a client of the role to the hub and starts writing
+// its messages to a recording peer
+func startWritingClient(t *testing.T, hub *ws.Hub, id, role string) (*ws.Client, <-chan *ws.Message) {
+	t.Helper()
+
+	conn, received := createRecordingWebSocketConn(t)
+	client := ws.NewClient(id, conn, hub, role, zap.NewNop())
+	hub.Register <- client
+	require.Eventually(t, func() bool {
+		_, ok := hub.GetClient(id)
+		return ok
+	}, time.Second, time.Millisecond)
+	go client.WritePump()
+	return client, received
+}
+
+func nextWritten(t *testing.T, received <-chan *ws.Message) *ws.Message {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message written")
+		return nil
+	}
+}
+
+func TestHandleEmergency(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, redisMock := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+	service := NewService(hub, db, redisClient, geo.NewService(redisClient), zap.NewNop())
+	service.activeRide = noActiveRide
+
+	driverID := "0b5c3a5e-3f4e-4c55-9a43-6f2f9d0a1b2c"
+	rider, riderReceived := startWritingClient(t, hub, "rider-1", ws.RoleRider)
+	rider.SetRide("ride-1")
+	_, dispatcherReceived := startWritingClient(t, hub, "dispatcher-1", ws.RoleDispatcher)
+	require.Equal(t, 1, hub.GetDispatcherCount())
+
+	dbMock.ExpectQuery("SELECT driver_id FROM rides").
+		WithArgs("ride-1", "rider-1").
+		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow(driverID))
+	redisMock.ExpectGet("driver:location:" + driverID).
+		SetVal(`{"latitude":37.77,"longitude":-122.41,"timestamp":"2026-01-02T03:04:05Z"}`)
+	redisMock.ExpectGet("driver:eta:" + driverID).RedisNil()
+	dbMock.ExpectExec("INSERT INTO ride_emergencies").
+		WithArgs(sqlmock.AnyArg(), "ride-1", "rider-1", ws.RoleRider, driverID, "help",
+			37.77, -122.41, sqlmock.AnyArg(), 37.7, -122.4, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	redisMock.Regexp().ExpectRPush("ride:history:ride-1", `.*"type":"emergency".*`).SetVal(1)
+	redisMock.ExpectExpire("ride:history:ride-1", 24*time.Hour).SetVal(true)
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		// hset emergencies:active <emergency_id> <emergency>
+		if len(actual) != 4 || actual[1] != activeEmergenciesKey ||
+			!strings.Contains(actual[3].(string), `"ride_id":"ride-1"`) {
+			return fmt.Errorf("unexpected hset %v", actual)
+		}
+		return nil
+	}).ExpectHSet(activeEmergenciesKey, "", "").SetVal(1)
+
+	// The emergency message itself carries no ride; it comes from the client's room
+	service.handleEmergency(rider, &ws.Message{
+		Type: "emergency",
+		Data: map[string]interface{}{"message": "help", "latitude": 37.7, "longitude": -122.4},
+	})
+
+	alert := nextWritten(t, dispatcherReceived)
+	assert.Equal(t, "emergency_alert", alert.Type)
+	assert.Equal(t, "ride-1", alert.RideID)
+	assert.Equal(t, "rider-1", alert.Data["sender_id"])
+	assert.Equal(t, driverID, alert.Data["driver_id"])
+	assert.Equal(t, "help", alert.Data["message"])
+	location := alert.Data["location"].(map[string]interface{})
+	assert.Equal(t, 37.77, location["latitude"])
+	assert.Equal(t, -122.41, location["longitude"])
+
+	ack := nextWritten(t, riderReceived)
+	assert.Equal(t, "emergency_received", ack.Type)
+	assert.Equal(t, alert.Data["emergency_id"], ack.Data["emergency_id"])
+	assert.Equal(t, float64(1), ack.Data["dispatchers_notified"])
+
+	// Pressing SOS again straight away is acknowledged as the same emergency
+	dbMock.ExpectQuery("SELECT driver_id FROM rides").
+		WithArgs("ride-1", "rider-1").
+		WillReturnRows(sqlmock.NewRows([]string{"driver_id"}).AddRow(driverID))
+	service.handleEmergency(rider, &ws.Message{Type: "emergency"})
+
+	repeat := nextWritten(t, riderReceived)
+	assert.Equal(t, "emergency_received", repeat.Type)
+	assert.Equal(t, alert.Data["emergency_id"], repeat.Data["emergency_id"])
+	assert.Equal(t, true, repeat.Data["duplicate"])
+	select {
+	case msg := <-dispatcherReceived:
+		t.Fatalf("dispatcher alerted again with %s", msg.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestEmergencyCooldown(t *testing.T) {
+	cooldown := newEmergencyCooldown(30 * time.Second)
+	now := time.Now()
+
+	id, ok := cooldown.claim("rider-1", "ride-1", "e1", now)
+	assert.True(t, ok)
+	assert.Equal(t, "e1", id)
+
+	id, ok = cooldown.claim("rider-1", "ride-1", "e2", now.Add(10*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, "e1", id)
+
+	_, ok = cooldown.claim("driver-1", "ride-1", "e3", now.Add(10*time.Second))
+	assert.True(t, ok, "the other side of the ride has its own cooldown")
+
+	id, ok = cooldown.claim("rider-1", "ride-1", "e4", now.Add(31*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, "e4", id)
+}
+
+func TestHandleEmergency_RequiresActiveRide(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, redisMock := redismock.NewClientMock()
+	redisClient := &redis.Client{Client: redisDB}
+
+	hub := ws.NewHub()
+	go hub.Run()
+	service := NewService(hub, db, redisClient, nil, zap.NewNop())
+	service.activeRide = noActiveRide
+
+	rider, riderReceived := startWritingClient(t, hub, "rider-1", ws.RoleRider)
+	_, dispatcherReceived := startWritingClient(t, hub, "dispatcher-1", ws.RoleDispatcher)
+
+	dbMock.ExpectQuery("SELECT driver_id FROM rides").
+		WithArgs("ride-9", "rider-1").
+		WillReturnError(sql.ErrNoRows)
+
+	service.handleEmergency(rider, &ws.Message{Type: "emergency", RideID: "ride-9"})
+
+	assert.Equal(t, "error", nextWritten(t, riderReceived).Type)
+	select {
+	case msg := <-dispatcherReceived:
+		t.Fatalf("dispatcher alerted for %s outside an active ride", msg.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestGetActiveEmergencies(t *testing.T) {
+	redisDB, redisMock := redismock.NewClientMock()
+	service := NewService(ws.NewHub(), nil, &redis.Client{Client: redisDB}, nil, zap.NewNop())
+
+	redisMock.ExpectHGetAll(activeEmergenciesKey).SetVal(map[string]string{
+		"e2":  `{"id":"e2","ride_id":"ride-2","raised_at":"2026-01-02T03:05:00Z"}`,
+		"e1":  `{"id":"e1","ride_id":"ride-1","raised_at":"2026-01-02T03:04:00Z"}`,
+		"bad": `not json`,
+	})
+
+	emergencies, err := service.GetActiveEmergencies(context.Background())
+	require.NoError(t, err)
+	require.Len(t, emergencies, 2)
+	assert.Equal(t, "e1", emergencies[0].ID)
+	assert.Equal(t, "e2", emergencies[1].ID)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestResolveEmergency(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, redisMock := redismock.NewClientMock()
+	hub := ws.NewHub()
+	go hub.Run()
+	service := NewService(hub, db, &redis.Client{Client: redisDB}, nil, zap.NewNop())
+	_, dispatcherReceived := startWritingClient(t, hub, "dispatcher-1", ws.RoleDispatcher)
+
+	redisMock.ExpectHGet(activeEmergenciesKey, "missing").RedisNil()
+	err = service.ResolveEmergency(context.Background(), "missing", "admin-1")
+	appErr, ok := common.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, appErr.Code)
+
+	redisMock.ExpectHGet(activeEmergenciesKey, "e1").SetVal(`{"id":"e1"}`)
+	dbMock.ExpectExec("UPDATE ride_emergencies SET resolved_at").
+		WithArgs("e1", sqlmock.AnyArg(), "admin-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	redisMock.ExpectHDel(activeEmergenciesKey, "e1").SetVal(1)
+	require.NoError(t, service.ResolveEmergency(context.Background(), "e1", "admin-1"))
+
+	resolved := nextWritten(t, dispatcherReceived)
+	assert.Equal(t, "emergency_resolved", resolved.Type)
+	assert.Equal(t, "e1", resolved.Data["emergency_id"])
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
@@ -162,6 +162,14 @@ func (m *mockStorage) Upload(ctx context.Context, key string, reader io.Reader,
 	return args.Get(0).(*storage.UploadResult), args.Error(1)
 }
 
+func (m *mockStorage) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*storage.UploadResult, error) {
+	args := m.Called(ctx, key, reader, contentType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.UploadResult), args.Error(1)
+}
+
 func (m *mockStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	args := m.Called(ctx, key)
 	if args.Get(0) == nil {
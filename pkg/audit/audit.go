@@ -0,0 +1,69 @@
+// Package audit records who changed what through admin and reviewer actions.
+// Services record an Entry for every state-changing admin operation, apart
+// from any history they keep of their own.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+)
+
+// Entry is one audited change: who made it, what they did to which entity, and
+// a summary of the entity before and after
+type Entry struct {
+	ActorID    uuid.UUID // Admin or reviewer; taken from the context when unset
+	System     bool      // Automated change no admin made; recorded without an actor when there is none
+	Action     string    // e.g. document.approve, loyalty.points_adjusted
+	TargetType string
+	TargetID   string
+	Before     map[string]interface{}
+	After      map[string]interface{}
+	Timestamp  time.Time // Now when unset
+}
+
+// Logger records audit entries. Recording never fails the caller's operation;
+// an entry that can't be written is reported loudly by the implementation.
+type Logger interface {
+	Record(ctx context.Context, entry Entry)
+}
+
+// nopLogger discards entries
+type nopLogger struct{}
+
+func (nopLogger) Record(context.Context, Entry) {}
+
+// Nop returns a logger that discards every entry, for services without an
+// audit sink and for tests
+func Nop() Logger {
+	return nopLogger{}
+}
+
+type actorContextKey struct{}
+
+// WithActor returns a context carrying the admin or reviewer acting in it
+func WithActor(ctx context.Context, actorID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorID)
+}
+
+// ActorFromContext returns the admin or reviewer acting in ctx, if any.
+// Operations without one, such as scheduled jobs, aren't admin actions.
+func ActorFromContext(ctx context.Context) (uuid.UUID, bool) {
+	actorID, ok := ctx.Value(actorContextKey{}).(uuid.UUID)
+	return actorID, ok && actorID != uuid.Nil
+}
+
+// Actor puts the authenticated user into the request context as the actor, so
+// services can attribute audit entries without an actor parameter. It belongs
+// after the auth middleware on admin routes.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, err := middleware.GetUserID(c); err == nil {
+			c.Request = c.Request.WithContext(WithActor(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorFromContext(t *testing.T) {
+	actorID := uuid.New()
+
+	got, ok := ActorFromContext(WithActor(context.Background(), actorID))
+	assert.True(t, ok)
+	assert.Equal(t, actorID, got)
+
+	_, ok = ActorFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = ActorFromContext(WithActor(context.Background(), uuid.Nil))
+	assert.False(t, ok, "a nil actor is no actor")
+}
+
+func setupActorRouter(userID *uuid.UUID) (*gin.Engine, *uuid.UUID, *bool) {
+	gin.SetMode(gin.TestMode)
+	var seen uuid.UUID
+	var found bool
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != nil {
+			c.Set("user_id", *userID)
+		}
+		c.Next()
+	})
+	r.Use(Actor())
+	r.GET("/", func(c *gin.Context) {
+		seen, found = ActorFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	return r, &seen, &found
+}
+
+func TestActor_PutsAuthenticatedUserInContext(t *testing.T) {
+	userID := uuid.New()
+	r, seen, found := setupActorRouter(&userID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *found)
+	assert.Equal(t, userID, *seen)
+}
+
+func TestActor_WithoutAuthenticatedUser(t *testing.T) {
+	r, _, found := setupActorRouter(nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, *found)
+}
+
+func TestNop_DiscardsEntries(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Nop().Record(context.Background(), Entry{Action: "document.approve"})
+	})
+}
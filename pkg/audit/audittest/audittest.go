@@ -0,0 +1,31 @@
+// Package audittest provides an audit.Logger for tests that check what a
+// service audits.
+package audittest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/richxcame/ride-hailing/pkg/audit"
+)
+
+// Recorder is an audit.Logger that keeps the entries it is given. It is safe
+// for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+// Record keeps entry
+func (r *Recorder) Record(ctx context.Context, entry audit.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns the entries recorded so far, oldest first
+func (r *Recorder) Entries() []audit.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]audit.Entry(nil), r.entries...)
+}
@@ -1,8 +1,11 @@
 package common
 
 import (
+	"context"
 	"errors"
 	"net/http"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
 )
 
 // Common error types
@@ -49,6 +52,36 @@ const (
 	ErrCodeDriverUnauthorized = "DRIVER_UNAUTHORIZED"
 	ErrCodeDriverUnavailable  = "DRIVER_UNAVAILABLE"
 
+	// Loyalty errors
+	ErrCodeRedemptionUsed      = "REDEMPTION_ALREADY_USED"
+	ErrCodeRedemptionExpired   = "REDEMPTION_EXPIRED"
+	ErrCodeRedemptionNotActive = "REDEMPTION_NOT_ACTIVE"
+	ErrCodeInsufficientPoints  = "LOYALTY_INSUFFICIENT_POINTS"
+	ErrCodeRewardUnavailable   = "LOYALTY_REWARD_UNAVAILABLE"
+	ErrCodeRewardOutOfStock    = "LOYALTY_REWARD_OUT_OF_STOCK"
+	ErrCodeRedemptionLimit     = "LOYALTY_REDEMPTION_LIMIT_REACHED"
+	ErrCodeTierRequired        = "LOYALTY_TIER_REQUIRED"
+	ErrCodeAdjustmentLimit     = "LOYALTY_ADJUSTMENT_LIMIT_EXCEEDED"
+
+	// Document errors
+	ErrCodeFileTooLarge           = "DOCUMENT_FILE_TOO_LARGE"
+	ErrCodeUnsupportedFileType    = "DOCUMENT_UNSUPPORTED_FILE_TYPE"
+	ErrCodeFileContentMismatch    = "DOCUMENT_CONTENT_MISMATCH"
+	ErrCodeDocumentExpired        = "DOCUMENT_EXPIRED"
+	ErrCodeDocumentNotReviewable  = "DOCUMENT_NOT_REVIEWABLE"
+	ErrCodeResubmissionNotAllowed = "DOCUMENT_RESUBMISSION_NOT_ALLOWED"
+	ErrCodeDocumentInUse          = "DOCUMENT_IN_USE"
+	ErrCodeDocumentClaimed        = "DOCUMENT_CLAIMED"
+	ErrCodeDuplicateFile          = "DOCUMENT_DUPLICATE_FILE"
+	ErrCodeInvalidPDF             = "DOCUMENT_INVALID_PDF"
+	ErrCodeEncryptedPDF           = "DOCUMENT_ENCRYPTED_PDF"
+	ErrCodeTooManyPages           = "DOCUMENT_TOO_MANY_PAGES"
+	ErrCodeOCRJobClosed           = "DOCUMENT_OCR_JOB_CLOSED"
+
+	// Idempotency errors
+	ErrCodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+
 	// System errors
 	ErrCodeInternal           = "INTERNAL_ERROR"
 	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
@@ -60,6 +93,7 @@ type AppError struct {
 	Code      int    `json:"code"`
 	ErrorCode string `json:"error_code,omitempty"`
 	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"` // Request the error was raised in, for following it through the logs
 	Err       error  `json:"-"`
 }
 
@@ -71,6 +105,48 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error, so errors.Is and errors.As see through an AppError
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// AsAppError returns the first AppError in err's chain
+func AsAppError(err error) (*AppError, bool) {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, or "" if
+// there is none. Clients calling other services forward it as X-Request-ID.
+func RequestIDFromContext(ctx context.Context) string {
+	return logger.CorrelationIDFromContext(ctx)
+}
+
+// WithRequestID records the ID of the request ctx belongs to on the AppError in
+// err's chain, unless it already has one, and returns err
+func WithRequestID(ctx context.Context, err error) error {
+	if appErr, ok := AsAppError(err); ok && appErr.RequestID == "" {
+		appErr.RequestID = RequestIDFromContext(ctx)
+	}
+	return err
+}
+
+// ErrorCodeOf returns the machine-readable code of the AppError in err's chain,
+// or ErrCodeInternal for any other non-nil error. Callers and tests should branch
+// on this rather than on error messages.
+func ErrorCodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	if appErr, ok := AsAppError(err); ok && appErr.ErrorCode != "" {
+		return appErr.ErrorCode
+	}
+	return ErrCodeInternal
+}
+
 // NewAppError creates a new AppError
 func NewAppError(code int, message string, err error) *AppError {
 	return &AppError{
@@ -0,0 +1,126 @@
+package common_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInternalError_WrapsCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := common.NewInternalError("failed to save", cause)
+
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "failed to save", err.Message)
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	wrapped := fmt.Errorf("redeem: %w", common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeInsufficientPoints, "insufficient points", nil))
+
+	assert.Equal(t, common.ErrCodeInsufficientPoints, common.ErrorCodeOf(wrapped))
+	assert.Equal(t, common.ErrCodeInternal, common.ErrorCodeOf(errors.New("boom")))
+	assert.Equal(t, "", common.ErrorCodeOf(nil))
+
+	appErr, ok := common.AsAppError(wrapped)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, appErr.Code)
+}
+
+func TestRespondError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "typed error",
+			err:         common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "reward not found", nil),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    common.ErrCodeNotFound,
+			wantMessage: "reward not found",
+		},
+		{
+			name:        "wrapped typed error",
+			err:         fmt.Errorf("redeem: %w", common.NewErrorWithCode(http.StatusForbidden, common.ErrCodeTierRequired, "this reward requires a higher tier", nil)),
+			wantStatus:  http.StatusForbidden,
+			wantCode:    common.ErrCodeTierRequired,
+			wantMessage: "this reward requires a higher tier",
+		},
+		{
+			name:        "internal error hides its cause",
+			err:         common.NewInternalError("failed to redeem reward", errors.New("pq: deadlock detected")),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    common.ErrCodeInternal,
+			wantMessage: "failed to redeem reward",
+		},
+		{
+			name:        "untyped error",
+			err:         errors.New("pq: deadlock detected"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    common.ErrCodeInternal,
+			wantMessage: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+			common.RespondError(c, tt.err)
+
+			var resp common.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.False(t, resp.Success)
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, tt.wantCode, resp.Error.ErrorCode)
+			assert.Equal(t, tt.wantMessage, resp.Error.Message)
+			assert.NotContains(t, w.Body.String(), "deadlock")
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := logger.ContextWithCorrelationID(context.Background(), "req-123")
+
+	err := common.WithRequestID(ctx, fmt.Errorf("redeem: %w", common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "reward not found", nil)))
+	appErr, ok := common.AsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, "req-123", appErr.RequestID)
+
+	// The request an error was raised in is kept
+	err = common.WithRequestID(logger.ContextWithCorrelationID(context.Background(), "req-456"), err)
+	assert.Equal(t, "req-123", appErr.RequestID)
+
+	plain := errors.New("deadlock detected")
+	assert.Equal(t, plain, common.WithRequestID(ctx, plain))
+}
+
+func TestRespondError_ReportsRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	c.Request = c.Request.WithContext(logger.ContextWithCorrelationID(c.Request.Context(), "req-123"))
+
+	err := common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNotFound, "reward not found", nil)
+	common.RespondError(c, err)
+
+	var resp common.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "req-123", resp.CorrelationID)
+	assert.Equal(t, "req-123", err.RequestID)
+}
@@ -25,7 +25,7 @@ func HandleServiceError(c *gin.Context, err error, fallbackMessage string) bool
 	}
 
 	// Check for AppError first (typed business errors)
-	if appErr, ok := err.(*AppError); ok {
+	if appErr, ok := AsAppError(err); ok {
 		AppErrorResponse(c, appErr)
 		return true
 	}
@@ -48,7 +48,7 @@ func HandleServiceErrorWithCode(c *gin.Context, err error, fallbackCode int, fal
 	}
 
 	// Check for AppError first (typed business errors)
-	if appErr, ok := err.(*AppError); ok {
+	if appErr, ok := AsAppError(err); ok {
 		AppErrorResponse(c, appErr)
 		return true
 	}
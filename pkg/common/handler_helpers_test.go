@@ -2,6 +2,7 @@ package common_test
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -48,6 +49,14 @@ func TestHandleServiceError(t *testing.T) {
 			expectStatus:   http.StatusInternalServerError,
 			expectContains: "failed to get user",
 		},
+		{
+			name:           "wrapped AppError is handled",
+			err:            fmt.Errorf("loading user: %w", common.NewForbiddenError("not your account")),
+			fallbackMsg:    "failed to get user",
+			expectHandled:  true,
+			expectStatus:   http.StatusForbidden,
+			expectContains: "not your account",
+		},
 		{
 			name:           "bad request AppError",
 			err:            common.NewBadRequestError("invalid input", nil),
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // Response represents a standard API response
@@ -115,8 +116,13 @@ func NoMethodHandler() gin.HandlerFunc {
 	}
 }
 
-// AppErrorResponse sends an AppError response
+// AppErrorResponse sends an AppError response. The correlation ID is the one
+// recorded on the error, if any, so it matches the logs of where it was raised.
 func AppErrorResponse(c *gin.Context, err *AppError) {
+	correlationID := err.RequestID
+	if correlationID == "" {
+		correlationID = RequestIDFromContext(c.Request.Context())
+	}
 	c.JSON(err.Code, Response{
 		Success: false,
 		Error: &ErrorInfo{
@@ -124,6 +130,23 @@ func AppErrorResponse(c *gin.Context, err *AppError) {
 			ErrorCode: err.ErrorCode,
 			Message:   err.Message,
 		},
-		CorrelationID: logger.CorrelationIDFromContext(c.Request.Context()),
+		CorrelationID: correlationID,
 	})
 }
+
+// RespondError sends the response for a service error. An AppError anywhere in
+// the chain is sent with its status, error code and user-safe message; any other
+// error is logged and reported as a generic internal error.
+func RespondError(c *gin.Context, err error) {
+	err = WithRequestID(c.Request.Context(), err)
+	if appErr, ok := AsAppError(err); ok {
+		if appErr.Code >= http.StatusInternalServerError {
+			logger.ErrorContext(c.Request.Context(), appErr.Message, zap.Error(err))
+		}
+		AppErrorResponse(c, appErr)
+		return
+	}
+
+	logger.ErrorContext(c.Request.Context(), "unhandled service error", zap.Error(err))
+	AppErrorResponse(c, NewInternalError("internal server error", err))
+}
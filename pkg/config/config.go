@@ -10,7 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/richxcame/ride-hailing/pkg/secrets"
 )
 
@@ -33,6 +32,57 @@ type Config struct {
 	Maps          MapsConfig
 	Checkr        CheckrConfig
 	Onfido        OnfidoConfig
+	Currency      CurrencyConfig
+	Loyalty       LoyaltyConfig
+	WebSocket     WebSocketConfig
+	Storage       StorageConfig
+}
+
+// StorageConfig holds the S3-compatible object store for uploaded files. An
+// empty bucket leaves file storage unconfigured.
+type StorageConfig struct {
+	Bucket    string
+	Region    string
+	Endpoint  string // for S3-compatible stores such as MinIO
+	AccessKey string
+	SecretKey string
+	BaseURL   string // CDN or custom domain URL prefix
+}
+
+// Enabled reports whether an object store is configured
+func (s StorageConfig) Enabled() bool {
+	return s.Bucket != ""
+}
+
+// WebSocketConfig holds inbound limits for WebSocket connections. Zero disables a limit.
+type WebSocketConfig struct {
+	MessagesPerSecond     int // sustained inbound messages per connection
+	MessageBurst          int // messages a connection may send at once
+	MaxRateViolations     int // dropped messages tolerated before the connection is closed
+	MaxConnectionsPerUser int // concurrent connections per user ID
+
+	PingIntervalSeconds int // how often the server pings each connection
+	MaxMissedPongs      int // unanswered pings before a connection is closed
+	IdleTimeoutSeconds  int // close connections that send nothing for this long
+}
+
+// CurrencyConfig holds exchange rate provider and cleanup configuration
+type CurrencyConfig struct {
+	RateProvider string // fixer, ecb (empty disables auto-refresh)
+	FixerAPIKey  string
+
+	CleanupIntervalMinutes int // how often expired rates are swept
+	RateRetentionHours     int // how long expired rates are kept before deletion
+
+	SeedOnBoot bool   // create missing seed currencies and rates at startup
+	SeedFile   string // JSON seed set replacing the default currencies (empty uses the defaults)
+
+	MaxRateChangePercent float64 // largest change from the stored rate a manually set rate may make (0 disables)
+}
+
+// LoyaltyConfig holds loyalty points configuration
+type LoyaltyConfig struct {
+	RoundingMode string // truncate, floor, round or banker; how fractional points are resolved
 }
 
 // CheckrConfig holds Checkr background check configuration
@@ -72,6 +122,14 @@ type ServerConfig struct {
 	ReadTimeout  int
 	WriteTimeout int
 	CORSOrigins  string // Comma-separated list of allowed origins
+
+	// CORSAllowCredentials lets browsers send cookies and auth headers on
+	// cross-origin requests. A "*" origin is only accepted when it's off.
+	CORSAllowCredentials bool
+
+	// ShutdownTimeout is how long, in seconds, a service waits for in-flight
+	// requests and connections to drain after a shutdown signal
+	ShutdownTimeout int
 }
 
 // DatabaseConfig holds database configuration
@@ -128,16 +186,17 @@ type EndpointRateLimitConfig struct {
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret         string
-	Expiration     int // in hours
-	KeyFile        string
-	RotationHours  int
-	GraceHours     int
-	RefreshMinutes int
-	VaultAddress   string
-	VaultToken     string
-	VaultPath      string
-	VaultNamespace string
+	Secret            string
+	Expiration        int // in hours
+	RefreshExpiration int // refresh token lifetime in hours
+	KeyFile           string
+	RotationHours     int
+	GraceHours        int
+	RefreshMinutes    int
+	VaultAddress      string
+	VaultToken        string
+	VaultPath         string
+	VaultNamespace    string
 }
 
 // PubSubConfig holds Google Pub/Sub configuration
@@ -246,6 +305,7 @@ const (
 	DefaultRedisWriteTimeout          = 5
 	DefaultWebSocketConnectionTimeout = 60
 	DefaultRequestTimeout             = 30
+	DefaultShutdownTimeout            = 15
 
 	// Maximum allowed timeouts (prevent misconfigurations)
 	MaxHTTPClientTimeout          = 300 // 5 minutes
@@ -332,7 +392,7 @@ func (t TimeoutConfig) TimeoutForRoute(method, path string) time.Duration {
 // Load loads configuration from environment variables
 func Load(serviceName string) (*Config, error) {
 	// Load .env file if it exists
-	_ = godotenv.Load()
+	loadEnvFile()
 
 	cfg := &Config{
 		Server: ServerConfig{
@@ -342,6 +402,10 @@ func Load(serviceName string) (*Config, error) {
 			ReadTimeout:  getEnvAsInt("READ_TIMEOUT", 10),
 			WriteTimeout: getEnvAsInt("WRITE_TIMEOUT", 10),
 			CORSOrigins:  getEnv("CORS_ORIGINS", "http://localhost:3000"),
+
+			CORSAllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
+
+			ShutdownTimeout: getEnvAsInt("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout),
 		},
 		Database: DatabaseConfig{
 			Host:        getEnv("DB_HOST", "localhost"),
@@ -368,16 +432,17 @@ func Load(serviceName string) (*Config, error) {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			Secret:         getEnv("JWT_SECRET", ""),
-			Expiration:     getEnvAsInt("JWT_EXPIRATION", 24),
-			KeyFile:        getEnv("JWT_KEYS_FILE", "config/jwt_keys.json"),
-			RotationHours:  getEnvAsInt("JWT_ROTATION_HOURS", 24*30),
-			GraceHours:     getEnvAsInt("JWT_ROTATION_GRACE_HOURS", 24*30),
-			RefreshMinutes: getEnvAsInt("JWT_KEY_REFRESH_MINUTES", 5),
-			VaultAddress:   getEnv("JWT_KEYS_VAULT_ADDR", ""),
-			VaultToken:     getEnv("JWT_KEYS_VAULT_TOKEN", ""),
-			VaultPath:      getEnv("JWT_KEYS_VAULT_PATH", ""),
-			VaultNamespace: getEnv("JWT_KEYS_VAULT_NAMESPACE", ""),
+			Secret:            getEnv("JWT_SECRET", ""),
+			Expiration:        getEnvAsInt("JWT_EXPIRATION", 24),
+			RefreshExpiration: getEnvAsInt("JWT_REFRESH_EXPIRATION", 24*30),
+			KeyFile:           getEnv("JWT_KEYS_FILE", "config/jwt_keys.json"),
+			RotationHours:     getEnvAsInt("JWT_ROTATION_HOURS", 24*30),
+			GraceHours:        getEnvAsInt("JWT_ROTATION_GRACE_HOURS", 24*30),
+			RefreshMinutes:    getEnvAsInt("JWT_KEY_REFRESH_MINUTES", 5),
+			VaultAddress:      getEnv("JWT_KEYS_VAULT_ADDR", ""),
+			VaultToken:        getEnv("JWT_KEYS_VAULT_TOKEN", ""),
+			VaultPath:         getEnv("JWT_KEYS_VAULT_PATH", ""),
+			VaultNamespace:    getEnv("JWT_KEYS_VAULT_NAMESPACE", ""),
 		},
 		PubSub: PubSubConfig{
 			ProjectID: getEnv("PUBSUB_PROJECT_ID", ""),
@@ -472,6 +537,38 @@ func Load(serviceName string) (*Config, error) {
 			WorkflowID: getEnv("ONFIDO_WORKFLOW_ID", ""),
 			Enabled:    getEnvAsBool("ONFIDO_ENABLED", false),
 		},
+		WebSocket: WebSocketConfig{
+			MessagesPerSecond:     getEnvAsInt("WS_MESSAGES_PER_SECOND", 20),
+			MessageBurst:          getEnvAsInt("WS_MESSAGE_BURST", 40),
+			MaxRateViolations:     getEnvAsInt("WS_MAX_RATE_VIOLATIONS", 10),
+			MaxConnectionsPerUser: getEnvAsInt("WS_MAX_CONNECTIONS_PER_USER", 5),
+			PingIntervalSeconds:   getEnvAsInt("WS_PING_INTERVAL_SECONDS", 30),
+			MaxMissedPongs:        getEnvAsInt("WS_MAX_MISSED_PONGS", 2),
+			IdleTimeoutSeconds:    getEnvAsInt("WS_IDLE_TIMEOUT_SECONDS", 0),
+		},
+		Storage: StorageConfig{
+			Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+			Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+			Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+			AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+			BaseURL:   getEnv("STORAGE_S3_BASE_URL", ""),
+		},
+		Currency: CurrencyConfig{
+			RateProvider: getEnv("CURRENCY_RATE_PROVIDER", ""),
+			FixerAPIKey:  getEnv("FIXER_API_KEY", ""),
+
+			CleanupIntervalMinutes: getEnvAsInt("CURRENCY_CLEANUP_INTERVAL_MINUTES", 60),
+			RateRetentionHours:     getEnvAsInt("CURRENCY_RATE_RETENTION_HOURS", 24*7),
+
+			SeedOnBoot: getEnvAsBool("CURRENCY_SEED_ON_BOOT", false),
+			SeedFile:   getEnv("CURRENCY_SEED_FILE", ""),
+
+			MaxRateChangePercent: getEnvAsFloat("CURRENCY_MAX_RATE_CHANGE_PERCENT", 20),
+		},
+		Loyalty: LoyaltyConfig{
+			RoundingMode: getEnv("LOYALTY_ROUNDING_MODE", "truncate"),
+		},
 		Secrets: SecretsSettings{
 			Provider:        secrets.ProviderType(getEnv("SECRETS_PROVIDER", "")),
 			CacheTTLSeconds: getEnvAsInt("SECRETS_CACHE_TTL_SECONDS", 300),
@@ -593,6 +690,10 @@ func Load(serviceName string) (*Config, error) {
 		return nil, fmt.Errorf("REDIS_WRITE_TIMEOUT (%d seconds) exceeds maximum allowed value of %d seconds", cfg.Timeout.RedisWriteTimeout, MaxRedisOperationTimeout)
 	}
 
+	if cfg.Server.ShutdownTimeout <= 0 {
+		cfg.Server.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
 	// Validate and set WebSocket connection timeout
 	if cfg.Timeout.WebSocketConnectionTimeout <= 0 {
 		cfg.Timeout.WebSocketConnectionTimeout = DefaultWebSocketConnectionTimeout
@@ -622,6 +723,11 @@ func Load(serviceName string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.Validate(); err != nil {
+		cfg.Close()
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
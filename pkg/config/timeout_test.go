@@ -11,7 +11,7 @@ import (
 
 func TestTimeoutConfigDefaults(t *testing.T) {
 	// Clear environment variables
-	os.Clearenv()
+	clearEnv()
 
 	cfg, err := Load("test-service")
 	require.NoError(t, err)
@@ -28,7 +28,7 @@ func TestTimeoutConfigDefaults(t *testing.T) {
 
 func TestTimeoutConfigCustomValues(t *testing.T) {
 	// Set custom timeout values
-	os.Clearenv()
+	clearEnv()
 	os.Setenv("HTTP_CLIENT_TIMEOUT", "60")
 	os.Setenv("DB_QUERY_TIMEOUT", "20")
 	os.Setenv("REDIS_OPERATION_TIMEOUT", "10")
@@ -52,7 +52,7 @@ func TestTimeoutConfigCustomValues(t *testing.T) {
 
 func TestTimeoutConfigMaxValidation(t *testing.T) {
 	t.Run("HTTP client timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("HTTP_CLIENT_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -62,7 +62,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("Database query timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("DB_QUERY_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -72,7 +72,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("Redis operation timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("REDIS_OPERATION_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -82,7 +82,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("Redis read timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("REDIS_READ_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -92,7 +92,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("Redis write timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("REDIS_WRITE_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -102,7 +102,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("WebSocket connection timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("WS_CONNECTION_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -112,7 +112,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 	})
 
 	t.Run("Default request timeout exceeds maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("DEFAULT_REQUEST_TIMEOUT", "999")
 
 		_, err := Load("test-service")
@@ -124,7 +124,7 @@ func TestTimeoutConfigMaxValidation(t *testing.T) {
 
 func TestTimeoutConfigRouteOverrides(t *testing.T) {
 	t.Run("should parse valid route overrides", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("ROUTE_TIMEOUT_OVERRIDES", `{"POST:/api/v1/rides": 60, "GET:/api/v1/analytics": 120}`)
 
 		cfg, err := Load("test-service")
@@ -136,7 +136,7 @@ func TestTimeoutConfigRouteOverrides(t *testing.T) {
 	})
 
 	t.Run("should reject route override exceeding maximum", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("ROUTE_TIMEOUT_OVERRIDES", `{"POST:/api/v1/rides": 999}`)
 
 		_, err := Load("test-service")
@@ -146,7 +146,7 @@ func TestTimeoutConfigRouteOverrides(t *testing.T) {
 	})
 
 	t.Run("should filter out invalid timeout values", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("ROUTE_TIMEOUT_OVERRIDES", `{"POST:/api/v1/rides": 60, "GET:/api/v1/invalid": 0}`)
 
 		cfg, err := Load("test-service")
@@ -158,7 +158,7 @@ func TestTimeoutConfigRouteOverrides(t *testing.T) {
 	})
 
 	t.Run("should return error for invalid JSON", func(t *testing.T) {
-		os.Clearenv()
+		clearEnv()
 		os.Setenv("ROUTE_TIMEOUT_OVERRIDES", `{invalid json}`)
 
 		_, err := Load("test-service")
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET Load accepts
+const MinJWTSecretLength = 32
+
+var (
+	// ErrMissingJWTSecret is reported when JWT_SECRET is not set
+	ErrMissingJWTSecret = errors.New("JWT_SECRET is required")
+	// ErrJWTSecretTooShort is reported when JWT_SECRET is shorter than MinJWTSecretLength
+	ErrJWTSecretTooShort = fmt.Errorf("JWT_SECRET must be at least %d characters", MinJWTSecretLength)
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Error()
+	}
+	return "invalid configuration: " + strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is match any of the individual problems
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// Validate checks that required settings are present and well-formed. It
+// returns a *ValidationError listing every problem, or nil.
+func (c *Config) Validate() error {
+	var problems []error
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	add(validatePort("PORT", c.Server.Port))
+	for _, origin := range strings.Split(c.Server.CORSOrigins, ",") {
+		if strings.TrimSpace(origin) == "" && c.Server.CORSOrigins != "" {
+			add(errors.New("CORS_ORIGINS contains an empty origin"))
+			break
+		}
+	}
+	if c.Server.CORSAllowCredentials {
+		for _, origin := range strings.Split(c.Server.CORSOrigins, ",") {
+			if strings.TrimSpace(origin) == "*" {
+				add(errors.New(`CORS_ORIGINS must not contain "*" when CORS_ALLOW_CREDENTIALS is set`))
+				break
+			}
+		}
+	}
+
+	switch {
+	case c.JWT.Secret == "":
+		add(ErrMissingJWTSecret)
+	case len(c.JWT.Secret) < MinJWTSecretLength:
+		add(ErrJWTSecretTooShort)
+	}
+
+	add(requireValue("DB_HOST", c.Database.Host))
+	add(validatePort("DB_PORT", c.Database.Port))
+	add(requireValue("DB_USER", c.Database.User))
+	add(requireValue("DB_NAME", c.Database.DBName))
+
+	add(requireValue("REDIS_HOST", c.Redis.Host))
+	add(validatePort("REDIS_PORT", c.Redis.Port))
+	if c.Redis.DB < 0 {
+		add(fmt.Errorf("REDIS_DB must not be negative"))
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.DefaultLimit <= 0 || c.RateLimit.AnonymousLimit <= 0 {
+			add(errors.New("rate limits must be positive when RATE_LIMIT_ENABLED is set"))
+		}
+		if c.RateLimit.WindowSeconds < 0 {
+			add(errors.New("RATE_LIMIT_WINDOW_SECONDS must not be negative"))
+		}
+	}
+
+	switch c.Loyalty.RoundingMode {
+	case "", "truncate", "floor", "round", "banker":
+	default:
+		add(fmt.Errorf("LOYALTY_ROUNDING_MODE %q must be truncate, floor, round or banker", c.Loyalty.RoundingMode))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func requireValue(name, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	return nil
+}
+
+func validatePort(name, value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("%s %q is not a valid port", name, value)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret-key-for-testing-only"
+
+// clearEnv resets the environment, keeping only the settings Load requires
+func clearEnv() {
+	os.Clearenv()
+	os.Setenv("JWT_SECRET", testJWTSecret)
+}
+
+func validConfig() Config {
+	return Config{
+		Server:   ServerConfig{Port: "8080", CORSOrigins: "https://a.example.com, https://b.example.com"},
+		JWT:      JWTConfig{Secret: testJWTSecret},
+		Database: DatabaseConfig{Host: "localhost", Port: "5432", User: "postgres", DBName: "ridehailing"},
+		Redis:    RedisConfig{Host: "localhost", Port: "6379"},
+	}
+}
+
+func TestLoad_MissingJWTSecret(t *testing.T) {
+	os.Clearenv()
+
+	cfg, err := Load("test-service")
+
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.True(t, errors.Is(err, ErrMissingJWTSecret))
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := validConfig()
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"invalid port", func(c *Config) { c.Server.Port = "70000" }, `PORT "70000" is not a valid port`},
+		{"empty CORS origin", func(c *Config) { c.Server.CORSOrigins = "https://a.example.com,," }, "CORS_ORIGINS contains an empty origin"},
+		{"wildcard CORS origin with credentials", func(c *Config) {
+			c.Server.CORSOrigins = "https://a.example.com, *"
+			c.Server.CORSAllowCredentials = true
+		}, `CORS_ORIGINS must not contain "*"`},
+		{"short JWT secret", func(c *Config) { c.JWT.Secret = "short" }, ErrJWTSecretTooShort.Error()},
+		{"missing DB host", func(c *Config) { c.Database.Host = "" }, "DB_HOST is required"},
+		{"invalid Redis port", func(c *Config) { c.Redis.Port = "redis" }, `REDIS_PORT "redis" is not a valid port`},
+		{"zero rate limit", func(c *Config) {
+			c.RateLimit = RateLimitConfig{Enabled: true, DefaultLimit: 0, AnonymousLimit: 10}
+		}, "rate limits must be positive"},
+		{"unknown loyalty rounding mode", func(c *Config) { c.Loyalty.RoundingMode = "ceil" }, `LOYALTY_ROUNDING_MODE "ceil" must be`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(&cfg)
+
+			err := cfg.Validate()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfigValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.JWT.Secret = ""
+	cfg.Database.User = ""
+	cfg.Redis.Host = ""
+
+	err := cfg.Validate()
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Problems, 3)
+	assert.True(t, errors.Is(err, ErrMissingJWTSecret))
+	assert.Contains(t, err.Error(), "DB_USER is required")
+	assert.Contains(t, err.Error(), "REDIS_HOST is required")
+}
+
+func TestConfigValidate_WildcardCORSOriginWithoutCredentials(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.CORSOrigins = "*"
+	cfg.Server.CORSAllowCredentials = false
+
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// envFile is the optional dotenv file Load reads and Watch monitors
+const envFile = ".env"
+
+// watchInterval is how often Watch checks the env file for changes
+var watchInterval = 10 * time.Second
+
+var (
+	envMu sync.Mutex
+	// processEnvKeys holds the variables set by the process environment before
+	// the env file was first loaded. They always take precedence over the file.
+	processEnvKeys map[string]struct{}
+	// fileEnvKeys holds the variables currently provided by the env file
+	fileEnvKeys map[string]struct{}
+)
+
+// loadEnvFile loads the env file without overriding the process environment,
+// remembering which variables came from the file so reloads can replace them
+func loadEnvFile() {
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	if processEnvKeys == nil {
+		processEnvKeys = make(map[string]struct{})
+		for _, kv := range os.Environ() {
+			key, _, _ := strings.Cut(kv, "=")
+			processEnvKeys[key] = struct{}{}
+		}
+	}
+
+	values, err := godotenv.Read(envFile)
+	if err != nil {
+		return
+	}
+	applyEnvFile(values)
+}
+
+// reloadEnvFile re-reads the env file, replacing the variables it provided
+// earlier and unsetting any that were removed from it. The returned restore
+// func puts back the environment as it was before the reload.
+func reloadEnvFile() (restore func(), err error) {
+	values, err := godotenv.Read(envFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	previousKeys := fileEnvKeys
+	previous := make(map[string]*string, len(fileEnvKeys)+len(values))
+	snapshot := func(key string) {
+		if _, ok := previous[key]; ok {
+			return
+		}
+		if value, ok := os.LookupEnv(key); ok {
+			previous[key] = &value
+		} else {
+			previous[key] = nil
+		}
+	}
+	for key := range fileEnvKeys {
+		snapshot(key)
+	}
+	for key := range values {
+		if _, ok := processEnvKeys[key]; !ok {
+			snapshot(key)
+		}
+	}
+
+	for key := range fileEnvKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+		}
+	}
+	applyEnvFile(values)
+
+	return func() {
+		envMu.Lock()
+		defer envMu.Unlock()
+		for key, value := range previous {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+		fileEnvKeys = previousKeys
+	}, nil
+}
+
+// applyEnvFile sets the env file's variables, skipping those owned by the
+// process environment. Callers must hold envMu.
+func applyEnvFile(values map[string]string) {
+	fileEnvKeys = make(map[string]struct{}, len(values))
+	for key, value := range values {
+		if _, ok := processEnvKeys[key]; ok {
+			continue
+		}
+		os.Setenv(key, value)
+		fileEnvKeys[key] = struct{}{}
+	}
+}
+
+// envFileModTime returns the env file's modification time, or the zero time if
+// it doesn't exist
+func envFileModTime() time.Time {
+	info, err := os.Stat(envFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Watch reloads the configuration for serviceName whenever the env file changes
+// and passes each new, validated configuration to onChange. A reload that fails
+// to load or validate is logged and skipped, leaving the last good configuration
+// in place. Watch blocks until ctx is cancelled, so run it in a goroutine.
+//
+// onChange owns the configuration it receives and should Close the one it
+// replaces.
+func Watch(ctx context.Context, serviceName string, onChange func(*Config)) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	lastModTime := envFileModTime()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime := envFileModTime()
+		if modTime.Equal(lastModTime) {
+			continue
+		}
+		lastModTime = modTime
+
+		cfg, err := reload(serviceName)
+		if err != nil {
+			logger.Error("Failed to reload configuration, keeping the current one",
+				zap.String("service", serviceName),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		logger.Info("Configuration reloaded", zap.String("service", serviceName))
+		onChange(cfg)
+	}
+}
+
+// reload re-reads the env file and loads the configuration from it. If the
+// new configuration is rejected, the environment is restored so the invalid
+// values don't leak into later reloads or direct os.Getenv readers.
+func reload(serviceName string) (*Config, error) {
+	restore, err := reloadEnvFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := Load(serviceName)
+	if err != nil {
+		restore()
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupEnvFile runs the test in a temp dir with a fresh environment and env file
+func setupEnvFile(t *testing.T, contents string) {
+	clearEnv()
+	t.Chdir(t.TempDir())
+	writeEnvFile(t, contents, time.Now().Add(-time.Minute))
+
+	envMu.Lock()
+	processEnvKeys, fileEnvKeys = nil, nil
+	envMu.Unlock()
+
+	previous := watchInterval
+	watchInterval = 5 * time.Millisecond
+	t.Cleanup(func() { watchInterval = previous })
+}
+
+// writeEnvFile writes the env file with an explicit mtime so changes are always detected
+func writeEnvFile(t *testing.T, contents string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(envFile, []byte(contents), 0o600))
+	require.NoError(t, os.Chtimes(envFile, modTime, modTime))
+}
+
+func TestLoad_ProcessEnvOverridesEnvFile(t *testing.T) {
+	setupEnvFile(t, "PORT=9000\nCORS_ORIGINS=https://file.example.com\n")
+	os.Setenv("PORT", "9100")
+
+	cfg, err := Load("test-service")
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.Equal(t, "9100", cfg.Server.Port)
+	assert.Equal(t, "https://file.example.com", cfg.Server.CORSOrigins)
+}
+
+func TestWatch_ReloadsOnChange(t *testing.T) {
+	setupEnvFile(t, "CORS_ORIGINS=https://a.example.com\nRATE_LIMIT_ANON_LIMIT=30\n")
+	cfg, err := Load("test-service")
+	require.NoError(t, err)
+	defer cfg.Close()
+	require.Equal(t, "https://a.example.com", cfg.Server.CORSOrigins)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan *Config, 1)
+	go Watch(ctx, "test-service", func(c *Config) { updates <- c })
+
+	time.Sleep(20 * time.Millisecond)
+	writeEnvFile(t, "CORS_ORIGINS=https://b.example.com\n", time.Now())
+
+	select {
+	case updated := <-updates:
+		defer updated.Close()
+		assert.Equal(t, "https://b.example.com", updated.Server.CORSOrigins)
+		assert.Equal(t, 60, updated.RateLimit.AnonymousLimit, "removed keys fall back to defaults")
+	case <-time.After(time.Second):
+		t.Fatal("expected a reloaded configuration")
+	}
+}
+
+func TestWatch_InvalidReloadKeepsCurrentConfig(t *testing.T) {
+	setupEnvFile(t, "PORT=8080\n")
+	cfg, err := Load("test-service")
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan *Config, 2)
+	go Watch(ctx, "test-service", func(c *Config) { updates <- c })
+
+	time.Sleep(20 * time.Millisecond)
+	writeEnvFile(t, "PORT=not-a-port\n", time.Now())
+	time.Sleep(50 * time.Millisecond)
+	writeEnvFile(t, "PORT=8081\n", time.Now().Add(time.Second))
+
+	select {
+	case updated := <-updates:
+		defer updated.Close()
+		assert.Equal(t, "8081", updated.Server.Port, "the invalid edit must not be delivered")
+	case <-time.After(time.Second):
+		t.Fatal("expected a reloaded configuration")
+	}
+}
+
+func TestReload_InvalidConfigRestoresEnvironment(t *testing.T) {
+	setupEnvFile(t, "PORT=8080\nCORS_ORIGINS=https://a.example.com\n")
+	cfg, err := Load("test-service")
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	writeEnvFile(t, "PORT=not-a-port\nRATE_LIMIT_ANON_LIMIT=5\n", time.Now())
+
+	_, err = reload("test-service")
+
+	require.Error(t, err)
+	assert.Equal(t, "8080", os.Getenv("PORT"))
+	assert.Equal(t, "https://a.example.com", os.Getenv("CORS_ORIGINS"))
+	_, ok := os.LookupEnv("RATE_LIMIT_ANON_LIMIT")
+	assert.False(t, ok, "variables only in the rejected file must be unset")
+}
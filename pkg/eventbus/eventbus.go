@@ -28,6 +28,8 @@ const (
 	SubjectDriverOnline          = "drivers.online"
 	SubjectDriverOffline         = "drivers.offline"
 
+	SubjectDriverVerificationChanged = "drivers.verification.changed"
+
 	SubjectFraudDetected = "fraud.detected"
 )
 
@@ -148,6 +148,7 @@ func TestSubjectConstants(t *testing.T) {
 		{"DriverLocationUpdated", SubjectDriverLocationUpdated, "drivers.location.updated"},
 		{"DriverOnline", SubjectDriverOnline, "drivers.online"},
 		{"DriverOffline", SubjectDriverOffline, "drivers.offline"},
+		{"DriverVerificationChanged", SubjectDriverVerificationChanged, "drivers.verification.changed"},
 		{"FraudDetected", SubjectFraudDetected, "fraud.detected"},
 	}
 
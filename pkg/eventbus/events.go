@@ -104,6 +104,16 @@ type DriverLocationUpdatedData struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// DriverVerificationChangedData is emitted when a driver's document
+// verification status changes, e.g. when they become cleared to drive.
+type DriverVerificationChangedData struct {
+	DriverID  uuid.UUID `json:"driver_id"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	CanDrive  bool      `json:"can_drive"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
 // FraudDetectedData is emitted when suspicious activity is detected.
 type FraudDetectedData struct {
 	UserID     uuid.UUID `json:"user_id"`
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/pkg/storage"
+)
+
+// storageProbeKey is looked up to check storage is reachable; it doesn't need to exist
+const storageProbeKey = ".healthcheck"
+
+// Dependency is something a service needs to serve requests
+type Dependency struct {
+	Name     string
+	Critical bool // The service is not ready while a critical dependency is down
+	Check    func(ctx context.Context) error
+}
+
+// Optional returns the dependency as one the service can run without
+func (d Dependency) Optional() Dependency {
+	d.Critical = false
+	return d
+}
+
+// DatabaseDependency pings a PostgreSQL database
+func DatabaseDependency(db *sql.DB) Dependency {
+	return Dependency{
+		Name:     "database",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if db == nil {
+				return fmt.Errorf("database connection is nil")
+			}
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// RedisDependency pings Redis
+func RedisDependency(client *redis.Client) Dependency {
+	return Dependency{
+		Name:     "redis",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if client == nil {
+				return fmt.Errorf("redis client is nil")
+			}
+			return client.Ping(ctx).Err()
+		},
+	}
+}
+
+// StorageDependency checks the object store answers a lookup
+func StorageDependency(store storage.Storage) Dependency {
+	return Dependency{
+		Name:     "storage",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if store == nil {
+				return fmt.Errorf("storage is nil")
+			}
+			_, err := store.Exists(ctx, storageProbeKey)
+			return err
+		},
+	}
+}
+
+// DependencyReport is the result of checking one dependency
+type DependencyReport struct {
+	Status    string  `json:"status"` // "healthy" or "unhealthy"
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessReport is the result of checking every dependency of a service
+type ReadinessReport struct {
+	Status       string                      `json:"status"` // "healthy", "degraded" or "unhealthy"
+	Ready        bool                        `json:"ready"`
+	Dependencies map[string]DependencyReport `json:"dependencies"`
+	CheckedAt    time.Time                   `json:"checked_at"`
+}
+
+// HealthChecker checks a configurable set of dependencies. A service is
+// degraded while an optional dependency is down and unhealthy, and not ready,
+// while a critical one is.
+type HealthChecker struct {
+	dependencies []Dependency
+	timeout      time.Duration
+}
+
+// NewHealthChecker creates a health checker for the given dependencies
+func NewHealthChecker(cfg CheckerConfig, dependencies ...Dependency) *HealthChecker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultCheckerConfig().Timeout
+	}
+	return &HealthChecker{
+		dependencies: dependencies,
+		timeout:      cfg.Timeout,
+	}
+}
+
+// Check checks every dependency concurrently, each with the configured timeout
+func (h *HealthChecker) Check(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{
+		Status:       "healthy",
+		Ready:        true,
+		Dependencies: make(map[string]DependencyReport, len(h.dependencies)),
+		CheckedAt:    time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, dep := range h.dependencies {
+		wg.Add(1)
+		go func(dep Dependency) {
+			defer wg.Done()
+			result := h.checkDependency(ctx, dep)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[dep.Name] = result
+			if result.Status == "healthy" {
+				return
+			}
+			if dep.Critical {
+				report.Status = "unhealthy"
+				report.Ready = false
+			} else if report.Status == "healthy" {
+				report.Status = "degraded"
+			}
+		}(dep)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// checkDependency runs one dependency check, giving up after the timeout even
+// if the check ignores its context
+func (h *HealthChecker) checkDependency(ctx context.Context, dep Dependency) DependencyReport {
+	checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- dep.Check(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("check timed out after %v", h.timeout)
+	}
+
+	result := DependencyReport{
+		Status:    "healthy",
+		Critical:  dep.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "unhealthy"
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/richxcame/ride-hailing/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func upDependency(name string) health.Dependency {
+	return health.Dependency{Name: name, Critical: true, Check: func(ctx context.Context) error { return nil }}
+}
+
+func downDependency(name string, err error) health.Dependency {
+	return health.Dependency{Name: name, Critical: true, Check: func(ctx context.Context) error { return err }}
+}
+
+func TestHealthChecker_AllHealthy(t *testing.T) {
+	checker := health.NewHealthChecker(health.DefaultCheckerConfig(), upDependency("database"), upDependency("redis"))
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, "healthy", report.Status)
+	assert.True(t, report.Ready)
+	require.Len(t, report.Dependencies, 2)
+	assert.Equal(t, "healthy", report.Dependencies["database"].Status)
+	assert.Equal(t, "healthy", report.Dependencies["redis"].Status)
+	assert.Empty(t, report.Dependencies["redis"].Error)
+}
+
+func TestHealthChecker_CriticalDependencyDown(t *testing.T) {
+	checker := health.NewHealthChecker(health.DefaultCheckerConfig(),
+		upDependency("database"),
+		downDependency("redis", errors.New("connection refused")),
+	)
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, "unhealthy", report.Status)
+	assert.False(t, report.Ready)
+	assert.Equal(t, "healthy", report.Dependencies["database"].Status)
+	assert.Equal(t, "unhealthy", report.Dependencies["redis"].Status)
+	assert.Equal(t, "connection refused", report.Dependencies["redis"].Error)
+	assert.True(t, report.Dependencies["redis"].Critical)
+}
+
+func TestHealthChecker_OptionalDependencyDownIsDegraded(t *testing.T) {
+	checker := health.NewHealthChecker(health.DefaultCheckerConfig(),
+		upDependency("database"),
+		downDependency("storage", errors.New("bucket unavailable")).Optional(),
+	)
+
+	report := checker.Check(context.Background())
+
+	assert.Equal(t, "degraded", report.Status)
+	assert.True(t, report.Ready)
+	assert.False(t, report.Dependencies["storage"].Critical)
+
+	// The payload reports each dependency with its status and latency
+	body, err := json.Marshal(report)
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "degraded", payload["status"])
+	assert.Equal(t, true, payload["ready"])
+	storage := payload["dependencies"].(map[string]interface{})["storage"].(map[string]interface{})
+	assert.Equal(t, "unhealthy", storage["status"])
+	assert.Equal(t, "bucket unavailable", storage["error"])
+	assert.Contains(t, storage, "latency_ms")
+}
+
+func TestHealthChecker_TimesOutSlowDependency(t *testing.T) {
+	slow := health.Dependency{
+		Name:     "database",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	}
+	checker := health.NewHealthChecker(health.CheckerConfig{Timeout: 20 * time.Millisecond}, slow)
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.False(t, report.Ready)
+	assert.Contains(t, report.Dependencies["database"].Error, "timed out")
+}
+
+func TestHealthChecker_NoDependencies(t *testing.T) {
+	report := health.NewHealthChecker(health.CheckerConfig{}).Check(context.Background())
+
+	assert.Equal(t, "healthy", report.Status)
+	assert.True(t, report.Ready)
+	assert.Empty(t, report.Dependencies)
+}
+
+func TestDatabaseDependency_PingFails(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectPing().WillReturnError(errors.New("database is down"))
+
+	report := health.NewHealthChecker(health.DefaultCheckerConfig(), health.DatabaseDependency(db)).Check(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, "database is down", report.Dependencies["database"].Error)
+}
+
+func TestStorageDependency_NilStorage(t *testing.T) {
+	report := health.NewHealthChecker(health.DefaultCheckerConfig(), health.StorageDependency(nil).Optional()).Check(context.Background())
+
+	assert.Equal(t, "degraded", report.Status)
+	assert.Equal(t, "storage is nil", report.Dependencies["storage"].Error)
+}
@@ -0,0 +1,49 @@
+// Package httputil holds helpers shared by the services' HTTP list endpoints
+package httputil
+
+import "github.com/richxcame/ride-hailing/pkg/pagination"
+
+// NormalizePagination clamps the page a service is asked to fetch using the
+// same rule as pagination.ParseParams
+func NormalizePagination(limit, offset int) (int, int) {
+	return pagination.Clamp(limit, offset)
+}
+
+// PageInfo says which page of a list a response holds. List responses embed it
+// so every list reports its page the same way.
+type PageInfo struct {
+	Total      int `json:"total"`
+	Limit      int `json:"limit"`
+	Offset     int `json:"offset"`
+	Page       int `json:"page"` // 1-indexed
+	TotalPages int `json:"total_pages"`
+}
+
+// NewPageInfo describes the page at offset of limit items out of total
+func NewPageInfo(total, limit, offset int) PageInfo {
+	info := PageInfo{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+		Page:   pagination.GetCurrentPage(offset, limit),
+	}
+	if limit > 0 {
+		info.TotalPages = (total + limit - 1) / limit
+	}
+	return info
+}
+
+// ListResponse is a page of items with its PageInfo
+type ListResponse[T any] struct {
+	Items []T `json:"items"`
+	PageInfo
+}
+
+// NewListResponse wraps a page of items. Items is never nil, so an empty page
+// encodes as an empty list rather than null.
+func NewListResponse[T any](items []T, total, limit, offset int) *ListResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &ListResponse[T]{Items: items, PageInfo: NewPageInfo(total, limit, offset)}
+}
@@ -0,0 +1,49 @@
+package httputil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/richxcame/ride-hailing/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePagination(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit, offset  int
+		expectedLimit  int
+		expectedOffset int
+	}{
+		{"zero limit uses default", 0, 0, pagination.DefaultLimit, 0},
+		{"negative limit uses default", -1, 10, pagination.DefaultLimit, 10},
+		{"limit at max is kept", pagination.MaxLimit, 0, pagination.MaxLimit, 0},
+		{"limit over max is capped", pagination.MaxLimit + 1, 0, pagination.MaxLimit, 0},
+		{"negative offset becomes zero", 10, -5, 10, 0},
+		{"valid values are kept", 50, 100, 50, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset := NormalizePagination(tt.limit, tt.offset)
+			assert.Equal(t, tt.expectedLimit, limit)
+			assert.Equal(t, tt.expectedOffset, offset)
+		})
+	}
+}
+
+func TestNewPageInfo(t *testing.T) {
+	info := NewPageInfo(45, 20, 40)
+
+	assert.Equal(t, PageInfo{Total: 45, Limit: 20, Offset: 40, Page: 3, TotalPages: 3}, info)
+	assert.Equal(t, 0, NewPageInfo(0, 20, 0).TotalPages)
+	assert.Equal(t, 1, NewPageInfo(20, 20, 0).TotalPages)
+}
+
+func TestNewListResponse_EmptyPageEncodesAsEmptyList(t *testing.T) {
+	data, err := json.Marshal(NewListResponse[string](nil, 0, 20, 0))
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[],"total":0,"limit":20,"offset":0,"page":1,"total_pages":0}`, string(data))
+}
@@ -13,14 +13,71 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
+// Token types carried in the typ claim. Tokens issued before refresh tokens
+// existed have no type and are treated as access tokens.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	// ErrTokenExpired is returned for a correctly signed token past its expiry
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenInvalid is returned for any other token that fails verification
+	ErrTokenInvalid = errors.New("invalid token")
+)
+
 // Claims represents JWT claims
 type Claims struct {
-	UserID uuid.UUID       `json:"user_id"`
-	Email  string          `json:"email"`
-	Role   models.UserRole `json:"role"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Email     string          `json:"email"`
+	Role      models.UserRole `json:"role"`
+	TokenType string          `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ParseToken verifies a signed JWT and returns its claims. It returns
+// ErrTokenExpired for expired tokens and ErrTokenInvalid for everything else,
+// so callers can tell the client whether refreshing will help.
+func ParseToken(provider jwtkeys.KeyProvider, tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return resolveSigningKey(provider, token)
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+
+	return claims, nil
+}
+
+// ParseAccessToken is ParseToken for bearer credentials: refresh tokens are rejected
+func ParseAccessToken(provider jwtkeys.KeyProvider, tokenString string) (*Claims, error) {
+	claims, err := ParseToken(provider, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != "" && claims.TokenType != TokenTypeAccess {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// TokenError converts a ParseToken error into the 401 response sent to clients
+func TokenError(err error) *common.AppError {
+	if errors.Is(err, ErrTokenExpired) {
+		return common.NewErrorWithCode(http.StatusUnauthorized, common.ErrCodeExpiredToken, "token expired", err)
+	}
+	return common.NewErrorWithCode(http.StatusUnauthorized, common.ErrCodeInvalidToken, "invalid token", err)
+}
+
 // AuthMiddleware validates JWT tokens with a static secret (deprecated). Prefer
 // AuthMiddlewareWithProvider to enable key rotation support.
 func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
@@ -52,19 +109,9 @@ func AuthMiddlewareWithProvider(provider jwtkeys.KeyProvider) gin.HandlerFunc {
 		}
 
 		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return resolveSigningKey(provider, token)
-		})
-
-		if err != nil || !token.Valid {
-			common.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired token")
-			c.Abort()
-			return
-		}
-
-		claims, ok := token.Claims.(*Claims)
-		if !ok {
-			common.ErrorResponse(c, http.StatusUnauthorized, "invalid token claims")
+		claims, err := ParseAccessToken(provider, tokenString)
+		if err != nil {
+			common.AppErrorResponse(c, TokenError(err))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret"
+
+func signTestToken(t *testing.T, secret, tokenType string, expiresAt time.Time) string {
+	t.Helper()
+	claims := &Claims{
+		UserID:    uuid.New(),
+		Email:     "rider@example.com",
+		Role:      models.RoleRider,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func setupAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthMiddleware(testJWTSecret))
+	r.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r
+}
+
+func serveWithToken(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddleware_ValidToken(t *testing.T) {
+	r := setupAuthRouter()
+
+	w := serveWithToken(r, signTestToken(t, testJWTSecret, TokenTypeAccess, time.Now().Add(time.Hour)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthMiddleware_LegacyTokenWithoutType(t *testing.T) {
+	r := setupAuthRouter()
+
+	w := serveWithToken(r, signTestToken(t, testJWTSecret, "", time.Now().Add(time.Hour)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	r := setupAuthRouter()
+
+	w := serveWithToken(r, signTestToken(t, testJWTSecret, TokenTypeAccess, time.Now().Add(-time.Minute)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), common.ErrCodeExpiredToken)
+	assert.Contains(t, w.Body.String(), "token expired")
+}
+
+func TestAuthMiddleware_InvalidSignature(t *testing.T) {
+	r := setupAuthRouter()
+
+	w := serveWithToken(r, signTestToken(t, "other-secret", TokenTypeAccess, time.Now().Add(time.Hour)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), common.ErrCodeInvalidToken)
+}
+
+func TestAuthMiddleware_RejectsRefreshToken(t *testing.T) {
+	r := setupAuthRouter()
+
+	w := serveWithToken(r, signTestToken(t, testJWTSecret, TokenTypeRefresh, time.Now().Add(time.Hour)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), common.ErrCodeInvalidToken)
+}
+
+func TestParseToken(t *testing.T) {
+	provider := jwtkeys.NewStaticProvider(testJWTSecret)
+
+	claims, err := ParseToken(provider, signTestToken(t, testJWTSecret, TokenTypeRefresh, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, TokenTypeRefresh, claims.TokenType)
+
+	_, err = ParseToken(provider, signTestToken(t, testJWTSecret, TokenTypeAccess, time.Now().Add(-time.Hour)))
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = ParseToken(provider, "not-a-jwt")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// InternalAPIKeyHeader carries the shared secret services present to each other
+const InternalAPIKeyHeader = "X-Internal-API-Key"
+
 // InternalAPIKey returns a Gin middleware that validates requests using a shared
 // secret passed in the X-Internal-API-Key header. It uses constant-time
 // comparison to prevent timing attacks.
@@ -22,7 +25,7 @@ func InternalAPIKey() gin.HandlerFunc {
 			return
 		}
 
-		provided := c.GetHeader("X-Internal-API-Key")
+		provided := c.GetHeader(InternalAPIKeyHeader)
 		if subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) != 1 {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid internal API key",
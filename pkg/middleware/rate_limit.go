@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/logger"
@@ -64,37 +65,141 @@ func RateLimit(limiter *ratelimit.Limiter, cfg config.RateLimitConfig) gin.Handl
 			return
 		}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
-		remaining := result.Remaining
-		if remaining < 0 {
-			remaining = 0
-		}
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-		resetSeconds := int(result.ResetAfter.Round(time.Second) / time.Second)
-		if resetSeconds < 0 {
-			resetSeconds = 0
+		if writeRateLimitResult(c, result, endpointKey, identity) {
+			c.Next()
+			return
 		}
-		c.Header("X-RateLimit-Reset", strconv.Itoa(resetSeconds))
-		c.Header("X-RateLimit-Resource", endpointKey)
+		c.Abort()
+	}
+}
+
+// writeRateLimitResult sets the rate limit headers for a decision and, when the
+// request is over the limit, sends a 429 with Retry-After. It reports whether the
+// request may proceed.
+func writeRateLimitResult(c *gin.Context, result ratelimit.Result, endpointKey, identity string) bool {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	remaining := result.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	resetSeconds := int(result.ResetAfter.Round(time.Second) / time.Second)
+	if resetSeconds < 0 {
+		resetSeconds = 0
+	}
+	c.Header("X-RateLimit-Reset", strconv.Itoa(resetSeconds))
+	c.Header("X-RateLimit-Resource", endpointKey)
+
+	if result.Allowed {
+		return true
+	}
+
+	retrySeconds := int(result.RetryAfter.Round(time.Second) / time.Second)
+	if retrySeconds <= 0 {
+		retrySeconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retrySeconds))
+
+	logger.WarnContext(c.Request.Context(), "rate limit exceeded",
+		zap.String("endpoint", endpointKey),
+		zap.String("identity", identity),
+		zap.Int("retry_after_seconds", retrySeconds),
+	)
+
+	common.ErrorResponse(c, http.StatusTooManyRequests, "rate limit exceeded")
+	return false
+}
+
+const (
+	// DefaultRateLimit is the number of requests RateLimitMiddleware allows per window
+	// when given a non-positive limit
+	DefaultRateLimit = 120
+	// DefaultRateLimitWindow is used when RateLimitMiddleware is given a non-positive window
+	DefaultRateLimitWindow = time.Minute
+	// rateLimitMiddlewarePrefix namespaces RateLimitMiddleware buckets in Redis
+	rateLimitMiddlewarePrefix = "ratelimit:mw"
+)
+
+// RateLimitKeyFunc returns the identity a request is rate limited by
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// UserOrIPKey keys authenticated requests on the user ID and anonymous ones on
+// the client IP. Register the rate limiter after AuthMiddleware for user keys
+// to be available.
+func UserOrIPKey(c *gin.Context) string {
+	if userID, err := GetUserID(c); err == nil && userID != uuid.Nil {
+		return "user:" + userID.String()
+	}
+	return IPKey(c)
+}
+
+// IPKey keys every request on the client IP. Use it for a limiter registered
+// before authentication, where no user ID is known yet.
+func IPKey(c *gin.Context) string {
+	ip := c.ClientIP()
+	if ip == "" {
+		ip = "unknown"
+	}
+	return "ip:" + ip
+}
+
+// RateLimitMiddleware throttles each endpoint to limit requests per window for
+// every key returned by keyFunc, using a token bucket in Redis so the limit holds
+// across service instances. A nil keyFunc uses UserOrIPKey. Requests are let
+// through if Redis can't be reached.
+func RateLimitMiddleware(redisClient goredis.Cmdable, keyFunc RateLimitKeyFunc, limit int, window time.Duration) gin.HandlerFunc {
+	if keyFunc == nil {
+		keyFunc = UserOrIPKey
+	}
+	if limit <= 0 {
+		limit = DefaultRateLimit
+	}
+	if window <= 0 {
+		window = DefaultRateLimitWindow
+	}
 
-		if result.Allowed {
+	limiter := ratelimit.NewLimiter(redisClient, config.RateLimitConfig{
+		Enabled:        true,
+		WindowSeconds:  int(window / time.Second),
+		DefaultLimit:   limit,
+		AnonymousLimit: limit,
+		RedisPrefix:    rateLimitMiddlewarePrefix,
+	})
+	rule := ratelimit.Rule{Limit: limit, Window: window}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
 			c.Next()
 			return
 		}
 
-		retrySeconds := int(result.RetryAfter.Round(time.Second) / time.Second)
-		if retrySeconds <= 0 {
-			retrySeconds = 1
+		endpointPath := c.FullPath()
+		if endpointPath == "" {
+			endpointPath = c.Request.URL.Path
 		}
-		c.Header("Retry-After", strconv.Itoa(retrySeconds))
+		endpointKey := fmt.Sprintf("%s:%s", c.Request.Method, endpointPath)
 
-		logger.WarnContext(c.Request.Context(), "rate limit exceeded",
-			zap.String("endpoint", endpointKey),
-			zap.String("identity", identity),
-			zap.Int("retry_after_seconds", retrySeconds),
-		)
+		identityType := ratelimit.IdentityAnonymous
+		if userID, err := GetUserID(c); err == nil && userID != uuid.Nil {
+			identityType = ratelimit.IdentityAuthenticated
+		}
+		identity := keyFunc(c)
 
-		common.ErrorResponse(c, http.StatusTooManyRequests, "rate limit exceeded")
+		result, err := limiter.Allow(c.Request.Context(), endpointKey, identity, rule, identityType)
+		if err != nil {
+			logger.WarnContext(c.Request.Context(), "rate limit evaluation failed",
+				zap.String("endpoint", endpointKey),
+				zap.String("identity", identity),
+				zap.Error(err),
+			)
+			c.Next()
+			return
+		}
+
+		if writeRateLimitResult(c, result, endpointKey, identity) {
+			c.Next()
+			return
+		}
 		c.Abort()
 	}
 }
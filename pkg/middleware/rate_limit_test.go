@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectBucket makes the next token bucket script call return the given decision
+// and records the Redis key it was evaluated against. The script takes one key and
+// four arguments; their values are checked by the custom matcher, not the mock.
+func expectBucket(mock redismock.ClientMock, allowed int64, remaining string, retryAfterMillis int64, key *string) {
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		if len(actual) < 4 {
+			return errors.New("unexpected script call")
+		}
+		*key, _ = actual[3].(string)
+		return nil
+	}).ExpectEvalSha("", []string{""}, 0, 0, 0, 0).SetVal([]interface{}{allowed, remaining, retryAfterMillis})
+}
+
+func newRateLimitRouter(t *testing.T, userID uuid.UUID) (*gin.Engine, redismock.ClientMock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	client, mock := redismock.NewClientMock()
+
+	r := gin.New()
+	if userID != uuid.Nil {
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Next()
+		})
+	}
+	r.Use(RateLimitMiddleware(client, nil, 10, time.Minute))
+	r.GET("/rides", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r, mock
+}
+
+func TestRateLimitMiddleware_AllowsUnderLimit(t *testing.T) {
+	r, mock := newRateLimitRouter(t, uuid.Nil)
+	var key string
+	expectBucket(mock, 1, "9", 0, &key)
+
+	req := httptest.NewRequest(http.MethodGet, "/rides", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "10", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "9", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "ratelimit:mw:GET:/rides:ip:203.0.113.7", key)
+}
+
+func TestRateLimitMiddleware_KeysOnUserWhenAuthenticated(t *testing.T) {
+	userID := uuid.New()
+	r, mock := newRateLimitRouter(t, userID)
+	var key string
+	expectBucket(mock, 1, "9", 0, &key)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rides", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ratelimit:mw:GET:/rides:user:"+userID.String(), key)
+}
+
+func TestRateLimitMiddleware_RejectsOverLimit(t *testing.T) {
+	r, mock := newRateLimitRouter(t, uuid.Nil)
+	var key string
+	expectBucket(mock, 0, "0", 2500, &key)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rides", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "rate limit exceeded")
+}
+
+func TestRateLimitMiddleware_FailsOpenWhenRedisErrors(t *testing.T) {
+	r, mock := newRateLimitRouter(t, uuid.Nil)
+	var key string
+	mock.CustomMatch(func(expected, actual []interface{}) error {
+		key, _ = actual[3].(string)
+		return nil
+	}).ExpectEvalSha("", []string{""}, 0, 0, 0, 0).SetErr(errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rides", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, key)
+}
+
+func TestUserOrIPKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "198.51.100.2:5678"
+
+	assert.Equal(t, "ip:198.51.100.2", UserOrIPKey(c))
+
+	userID := uuid.New()
+	c.Set("user_id", userID)
+	require.Equal(t, "user:"+userID.String(), UserOrIPKey(c))
+}
+
+func TestIPKey_IgnoresUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "198.51.100.2:5678"
+	c.Set("user_id", uuid.New())
+
+	assert.Equal(t, "ip:198.51.100.2", IPKey(c))
+}
@@ -48,7 +48,7 @@ func TracingMiddleware(serviceName string) gin.HandlerFunc {
 		}
 
 		// Add request ID from correlation middleware if present
-		if requestID := c.GetString("request_id"); requestID != "" {
+		if requestID := c.GetString(CorrelationIDKey); requestID != "" {
 			span.SetAttributes(attribute.String("http.request_id", requestID))
 		}
 
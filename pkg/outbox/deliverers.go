@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/realtime"
+)
+
+// RealtimeDeliverer pushes events to their user's WebSocket connections through
+// the realtime service. The message type is the event type, and its data is the
+// event payload with the event ID added as event_id.
+type RealtimeDeliverer struct {
+	client realtime.Client
+}
+
+// NewRealtimeDeliverer creates a deliverer that broadcasts through client
+func NewRealtimeDeliverer(client realtime.Client) *RealtimeDeliverer {
+	return &RealtimeDeliverer{client: client}
+}
+
+// Deliver broadcasts the event to its user. Events without a user have no one
+// to be pushed to and are skipped.
+func (d *RealtimeDeliverer) Deliver(ctx context.Context, event *Event) error {
+	if event.UserID == nil {
+		return nil
+	}
+
+	data := map[string]interface{}{}
+	if len(event.Payload) > 0 {
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return fmt.Errorf("payload of %s event is not a JSON object: %w", event.Type, err)
+		}
+	}
+	data["event_id"] = event.ID.String()
+
+	return d.client.BroadcastToUser(ctx, *event.UserID, realtime.Event{Type: event.Type, Data: data})
+}
+
+// BusPublisher publishes to the event bus; *eventbus.Bus implements it
+type BusPublisher interface {
+	Publish(ctx context.Context, subject string, event *eventbus.Event) error
+}
+
+// BusDeliverer publishes events to the event bus under a subject equal to the
+// event type, which the bus stream must cover. The bus event ID is the outbox
+// event ID, so JetStream drops repeats within its duplicate window.
+type BusDeliverer struct {
+	bus BusPublisher
+}
+
+// NewBusDeliverer creates a deliverer that publishes to bus
+func NewBusDeliverer(bus BusPublisher) *BusDeliverer {
+	return &BusDeliverer{bus: bus}
+}
+
+// Deliver publishes the event
+func (d *BusDeliverer) Deliver(ctx context.Context, event *Event) error {
+	return d.bus.Publish(ctx, event.Type, &eventbus.Event{
+		ID:        event.ID.String(),
+		Type:      event.Type,
+		Source:    event.Source,
+		Timestamp: event.CreatedAt,
+		Data:      event.Payload,
+	})
+}
@@ -0,0 +1,80 @@
+// Package outbox implements the transactional outbox pattern. Services write
+// events with Publisher in the same database transaction as the state change
+// they describe, and a Relay delivers them afterwards, retrying until a
+// deliverer accepts them. An event is therefore delivered at least once for
+// every committed change, and never for a rolled back one.
+//
+// Delivery is at least once: a relay that stops between delivering an event and
+// recording it delivers the event again. Every event carries a unique ID that
+// consumers should remember, for a while, to drop repeats.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+)
+
+// Event is a message waiting in, or delivered from, the outbox
+type Event struct {
+	ID        uuid.UUID       `json:"id"` // Stable across redeliveries; consumers de-duplicate on it
+	Type      string          `json:"type"`
+	Source    string          `json:"source"`
+	UserID    *uuid.UUID      `json:"user_id,omitempty"` // Recipient, for events meant for a user
+	Payload   json.RawMessage `json:"payload"`
+	RequestID string          `json:"request_id,omitempty"` // Request that published the event, forwarded on delivery
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Execer runs a statement. pgx.Tx satisfies it; pass the transaction making the
+// state change so the event commits or rolls back with it.
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Publisher writes a service's events to the outbox
+type Publisher struct {
+	source string
+}
+
+// NewPublisher creates a publisher for events from the named service
+func NewPublisher(source string) *Publisher {
+	return &Publisher{source: source}
+}
+
+// Publish writes an event to the outbox within tx. userID is the user the event
+// is for, or nil for events that aren't meant for anyone in particular. The
+// event keeps the ID of the request ctx belongs to, so its delivery can be
+// followed back to it.
+func (p *Publisher) Publish(ctx context.Context, tx Execer, eventType string, userID *uuid.UUID, data interface{}) (*Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal outbox payload: %w", err)
+	}
+
+	event := &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Source:    p.source,
+		UserID:    userID,
+		Payload:   payload,
+		RequestID: logger.CorrelationIDFromContext(ctx),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	query := `
+		INSERT INTO outbox_events (id, event_type, source, user_id, payload, request_id, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $7)
+	`
+	if _, err := tx.Exec(ctx, query, event.ID, event.Type, event.Source, event.UserID, event.Payload, event.RequestID, event.CreatedAt); err != nil {
+		return nil, fmt.Errorf("write outbox event: %w", err)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,394 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/realtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	mu       sync.Mutex
+	events   []*storedEvent
+	claimErr error
+}
+
+type storedEvent struct {
+	event         Event
+	status        string
+	nextAttemptAt time.Time
+	lockedUntil   time.Time
+	lastError     string
+}
+
+func (s *memoryStore) add(event *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, &storedEvent{event: *event, status: "pending"})
+}
+
+func (s *memoryStore) get(id uuid.UUID) *storedEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.events {
+		if stored.event.ID == id {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) ClaimPending(ctx context.Context, now, lockUntil time.Time, limit int) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimErr != nil {
+		return nil, s.claimErr
+	}
+
+	var claimed []*Event
+	for _, stored := range s.events {
+		if len(claimed) == limit {
+			break
+		}
+		if stored.status != "pending" || stored.nextAttemptAt.After(now) || stored.lockedUntil.After(now) {
+			continue
+		}
+		stored.lockedUntil = lockUntil
+		event := stored.event
+		claimed = append(claimed, &event)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.events {
+		if stored.event.ID == id {
+			stored.status = "delivered"
+			stored.event.Attempts++
+			stored.lockedUntil = time.Time{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MarkRetry(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.events {
+		if stored.event.ID == id {
+			stored.event.Attempts++
+			stored.nextAttemptAt = nextAttemptAt
+			stored.lastError = lastError
+			stored.lockedUntil = time.Time{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.events {
+		if stored.event.ID == id {
+			stored.status = "failed"
+			stored.event.Attempts++
+			stored.lastError = lastError
+			stored.lockedUntil = time.Time{}
+		}
+	}
+	return nil
+}
+
+// recordingDeliverer records the events it is given, and the request ID each
+// was delivered under, and fails while err is set
+type recordingDeliverer struct {
+	mu         sync.Mutex
+	events     []*Event
+	requestIDs []string
+	err        error
+}
+
+func (d *recordingDeliverer) Deliver(ctx context.Context, event *Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+	d.requestIDs = append(d.requestIDs, logger.CorrelationIDFromContext(ctx))
+	return d.err
+}
+
+func (d *recordingDeliverer) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.events)
+}
+
+func newTestEvent(eventType string) *Event {
+	userID := uuid.New()
+	return &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Source:    "loyalty",
+		UserID:    &userID,
+		Payload:   json.RawMessage(`{"tier_name":"gold"}`),
+		CreatedAt: time.Now(),
+	}
+}
+
+// execRecorder is an Execer that records the statement it runs
+type execRecorder struct {
+	sql  string
+	args []any
+	err  error
+}
+
+func (e *execRecorder) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	e.sql, e.args = sql, arguments
+	return pgconn.NewCommandTag("INSERT 0 1"), e.err
+}
+
+func TestPublisher_PublishWritesEventInTransaction(t *testing.T) {
+	tx := &execRecorder{}
+	userID := uuid.New()
+
+	event, err := NewPublisher("loyalty").Publish(context.Background(), tx, "loyalty.tier_upgraded", &userID, map[string]string{"tier_name": "gold"})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, event.ID)
+	assert.Equal(t, "loyalty", event.Source)
+	assert.JSONEq(t, `{"tier_name":"gold"}`, string(event.Payload))
+	assert.Contains(t, tx.sql, "INSERT INTO outbox_events")
+	require.Len(t, tx.args, 7)
+	assert.Equal(t, event.ID, tx.args[0])
+	assert.Equal(t, "loyalty.tier_upgraded", tx.args[1])
+	assert.Equal(t, &userID, tx.args[3])
+}
+
+func TestPublisher_PublishKeepsRequestID(t *testing.T) {
+	tx := &execRecorder{}
+	ctx := logger.ContextWithCorrelationID(context.Background(), "req-123")
+
+	event, err := NewPublisher("loyalty").Publish(ctx, tx, "loyalty.tier_upgraded", nil, map[string]string{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", event.RequestID)
+	assert.Equal(t, "req-123", tx.args[5])
+}
+
+func TestPublisher_PublishFailsWithTransaction(t *testing.T) {
+	tx := &execRecorder{err: errors.New("tx aborted")}
+
+	event, err := NewPublisher("loyalty").Publish(context.Background(), tx, "loyalty.tier_upgraded", nil, map[string]string{})
+
+	require.Error(t, err)
+	assert.Nil(t, event)
+}
+
+func TestRelay_DeliversUnderPublishingRequestID(t *testing.T) {
+	store := &memoryStore{}
+	traced, untraced := newTestEvent("loyalty.tier_upgraded"), newTestEvent("documents.approved")
+	traced.RequestID = "req-123"
+	store.add(traced)
+	store.add(untraced)
+	deliverer := &recordingDeliverer{}
+
+	relay := NewRelay(store, deliverer, RelayConfig{})
+	assert.Equal(t, 2, relay.ProcessBatch(context.Background()))
+
+	assert.Equal(t, []string{"req-123", ""}, deliverer.requestIDs)
+}
+
+func TestRelay_DeliversPendingEvents(t *testing.T) {
+	store := &memoryStore{}
+	first, second := newTestEvent("loyalty.tier_upgraded"), newTestEvent("documents.approved")
+	store.add(first)
+	store.add(second)
+	deliverer := &recordingDeliverer{}
+
+	relay := NewRelay(store, deliverer, RelayConfig{})
+	assert.Equal(t, 2, relay.ProcessBatch(context.Background()))
+
+	assert.Equal(t, 2, deliverer.count())
+	assert.Equal(t, "delivered", store.get(first.ID).status)
+	assert.Equal(t, "delivered", store.get(second.ID).status)
+
+	// Delivered events aren't delivered again
+	assert.Equal(t, 0, relay.ProcessBatch(context.Background()))
+	assert.Equal(t, 2, deliverer.count())
+}
+
+func TestRelay_RetriesWithBackoffThenDelivers(t *testing.T) {
+	store := &memoryStore{}
+	event := newTestEvent("loyalty.tier_upgraded")
+	store.add(event)
+	deliverer := &recordingDeliverer{err: errors.New("realtime unavailable")}
+
+	relay := NewRelay(store, deliverer, RelayConfig{InitialBackoff: time.Minute, MaxAttempts: 5})
+	before := time.Now()
+	relay.ProcessBatch(context.Background())
+
+	stored := store.get(event.ID)
+	assert.Equal(t, "pending", stored.status)
+	assert.Equal(t, 1, stored.event.Attempts)
+	assert.Equal(t, "realtime unavailable", stored.lastError)
+	assert.WithinDuration(t, before.Add(time.Minute), stored.nextAttemptAt, time.Second)
+
+	// Not due yet
+	assert.Equal(t, 0, relay.ProcessBatch(context.Background()))
+
+	// Once due, the same event is delivered again with the same ID
+	store.mu.Lock()
+	stored.nextAttemptAt = time.Now().Add(-time.Second)
+	store.mu.Unlock()
+	deliverer.mu.Lock()
+	deliverer.err = nil
+	deliverer.mu.Unlock()
+
+	relay.ProcessBatch(context.Background())
+	assert.Equal(t, "delivered", store.get(event.ID).status)
+	require.Equal(t, 2, deliverer.count())
+	assert.Equal(t, deliverer.events[0].ID, deliverer.events[1].ID)
+}
+
+func TestRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	store := &memoryStore{}
+	event := newTestEvent("loyalty.tier_upgraded")
+	event.Attempts = 2
+	store.add(event)
+	deliverer := &recordingDeliverer{err: errors.New("bad request")}
+
+	relay := NewRelay(store, deliverer, RelayConfig{MaxAttempts: 3})
+	relay.ProcessBatch(context.Background())
+
+	stored := store.get(event.ID)
+	assert.Equal(t, "failed", stored.status)
+	assert.Equal(t, 3, stored.event.Attempts)
+	assert.Equal(t, "bad request", stored.lastError)
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := NewRelay(&memoryStore{}, &recordingDeliverer{}, RelayConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 4*time.Second, relay.backoff(3))
+	assert.Equal(t, 5*time.Second, relay.backoff(4))
+	assert.Equal(t, 5*time.Second, relay.backoff(20))
+}
+
+func TestRelay_ClaimErrorDeliversNothing(t *testing.T) {
+	store := &memoryStore{claimErr: errors.New("connection refused")}
+	deliverer := &recordingDeliverer{}
+
+	assert.Equal(t, 0, NewRelay(store, deliverer, RelayConfig{}).ProcessBatch(context.Background()))
+	assert.Equal(t, 0, deliverer.count())
+}
+
+func TestRelay_StartDeliversUntilStopped(t *testing.T) {
+	store := &memoryStore{}
+	deliverer := &recordingDeliverer{}
+	relay := NewRelay(store, deliverer, RelayConfig{PollInterval: 10 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		relay.Start(context.Background())
+		close(done)
+	}()
+
+	store.add(newTestEvent("loyalty.tier_upgraded"))
+	require.Eventually(t, func() bool { return deliverer.count() == 1 }, time.Second, 5*time.Millisecond)
+
+	relay.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay did not stop")
+	}
+}
+
+// realtimeRecorder records user broadcasts
+type realtimeRecorder struct {
+	userID uuid.UUID
+	event  realtime.Event
+	calls  int
+	err    error
+}
+
+func (r *realtimeRecorder) BroadcastRideUpdate(ctx context.Context, rideID uuid.UUID, event realtime.Event) error {
+	return errors.New("unexpected ride broadcast")
+}
+
+func (r *realtimeRecorder) BroadcastToUser(ctx context.Context, userID uuid.UUID, event realtime.Event) error {
+	r.calls++
+	r.userID = userID
+	r.event = event
+	return r.err
+}
+
+func TestRealtimeDeliverer_BroadcastsToUser(t *testing.T) {
+	event := newTestEvent("loyalty.tier_upgraded")
+	client := &realtimeRecorder{}
+
+	err := NewRealtimeDeliverer(client).Deliver(context.Background(), event)
+
+	require.NoError(t, err)
+	assert.Equal(t, *event.UserID, client.userID)
+	assert.Equal(t, "loyalty.tier_upgraded", client.event.Type)
+	data := client.event.Data.(map[string]interface{})
+	assert.Equal(t, "gold", data["tier_name"])
+	assert.Equal(t, event.ID.String(), data["event_id"])
+}
+
+func TestRealtimeDeliverer_BroadcastErrorIsReturned(t *testing.T) {
+	client := &realtimeRecorder{err: errors.New("realtime broadcast failed")}
+
+	err := NewRealtimeDeliverer(client).Deliver(context.Background(), newTestEvent("loyalty.tier_upgraded"))
+
+	assert.Error(t, err)
+}
+
+func TestRealtimeDeliverer_SkipsEventsWithoutUser(t *testing.T) {
+	event := newTestEvent("documents.approved")
+	event.UserID = nil
+	client := &realtimeRecorder{}
+
+	err := NewRealtimeDeliverer(client).Deliver(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.Zero(t, client.calls)
+}
+
+// busRecorder records published bus events
+type busRecorder struct {
+	subject string
+	event   *eventbus.Event
+}
+
+func (b *busRecorder) Publish(ctx context.Context, subject string, event *eventbus.Event) error {
+	b.subject, b.event = subject, event
+	return nil
+}
+
+func TestBusDeliverer_PublishesWithOutboxID(t *testing.T) {
+	event := newTestEvent("loyalty.tier_upgraded")
+	bus := &busRecorder{}
+
+	require.NoError(t, NewBusDeliverer(bus).Deliver(context.Background(), event))
+
+	assert.Equal(t, "loyalty.tier_upgraded", bus.subject)
+	assert.Equal(t, event.ID.String(), bus.event.ID)
+	assert.Equal(t, "loyalty", bus.event.Source)
+	assert.JSONEq(t, string(event.Payload), string(bus.event.Data))
+}
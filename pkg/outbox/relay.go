@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Delivery results
+const (
+	resultDelivered = "delivered"
+	resultRetry     = "retry"
+	resultFailed    = "failed"
+)
+
+var outboxDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "outbox_deliveries_total",
+	Help: "Total number of outbox delivery attempts by event type and result",
+}, []string{"type", "result"})
+
+// Deliverer hands an event to whatever consumes it. Returning an error has the
+// relay try again later.
+type Deliverer interface {
+	Deliver(ctx context.Context, event *Event) error
+}
+
+// RelayConfig holds relay configuration
+type RelayConfig struct {
+	BatchSize      int
+	PollInterval   time.Duration
+	LockDuration   time.Duration // How long a claimed event is held before another relay may take it
+	MaxAttempts    int           // Attempts before an event is marked failed
+	InitialBackoff time.Duration // Delay before the first retry; doubled for each later one
+	MaxBackoff     time.Duration
+}
+
+// DefaultRelayConfig returns the default relay configuration
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		BatchSize:      50,
+		PollInterval:   time.Second,
+		LockDuration:   time.Minute,
+		MaxAttempts:    10,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+	}
+}
+
+// Relay delivers pending outbox events
+type Relay struct {
+	store     Store
+	deliverer Deliverer
+	config    RelayConfig
+	stopCh    chan struct{}
+}
+
+// NewRelay creates a relay. Zero config values take their defaults.
+func NewRelay(store Store, deliverer Deliverer, config RelayConfig) *Relay {
+	defaults := DefaultRelayConfig()
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.LockDuration <= 0 {
+		config.LockDuration = defaults.LockDuration
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+
+	return &Relay{
+		store:     store,
+		deliverer: deliverer,
+		config:    config,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start delivers events until the context is cancelled or Stop is called
+func (r *Relay) Start(ctx context.Context) {
+	logger.Info("Outbox relay started")
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch suggests more are waiting, so go again without waiting
+		if r.ProcessBatch(ctx) == r.config.BatchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stopCh:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Outbox relay stopping due to context cancellation")
+			return
+		case <-r.stopCh:
+			logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the relay
+func (r *Relay) Stop() {
+	close(r.stopCh)
+}
+
+// ProcessBatch claims and delivers one batch of due events, returning how many
+// it claimed
+func (r *Relay) ProcessBatch(ctx context.Context) int {
+	now := time.Now()
+	events, err := r.store.ClaimPending(ctx, now, now.Add(r.config.LockDuration), r.config.BatchSize)
+	if err != nil {
+		logger.Error("Failed to claim outbox events", zap.Error(err))
+		return 0
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// Unprocessed claims are released when their lock expires
+			break
+		}
+		r.deliver(ctx, event)
+	}
+	return len(events)
+}
+
+// deliver delivers one event and records the outcome. An event whose outcome
+// can't be recorded is delivered again once its claim expires. Delivery runs
+// under the ID of the request that published the event, so deliverers forward
+// it and the logs of both can be joined.
+func (r *Relay) deliver(ctx context.Context, event *Event) {
+	if event.RequestID != "" {
+		ctx = logger.ContextWithCorrelationID(ctx, event.RequestID)
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, r.config.LockDuration)
+	err := r.deliverer.Deliver(deliverCtx, event)
+	cancel()
+
+	if err == nil {
+		outboxDeliveries.WithLabelValues(event.Type, resultDelivered).Inc()
+		if err := r.store.MarkDelivered(ctx, event.ID, time.Now()); err != nil {
+			logger.ErrorContext(ctx, "Failed to mark outbox event delivered", zap.String("event_id", event.ID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	attempt := event.Attempts + 1
+	if attempt >= r.config.MaxAttempts {
+		outboxDeliveries.WithLabelValues(event.Type, resultFailed).Inc()
+		logger.ErrorContext(ctx, "Giving up on outbox event",
+			zap.String("event_id", event.ID.String()),
+			zap.String("type", event.Type),
+			zap.Int("attempts", attempt),
+			zap.Error(err),
+		)
+		if err := r.store.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+			logger.ErrorContext(ctx, "Failed to mark outbox event failed", zap.String("event_id", event.ID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	outboxDeliveries.WithLabelValues(event.Type, resultRetry).Inc()
+	logger.WarnContext(ctx, "Outbox event delivery failed, will retry",
+		zap.String("event_id", event.ID.String()),
+		zap.String("type", event.Type),
+		zap.Int("attempt", attempt),
+		zap.Error(err),
+	)
+	if err := r.store.MarkRetry(ctx, event.ID, time.Now().Add(r.backoff(attempt)), err.Error()); err != nil {
+		logger.ErrorContext(ctx, "Failed to schedule outbox event retry", zap.String("event_id", event.ID.String()), zap.Error(err))
+	}
+}
+
+// backoff is how long to wait after the given failed attempt
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.config.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store is where the relay reads pending events and records their delivery
+type Store interface {
+	// ClaimPending locks up to limit events that are due for delivery until
+	// lockUntil, so concurrent relays don't deliver them at the same time
+	ClaimPending(ctx context.Context, now, lockUntil time.Time, limit int) ([]*Event, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error
+	// MarkRetry records a failed attempt and when to try again
+	MarkRetry(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error
+	// MarkFailed records a failed attempt after which the event is given up on
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error
+}
+
+// PostgresStore keeps the outbox in the outbox_events table
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresStore creates an outbox store
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// ClaimPending locks the oldest due events. Rows another relay is claiming are
+// skipped rather than waited on.
+func (s *PostgresStore) ClaimPending(ctx context.Context, now, lockUntil time.Time, limit int) ([]*Event, error) {
+	query := `
+		UPDATE outbox_events
+		SET locked_until = $2
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = 'pending'
+			  AND next_attempt_at <= $1
+			  AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, source, user_id, payload, COALESCE(request_id, ''), attempts, created_at
+	`
+
+	rows, err := s.db.Query(ctx, query, now, lockUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		event := &Event{}
+		if err := rows.Scan(
+			&event.ID, &event.Type, &event.Source, &event.UserID, &event.Payload,
+			&event.RequestID, &event.Attempts, &event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkDelivered records an event as delivered
+func (s *PostgresStore) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE outbox_events
+		SET status = 'delivered', delivered_at = $2, attempts = attempts + 1,
+		    locked_until = NULL, last_error = NULL
+		WHERE id = $1
+	`
+	_, err := s.db.Exec(ctx, query, id, at)
+	return err
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (s *PostgresStore) MarkRetry(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3, locked_until = NULL
+		WHERE id = $1
+	`
+	_, err := s.db.Exec(ctx, query, id, nextAttemptAt, lastError)
+	return err
+}
+
+// MarkFailed records a final failed attempt
+func (s *PostgresStore) MarkFailed(ctx context.Context, id uuid.UUID, lastError string) error {
+	query := `
+		UPDATE outbox_events
+		SET status = 'failed', attempts = attempts + 1, last_error = $2, locked_until = NULL
+		WHERE id = $1
+	`
+	_, err := s.db.Exec(ctx, query, id, lastError)
+	return err
+}
@@ -0,0 +1,78 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CursorParam is the query parameter that carries a page cursor
+const CursorParam = "cursor"
+
+// ErrInvalidCursor is returned for a cursor that wasn't issued by EncodeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last item of a page in a list ordered by a timestamp and then
+// ID. The next page starts after it, so rows added or removed meanwhile don't
+// shift the page boundaries the way they do with an offset.
+type Cursor struct {
+	At time.Time
+	ID uuid.UUID
+}
+
+// NewCursor returns the cursor for an item
+func NewCursor(at time.Time, id uuid.UUID) *Cursor {
+	return &Cursor{At: at, ID: id}
+}
+
+// Encode returns the cursor as an opaque string for clients to send back
+func (c *Cursor) Encode() string {
+	raw := c.At.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor made by Encode. An empty string is the start of
+// the list and decodes to nil.
+func DecodeCursor(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &Cursor{}
+	if cursor.At, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// WantsCursor reports whether the request pages by cursor rather than offset.
+// Clients ask for the first page with an empty cursor parameter.
+func WantsCursor(c *gin.Context) bool {
+	_, ok := c.GetQuery(CursorParam)
+	return ok
+}
+
+// Page splits items fetched with one more than limit into the page to return
+// and the cursor for the next page, which is empty on the last page. cursorOf
+// returns an item's position in the list's order.
+func Page[T any](items []T, limit int, cursorOf func(T) *Cursor) ([]T, string) {
+	if limit <= 0 || len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, cursorOf(items[len(items)-1]).Encode()
+}
@@ -0,0 +1,98 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 14, 15, 9, 26, 535897000, time.FixedZone("UTC+3", 3*60*60))
+	id := uuid.New()
+
+	decoded, err := DecodeCursor(NewCursor(at, id).Encode())
+
+	require.NoError(t, err)
+	require.NotNil(t, decoded)
+	assert.True(t, decoded.At.Equal(at))
+	assert.Equal(t, id, decoded.ID)
+}
+
+func TestDecodeCursor_EmptyIsFirstPage(t *testing.T) {
+	cursor, err := DecodeCursor("")
+
+	assert.NoError(t, err)
+	assert.Nil(t, cursor)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not base64":   "%%%",
+		"no separator": "bm90LWEtY3Vyc29y",
+		"bad time":     base64.RawURLEncoding.EncodeToString([]byte("yesterday|" + uuid.NewString())),
+		"bad id":       base64.RawURLEncoding.EncodeToString([]byte("2026-01-01T00:00:00Z|not-a-uuid")),
+	}
+
+	for name, encoded := range tests {
+		t.Run(name, func(t *testing.T) {
+			cursor, err := DecodeCursor(encoded)
+
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+			assert.Nil(t, cursor)
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]int, 4)
+	for i := range items {
+		items[i] = i
+	}
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	cursorOf := func(i int) *Cursor { return NewCursor(base.Add(time.Duration(i)*time.Minute), ids[i]) }
+
+	t.Run("more than limit", func(t *testing.T) {
+		page, next := Page(items, 3, cursorOf)
+
+		assert.Equal(t, []int{0, 1, 2}, page)
+		cursor, err := DecodeCursor(next)
+		require.NoError(t, err)
+		assert.Equal(t, ids[2], cursor.ID)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		page, next := Page(items, 4, cursorOf)
+
+		assert.Equal(t, items, page)
+		assert.Empty(t, next)
+	})
+}
+
+func TestWantsCursor(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"", false},
+		{"?limit=10&offset=20", false},
+		{"?cursor=", true},
+		{"?limit=10&cursor=abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil)
+
+			assert.Equal(t, tt.expected, WantsCursor(c))
+		})
+	}
+}
@@ -35,20 +35,23 @@ func ParseParams(c *gin.Context) Params {
 		return params
 	}
 
-	// Validate and sanitize limit
-	if params.Limit <= 0 {
-		params.Limit = DefaultLimit
+	params.Limit, params.Offset = Clamp(params.Limit, params.Offset)
+	return params
+}
+
+// Clamp sanitizes a page: a non-positive limit becomes DefaultLimit, a limit
+// over MaxLimit is capped at MaxLimit and a negative offset becomes 0
+func Clamp(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = DefaultLimit
 	}
-	if params.Limit > MaxLimit {
-		params.Limit = MaxLimit
+	if limit > MaxLimit {
+		limit = MaxLimit
 	}
-
-	// Validate and sanitize offset
-	if params.Offset < 0 {
-		params.Offset = DefaultOffset
+	if offset < 0 {
+		offset = DefaultOffset
 	}
-
-	return params
+	return limit, offset
 }
 
 // BuildMeta creates pagination metadata for responses
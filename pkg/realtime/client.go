@@ -0,0 +1,127 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/httpclient"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/resilience"
+)
+
+// Client pushes messages to WebSocket clients through the realtime service
+type Client interface {
+	// BroadcastRideUpdate sends an event to everyone in a ride's room. An empty
+	// event type is sent as EventRideUpdate.
+	BroadcastRideUpdate(ctx context.Context, rideID uuid.UUID, event Event) error
+	// BroadcastToUser sends an event to a user, or holds it until they reconnect
+	BroadcastToUser(ctx context.Context, userID uuid.UUID, event Event) error
+}
+
+// clientTimeout bounds each broadcast attempt
+const clientTimeout = 5 * time.Second
+
+// DefaultRetryConfig is how broadcasts are retried: briefly, since most events
+// are soon superseded by newer ones
+func DefaultRetryConfig() resilience.RetryConfig {
+	return resilience.RetryConfig{
+		MaxAttempts:       3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		BackoffMultiplier: 2.0,
+		EnableJitter:      true,
+		RetryableChecker:  isTransient,
+	}
+}
+
+// HTTPClient calls the realtime service's internal broadcast endpoints,
+// authenticating with the internal API key and retrying transient failures
+type HTTPClient struct {
+	client *httpclient.Client
+	apiKey string
+}
+
+// NewHTTPClient creates a client for the realtime service at baseURL
+func NewHTTPClient(baseURL, apiKey string) *HTTPClient {
+	return NewHTTPClientWithRetry(baseURL, apiKey, DefaultRetryConfig())
+}
+
+// NewHTTPClientWithRetry creates a client that retries as configured. Without a
+// RetryableChecker, every failure is retried.
+func NewHTTPClientWithRetry(baseURL, apiKey string, retry resilience.RetryConfig) *HTTPClient {
+	client := httpclient.NewClient(baseURL, clientTimeout)
+	httpclient.WithRetry(retry)(client)
+	return &HTTPClient{client: client, apiKey: apiKey}
+}
+
+// Ensure HTTPClient implements Client
+var _ Client = (*HTTPClient)(nil)
+
+// BroadcastRideUpdate sends an event to everyone in a ride's room
+func (c *HTTPClient) BroadcastRideUpdate(ctx context.Context, rideID uuid.UUID, event Event) error {
+	data, err := eventData(event)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, RideBroadcastPath, &RideBroadcast{
+		RideID: rideID.String(),
+		Type:   event.Type,
+		Data:   data,
+	})
+}
+
+// BroadcastToUser sends an event to a user
+func (c *HTTPClient) BroadcastToUser(ctx context.Context, userID uuid.UUID, event Event) error {
+	if event.Type == "" {
+		return errors.New("user broadcast needs an event type")
+	}
+	data, err := eventData(event)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, UserBroadcastPath, &UserBroadcast{
+		UserID: userID.String(),
+		Type:   event.Type,
+		Data:   data,
+	})
+}
+
+func (c *HTTPClient) post(ctx context.Context, path string, body interface{}) error {
+	headers := map[string]string{middleware.InternalAPIKeyHeader: c.apiKey}
+	if _, err := c.client.Post(ctx, path, body, headers); err != nil {
+		return fmt.Errorf("realtime broadcast failed: %w", err)
+	}
+	return nil
+}
+
+// eventData converts an event's data to the JSON object the endpoints take
+func eventData(event Event) (map[string]interface{}, error) {
+	if data, ok := event.Data.(map[string]interface{}); ok && data != nil {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s event: %w", event.Type, err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil || data == nil {
+		return nil, fmt.Errorf("%s event data is not a JSON object", event.Type)
+	}
+	return data, nil
+}
+
+// isTransient reports whether a failed broadcast may succeed if tried again:
+// network errors and server-side or throttling statuses, but not rejections
+// such as a bad API key
+func isTransient(err error) bool {
+	var httpErr *httpclient.HTTPError
+	if errors.As(err, &httpErr) {
+		return resilience.IsRetryableHTTPStatus(httpErr.StatusCode)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,120 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
+	"github.com/richxcame/ride-hailing/pkg/resilience"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRetryConfig retries like the default, without the waits
+func testRetryConfig() resilience.RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = time.Millisecond
+	return cfg
+}
+
+func TestBroadcastToUser_SendsPayloadWithAPIKey(t *testing.T) {
+	userID := uuid.New()
+
+	var path, apiKey string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		apiKey = r.Header.Get(middleware.InternalAPIKeyHeader)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	type etaData struct {
+		ETAMinutes int `json:"eta_minutes"`
+	}
+	client := NewHTTPClientWithRetry(server.URL, "secret", testRetryConfig())
+	err := client.BroadcastToUser(context.Background(), userID, Event{Type: EventETAUpdate, Data: etaData{ETAMinutes: 4}})
+
+	require.NoError(t, err)
+	assert.Equal(t, UserBroadcastPath, path)
+	assert.Equal(t, "secret", apiKey)
+	assert.Equal(t, userID.String(), body["user_id"])
+	assert.Equal(t, EventETAUpdate, body["type"])
+	assert.Equal(t, float64(4), body["data"].(map[string]interface{})["eta_minutes"])
+}
+
+func TestBroadcastRideUpdate_OmitsEmptyType(t *testing.T) {
+	rideID := uuid.New()
+
+	var path string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithRetry(server.URL, "secret", testRetryConfig())
+	err := client.BroadcastRideUpdate(context.Background(), rideID, Event{Data: map[string]interface{}{"status": "started"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, RideBroadcastPath, path)
+	assert.Equal(t, rideID.String(), body["ride_id"])
+	assert.NotContains(t, body, "type")
+	assert.Equal(t, "started", body["data"].(map[string]interface{})["status"])
+}
+
+func TestBroadcast_RetriesTransientFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientWithRetry(server.URL, "secret", testRetryConfig())
+	err := client.BroadcastRideUpdate(context.Background(), uuid.New(), Event{Data: map[string]interface{}{"status": "started"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestBroadcast_DoesNotRetryRejections(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(status)
+		}))
+
+		client := NewHTTPClientWithRetry(server.URL, "wrong", testRetryConfig())
+		err := client.BroadcastToUser(context.Background(), uuid.New(), Event{Type: "test", Data: map[string]interface{}{}})
+
+		assert.Error(t, err, "status %d", status)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "status %d", status)
+		server.Close()
+	}
+}
+
+func TestBroadcast_RejectsInvalidEvents(t *testing.T) {
+	// No server: a request would fail differently
+	client := NewHTTPClientWithRetry("http://127.0.0.1:0", "secret", testRetryConfig())
+
+	err := client.BroadcastToUser(context.Background(), uuid.New(), Event{Data: map[string]interface{}{}})
+	assert.ErrorContains(t, err, "event type")
+
+	err = client.BroadcastRideUpdate(context.Background(), uuid.New(), Event{Data: []string{"not", "an", "object"}})
+	assert.ErrorContains(t, err, "not a JSON object")
+}
@@ -0,0 +1,40 @@
+// Package realtime is the contract between the realtime service and the
+// services that push messages through it: the request bodies of its internal
+// broadcast endpoints, and a client for calling them.
+package realtime
+
+// Internal broadcast endpoints of the realtime service. Both require the
+// internal API key.
+const (
+	RideBroadcastPath = "/api/v1/internal/broadcast/ride"
+	UserBroadcastPath = "/api/v1/internal/broadcast/user"
+)
+
+// Event types pushed to WebSocket clients by other services
+const (
+	EventRideUpdate = "ride_update" // Default for ride broadcasts
+	EventETAUpdate  = "eta_update"
+)
+
+// Event is a message for WebSocket clients
+type Event struct {
+	Type string
+	// Data is the message body. It must marshal to a JSON object, e.g. a map or
+	// a struct.
+	Data interface{}
+}
+
+// RideBroadcast is the body of a ride broadcast, sent to everyone in the ride's room
+type RideBroadcast struct {
+	RideID string                 `json:"ride_id" binding:"required"`
+	Type   string                 `json:"type,omitempty"` // Defaults to EventRideUpdate
+	Data   map[string]interface{} `json:"data" binding:"required"`
+}
+
+// UserBroadcast is the body of a user broadcast, sent to the user's
+// connections or buffered until they reconnect
+type UserBroadcast struct {
+	UserID string                 `json:"user_id" binding:"required"`
+	Type   string                 `json:"type" binding:"required"`
+	Data   map[string]interface{} `json:"data" binding:"required"`
+}
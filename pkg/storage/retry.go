@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/resilience"
+)
+
+// RetryOptions configures the retry behaviour of RetryStorage
+type RetryOptions struct {
+	MaxAttempts       int           // Attempts per operation, including the first
+	InitialBackoff    time.Duration // Wait before the first retry
+	MaxBackoff        time.Duration // Upper bound for any single wait
+	BackoffMultiplier float64       // Growth factor between retries
+	// IsRetryable overrides the default transient-error check when set
+	IsRetryable func(error) bool
+}
+
+// DefaultRetryOptions returns retry options suited to object storage calls
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts:       3,
+		InitialBackoff:    200 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+		BackoffMultiplier: 2.0,
+	}
+}
+
+// RetryStorage wraps a Storage and retries operations that fail with transient
+// errors, using exponential backoff with jitter. Uploads are only retried when
+// the reader implements io.Seeker, since the body has to be replayed.
+type RetryStorage struct {
+	inner  Storage
+	config resilience.RetryConfig
+}
+
+// NewRetryStorage wraps inner with retries. Zero-valued options fall back to
+// DefaultRetryOptions.
+func NewRetryStorage(inner Storage, opts RetryOptions) *RetryStorage {
+	defaults := DefaultRetryOptions()
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaults.InitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	if opts.BackoffMultiplier <= 0 {
+		opts.BackoffMultiplier = defaults.BackoffMultiplier
+	}
+	if opts.IsRetryable == nil {
+		opts.IsRetryable = IsRetryableError
+	}
+
+	return &RetryStorage{
+		inner: inner,
+		config: resilience.RetryConfig{
+			MaxAttempts:       opts.MaxAttempts,
+			InitialBackoff:    opts.InitialBackoff,
+			MaxBackoff:        opts.MaxBackoff,
+			BackoffMultiplier: opts.BackoffMultiplier,
+			EnableJitter:      true,
+			RetryableChecker:  opts.IsRetryable,
+		},
+	}
+}
+
+// IsRetryableError reports whether a storage error is worth retrying. Context
+// cancellation and HTTP 4xx responses (other than 408 and 429) are permanent;
+// 5xx responses and errors without a status, such as network failures, are not.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return resilience.IsRetryableHTTPStatus(statusErr.HTTPStatusCode())
+	}
+
+	return true
+}
+
+// retryStorageOp runs op with the wrapper's retry policy
+func retryStorageOp[T any](ctx context.Context, r *RetryStorage, name string, op func(context.Context) (T, error)) (T, error) {
+	result, err := resilience.RetryWithName(ctx, r.config, func(ctx context.Context) (interface{}, error) {
+		return op(ctx)
+	}, name)
+	if err != nil {
+		return *new(T), err
+	}
+	return result.(T), nil
+}
+
+// rewindable returns a function that resets reader to its current position
+// before each attempt, or nil when the reader can't be replayed
+func rewindable(reader io.Reader) (func() error, error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil, nil
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reader position: %w", err)
+	}
+	return func() error {
+		_, err := seeker.Seek(start, io.SeekStart)
+		return err
+	}, nil
+}
+
+// Upload uploads a file, retrying when the reader can be rewound
+func (r *RetryStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*UploadResult, error) {
+	rewind, err := rewindable(reader)
+	if err != nil {
+		return nil, err
+	}
+	if rewind == nil {
+		return r.inner.Upload(ctx, key, reader, size, contentType)
+	}
+
+	return retryStorageOp(ctx, r, "storage.upload", func(ctx context.Context) (*UploadResult, error) {
+		if err := rewind(); err != nil {
+			return nil, fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		return r.inner.Upload(ctx, key, reader, size, contentType)
+	})
+}
+
+// UploadStream uploads a file of unknown size, retrying when the reader can be rewound
+func (r *RetryStorage) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*UploadResult, error) {
+	rewind, err := rewindable(reader)
+	if err != nil {
+		return nil, err
+	}
+	if rewind == nil {
+		return r.inner.UploadStream(ctx, key, reader, contentType)
+	}
+
+	return retryStorageOp(ctx, r, "storage.upload_stream", func(ctx context.Context) (*UploadResult, error) {
+		if err := rewind(); err != nil {
+			return nil, fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		return r.inner.UploadStream(ctx, key, reader, contentType)
+	})
+}
+
+// Download downloads a file
+func (r *RetryStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	return retryStorageOp(ctx, r, "storage.download", func(ctx context.Context) (io.ReadCloser, error) {
+		return r.inner.Download(ctx, key)
+	})
+}
+
+// Delete deletes a file
+func (r *RetryStorage) Delete(ctx context.Context, key string) error {
+	_, err := retryStorageOp(ctx, r, "storage.delete", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.inner.Delete(ctx, key)
+	})
+	return err
+}
+
+// GetURL returns the public URL for a file
+func (r *RetryStorage) GetURL(key string) string {
+	return r.inner.GetURL(key)
+}
+
+// GetPresignedUploadURL generates a presigned URL for direct upload
+func (r *RetryStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (*PresignedURLResult, error) {
+	return retryStorageOp(ctx, r, "storage.presign_upload", func(ctx context.Context) (*PresignedURLResult, error) {
+		return r.inner.GetPresignedUploadURL(ctx, key, contentType, expiresIn)
+	})
+}
+
+// GetPresignedDownloadURL generates a presigned URL for direct download
+func (r *RetryStorage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (*PresignedURLResult, error) {
+	return retryStorageOp(ctx, r, "storage.presign_download", func(ctx context.Context) (*PresignedURLResult, error) {
+		return r.inner.GetPresignedDownloadURL(ctx, key, expiresIn)
+	})
+}
+
+// Exists checks if a file exists
+func (r *RetryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return retryStorageOp(ctx, r, "storage.exists", func(ctx context.Context) (bool, error) {
+		return r.inner.Exists(ctx, key)
+	})
+}
+
+// Copy copies a file within storage
+func (r *RetryStorage) Copy(ctx context.Context, sourceKey, destKey string) error {
+	_, err := retryStorageOp(ctx, r, "storage.copy", func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, r.inner.Copy(ctx, sourceKey, destKey)
+	})
+	return err
+}
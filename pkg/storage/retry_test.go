@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusError is an error carrying an HTTP status, like the AWS SDK's response errors
+type statusError struct{ status int }
+
+func (e *statusError) Error() string       { return "storage request failed" }
+func (e *statusError) HTTPStatusCode() int { return e.status }
+
+// flakyStorage fails the first failures calls with err, then succeeds
+type flakyStorage struct {
+	Storage
+	failures int
+	err      error
+	calls    int
+	uploads  [][]byte
+}
+
+func (f *flakyStorage) fail() error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *flakyStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*UploadResult, error) {
+	data, _ := io.ReadAll(reader)
+	f.uploads = append(f.uploads, data)
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &UploadResult{Key: key, Size: int64(len(data))}, nil
+}
+
+func (f *flakyStorage) Delete(ctx context.Context, key string) error {
+	return f.fail()
+}
+
+func (f *flakyStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader([]byte("content"))), nil
+}
+
+func (f *flakyStorage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (*PresignedURLResult, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &PresignedURLResult{URL: "https://signed.example.com/" + key}, nil
+}
+
+func (f *flakyStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := f.fail(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func fastRetryOptions() RetryOptions {
+	return RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestRetryStorage_RetriesTransientErrors(t *testing.T) {
+	inner := &flakyStorage{failures: 2, err: &statusError{status: 503}}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	err := s.Delete(context.Background(), "docs/a.pdf")
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestRetryStorage_GivesUpAfterMaxAttempts(t *testing.T) {
+	inner := &flakyStorage{failures: 5, err: errors.New("connection reset")}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	_, err := s.Download(context.Background(), "docs/a.pdf")
+
+	require.Error(t, err)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestRetryStorage_DoesNotRetryClientErrors(t *testing.T) {
+	inner := &flakyStorage{failures: 5, err: &statusError{status: 404}}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	_, err := s.GetPresignedDownloadURL(context.Background(), "docs/a.pdf", time.Minute)
+
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestRetryStorage_RetriesExists(t *testing.T) {
+	inner := &flakyStorage{failures: 1, err: errors.New("connection reset")}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	exists, err := s.Exists(context.Background(), "docs/a.pdf")
+
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestRetryStorage_UploadReplaysSeekableReader(t *testing.T) {
+	inner := &flakyStorage{failures: 1, err: &statusError{status: 500}}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	result, err := s.Upload(context.Background(), "docs/a.pdf", bytes.NewReader([]byte("scan")), 4, "application/pdf")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), result.Size)
+	require.Len(t, inner.uploads, 2)
+	assert.Equal(t, "scan", string(inner.uploads[1]), "retry must resend the whole body")
+}
+
+func TestRetryStorage_UploadNonSeekableReaderIsNotRetried(t *testing.T) {
+	inner := &flakyStorage{failures: 1, err: &statusError{status: 500}}
+	s := NewRetryStorage(inner, fastRetryOptions())
+
+	_, err := s.Upload(context.Background(), "docs/a.pdf", io.MultiReader(bytes.NewReader([]byte("scan"))), 4, "application/pdf")
+
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.False(t, IsRetryableError(nil))
+	assert.False(t, IsRetryableError(context.Canceled))
+	assert.False(t, IsRetryableError(&statusError{status: 403}))
+	assert.True(t, IsRetryableError(&statusError{status: 429}))
+	assert.True(t, IsRetryableError(&statusError{status: 502}))
+	assert.True(t, IsRetryableError(errors.New("connection reset")))
+}
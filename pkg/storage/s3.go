@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -15,6 +16,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// s3PartSize is the chunk size for multipart uploads. S3 requires every part
+// except the last to be at least 5 MiB.
+const s3PartSize = 5 * 1024 * 1024
+
 // S3Storage implements Storage interface for AWS S3
 type S3Storage struct {
 	client  *s3.Client
@@ -111,6 +116,99 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 	}, nil
 }
 
+// UploadStream uploads a file of unknown size to S3. Files smaller than one part
+// are sent with a single PutObject; larger files use a multipart upload that is
+// aborted if any part fails.
+func (s *S3Storage) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*UploadResult, error) {
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(reader, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.Upload(ctx, key, bytes.NewReader(buf[:n]), int64(n), contentType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		ACL:         types.ObjectCannedACLPrivate,
+	})
+	if err != nil {
+		logger.Error("Failed to start S3 multipart upload", zap.String("key", key), zap.Error(err))
+		return nil, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	size, parts, err := s.uploadParts(ctx, key, created.UploadId, reader, buf[:n])
+	if err == nil {
+		_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// Don't leave orphaned parts behind, even if the caller has gone away
+		abortCtx := context.WithoutCancel(ctx)
+		if _, abortErr := s.client.AbortMultipartUpload(abortCtx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		}); abortErr != nil {
+			logger.Warn("Failed to abort S3 multipart upload", zap.String("key", key), zap.Error(abortErr))
+		}
+		logger.Error("Failed to upload to S3", zap.String("key", key), zap.Error(err))
+		return nil, fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	logger.Info("File uploaded to S3", zap.String("key", key), zap.Int64("size", size), zap.Int("parts", len(parts)))
+
+	return &UploadResult{
+		Key:        key,
+		URL:        s.GetURL(key),
+		Size:       size,
+		MimeType:   contentType,
+		UploadedAt: time.Now(),
+	}, nil
+}
+
+// uploadParts sends first and then the rest of reader as multipart upload parts,
+// returning the total size and the completed parts in order
+func (s *S3Storage) uploadParts(ctx context.Context, key string, uploadID *string, reader io.Reader, first []byte) (int64, []types.CompletedPart, error) {
+	var (
+		size  int64
+		parts []types.CompletedPart
+		chunk = first
+		buf   = make([]byte, s3PartSize)
+	)
+
+	for partNumber := int32(1); len(chunk) > 0; partNumber++ {
+		output, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(chunk),
+			ContentLength: aws.Int64(int64(len(chunk))),
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(partNumber)})
+		size += int64(len(chunk))
+
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		chunk = buf[:n]
+	}
+
+	return size, parts, nil
+}
+
 // Download downloads a file from S3
 func (s *S3Storage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	input := &s3.GetObjectInput{
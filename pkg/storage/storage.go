@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"path"
 	"strings"
 	"time"
@@ -56,6 +58,9 @@ type Storage interface {
 	// Upload uploads a file to storage
 	Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*UploadResult, error)
 
+	// UploadStream uploads a file of unknown size, reporting the stored size in the result
+	UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*UploadResult, error)
+
 	// Download downloads a file from storage
 	Download(ctx context.Context, key string) (io.ReadCloser, error)
 
@@ -85,15 +90,20 @@ func GenerateDocumentKey(driverID uuid.UUID, documentType, filename string) stri
 	timestamp := time.Now().Format("20060102")
 
 	// Format: drivers/{driver_id}/documents/{document_type}/{timestamp}_{unique_id}{ext}
-	return fmt.Sprintf("drivers/%s/documents/%s/%s_%s%s",
-		driverID.String(),
-		strings.ToLower(documentType),
+	return fmt.Sprintf("%s%s_%s%s",
+		DocumentKeyPrefix(driverID, documentType),
 		timestamp,
 		uniqueID,
 		ext,
 	)
 }
 
+// DocumentKeyPrefix returns the prefix of every key GenerateDocumentKey returns
+// for a driver and document type
+func DocumentKeyPrefix(driverID uuid.UUID, documentType string) string {
+	return fmt.Sprintf("drivers/%s/documents/%s/", driverID.String(), strings.ToLower(documentType))
+}
+
 // GenerateProfilePhotoKey generates a unique storage key for profile photos
 func GenerateProfilePhotoKey(userID uuid.UUID, filename string) string {
 	ext := path.Ext(filename)
@@ -137,6 +147,51 @@ func ValidateMimeType(mimeType string, allowedTypes []string) bool {
 	return false
 }
 
+// sniffLen is the number of bytes inspected to detect a file's content type
+const sniffLen = 512
+
+// DetectContentType sniffs a file's content type from its first bytes. It returns
+// a reader that still yields the full content, including the sniffed bytes. A
+// seekable reader is rewound and returned as is, so it stays seekable.
+func DetectContentType(reader io.Reader) (string, io.Reader, error) {
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	header = header[:n]
+
+	if seeker, ok := reader.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err == nil {
+			return sniffContentType(header), reader, nil
+		}
+	}
+
+	return sniffContentType(header), io.MultiReader(bytes.NewReader(header), reader), nil
+}
+
+// sniffContentType identifies PDF and WebP by their magic bytes and falls back to
+// http.DetectContentType for everything else. Parameters such as charset are dropped.
+func sniffContentType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		return "application/pdf"
+	case len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "image/webp"
+	}
+
+	return BaseMimeType(http.DetectContentType(header))
+}
+
+// BaseMimeType strips parameters from a MIME type and lower-cases it,
+// e.g. "Text/Plain; charset=utf-8" becomes "text/plain"
+func BaseMimeType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
 // GetMimeTypeFromExtension returns the MIME type for common file extensions
 func GetMimeTypeFromExtension(filename string) string {
 	ext := strings.ToLower(path.Ext(filename))
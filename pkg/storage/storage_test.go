@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F'}, "image/jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "image/png"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"pdf", []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3"), "application/pdf"},
+		{"text drops charset", []byte("hello world"), "text/plain"},
+		{"executable", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00"), "application/octet-stream"},
+		{"empty", nil, "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detected, reader, err := DetectContentType(bytes.NewReader(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, detected)
+
+			replayed, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.content), len(replayed))
+		})
+	}
+}
+
+func TestDetectContentType_PreservesLargeContent(t *testing.T) {
+	content := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("a"), 4096)...)
+
+	_, reader, err := DetectContentType(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	replayed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, replayed)
+}
+
+func TestDetectContentType_KeepsSeekableReader(t *testing.T) {
+	content := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3")
+	original := bytes.NewReader(content)
+
+	detected, reader, err := DetectContentType(original)
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", detected)
+	assert.Same(t, original, reader)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestDetectContentType_ReadError(t *testing.T) {
+	_, _, err := DetectContentType(failingReader{})
+	assert.Error(t, err)
+}
+
+func TestBaseMimeType(t *testing.T) {
+	assert.Equal(t, "text/plain", BaseMimeType("Text/Plain; charset=utf-8"))
+	assert.Equal(t, "image/jpeg", BaseMimeType("image/jpeg"))
+}
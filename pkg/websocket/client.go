@@ -3,9 +3,11 @@ package websocket
 import (
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -13,22 +15,38 @@ const (
 	// Time allowed to write a message to the peer
 	writeWait = 10 * time.Second
 
-	// Time allowed to read the next pong message from the peer
-	pongWait = 60 * time.Second
-
-	// Send pings to peer with this period (must be less than pongWait)
-	pingPeriod = (pongWait * 9) / 10
-
 	// Maximum message size allowed from peer
 	maxMessageSize = 512 * 1024 // 512KB
 )
 
+// Client roles
+const (
+	RoleRider  = "rider"
+	RoleDriver = "driver"
+	// RoleDispatcher observes many rides without taking part in them
+	RoleDispatcher = "dispatcher"
+)
+
+// ClientRole returns the role a user's connection acts in. Admins connect as
+// dispatchers; anyone who isn't a driver is treated as a rider.
+func ClientRole(userRole models.UserRole) string {
+	switch userRole {
+	case models.RoleDriver:
+		return RoleDriver
+	case models.RoleAdmin:
+		return RoleDispatcher
+	default:
+		return RoleRider
+	}
+}
+
 // Message represents a WebSocket message
 type Message struct {
 	Type      string                 `json:"type"` // Message type (location, status, chat, etc.)
 	RideID    string                 `json:"ride_id,omitempty"`
 	UserID    string                 `json:"user_id,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
+	Seq       int64                  `json:"seq,omitempty"` // Per-user sequence number for de-duplication
 	Data      map[string]interface{} `json:"data"`
 }
 
@@ -36,7 +54,7 @@ type Message struct {
 type Client struct {
 	ID        string          // Unique client identifier (user ID)
 	RideID    string          // Current ride ID (if in a ride)
-	Role      string          // "rider" or "driver"
+	Role      string          // RoleRider, RoleDriver or RoleDispatcher
 	Conn      *websocket.Conn // WebSocket connection
 	Send      chan *Message   // Buffered channel of outbound messages
 	Hub       *Hub            // Reference to hub
@@ -44,18 +62,37 @@ type Client struct {
 	mu        sync.RWMutex    // Protects concurrent access
 	closeOnce sync.Once       // Ensures channel is closed only once
 	closed    bool            // Tracks if channel is closed
+
+	lastPong    atomic.Int64 // When the last pong arrived, in Unix nanoseconds
+	lastInbound atomic.Int64 // When the client last sent a message, in Unix nanoseconds
+
+	priority      []*Message    // Messages written before any in Send, capped at maxPriorityQueue; protected by mu
+	priorityReady chan struct{} // Signalled when priority has messages
 }
 
 // NewClient creates a new WebSocket client
 func NewClient(id string, conn *websocket.Conn, hub *Hub, role string, logger *zap.Logger) *Client {
-	return &Client{
+	client := &Client{
 		ID:     id,
 		Conn:   conn,
 		Send:   make(chan *Message, 256),
 		Hub:    hub,
 		Role:   role,
 		logger: logger,
+
+		priorityReady: make(chan struct{}, 1),
 	}
+	// A new connection counts as active so the idle timeout runs from connecting
+	client.lastInbound.Store(time.Now().UnixNano())
+	return client
+}
+
+// keepalive returns the keepalive of the client's hub
+func (c *Client) keepalive() Keepalive {
+	if c.Hub == nil {
+		return DefaultKeepalive()
+	}
+	return c.Hub.Keepalive()
 }
 
 // ReadPump pumps messages from the WebSocket connection to the hub
@@ -65,13 +102,25 @@ func (c *Client) ReadPump() {
 		c.Conn.Close()
 	}()
 
-	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	readTimeout := c.keepalive().readTimeout()
+	extendDeadline := func() {
+		if readTimeout > 0 {
+			c.Conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+	}
+
+	extendDeadline()
 	c.Conn.SetReadLimit(maxMessageSize)
 	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.lastPong.Store(time.Now().UnixNano())
+		extendDeadline()
 		return nil
 	})
 
+	limits := c.Hub.Limits()
+	limiter := newTokenBucket(limits.MessagesPerSecond, limits.Burst)
+	violations := 0
+
 	for {
 		var msg Message
 		err := c.Conn.ReadJSON(&msg)
@@ -81,6 +130,29 @@ func (c *Client) ReadPump() {
 			}
 			break
 		}
+		c.lastInbound.Store(time.Now().UnixNano())
+		extendDeadline()
+
+		// Drop messages over the rate limit, closing the connection if it keeps
+		// flooding. Priority messages are always let through.
+		if !c.Hub.isPriority(msg.Type) && !limiter.allow(time.Now()) {
+			violations++
+			if limits.MaxViolations > 0 && violations > limits.MaxViolations {
+				c.logger.Warn("closing connection after repeated rate limit violations",
+					zap.String("client_id", c.ID), zap.Int("violations", violations))
+				break
+			}
+			c.SendMessage(&Message{
+				Type:      "rate_limited",
+				Timestamp: time.Now(),
+				Data: map[string]interface{}{
+					"dropped_type":   msg.Type,
+					"violations":     violations,
+					"max_violations": limits.MaxViolations,
+				},
+			})
+			continue
+		}
 
 		msg.Timestamp = time.Now()
 		msg.UserID = c.ID
@@ -90,21 +162,40 @@ func (c *Client) ReadPump() {
 	}
 }
 
-// WritePump pumps messages from the hub to the WebSocket connection
+// WritePump pumps messages from the hub to the WebSocket connection, priority
+// messages ahead of the rest. It also
+// pings the peer and reaps the connection when it stops answering or idles
+// past the hub's keepalive; the ticker stops with the pump.
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
-	defer func() {
-		ticker.Stop()
-		c.Conn.Close()
-	}()
+	liveness := &livenessCheck{keepalive: c.keepalive()}
+	var tick <-chan time.Time
+	if interval := liveness.keepalive.checkInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	defer c.Conn.Close()
 
 	for {
 		select {
+		case <-c.priorityReady:
+			if !c.writePriority() {
+				return
+			}
+
 		case message, ok := <-c.Send:
+			// Priority messages queued meanwhile go first
+			if !c.writePriority() {
+				return
+			}
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// Hub closed the channel
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeFrame := []byte{}
+				if c.Hub != nil && c.Hub.ShuttingDown() {
+					closeFrame = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				}
+				c.Conn.WriteMessage(websocket.CloseMessage, closeFrame)
 				return
 			}
 
@@ -113,35 +204,59 @@ func (c *Client) WritePump() {
 				return
 			}
 
-		case <-ticker.C:
+		case now := <-tick:
+			if reason := liveness.check(c, now); reason != "" {
+				c.reap(reason)
+				return
+			}
+			if liveness.keepalive.PingInterval <= 0 {
+				continue
+			}
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+			liveness.lastPingAt = now
 		}
 	}
 }
 
-// SendMessage sends a message to the client
+// SendMessage queues a message for the client without blocking. When the client
+// isn't keeping up and its queue is full, the oldest queued message is dropped
+// to make room, so a slow client misses stale updates rather than holding up
+// the hub.
 func (c *Client) SendMessage(msg *Message) {
+	// Held while sending so the channel can't be closed underneath
 	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.closed {
-		c.mu.Unlock()
 		return
 	}
-	c.mu.Unlock()
 
+	select {
+	case c.Send <- msg:
+		return
+	default:
+	}
+
+	select {
+	case <-c.Send:
+		c.messageDropped()
+	default:
+	}
+	// Only WritePump receives meanwhile, so there is room unless the channel is unbuffered
 	select {
 	case c.Send <- msg:
 	default:
-		c.logger.Warn("client channel full, closing connection", zap.String("client_id", c.ID))
-		c.mu.Lock()
-		c.closed = true
-		c.mu.Unlock()
-		c.closeOnce.Do(func() {
-			close(c.Send)
-		})
-		c.Hub.Unregister <- c
+		c.messageDropped()
+	}
+}
+
+// messageDropped counts a message the client was too slow to receive
+func (c *Client) messageDropped() {
+	wsMessagesDropped.Inc()
+	if c.Hub != nil {
+		c.Hub.counters.dropped.Add(1)
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -25,6 +26,14 @@ func TestNewClient(t *testing.T) {
 	assert.Equal(t, "", client.RideID)
 }
 
+// TestClientRole tests mapping user roles to client roles
+func TestClientRole(t *testing.T) {
+	assert.Equal(t, RoleRider, ClientRole(models.RoleRider))
+	assert.Equal(t, RoleDriver, ClientRole(models.RoleDriver))
+	assert.Equal(t, RoleDispatcher, ClientRole(models.RoleAdmin))
+	assert.Equal(t, RoleRider, ClientRole(models.UserRole("unknown")))
+}
+
 // TestClientSetRide tests setting ride ID
 func TestClientSetRide(t *testing.T) {
 	hub := NewHub()
@@ -108,14 +117,15 @@ func TestClientSendMessageChannelFull(t *testing.T) {
 		client.SendMessage(msg)
 	}
 
-	// This should trigger channel close due to overflow
+	// This should drop the oldest message to make room
 	msg := &Message{
 		Type: "overflow",
 		Data: map[string]interface{}{},
 	}
 	client.SendMessage(msg)
 
-	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, (<-client.Send).Data["count"])
+	assert.Equal(t, "overflow", (<-client.Send).Type)
 }
 
 // TestClientConcurrentRideAccess tests thread-safe ride ID access
@@ -5,22 +5,12 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
-	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/middleware"
 	"go.uber.org/zap"
 )
 
-// Claims represents JWT claims for WebSocket authentication
-type Claims struct {
-	UserID uuid.UUID       `json:"user_id"`
-	Email  string          `json:"email"`
-	Role   models.UserRole `json:"role"`
-	jwt.RegisteredClaims
-}
-
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -44,69 +34,48 @@ func HandleWebSocket(c *gin.Context, hub *Hub, jwtProvider jwtkeys.KeyProvider)
 		}
 	}
 
+	if hub.ShuttingDown() {
+		wsConnectionsRejected.WithLabelValues("shutting_down").Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+
 	if tokenString == "" {
+		wsConnectionsRejected.WithLabelValues("unauthorized").Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
 		return
 	}
 
 	// Parse and validate token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return resolveSigningKey(jwtProvider, token)
-	})
-
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+	claims, err := middleware.ParseAccessToken(jwtProvider, tokenString)
+	if err != nil {
+		wsConnectionsRejected.WithLabelValues("unauthorized").Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+	userID := claims.UserID.String()
+	if !hub.AcquireConnection(userID) {
+		wsConnectionsRejected.WithLabelValues("too_many_connections").Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many connections"})
 		return
 	}
 
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
+		hub.ReleaseConnection(userID)
+		wsConnectionsRejected.WithLabelValues("upgrade_failed").Inc()
 		zap.L().Error("failed to upgrade WebSocket", zap.Error(err))
 		return
 	}
 
-	// Determine role
-	role := "rider"
-	if claims.Role == models.RoleDriver {
-		role = "driver"
-	}
-
 	// Create client
-	client := NewClient(claims.UserID.String(), conn, hub, role, zap.L())
+	client := NewClient(userID, conn, hub, ClientRole(claims.Role), zap.L())
 
 	// Register client with hub
 	hub.Register <- client
 
 	// Start read/write pumps
-	go client.WritePump()
-	go client.ReadPump()
-}
-
-// resolveSigningKey resolves the JWT signing key
-func resolveSigningKey(provider jwtkeys.KeyProvider, token *jwt.Token) ([]byte, error) {
-	if provider == nil {
-		return nil, jwt.ErrInvalidKey
-	}
-
-	var kid string
-	if headerKid, ok := token.Header["kid"]; ok {
-		kid, _ = headerKid.(string)
-	}
-
-	if kid != "" {
-		return provider.ResolveKey(kid)
-	}
-
-	legacy := provider.LegacyKey()
-	if len(legacy) == 0 {
-		return nil, jwtkeys.ErrKeyNotFound
-	}
-	return legacy, nil
+	hub.StartPumps(client, func() { hub.ReleaseConnection(userID) })
 }
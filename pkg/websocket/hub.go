@@ -1,7 +1,10 @@
 package websocket
 
 import (
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"go.uber.org/zap"
@@ -18,9 +21,18 @@ type Hub struct {
 	// Clients grouped by ride ID
 	rides map[string]map[string]*Client
 
+	// Ride rooms each client is in, so disconnects can leave all of them
+	clientRides map[string]map[string]struct{}
+
 	// Clients grouped by negotiation session ID
 	negotiations map[string]map[string]*Client
 
+	// Connected dispatchers, who receive priority messages such as emergencies
+	dispatchers map[string]*Client
+
+	// Inbound message types exempt from the rate limit
+	priorityTypes map[string]bool
+
 	// Register requests from clients
 	Register chan *Client
 
@@ -33,6 +45,39 @@ type Hub struct {
 	// Message handlers by message type
 	handlers map[string]MessageHandler
 
+	// Called after a client has been registered
+	connectHandler func(*Client)
+
+	// Called after a client has been unregistered
+	disconnectHandler func(*Client)
+
+	// Inbound rate and connection limits
+	limits Limits
+
+	// Ping and idle checks applied to each connection
+	keepalive Keepalive
+
+	// Connection slots held per user ID
+	connections map[string]int
+
+	// Traffic counted for Stats
+	counters hubCounters
+
+	// Set once Shutdown starts draining the hub
+	shuttingDown atomic.Bool
+
+	// Drain requests from Shutdown, closed once queued broadcasts are delivered
+	drain chan chan struct{}
+
+	// Clients whose WritePump is still running, so Shutdown can wait for them
+	activeWriters atomic.Int64
+
+	// Connections spread over goroutines that deliver broadcasts to them
+	shards []*hubShard
+
+	// Set once Run has started the shards
+	running atomic.Bool
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
 }
@@ -46,20 +91,32 @@ type BroadcastMessage struct {
 
 // NewHub creates a new Hub instance
 func NewHub() *Hub {
-	return &Hub{
-		clients:      make(map[string]*Client),
-		rides:        make(map[string]map[string]*Client),
-		negotiations: make(map[string]map[string]*Client),
-		Register:     make(chan *Client),
-		Unregister:   make(chan *Client),
-		Broadcast:    make(chan *BroadcastMessage, 256),
-		handlers:     make(map[string]MessageHandler),
+	h := &Hub{
+		clients:       make(map[string]*Client),
+		rides:         make(map[string]map[string]*Client),
+		clientRides:   make(map[string]map[string]struct{}),
+		negotiations:  make(map[string]map[string]*Client),
+		dispatchers:   make(map[string]*Client),
+		priorityTypes: make(map[string]bool),
+		Register:      make(chan *Client),
+		Unregister:    make(chan *Client),
+		Broadcast:     make(chan *BroadcastMessage, 256),
+		drain:         make(chan chan struct{}),
+		handlers:      make(map[string]MessageHandler),
+		limits:        DefaultLimits(),
+		keepalive:     DefaultKeepalive(),
+		connections:   make(map[string]int),
 	}
+	h.SetShards(defaultHubShards())
+	return h
 }
 
-// Run starts the hub's main loop
+// Run starts the hub's main loop. Broadcasts fan in through the Broadcast
+// channel and are dispatched to the shards that own their recipients.
 func (h *Hub) Run() {
-	logger.Info("WebSocket Hub started")
+	h.running.Store(true)
+	shards := h.startShards()
+	logger.Info("WebSocket Hub started", zap.Int("shards", shards))
 	for {
 		select {
 		case client := <-h.Register:
@@ -70,17 +127,26 @@ func (h *Hub) Run() {
 
 		case broadcast := <-h.Broadcast:
 			h.broadcastMessage(broadcast)
+
+		case done := <-h.drain:
+			h.drainClients()
+			close(done)
 		}
 	}
 }
 
 // registerClient adds a client to the hub
 func (h *Hub) registerClient(client *Client) {
+	// Runs after the lock below is released so the handler can use the hub
+	defer h.notifyConnect(client)
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	// Remove existing client with same ID (e.g., reconnection)
+	// Remove existing client with same ID (e.g., reconnection). The new
+	// connection joins its rooms again, so the old one's memberships go.
 	if existingClient, ok := h.clients[client.ID]; ok {
+		h.leaveAllRides(client.ID)
 		// Safely close the old client's channel
 		existingClient.mu.Lock()
 		existingClient.closed = true
@@ -88,15 +154,37 @@ func (h *Hub) registerClient(client *Client) {
 		existingClient.closeOnce.Do(func() {
 			close(existingClient.Send)
 		})
+		h.counters.connectionClosed()
 		logger.Info("Replaced existing client connection", zap.String("client_id", client.ID))
+	} else {
+		wsConnectedClients.Inc()
 	}
 
 	h.clients[client.ID] = client
+	h.shardFor(client.ID).add(client)
+	if client.Role == RoleDispatcher {
+		h.dispatchers[client.ID] = client
+	} else {
+		delete(h.dispatchers, client.ID)
+	}
+	h.counters.connectionOpened()
+	if h.shuttingDown.Load() {
+		// Accepted just as the hub started draining; let it go straight away
+		client.closeSend()
+	}
 	logger.Info("Client registered", zap.String("client_id", client.ID), zap.String("role", client.Role))
 }
 
 // unregisterClient removes a client from the hub
 func (h *Hub) unregisterClient(client *Client) {
+	removed := false
+	// Runs after the lock below is released so the handler can use the hub
+	defer func() {
+		if removed {
+			h.notifyDisconnect(client)
+		}
+	}()
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -105,17 +193,11 @@ func (h *Hub) unregisterClient(client *Client) {
 		// Remove from clients map only if it's the same instance
 		// (a reconnected client may have already replaced this one)
 		delete(h.clients, client.ID)
+		delete(h.dispatchers, client.ID)
+		h.shardFor(client.ID).remove(client)
 
-		// Remove from ride room if in one
-		rideID := client.GetRide()
-		if rideID != "" {
-			if ride, ok := h.rides[rideID]; ok {
-				delete(ride, client.ID)
-				if len(ride) == 0 {
-					delete(h.rides, rideID)
-				}
-			}
-		}
+		// Remove from every ride room it joined or watched
+		h.leaveAllRides(client.ID)
 
 		// Close channel using sync.Once to prevent double-close
 		client.mu.Lock()
@@ -124,6 +206,9 @@ func (h *Hub) unregisterClient(client *Client) {
 		client.closeOnce.Do(func() {
 			close(client.Send)
 		})
+		removed = true
+		wsConnectedClients.Dec()
+		h.counters.connectionClosed()
 		logger.Info("Client unregistered", zap.String("client_id", client.ID))
 	} else if ok && existingClient != client {
 		// Old client trying to unregister after being replaced by a new connection
@@ -131,43 +216,55 @@ func (h *Hub) unregisterClient(client *Client) {
 	}
 }
 
-// broadcastMessage sends a message to target clients
+// broadcastMessage dispatches a message to the shards that own its target
+// clients. It doesn't wait for them to deliver it.
 func (h *Hub) broadcastMessage(broadcast *BroadcastMessage) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	start := time.Now()
+	defer func() {
+		h.counters.messageBroadcast(broadcast.Target, broadcast.Message.Type, time.Since(start))
+	}()
 
+	var dispatches []shardDispatch
+	h.mu.RLock()
 	switch broadcast.Target {
 	case "user":
 		// Send to specific user
 		if client, ok := h.clients[broadcast.TargetID]; ok {
 			logger.Info("Delivering message to user", zap.String("user_id", broadcast.TargetID), zap.String("type", broadcast.Message.Type))
-			client.SendMessage(broadcast.Message)
+			dispatches = h.groupByShard([]*Client{client}, broadcast.Message)
 		} else {
 			logger.Warn("User not found in hub for message delivery", zap.String("user_id", broadcast.TargetID), zap.String("type", broadcast.Message.Type), zap.Int("total_clients", len(h.clients)))
 		}
 
 	case "ride":
 		// Send to all clients in a ride
-		if ride, ok := h.rides[broadcast.TargetID]; ok {
-			for _, client := range ride {
-				client.SendMessage(broadcast.Message)
-			}
-		}
+		dispatches = h.groupByShard(roomClients(h.rides[broadcast.TargetID]), broadcast.Message)
 
 	case "negotiation":
 		// Send to all clients in a negotiation session
-		if negotiation, ok := h.negotiations[broadcast.TargetID]; ok {
-			for _, client := range negotiation {
-				client.SendMessage(broadcast.Message)
-			}
-		}
+		dispatches = h.groupByShard(roomClients(h.negotiations[broadcast.TargetID]), broadcast.Message)
 
 	case "all":
-		// Send to all connected clients
-		for _, client := range h.clients {
-			client.SendMessage(broadcast.Message)
+		// Each shard sends to every client it owns
+		for _, shard := range h.shards {
+			dispatches = append(dispatches, shardDispatch{shard: shard, delivery: shardDelivery{msg: broadcast.Message, all: true}})
 		}
 	}
+	h.mu.RUnlock()
+
+	// Queued after the lock is released so a busy shard doesn't hold up the hub's readers
+	for _, dispatch := range dispatches {
+		dispatch.shard.deliveries <- dispatch.delivery
+	}
+}
+
+// roomClients lists the clients in a room
+func roomClients(room map[string]*Client) []*Client {
+	clients := make([]*Client, 0, len(room))
+	for _, client := range room {
+		clients = append(clients, client)
+	}
+	return clients
 }
 
 // HandleMessage routes incoming messages to appropriate handlers
@@ -191,7 +288,50 @@ func (h *Hub) RegisterHandler(msgType string, handler MessageHandler) {
 	logger.Info("Registered handler for message type", zap.String("type", msgType))
 }
 
-// AddClientToRide adds a client to a ride room
+// OnConnect registers a handler that is called after a client has been registered
+func (h *Hub) OnConnect(handler func(*Client)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connectHandler = handler
+}
+
+// notifyConnect invokes the connect handler, if any
+func (h *Hub) notifyConnect(client *Client) {
+	h.mu.RLock()
+	handler := h.connectHandler
+	h.mu.RUnlock()
+
+	if handler != nil {
+		handler(client)
+	}
+}
+
+// OnDisconnect registers a handler that is called after a client disconnects.
+// The client's ride ID is still set when the handler runs.
+func (h *Hub) OnDisconnect(handler func(*Client)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disconnectHandler = handler
+}
+
+// notifyDisconnect invokes the disconnect handler, if any
+func (h *Hub) notifyDisconnect(client *Client) {
+	h.mu.RLock()
+	handler := h.disconnectHandler
+	h.mu.RUnlock()
+
+	if handler != nil {
+		handler(client)
+	}
+}
+
+// RideRoom returns the name of a ride's room
+func RideRoom(rideID string) string {
+	return "ride:" + rideID
+}
+
+// AddClientToRide adds a client to a ride room as a participant, making it the
+// client's current ride
 func (h *Hub) AddClientToRide(clientID, rideID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -201,16 +341,28 @@ func (h *Hub) AddClientToRide(clientID, rideID string) {
 		return
 	}
 
-	// Create ride room if doesn't exist
-	if _, ok := h.rides[rideID]; !ok {
-		h.rides[rideID] = make(map[string]*Client)
+	h.joinRide(client, rideID)
+	client.SetRide(rideID)
+
+	logger.Info("Client joined ride", zap.String("client_id", clientID), zap.String("room", RideRoom(rideID)))
+}
+
+// WatchRide adds a client to a ride room as an observer. Unlike AddClientToRide
+// it leaves the client's current ride alone, so one client can watch many rides.
+// It returns false if the client isn't connected.
+func (h *Hub) WatchRide(clientID, rideID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client, ok := h.clients[clientID]
+	if !ok {
+		return false
 	}
 
-	// Add client to ride room
-	h.rides[rideID][clientID] = client
-	client.SetRide(rideID)
+	h.joinRide(client, rideID)
 
-	logger.Info("Client joined ride", zap.String("client_id", clientID), zap.String("ride_id", rideID))
+	logger.Info("Client watching ride", zap.String("client_id", clientID), zap.String("room", RideRoom(rideID)))
+	return true
 }
 
 // RemoveClientFromRide removes a client from a ride room
@@ -218,6 +370,31 @@ func (h *Hub) RemoveClientFromRide(clientID, rideID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	h.leaveRide(clientID, rideID)
+
+	if client, ok := h.clients[clientID]; ok && client.GetRide() == rideID {
+		client.SetRide("")
+	}
+
+	logger.Info("Client left ride", zap.String("client_id", clientID), zap.String("room", RideRoom(rideID)))
+}
+
+// joinRide adds a client to a ride room. The caller must hold h.mu.
+func (h *Hub) joinRide(client *Client, rideID string) {
+	// Create ride room if doesn't exist
+	if _, ok := h.rides[rideID]; !ok {
+		h.rides[rideID] = make(map[string]*Client)
+	}
+	h.rides[rideID][client.ID] = client
+
+	if _, ok := h.clientRides[client.ID]; !ok {
+		h.clientRides[client.ID] = make(map[string]struct{})
+	}
+	h.clientRides[client.ID][rideID] = struct{}{}
+}
+
+// leaveRide removes a client from a ride room. The caller must hold h.mu.
+func (h *Hub) leaveRide(clientID, rideID string) {
 	if ride, ok := h.rides[rideID]; ok {
 		delete(ride, clientID)
 		if len(ride) == 0 {
@@ -225,11 +402,19 @@ func (h *Hub) RemoveClientFromRide(clientID, rideID string) {
 		}
 	}
 
-	if client, ok := h.clients[clientID]; ok {
-		client.SetRide("")
+	if rides, ok := h.clientRides[clientID]; ok {
+		delete(rides, rideID)
+		if len(rides) == 0 {
+			delete(h.clientRides, clientID)
+		}
 	}
+}
 
-	logger.Info("Client left ride", zap.String("client_id", clientID), zap.String("ride_id", rideID))
+// leaveAllRides removes a client from every ride room. The caller must hold h.mu.
+func (h *Hub) leaveAllRides(clientID string) {
+	for rideID := range h.clientRides[clientID] {
+		h.leaveRide(clientID, rideID)
+	}
 }
 
 // SendToUser sends a message to a specific user
@@ -280,6 +465,31 @@ func (h *Hub) GetClientsInRide(rideID string) []*Client {
 	return clients
 }
 
+// RoomMember describes a client in a ride room
+type RoomMember struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+	// Observer is true for clients watching the ride rather than taking part in it
+	Observer bool `json:"observer"`
+}
+
+// GetRoomMembers lists the clients in a ride room, ordered by user ID
+func (h *Hub) GetRoomMembers(rideID string) []RoomMember {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := make([]RoomMember, 0, len(h.rides[rideID]))
+	for _, client := range h.rides[rideID] {
+		members = append(members, RoomMember{
+			UserID:   client.ID,
+			Role:     client.Role,
+			Observer: client.GetRide() != rideID,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members
+}
+
 // GetClientCount returns the number of connected clients
 func (h *Hub) GetClientCount() int {
 	h.mu.RLock()
@@ -1,11 +1,14 @@
 package websocket
 
 import (
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -119,6 +122,40 @@ func TestUnregisterClientFromRide(t *testing.T) {
 	assert.Len(t, hub.GetClientsInRide(rideID), 0)
 }
 
+// TestOnDisconnect tests the disconnect handler runs once with the ride still set
+func TestOnDisconnect(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	disconnected := make(chan *Client, 2)
+	hub.OnDisconnect(func(c *Client) {
+		// The hub must be usable from inside the handler
+		_ = hub.GetClientsInRide(c.GetRide())
+		disconnected <- c
+	})
+
+	conn := createTestWebSocketConn(t)
+	client := NewClient("user-123", conn, hub, "rider", zap.NewNop())
+
+	hub.Register <- client
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(client.ID, "ride-789")
+
+	hub.Unregister <- client
+	hub.Unregister <- client
+
+	select {
+	case c := <-disconnected:
+		assert.Equal(t, client, c)
+		assert.Equal(t, "ride-789", c.GetRide())
+	case <-time.After(time.Second):
+		t.Fatal("disconnect handler was not called")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, disconnected)
+}
+
 // TestAddClientToRide tests adding client to ride room
 func TestAddClientToRide(t *testing.T) {
 	hub := NewHub()
@@ -336,6 +373,105 @@ func TestSendToRide(t *testing.T) {
 	}
 }
 
+// TestWatchRide tests a dispatcher watching several rides and leaving them all on disconnect
+func TestWatchRide(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	conn := createTestWebSocketConn(t)
+	dispatcher := NewClient("dispatcher-1", conn, hub, RoleDispatcher, zap.NewNop())
+	hub.Register <- dispatcher
+	time.Sleep(10 * time.Millisecond)
+
+	assert.True(t, hub.WatchRide(dispatcher.ID, "ride-1"))
+	assert.True(t, hub.WatchRide(dispatcher.ID, "ride-2"))
+	assert.False(t, hub.WatchRide("unknown", "ride-1"))
+
+	assert.Equal(t, 2, hub.GetRideCount())
+	assert.Empty(t, dispatcher.GetRide(), "watching must not change the current ride")
+
+	hub.SendToRide("ride-2", &Message{Type: "ride_update", RideID: "ride-2"})
+	select {
+	case msg := <-dispatcher.Send:
+		assert.Equal(t, "ride-2", msg.RideID)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("dispatcher did not receive ride update")
+	}
+
+	hub.Unregister <- dispatcher
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 0, hub.GetRideCount())
+}
+
+// TestGetRoomMembers tests listing participants and observers of a ride room
+func TestGetRoomMembers(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	rider := NewClient("rider-1", createTestWebSocketConn(t), hub, RoleRider, zap.NewNop())
+	driver := NewClient("driver-1", createTestWebSocketConn(t), hub, RoleDriver, zap.NewNop())
+	dispatcher := NewClient("dispatcher-1", createTestWebSocketConn(t), hub, RoleDispatcher, zap.NewNop())
+	hub.Register <- rider
+	hub.Register <- driver
+	hub.Register <- dispatcher
+	time.Sleep(10 * time.Millisecond)
+
+	hub.AddClientToRide(rider.ID, "ride-1")
+	hub.AddClientToRide(driver.ID, "ride-1")
+	hub.WatchRide(dispatcher.ID, "ride-1")
+
+	assert.Equal(t, []RoomMember{
+		{UserID: "dispatcher-1", Role: RoleDispatcher, Observer: true},
+		{UserID: "driver-1", Role: RoleDriver},
+		{UserID: "rider-1", Role: RoleRider},
+	}, hub.GetRoomMembers("ride-1"))
+	assert.Empty(t, hub.GetRoomMembers("ride-2"))
+	assert.Equal(t, "ride:ride-1", RideRoom("ride-1"))
+}
+
+// TestOnConnect tests the connect handler runs after registration
+func TestOnConnect(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	connected := make(chan *Client, 1)
+	hub.OnConnect(func(c *Client) {
+		// The hub must be usable from inside the handler
+		_, ok := hub.GetClient(c.ID)
+		assert.True(t, ok)
+		connected <- c
+	})
+
+	client := NewClient("user-123", createTestWebSocketConn(t), hub, RoleRider, zap.NewNop())
+	hub.Register <- client
+
+	select {
+	case c := <-connected:
+		assert.Equal(t, client, c)
+	case <-time.After(time.Second):
+		t.Fatal("connect handler was not called")
+	}
+}
+
+// TestReconnectLeavesOldRooms tests a replaced connection's rooms are dropped
+func TestReconnectLeavesOldRooms(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	first := NewClient("user-123", createTestWebSocketConn(t), hub, RoleRider, zap.NewNop())
+	hub.Register <- first
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(first.ID, "ride-1")
+
+	second := NewClient("user-123", createTestWebSocketConn(t), hub, RoleRider, zap.NewNop())
+	hub.Register <- second
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Empty(t, hub.GetClientsInRide("ride-1"))
+	assert.Equal(t, 0, hub.GetRideCount())
+}
+
 // TestSendToAll tests broadcasting to all clients
 func TestSendToAll(t *testing.T) {
 	hub := NewHub()
@@ -568,6 +704,54 @@ func TestGetRideCount(t *testing.T) {
 	assert.Equal(t, 3, hub.GetRideCount())
 }
 
+// TestHubStats tests the connection and traffic snapshot and the shared metrics
+func TestHubStats(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	connectedBefore := testutil.ToFloat64(wsConnectedClients)
+	openedBefore := testutil.ToFloat64(wsConnectionsOpened)
+	broadcastBefore := testutil.ToFloat64(wsMessagesBroadcast.WithLabelValues("ride_update"))
+
+	rider := NewClient("rider-1", createTestWebSocketConn(t), hub, RoleRider, zap.NewNop())
+	driver := NewClient("driver-1", createTestWebSocketConn(t), hub, RoleDriver, zap.NewNop())
+	hub.Register <- rider
+	hub.Register <- driver
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(rider.ID, "ride-1")
+
+	// A reconnect replaces the old connection without changing the client count
+	hub.Register <- NewClient("driver-1", createTestWebSocketConn(t), hub, RoleDriver, zap.NewNop())
+	time.Sleep(10 * time.Millisecond)
+
+	hub.SendToRide("ride-1", &Message{Type: "ride_update"})
+	hub.SendToRide("ride-1", &Message{Type: "ride_update"})
+	hub.SendToUser(rider.ID, &Message{Type: "chat_message"})
+	time.Sleep(10 * time.Millisecond)
+
+	stats := hub.Stats()
+	assert.Equal(t, 2, stats.ConnectedClients)
+	assert.Equal(t, 1, stats.ActiveRides)
+	assert.Equal(t, uint64(3), stats.ConnectionsOpened)
+	assert.Equal(t, uint64(1), stats.ConnectionsClosed)
+	assert.Equal(t, uint64(3), stats.MessagesBroadcast)
+	assert.Equal(t, map[string]uint64{"ride_update": 2, "chat_message": 1}, stats.MessagesByType)
+
+	assert.Equal(t, connectedBefore+2, testutil.ToFloat64(wsConnectedClients))
+	assert.Equal(t, openedBefore+3, testutil.ToFloat64(wsConnectionsOpened))
+	assert.Equal(t, broadcastBefore+2, testutil.ToFloat64(wsMessagesBroadcast.WithLabelValues("ride_update")))
+
+	hub.Unregister <- rider
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 1, hub.Stats().ConnectedClients)
+	assert.Equal(t, uint64(2), hub.Stats().ConnectionsClosed)
+	assert.Equal(t, connectedBefore+1, testutil.ToFloat64(wsConnectedClients))
+
+	// Metrics are registered once per process, so more hubs don't panic
+	assert.NotPanics(t, func() { NewHub() })
+}
+
 // TestBroadcastChannelCapacity tests broadcast channel buffering
 func TestBroadcastChannelCapacity(t *testing.T) {
 	hub := NewHub()
@@ -655,7 +839,121 @@ func TestClientChannelOverflow(t *testing.T) {
 		client.SendMessage(msg)
 	}
 
-	time.Sleep(10 * time.Millisecond)
+	// The oldest messages make room and the client stays connected
+	require.Len(t, client.Send, 2)
+	assert.Equal(t, 3, (<-client.Send).Data["count"])
+	assert.Equal(t, 4, (<-client.Send).Data["count"])
+	_, ok := hub.GetClient("user-123")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), hub.Stats().MessagesDropped)
+}
+
+func TestSendToUser_KeepsOrderPerConnection(t *testing.T) {
+	hub := NewHub()
+	hub.SetShards(4)
+	go hub.Run()
+
+	clients := make([]*Client, 8)
+	for i := range clients {
+		clients[i] = &Client{ID: fmt.Sprintf("user-%d", i), Send: make(chan *Message, 100), Hub: hub, logger: zap.NewNop()}
+		hub.Register <- clients[i]
+	}
+
+	for n := 0; n < 50; n++ {
+		for _, client := range clients {
+			hub.SendToUser(client.ID, &Message{Type: "seq", Data: map[string]interface{}{"n": n}})
+		}
+	}
+	hub.SendToAll(&Message{Type: "last"})
+
+	for _, client := range clients {
+		for n := 0; n < 50; n++ {
+			select {
+			case msg := <-client.Send:
+				require.Equal(t, n, msg.Data["n"], "client %s", client.ID)
+			case <-time.After(time.Second):
+				t.Fatalf("client %s missed message %d", client.ID, n)
+			}
+		}
+		select {
+		case msg := <-client.Send:
+			assert.Equal(t, "last", msg.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("client %s missed the broadcast", client.ID)
+		}
+	}
+}
+
+func TestSendToRide_SlowClientDoesNotHoldUpOthers(t *testing.T) {
+	hub := NewHub()
+	hub.SetShards(1)
+	go hub.Run()
+
+	slow := &Client{ID: "slow", Send: make(chan *Message, 1), Hub: hub, logger: zap.NewNop()}
+	fast := &Client{ID: "fast", Send: make(chan *Message, 100), Hub: hub, logger: zap.NewNop()}
+	hub.Register <- slow
+	hub.Register <- fast
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 2 }, time.Second, time.Millisecond)
+	hub.AddClientToRide("slow", "ride-1")
+	hub.AddClientToRide("fast", "ride-1")
+
+	for n := 0; n < 10; n++ {
+		hub.SendToRide("ride-1", &Message{Type: "location", Data: map[string]interface{}{"n": n}})
+	}
+
+	require.Eventually(t, func() bool { return len(fast.Send) == 10 }, time.Second, 5*time.Millisecond)
+	msg := <-slow.Send
+	assert.Equal(t, 9, msg.Data["n"], "the slow client keeps the latest update")
+}
+
+// benchmarkBroadcastToAll measures broadcasts to 10,000 connections spread over
+// the given number of shards. One shard delivers like a single hub loop.
+func benchmarkBroadcastToAll(b *testing.B, shards int) {
+	const connections = 10000
+
+	hub := NewHub()
+	hub.SetShards(shards)
+	hub.startShards()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < connections; i++ {
+		client := &Client{ID: fmt.Sprintf("user-%d", i), Send: make(chan *Message, 256), Hub: hub, logger: zap.NewNop()}
+		hub.mu.Lock()
+		hub.clients[client.ID] = client
+		hub.shardFor(client.ID).add(client)
+		hub.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-client.Send:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	b.Cleanup(func() {
+		close(stop)
+		wg.Wait()
+	})
+
+	msg := &Message{Type: "announcement"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hub.broadcastMessage(&BroadcastMessage{Target: "all", Message: msg})
+	}
+	hub.flushShards()
+	b.ReportMetric(float64(b.N*connections)/b.Elapsed().Seconds(), "deliveries/s")
+}
+
+func BenchmarkHub_BroadcastToAll_SingleShard(b *testing.B) {
+	benchmarkBroadcastToAll(b, 1)
+}
 
-	// Client should handle overflow gracefully (channel closed)
+func BenchmarkHub_BroadcastToAll_Sharded(b *testing.B) {
+	benchmarkBroadcastToAll(b, defaultHubShards())
 }
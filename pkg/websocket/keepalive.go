@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Default keepalive applied by NewHub
+const (
+	DefaultPingInterval   = 30 * time.Second
+	DefaultMaxMissedPongs = 2
+)
+
+// Reasons a connection is reaped
+const (
+	reapMissedPongs = "missed_pongs"
+	reapIdle        = "idle"
+)
+
+var wsConnectionsReaped = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "websocket_connections_reaped_total",
+	Help: "Total number of WebSocket connections closed by the server for missing pongs or idling",
+}, []string{"reason"})
+
+// Keepalive configures how the server detects dead and idle connections.
+// A zero value for any field disables that check.
+type Keepalive struct {
+	PingInterval   time.Duration // How often the server pings each connection
+	MaxMissedPongs int           // Consecutive pings left unanswered before the connection is closed
+	// IdleTimeout closes connections that send no messages for this long. Pongs
+	// don't count, so it also closes clients that only listen.
+	IdleTimeout time.Duration
+}
+
+// DefaultKeepalive returns the keepalive used by NewHub
+func DefaultKeepalive() Keepalive {
+	return Keepalive{
+		PingInterval:   DefaultPingInterval,
+		MaxMissedPongs: DefaultMaxMissedPongs,
+	}
+}
+
+// KeepaliveFromConfig converts WebSocket configuration into a hub keepalive
+func KeepaliveFromConfig(cfg config.WebSocketConfig) Keepalive {
+	return Keepalive{
+		PingInterval:   time.Duration(cfg.PingIntervalSeconds) * time.Second,
+		MaxMissedPongs: cfg.MaxMissedPongs,
+		IdleTimeout:    time.Duration(cfg.IdleTimeoutSeconds) * time.Second,
+	}
+}
+
+// SetKeepalive replaces the keepalive. It applies to connections opened after the call.
+func (h *Hub) SetKeepalive(keepalive Keepalive) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keepalive = keepalive
+}
+
+// Keepalive returns the current keepalive
+func (h *Hub) Keepalive() Keepalive {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.keepalive
+}
+
+// checkInterval is how often a connection's liveness is checked, or zero when
+// nothing is checked
+func (k Keepalive) checkInterval() time.Duration {
+	if k.PingInterval > 0 {
+		return k.PingInterval
+	}
+	if k.IdleTimeout > 0 {
+		return k.IdleTimeout / 4
+	}
+	return 0
+}
+
+// readTimeout is how long a read may wait for any frame before the connection
+// is given up on. It backs up the pong check should the writer be stuck, so it
+// allows a write's worth of time more than the pings do.
+func (k Keepalive) readTimeout() time.Duration {
+	if k.PingInterval <= 0 || k.MaxMissedPongs <= 0 {
+		return 0
+	}
+	return k.PingInterval*time.Duration(k.MaxMissedPongs+1) + writeWait
+}
+
+// livenessCheck tracks the pings a client's write loop has sent. It is only
+// used from that loop.
+type livenessCheck struct {
+	keepalive  Keepalive
+	lastPingAt time.Time
+	missed     int
+}
+
+// check reports why the connection should be reaped at now, if it should
+func (l *livenessCheck) check(c *Client, now time.Time) string {
+	if !l.lastPingAt.IsZero() {
+		if c.lastPong.Load() < l.lastPingAt.UnixNano() {
+			l.missed++
+		} else {
+			l.missed = 0
+		}
+	}
+	if l.keepalive.MaxMissedPongs > 0 && l.missed >= l.keepalive.MaxMissedPongs {
+		return reapMissedPongs
+	}
+	if l.keepalive.IdleTimeout > 0 && now.Sub(time.Unix(0, c.lastInbound.Load())) >= l.keepalive.IdleTimeout {
+		return reapIdle
+	}
+	return ""
+}
+
+// reap closes a connection that failed a liveness check. Closing it ends the
+// read loop, which removes the client from the hub.
+func (c *Client) reap(reason string) {
+	c.logger.Info("reaping WebSocket connection", zap.String("client_id", c.ID), zap.String("reason", reason))
+	wsConnectionsReaped.WithLabelValues(reason).Inc()
+	if c.Hub != nil {
+		c.Hub.counters.reaped.Add(1)
+	}
+
+	closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+	c.Conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(writeWait))
+	c.Conn.Close()
+}
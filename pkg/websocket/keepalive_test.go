@@ -0,0 +1,200 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/richxcame/ride-hailing/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestKeepaliveFromConfig(t *testing.T) {
+	keepalive := KeepaliveFromConfig(config.WebSocketConfig{
+		PingIntervalSeconds: 15,
+		MaxMissedPongs:      3,
+		IdleTimeoutSeconds:  300,
+	})
+
+	assert.Equal(t, Keepalive{PingInterval: 15 * time.Second, MaxMissedPongs: 3, IdleTimeout: 5 * time.Minute}, keepalive)
+}
+
+func TestLivenessCheck_MissedPongs(t *testing.T) {
+	client := NewClient("user-1", nil, nil, RoleRider, zap.NewNop())
+	live := &livenessCheck{keepalive: Keepalive{PingInterval: time.Second, MaxMissedPongs: 2}}
+	now := time.Now()
+
+	assert.Empty(t, live.check(client, now), "nothing is missed before the first ping")
+	live.lastPingAt = now
+
+	now = now.Add(time.Second)
+	assert.Empty(t, live.check(client, now), "one missed pong is tolerated")
+	live.lastPingAt = now
+
+	// A pong resets the count
+	client.lastPong.Store(now.Add(time.Millisecond).UnixNano())
+	now = now.Add(time.Second)
+	assert.Empty(t, live.check(client, now))
+	assert.Equal(t, 0, live.missed)
+	live.lastPingAt = now
+
+	now = now.Add(time.Second)
+	assert.Empty(t, live.check(client, now))
+	live.lastPingAt = now
+	now = now.Add(time.Second)
+	assert.Equal(t, reapMissedPongs, live.check(client, now))
+}
+
+func TestLivenessCheck_Idle(t *testing.T) {
+	client := NewClient("user-1", nil, nil, RoleRider, zap.NewNop())
+	live := &livenessCheck{keepalive: Keepalive{IdleTimeout: time.Minute}}
+	connected := time.Unix(0, client.lastInbound.Load())
+
+	assert.Empty(t, live.check(client, connected.Add(30*time.Second)))
+	assert.Equal(t, reapIdle, live.check(client, connected.Add(time.Minute)))
+
+	client.lastInbound.Store(connected.Add(time.Minute).UnixNano())
+	assert.Empty(t, live.check(client, connected.Add(time.Minute+time.Second)))
+}
+
+func TestKeepalive_Disabled(t *testing.T) {
+	k := Keepalive{}
+	assert.Zero(t, k.checkInterval())
+	assert.Zero(t, k.readTimeout())
+
+	assert.Equal(t, 15*time.Second, Keepalive{IdleTimeout: time.Minute}.checkInterval())
+}
+
+// startKeepaliveClient runs both pumps of a registered client behind a test
+// server and returns the dialed peer connection and a channel closed once the
+// write loop has returned
+func startKeepaliveClient(t *testing.T, hub *Hub) (*websocket.Conn, <-chan struct{}) {
+	t.Helper()
+
+	writerDone := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient("user-1", conn, hub, RoleRider, zap.NewNop())
+		hub.Register <- client
+		go func() {
+			client.WritePump()
+			close(writerDone)
+		}()
+		go client.ReadPump()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, writerDone
+}
+
+// readUntilClosed reads from the peer connection, answering pings unless a ping
+// handler says otherwise, and returns the error that ended the connection
+func readUntilClosed(conn *websocket.Conn) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	return errs
+}
+
+func TestKeepalive_ReapsUnresponsivePeer(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetKeepalive(Keepalive{PingInterval: 20 * time.Millisecond, MaxMissedPongs: 2})
+	reapedBefore := testutil.ToFloat64(wsConnectionsReaped.WithLabelValues(reapMissedPongs))
+
+	conn, writerDone := startKeepaliveClient(t, hub)
+	// The peer swallows pings instead of answering them
+	conn.SetPingHandler(func(string) error { return nil })
+	errs := readUntilClosed(conn)
+
+	select {
+	case err := <-errs:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "expected going-away close, got %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("unresponsive connection was not closed")
+	}
+
+	select {
+	case <-writerDone:
+	case <-time.After(time.Second):
+		t.Fatal("write loop did not stop after reaping")
+	}
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, uint64(1), hub.Stats().ConnectionsReaped)
+	assert.Equal(t, reapedBefore+1, testutil.ToFloat64(wsConnectionsReaped.WithLabelValues(reapMissedPongs)))
+}
+
+func TestKeepalive_KeepsResponsivePeer(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetKeepalive(Keepalive{PingInterval: 20 * time.Millisecond, MaxMissedPongs: 2})
+
+	conn, _ := startKeepaliveClient(t, hub)
+	errs := readUntilClosed(conn)
+
+	select {
+	case err := <-errs:
+		t.Fatalf("responsive connection was closed: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Equal(t, 1, hub.GetClientCount())
+	assert.Zero(t, hub.Stats().ConnectionsReaped)
+}
+
+func TestKeepalive_ReapsIdlePeer(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetKeepalive(Keepalive{PingInterval: 10 * time.Millisecond, MaxMissedPongs: 5, IdleTimeout: 80 * time.Millisecond})
+	reapedBefore := testutil.ToFloat64(wsConnectionsReaped.WithLabelValues(reapIdle))
+
+	// The peer answers pings but never sends anything
+	conn, writerDone := startKeepaliveClient(t, hub)
+	errs := readUntilClosed(conn)
+
+	select {
+	case err := <-errs:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "expected going-away close, got %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle connection was not closed")
+	}
+	<-writerDone
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, reapedBefore+1, testutil.ToFloat64(wsConnectionsReaped.WithLabelValues(reapIdle)))
+}
+
+func TestKeepalive_WritePumpStopsWhenPeerLeaves(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetKeepalive(Keepalive{PingInterval: 20 * time.Millisecond, MaxMissedPongs: 2})
+
+	conn, writerDone := startKeepaliveClient(t, hub)
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+	conn.Close()
+
+	// The read loop unregisters the client, closing Send, which ends the write loop
+	select {
+	case <-writerDone:
+	case <-time.After(time.Second):
+		t.Fatal("write loop did not stop after the peer left")
+	}
+	assert.Zero(t, hub.Stats().ConnectionsReaped)
+}
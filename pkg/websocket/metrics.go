@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registered once per process, so any number of hubs can be created (e.g. in tests).
+// Hubs in the same process share them; Hub.Stats reports a single hub.
+var (
+	wsConnectedClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "websocket_connected_clients",
+		Help: "Number of currently connected WebSocket clients",
+	})
+
+	wsConnectionsOpened = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "websocket_connections_opened_total",
+		Help: "Total number of WebSocket connections registered with the hub",
+	})
+
+	wsConnectionsClosed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "websocket_connections_closed_total",
+		Help: "Total number of WebSocket connections that disconnected or were replaced",
+	})
+
+	wsConnectionsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_connections_rejected_total",
+		Help: "Total number of WebSocket connection attempts rejected before upgrading",
+	}, []string{"reason"})
+
+	wsMessagesBroadcast = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_messages_broadcast_total",
+		Help: "Total number of messages broadcast through the hub, by message type",
+	}, []string{"type"})
+
+	wsMessagesDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "websocket_messages_dropped_total",
+		Help: "Total number of queued messages dropped because a client wasn't reading them fast enough",
+	})
+
+	wsBroadcastDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "websocket_broadcast_duration_seconds",
+		Help:    "Time taken to dispatch a broadcast to the shards of its recipients",
+		Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10), // 10µs to ~2.6s
+	}, []string{"target"})
+)
+
+// HubStats is a snapshot of a hub's connections and traffic
+type HubStats struct {
+	ConnectedClients   int               `json:"connected_clients"`
+	ActiveRides        int               `json:"active_rides"`
+	ActiveNegotiations int               `json:"active_negotiations"`
+	ConnectionsOpened  uint64            `json:"connections_opened"`
+	ConnectionsClosed  uint64            `json:"connections_closed"`
+	ConnectionsReaped  uint64            `json:"connections_reaped"`
+	MessagesBroadcast  uint64            `json:"messages_broadcast"`
+	MessagesDropped    uint64            `json:"messages_dropped"`
+	MessagesByType     map[string]uint64 `json:"messages_by_type"`
+}
+
+// hubCounters counts a single hub's traffic for Stats
+type hubCounters struct {
+	opened     atomic.Uint64
+	closed     atomic.Uint64
+	reaped     atomic.Uint64
+	broadcasts atomic.Uint64
+	dropped    atomic.Uint64
+
+	mu     sync.Mutex
+	byType map[string]uint64
+}
+
+func (c *hubCounters) connectionOpened() {
+	c.opened.Add(1)
+	wsConnectionsOpened.Inc()
+}
+
+func (c *hubCounters) connectionClosed() {
+	c.closed.Add(1)
+	wsConnectionsClosed.Inc()
+}
+
+func (c *hubCounters) messageBroadcast(target, msgType string, elapsed time.Duration) {
+	c.broadcasts.Add(1)
+	c.mu.Lock()
+	if c.byType == nil {
+		c.byType = make(map[string]uint64)
+	}
+	c.byType[msgType]++
+	c.mu.Unlock()
+
+	wsMessagesBroadcast.WithLabelValues(msgType).Inc()
+	wsBroadcastDuration.WithLabelValues(target).Observe(elapsed.Seconds())
+}
+
+func (c *hubCounters) messagesByType() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byType := make(map[string]uint64, len(c.byType))
+	for msgType, n := range c.byType {
+		byType[msgType] = n
+	}
+	return byType
+}
+
+// Stats returns a snapshot of the hub's connections and the traffic it has handled
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	stats := HubStats{
+		ConnectedClients:   len(h.clients),
+		ActiveRides:        len(h.rides),
+		ActiveNegotiations: len(h.negotiations),
+	}
+	h.mu.RUnlock()
+
+	stats.ConnectionsOpened = h.counters.opened.Load()
+	stats.ConnectionsClosed = h.counters.closed.Load()
+	stats.ConnectionsReaped = h.counters.reaped.Load()
+	stats.MessagesBroadcast = h.counters.broadcasts.Load()
+	stats.MessagesDropped = h.counters.dropped.Load()
+	stats.MessagesByType = h.counters.messagesByType()
+	return stats
+}
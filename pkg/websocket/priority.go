@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// maxPriorityQueue caps a client's priority queue. Priority messages are rare,
+// so a queue this long means the peer has stopped reading or something is
+// flooding it; the oldest are dropped rather than growing without bound.
+const maxPriorityQueue = 256
+
+// RegisterPriorityHandler registers a handler for a message type that must never
+// be held back, such as an emergency. Messages of the type skip the inbound rate
+// limit and don't count as violations.
+func (h *Hub) RegisterPriorityHandler(msgType string, handler MessageHandler) {
+	h.mu.Lock()
+	h.priorityTypes[msgType] = true
+	h.mu.Unlock()
+
+	h.RegisterHandler(msgType, handler)
+}
+
+// isPriority reports whether inbound messages of a type bypass the rate limit
+func (h *Hub) isPriority(msgType string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.priorityTypes[msgType]
+}
+
+// SendToDispatchers sends a message straight to every connected dispatcher on
+// their priority queue, bypassing the broadcast queue and the shards, and
+// returns how many it reached. Priority messages aren't dropped because a
+// dispatcher's regular queue is backed up.
+func (h *Hub) SendToDispatchers(msg *Message) int {
+	start := time.Now()
+
+	h.mu.RLock()
+	dispatchers := roomClients(h.dispatchers)
+	h.mu.RUnlock()
+
+	for _, client := range dispatchers {
+		client.SendPriority(msg)
+	}
+	h.counters.messageBroadcast("dispatch", msg.Type, time.Since(start))
+
+	if len(dispatchers) == 0 {
+		logger.Warn("No dispatcher connected for priority message", zap.String("type", msg.Type))
+	}
+	return len(dispatchers)
+}
+
+// GetDispatcherCount returns the number of connected dispatchers
+func (h *Hub) GetDispatcherCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.dispatchers)
+}
+
+// SendPriority queues a message ahead of the client's regular messages. Unlike
+// SendMessage it doesn't drop anything while the client keeps reading; only once
+// maxPriorityQueue messages are waiting is the oldest dropped, so it is for rare
+// messages that must arrive.
+func (c *Client) SendPriority(msg *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+
+	if len(c.priority) >= maxPriorityQueue {
+		c.priority = c.priority[1:]
+		c.messageDropped()
+	}
+	c.priority = append(c.priority, msg)
+	select {
+	case c.priorityReady <- struct{}{}:
+	default:
+	}
+}
+
+// takePriority empties the client's priority queue, returning what was on it
+func (c *Client) takePriority() []*Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queued := c.priority
+	c.priority = nil
+	return queued
+}
+
+// writePriority writes the queued priority messages to the peer, returning false
+// if the connection failed
+func (c *Client) writePriority() bool {
+	for _, message := range c.takePriority() {
+		c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.Conn.WriteJSON(message); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadPump_PriorityMessagesSkipRateLimit(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetLimits(Limits{MessagesPerSecond: 0.001, Burst: 1, MaxViolations: 1})
+
+	var handled int32
+	hub.RegisterPriorityHandler("emergency", func(*Client, *Message) { atomic.AddInt32(&handled, 1) })
+
+	_, conn, done := startRateLimitedClient(t, hub)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "emergency"}))
+	}
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&handled) == 5 }, time.Second, 5*time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("priority messages counted as violations")
+	default:
+	}
+}
+
+func TestSendToDispatchers_NeverDropsForSlowDispatcher(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	dispatcher := &Client{ID: "dispatcher-1", Role: RoleDispatcher, Send: make(chan *Message, 1), Hub: hub, logger: zap.NewNop(), priorityReady: make(chan struct{}, 1)}
+	rider := &Client{ID: "rider-1", Role: RoleRider, Send: make(chan *Message, 10), Hub: hub, logger: zap.NewNop(), priorityReady: make(chan struct{}, 1)}
+	hub.Register <- dispatcher
+	hub.Register <- rider
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, hub.GetDispatcherCount())
+
+	// The dispatcher's regular queue is full and nobody is reading it
+	dispatcher.SendMessage(&Message{Type: "location"})
+
+	for n := 0; n < 5; n++ {
+		assert.Equal(t, 1, hub.SendToDispatchers(&Message{Type: "emergency", Data: map[string]interface{}{"n": n}}))
+	}
+
+	queued := dispatcher.takePriority()
+	require.Len(t, queued, 5)
+	for n, msg := range queued {
+		assert.Equal(t, n, msg.Data["n"])
+	}
+	assert.Len(t, dispatcher.Send, 1, "regular queue untouched")
+	assert.Empty(t, rider.takePriority())
+	assert.Empty(t, rider.Send)
+
+	hub.Unregister <- dispatcher
+	require.Eventually(t, func() bool { return hub.GetDispatcherCount() == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, hub.SendToDispatchers(&Message{Type: "emergency"}))
+}
+
+func TestWritePump_WritesPriorityMessages(t *testing.T) {
+	hub := NewHub()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient("dispatcher-1", conn, hub, RoleDispatcher, zap.NewNop())
+		client.SendPriority(&Message{Type: "emergency", Timestamp: time.Now()})
+		client.WritePump()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "emergency", msg.Type)
+}
+
+func TestSendPriority_CapsQueue(t *testing.T) {
+	client := &Client{ID: "dispatcher-1", Role: RoleDispatcher, logger: zap.NewNop(), priorityReady: make(chan struct{}, 1)}
+
+	for n := 0; n < maxPriorityQueue+3; n++ {
+		client.SendPriority(&Message{Type: "emergency", Data: map[string]interface{}{"n": n}})
+	}
+
+	queued := client.takePriority()
+	require.Len(t, queued, maxPriorityQueue)
+	assert.Equal(t, 3, queued[0].Data["n"], "oldest dropped first")
+	assert.Equal(t, maxPriorityQueue+2, queued[len(queued)-1].Data["n"])
+}
@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/config"
+)
+
+// Default inbound limits applied by NewHub
+const (
+	DefaultMessagesPerSecond     = 20
+	DefaultMessageBurst          = 40
+	DefaultMaxRateViolations     = 10
+	DefaultMaxConnectionsPerUser = 5
+)
+
+// Limits configures inbound rate limiting for WebSocket clients.
+// A zero value for any field disables that limit.
+type Limits struct {
+	MessagesPerSecond     float64 // Sustained inbound messages allowed per connection
+	Burst                 int     // Messages a connection may send at once before being limited
+	MaxViolations         int     // Dropped messages tolerated before the connection is closed
+	MaxConnectionsPerUser int     // Concurrent connections allowed per user ID
+}
+
+// DefaultLimits returns the limits used by NewHub
+func DefaultLimits() Limits {
+	return Limits{
+		MessagesPerSecond:     DefaultMessagesPerSecond,
+		Burst:                 DefaultMessageBurst,
+		MaxViolations:         DefaultMaxRateViolations,
+		MaxConnectionsPerUser: DefaultMaxConnectionsPerUser,
+	}
+}
+
+// LimitsFromConfig converts WebSocket configuration into hub limits
+func LimitsFromConfig(cfg config.WebSocketConfig) Limits {
+	return Limits{
+		MessagesPerSecond:     float64(cfg.MessagesPerSecond),
+		Burst:                 cfg.MessageBurst,
+		MaxViolations:         cfg.MaxRateViolations,
+		MaxConnectionsPerUser: cfg.MaxConnectionsPerUser,
+	}
+}
+
+// tokenBucket is a token bucket rate limiter. It is only used from a client's
+// read loop, so it is not safe for concurrent use.
+type tokenBucket struct {
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket, or nil if rate limiting is disabled
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token if one is available. A nil bucket allows everything.
+func (b *tokenBucket) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// SetLimits replaces the inbound limits. Rate limits apply to connections
+// opened after the call; the connection cap applies immediately.
+func (h *Hub) SetLimits(limits Limits) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limits = limits
+}
+
+// Limits returns the current inbound limits
+func (h *Hub) Limits() Limits {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.limits
+}
+
+// AcquireConnection reserves a connection slot for a user. It returns false if
+// the user already has the maximum number of concurrent connections.
+func (h *Hub) AcquireConnection(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if max := h.limits.MaxConnectionsPerUser; max > 0 && h.connections[userID] >= max {
+		return false
+	}
+	h.connections[userID]++
+	return true
+}
+
+// ReleaseConnection frees a connection slot reserved with AcquireConnection
+func (h *Hub) ReleaseConnection(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.connections[userID] <= 1 {
+		delete(h.connections, userID)
+		return
+	}
+	h.connections[userID]--
+}
+
+// GetConnectionCount returns the number of connection slots held by a user
+func (h *Hub) GetConnectionCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.connections[userID]
+}
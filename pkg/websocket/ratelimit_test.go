@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/richxcame/ride-hailing/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 2)
+	now := b.last
+
+	assert.True(t, b.allow(now))
+	assert.True(t, b.allow(now))
+	assert.False(t, b.allow(now), "burst exhausted")
+
+	// 10 tokens per second refills one token every 100ms
+	assert.True(t, b.allow(now.Add(100*time.Millisecond)))
+	assert.False(t, b.allow(now.Add(100*time.Millisecond)))
+
+	// Refill is capped at the burst size
+	later := now.Add(time.Minute)
+	assert.True(t, b.allow(later))
+	assert.True(t, b.allow(later))
+	assert.False(t, b.allow(later))
+}
+
+func TestTokenBucket_Disabled(t *testing.T) {
+	b := newTokenBucket(0, 10)
+	assert.Nil(t, b)
+	for i := 0; i < 100; i++ {
+		assert.True(t, b.allow(time.Now()))
+	}
+}
+
+func TestLimitsFromConfig(t *testing.T) {
+	limits := LimitsFromConfig(config.WebSocketConfig{
+		MessagesPerSecond:     5,
+		MessageBurst:          8,
+		MaxRateViolations:     3,
+		MaxConnectionsPerUser: 2,
+	})
+
+	assert.Equal(t, Limits{MessagesPerSecond: 5, Burst: 8, MaxViolations: 3, MaxConnectionsPerUser: 2}, limits)
+}
+
+func TestHubConnectionCap(t *testing.T) {
+	hub := NewHub()
+	hub.SetLimits(Limits{MaxConnectionsPerUser: 2})
+
+	assert.True(t, hub.AcquireConnection("user-1"))
+	assert.True(t, hub.AcquireConnection("user-1"))
+	assert.False(t, hub.AcquireConnection("user-1"))
+	assert.True(t, hub.AcquireConnection("user-2"), "cap is per user")
+
+	hub.ReleaseConnection("user-1")
+	assert.Equal(t, 1, hub.GetConnectionCount("user-1"))
+	assert.True(t, hub.AcquireConnection("user-1"))
+
+	hub.ReleaseConnection("user-1")
+	hub.ReleaseConnection("user-1")
+	hub.ReleaseConnection("user-1")
+	assert.Equal(t, 0, hub.GetConnectionCount("user-1"))
+}
+
+func TestHubConnectionCap_Unlimited(t *testing.T) {
+	hub := NewHub()
+	hub.SetLimits(Limits{})
+
+	for i := 0; i < 50; i++ {
+		assert.True(t, hub.AcquireConnection("user-1"))
+	}
+}
+
+// startRateLimitedClient runs a client's read loop behind a test server and returns
+// the server-side client and a connection for sending to it
+func startRateLimitedClient(t *testing.T, hub *Hub) (*Client, *websocket.Conn, <-chan struct{}) {
+	t.Helper()
+
+	clients := make(chan *Client, 1)
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient("user-1", conn, hub, "rider", zap.NewNop())
+		clients <- client
+		go func() {
+			client.ReadPump()
+			close(done)
+		}()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return <-clients, conn, done
+}
+
+func TestReadPump_DropsMessagesOverLimit(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetLimits(Limits{MessagesPerSecond: 0.001, Burst: 2, MaxViolations: 5})
+
+	var handled int32
+	hub.RegisterHandler("ping", func(*Client, *Message) { atomic.AddInt32(&handled, 1) })
+
+	client, conn, _ := startRateLimitedClient(t, hub)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "ping"}))
+	}
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case msg := <-client.Send:
+			assert.Equal(t, "rate_limited", msg.Type)
+			assert.Equal(t, "ping", msg.Data["dropped_type"])
+			assert.Equal(t, i, msg.Data["violations"])
+		case <-time.After(time.Second):
+			t.Fatal("expected rate_limited frame")
+		}
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&handled))
+}
+
+func TestReadPump_ClosesAfterRepeatedViolations(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	hub.SetLimits(Limits{MessagesPerSecond: 0.001, Burst: 1, MaxViolations: 2})
+	hub.RegisterHandler("ping", func(*Client, *Message) {})
+
+	_, conn, done := startRateLimitedClient(t, hub)
+
+	// One allowed, two tolerated violations, then the connection is closed
+	for i := 0; i < 4; i++ {
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "ping"}))
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("connection was not closed")
+	}
+}
@@ -0,0 +1,160 @@
+package websocket
+
+import (
+	"hash/fnv"
+	"runtime"
+	"sync"
+)
+
+// shardQueueSize is how many deliveries a shard buffers before the hub waits for it
+const shardQueueSize = 1024
+
+// defaultHubShards is how many shards a hub spreads its connections over unless
+// SetShards says otherwise
+func defaultHubShards() int {
+	return max(1, runtime.GOMAXPROCS(0))
+}
+
+// hubShard owns a subset of the hub's connections and delivers broadcasts to them
+// on its own goroutine, so one broadcast fans out over all shards in parallel. A
+// connection always belongs to the same shard, which delivers in the order the
+// hub dispatched, so each connection receives broadcasts in order.
+type hubShard struct {
+	deliveries chan shardDelivery
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// shardDelivery is a message for some or all of a shard's clients
+type shardDelivery struct {
+	msg     *Message
+	clients []*Client     // Recipients, unless all is set
+	all     bool          // Deliver to every client in the shard
+	done    chan struct{} // Closed once the deliveries queued before it are made; carries no message
+}
+
+func newHubShard() *hubShard {
+	return &hubShard{
+		deliveries: make(chan shardDelivery, shardQueueSize),
+		clients:    make(map[string]*Client),
+	}
+}
+
+// run makes the shard's deliveries until the hub stops
+func (s *hubShard) run() {
+	for delivery := range s.deliveries {
+		switch {
+		case delivery.done != nil:
+			close(delivery.done)
+		case delivery.all:
+			s.mu.RLock()
+			for _, client := range s.clients {
+				client.SendMessage(delivery.msg)
+			}
+			s.mu.RUnlock()
+		default:
+			for _, client := range delivery.clients {
+				client.SendMessage(delivery.msg)
+			}
+		}
+	}
+}
+
+// add makes the shard own a client, replacing an earlier connection with its ID
+func (s *hubShard) add(client *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[client.ID] = client
+}
+
+// remove stops the shard owning a client, unless it was already replaced
+func (s *hubShard) remove(client *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients[client.ID] == client {
+		delete(s.clients, client.ID)
+	}
+}
+
+// SetShards sets how many goroutines the hub's connections are spread over for
+// broadcasting. It must be called before Run; later calls are ignored.
+func (h *Hub) SetShards(n int) {
+	if n < 1 || h.running.Load() {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shards = make([]*hubShard, n)
+	for i := range h.shards {
+		h.shards[i] = newHubShard()
+	}
+	for _, client := range h.clients {
+		h.shardFor(client.ID).add(client)
+	}
+}
+
+// startShards starts each shard's delivery goroutine, returning how many there are
+func (h *Hub) startShards() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, shard := range h.shards {
+		go shard.run()
+	}
+	return len(h.shards)
+}
+
+// shardFor returns the shard that owns a client ID. The caller must hold h.mu.
+func (h *Hub) shardFor(clientID string) *hubShard {
+	if len(h.shards) == 1 {
+		return h.shards[0]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(clientID))
+	return h.shards[hash.Sum32()%uint32(len(h.shards))]
+}
+
+// groupByShard splits a message's recipients into a delivery for each shard that
+// owns some of them. The caller must hold h.mu for reading.
+func (h *Hub) groupByShard(recipients []*Client, msg *Message) []shardDispatch {
+	if len(recipients) == 0 {
+		return nil
+	}
+	if len(recipients) == 1 {
+		return []shardDispatch{{shard: h.shardFor(recipients[0].ID), delivery: shardDelivery{msg: msg, clients: recipients}}}
+	}
+
+	byShard := make(map[*hubShard][]*Client)
+	for _, client := range recipients {
+		shard := h.shardFor(client.ID)
+		byShard[shard] = append(byShard[shard], client)
+	}
+	dispatches := make([]shardDispatch, 0, len(byShard))
+	for shard, clients := range byShard {
+		dispatches = append(dispatches, shardDispatch{shard: shard, delivery: shardDelivery{msg: msg, clients: clients}})
+	}
+	return dispatches
+}
+
+// shardDispatch is a delivery bound for a shard
+type shardDispatch struct {
+	shard    *hubShard
+	delivery shardDelivery
+}
+
+// flushShards waits until the shards have made every delivery dispatched to them
+func (h *Hub) flushShards() {
+	h.mu.RLock()
+	shards := h.shards
+	h.mu.RUnlock()
+
+	done := make([]chan struct{}, len(shards))
+	for i, shard := range shards {
+		done[i] = make(chan struct{})
+		shard.deliveries <- shardDelivery{done: done[i]}
+	}
+	for _, d := range done {
+		<-d
+	}
+}
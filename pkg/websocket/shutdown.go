@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// drainPollInterval is how often Shutdown checks whether client writers have finished
+const drainPollInterval = 10 * time.Millisecond
+
+// Shutdown drains the hub so clients are disconnected cleanly. New connections
+// are refused, broadcasts already queued are delivered, and every client is sent
+// its pending messages followed by a going-away close frame. It returns
+// ctx.Err() if broadcasts or clients are still draining when ctx is done.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.shuttingDown.Store(true)
+
+	done := make(chan struct{})
+	select {
+	case h.drain <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return waitUntil(ctx, func() bool { return h.activeWriters.Load() == 0 })
+}
+
+// drainClients delivers the broadcasts still queued and then closes every
+// client's outbound channel. It runs on the Run goroutine and waits for the
+// shards to deliver, so no broadcast can be half delivered when the clients are
+// closed.
+func (h *Hub) drainClients() {
+	// Run is the only receiver, so nothing else can empty the queue meanwhile
+	for len(h.Broadcast) > 0 {
+		h.broadcastMessage(<-h.Broadcast)
+	}
+	h.flushShards()
+
+	h.mu.Lock()
+	for _, client := range h.clients {
+		client.closeSend()
+	}
+	logger.Info("Draining WebSocket clients", zap.Int("clients", len(h.clients)))
+	h.mu.Unlock()
+}
+
+// StartPumps starts a registered client's read and write pumps. The writer is
+// counted before it starts so Shutdown can't miss it, and onClose, if set, runs
+// once the reader exits.
+func (h *Hub) StartPumps(client *Client, onClose func()) {
+	h.activeWriters.Add(1)
+	go func() {
+		defer h.activeWriters.Add(-1)
+		client.WritePump()
+	}()
+	go func() {
+		if onClose != nil {
+			defer onClose()
+		}
+		client.ReadPump()
+	}()
+}
+
+// ShuttingDown reports whether Shutdown has been called
+func (h *Hub) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// waitUntil polls done until it reports true or ctx is done
+func waitUntil(ctx context.Context, done func() bool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// closeSend closes the client's outbound channel. WritePump still writes the
+// messages already queued before it sends the close frame.
+func (c *Client) closeSend() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.closeOnce.Do(func() {
+		close(c.Send)
+	})
+}
@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// startDrainableClient registers a client whose write loop runs behind a test
+// server, counted the way HandleWebSocket counts it, and returns the dialed
+// connection that receives its messages
+func startDrainableClient(t *testing.T, hub *Hub, id string) *websocket.Conn {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient(id, conn, hub, RoleRider, zap.NewNop())
+		hub.Register <- client
+		hub.activeWriters.Add(1)
+		go func() {
+			defer hub.activeWriters.Add(-1)
+			client.WritePump()
+		}()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestHubShutdown_FlushesQueuedMessagesThenCloses(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	conn := startDrainableClient(t, hub, "rider-1")
+	require.Eventually(t, func() bool { return hub.GetClientCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	hub.SendToUser("rider-1", &Message{Type: "ride_update"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, hub.Shutdown(ctx))
+
+	var msg Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "ride_update", msg.Type)
+
+	_, _, err := conn.ReadMessage()
+	require.Error(t, err)
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "expected going-away close, got %v", err)
+	assert.True(t, hub.ShuttingDown())
+}
+
+func TestHubShutdown_TimesOutWhenWritersDontFinish(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	// A writer that never exits, e.g. stuck on a slow network
+	hub.activeWriters.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, hub.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestHandleWebSocket_RejectsWhileShuttingDown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := NewHub()
+	go hub.Run()
+	require.NoError(t, hub.Shutdown(context.Background()))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws?token=anything", nil)
+
+	HandleWebSocket(c, hub, nil)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -51,6 +51,14 @@ func (f *fakeStorage) Upload(ctx context.Context, key string, reader io.Reader,
 	}, nil
 }
 
+func (f *fakeStorage) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*storage.UploadResult, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return f.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
 func (f *fakeStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	data, ok := f.files[key]
 	if !ok {
@@ -38,6 +38,14 @@ func (m *MockCurrencyRepository) GetLatestExchangeRate(ctx context.Context, from
 	return args.Get(0).(*currency.ExchangeRate), args.Error(1)
 }
 
+func (m *MockCurrencyRepository) GetExchangeRateAt(ctx context.Context, fromCurrency, toCurrency string, at time.Time) (*currency.ExchangeRate, error) {
+	args := m.Called(ctx, fromCurrency, toCurrency, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*currency.ExchangeRate), args.Error(1)
+}
+
 func (m *MockCurrencyRepository) GetExchangeRateByID(ctx context.Context, id uuid.UUID) (*currency.ExchangeRate, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -64,11 +72,24 @@ func (m *MockCurrencyRepository) GetAllExchangeRatesFromBase(ctx context.Context
 	return args.Get(0).([]*currency.ExchangeRate), args.Error(1)
 }
 
+func (m *MockCurrencyRepository) GetLatestExchangeRates(ctx context.Context) ([]*currency.ExchangeRate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*currency.ExchangeRate), args.Error(1)
+}
+
 func (m *MockCurrencyRepository) InvalidateExchangeRates(ctx context.Context, fromCurrency string) error {
 	args := m.Called(ctx, fromCurrency)
 	return args.Error(0)
 }
 
+func (m *MockCurrencyRepository) UnpinExchangeRates(ctx context.Context, fromCurrency, toCurrency string, keep uuid.UUID) error {
+	args := m.Called(ctx, fromCurrency, toCurrency, keep)
+	return args.Error(0)
+}
+
 func (m *MockCurrencyRepository) CreateCurrency(ctx context.Context, curr *currency.Currency) error {
 	args := m.Called(ctx, curr)
 	return args.Error(0)